@@ -0,0 +1,22 @@
+// checked_getters.go - 带类型检查的取值函数
+package leptjson
+
+import "fmt"
+
+// ErrWrongType 表示取值时JSON值的实际类型与期望类型不符
+type ErrWrongType struct {
+	Expected string    // 期望的类型名称，如 "boolean"、"number"、"string"
+	Actual   ValueType // 值的实际类型
+}
+
+func (e *ErrWrongType) Error() string {
+	return fmt.Sprintf("类型错误: 期望 %s, 实际为 %s", e.Expected, e.Actual)
+}
+
+// GetNumberChecked 获取JSON数字值，值不是数字类型时返回 *ErrWrongType
+func GetNumberChecked(v *Value) (float64, error) {
+	if v.Type != NUMBER {
+		return 0, &ErrWrongType{Expected: "number", Actual: v.Type}
+	}
+	return v.N, nil
+}
@@ -413,3 +413,12 @@ func ExampleParse_error() {
 	fmt.Println(err)
 	// Output: 无效的值
 }
+
+// 示例 - 带类型检查的取值
+func ExampleGetNumberChecked() {
+	v := Value{}
+	Parse(&v, "true")
+	_, err := GetNumberChecked(&v)
+	fmt.Println(err)
+	// Output: 类型错误: 期望 number, 实际为 true
+}
@@ -0,0 +1,32 @@
+package leptjson
+
+import "fmt"
+
+// 示例 - 带类型检查的取值
+func ExampleGetNumberChecked() {
+	v := Value{}
+	Parse(&v, "123.456")
+	n, err := GetNumberChecked(&v)
+	fmt.Println(n, err)
+
+	Parse(&v, "true")
+	_, err = GetNumberChecked(&v)
+	fmt.Println(err)
+	// Output:
+	// 123.456 <nil>
+	// 类型错误: 期望 number, 实际为 boolean
+}
+
+func ExampleGetStringChecked() {
+	v := Value{}
+	Parse(&v, `"Hello"`)
+	s, err := GetStringChecked(&v)
+	fmt.Println(s, err)
+
+	Parse(&v, "null")
+	_, err = GetStringChecked(&v)
+	fmt.Println(err)
+	// Output:
+	// Hello <nil>
+	// 类型错误: 期望 string, 实际为 null
+}
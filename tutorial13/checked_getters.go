@@ -0,0 +1,60 @@
+// checked_getters.go - 带类型检查的取值函数
+package leptjson
+
+import "fmt"
+
+// ErrWrongType 表示取值时JSON值的实际类型与期望类型不符
+type ErrWrongType struct {
+	Expected string    // 期望的类型名称，如 "boolean"、"number"、"string"
+	Actual   ValueType // 值的实际类型
+}
+
+func (e *ErrWrongType) Error() string {
+	return fmt.Sprintf("类型错误: 期望 %s, 实际为 %s", e.Expected, valueTypeName(e.Actual))
+}
+
+// GetBooleanChecked 获取JSON布尔值，值不是布尔类型时返回 *ErrWrongType
+//
+// 与 GetBoolean 不同，它可以区分 false 和非布尔值。
+func GetBooleanChecked(v *Value) (bool, error) {
+	if v.Type != TRUE && v.Type != FALSE {
+		return false, &ErrWrongType{Expected: "boolean", Actual: v.Type}
+	}
+	return v.Type == TRUE, nil
+}
+
+// GetNumberChecked 获取JSON数字值，值不是数字类型时返回 *ErrWrongType
+func GetNumberChecked(v *Value) (float64, error) {
+	if v.Type != NUMBER {
+		return 0, &ErrWrongType{Expected: "number", Actual: v.Type}
+	}
+	return v.N, nil
+}
+
+// GetStringChecked 获取JSON字符串值，值不是字符串类型时返回 *ErrWrongType
+func GetStringChecked(v *Value) (string, error) {
+	if v.Type != STRING {
+		return "", &ErrWrongType{Expected: "string", Actual: v.Type}
+	}
+	return v.S, nil
+}
+
+// valueTypeName 返回类型的名称，true 和 false 都视为 boolean
+func valueTypeName(t ValueType) string {
+	switch t {
+	case NULL:
+		return "null"
+	case FALSE, TRUE:
+		return "boolean"
+	case NUMBER:
+		return "number"
+	case STRING:
+		return "string"
+	case ARRAY:
+		return "array"
+	case OBJECT:
+		return "object"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,422 @@
+// anonymize.go - 把生产数据中的字符串和数字替换为逼真的假数据，用于生成可分享的测试数据
+package leptjson
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	mathrand "math/rand"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// AnonymizeOptions 控制 Anonymizer 生成假数据的方式
+type AnonymizeOptions struct {
+	// 生成假数据的密钥。相同的密钥和输入总是得到相同的假数据，可以分多次处理相关的文件；
+	// 为空时使用随机密钥，结果不能通过猜测原值重现
+	Seed string
+
+	// 保持不变的路径，JSONPath（以 $ 开头）或 JSON Pointer（以 / 开头），匹配的值及其子树不被替换
+	KeepPaths []string
+
+	KeepNumbers bool // 数字保持不变，如数量、价格这类不敏感的值
+}
+
+// Anonymizer 把文档中的字符串和数字替换为假数据
+//
+// 对象的键、数组的长度、值的类型、布尔值和 null 保持不变。替换是一致的：
+// 同一个 Anonymizer 处理的所有文档中，相同的输入总是得到相同的假数据，
+// 不同的输入尽量得到不同的假数据，所以ID之间的引用关系仍然成立。
+// 一个字符串中的各个单词也独立替换，"Alice Smith" 和 "Alice" 中的 "Alice" 得到相同的名字。
+//
+// 假数据保留原值的格式：字符数、大小写、数字和标点的位置不变；首字母大写的单词和邮箱的
+// 用户名替换为名字，邮箱的顶级域名、URL 的协议保留；十六进制ID（如 UUID）仍是十六进制；
+// 以 YYYY-MM-DD 开头的日期替换为同一年中有效的日期。整数替换为位数相同的整数，
+// 小数保留整数和小数部分的位数，0 和 0.x 的整数部分仍是 0。
+//
+// Anonymizer 不是并发安全的。
+type Anonymizer struct {
+	key         []byte
+	keepPaths   []string
+	keepNumbers bool
+	strings     map[string]string // 原值 → 假数据
+	usedStrings map[string]bool   // 已经使用的假数据
+	numbers     map[float64]float64
+	usedNumbers map[float64]bool
+}
+
+// 为了避免不同的输入得到相同的假数据最多重新生成的次数，超过后接受重复。
+// 只有很短的值（如一位数字）才可能用完所有组合。
+const anonymizeMaxAttempts = 16
+
+// NewAnonymizer 创建 Anonymizer
+func NewAnonymizer(options AnonymizeOptions) *Anonymizer {
+	key := []byte(options.Seed)
+	if options.Seed == "" {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic(fmt.Sprintf("生成随机密钥失败: %v", err))
+		}
+	}
+	return &Anonymizer{
+		key:         key,
+		keepPaths:   options.KeepPaths,
+		keepNumbers: options.KeepNumbers,
+		strings:     make(map[string]string),
+		usedStrings: make(map[string]bool),
+		numbers:     make(map[float64]float64),
+		usedNumbers: make(map[float64]bool),
+	}
+}
+
+// Anonymize 用一个新的 Anonymizer 原地替换 v 中的字符串和数字
+func Anonymize(v *Value, options AnonymizeOptions) error {
+	return NewAnonymizer(options).Anonymize(v)
+}
+
+// Anonymize 原地替换 v 中的字符串和数字，KeepPaths 中有无效的路径时返回错误且不修改 v
+func (a *Anonymizer) Anonymize(v *Value) error {
+	if v == nil {
+		return fmt.Errorf("JSON 值不能为空")
+	}
+	var keep map[*Value]bool
+	if len(a.keepPaths) > 0 {
+		var err error
+		if keep, err = resolveIgnorePaths(v, a.keepPaths); err != nil {
+			return err
+		}
+	}
+	a.anonymizeValue(v, keep)
+	return nil
+}
+
+// anonymizeValue 递归替换 v 中不在 keep 中的值
+func (a *Anonymizer) anonymizeValue(v *Value, keep map[*Value]bool) {
+	if keep[v] {
+		return
+	}
+	switch v.Type {
+	case STRING:
+		s := GetString(v)
+		if fake := a.String(s); fake != s {
+			SetString(v, fake)
+		}
+	case NUMBER:
+		v.N = a.Number(v.N)
+	case ARRAY:
+		for _, e := range v.A {
+			a.anonymizeValue(e, keep)
+		}
+	case OBJECT:
+		for _, m := range v.O {
+			a.anonymizeValue(m.V, keep)
+		}
+	}
+}
+
+// String 返回 s 的假数据
+func (a *Anonymizer) String(s string) string {
+	if fake, ok := a.strings[s]; ok {
+		return fake
+	}
+	var fake string
+	for attempt := 0; attempt < anonymizeMaxAttempts; attempt++ {
+		fake = a.fakeString(s, attempt)
+		if !a.usedStrings[fake] {
+			break
+		}
+	}
+	a.strings[s] = fake
+	a.usedStrings[fake] = true
+	return fake
+}
+
+// Number 返回 n 的假数据，KeepNumbers 为 true 时返回 n
+func (a *Anonymizer) Number(n float64) float64 {
+	if a.keepNumbers {
+		return n
+	}
+	if fake, ok := a.numbers[n]; ok {
+		return fake
+	}
+	var fake float64
+	for attempt := 0; attempt < anonymizeMaxAttempts; attempt++ {
+		fake = a.fakeNumber(n, attempt)
+		if !a.usedNumbers[fake] {
+			break
+		}
+	}
+	a.numbers[n] = fake
+	a.usedNumbers[fake] = true
+	return fake
+}
+
+// random 返回由密钥、kind、text 和 attempt 确定的随机数生成器
+func (a *Anonymizer) random(kind, text string, attempt int) *mathrand.Rand {
+	mac := hmac.New(sha256.New, a.key)
+	fmt.Fprintf(mac, "%s\x00%s\x00%d", kind, text, attempt)
+	sum := mac.Sum(nil)
+	return mathrand.New(mathrand.NewSource(int64(binary.BigEndian.Uint64(sum))))
+}
+
+// fakeNumber 生成与 n 位数相同的假数字
+func (a *Anonymizer) fakeNumber(n float64, attempt int) float64 {
+	r := a.random("number", strconv.FormatFloat(n, 'g', -1, 64), attempt)
+	text := strconv.FormatFloat(n, 'f', -1, 64)
+	digits := []byte(text)
+	start := 0
+	if text[0] == '-' {
+		start = 1
+	}
+	end := strings.IndexByte(text, '.') // 整数部分的结尾
+	if end < 0 {
+		end = len(text)
+	}
+	for i := start; i < len(digits); i++ {
+		if !isDigit(digits[i]) {
+			continue
+		}
+		// 整数部分的首位和小数部分的末位不为零，否则位数会改变；整数部分为零时保持为零
+		if i == start && digits[i] == '0' {
+			continue
+		}
+		if (i == start && end-start > 1) || (end < len(text) && i == len(text)-1) {
+			digits[i] = '1' + byte(r.Intn(9))
+		} else {
+			digits[i] = '0' + byte(r.Intn(10))
+		}
+	}
+	fake, err := strconv.ParseFloat(string(digits), 64)
+	if err != nil {
+		return n
+	}
+	return fake
+}
+
+// fakeString 按 s 的格式生成假数据
+func (a *Anonymizer) fakeString(s string, attempt int) string {
+	if isEmailLike(s) {
+		at := strings.IndexByte(s, '@')
+		domain := s[at+1:]
+		dot := strings.LastIndexByte(domain, '.')
+		return a.fakeText(s[:at], true, attempt) + "@" + a.fakeText(domain[:dot], false, attempt) + domain[dot:]
+	}
+	if isHexID(s) {
+		return a.fakeHex(s, attempt)
+	}
+	if isDateLike(s) {
+		return a.fakeDate(s, attempt) + a.fakeText(s[10:], false, attempt)
+	}
+	if i := strings.Index(s, "://"); i > 0 && isASCIIWord(s[:i]) {
+		return s[:i+3] + a.fakeText(s[i+3:], false, attempt)
+	}
+	return a.fakeText(s, false, attempt)
+}
+
+// isEmailLike 判断 s 是否形如 local@domain.tld
+func isEmailLike(s string) bool {
+	at := strings.IndexByte(s, '@')
+	if at <= 0 || strings.Count(s, "@") != 1 || strings.ContainsAny(s, " \t\r\n") {
+		return false
+	}
+	dot := strings.LastIndexByte(s, '.')
+	return dot > at+1 && dot < len(s)-1
+}
+
+// isHexID 判断 s 是否是十六进制ID，如 UUID 或哈希值：至少8个字符，
+// 只有十六进制数字和连字符，同时包含数字和字母
+func isHexID(s string) bool {
+	if len(s) < 8 {
+		return false
+	}
+	digits, letters := false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case isDigit(c):
+			digits = true
+		case (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F'):
+			letters = true
+		case c != '-':
+			return false
+		}
+	}
+	return digits && letters
+}
+
+// isDateLike 判断 s 是否以 YYYY-MM-DD 开头
+func isDateLike(s string) bool {
+	if len(s) < 10 || s[4] != '-' || s[7] != '-' {
+		return false
+	}
+	for _, i := range []int{0, 1, 2, 3, 5, 6, 8, 9} {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return len(s) == 10 || !isDigit(s[10])
+}
+
+// isASCIIWord 判断 s 是否只包含 ASCII 字母
+func isASCIIWord(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !((s[i] >= 'a' && s[i] <= 'z') || (s[i] >= 'A' && s[i] <= 'Z')) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// fakeHex 把十六进制数字替换为随机的十六进制数字，数字和字母、大小写保持不变
+func (a *Anonymizer) fakeHex(s string, attempt int) string {
+	r := a.random("hex", s, attempt)
+	out := []byte(s)
+	for i, c := range out {
+		switch {
+		case isDigit(c):
+			out[i] = '0' + byte(r.Intn(10))
+		case c >= 'a' && c <= 'f':
+			out[i] = 'a' + byte(r.Intn(6))
+		case c >= 'A' && c <= 'F':
+			out[i] = 'A' + byte(r.Intn(6))
+		}
+	}
+	return string(out)
+}
+
+// fakeDate 返回与 s 开头的日期同一年的随机有效日期
+func (a *Anonymizer) fakeDate(s string, attempt int) string {
+	r := a.random("date", s[:10], attempt)
+	return fmt.Sprintf("%s-%02d-%02d", s[:4], 1+r.Intn(12), 1+r.Intn(28))
+}
+
+// fakeText 逐段替换 s 中的字母和数字，其余字符保持不变
+//
+// 每段字母或数字的假数据只由这一段决定。names 为 true 时所有单词都替换为名字，
+// 否则只有首字母大写的单词替换为名字，其余替换为可以读出的假单词。
+func (a *Anonymizer) fakeText(s string, names bool, attempt int) string {
+	var out strings.Builder
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		j := i + size
+		switch {
+		case unicode.IsLetter(r):
+			for j < len(s) {
+				next, n := utf8.DecodeRuneInString(s[j:])
+				if !unicode.IsLetter(next) {
+					break
+				}
+				j += n
+			}
+			out.WriteString(a.fakeWord(s[i:j], names, attempt))
+		case isDigit(s[i]):
+			for j < len(s) && isDigit(s[j]) {
+				j++
+			}
+			out.WriteString(a.fakeDigits(s[i:j], attempt))
+		default:
+			out.WriteString(s[i:j])
+		}
+		i = j
+	}
+	return out.String()
+}
+
+// fakeDigits 生成位数相同的随机数字串，原来首位不为零时假数据的首位也不为零
+func (a *Anonymizer) fakeDigits(digits string, attempt int) string {
+	r := a.random("digits", digits, attempt)
+	out := make([]byte, len(digits))
+	for i := range out {
+		if i == 0 && digits[0] != '0' && len(digits) > 1 {
+			out[i] = '1' + byte(r.Intn(9))
+		} else {
+			out[i] = '0' + byte(r.Intn(10))
+		}
+	}
+	return string(out)
+}
+
+// fakeWord 生成字符数和大小写与 word 相同的假单词
+func (a *Anonymizer) fakeWord(word string, names bool, attempt int) string {
+	r := a.random("word", word, attempt)
+	runes := []rune(word)
+	if names || isTitleWord(runes) {
+		if candidates := fakeNamesByLength[len(runes)]; len(candidates) > 0 {
+			return applyCase(candidates[r.Intn(len(candidates))], runes)
+		}
+	}
+
+	const consonants, vowels = "bcdfghjklmnprstvwz", "aeiou"
+	vowel := r.Intn(2) == 0
+	out := make([]rune, len(runes))
+	for i, c := range runes {
+		if unicode.Is(unicode.Han, c) {
+			out[i] = rune(0x4E00 + r.Intn(0x9FA5-0x4E00+1))
+			continue
+		}
+		letter := rune(consonants[r.Intn(len(consonants))])
+		if vowel {
+			letter = rune(vowels[r.Intn(len(vowels))])
+		}
+		vowel = !vowel
+		if unicode.IsUpper(c) {
+			letter = unicode.ToUpper(letter)
+		}
+		out[i] = letter
+	}
+	return string(out)
+}
+
+// isTitleWord 判断单词是否由 ASCII 字母组成且只有首字母大写，如 "Alice"
+func isTitleWord(runes []rune) bool {
+	if len(runes) < 2 || runes[0] < 'A' || runes[0] > 'Z' {
+		return false
+	}
+	for _, c := range runes[1:] {
+		if c < 'a' || c > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// applyCase 按 pattern 中每个字符的大小写转换 name
+func applyCase(name string, pattern []rune) string {
+	out := []rune(strings.ToLower(name))
+	for i := range out {
+		if i < len(pattern) && unicode.IsUpper(pattern[i]) {
+			out[i] = unicode.ToUpper(out[i])
+		}
+	}
+	return string(out)
+}
+
+// fakeNames 是替换名字使用的常见英文名和姓
+var fakeNames = []string{
+	"Amy", "Ann", "Bob", "Eve", "Ian", "Joe", "Kim", "Lee", "Max", "Roy",
+	"Anna", "Carl", "Chen", "Cole", "Dora", "Emma", "Finn", "Hall", "Hugo", "Iris",
+	"Jack", "Lily", "Mark", "Nina", "Omar", "Ross", "Ruth", "Sean", "Tara", "Wong",
+	"Alice", "Baker", "Brian", "Brown", "Clara", "Clark", "David", "Davis", "Ellen", "Evans",
+	"Frank", "Grace", "Henry", "Irene", "James", "Jones", "Karen", "Laura", "Moore", "Oscar",
+	"Peter", "Sarah", "Smith", "Young",
+	"Alexis", "Bianca", "Daniel", "Edward", "Foster", "Gordon", "Hannah", "Harris", "Isabel", "Martin",
+	"Miller", "Nathan", "Olivia", "Parker", "Robert", "Sophie", "Thomas", "Turner", "Walker", "Wilson",
+	"Abigail", "Bernard", "Charles", "Collins", "Deborah", "Eleanor", "Gabriel", "Jackson", "Jessica", "Johnson",
+	"Michael", "Natalie", "Patrick", "Raymond", "Roberts", "Stephen",
+	"Anderson", "Benjamin", "Campbell", "Caroline", "Florence", "Harrison", "Jonathan", "Margaret", "Mitchell", "Nicholas",
+	"Patricia", "Robinson", "Thompson", "Victoria",
+	"Alexander", "Catherine", "Christina", "Elizabeth", "Frederick", "Gallagher", "Henderson", "Josephine", "Katherine", "Patterson",
+}
+
+// fakeNamesByLength 按字符数分组的 fakeNames
+var fakeNamesByLength = func() map[int][]string {
+	byLength := make(map[int][]string)
+	for _, name := range fakeNames {
+		byLength[len(name)] = append(byLength[len(name)], name)
+	}
+	return byLength
+}()
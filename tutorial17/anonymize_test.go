@@ -0,0 +1,152 @@
+package leptjson
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestAnonymizePreservesShape(t *testing.T) {
+	doc := mustParse(t, `{
+		"id": "3f2a9c1e-8b4d-4e6f-a1b2-c3d4e5f60718",
+		"name": "Alice Smith",
+		"email": "alice.smith@corp.example.com",
+		"phone": "+1 (555) 010-2345",
+		"city": "上海",
+		"born": "1990-07-14",
+		"site": "https://alice.dev/about",
+		"age": 34,
+		"balance": -1234.56,
+		"active": true,
+		"notes": null,
+		"tags": ["vip", "Beta"]
+	}`)
+	original := &Value{}
+	Copy(original, doc)
+	if err := Anonymize(doc, AnonymizeOptions{Seed: "test"}); err != nil {
+		t.Fatalf("Anonymize 失败: %v", err)
+	}
+
+	for i, m := range original.O {
+		got := doc.O[i]
+		if got.K != m.K || got.V.Type != m.V.Type {
+			t.Errorf("成员 %s 的键或类型改变: %s %v", m.K, got.K, got.V.Type)
+			continue
+		}
+		switch m.V.Type {
+		case STRING:
+			before, after := GetString(m.V), GetString(got.V)
+			if before == after {
+				t.Errorf("%s 没有被替换: %q", m.K, after)
+			}
+			if utf8.RuneCountInString(before) != utf8.RuneCountInString(after) {
+				t.Errorf("%s 的长度改变: %q → %q", m.K, before, after)
+			}
+		case NUMBER:
+			if m.V.N == got.V.N {
+				t.Errorf("%s 没有被替换: %v", m.K, got.V.N)
+			}
+		}
+	}
+
+	get := func(key string) string { return GetString(GetObjectValueByKey(doc, key)) }
+	if id := get("id"); !isHexID(id) || strings.Count(id, "-") != 4 || id[8] != '-' {
+		t.Errorf("id = %q, 期望仍是 UUID 格式", id)
+	}
+	if email := get("email"); !isEmailLike(email) || !strings.HasSuffix(email, ".com") || strings.Count(email, ".") != 3 {
+		t.Errorf("email = %q, 期望仍是邮箱格式并保留顶级域名", email)
+	}
+	if phone := get("phone"); phone[0] != '+' || phone[2:4] != " (" || phone[7:9] != ") " || phone[12] != '-' {
+		t.Errorf("phone = %q, 期望标点位置不变", phone)
+	}
+	if born := get("born"); !strings.HasPrefix(born, "1990-") {
+		t.Errorf("born = %q, 期望保留年份", born)
+	} else if _, err := time.Parse("2006-01-02", born); err != nil {
+		t.Errorf("born = %q 不是有效日期: %v", born, err)
+	}
+	if site := get("site"); !strings.HasPrefix(site, "https://") {
+		t.Errorf("site = %q, 期望保留协议", site)
+	}
+	if name := get("name"); name[0] < 'A' || name[0] > 'Z' || name[6] < 'A' || name[6] > 'Z' {
+		t.Errorf("name = %q, 期望仍是首字母大写的名字", name)
+	}
+	age := GetObjectValueByKey(doc, "age").N
+	if age < 10 || age > 99 || age != float64(int(age)) {
+		t.Errorf("age = %v, 期望两位整数", age)
+	}
+	if balance := strconv.FormatFloat(GetObjectValueByKey(doc, "balance").N, 'f', -1, 64); len(balance) != len("-1234.56") || balance[0] != '-' {
+		t.Errorf("balance = %s, 期望保留符号和位数", balance)
+	}
+}
+
+func TestAnonymizeConsistent(t *testing.T) {
+	a := NewAnonymizer(AnonymizeOptions{Seed: "s"})
+	users := mustParse(t, `[{"id": "u-1001", "name": "Alice"}, {"id": "u-1002", "name": "Bob"}]`)
+	orders := mustParse(t, `[{"user": "u-1002", "by": "Alice Jones"}, {"user": "u-1001", "by": "Alice"}]`)
+	if err := a.Anonymize(users); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Anonymize(orders); err != nil {
+		t.Fatal(err)
+	}
+	id1, id2 := GetString(users.A[0].O[0].V), GetString(users.A[1].O[0].V)
+	if id1 == id2 {
+		t.Errorf("不同的ID得到相同的假数据 %q", id1)
+	}
+	if GetString(orders.A[0].O[0].V) != id2 || GetString(orders.A[1].O[0].V) != id1 {
+		t.Errorf("引用关系没有保留: %q %q", GetString(orders.A[0].O[0].V), GetString(orders.A[1].O[0].V))
+	}
+	alice := GetString(users.A[0].O[1].V)
+	if GetString(orders.A[1].O[1].V) != alice || !strings.HasPrefix(GetString(orders.A[0].O[1].V), alice+" ") {
+		t.Errorf("同一个名字在不同字符串中得到不同的假数据: %q %q", alice, GetString(orders.A[0].O[1].V))
+	}
+
+	// 相同的种子得到相同的结果，不同的种子不同
+	again := mustParse(t, `{"name": "Alice", "n": 42}`)
+	other := mustParse(t, `{"name": "Alice", "n": 42}`)
+	Anonymize(again, AnonymizeOptions{Seed: "s"})
+	Anonymize(other, AnonymizeOptions{Seed: "s2"})
+	if GetString(again.O[0].V) != alice {
+		t.Errorf("相同的种子得到 %q, 期望 %q", GetString(again.O[0].V), alice)
+	}
+	if Equal(again, other) {
+		t.Errorf("不同的种子不应该得到相同的结果")
+	}
+}
+
+func TestAnonymizeDistinctNumbers(t *testing.T) {
+	a := NewAnonymizer(AnonymizeOptions{Seed: "n"})
+	seen := make(map[float64]float64)
+	for i := 100; i < 600; i++ {
+		fake := a.Number(float64(i))
+		if fake < 100 || fake > 999 || fake != float64(int(fake)) {
+			t.Fatalf("Number(%d) = %v, 期望三位整数", i, fake)
+		}
+		if prev, ok := seen[fake]; ok {
+			t.Fatalf("%v 和 %d 得到相同的假数据 %v", prev, i, fake)
+		}
+		seen[fake] = float64(i)
+	}
+	if a.Number(0.5) == 0.5 || strconv.FormatFloat(a.Number(0.5), 'f', -1, 64)[:2] != "0." {
+		t.Errorf("Number(0.5) = %v", a.Number(0.5))
+	}
+}
+
+func TestAnonymizeKeep(t *testing.T) {
+	doc := mustParse(t, `{"type": "user", "meta": {"version": 3, "source": "api"}, "name": "Alice", "count": 7}`)
+	err := Anonymize(doc, AnonymizeOptions{KeepPaths: []string{"/type", "$.meta"}, KeepNumbers: true})
+	if err != nil {
+		t.Fatalf("Anonymize 失败: %v", err)
+	}
+	expected := `{"type":"user","meta":{"version":3,"source":"api"},"count":7}`
+	RemoveObjectValueByKey(doc, "name")
+	if s, _ := Stringify(doc); s != expected {
+		t.Errorf("Anonymize = %s, 期望 %s", s, expected)
+	}
+
+	if err := Anonymize(doc, AnonymizeOptions{KeepPaths: []string{"bad"}}); err == nil {
+		t.Errorf("无效的路径应该返回错误")
+	}
+}
@@ -0,0 +1,277 @@
+// array_diff.go - 标量数组的最长公共子序列差异和移动检测
+package leptjson
+
+import (
+	"fmt"
+)
+
+// arrayEdit 是把一个标量数组变为另一个的一步编辑
+type arrayEdit struct {
+	kind     DiffKind // DIFF_REMOVED、DIFF_ADDED、DIFF_MOVED 或 DIFF_VALUE_CHANGED
+	from, to int      // 元素在第一个、第二个数组中的下标，不存在的一边为 -1
+}
+
+// scalarKey 返回标量的比较键，数组和对象返回 false
+func scalarKey(v *Value, form NormalizationForm) (string, bool) {
+	if v == nil || v.Type == ARRAY || v.Type == OBJECT {
+		return "", false
+	}
+	if v.Type == STRING && form != NORMALIZE_NONE {
+		v = &Value{Type: STRING, S: NormalizeString(GetString(v), form)}
+	}
+	text, err := Stringify(v)
+	return text, err == STRINGIFY_OK
+}
+
+// scalarArrayEdits 按最长公共子序列计算把标量数组 a 变为 b 的编辑，有非标量元素时返回 false
+//
+// 只在一边出现的相同值配对为移动；同一段修改中剩下的删除和新增依次配对为值的修改，
+// 其余的是删除和新增。编辑按它们在 a、b 中的位置排列。
+func scalarArrayEdits(a, b []*Value, form NormalizationForm) ([]arrayEdit, bool) {
+	keysA := make([]string, len(a))
+	for i, v := range a {
+		key, ok := scalarKey(v, form)
+		if !ok {
+			return nil, false
+		}
+		keysA[i] = key
+	}
+	keysB := make([]string, len(b))
+	for j, v := range b {
+		key, ok := scalarKey(v, form)
+		if !ok {
+			return nil, false
+		}
+		keysB[j] = key
+	}
+
+	// 把逐行差异的结果换算成下标，每段连续的修改记为一个 hunk
+	type hunk struct{ removed, added []int }
+	var hunks []hunk
+	var current hunk
+	i, j := 0, 0
+	for _, op := range diffLines(keysA, keysB) {
+		switch op.kind {
+		case ' ':
+			if len(current.removed)+len(current.added) > 0 {
+				hunks = append(hunks, current)
+				current = hunk{}
+			}
+			i++
+			j++
+		case '-':
+			current.removed = append(current.removed, i)
+			i++
+		case '+':
+			current.added = append(current.added, j)
+			j++
+		}
+	}
+	if len(current.removed)+len(current.added) > 0 {
+		hunks = append(hunks, current)
+	}
+
+	// 相同的值先配对为移动，按出现顺序一一对应
+	pending := make(map[string][]int)
+	for _, h := range hunks {
+		for _, j := range h.added {
+			pending[keysB[j]] = append(pending[keysB[j]], j)
+		}
+	}
+	movedTo := make(map[int]int)
+	movedFrom := make(map[int]bool)
+	for _, h := range hunks {
+		for _, i := range h.removed {
+			if targets := pending[keysA[i]]; len(targets) > 0 {
+				movedTo[i] = targets[0]
+				movedFrom[targets[0]] = true
+				pending[keysA[i]] = targets[1:]
+			}
+		}
+	}
+
+	var edits []arrayEdit
+	for _, h := range hunks {
+		var removed, added []int
+		for _, i := range h.removed {
+			if j, ok := movedTo[i]; ok {
+				edits = append(edits, arrayEdit{DIFF_MOVED, i, j})
+			} else {
+				removed = append(removed, i)
+			}
+		}
+		for _, j := range h.added {
+			if !movedFrom[j] {
+				added = append(added, j)
+			}
+		}
+		for len(removed) > 0 && len(added) > 0 {
+			edits = append(edits, arrayEdit{DIFF_VALUE_CHANGED, removed[0], added[0]})
+			removed, added = removed[1:], added[1:]
+		}
+		for _, i := range removed {
+			edits = append(edits, arrayEdit{DIFF_REMOVED, i, -1})
+		}
+		for _, j := range added {
+			edits = append(edits, arrayEdit{DIFF_ADDED, -1, j})
+		}
+	}
+	return edits, true
+}
+
+// compareScalarArrays 按最长公共子序列比较两个标量数组，有非标量元素时返回 false
+func compareScalarArrays(v1, v2 *Value, path, rightPath string, ctx *compareContext, differences *[]Difference) bool {
+	edits, ok := scalarArrayEdits(v1.A, v2.A, ctx.form)
+	if !ok {
+		return false
+	}
+	for _, e := range edits {
+		var left, right *Value
+		diff := Difference{Kind: e.kind}
+		if e.from >= 0 {
+			left = v1.A[e.from]
+			diff.Path = fmt.Sprintf("%s[%d]", path, e.from)
+		}
+		if e.to >= 0 {
+			right = v2.A[e.to]
+			if e.from < 0 {
+				diff.Path = fmt.Sprintf("%s[%d]", rightPath, e.to)
+			} else if to := fmt.Sprintf("%s[%d]", rightPath, e.to); to != diff.Path {
+				diff.RightPath = to
+			}
+		}
+		if ctx.ignoreLeft[left] || ctx.ignoreRight[right] {
+			continue
+		}
+		diff.Left, diff.Right = left, right
+		switch e.kind {
+		case DIFF_REMOVED:
+			diff.Message = fmt.Sprintf("路径 %s: 第一个JSON有元素 %s，但第二个没有", path, previewValue(left))
+		case DIFF_ADDED:
+			diff.Message = fmt.Sprintf("路径 %s: 第二个JSON有元素 %s，但第一个没有", rightPath, previewValue(right))
+		case DIFF_MOVED:
+			diff.Message = fmt.Sprintf("路径 %s: 元素 %s 从下标 %d 移动到 %d", path, previewValue(left), e.from, e.to)
+		default:
+			diff.Message = fmt.Sprintf("路径 %s: 元素不同 (%s vs %s)", diff.Path, previewValue(left), previewValue(right))
+		}
+		*differences = append(*differences, diff)
+	}
+	return true
+}
+
+// diffScalarArray 为标量数组生成最少的 add、remove、replace 和 move 操作，有非标量元素时返回 false
+//
+// 操作分四步：从后向前删除、把移动的元素依次放到它在目标中的前一个元素之后、
+// 按目标顺序插入新元素、最后替换修改的值。每一步都在前一步的结果上计算下标。
+func diffScalarArray(source, target *Value, path string, patch *JSONPatch) bool {
+	edits, ok := scalarArrayEdits(source.A, target.A, NORMALIZE_NONE)
+	if !ok {
+		return false
+	}
+	itemPath := func(index int) string { return fmt.Sprintf("%s/%d", path, index) }
+	value := func(v *Value) *Value {
+		copied := &Value{}
+		Copy(copied, v)
+		return copied
+	}
+
+	removed := make(map[int]bool)
+	var moves, adds, changes []arrayEdit
+	for _, e := range edits {
+		switch e.kind {
+		case DIFF_REMOVED:
+			removed[e.from] = true
+		case DIFF_ADDED:
+			adds = append(adds, e)
+		case DIFF_MOVED:
+			moves = append(moves, e)
+		case DIFF_VALUE_CHANGED:
+			changes = append(changes, e)
+		}
+	}
+	// origin[j] 是目标中第 j 个元素对应的源元素下标，新增的为 -1；未参与编辑的元素按顺序一一对应
+	origin := make([]int, len(target.A))
+	edited := make(map[int]bool)
+	editedTarget := make(map[int]bool)
+	for _, e := range edits {
+		if e.from >= 0 {
+			edited[e.from] = true
+		}
+		if e.to >= 0 {
+			editedTarget[e.to] = true
+		}
+	}
+	i := 0
+	for j := range origin {
+		origin[j] = -1
+		if editedTarget[j] {
+			continue
+		}
+		for edited[i] {
+			i++
+		}
+		origin[j] = i
+		i++
+	}
+	for _, e := range edits {
+		if e.kind == DIFF_MOVED || e.kind == DIFF_VALUE_CHANGED {
+			origin[e.to] = e.from
+		}
+	}
+
+	// 第一步：删除
+	current := make([]int, 0, len(source.A))
+	for i := range source.A {
+		if !removed[i] {
+			current = append(current, i)
+		}
+	}
+	for i := len(source.A) - 1; i >= 0; i-- {
+		if removed[i] {
+			patch.Operations = append(patch.Operations, PatchOperation{Op: "remove", Path: itemPath(i)})
+		}
+	}
+
+	// 第二步：按目标顺序移动，每个元素放在目标中前一个已存在的元素之后
+	isMoved := make(map[int]bool, len(moves))
+	for _, e := range moves {
+		isMoved[e.to] = true
+	}
+	indexOf := func(id int) int {
+		for k, v := range current {
+			if v == id {
+				return k
+			}
+		}
+		return -1
+	}
+	for j := range target.A {
+		if !isMoved[j] {
+			continue
+		}
+		from := indexOf(origin[j])
+		current = append(current[:from], current[from+1:]...)
+		to := 0
+		for p := j - 1; p >= 0; p-- {
+			if origin[p] >= 0 {
+				to = indexOf(origin[p]) + 1
+				break
+			}
+		}
+		current = append(current[:to], append([]int{origin[j]}, current[to:]...)...)
+		if from != to {
+			patch.Operations = append(patch.Operations, PatchOperation{Op: "move", From: itemPath(from), Path: itemPath(to)})
+		}
+	}
+
+	// 第三步：插入，此时目标中排在前面的元素都已就位
+	for _, e := range adds {
+		patch.Operations = append(patch.Operations, PatchOperation{Op: "add", Path: itemPath(e.to), Value: value(target.A[e.to])})
+	}
+
+	// 第四步：替换
+	for _, e := range changes {
+		patch.Operations = append(patch.Operations, PatchOperation{Op: "replace", Path: itemPath(e.to), Value: value(target.A[e.to])})
+	}
+	return true
+}
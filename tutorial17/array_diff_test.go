@@ -0,0 +1,98 @@
+package leptjson
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScalarArrayPatch(t *testing.T) {
+	tests := []struct {
+		source, target string
+		ops            []string
+	}{
+		{`[1,2,3,4]`, `[1,9,2,3,4]`, []string{"add /1"}},
+		{`[1,2,3,4]`, `[1,3,4]`, []string{"remove /1"}},
+		{`["a","b","c"]`, `["c","a","b"]`, []string{"move /2 /0"}},
+		{`["a","b","c","d"]`, `["b","c","d","a"]`, []string{"move /0 /3"}},
+		{`[1,2,3]`, `[1,5,3]`, []string{"replace /1"}},
+		{`[1,2,3]`, `[3,2,4]`, []string{"remove /0", "move /0 /1", "add /2"}},
+		{`[]`, `[1,2]`, []string{"add /0", "add /1"}},
+		{`[1,[2]]`, `[[2]]`, nil}, // 非标量数组按下标比较
+	}
+	for _, tt := range tests {
+		source, target := &Value{}, &Value{}
+		if err := Parse(source, tt.source); err != PARSE_OK {
+			t.Fatal(err)
+		}
+		if err := Parse(target, tt.target); err != PARSE_OK {
+			t.Fatal(err)
+		}
+		patch, _ := CreatePatch(source, target)
+		if tt.ops != nil {
+			var ops []string
+			for _, op := range patch.Operations {
+				if op.Op == "move" {
+					ops = append(ops, op.Op+" "+op.From+" "+op.Path)
+				} else {
+					ops = append(ops, op.Op+" "+op.Path)
+				}
+			}
+			if !reflect.DeepEqual(ops, tt.ops) {
+				t.Errorf("%s → %s: 操作 = %q, 期望 %q", tt.source, tt.target, ops, tt.ops)
+			}
+		}
+		if err := patch.Apply(source); err != nil || !Equal(source, target) {
+			got, _ := Stringify(source)
+			t.Errorf("%s → %s: 应用补丁得到 %s, %v", tt.source, tt.target, got, err)
+		}
+	}
+}
+
+func TestScalarArrayPatchRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	randomArray := func() string {
+		items := make([]string, rng.Intn(8))
+		for i := range items {
+			items[i] = fmt.Sprint(rng.Intn(5))
+		}
+		return "[" + strings.Join(items, ",") + "]"
+	}
+	for n := 0; n < 2000; n++ {
+		a, b := randomArray(), randomArray()
+		source, target := &Value{}, &Value{}
+		Parse(source, a)
+		Parse(target, b)
+		patch, _ := CreatePatch(source, target)
+		if len(patch.Operations) > len(source.A)+len(target.A) {
+			t.Errorf("%s → %s: %d 个操作", a, b, len(patch.Operations))
+		}
+		if err := patch.Apply(source); err != nil || !Equal(source, target) {
+			got, _ := Stringify(source)
+			t.Fatalf("%s → %s: 应用补丁得到 %s, %v", a, b, got, err)
+		}
+	}
+}
+
+func TestCompareLCSArrays(t *testing.T) {
+	left, right := &Value{}, &Value{}
+	Parse(left, `{"tags":["a","b","c","d"]}`)
+	Parse(right, `{"tags":["x","a","c","d","b"]}`)
+	diffs, err := CompareValuesWithOptions(left, right, CompareOptions{LCSArrays: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, d := range diffs {
+		got = append(got, fmt.Sprintf("%s %s %s", d.Kind, d.Path, d.RightPath))
+	}
+	want := []string{"added $.tags[0] ", "moved $.tags[1] $.tags[4]"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LCS 比较 = %q, 期望 %q", got, want)
+	}
+	if diffs := CompareValues(left, right); len(diffs) != 3 {
+		t.Errorf("默认按下标比较, 得到 %d 处差异", len(diffs))
+	}
+}
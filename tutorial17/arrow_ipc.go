@@ -0,0 +1,367 @@
+// arrow_ipc.go - 把列式数据写为 Apache Arrow IPC 流格式和文件格式
+package leptjson
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Arrow 格式中使用的常量，见 Arrow 仓库的 format/Message.fbs 和 format/Schema.fbs
+const (
+	arrowMetadataV5      = 4 // MetadataVersion.V5
+	arrowHeaderSchema    = 1 // MessageHeader.Schema
+	arrowHeaderBatch     = 3 // MessageHeader.RecordBatch
+	arrowTypeInt         = 2 // Type.Int
+	arrowTypeFloat       = 3 // Type.FloatingPoint
+	arrowTypeUtf8        = 5 // Type.Utf8
+	arrowTypeBool        = 6 // Type.Bool
+	arrowPrecisionDouble = 2 // Precision.DOUBLE
+	arrowContinuation    = 0xFFFFFFFF
+)
+
+// arrowMagic 是文件格式开头和结尾的标记
+const arrowMagic = "ARROW1"
+
+// fbTable 是待编码的 FlatBuffers 表，元素按字段编号排列，nil 表示缺省的字段。
+// 元素可以是 fbScalar、string、fbTable、[]fbTable（表的向量）或 fbStructs（结构体的向量）。
+type fbTable []interface{}
+
+// fbScalar 是表中的标量字段
+type fbScalar struct {
+	size int
+	bits uint64
+}
+
+func fbBool(b bool) fbScalar {
+	if b {
+		return fbScalar{1, 1}
+	}
+	return fbScalar{1, 0}
+}
+
+func fbUint8(v uint8) fbScalar    { return fbScalar{1, uint64(v)} }
+func fbInt16(v int16) fbScalar    { return fbScalar{2, uint64(uint16(v))} }
+func fbInt32(v int32) fbScalar    { return fbScalar{4, uint64(uint32(v))} }
+func fbInt64(v int64) fbScalar    { return fbScalar{8, uint64(v)} }
+func fbStructsOf(n int) fbStructs { return fbStructs{count: n} }
+
+// fbStructs 是结构体的向量，data 是按小端序排好的所有元素，元素按8字节对齐
+type fbStructs struct {
+	count int
+	data  []byte
+}
+
+// add 追加一个由若干个 int64 组成的结构体
+func (s *fbStructs) add(fields ...int64) {
+	for _, f := range fields {
+		s.data = appendUint64LE(s.data, uint64(f))
+	}
+	s.count++
+}
+
+// fbBuilder 从前往后编码 FlatBuffers：先写父对象，再写它引用的子对象，
+// 使所有 uoffset 都指向更高的地址
+type fbBuilder struct {
+	buf []byte
+}
+
+// fbFinish 编码以 root 为根的表，返回长度为8的倍数的缓冲区
+func fbFinish(root fbTable) []byte {
+	b := &fbBuilder{}
+	b.reserve(4)
+	pos := b.table(root)
+	binary.LittleEndian.PutUint32(b.buf, uint32(pos))
+	b.pad(8)
+	return b.buf
+}
+
+func (b *fbBuilder) pad(align int) {
+	for len(b.buf)%align != 0 {
+		b.buf = append(b.buf, 0)
+	}
+}
+
+func (b *fbBuilder) reserve(n int) int {
+	pos := len(b.buf)
+	b.buf = append(b.buf, make([]byte, n)...)
+	return pos
+}
+
+// table 先写虚表，再写表本身，最后写表引用的对象，返回表的位置
+func (b *fbBuilder) table(t fbTable) int {
+	// 表以4字节的虚表偏移开始，之后的字段按各自的大小对齐
+	offsets := make([]int, len(t))
+	size := 4
+	for i, f := range t {
+		n := 0
+		switch f := f.(type) {
+		case nil:
+			continue
+		case fbScalar:
+			n = f.size
+		default:
+			n = 4
+		}
+		size = (size + n - 1) / n * n
+		offsets[i] = size
+		size += n
+	}
+
+	b.pad(2)
+	vtable := b.reserve(4 + 2*len(t))
+	binary.LittleEndian.PutUint16(b.buf[vtable:], uint16(4+2*len(t)))
+	binary.LittleEndian.PutUint16(b.buf[vtable+2:], uint16(size))
+	for i, off := range offsets {
+		binary.LittleEndian.PutUint16(b.buf[vtable+4+2*i:], uint16(off))
+	}
+
+	b.pad(8)
+	start := b.reserve(size)
+	binary.LittleEndian.PutUint32(b.buf[start:], uint32(int32(start-vtable)))
+	for i, f := range t {
+		if s, ok := f.(fbScalar); ok {
+			for j := 0; j < s.size; j++ {
+				b.buf[start+offsets[i]+j] = byte(s.bits >> (8 * j))
+			}
+		}
+	}
+	for i, f := range t {
+		switch f.(type) {
+		case nil, fbScalar:
+			continue
+		}
+		field := start + offsets[i]
+		pos := b.object(f)
+		binary.LittleEndian.PutUint32(b.buf[field:], uint32(pos-field))
+	}
+	return start
+}
+
+// object 写入字段引用的对象，返回它的位置
+func (b *fbBuilder) object(f interface{}) int {
+	switch f := f.(type) {
+	case string:
+		b.pad(4)
+		pos := b.reserve(4 + len(f) + 1)
+		binary.LittleEndian.PutUint32(b.buf[pos:], uint32(len(f)))
+		copy(b.buf[pos+4:], f)
+		return pos
+	case fbTable:
+		return b.table(f)
+	case []fbTable:
+		b.pad(4)
+		pos := b.reserve(4 + 4*len(f))
+		binary.LittleEndian.PutUint32(b.buf[pos:], uint32(len(f)))
+		for i, t := range f {
+			elem := pos + 4 + 4*i
+			child := b.table(t)
+			binary.LittleEndian.PutUint32(b.buf[elem:], uint32(child-elem))
+		}
+		return pos
+	case fbStructs:
+		// 长度之后的第一个元素按8字节对齐
+		for (len(b.buf)+4)%8 != 0 {
+			b.buf = append(b.buf, 0)
+		}
+		pos := b.reserve(4)
+		binary.LittleEndian.PutUint32(b.buf[pos:], uint32(f.count))
+		b.buf = append(b.buf, f.data...)
+		return pos
+	default:
+		panic(fmt.Sprintf("不支持的 FlatBuffers 字段类型 %T", f))
+	}
+}
+
+// appendUint64LE 以小端序追加一个 uint64
+func appendUint64LE(b []byte, v uint64) []byte {
+	return append(b, byte(v), byte(v>>8), byte(v>>16), byte(v>>24), byte(v>>32), byte(v>>40), byte(v>>48), byte(v>>56))
+}
+
+// appendUint32LE 以小端序追加一个 uint32
+func appendUint32LE(b []byte, v uint32) []byte {
+	return append(b, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+// arrowSchema 返回 Schema 表，所有字段都可以为 null
+func (t *ColumnarTable) arrowSchema() fbTable {
+	fields := make([]fbTable, len(t.Fields))
+	for i, f := range t.Fields {
+		var typeID uint8
+		var typ fbTable
+		switch f.Type {
+		case COLUMN_INT64:
+			typeID, typ = arrowTypeInt, fbTable{fbInt32(64), fbBool(true)}
+		case COLUMN_FLOAT64:
+			typeID, typ = arrowTypeFloat, fbTable{fbInt16(arrowPrecisionDouble)}
+		case COLUMN_BOOL:
+			typeID, typ = arrowTypeBool, fbTable{}
+		default:
+			typeID, typ = arrowTypeUtf8, fbTable{}
+		}
+		// name, nullable, type_type, type, dictionary, children
+		fields[i] = fbTable{f.Name, fbBool(true), fbUint8(typeID), typ, nil, []fbTable{}}
+	}
+	// endianness 缺省为 Little
+	return fbTable{nil, fields}
+}
+
+// arrowBatch 返回记录批次的 RecordBatch 表和消息体
+func (t *ColumnarTable) arrowBatch(batch *ColumnBatch) (fbTable, []byte, error) {
+	var body []byte
+	nodes, buffers := fbStructsOf(0), fbStructsOf(0)
+	addBuffer := func(data []byte) {
+		buffers.add(int64(len(body)), int64(len(data)))
+		body = append(body, data...)
+		for len(body)%8 != 0 {
+			body = append(body, 0)
+		}
+	}
+
+	for i, f := range t.Fields {
+		c := batch.Columns[i]
+		nodes.add(int64(batch.Length), int64(c.NullCount))
+		// 没有 null 时可以省略有效位图
+		if c.NullCount == 0 {
+			addBuffer(nil)
+		} else {
+			addBuffer(packBits(c.Valid))
+		}
+		switch f.Type {
+		case COLUMN_INT64:
+			data := make([]byte, 0, 8*batch.Length)
+			for _, v := range c.Int64s {
+				data = appendUint64LE(data, uint64(v))
+			}
+			addBuffer(data)
+		case COLUMN_FLOAT64:
+			data := make([]byte, 0, 8*batch.Length)
+			for _, v := range c.Float64s {
+				data = appendUint64LE(data, math.Float64bits(v))
+			}
+			addBuffer(data)
+		case COLUMN_BOOL:
+			addBuffer(packBits(c.Bools))
+		default:
+			offsets := make([]byte, 0, 4*(batch.Length+1))
+			var data []byte
+			offsets = appendUint32LE(offsets, 0)
+			for _, s := range c.Strings {
+				data = append(data, s...)
+				if len(data) > math.MaxInt32 {
+					return nil, nil, fmt.Errorf("列 %s 在一个批次中的字符串超过 2GB，请减小批次大小", f.Name)
+				}
+				offsets = appendUint32LE(offsets, uint32(len(data)))
+			}
+			addBuffer(offsets)
+			addBuffer(data)
+		}
+	}
+	// length, nodes, buffers
+	return fbTable{fbInt64(int64(batch.Length)), nodes, buffers}, body, nil
+}
+
+// packBits 按 Arrow 和 Parquet 使用的 LSB 顺序把布尔值打包为位图
+func packBits(bits []bool) []byte {
+	data := make([]byte, (len(bits)+7)/8)
+	for i, b := range bits {
+		if b {
+			data[i/8] |= 1 << uint(i%8)
+		}
+	}
+	return data
+}
+
+// writeArrowMessage 写入一条封装的消息：继续标记、元数据长度、元数据和消息体，
+// 返回元数据部分（含前8个字节）的长度
+func writeArrowMessage(w io.Writer, headerType uint8, header fbTable, body []byte) (int, error) {
+	// version, header_type, header, bodyLength
+	meta := fbFinish(fbTable{fbInt16(arrowMetadataV5), fbUint8(headerType), header, fbInt64(int64(len(body)))})
+	prefix := appendUint32LE(appendUint32LE(nil, arrowContinuation), uint32(len(meta)))
+	if _, err := w.Write(prefix); err != nil {
+		return 0, err
+	}
+	if _, err := w.Write(meta); err != nil {
+		return 0, err
+	}
+	if _, err := w.Write(body); err != nil {
+		return 0, err
+	}
+	return len(prefix) + len(meta), nil
+}
+
+// arrowBlock 是文件尾部索引的一个记录批次
+type arrowBlock struct {
+	offset     int64
+	metaLength int
+	bodyLength int
+}
+
+// writeArrowMessages 写入 schema、所有记录批次和流结束标记
+func (t *ColumnarTable) writeArrowMessages(w *countingWriter) ([]arrowBlock, error) {
+	if _, err := writeArrowMessage(w, arrowHeaderSchema, t.arrowSchema(), nil); err != nil {
+		return nil, err
+	}
+	var blocks []arrowBlock
+	for _, batch := range t.Batches {
+		header, body, err := t.arrowBatch(batch)
+		if err != nil {
+			return nil, err
+		}
+		offset := w.n
+		n, err := writeArrowMessage(w, arrowHeaderBatch, header, body)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, arrowBlock{offset, n, len(body)})
+	}
+	if _, err := w.Write(appendUint32LE(appendUint32LE(nil, arrowContinuation), 0)); err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// WriteArrowStream 以 Arrow IPC 流格式（.arrows）输出表
+func (t *ColumnarTable) WriteArrowStream(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if _, err := t.writeArrowMessages(&countingWriter{w: bw}); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// WriteArrow 以 Arrow IPC 文件格式（.arrow，即 Feather V2）输出表
+//
+// 文件格式在流格式的前后加上 ARROW1 标记，并在末尾写入索引所有记录批次的 Footer，
+// 读取方可以随机访问任意批次。
+func (t *ColumnarTable) WriteArrow(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	cw := &countingWriter{w: bw}
+	if _, err := cw.Write([]byte(arrowMagic + "\x00\x00")); err != nil {
+		return err
+	}
+	blocks, err := t.writeArrowMessages(cw)
+	if err != nil {
+		return err
+	}
+
+	records := fbStructsOf(0)
+	for _, b := range blocks {
+		// Block 结构体: offset, metaDataLength（int32，后面填充4字节）, bodyLength
+		records.add(b.offset, int64(b.metaLength), int64(b.bodyLength))
+	}
+	// version, schema, dictionaries, recordBatches
+	footer := fbFinish(fbTable{fbInt16(arrowMetadataV5), t.arrowSchema(), fbStructsOf(0), records})
+	if _, err := cw.Write(footer); err != nil {
+		return err
+	}
+	if _, err := cw.Write(appendUint32LE(nil, uint32(len(footer)))); err != nil {
+		return err
+	}
+	if _, err := cw.Write([]byte(arrowMagic)); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
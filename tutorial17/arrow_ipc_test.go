@@ -0,0 +1,268 @@
+package leptjson
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// fbRef 指向 FlatBuffers 缓冲区中的一个表，用于在测试中读回编码结果
+type fbRef struct {
+	buf []byte
+	pos int
+}
+
+func fbRoot(buf []byte) fbRef {
+	return fbRef{buf, int(binary.LittleEndian.Uint32(buf))}
+}
+
+// field 返回第 i 个字段的位置，字段缺省时返回 0
+func (r fbRef) field(i int) int {
+	vtable := r.pos - int(int32(binary.LittleEndian.Uint32(r.buf[r.pos:])))
+	if 4+2*i >= int(binary.LittleEndian.Uint16(r.buf[vtable:])) {
+		return 0
+	}
+	off := int(binary.LittleEndian.Uint16(r.buf[vtable+4+2*i:]))
+	if off == 0 {
+		return 0
+	}
+	return r.pos + off
+}
+
+func (r fbRef) deref(pos int) int {
+	return pos + int(binary.LittleEndian.Uint32(r.buf[pos:]))
+}
+
+func (r fbRef) uint8(i int) uint8 {
+	if p := r.field(i); p != 0 {
+		return r.buf[p]
+	}
+	return 0
+}
+
+func (r fbRef) int64(i int) int64 {
+	if p := r.field(i); p != 0 {
+		return int64(binary.LittleEndian.Uint64(r.buf[p:]))
+	}
+	return 0
+}
+
+func (r fbRef) table(i int) fbRef {
+	return fbRef{r.buf, r.deref(r.field(i))}
+}
+
+func (r fbRef) str(i int) string {
+	p := r.deref(r.field(i))
+	n := int(binary.LittleEndian.Uint32(r.buf[p:]))
+	return string(r.buf[p+4 : p+4+n])
+}
+
+// vector 返回向量第一个元素的位置和元素个数
+func (r fbRef) vector(i int) (int, int) {
+	p := r.deref(r.field(i))
+	return p + 4, int(binary.LittleEndian.Uint32(r.buf[p:]))
+}
+
+// tables 返回表的向量
+func (r fbRef) tables(i int) []fbRef {
+	p, n := r.vector(i)
+	var refs []fbRef
+	for j := 0; j < n; j++ {
+		refs = append(refs, fbRef{r.buf, r.deref(p + 4*j)})
+	}
+	return refs
+}
+
+// int64s 返回由 int64 组成的结构体向量的所有字段
+func (r fbRef) int64s(i int, fieldsPerStruct int) []int64 {
+	p, n := r.vector(i)
+	if p%8 != 0 {
+		panic("结构体向量没有按8字节对齐")
+	}
+	values := make([]int64, n*fieldsPerStruct)
+	for j := range values {
+		values[j] = int64(binary.LittleEndian.Uint64(r.buf[p+8*j:]))
+	}
+	return values
+}
+
+func TestFlatBuffersBuilder(t *testing.T) {
+	buf := fbFinish(fbTable{fbInt16(7), "abc", nil, fbTable{fbBool(true)}, []fbTable{{fbInt64(-1)}, {}}, fbInt64(1 << 40)})
+	if len(buf)%8 != 0 {
+		t.Errorf("缓冲区长度 %d 不是8的倍数", len(buf))
+	}
+	root := fbRoot(buf)
+	if root.pos%8 != 0 {
+		t.Errorf("表没有按8字节对齐")
+	}
+	if v := binary.LittleEndian.Uint16(buf[root.field(0):]); v != 7 {
+		t.Errorf("字段0 = %d", v)
+	}
+	if s := root.str(1); s != "abc" {
+		t.Errorf("字段1 = %q", s)
+	}
+	if root.field(2) != 0 {
+		t.Errorf("缺省的字段2应该不存在")
+	}
+	if root.table(3).uint8(0) != 1 {
+		t.Errorf("子表的字段0应该为 true")
+	}
+	if elems := root.tables(4); len(elems) != 2 || elems[0].int64(0) != -1 || elems[1].field(0) != 0 {
+		t.Errorf("表的向量解码错误")
+	}
+	if p := root.field(5); p%8 != 0 || root.int64(5) != 1<<40 {
+		t.Errorf("字段5 = %d，位置 %d", root.int64(5), p)
+	}
+	// 字符串以 NUL 结尾
+	p := root.deref(root.field(1))
+	if buf[p+4+3] != 0 {
+		t.Errorf("字符串没有以 NUL 结尾")
+	}
+}
+
+// arrowTestTable 返回包含四种列类型和 null 值的表
+func arrowTestTable(t *testing.T) *ColumnarTable {
+	table, err := BuildColumnarTable(mustParse(t, `[
+		{"id": 1, "score": 0.5, "ok": true, "name": "张三"},
+		{"id": 2, "score": null, "ok": false, "name": null},
+		{"id": 3, "score": -2, "ok": true, "name": ""}
+	]`), ColumnarOptions{BatchSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return table
+}
+
+// readArrowMessage 读取一条封装的消息，返回 Message 表、消息体和下一条消息的位置
+func readArrowMessage(t *testing.T, data []byte, pos int) (fbRef, []byte, int) {
+	t.Helper()
+	if binary.LittleEndian.Uint32(data[pos:]) != arrowContinuation {
+		t.Fatalf("位置 %d 没有继续标记", pos)
+	}
+	size := int(binary.LittleEndian.Uint32(data[pos+4:]))
+	if size%8 != 0 {
+		t.Fatalf("元数据长度 %d 不是8的倍数", size)
+	}
+	meta := data[pos+8 : pos+8+size]
+	msg := fbRoot(meta)
+	bodyLength := int(msg.int64(3))
+	start := pos + 8 + size
+	return msg, data[start : start+bodyLength], start + bodyLength
+}
+
+func TestWriteArrowStream(t *testing.T) {
+	table := arrowTestTable(t)
+	var buf bytes.Buffer
+	if err := table.WriteArrowStream(&buf); err != nil {
+		t.Fatalf("WriteArrowStream 失败: %v", err)
+	}
+	data := buf.Bytes()
+
+	msg, _, pos := readArrowMessage(t, data, 0)
+	if binary.LittleEndian.Uint16(msg.buf[msg.field(0):]) != arrowMetadataV5 || msg.uint8(1) != arrowHeaderSchema {
+		t.Fatalf("第一条消息应该是 schema")
+	}
+	fields := msg.table(2).tables(1)
+	if len(fields) != 4 {
+		t.Fatalf("schema 有 %d 列", len(fields))
+	}
+	types := []uint8{arrowTypeInt, arrowTypeFloat, arrowTypeBool, arrowTypeUtf8}
+	for i, f := range fields {
+		if f.str(0) != table.Fields[i].Name || f.uint8(1) != 1 || f.uint8(2) != types[i] {
+			t.Errorf("第%d列的 Field 解码错误", i+1)
+		}
+		if _, n := f.vector(5); n != 0 {
+			t.Errorf("第%d列的 children 应该是空向量", i+1)
+		}
+	}
+	if bits := fields[0].table(3); bits.field(0) == 0 || binary.LittleEndian.Uint32(bits.buf[bits.field(0):]) != 64 || bits.uint8(1) != 1 {
+		t.Errorf("Int 类型应该是64位有符号整数")
+	}
+
+	msg, body, pos := readArrowMessage(t, data, pos)
+	if msg.uint8(1) != arrowHeaderBatch {
+		t.Fatalf("第二条消息应该是记录批次")
+	}
+	batch := msg.table(2)
+	if batch.int64(0) != 2 {
+		t.Errorf("第一个批次的行数 = %d", batch.int64(0))
+	}
+	nodes := batch.int64s(1, 2)
+	if expected := []int64{2, 0, 2, 1, 2, 0, 2, 1}; !equalInt64s(nodes, expected) {
+		t.Errorf("FieldNode = %v, 期望 %v", nodes, expected)
+	}
+	buffers := batch.int64s(2, 2)
+	if len(buffers) != 2*9 {
+		t.Fatalf("有 %d 个缓冲区，期望 9", len(buffers)/2)
+	}
+	buffer := func(i int) []byte {
+		if buffers[2*i]%8 != 0 {
+			t.Errorf("第%d个缓冲区没有按8字节对齐", i)
+		}
+		return body[buffers[2*i] : buffers[2*i]+buffers[2*i+1]]
+	}
+	if len(buffer(0)) != 0 || binary.LittleEndian.Uint64(buffer(1)[8:]) != 2 {
+		t.Errorf("id 列解码错误")
+	}
+	if buffer(2)[0] != 0x01 || math.Float64frombits(binary.LittleEndian.Uint64(buffer(3))) != 0.5 {
+		t.Errorf("score 列解码错误")
+	}
+	if buffer(5)[0] != 0x01 {
+		t.Errorf("ok 列的值位图 = %x", buffer(5))
+	}
+	offsets := buffer(7)
+	if binary.LittleEndian.Uint32(offsets[4:]) != 6 || binary.LittleEndian.Uint32(offsets[8:]) != 6 || string(buffer(8)) != "张三" {
+		t.Errorf("name 列解码错误")
+	}
+
+	_, _, pos = readArrowMessage(t, data, pos)
+	if !bytes.Equal(data[pos:], []byte{0xFF, 0xFF, 0xFF, 0xFF, 0, 0, 0, 0}) {
+		t.Errorf("流应该以结束标记结尾: % x", data[pos:])
+	}
+}
+
+func TestWriteArrowFile(t *testing.T) {
+	table := arrowTestTable(t)
+	var buf bytes.Buffer
+	if err := table.WriteArrow(&buf); err != nil {
+		t.Fatalf("WriteArrow 失败: %v", err)
+	}
+	data := buf.Bytes()
+	if !bytes.HasPrefix(data, []byte("ARROW1\x00\x00")) || !bytes.HasSuffix(data, []byte("ARROW1")) {
+		t.Fatalf("文件的开头或结尾没有 ARROW1 标记")
+	}
+
+	size := int(binary.LittleEndian.Uint32(data[len(data)-10:]))
+	footer := fbRoot(data[len(data)-10-size : len(data)-10])
+	if len(footer.table(1).tables(1)) != 4 {
+		t.Errorf("Footer 中的 schema 解码错误")
+	}
+	blocks := footer.int64s(3, 3)
+	if len(blocks) != 2*3 {
+		t.Fatalf("Footer 索引了 %d 个批次", len(blocks)/3)
+	}
+	for i := 0; i < len(blocks); i += 3 {
+		msg, body, _ := readArrowMessage(t, data, int(blocks[i]))
+		if msg.uint8(1) != arrowHeaderBatch || int64(8+binary.LittleEndian.Uint32(data[blocks[i]+4:])) != blocks[i+1] || int64(len(body)) != blocks[i+2] {
+			t.Errorf("第%d个 Block = %v", i/3+1, blocks[i:i+3])
+		}
+	}
+	// 第二个批次只有一行
+	msg, _, _ := readArrowMessage(t, data, int(blocks[3]))
+	if msg.table(2).int64(0) != 1 {
+		t.Errorf("第二个批次的行数 = %d", msg.table(2).int64(0))
+	}
+}
+
+func equalInt64s(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
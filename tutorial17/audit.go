@@ -0,0 +1,317 @@
+// audit.go - 检查 JSON 输入中可能用于攻击的可疑结构
+package leptjson
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// AuditRisk 是审计发现的风险等级
+type AuditRisk int
+
+const (
+	RISK_NONE   AuditRisk = iota // 没有发现问题
+	RISK_LOW                     // 可能导致精度丢失等轻微问题
+	RISK_MEDIUM                  // 可能被用于绕过检查或消耗资源
+	RISK_HIGH                    // 很可能导致拒绝服务
+)
+
+// String 返回风险等级的名称
+func (r AuditRisk) String() string {
+	switch r {
+	case RISK_LOW:
+		return "low"
+	case RISK_MEDIUM:
+		return "medium"
+	case RISK_HIGH:
+		return "high"
+	default:
+		return "none"
+	}
+}
+
+// MarshalJSON 将风险等级序列化为名称
+func (r AuditRisk) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + r.String() + `"`), nil
+}
+
+// AuditKind 是审计发现的种类
+type AuditKind int
+
+const (
+	AUDIT_PARSE_ERROR   AuditKind = iota // 输入无法完整解析
+	AUDIT_DEEP_NESTING                   // 嵌套过深
+	AUDIT_LONG_STRING                    // 字符串或键过长
+	AUDIT_HUGE_NUMBER                    // 数字超出精确表示范围或字面量过长
+	AUDIT_DUPLICATE_KEY                  // 对象中有重复的键
+	AUDIT_INVALID_UTF8                   // 字符串不是有效的 UTF-8
+	AUDIT_REF_EXPANSION                  // $ref 展开后的规模过大或形成循环
+)
+
+// String 返回种类的名称
+func (k AuditKind) String() string {
+	switch k {
+	case AUDIT_PARSE_ERROR:
+		return "parse_error"
+	case AUDIT_DEEP_NESTING:
+		return "deep_nesting"
+	case AUDIT_LONG_STRING:
+		return "long_string"
+	case AUDIT_HUGE_NUMBER:
+		return "huge_number"
+	case AUDIT_DUPLICATE_KEY:
+		return "duplicate_key"
+	case AUDIT_INVALID_UTF8:
+		return "invalid_utf8"
+	case AUDIT_REF_EXPANSION:
+		return "ref_expansion"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalJSON 将种类序列化为名称
+func (k AuditKind) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + k.String() + `"`), nil
+}
+
+// AuditFinding 是一项审计发现
+type AuditFinding struct {
+	Kind    AuditKind `json:"kind"`
+	Risk    AuditRisk `json:"risk"`
+	Path    string    `json:"path"` // 可疑值的位置，如 $.items[0].name
+	Message string    `json:"message"`
+}
+
+// AuditReport 是审计的结果
+type AuditReport struct {
+	Risk      AuditRisk      `json:"risk"` // 所有发现中最高的风险等级
+	Findings  []AuditFinding `json:"findings"`
+	Truncated bool           `json:"truncated,omitempty"` // 发现数量超过上限，后面的发现被省略
+	Values    int            `json:"values"`              // 值的总数
+	MaxDepth  int            `json:"max_depth"`           // 最大嵌套深度
+}
+
+// AuditOptions 是审计的阈值
+type AuditOptions struct {
+	MaxDepth        int // 嵌套深度超过此值时报告
+	MaxStringLength int // 字符串或键的字节数超过此值时报告
+	MaxRefExpansion int // $ref 完全展开后的值数量超过此值时报告
+	MaxFindings     int // 最多报告的发现数量
+}
+
+// DefaultAuditOptions 返回默认的审计阈值
+func DefaultAuditOptions() AuditOptions {
+	return AuditOptions{
+		MaxDepth:        64,
+		MaxStringLength: 64 * 1024,
+		MaxRefExpansion: 100000,
+		MaxFindings:     100,
+	}
+}
+
+// auditParseDepth 是审计时解析器允许的最大深度，更深的输入无法完整检查
+const auditParseDepth = 10000
+
+// maxSafeInteger 是 float64 能精确表示的最大整数
+const maxSafeInteger = 1<<53 - 1
+
+// Audit 检查 JSON 文本中的可疑结构并生成风险报告
+//
+// 检查的内容包括过深的嵌套、过长的字符串、超出范围的数字、重复的键、
+// 无效的 UTF-8，以及通过文档内部的 $ref 展开成指数规模的结构（类似 XML
+// 的“十亿笑声”攻击）。Audit 不受默认解析限制的约束，可以检查解析器会拒绝的输入。
+func Audit(json string, options AuditOptions) *AuditReport {
+	a := &auditor{options: options, report: &AuditReport{Findings: []AuditFinding{}}, numbers: make(map[*Value]string)}
+
+	// 放宽解析限制，由审计本身报告超出阈值的值
+	parseOptions := DefaultParseOptions()
+	parseOptions.MaxDepth = auditParseDepth
+	parseOptions.MaxStringLength = math.MaxInt32
+	parseOptions.MaxArraySize = math.MaxInt32
+	parseOptions.MaxObjectSize = math.MaxInt32
+	parseOptions.MaxTotalSize = len(json)
+	parseOptions.DisableKeyInterning = true
+	parseOptions.NumberHandler = a.handleNumber
+
+	root := &Value{}
+	if err := ParseWithOptions(root, json, parseOptions); err != PARSE_OK {
+		a.add(AUDIT_PARSE_ERROR, RISK_HIGH, "$", fmt.Sprintf("无法解析输入: %v", err))
+		return a.report
+	}
+
+	a.walk(root, "$", 0)
+	if a.report.MaxDepth > options.MaxDepth {
+		risk := RISK_MEDIUM
+		if a.report.MaxDepth > 4*options.MaxDepth {
+			risk = RISK_HIGH
+		}
+		a.add(AUDIT_DEEP_NESTING, risk, a.deepestPath,
+			fmt.Sprintf("嵌套深度 %d 超过 %d", a.report.MaxDepth, options.MaxDepth))
+	}
+	if a.hasRefs {
+		a.checkRefExpansion(root)
+	}
+	return a.report
+}
+
+// auditor 保存一次审计的状态
+type auditor struct {
+	options     AuditOptions
+	report      *AuditReport
+	numbers     map[*Value]string // 可疑数字的原始字面量
+	deepestPath string
+	hasRefs     bool
+}
+
+// add 添加一项发现并更新整体风险等级
+func (a *auditor) add(kind AuditKind, risk AuditRisk, path, message string) {
+	if risk > a.report.Risk {
+		a.report.Risk = risk
+	}
+	if len(a.report.Findings) >= a.options.MaxFindings {
+		a.report.Truncated = true
+		return
+	}
+	a.report.Findings = append(a.report.Findings, AuditFinding{Kind: kind, Risk: risk, Path: path, Message: message})
+}
+
+// handleNumber 解析数字并记录可疑的字面量，超出范围的数字不会导致解析失败
+func (a *auditor) handleNumber(v *Value, literal string) ParseError {
+	n, err := strconv.ParseFloat(literal, 64)
+	v.Type = NUMBER
+	v.N = n
+	if err != nil || len(literal) > 100 || (math.Abs(n) > maxSafeInteger && !strings.ContainsAny(literal, ".eE")) {
+		a.numbers[v] = literal
+	}
+	return PARSE_OK
+}
+
+// walk 检查 v 及其所有子值
+func (a *auditor) walk(v *Value, path string, depth int) {
+	a.report.Values++
+	if depth > a.report.MaxDepth {
+		a.report.MaxDepth = depth
+		a.deepestPath = path
+	}
+
+	switch v.Type {
+	case STRING:
+		a.checkString(GetString(v), path, "字符串")
+	case NUMBER:
+		if literal, ok := a.numbers[v]; ok {
+			a.checkNumber(v.N, literal, path)
+		}
+	case ARRAY:
+		for i, e := range v.A {
+			a.walk(e, fmt.Sprintf("%s[%d]", path, i), depth+1)
+		}
+	case OBJECT:
+		seen := make(map[string]bool, len(v.O))
+		for _, m := range v.O {
+			memberPath := path + "." + m.K
+			a.checkString(m.K, memberPath, "键")
+			if seen[m.K] {
+				a.add(AUDIT_DUPLICATE_KEY, RISK_MEDIUM, memberPath,
+					fmt.Sprintf("重复的键 '%s'，不同的解析器可能取不同的值", m.K))
+			}
+			seen[m.K] = true
+			if m.K == "$ref" && m.V.Type == STRING && strings.HasPrefix(GetString(m.V), "#") {
+				a.hasRefs = true
+			}
+			a.walk(m.V, memberPath, depth+1)
+		}
+	}
+}
+
+// checkString 检查字符串或键的长度和编码
+func (a *auditor) checkString(s, path, what string) {
+	if !utf8.ValidString(s) {
+		a.add(AUDIT_INVALID_UTF8, RISK_MEDIUM, path, fmt.Sprintf("%s包含无效的 UTF-8 字节", what))
+	}
+	if limit := a.options.MaxStringLength; len(s) > limit {
+		risk := RISK_MEDIUM
+		if len(s) > 16*limit {
+			risk = RISK_HIGH
+		}
+		a.add(AUDIT_LONG_STRING, risk, path, fmt.Sprintf("%s长度 %d 字节超过 %d", what, len(s), limit))
+	}
+}
+
+// checkNumber 报告可疑的数字
+func (a *auditor) checkNumber(n float64, literal, path string) {
+	switch {
+	case math.IsInf(n, 0):
+		a.add(AUDIT_HUGE_NUMBER, RISK_MEDIUM, path, "数字超出 float64 的范围")
+	case len(literal) > 100:
+		a.add(AUDIT_HUGE_NUMBER, RISK_MEDIUM, path, fmt.Sprintf("数字字面量长度 %d 过长", len(literal)))
+	default:
+		a.add(AUDIT_HUGE_NUMBER, RISK_LOW, path, fmt.Sprintf("整数 %s 超出 float64 的精确表示范围，会丢失精度", literal))
+	}
+}
+
+// checkRefExpansion 估算文档内部 $ref 完全展开后的规模
+func (a *auditor) checkRefExpansion(root *Value) {
+	sizes := make(map[*Value]float64)
+	visiting := make(map[*Value]bool)
+	reported := false
+	limit := float64(a.options.MaxRefExpansion)
+
+	var size func(v *Value, path string) float64
+	size = func(v *Value, path string) float64 {
+		if n, ok := sizes[v]; ok {
+			return n
+		}
+		if visiting[v] {
+			a.add(AUDIT_REF_EXPANSION, RISK_MEDIUM, path, "$ref 形成循环，完全展开时不会结束")
+			return 1
+		}
+		visiting[v] = true
+		defer delete(visiting, v)
+
+		n := 1.0
+		if target := localRefTarget(root, v); target != nil {
+			n = size(target, path)
+			if n > limit && !reported {
+				reported = true
+				a.add(AUDIT_REF_EXPANSION, RISK_HIGH, path,
+					fmt.Sprintf("$ref 展开后约有 %.3g 个值，超过 %d", n, a.options.MaxRefExpansion))
+			}
+		} else {
+			for i, e := range v.A {
+				n += size(e, fmt.Sprintf("%s[%d]", path, i))
+			}
+			for _, m := range v.O {
+				n += size(m.V, path+"."+m.K)
+			}
+		}
+		sizes[v] = n
+		return n
+	}
+	size(root, "$")
+}
+
+// localRefTarget 返回 {"$ref": "#/..."} 引用的文档内部的值，不是这种引用时返回 nil
+func localRefTarget(root, v *Value) *Value {
+	ref := findObjectKey(v, "$ref")
+	if ref == nil || ref.Type != STRING || !strings.HasPrefix(GetString(ref), "#") {
+		return nil
+	}
+	fragment, err := url.PathUnescape(GetString(ref)[1:])
+	if err != nil {
+		return nil
+	}
+	pointer, perr := ParseJSONPointer(fragment)
+	if perr != POINTER_OK {
+		return nil
+	}
+	target, perr := pointer.Get(root)
+	if perr != POINTER_OK {
+		return nil
+	}
+	return target
+}
@@ -0,0 +1,84 @@
+package leptjson
+
+import (
+	"strings"
+	"testing"
+)
+
+func auditKinds(report *AuditReport) string {
+	var kinds []string
+	for _, f := range report.Findings {
+		kinds = append(kinds, f.Kind.String()+"@"+f.Path)
+	}
+	return strings.Join(kinds, ",")
+}
+
+func TestAudit(t *testing.T) {
+	options := DefaultAuditOptions()
+	options.MaxDepth = 3
+	options.MaxStringLength = 8
+
+	tests := []struct {
+		name  string
+		json  string
+		risk  AuditRisk
+		kinds string
+	}{
+		{"干净的输入", `{"a":[1,2.5,"x"],"b":{"c":null}}`, RISK_NONE, ""},
+		{"嵌套过深", `{"a":[[[[1]]]]}`, RISK_MEDIUM, "deep_nesting@$.a[0][0][0][0]"},
+		{"嵌套极深", strings.Repeat("[", 20) + strings.Repeat("]", 20), RISK_HIGH, "deep_nesting@$" + strings.Repeat("[0]", 19)},
+		{"长字符串和长键", `{"short":"123456789","123456789":1}`, RISK_MEDIUM,
+			"long_string@$.short,long_string@$.123456789"},
+		{"重复的键", `{"role":"user","role":"admin"}`, RISK_MEDIUM, "duplicate_key@$.role"},
+		{"无效的UTF-8", "[\"ok\",\"\xff\"]", RISK_MEDIUM, "invalid_utf8@$[1]"},
+		{"超出范围的数字", `[1e400,9007199254740993,1.5e300,9007199254740991]`, RISK_MEDIUM,
+			"huge_number@$[0],huge_number@$[1]"},
+		{"无法解析", `{"a":`, RISK_HIGH, "parse_error@$"},
+	}
+	for _, tt := range tests {
+		report := Audit(tt.json, options)
+		if report.Risk != tt.risk || auditKinds(report) != tt.kinds {
+			t.Errorf("%s: 风险 = %v, 发现 = %s\n期望 %v, %s", tt.name, report.Risk, auditKinds(report), tt.risk, tt.kinds)
+		}
+	}
+}
+
+func TestAuditRefExpansion(t *testing.T) {
+	// 每一层引用上一层十次，展开后有约 10^8 个值
+	var sb strings.Builder
+	sb.WriteString(`{"definitions":{"l0":"lol"`)
+	for i := 1; i <= 8; i++ {
+		sb.WriteString(`,"l` + string(rune('0'+i)) + `":[`)
+		for j := 0; j < 10; j++ {
+			if j > 0 {
+				sb.WriteString(",")
+			}
+			sb.WriteString(`{"$ref":"#/definitions/l` + string(rune('0'+i-1)) + `"}`)
+		}
+		sb.WriteString("]")
+	}
+	sb.WriteString(`},"$ref":"#/definitions/l8"}`)
+
+	report := Audit(sb.String(), DefaultAuditOptions())
+	if report.Risk != RISK_HIGH || len(report.Findings) != 1 || report.Findings[0].Kind != AUDIT_REF_EXPANSION {
+		t.Fatalf("报告 = %+v", report)
+	}
+	if report.Values > 200 {
+		t.Errorf("文档本身只有 %d 个值", report.Values)
+	}
+
+	report = Audit(`{"definitions":{"node":{"properties":{"child":{"$ref":"#/definitions/node"}}}},"$ref":"#/definitions/node"}`, DefaultAuditOptions())
+	if report.Risk != RISK_MEDIUM || !strings.Contains(auditKinds(report), "ref_expansion@$") ||
+		!strings.Contains(report.Findings[0].Message, "循环") {
+		t.Errorf("循环引用 = %+v", report.Findings)
+	}
+}
+
+func TestAuditFindingLimit(t *testing.T) {
+	options := DefaultAuditOptions()
+	options.MaxFindings = 2
+	report := Audit(`{"a":1,"a":2,"a":3,"a":4}`, options)
+	if len(report.Findings) != 2 || !report.Truncated || report.Risk != RISK_MEDIUM {
+		t.Errorf("报告 = %+v", report)
+	}
+}
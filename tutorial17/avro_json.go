@@ -0,0 +1,259 @@
+// avro_json.go - 验证 Avro 的JSON编码，把普通JSON转换为 Avro 的JSON编码
+package leptjson
+
+import (
+	"fmt"
+	"math"
+	"unicode/utf8"
+)
+
+// avroValidator 保存一次验证的状态
+type avroValidator struct {
+	plain  bool // union 的值不包装，即普通JSON形式和字段默认值的形式
+	issues []ValidationIssue
+}
+
+// ValidateAvroJSON 验证 data 是否是 schema 的 Avro JSON编码
+//
+// 在这种编码中 union 的非 null 值要包装为只有一个成员的对象，键是分支的类型名，
+// 如 {"string": "a"}、{"com.example.User": {...}}；bytes 和 fixed 是每个字符表示
+// 一个字节（U+0000 到 U+00FF）的字符串。记录中有默认值的字段可以省略。
+func ValidateAvroJSON(schema *AvroSchema, data *Value) ValidationResult {
+	v := &avroValidator{}
+	v.validate(schema, data, "$")
+	translator, _ := LookupValidationTranslator(DefaultValidationLanguage)
+	return newValidationResult(v.issues, translator)
+}
+
+// validateAvroDefault 检查字段的默认值：默认值不包装 union，union 字段的默认值对应第一个分支
+func validateAvroDefault(schema *AvroSchema, value *Value) []ValidationIssue {
+	if schema.Type == AVRO_UNION {
+		schema = schema.Branches[0]
+	}
+	v := &avroValidator{plain: true}
+	v.validate(schema, value, "$")
+	return v.issues
+}
+
+// avroMatches 判断普通JSON值是否符合 schema
+func avroMatches(schema *AvroSchema, value *Value) bool {
+	v := &avroValidator{plain: true}
+	v.validate(schema, value, "$")
+	return len(v.issues) == 0
+}
+
+// fail 记录一条错误
+func (v *avroValidator) fail(path, keyword string, format string, args ...interface{}) {
+	v.issues = append(v.issues, ValidationIssue{
+		Path:    path,
+		Keyword: keyword,
+		Message: "路径 " + path + ": " + fmt.Sprintf(format, args...),
+	})
+}
+
+// isAvroBytes 判断字符串的每个字符是否都在 U+0000 到 U+00FF 之间
+func isAvroBytes(s string) bool {
+	for _, r := range s {
+		if r > 0xFF || r == utf8.RuneError {
+			return false
+		}
+	}
+	return true
+}
+
+func (v *avroValidator) validate(s *AvroSchema, data *Value, path string) {
+	switch s.Type {
+	case AVRO_NULL:
+		if data.Type != NULL {
+			v.fail(path, "type", "应该是 null，实际是 %s", valueTypeName(data.Type))
+		}
+	case AVRO_BOOLEAN:
+		if data.Type != TRUE && data.Type != FALSE {
+			v.fail(path, "type", "应该是布尔值，实际是 %s", valueTypeName(data.Type))
+		}
+	case AVRO_INT, AVRO_LONG:
+		if data.Type != NUMBER {
+			v.fail(path, "type", "%s 应该是整数，实际是 %s", s.Type, valueTypeName(data.Type))
+		} else if data.N != math.Trunc(data.N) {
+			v.fail(path, "type", "%v 不是整数", data.N)
+		} else if s.Type == AVRO_INT && (data.N < math.MinInt32 || data.N > math.MaxInt32) {
+			v.fail(path, "range", "%v 超出了 int 的范围", data.N)
+		} else if data.N < -(1<<63) || data.N >= 1<<63 {
+			v.fail(path, "range", "%v 超出了 long 的范围", data.N)
+		}
+	case AVRO_FLOAT, AVRO_DOUBLE:
+		if data.Type != NUMBER {
+			v.fail(path, "type", "%s 应该是数字，实际是 %s", s.Type, valueTypeName(data.Type))
+		} else if s.Type == AVRO_FLOAT && math.Abs(data.N) > math.MaxFloat32 {
+			v.fail(path, "range", "%v 超出了 float 的范围", data.N)
+		}
+	case AVRO_STRING:
+		if data.Type != STRING {
+			v.fail(path, "type", "应该是字符串，实际是 %s", valueTypeName(data.Type))
+		}
+	case AVRO_BYTES, AVRO_FIXED:
+		if data.Type != STRING {
+			v.fail(path, "type", "%s 应该是字符串，实际是 %s", s.Type, valueTypeName(data.Type))
+		} else if !isAvroBytes(GetString(data)) {
+			v.fail(path, "format", "%s 的字符串中每个字符表示一个字节，只能是 U+0000 到 U+00FF", s.Type)
+		} else if n := utf8.RuneCountInString(GetString(data)); s.Type == AVRO_FIXED && n != s.Size {
+			v.fail(path, "size", "fixed %s 应该有 %d 个字节，实际是 %d 个", s.Name, s.Size, n)
+		}
+	case AVRO_ENUM:
+		if data.Type != STRING {
+			v.fail(path, "type", "enum %s 应该是字符串，实际是 %s", s.Name, valueTypeName(data.Type))
+			return
+		}
+		for _, sym := range s.Symbols {
+			if sym == GetString(data) {
+				return
+			}
+		}
+		v.fail(path, "enum", "%q 不是 enum %s 的符号", GetString(data), s.Name)
+	case AVRO_ARRAY:
+		if data.Type != ARRAY {
+			v.fail(path, "type", "应该是数组，实际是 %s", valueTypeName(data.Type))
+			return
+		}
+		for i, elem := range data.A {
+			v.validate(s.Items, elem, fmt.Sprintf("%s[%d]", path, i))
+		}
+	case AVRO_MAP:
+		if data.Type != OBJECT {
+			v.fail(path, "type", "map 应该是对象，实际是 %s", valueTypeName(data.Type))
+			return
+		}
+		for _, m := range data.O {
+			v.validate(s.Values, m.V, path+"."+m.K)
+		}
+	case AVRO_RECORD:
+		v.record(s, data, path)
+	case AVRO_UNION:
+		v.union(s, data, path)
+	}
+}
+
+// record 验证记录：没有默认值的字段必须出现，不能有 schema 中没有的字段
+func (v *avroValidator) record(s *AvroSchema, data *Value, path string) {
+	if data.Type != OBJECT {
+		v.fail(path, "type", "record %s 应该是对象，实际是 %s", s.Name, valueTypeName(data.Type))
+		return
+	}
+	fields := make(map[string]*AvroField, len(s.Fields))
+	for _, f := range s.Fields {
+		fields[f.Name] = f
+		if f.Default == nil && GetObjectValueByKey(data, f.Name) == nil {
+			v.fail(path, "required", "缺少 record %s 的字段 %s", s.Name, f.Name)
+		}
+	}
+	for _, m := range data.O {
+		f, ok := fields[m.K]
+		if !ok {
+			v.fail(path+"."+m.K, "unknownField", "record %s 没有字段 %s", s.Name, m.K)
+			continue
+		}
+		v.validate(f.Type, m.V, path+"."+m.K)
+	}
+}
+
+// union 验证 union：null 直接编码，其他分支的值包装为 {"类型名": 值}
+func (v *avroValidator) union(s *AvroSchema, data *Value, path string) {
+	if v.plain {
+		for _, b := range s.Branches {
+			if avroMatches(b, data) {
+				return
+			}
+		}
+		v.fail(path, "union", "不符合 union %s 的任何分支", avroUnionNames(s))
+		return
+	}
+	if data.Type == NULL {
+		for _, b := range s.Branches {
+			if b.Type == AVRO_NULL {
+				return
+			}
+		}
+		v.fail(path, "union", "union %s 中没有 null", avroUnionNames(s))
+		return
+	}
+	if data.Type != OBJECT || len(data.O) != 1 {
+		example := s.Branches[0]
+		if example.Type == AVRO_NULL && len(s.Branches) > 1 {
+			example = s.Branches[1]
+		}
+		v.fail(path, "union", "union 的值应该包装为只有一个成员的对象，如 {\"%s\": ...}", example.unionName())
+		return
+	}
+	key := data.O[0].K
+	for _, b := range s.Branches {
+		if b.Type != AVRO_NULL && b.unionName() == key {
+			v.validate(b, data.O[0].V, path+"."+key)
+			return
+		}
+	}
+	v.fail(path, "union", "%q 不是 union %s 的分支", key, avroUnionNames(s))
+}
+
+// avroUnionNames 返回 union 分支名称的列表，用于错误信息
+func avroUnionNames(s *AvroSchema) string {
+	names := make([]string, len(s.Branches))
+	for i, b := range s.Branches {
+		names[i] = b.unionName()
+	}
+	return fmt.Sprintf("%v", names)
+}
+
+// NormalizeAvroJSON 把普通JSON值转换为 schema 的 Avro JSON编码
+//
+// union 的非 null 值包装为 {"类型名": 值}，使用第一个能接受该值的分支，
+// 记录的字段按 schema 的顺序排列，缺少的字段使用默认值。data 不符合 schema 时返回错误。
+func NormalizeAvroJSON(schema *AvroSchema, data *Value) (*Value, error) {
+	v := &avroValidator{plain: true}
+	v.validate(schema, data, "$")
+	if len(v.issues) > 0 {
+		return nil, fmt.Errorf("%s（共 %d 个错误）", v.issues[0].Message, len(v.issues))
+	}
+	result := &Value{}
+	normalizeAvro(schema, data, result)
+	return result, nil
+}
+
+// normalizeAvro 把已经验证过的值转换到 dst
+func normalizeAvro(s *AvroSchema, data, dst *Value) {
+	switch s.Type {
+	case AVRO_ARRAY:
+		SetArray(dst, len(data.A))
+		for _, elem := range data.A {
+			normalizeAvro(s.Items, elem, PushBackArrayElement(dst))
+		}
+	case AVRO_MAP:
+		SetObject(dst)
+		for _, m := range data.O {
+			normalizeAvro(s.Values, m.V, SetObjectValue(dst, m.K))
+		}
+	case AVRO_RECORD:
+		SetObject(dst)
+		for _, f := range s.Fields {
+			value := GetObjectValueByKey(data, f.Name)
+			if value == nil {
+				value = f.Default
+			}
+			normalizeAvro(f.Type, value, SetObjectValue(dst, f.Name))
+		}
+	case AVRO_UNION:
+		for _, b := range s.Branches {
+			if !avroMatches(b, data) {
+				continue
+			}
+			if b.Type == AVRO_NULL {
+				SetNull(dst)
+			} else {
+				SetObject(dst)
+				normalizeAvro(b, data, SetObjectValue(dst, b.unionName()))
+			}
+			return
+		}
+	default:
+		Copy(dst, data)
+	}
+}
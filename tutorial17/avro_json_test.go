@@ -0,0 +1,99 @@
+package leptjson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateAvroJSON(t *testing.T) {
+	schema := avroTestParse(t)
+	valid := []string{
+		`{"id": 1, "name": "a"}`,
+		`{"id": -9007199254740992, "name": "a", "age": {"int": 3}, "email": {"string": "x"}, "status": "DELETED"}`,
+		`{"id": 1, "name": "a", "age": null, "tags": ["x"], "scores": {"m": 1.5}, "hash": "\u0000ÿ\u0001a"}`,
+		`{"id": 1, "name": "a", "friend": {"com.example.User": {"id": 2, "name": "b"}}}`,
+		`{"id": 1, "name": "a", "contact": {"com.example.types.MD5": "abcd"}}`,
+		`{"id": 1, "name": "a", "contact": {"com.example.Phone": {"number": "1"}}}`,
+	}
+	for _, json := range valid {
+		if result := ValidateAvroJSON(schema, mustParse(t, json)); !result.Valid {
+			t.Errorf("%s 应该有效: %v", json, result.Errors)
+		}
+	}
+
+	invalid := []struct {
+		json, path, keyword string
+	}{
+		{`[]`, "$", "type"},
+		{`{"name": "a"}`, "$", "required"},
+		{`{"id": 1, "name": "a", "nickname": "x"}`, "$.nickname", "unknownField"},
+		{`{"id": 1.5, "name": "a"}`, "$.id", "type"},
+		{`{"id": 1e19, "name": "a"}`, "$.id", "range"},
+		{`{"id": 1, "name": "a", "age": 3}`, "$.age", "union"},
+		{`{"id": 1, "name": "a", "age": {"long": 3}}`, "$.age", "union"},
+		{`{"id": 1, "name": "a", "age": {"int": 2147483648}}`, "$.age.int", "range"},
+		{`{"id": 1, "name": "a", "email": {"string": "x", "null": null}}`, "$.email", "union"},
+		{`{"id": 1, "name": "a", "status": "GONE"}`, "$.status", "enum"},
+		{`{"id": 1, "name": "a", "tags": [1]}`, "$.tags[0]", "type"},
+		{`{"id": 1, "name": "a", "scores": {"m": "1"}}`, "$.scores.m", "type"},
+		{`{"id": 1, "name": "a", "hash": "abc"}`, "$.hash", "size"},
+		{`{"id": 1, "name": "a", "hash": "中文字符"}`, "$.hash", "format"},
+		{`{"id": 1, "name": "a", "friend": {"User": {"id": 2, "name": "b"}}}`, "$.friend", "union"},
+		{`{"id": 1, "name": "a", "contact": {"com.example.Phone": {}}}`, "$.contact.com.example.Phone", "required"},
+	}
+	for _, tt := range invalid {
+		result := ValidateAvroJSON(schema, mustParse(t, tt.json))
+		if result.Valid || len(result.Issues) != 1 {
+			t.Errorf("%s 应该有一个错误，实际是 %v", tt.json, result.Errors)
+			continue
+		}
+		if issue := result.Issues[0]; issue.Path != tt.path || issue.Keyword != tt.keyword {
+			t.Errorf("%s 的错误 = %s %s, 期望 %s %s", tt.json, issue.Path, issue.Keyword, tt.path, tt.keyword)
+		}
+	}
+
+	result := ValidateAvroJSON(schema, mustParse(t, `{"id": "1", "name": "a"}`))
+	if len(result.Errors) != 1 || result.Errors[0] != "路径 $.id: long 应该是整数，实际是 string" {
+		t.Errorf("错误信息 = %v", result.Errors)
+	}
+}
+
+func TestNormalizeAvroJSON(t *testing.T) {
+	schema := avroTestParse(t)
+	data := mustParse(t, `{"contact": {"number": "1"}, "name": "a", "id": 1, "age": 3, "email": null,
+		"friend": {"id": 2, "name": "b", "email": "x", "contact": "abcd", "friend": null}}`)
+	result, err := NormalizeAvroJSON(schema, data)
+	if err != nil {
+		t.Fatalf("NormalizeAvroJSON 失败: %v", err)
+	}
+	text, _ := Stringify(result)
+	expected := `{"id":1,"name":"a","age":{"int":3},"email":null,"status":"ACTIVE","tags":[],"scores":{},` +
+		`"hash":"\u0000ÿ\u0001\u0002","created":0,"friend":{"com.example.User":{"id":2,"name":"b","age":null,` +
+		`"email":{"string":"x"},"status":"ACTIVE","tags":[],"scores":{},"hash":"\u0000ÿ\u0001\u0002","created":0,` +
+		`"friend":null,"contact":{"string":"abcd"}}},"contact":{"com.example.Phone":{"number":"1"}}}`
+	if text != expected {
+		t.Errorf("NormalizeAvroJSON = %s\n期望 %s", text, expected)
+	}
+	// 转换结果是有效的 Avro JSON编码
+	if r := ValidateAvroJSON(schema, result); !r.Valid {
+		t.Errorf("转换结果无效: %v", r.Errors)
+	}
+	// 默认值中的 union 也要包装
+	if email := GetObjectValueByKey(mustNormalize(t, schema, `{"id": 1, "name": "a"}`), "email"); email.Type != OBJECT || email.O[0].K != "string" {
+		t.Errorf("email 的默认值 = %v", email)
+	}
+
+	_, err = NormalizeAvroJSON(schema, mustParse(t, `{"id": 1, "name": 2, "status": "GONE"}`))
+	if err == nil || !strings.Contains(err.Error(), "$.name") || !strings.Contains(err.Error(), "共 2 个错误") {
+		t.Errorf("无效数据的错误 = %v", err)
+	}
+}
+
+func mustNormalize(t *testing.T, schema *AvroSchema, json string) *Value {
+	t.Helper()
+	result, err := NormalizeAvroJSON(schema, mustParse(t, json))
+	if err != nil {
+		t.Fatalf("NormalizeAvroJSON 失败: %v", err)
+	}
+	return result
+}
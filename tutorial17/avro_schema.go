@@ -0,0 +1,484 @@
+// avro_schema.go - 解析 Avro schema 并转换为 JSON Schema
+package leptjson
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// AvroType 是 Avro schema 的类型
+type AvroType int
+
+// Avro 类型常量
+const (
+	AVRO_NULL AvroType = iota
+	AVRO_BOOLEAN
+	AVRO_INT
+	AVRO_LONG
+	AVRO_FLOAT
+	AVRO_DOUBLE
+	AVRO_BYTES
+	AVRO_STRING
+	AVRO_RECORD
+	AVRO_ENUM
+	AVRO_ARRAY
+	AVRO_MAP
+	AVRO_UNION
+	AVRO_FIXED
+)
+
+var avroTypeNames = []string{"null", "boolean", "int", "long", "float", "double", "bytes", "string", "record", "enum", "array", "map", "union", "fixed"}
+
+// String 返回类型在 schema 中的名称
+func (t AvroType) String() string {
+	if int(t) >= 0 && int(t) < len(avroTypeNames) {
+		return avroTypeNames[t]
+	}
+	return "unknown"
+}
+
+// MarshalJSON 将类型编码为名称字符串
+func (t AvroType) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(t.String())), nil
+}
+
+// avroPrimitives 是原始类型的名称
+var avroPrimitives = map[string]AvroType{
+	"null": AVRO_NULL, "boolean": AVRO_BOOLEAN, "int": AVRO_INT, "long": AVRO_LONG,
+	"float": AVRO_FLOAT, "double": AVRO_DOUBLE, "bytes": AVRO_BYTES, "string": AVRO_STRING,
+}
+
+// AvroField 是记录的一个字段
+type AvroField struct {
+	Name    string
+	Type    *AvroSchema
+	Default *Value // 缺少字段时使用的默认值，没有默认值时为 nil
+	Doc     string
+}
+
+// AvroSchema 是解析后的 Avro schema
+//
+// 命名类型（record、enum、fixed）可以递归引用自己，引用同一个命名类型的地方共享同一个 *AvroSchema。
+type AvroSchema struct {
+	Type        AvroType
+	Name        string        // 命名类型的完整名称，如 com.example.User
+	Doc         string        // 文档说明
+	LogicalType string        // 逻辑类型，如 timestamp-millis、uuid，只作记录，不影响验证
+	Fields      []*AvroField  // record 的字段
+	Symbols     []string      // enum 的符号
+	Items       *AvroSchema   // array 的元素类型
+	Values      *AvroSchema   // map 的值类型
+	Branches    []*AvroSchema // union 的分支
+	Size        int           // fixed 的字节数
+}
+
+// unionName 返回类型在 union 的JSON编码中作为键的名称：命名类型是完整名称，其他是类型名
+func (s *AvroSchema) unionName() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return s.Type.String()
+}
+
+// avroNamePattern 是名称中每一段的语法
+var avroNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// avroParser 保存解析过程中定义的命名类型
+type avroParser struct {
+	named map[string]*AvroSchema
+}
+
+// ParseAvroSchema 解析 Avro schema（.avsc 文件的内容）
+//
+// 支持所有原始类型和复杂类型、命名类型的引用和命名空间。字段的默认值按字段的类型检查，
+// union 字段的默认值对应第一个分支。
+func ParseAvroSchema(schema *Value) (*AvroSchema, error) {
+	p := &avroParser{named: make(map[string]*AvroSchema)}
+	return p.parse(schema, "", "$")
+}
+
+// avroFullName 按命名空间规则计算名称的完整形式
+func avroFullName(name, namespace string) string {
+	if strings.Contains(name, ".") || namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
+
+// avroNamespaceOf 返回完整名称中的命名空间
+func avroNamespaceOf(fullName string) string {
+	if i := strings.LastIndex(fullName, "."); i >= 0 {
+		return fullName[:i]
+	}
+	return ""
+}
+
+func (p *avroParser) parse(v *Value, namespace, path string) (*AvroSchema, error) {
+	switch v.Type {
+	case STRING:
+		return p.reference(GetString(v), namespace, path)
+	case ARRAY:
+		return p.union(v, namespace, path)
+	case OBJECT:
+		typ := GetObjectValueByKey(v, "type")
+		if typ == nil {
+			return nil, fmt.Errorf("%s: 缺少 type", path)
+		}
+		if typ.Type != STRING {
+			// {"type": {...}} 和 {"type": [...]} 只是包了一层
+			return p.parse(typ, namespace, path+".type")
+		}
+		var s *AvroSchema
+		var err error
+		switch GetString(typ) {
+		case "record", "error":
+			s, err = p.record(v, namespace, path)
+		case "enum":
+			s, err = p.enum(v, namespace, path)
+		case "fixed":
+			s, err = p.fixed(v, namespace, path)
+		case "array":
+			items := GetObjectValueByKey(v, "items")
+			if items == nil {
+				return nil, fmt.Errorf("%s: array 缺少 items", path)
+			}
+			s = &AvroSchema{Type: AVRO_ARRAY}
+			s.Items, err = p.parse(items, namespace, path+".items")
+		case "map":
+			values := GetObjectValueByKey(v, "values")
+			if values == nil {
+				return nil, fmt.Errorf("%s: map 缺少 values", path)
+			}
+			s = &AvroSchema{Type: AVRO_MAP}
+			s.Values, err = p.parse(values, namespace, path+".values")
+		default:
+			t, ok := avroPrimitives[GetString(typ)]
+			if !ok {
+				// {"type": "com.example.User"} 引用命名类型
+				return p.reference(GetString(typ), namespace, path)
+			}
+			s = &AvroSchema{Type: t}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if lt := GetObjectValueByKey(v, "logicalType"); lt != nil && lt.Type == STRING {
+			s.LogicalType = GetString(lt)
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("%s: schema 应该是字符串、对象或数组，实际是 %s", path, valueTypeName(v.Type))
+	}
+}
+
+// reference 解析原始类型的名称或对已定义的命名类型的引用
+func (p *avroParser) reference(name, namespace, path string) (*AvroSchema, error) {
+	if t, ok := avroPrimitives[name]; ok {
+		return &AvroSchema{Type: t}, nil
+	}
+	if s, ok := p.named[avroFullName(name, namespace)]; ok {
+		return s, nil
+	}
+	if s, ok := p.named[name]; ok {
+		return s, nil
+	}
+	return nil, fmt.Errorf("%s: 未定义的类型 %q", path, name)
+}
+
+// union 解析 union：分支不能是 union，除命名类型外同一种类型只能出现一次
+func (p *avroParser) union(v *Value, namespace, path string) (*AvroSchema, error) {
+	if len(v.A) == 0 {
+		return nil, fmt.Errorf("%s: union 至少需要一个分支", path)
+	}
+	s := &AvroSchema{Type: AVRO_UNION}
+	seen := make(map[string]bool)
+	for i, b := range v.A {
+		branch, err := p.parse(b, namespace, fmt.Sprintf("%s[%d]", path, i))
+		if err != nil {
+			return nil, err
+		}
+		if branch.Type == AVRO_UNION {
+			return nil, fmt.Errorf("%s[%d]: union 不能直接包含 union", path, i)
+		}
+		name := branch.unionName()
+		if seen[name] {
+			return nil, fmt.Errorf("%s[%d]: union 中重复的类型 %s", path, i, name)
+		}
+		seen[name] = true
+		s.Branches = append(s.Branches, branch)
+	}
+	return s, nil
+}
+
+// define 读取命名类型的 name、namespace 和 doc 并登记，返回类型内部使用的命名空间
+func (p *avroParser) define(s *AvroSchema, v *Value, namespace, path string) (string, error) {
+	name := GetObjectValueByKey(v, "name")
+	if name == nil || name.Type != STRING {
+		return "", fmt.Errorf("%s: %s 缺少字符串类型的 name", path, s.Type)
+	}
+	if ns := GetObjectValueByKey(v, "namespace"); ns != nil && ns.Type == STRING {
+		namespace = GetString(ns)
+	}
+	s.Name = avroFullName(GetString(name), namespace)
+	for _, part := range strings.Split(s.Name, ".") {
+		if !avroNamePattern.MatchString(part) {
+			return "", fmt.Errorf("%s: 无效的名称 %q", path, s.Name)
+		}
+	}
+	if _, ok := avroPrimitives[s.Name]; ok {
+		return "", fmt.Errorf("%s: 命名类型不能使用原始类型的名称 %q", path, s.Name)
+	}
+	if _, ok := p.named[s.Name]; ok {
+		return "", fmt.Errorf("%s: 重复定义的类型 %s", path, s.Name)
+	}
+	if doc := GetObjectValueByKey(v, "doc"); doc != nil && doc.Type == STRING {
+		s.Doc = GetString(doc)
+	}
+	p.named[s.Name] = s
+	return avroNamespaceOf(s.Name), nil
+}
+
+// record 解析记录，先登记名称，使字段可以递归引用记录自身
+func (p *avroParser) record(v *Value, namespace, path string) (*AvroSchema, error) {
+	s := &AvroSchema{Type: AVRO_RECORD}
+	inner, err := p.define(s, v, namespace, path)
+	if err != nil {
+		return nil, err
+	}
+	fields := GetObjectValueByKey(v, "fields")
+	if fields == nil || fields.Type != ARRAY {
+		return nil, fmt.Errorf("%s: record %s 缺少数组类型的 fields", path, s.Name)
+	}
+	seen := make(map[string]bool)
+	for i, f := range fields.A {
+		fieldPath := fmt.Sprintf("%s.fields[%d]", path, i)
+		name := GetObjectValueByKey(f, "name")
+		if f.Type != OBJECT || name == nil || name.Type != STRING || !avroNamePattern.MatchString(GetString(name)) {
+			return nil, fmt.Errorf("%s: 字段缺少有效的 name", fieldPath)
+		}
+		if seen[GetString(name)] {
+			return nil, fmt.Errorf("%s: record %s 中重复的字段 %s", fieldPath, s.Name, GetString(name))
+		}
+		seen[GetString(name)] = true
+		typ := GetObjectValueByKey(f, "type")
+		if typ == nil {
+			return nil, fmt.Errorf("%s: 字段 %s 缺少 type", fieldPath, GetString(name))
+		}
+		field := &AvroField{Name: GetString(name), Default: GetObjectValueByKey(f, "default")}
+		if field.Type, err = p.parse(typ, inner, fieldPath+".type"); err != nil {
+			return nil, err
+		}
+		if doc := GetObjectValueByKey(f, "doc"); doc != nil && doc.Type == STRING {
+			field.Doc = GetString(doc)
+		}
+		s.Fields = append(s.Fields, field)
+	}
+	// 所有字段都解析后再检查默认值，默认值可能是递归类型
+	for i, field := range s.Fields {
+		if field.Default == nil {
+			continue
+		}
+		if issues := validateAvroDefault(field.Type, field.Default); len(issues) > 0 {
+			return nil, fmt.Errorf("%s.fields[%d]: 字段 %s 的默认值无效: %s", path, i, field.Name, issues[0].Message)
+		}
+	}
+	return s, nil
+}
+
+// enum 解析枚举，符号必须是有效的名称且不重复
+func (p *avroParser) enum(v *Value, namespace, path string) (*AvroSchema, error) {
+	s := &AvroSchema{Type: AVRO_ENUM}
+	if _, err := p.define(s, v, namespace, path); err != nil {
+		return nil, err
+	}
+	symbols := GetObjectValueByKey(v, "symbols")
+	if symbols == nil || symbols.Type != ARRAY {
+		return nil, fmt.Errorf("%s: enum %s 缺少数组类型的 symbols", path, s.Name)
+	}
+	seen := make(map[string]bool)
+	for _, sym := range symbols.A {
+		if sym.Type != STRING || !avroNamePattern.MatchString(GetString(sym)) || seen[GetString(sym)] {
+			return nil, fmt.Errorf("%s: enum %s 的符号无效或重复", path, s.Name)
+		}
+		seen[GetString(sym)] = true
+		s.Symbols = append(s.Symbols, GetString(sym))
+	}
+	return s, nil
+}
+
+// fixed 解析定长字节类型
+func (p *avroParser) fixed(v *Value, namespace, path string) (*AvroSchema, error) {
+	s := &AvroSchema{Type: AVRO_FIXED}
+	if _, err := p.define(s, v, namespace, path); err != nil {
+		return nil, err
+	}
+	size := GetObjectValueByKey(v, "size")
+	if size == nil || size.Type != NUMBER || size.N < 0 || size.N != float64(int(size.N)) {
+		return nil, fmt.Errorf("%s: fixed %s 缺少有效的 size", path, s.Name)
+	}
+	s.Size = int(size.N)
+	return s, nil
+}
+
+// AvroToJSONSchema 把 Avro schema 转换为 Draft-07 JSON Schema
+//
+// 生成的 JSON Schema 描述普通的JSON形式，union 的值不需要用类型名包装，
+// 可以先用它验证数据，再用 NormalizeAvroJSON 转换为 Avro 的JSON编码。
+// 命名类型放在 definitions 中，用 $ref 引用，因此可以表示递归的记录。
+// 没有默认值的字段是必需的，记录不允许未定义的字段。
+func AvroToJSONSchema(s *AvroSchema) *Value {
+	c := &avroConverter{definitions: &Value{}, done: make(map[string]bool)}
+	SetObject(c.definitions)
+	root := c.convert(s)
+	result := &Value{}
+	SetObject(result)
+	SetString(SetObjectValue(result, "$schema"), "http://json-schema.org/draft-07/schema#")
+	for _, m := range root.O {
+		Copy(SetObjectValue(result, m.K), m.V)
+	}
+	if len(c.definitions.O) > 0 {
+		Copy(SetObjectValue(result, "definitions"), c.definitions)
+	}
+	return result
+}
+
+// avroConverter 保存转换过程中生成的 definitions
+type avroConverter struct {
+	definitions *Value
+	done        map[string]bool
+}
+
+// jsonSchemaType 返回 {"type": name}
+func jsonSchemaType(name string) *Value {
+	v := &Value{}
+	SetObject(v)
+	SetString(SetObjectValue(v, "type"), name)
+	return v
+}
+
+func (c *avroConverter) convert(s *AvroSchema) *Value {
+	switch s.Type {
+	case AVRO_NULL:
+		return jsonSchemaType("null")
+	case AVRO_BOOLEAN:
+		return jsonSchemaType("boolean")
+	case AVRO_INT:
+		v := jsonSchemaType("integer")
+		SetNumber(SetObjectValue(v, "minimum"), -1<<31)
+		SetNumber(SetObjectValue(v, "maximum"), 1<<31-1)
+		return v
+	case AVRO_LONG:
+		return jsonSchemaType("integer")
+	case AVRO_FLOAT, AVRO_DOUBLE:
+		return jsonSchemaType("number")
+	case AVRO_STRING, AVRO_BYTES:
+		return jsonSchemaType("string")
+	case AVRO_ARRAY:
+		v := jsonSchemaType("array")
+		Copy(SetObjectValue(v, "items"), c.convert(s.Items))
+		return v
+	case AVRO_MAP:
+		v := jsonSchemaType("object")
+		Copy(SetObjectValue(v, "additionalProperties"), c.convert(s.Values))
+		return v
+	case AVRO_UNION:
+		return c.union(s)
+	}
+
+	// 命名类型：第一次遇到时生成定义，先占位以支持递归引用
+	if !c.done[s.Name] {
+		c.done[s.Name] = true
+		def := SetObjectValue(c.definitions, s.Name)
+		Copy(def, c.named(s))
+	}
+	ref := &Value{}
+	SetObject(ref)
+	SetString(SetObjectValue(ref, "$ref"), "#/definitions/"+s.Name)
+	return ref
+}
+
+// named 生成 record、enum 和 fixed 的定义
+func (c *avroConverter) named(s *AvroSchema) *Value {
+	var v *Value
+	switch s.Type {
+	case AVRO_ENUM:
+		v = jsonSchemaType("string")
+		symbols := SetObjectValue(v, "enum")
+		SetArray(symbols, len(s.Symbols))
+		for _, sym := range s.Symbols {
+			SetString(PushBackArrayElement(symbols), sym)
+		}
+	case AVRO_FIXED:
+		// bytes 和 fixed 的JSON形式中每个字符是一个字节
+		v = jsonSchemaType("string")
+		SetNumber(SetObjectValue(v, "minLength"), float64(s.Size))
+		SetNumber(SetObjectValue(v, "maxLength"), float64(s.Size))
+	default:
+		v = jsonSchemaType("object")
+		properties := SetObjectValue(v, "properties")
+		SetObject(properties)
+		var required []string
+		for _, f := range s.Fields {
+			property := c.convert(f.Type)
+			// 与 $ref 并列的关键字会被忽略，引用命名类型时不加说明和默认值
+			if GetObjectValueByKey(property, "$ref") == nil {
+				if f.Doc != "" {
+					SetString(SetObjectValue(property, "description"), f.Doc)
+				}
+				if f.Default != nil {
+					Copy(SetObjectValue(property, "default"), f.Default)
+				}
+			}
+			Copy(SetObjectValue(properties, f.Name), property)
+			if f.Default == nil {
+				required = append(required, f.Name)
+			}
+		}
+		if len(required) > 0 {
+			list := SetObjectValue(v, "required")
+			SetArray(list, len(required))
+			for _, name := range required {
+				SetString(PushBackArrayElement(list), name)
+			}
+		}
+		SetBoolean(SetObjectValue(v, "additionalProperties"), false)
+	}
+	SetString(SetObjectValue(v, "title"), s.Name)
+	if s.Doc != "" {
+		SetString(SetObjectValue(v, "description"), s.Doc)
+	}
+	return v
+}
+
+// union 生成 union 的 schema：分支都只有 type 时合并为类型数组，否则使用 anyOf
+func (c *avroConverter) union(s *AvroSchema) *Value {
+	branches := make([]*Value, len(s.Branches))
+	simple := true
+	for i, b := range s.Branches {
+		branches[i] = c.convert(b)
+		if len(branches[i].O) != 1 || branches[i].O[0].K != "type" {
+			simple = false
+		}
+	}
+	if len(branches) == 1 {
+		return branches[0]
+	}
+	v := &Value{}
+	SetObject(v)
+	if simple {
+		types := SetObjectValue(v, "type")
+		SetArray(types, len(branches))
+		for _, b := range branches {
+			Copy(PushBackArrayElement(types), b.O[0].V)
+		}
+		return v
+	}
+	anyOf := SetObjectValue(v, "anyOf")
+	SetArray(anyOf, len(branches))
+	for _, b := range branches {
+		Copy(PushBackArrayElement(anyOf), b)
+	}
+	return v
+}
@@ -0,0 +1,145 @@
+package leptjson
+
+import (
+	"strings"
+	"testing"
+)
+
+// avroTestSchema 是测试使用的 schema，包括命名空间、递归引用、union 和默认值
+const avroTestSchema = `{
+	"type": "record", "name": "User", "namespace": "com.example", "doc": "用户",
+	"fields": [
+		{"name": "id", "type": "long"},
+		{"name": "name", "type": "string", "doc": "姓名"},
+		{"name": "age", "type": ["null", "int"], "default": null},
+		{"name": "email", "type": ["string", "null"], "default": ""},
+		{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["ACTIVE", "DELETED"]}, "default": "ACTIVE"},
+		{"name": "tags", "type": {"type": "array", "items": "string"}, "default": []},
+		{"name": "scores", "type": {"type": "map", "values": "double"}, "default": {}},
+		{"name": "hash", "type": {"type": "fixed", "name": "MD5", "namespace": "com.example.types", "size": 4}, "default": "\u0000ÿ\u0001\u0002"},
+		{"name": "created", "type": {"type": "long", "logicalType": "timestamp-millis"}, "default": 0},
+		{"name": "friend", "type": ["null", "User"], "default": null},
+		{"name": "contact", "type": ["null", "string", "com.example.types.MD5", {"type": "record", "name": "Phone", "fields": [{"name": "number", "type": "string"}]}], "default": null}
+	]
+}`
+
+func avroTestParse(t *testing.T) *AvroSchema {
+	t.Helper()
+	schema, err := ParseAvroSchema(mustParse(t, avroTestSchema))
+	if err != nil {
+		t.Fatalf("ParseAvroSchema 失败: %v", err)
+	}
+	return schema
+}
+
+func TestParseAvroSchema(t *testing.T) {
+	s := avroTestParse(t)
+	if s.Type != AVRO_RECORD || s.Name != "com.example.User" || len(s.Fields) != 11 || s.Doc != "用户" {
+		t.Fatalf("schema = %+v", s)
+	}
+	if status := s.Fields[4].Type; status.Type != AVRO_ENUM || status.Name != "com.example.Status" || len(status.Symbols) != 2 {
+		t.Errorf("status = %+v", status)
+	}
+	if hash := s.Fields[7].Type; hash.Name != "com.example.types.MD5" || hash.Size != 4 {
+		t.Errorf("hash = %+v", hash)
+	}
+	if created := s.Fields[8].Type; created.Type != AVRO_LONG || created.LogicalType != "timestamp-millis" {
+		t.Errorf("created = %+v", created)
+	}
+	// 递归引用和其他命名空间中的类型共享同一个定义
+	if friend := s.Fields[9].Type; friend.Type != AVRO_UNION || friend.Branches[1] != s {
+		t.Errorf("friend 应该引用 User 本身")
+	}
+	contact := s.Fields[10].Type
+	if contact.Branches[2] != s.Fields[7].Type || contact.Branches[3].Name != "com.example.Phone" {
+		t.Errorf("contact = %+v", contact.Branches)
+	}
+	if s.Fields[0].Default != nil || s.Fields[2].Default == nil {
+		t.Errorf("默认值解析错误")
+	}
+}
+
+func TestParseAvroSchemaErrors(t *testing.T) {
+	tests := []struct {
+		schema, expected string
+	}{
+		{`"uuid"`, "未定义的类型"},
+		{`42`, "schema 应该是字符串、对象或数组，实际是 number"},
+		{`[]`, "至少需要一个分支"},
+		{`["null", "null"]`, "重复的类型 null"},
+		{`["null", ["int"]]`, "不能直接包含 union"},
+		{`{"type": "record", "fields": []}`, "缺少字符串类型的 name"},
+		{`{"type": "record", "name": "1x", "fields": []}`, "无效的名称"},
+		{`{"type": "record", "name": "R", "fields": [{"name": "a", "type": "int"}, {"name": "a", "type": "int"}]}`, "重复的字段"},
+		{`{"type": "record", "name": "R", "fields": [{"name": "a", "type": {"type": "enum", "name": "R", "symbols": []}}]}`, "重复定义"},
+		{`{"type": "enum", "name": "E", "symbols": ["A", "A"]}`, "符号无效或重复"},
+		{`{"type": "fixed", "name": "F", "size": -1}`, "有效的 size"},
+		{`{"type": "array"}`, "缺少 items"},
+		{`{"type": "record", "name": "R", "fields": [{"name": "a", "type": "int", "default": "1"}]}`, "默认值无效"},
+		// union 字段的默认值对应第一个分支
+		{`{"type": "record", "name": "R", "fields": [{"name": "a", "type": ["null", "int"], "default": 1}]}`, "默认值无效"},
+		{`{"type": "record", "name": "R", "fields": [{"name": "a", "type": "bytes", "default": "中"}]}`, "U+00FF"},
+	}
+	for _, tt := range tests {
+		_, err := ParseAvroSchema(mustParse(t, tt.schema))
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("%s 的错误 = %v, 期望包含 %q", tt.schema, err, tt.expected)
+		}
+	}
+
+	// 名称在当前命名空间和完整名称中查找
+	s, err := ParseAvroSchema(mustParse(t, `{"type": "record", "name": "a.R", "fields": [
+		{"name": "x", "type": {"type": "enum", "name": "E", "symbols": ["A"]}},
+		{"name": "y", "type": "E"}, {"name": "z", "type": "a.E"}, {"name": "w", "type": {"type": "int"}}]}`))
+	if err != nil || s.Fields[1].Type != s.Fields[0].Type || s.Fields[2].Type != s.Fields[0].Type || s.Fields[3].Type.Type != AVRO_INT {
+		t.Errorf("命名空间解析错误: %v", err)
+	}
+}
+
+func TestAvroToJSONSchema(t *testing.T) {
+	result := AvroToJSONSchema(avroTestParse(t))
+	text, _ := Stringify(result)
+	for _, expected := range []string{
+		`"$schema":"http://json-schema.org/draft-07/schema#"`,
+		`"$ref":"#/definitions/com.example.User"`,
+		`"required":["id","name"]`,
+		`"additionalProperties":false`,
+		`"name":{"type":"string","description":"姓名"}`,
+		`"age":{"anyOf":[{"type":"null"},{"type":"integer","minimum":`,
+		`"email":{"type":["string","null"],"default":""}`,
+		`"status":{"$ref":"#/definitions/com.example.Status"}`,
+		`"com.example.Status":{"type":"string","enum":["ACTIVE","DELETED"],"title":"com.example.Status"}`,
+		`"com.example.types.MD5":{"type":"string","minLength":4,"maxLength":4`,
+		`"scores":{"type":"object","additionalProperties":{"type":"number"},"default":{}}`,
+	} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("JSON Schema 中缺少 %s\n%s", expected, text)
+		}
+	}
+
+	// 生成的 schema 可以直接用于验证普通JSON
+	js, err := NewJSONSchemaFromValue(result)
+	if err != nil {
+		t.Fatalf("NewJSONSchemaFromValue 失败: %v", err)
+	}
+	valid := mustParse(t, `{"id": 1, "name": "a", "age": 3, "friend": {"id": 2, "name": "b", "email": null}, "contact": {"number": "1"}}`)
+	if r := js.Validate(valid); !r.Valid {
+		t.Errorf("应该有效: %v", r.Errors)
+	}
+	for _, data := range []string{
+		`{"id": 1, "name": "a", "friend": {"id": 2}}`,
+		`{"id": 1, "name": "a", "status": "GONE"}`,
+		`{"id": 1, "name": "a", "age": 2147483648}`,
+		`{"id": 1, "name": "a", "nickname": "x"}`,
+	} {
+		if r := js.Validate(mustParse(t, data)); r.Valid {
+			t.Errorf("%s 应该无效", data)
+		}
+	}
+
+	// 原始类型的 schema 没有 definitions
+	text, _ = Stringify(AvroToJSONSchema(&AvroSchema{Type: AVRO_STRING}))
+	if text != `{"$schema":"http://json-schema.org/draft-07/schema#","type":"string"}` {
+		t.Errorf("string 的 JSON Schema = %s", text)
+	}
+}
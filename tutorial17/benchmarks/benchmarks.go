@@ -0,0 +1,147 @@
+// Package benchmarks 对比 leptjson 与其他JSON库的解析和序列化性能
+//
+// 默认对比 encoding/json，其他库（如 json-iterator）可以通过向 Codecs 追加 Codec 接入，
+// 而不必让主模块依赖它们。
+package benchmarks
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	leptjson "github.com/Cactusinhand/go-json-tutorial/tutorial17"
+)
+
+// Codec 描述一个参与对比的JSON库
+type Codec struct {
+	Name      string
+	Parse     func(c *Corpus) (interface{}, error)       // 解析语料，返回解析结果
+	Stringify func(doc interface{}) (interface{}, error) // 将 Parse 的结果序列化
+}
+
+// Codecs 是参与对比的JSON库
+var Codecs = []Codec{LeptJSONCodec(), EncodingJSONCodec()}
+
+// benchParseOptions 关闭安全限制，使大语料可以被完整解析
+func benchParseOptions() leptjson.ParseOptions {
+	opts := leptjson.DefaultParseOptions()
+	opts.EnabledSecurity = false
+	return opts
+}
+
+// LeptJSONCodec 返回 leptjson 的 Codec
+func LeptJSONCodec() Codec {
+	opts := benchParseOptions()
+	return Codec{
+		Name: "leptjson",
+		Parse: func(c *Corpus) (interface{}, error) {
+			v := &leptjson.Value{}
+			if err := leptjson.ParseWithOptions(v, c.Text, opts); err != leptjson.PARSE_OK {
+				return nil, err
+			}
+			return v, nil
+		},
+		Stringify: func(doc interface{}) (interface{}, error) {
+			s, err := leptjson.Stringify(doc.(*leptjson.Value))
+			if err != leptjson.STRINGIFY_OK {
+				return nil, err
+			}
+			return s, nil
+		},
+	}
+}
+
+// EncodingJSONCodec 返回标准库 encoding/json 的 Codec
+func EncodingJSONCodec() Codec {
+	return Codec{
+		Name: "encoding/json",
+		Parse: func(c *Corpus) (interface{}, error) {
+			var doc interface{}
+			if err := json.Unmarshal(c.Data, &doc); err != nil {
+				return nil, err
+			}
+			return doc, nil
+		},
+		Stringify: func(doc interface{}) (interface{}, error) {
+			return json.Marshal(doc)
+		},
+	}
+}
+
+// Result 是一项基准测试的结果
+type Result struct {
+	Corpus      string  `json:"corpus"`
+	Synthetic   bool    `json:"synthetic"`
+	Codec       string  `json:"codec"`
+	Operation   string  `json:"operation"` // parse 或 stringify
+	Iterations  int     `json:"iterations"`
+	NsPerOp     int64   `json:"ns_per_op"`
+	MBPerSec    float64 `json:"mb_per_sec"`
+	AllocsPerOp int64   `json:"allocs_per_op"`
+	BytesPerOp  int64   `json:"bytes_per_op"`
+}
+
+// Run 对每份语料和每个库分别测量解析和序列化的性能
+func Run(corpora []Corpus, codecs []Codec) ([]Result, error) {
+	var results []Result
+	for i := range corpora {
+		corpus := &corpora[i]
+		for _, codec := range codecs {
+			doc, err := codec.Parse(corpus)
+			if err != nil {
+				return nil, fmt.Errorf("%s 解析 %s 失败: %v", codec.Name, corpus.Name, err)
+			}
+
+			parse := testing.Benchmark(func(b *testing.B) {
+				MeasureParse(b, codec, corpus)
+			})
+			results = append(results, newResult(corpus, codec, "parse", parse))
+
+			stringify := testing.Benchmark(func(b *testing.B) {
+				MeasureStringify(b, codec, corpus, doc)
+			})
+			results = append(results, newResult(corpus, codec, "stringify", stringify))
+		}
+	}
+	return results, nil
+}
+
+// MeasureParse 测量解析语料的性能
+func MeasureParse(b *testing.B, codec Codec, corpus *Corpus) {
+	b.SetBytes(int64(len(corpus.Data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := codec.Parse(corpus); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// MeasureStringify 测量序列化已解析文档的性能
+func MeasureStringify(b *testing.B, codec Codec, corpus *Corpus, doc interface{}) {
+	b.SetBytes(int64(len(corpus.Data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := codec.Stringify(doc); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// newResult 将 testing.BenchmarkResult 转换为 Result
+func newResult(corpus *Corpus, codec Codec, operation string, r testing.BenchmarkResult) Result {
+	result := Result{
+		Corpus:      corpus.Name,
+		Synthetic:   corpus.Synthetic,
+		Codec:       codec.Name,
+		Operation:   operation,
+		Iterations:  r.N,
+		NsPerOp:     r.NsPerOp(),
+		AllocsPerOp: r.AllocsPerOp(),
+		BytesPerOp:  r.AllocedBytesPerOp(),
+	}
+	if r.T > 0 {
+		result.MBPerSec = float64(r.Bytes) * float64(r.N) / r.T.Seconds() / 1e6
+	}
+	return result
+}
@@ -0,0 +1,138 @@
+package benchmarks
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// loadTestCorpora 加载基准测试语料，LEPTJSON_CORPUS_DIR 指定真实语料所在目录
+func loadTestCorpora(tb testing.TB) []Corpus {
+	corpora, err := LoadCorpora(os.Getenv("LEPTJSON_CORPUS_DIR"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return corpora
+}
+
+// canonical 用 encoding/json 重新解析序列化结果，便于比较不同库的输出
+func canonical(t *testing.T, data interface{}) interface{} {
+	var text []byte
+	switch d := data.(type) {
+	case string:
+		text = []byte(d)
+	case []byte:
+		text = d
+	default:
+		t.Fatalf("未知的序列化结果类型 %T", data)
+	}
+	var doc interface{}
+	if err := json.Unmarshal(text, &doc); err != nil {
+		t.Fatalf("序列化结果不是合法的JSON: %v", err)
+	}
+	return doc
+}
+
+func TestCorporaAreValidJSON(t *testing.T) {
+	for _, corpus := range loadTestCorpora(t) {
+		if !json.Valid(corpus.Data) {
+			t.Errorf("语料 %s 不是合法的JSON", corpus.Name)
+		}
+		if len(corpus.Data) < 100*1024 {
+			t.Errorf("语料 %s 过小: %d 字节", corpus.Name, len(corpus.Data))
+		}
+	}
+}
+
+func TestSyntheticCorporaAreDeterministic(t *testing.T) {
+	for _, name := range StandardCorpusNames {
+		if generateCorpus(name) != generateCorpus(name) {
+			t.Errorf("语料 %s 的生成结果不稳定", name)
+		}
+	}
+}
+
+func TestLoadCorporaFromDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "canada.json"), []byte(`{"type":"FeatureCollection"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	corpora, err := LoadCorpora(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, corpus := range corpora {
+		if fromDir := corpus.Name == "canada"; corpus.Synthetic == fromDir {
+			t.Errorf("语料 %s 的来源错误: Synthetic=%v", corpus.Name, corpus.Synthetic)
+		}
+	}
+}
+
+func TestCodecsAgree(t *testing.T) {
+	for _, corpus := range loadTestCorpora(t) {
+		var expected interface{}
+		for _, codec := range Codecs {
+			doc, err := codec.Parse(&corpus)
+			if err != nil {
+				t.Fatalf("%s 解析 %s 失败: %v", codec.Name, corpus.Name, err)
+			}
+			out, err := codec.Stringify(doc)
+			if err != nil {
+				t.Fatalf("%s 序列化 %s 失败: %v", codec.Name, corpus.Name, err)
+			}
+			got := canonical(t, out)
+			if expected == nil {
+				expected = got
+			} else if !reflect.DeepEqual(got, expected) {
+				t.Errorf("%s 对 %s 的往返结果与其他库不一致", codec.Name, corpus.Name)
+			}
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip("跳过耗时的基准测试")
+	}
+	corpus := newCorpus("small", `{"a":[1,2,3],"b":"x"}`, true)
+	results, err := Run([]Corpus{corpus}, Codecs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2*len(Codecs) {
+		t.Fatalf("结果数量 = %d, 期望 %d", len(results), 2*len(Codecs))
+	}
+	for _, r := range results {
+		if r.Corpus != "small" || r.Iterations == 0 || r.NsPerOp <= 0 || r.MBPerSec <= 0 {
+			t.Errorf("结果不完整: %+v", r)
+		}
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	for _, corpus := range loadTestCorpora(b) {
+		corpus := corpus
+		for _, codec := range Codecs {
+			b.Run(corpus.Name+"/"+codec.Name, func(b *testing.B) {
+				MeasureParse(b, codec, &corpus)
+			})
+		}
+	}
+}
+
+func BenchmarkStringify(b *testing.B) {
+	for _, corpus := range loadTestCorpora(b) {
+		corpus := corpus
+		for _, codec := range Codecs {
+			doc, err := codec.Parse(&corpus)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.Run(corpus.Name+"/"+codec.Name, func(b *testing.B) {
+				MeasureStringify(b, codec, &corpus, doc)
+			})
+		}
+	}
+}
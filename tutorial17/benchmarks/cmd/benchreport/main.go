@@ -0,0 +1,56 @@
+// benchreport 运行基准测试并以JSON格式输出结果表
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/Cactusinhand/go-json-tutorial/tutorial17/benchmarks"
+)
+
+func main() {
+	output := flag.String("o", "", "结果输出文件，默认输出到标准输出")
+	corpusDir := flag.String("corpus-dir", os.Getenv("LEPTJSON_CORPUS_DIR"), "真实语料所在目录，缺少的语料使用生成的替代语料")
+	flag.Parse()
+
+	corpora, err := benchmarks.LoadCorpora(*corpusDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "加载语料失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	results, err := benchmarks.Run(corpora, benchmarks.Codecs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "基准测试失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	// 人类可读的表格输出到标准错误，不影响JSON结果
+	tw := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "语料\t库\t操作\tns/op\tMB/s\tallocs/op\tB/op\t")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%.2f\t%d\t%d\t\n",
+			r.Corpus, r.Codec, r.Operation, r.NsPerOp, r.MBPerSec, r.AllocsPerOp, r.BytesPerOp)
+	}
+	tw.Flush()
+
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "生成JSON失败: %v\n", err)
+		os.Exit(1)
+	}
+	data = append(data, '\n')
+
+	if *output == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*output, data, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "写入结果失败: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "结果已写入 %s\n", *output)
+}
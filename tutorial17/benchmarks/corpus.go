@@ -0,0 +1,187 @@
+// corpus.go - 基准测试使用的JSON语料
+package benchmarks
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Corpus 是一份用于基准测试的JSON文档
+type Corpus struct {
+	Name      string // 语料名称，如 twitter
+	Text      string // JSON文本
+	Data      []byte // 与 Text 相同的内容，供以 []byte 为输入的库使用
+	Synthetic bool   // 是否为生成的语料
+}
+
+// StandardCorpusNames 是 nativejson-benchmark 中使用的标准语料
+var StandardCorpusNames = []string{"twitter", "canada", "citm_catalog"}
+
+// LoadCorpora 加载标准语料
+//
+// dir 中存在 twitter.json、canada.json、citm_catalog.json 时使用这些文件
+// （可从 https://github.com/miloyip/nativejson-benchmark/tree/master/data 下载），
+// 否则使用结构相同、内容由固定种子生成的替代语料，保证结果可重复。
+func LoadCorpora(dir string) ([]Corpus, error) {
+	var corpora []Corpus
+	for _, name := range StandardCorpusNames {
+		if dir != "" {
+			data, err := os.ReadFile(filepath.Join(dir, name+".json"))
+			if err == nil {
+				corpora = append(corpora, newCorpus(name, string(data), false))
+				continue
+			}
+			if !os.IsNotExist(err) {
+				return nil, fmt.Errorf("读取语料 %s 失败: %w", name, err)
+			}
+		}
+		corpora = append(corpora, newCorpus(name, generateCorpus(name), true))
+	}
+	return corpora, nil
+}
+
+// newCorpus 创建语料
+func newCorpus(name, text string, synthetic bool) Corpus {
+	return Corpus{Name: name, Text: text, Data: []byte(text), Synthetic: synthetic}
+}
+
+// generateCorpus 按名称生成替代语料
+func generateCorpus(name string) string {
+	rng := rand.New(rand.NewSource(42))
+	switch name {
+	case "twitter":
+		return generateTwitter(rng)
+	case "canada":
+		return generateCanada(rng)
+	default:
+		return generateCitmCatalog(rng)
+	}
+}
+
+// generateTwitter 生成类似 twitter.json 的语料：大量字符串和嵌套对象，包含非ASCII文本和转义
+func generateTwitter(rng *rand.Rand) string {
+	words := []string{"golang", "json", "解析器", "性能", "テスト", "benchmark", "\\\"quoted\\\"", "emoji \\ud83d\\ude00", "line\\nbreak", "data"}
+	sentence := func(n int) string {
+		parts := make([]string, n)
+		for i := range parts {
+			parts[i] = words[rng.Intn(len(words))]
+		}
+		return strings.Join(parts, " ")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`{"statuses":[`)
+	for i := 0; i < 100; i++ {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		id := 505874924095815681 + int64(i)*7919
+		userID := 1186275104 + int64(rng.Intn(100000))
+		fmt.Fprintf(&sb, `{"metadata":{"result_type":"recent","iso_language_code":"ja"},`+
+			`"created_at":"Sun Aug 31 00:29:%02d +0000 2014","id":%d,"id_str":"%d","text":"%s",`+
+			`"source":"<a href=\"http://twitter.com/download/iphone\" rel=\"nofollow\">Twitter for iPhone</a>",`+
+			`"truncated":false,"in_reply_to_status_id":null,"in_reply_to_user_id":null,`+
+			`"user":{"id":%d,"id_str":"%d","name":"%s","screen_name":"user_%d","location":"","description":"%s",`+
+			`"url":null,"protected":false,"followers_count":%d,"friends_count":%d,"listed_count":%d,`+
+			`"favourites_count":%d,"utc_offset":null,"time_zone":null,"geo_enabled":%t,"verified":%t,`+
+			`"statuses_count":%d,"lang":"ja","profile_background_color":"C0DEED","default_profile":true},`+
+			`"geo":null,"coordinates":null,"place":null,"retweet_count":%d,"favorite_count":%d,`+
+			`"entities":{"hashtags":[{"text":"%s","indices":[%d,%d]}],"symbols":[],"urls":[],"user_mentions":[]},`+
+			`"favorited":false,"retweeted":false,"lang":"ja"}`,
+			i%60, id, id, sentence(12+rng.Intn(10)),
+			userID, userID, sentence(2), i, sentence(8),
+			rng.Intn(10000), rng.Intn(5000), rng.Intn(100),
+			rng.Intn(2000), rng.Intn(2) == 0, rng.Intn(10) == 0,
+			rng.Intn(50000), rng.Intn(100), rng.Intn(100),
+			words[rng.Intn(len(words))], rng.Intn(20), 20+rng.Intn(20))
+	}
+	sb.WriteString(`],"search_metadata":{"completed_in":0.087,"max_id":505874924095815681,` +
+		`"max_id_str":"505874924095815681","next_results":"?max_id=505874847260352512&q=%E4%B8%80&count=100",` +
+		`"query":"%E4%B8%80","count":100,"since_id":0,"since_id_str":"0"}}`)
+	return sb.String()
+}
+
+// generateCanada 生成类似 canada.json 的语料：以大量高精度浮点数为主的 GeoJSON
+func generateCanada(rng *rand.Rand) string {
+	var sb strings.Builder
+	sb.WriteString(`{"type":"FeatureCollection","features":[{"type":"Feature","properties":{"name":"Canada"},` +
+		`"geometry":{"type":"Polygon","coordinates":[`)
+	for ring := 0; ring < 60; ring++ {
+		if ring > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteByte('[')
+		lon, lat := -141.0+rng.Float64()*88, 42.0+rng.Float64()*40
+		for point := 0; point < 300; point++ {
+			if point > 0 {
+				sb.WriteByte(',')
+			}
+			lon += (rng.Float64() - 0.5) * 0.01
+			lat += (rng.Float64() - 0.5) * 0.01
+			sb.WriteByte('[')
+			sb.WriteString(strconv.FormatFloat(lon, 'f', 15, 64))
+			sb.WriteByte(',')
+			sb.WriteString(strconv.FormatFloat(lat, 'f', 15, 64))
+			sb.WriteByte(']')
+		}
+		sb.WriteByte(']')
+	}
+	sb.WriteString(`]}}]}`)
+	return sb.String()
+}
+
+// generateCitmCatalog 生成类似 citm_catalog.json 的语料：以整数ID为键的大对象和大量重复的键
+func generateCitmCatalog(rng *rand.Rand) string {
+	var sb strings.Builder
+
+	sb.WriteString(`{"areaNames":{`)
+	for i := 0; i < 20; i++ {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		fmt.Fprintf(&sb, `"%d":"Arrière-scène %d"`, 205705993+i, i)
+	}
+
+	sb.WriteString(`},"audienceSubCategoryNames":{"337100890":"Abonné"},"blockNames":{},"events":{`)
+	for i := 0; i < 180; i++ {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		id := 138586341 + i*13
+		fmt.Fprintf(&sb, `"%d":{"description":null,"id":%d,"logo":"/images/UE0AAAAACEKo6QAAAAZDSVRN","name":"Event %d",`+
+			`"subTopicIds":[337184269,337184283,%d],"subjectCode":null,"subtitle":null,"topicIds":[324846099,%d]}`,
+			id, id, i, 337184262+rng.Intn(30), 107888604+rng.Intn(10))
+	}
+
+	sb.WriteString(`},"performances":[`)
+	for i := 0; i < 240; i++ {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		fmt.Fprintf(&sb, `{"eventId":%d,"id":%d,"logo":null,"name":null,"prices":[`, 138586341+rng.Intn(180)*13, 339887544+i)
+		for p := 0; p < 3; p++ {
+			if p > 0 {
+				sb.WriteByte(',')
+			}
+			fmt.Fprintf(&sb, `{"amount":%d,"audienceSubCategoryId":337100890,"seatCategoryId":%d}`, 10000+rng.Intn(90000), 338937295+p)
+		}
+		sb.WriteString(`],"seatCategories":[`)
+		for s := 0; s < 3; s++ {
+			if s > 0 {
+				sb.WriteByte(',')
+			}
+			fmt.Fprintf(&sb, `{"areas":[{"areaId":%d,"blockIds":[]},{"areaId":%d,"blockIds":[]}],"seatCategoryId":%d}`,
+				205705993+rng.Intn(20), 205705993+rng.Intn(20), 338937295+s)
+		}
+		fmt.Fprintf(&sb, `],"seatMapImage":null,"start":%d,"venueCode":"PLEYEL_PLEYEL"}`, 1372701600000+int64(i)*86400000)
+	}
+
+	sb.WriteString(`],"seatCategoryNames":{"338937295":"1ère catégorie"},"subTopicNames":{"337184269":"Classique"},` +
+		`"subjectNames":{},"topicNames":{"107888604":"Activité","324846099":"Genre"},"topicSubTopics":{"107888604":[107888604]},` +
+		`"venueNames":{"PLEYEL_PLEYEL":"Salle Pleyel"}}`)
+	return sb.String()
+}
@@ -0,0 +1,355 @@
+// binary_format.go - 解析结果的紧凑二进制格式，用于持久化和快速加载
+package leptjson
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// binaryMagic 是二进制文档开头的标识和格式版本
+const binaryMagic = "LJB\x01"
+
+// 二进制格式中值的类型标签
+const (
+	binaryNull   byte = iota
+	binaryFalse       // false
+	binaryTrue        // true
+	binaryFloat       // 8 字节小端序的 float64
+	binaryInt         // zigzag 编码的 varint，用于可以精确表示的整数
+	binaryString      // 字符串表中的下标
+	binaryArray       // 元素个数，之后是每个元素
+	binaryObject      // 成员个数，之后是每个成员的键在字符串表中的下标和值
+)
+
+// binaryMaxDepth 是 Load 接受的最大嵌套深度，防止损坏的输入耗尽栈
+const binaryMaxDepth = 10000
+
+// ErrBinaryFormat 表示 Load 的输入不是有效的二进制文档，可以用 errors.Is 判断
+var ErrBinaryFormat = errors.New("无效的二进制文档")
+
+// Save 将 v 以二进制格式写入 w
+//
+// 格式由标识、节点数量、字符串表和值组成：对象的键和字符串值去重后保存在
+// 字符串表中，值按类型标签、varint 长度和字符串表下标编码，整数使用 varint。
+// Load 根据节点数量一次分配所有的值，不需要逐个分配。用 Load
+// 读回二进制文档比重新解析 JSON 快得多，适合缓存解析结果以便快速预热。
+func Save(v *Value, w io.Writer) error {
+	if v == nil {
+		v = &Value{}
+	}
+	e := &binaryEncoder{index: make(map[string]uint64)}
+	e.collect(v)
+
+	e.buf = append(e.buf, binaryMagic...)
+	e.putUvarint(uint64(e.elems))
+	e.putUvarint(uint64(e.members))
+	e.putUvarint(uint64(len(e.table)))
+	for _, s := range e.table {
+		e.putUvarint(uint64(len(s)))
+		e.buf = append(e.buf, s...)
+	}
+	e.value(v)
+	_, err := w.Write(e.buf)
+	return err
+}
+
+// binaryEncoder 保存编码中的字符串表和输出
+type binaryEncoder struct {
+	table   []string          // 按第一次出现的顺序排列的字符串
+	index   map[string]uint64 // 字符串在表中的下标
+	elems   int               // 所有数组的元素总数
+	members int               // 所有对象的成员总数
+	buf     []byte
+}
+
+// collect 将 v 中所有的键和字符串加入字符串表，并统计元素和成员的数量
+func (e *binaryEncoder) collect(v *Value) {
+	switch v.Type {
+	case STRING:
+		e.intern(GetString(v))
+	case ARRAY:
+		e.elems += len(v.A)
+		for _, elem := range v.A {
+			e.collect(elem)
+		}
+	case OBJECT:
+		e.members += len(v.O)
+		for _, m := range v.O {
+			e.intern(m.K)
+			e.collect(m.V)
+		}
+	}
+}
+
+func (e *binaryEncoder) intern(s string) {
+	if _, ok := e.index[s]; !ok {
+		e.index[s] = uint64(len(e.table))
+		e.table = append(e.table, s)
+	}
+}
+
+func (e *binaryEncoder) putUvarint(x uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], x)
+	e.buf = append(e.buf, tmp[:n]...)
+}
+
+// value 编码 v
+func (e *binaryEncoder) value(v *Value) {
+	switch v.Type {
+	case FALSE:
+		e.buf = append(e.buf, binaryFalse)
+	case TRUE:
+		e.buf = append(e.buf, binaryTrue)
+	case NUMBER:
+		// 只有绝对值不超过 2^53 的整数才能在 float64 和 int64 之间无损转换；-0 保留为浮点数
+		if v.N == math.Trunc(v.N) && math.Abs(v.N) <= 1<<53 && !(v.N == 0 && math.Signbit(v.N)) {
+			var tmp [binary.MaxVarintLen64]byte
+			n := binary.PutVarint(tmp[:], int64(v.N))
+			e.buf = append(e.buf, binaryInt)
+			e.buf = append(e.buf, tmp[:n]...)
+		} else {
+			var tmp [8]byte
+			binary.LittleEndian.PutUint64(tmp[:], math.Float64bits(v.N))
+			e.buf = append(e.buf, binaryFloat)
+			e.buf = append(e.buf, tmp[:]...)
+		}
+	case STRING:
+		e.buf = append(e.buf, binaryString)
+		e.putUvarint(e.index[GetString(v)])
+	case ARRAY:
+		e.buf = append(e.buf, binaryArray)
+		e.putUvarint(uint64(len(v.A)))
+		for _, elem := range v.A {
+			e.value(elem)
+		}
+	case OBJECT:
+		e.buf = append(e.buf, binaryObject)
+		e.putUvarint(uint64(len(v.O)))
+		for _, m := range v.O {
+			e.putUvarint(e.index[m.K])
+			e.value(m.V)
+		}
+	default:
+		e.buf = append(e.buf, binaryNull)
+	}
+}
+
+// Load 读取 Save 写入的二进制文档
+//
+// 输入损坏或被截断时返回的错误满足 errors.Is(err, ErrBinaryFormat)。
+func Load(r io.Reader) (*Value, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, []byte(binaryMagic)) {
+		if len(data) < len(binaryMagic) && bytes.HasPrefix([]byte(binaryMagic), data) {
+			return nil, errBinaryTruncated
+		}
+		return nil, fmt.Errorf("%w: 文件标识不匹配", ErrBinaryFormat)
+	}
+	d := &binaryDecoder{data: data, pos: len(binaryMagic)}
+
+	// 所有的值、数组元素指针和对象成员各自一次分配，解码时依次取用
+	elems, err := d.count()
+	if err != nil {
+		return nil, err
+	}
+	members, err := d.count()
+	if err != nil {
+		return nil, err
+	}
+	if elems+members > len(d.data)-d.pos {
+		// 每个节点至少占一个字节
+		return nil, errBinaryTruncated
+	}
+	d.values = make([]Value, elems+members+1)
+	d.pointers = make([]*Value, elems)
+	d.members = make([]Member, members)
+
+	count, err := d.count()
+	if err != nil {
+		return nil, err
+	}
+	d.table = make([]string, count)
+	for i := range d.table {
+		length, err := d.uvarint()
+		if err != nil {
+			return nil, err
+		}
+		if length > uint64(len(d.data)-d.pos) {
+			return nil, errBinaryTruncated
+		}
+		d.table[i] = string(d.data[d.pos : d.pos+int(length)])
+		d.pos += int(length)
+	}
+
+	v := d.alloc()
+	if err := d.value(v, 0); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// errBinaryTruncated 表示输入在文档结束之前就结束了
+var errBinaryTruncated = fmt.Errorf("%w: 输入被截断", ErrBinaryFormat)
+
+// binaryDecoder 保存解码中的输入和字符串表
+type binaryDecoder struct {
+	data     []byte
+	pos      int
+	table    []string
+	values   []Value  // 还没有使用的值
+	pointers []*Value // 还没有使用的数组元素指针
+	members  []Member // 还没有使用的对象成员
+}
+
+// alloc 取出一个值，头部记录的数量与实际不符时单独分配
+func (d *binaryDecoder) alloc() *Value {
+	if len(d.values) == 0 {
+		return &Value{}
+	}
+	v := &d.values[0]
+	d.values = d.values[1:]
+	return v
+}
+
+// takePointers 从 pool 中取出 n 个指针，不够时单独分配
+//
+// 返回的切片容量限制为 n，调用者之后追加元素时不会覆盖其他数组的部分。
+func takePointers(pool *[]*Value, n int) []*Value {
+	if len(*pool) < n {
+		return make([]*Value, n)
+	}
+	s := (*pool)[:n:n]
+	*pool = (*pool)[n:]
+	return s
+}
+
+func (d *binaryDecoder) uvarint() (uint64, error) {
+	x, n := binary.Uvarint(d.data[d.pos:])
+	if n <= 0 {
+		return 0, d.varintError(n)
+	}
+	d.pos += n
+	return x, nil
+}
+
+// varintError 返回 varint 读取失败的原因，n 为 0 时输入被截断，否则数值溢出
+func (d *binaryDecoder) varintError(n int) error {
+	if n == 0 {
+		return errBinaryTruncated
+	}
+	return fmt.Errorf("%w: 位置 %d 的数值溢出", ErrBinaryFormat, d.pos)
+}
+
+// count 读取元素个数，每个元素至少占一个字节，超过剩余输入的个数一定是损坏的
+func (d *binaryDecoder) count() (int, error) {
+	x, err := d.uvarint()
+	if err != nil {
+		return 0, err
+	}
+	if x > uint64(len(d.data)-d.pos) {
+		return 0, errBinaryTruncated
+	}
+	return int(x), nil
+}
+
+// lookup 返回字符串表中的字符串
+func (d *binaryDecoder) lookup() (string, error) {
+	i, err := d.uvarint()
+	if err != nil {
+		return "", err
+	}
+	if i >= uint64(len(d.table)) {
+		return "", fmt.Errorf("%w: 字符串下标 %d 超出范围", ErrBinaryFormat, i)
+	}
+	return d.table[i], nil
+}
+
+// value 解码一个值写入 v
+func (d *binaryDecoder) value(v *Value, depth int) error {
+	if depth > binaryMaxDepth {
+		return fmt.Errorf("%w: 嵌套深度超过 %d", ErrBinaryFormat, binaryMaxDepth)
+	}
+	if d.pos >= len(d.data) {
+		return errBinaryTruncated
+	}
+	tag := d.data[d.pos]
+	d.pos++
+	switch tag {
+	case binaryNull:
+		v.Type = NULL
+	case binaryFalse:
+		v.Type = FALSE
+	case binaryTrue:
+		v.Type = TRUE
+	case binaryFloat:
+		if len(d.data)-d.pos < 8 {
+			return errBinaryTruncated
+		}
+		v.Type, v.N = NUMBER, math.Float64frombits(binary.LittleEndian.Uint64(d.data[d.pos:]))
+		d.pos += 8
+	case binaryInt:
+		x, n := binary.Varint(d.data[d.pos:])
+		if n <= 0 {
+			return d.varintError(n)
+		}
+		d.pos += n
+		v.Type, v.N = NUMBER, float64(x)
+	case binaryString:
+		s, err := d.lookup()
+		if err != nil {
+			return err
+		}
+		v.Type, v.S = STRING, s
+	case binaryArray:
+		count, err := d.count()
+		if err != nil {
+			return err
+		}
+		v.Type, v.A = ARRAY, takePointers(&d.pointers, count)
+		for i := range v.A {
+			elem := d.alloc()
+			if err := d.value(elem, depth+1); err != nil {
+				return err
+			}
+			v.A[i] = elem
+		}
+	case binaryObject:
+		count, err := d.count()
+		if err != nil {
+			return err
+		}
+		v.Type, v.O = OBJECT, takeMembers(&d.members, count)
+		for i := range v.O {
+			key, err := d.lookup()
+			if err != nil {
+				return err
+			}
+			member := d.alloc()
+			if err := d.value(member, depth+1); err != nil {
+				return err
+			}
+			v.O[i] = Member{K: key, V: member}
+		}
+	default:
+		return fmt.Errorf("%w: 未知的类型标签 %d", ErrBinaryFormat, tag)
+	}
+	return nil
+}
+
+// takeMembers 从 pool 中取出 n 个成员，不够时单独分配
+func takeMembers(pool *[]Member, n int) []Member {
+	if len(*pool) < n {
+		return make([]Member, n)
+	}
+	s := (*pool)[:n:n]
+	*pool = (*pool)[n:]
+	return s
+}
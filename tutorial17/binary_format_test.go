@@ -0,0 +1,119 @@
+package leptjson
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	tests := []string{
+		`null`,
+		`true`,
+		`"hello"`,
+		`[]`,
+		`{}`,
+		`[0,-1,1.5,-0.25,9007199254740992,9007199254740993,1e300,-1e-300,123456789012]`,
+		`{"a":{"a":["a","b","a"]},"b":null,"":false,"中文":"😀"}`,
+		largeArray(100),
+	}
+	for _, json := range tests {
+		v := &Value{}
+		if err := Parse(v, json); err != PARSE_OK {
+			t.Fatalf("解析 %s: %v", json, err)
+		}
+		var buf bytes.Buffer
+		if err := Save(v, &buf); err != nil {
+			t.Fatalf("Save(%s): %v", json, err)
+		}
+		loaded, err := Load(&buf)
+		if err != nil {
+			t.Fatalf("Load(%s): %v", json, err)
+		}
+		if !Equal(v, loaded) {
+			want, _ := Stringify(v)
+			got, _ := Stringify(loaded)
+			t.Errorf("往返后 %s != %s", got, want)
+		}
+	}
+
+	// -0 保留符号
+	var buf bytes.Buffer
+	Save(&Value{Type: NUMBER, N: math.Copysign(0, -1)}, &buf)
+	if v, _ := Load(&buf); !math.Signbit(v.N) {
+		t.Error("-0 的符号丢失了")
+	}
+}
+
+func TestSaveIsCompact(t *testing.T) {
+	v := &Value{}
+	Parse(v, largeArray(1000))
+	var buf bytes.Buffer
+	Save(v, &buf)
+	json, _ := Stringify(v)
+	// 重复的键和字符串只保存一次
+	if buf.Len() >= len(json) {
+		t.Errorf("二进制 %d 字节, JSON %d 字节", buf.Len(), len(json))
+	}
+}
+
+func TestLoadInvalidInput(t *testing.T) {
+	v := &Value{}
+	Parse(v, `{"a":[1,"x",2.5]}`)
+	var buf bytes.Buffer
+	Save(v, &buf)
+	data := buf.Bytes()
+
+	// 任何位置截断都应当报告错误
+	for i := 0; i < len(data); i++ {
+		if _, err := Load(bytes.NewReader(data[:i])); !errors.Is(err, ErrBinaryFormat) {
+			t.Errorf("截断到 %d 字节: %v", i, err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"标识不匹配", `{"a":1}`, "文件标识不匹配"},
+		{"未知的类型标签", binaryMagic + "\x00\x00\x00\x09", "未知的类型标签"},
+		{"字符串下标越界", binaryMagic + "\x00\x00\x00\x05\x03", "字符串下标 3 超出范围"},
+		{"嵌套过深", binaryMagic + "\x00\x00\x00" + strings.Repeat("\x06\x01", binaryMaxDepth+2), "嵌套深度超过"},
+		{"长度超出输入", binaryMagic + "\xff\xff\xff\xff\x0f", "输入被截断"},
+	}
+	for _, tt := range tests {
+		_, err := Load(strings.NewReader(tt.data))
+		if !errors.Is(err, ErrBinaryFormat) || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+	}
+}
+
+func BenchmarkLoad(b *testing.B) {
+	v := &Value{}
+	Parse(v, largeArray(10000))
+	var buf bytes.Buffer
+	Save(v, &buf)
+	data := buf.Bytes()
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Load(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseForLoadComparison(b *testing.B) {
+	json := largeArray(10000)
+	b.SetBytes(int64(len(json)))
+	for i := 0; i < b.N; i++ {
+		v := &Value{}
+		if err := Parse(v, json); err != PARSE_OK {
+			b.Fatal(err)
+		}
+	}
+}
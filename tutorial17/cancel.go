@@ -0,0 +1,83 @@
+// cancel.go - 可以通过 context 取消的序列化
+package leptjson
+
+import (
+	"bytes"
+	"context"
+)
+
+// cancelCheckInterval 是两次检查取消信号之间处理的值的数量
+const cancelCheckInterval = 1024
+
+// isDone 判断取消信号是否已经关闭
+func isDone(done <-chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
+
+// StringifyContext 将Value转换为JSON字符串，ctx 被取消或超时后返回 STRINGIFY_CANCELLED
+func StringifyContext(ctx context.Context, v *Value) (string, StringifyError) {
+	if ctx.Err() != nil {
+		return "", STRINGIFY_CANCELLED
+	}
+	if v == nil {
+		return "", STRINGIFY_OK
+	}
+	done := ctx.Done()
+	if done == nil {
+		return Stringify(v)
+	}
+
+	s := &cancellableStringifier{done: done}
+	if !s.value(v) {
+		return "", STRINGIFY_CANCELLED
+	}
+	return s.buffer.String(), STRINGIFY_OK
+}
+
+// cancellableStringifier 与 stringifyValue 相同，但定期检查取消信号
+type cancellableStringifier struct {
+	buffer bytes.Buffer
+	done   <-chan struct{}
+	values int
+}
+
+// value 写入 v，被取消时返回 false
+func (s *cancellableStringifier) value(v *Value) bool {
+	if s.values++; s.values%cancelCheckInterval == 0 && isDone(s.done) {
+		return false
+	}
+	switch v.Type {
+	case ARRAY:
+		s.buffer.WriteByte('[')
+		for i, elem := range v.A {
+			if i > 0 {
+				s.buffer.WriteByte(',')
+			}
+			if !s.value(elem) {
+				return false
+			}
+		}
+		s.buffer.WriteByte(']')
+	case OBJECT:
+		s.buffer.WriteByte('{')
+		for i, member := range v.O {
+			if i > 0 {
+				s.buffer.WriteByte(',')
+			}
+			stringifyString(member.K, &s.buffer)
+			s.buffer.WriteByte(':')
+			if !s.value(member.V) {
+				return false
+			}
+		}
+		s.buffer.WriteByte('}')
+	default:
+		stringifyValue(v, &s.buffer)
+	}
+	return true
+}
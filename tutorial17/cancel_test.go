@@ -0,0 +1,106 @@
+package leptjson
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// cancelAfterContext 第一次调用 Err 时还没有被取消，之后的调用返回 context.Canceled，
+// 用来模拟在处理过程中被取消
+type cancelAfterContext struct {
+	context.Context
+	done  chan struct{}
+	calls int
+}
+
+func newCancelAfterContext() *cancelAfterContext {
+	done := make(chan struct{})
+	close(done)
+	return &cancelAfterContext{Context: context.Background(), done: done}
+}
+
+func (c *cancelAfterContext) Done() <-chan struct{} { return c.done }
+
+func (c *cancelAfterContext) Err() error {
+	c.calls++
+	if c.calls == 1 {
+		return nil
+	}
+	return context.Canceled
+}
+
+// largeArray 返回有 n 个对象元素的数组
+func largeArray(n int) string {
+	return "[" + strings.Repeat(`{"a":[1,"x"]},`, n-1) + `{"a":[1,"x"]}]`
+}
+
+func TestParseContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	v := &Value{}
+	if err := ParseContext(ctx, v, `[1,2]`); err != PARSE_CANCELLED || v.Type != NULL {
+		t.Errorf("已经取消的 context: %v, %v", err, v.Type)
+	}
+
+	// 解析过程中被取消
+	if err := ParseContext(newCancelAfterContext(), v, largeArray(1000)); err != PARSE_CANCELLED {
+		t.Errorf("解析过程中取消: %v", err)
+	}
+	// 值的数量少于检查间隔时来不及检查
+	if err := ParseContext(newCancelAfterContext(), v, `{"a":[1,2,3]}`); err != PARSE_OK {
+		t.Errorf("小文档: %v", err)
+	}
+	if err := ParseContext(context.Background(), v, largeArray(1000)); err != PARSE_OK {
+		t.Errorf("没有取消: %v", err)
+	}
+	if PARSE_CANCELLED.Error() != "解析被取消" {
+		t.Errorf("错误描述 = %q", PARSE_CANCELLED.Error())
+	}
+}
+
+func TestStringifyContextCancel(t *testing.T) {
+	v := &Value{}
+	Parse(v, largeArray(1000))
+	expected, _ := Stringify(v)
+
+	if s, err := StringifyContext(context.Background(), v); err != STRINGIFY_OK || s != expected {
+		t.Errorf("没有取消时结果应当与 Stringify 相同: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if s, err := StringifyContext(ctx, v); err != STRINGIFY_OK || s != expected {
+		t.Errorf("可取消但没有取消的 context: %v", err)
+	}
+	cancel()
+	if _, err := StringifyContext(ctx, v); err != STRINGIFY_CANCELLED {
+		t.Errorf("已经取消的 context: %v", err)
+	}
+	if _, err := StringifyContext(newCancelAfterContext(), v); err != STRINGIFY_CANCELLED {
+		t.Errorf("序列化过程中取消: %v", err)
+	}
+}
+
+func TestQueryContextCancel(t *testing.T) {
+	doc := &Value{}
+	Parse(doc, largeArray(2000))
+
+	for _, options := range []JSONPathOptions{{}, {RFC9535: true}} {
+		for _, path := range []string{"$..a", "$[?(@.a)]", "$[*].a[0]"} {
+			if options.RFC9535 && path == "$[?(@.a)]" {
+				path = "$[?@.a]"
+			}
+			jp, err := NewJSONPathWithOptions(path, options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			results, err := jp.QueryContext(context.Background(), doc)
+			if err != nil || len(results) != 2000 {
+				t.Errorf("%s: 没有取消时结果数量 = %d, %v", path, len(results), err)
+			}
+			if _, err := jp.QueryContext(newCancelAfterContext(), doc); !errors.Is(err, context.Canceled) {
+				t.Errorf("%s: 查询过程中取消 error = %v", path, err)
+			}
+		}
+	}
+}
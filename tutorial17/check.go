@@ -0,0 +1,268 @@
+// check.go - 检查JSON文件是否有效并且符合项目的规范格式
+package leptjson
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CheckResult 是检查一个文件的结果
+type CheckResult struct {
+	File     string
+	Config   ProjectConfig // 检查时使用的配置
+	Err      error         // 无法读取、解析文件或配置时的错误
+	Expected string        // 规范格式的文件内容
+	Diff     string        // 当前内容与规范格式之间的统一格式差异，符合规范时为空
+}
+
+// OK 判断文件是否有效并且符合规范格式
+func (r CheckResult) OK() bool {
+	return r.Err == nil && r.Diff == ""
+}
+
+// CheckFile 检查文件是否是有效的JSON，并且与按项目配置格式化的结果完全相同
+//
+// 配置从文件所在的目录开始向上查找 .leptjsonrc，找不到时使用默认配置。
+func CheckFile(filename string) CheckResult {
+	result := CheckResult{File: filename}
+	result.Config, result.Err = LoadProjectConfig(filepath.Dir(filename))
+	if result.Err != nil {
+		return result
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		result.Err = fmt.Errorf("无法打开文件: %w", err)
+		return result
+	}
+	v := &Value{}
+	if err := Parse(v, string(data)); err != PARSE_OK {
+		result.Err = fmt.Errorf("解析JSON失败: %s", err)
+		return result
+	}
+	result.Expected = result.Config.Format(v)
+	if string(data) != result.Expected {
+		result.Diff = UnifiedDiff(filename, filename+" (规范格式)", string(data), result.Expected, 3)
+	}
+	return result
+}
+
+// diffOp 是行差异中的一行，kind 为 ' '、'-' 或 '+'
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// maxDiffCells 是计算最长公共子序列时表格的最大单元数，超过时把中间部分整体视为替换
+const maxDiffCells = 1 << 22
+
+// diffLines 计算把 a 变为 b 的最少行修改
+func diffLines(a, b []string) []diffOp {
+	// 去掉相同的开头和结尾，格式化前后通常只有少数几处不同
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []diffOp
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ma, mb := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if (len(ma)+1)*(len(mb)+1) > maxDiffCells {
+		for _, line := range ma {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range mb {
+			ops = append(ops, diffOp{'+', line})
+		}
+	} else {
+		ops = append(ops, lcsDiff(ma, mb)...)
+	}
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// lcsDiff 按最长公共子序列计算行差异
+func lcsDiff(a, b []string) []diffOp {
+	// lcs[i][j] 是 a[i:] 和 b[j:] 的最长公共子序列长度
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// splitDiffLines 按行拆分文本，最后一行没有换行符时加上 diff 惯用的提示
+func splitDiffLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if last := lines[len(lines)-1]; last == "" {
+		lines = lines[:len(lines)-1]
+	} else {
+		lines[len(lines)-1] = last + "\n\\ No newline at end of file\n"
+	}
+	return lines
+}
+
+// UnifiedDiff 返回把 a 变为 b 的统一格式差异，每处修改前后保留 context 行上下文；
+// 内容相同时返回空字符串
+func UnifiedDiff(nameA, nameB, a, b string, context int) string {
+	ops := diffLines(splitDiffLines(a), splitDiffLines(b))
+
+	var out strings.Builder
+	// 找出每一段修改，与相邻修改的距离不超过 2*context 行时合并为一个块
+	for start := 0; start < len(ops); {
+		if ops[start].kind == ' ' {
+			start++
+			continue
+		}
+		end := start
+		for k := start; k < len(ops) && k <= end+2*context; k++ {
+			if ops[k].kind != ' ' {
+				end = k
+			}
+		}
+		from, to := start-context, end+context+1
+		if from < 0 {
+			from = 0
+		}
+		if to > len(ops) {
+			to = len(ops)
+		}
+
+		// 块之前的行数决定了块在两个文件中的起始行号
+		lineA, lineB := 1, 1
+		for _, op := range ops[:from] {
+			if op.kind != '+' {
+				lineA++
+			}
+			if op.kind != '-' {
+				lineB++
+			}
+		}
+		countA, countB := 0, 0
+		for _, op := range ops[from:to] {
+			if op.kind != '+' {
+				countA++
+			}
+			if op.kind != '-' {
+				countB++
+			}
+		}
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", nameA, nameB)
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(lineA, countA), hunkRange(lineB, countB))
+		for _, op := range ops[from:to] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.line)
+		}
+		start = to
+	}
+	return out.String()
+}
+
+// hunkRange 格式化块头中的行范围，空范围的起始行号是它之前的一行
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// runCheck 实现check命令
+func runCheck(args []string, verbose bool) {
+	fix := false
+	var files []string
+	for _, arg := range args {
+		if arg == "--fix" {
+			fix = true
+			continue
+		}
+		files = append(files, arg)
+	}
+	if len(files) == 0 {
+		fmt.Println("错误: check命令需要至少一个文件参数")
+		fmt.Println("\n用法: leptjson check [--fix] FILE...")
+		return
+	}
+
+	failed := 0
+	for _, file := range files {
+		result := CheckFile(file)
+		switch {
+		case result.Err != nil:
+			fmt.Printf("%s: %s\n", file, result.Err)
+		case result.Diff != "" && fix:
+			if err := os.WriteFile(file, []byte(result.Expected), 0644); err != nil {
+				fmt.Printf("%s: 写入文件失败: %s\n", file, err)
+				failed++
+			} else {
+				fmt.Printf("%s: 已修正格式\n", file)
+			}
+			continue
+		case result.Diff != "":
+			fmt.Printf("%s: 不符合规范格式\n", file)
+			fmt.Print(result.Diff)
+		default:
+			if verbose {
+				fmt.Printf("%s: 通过\n", file)
+			}
+			continue
+		}
+		failed++
+	}
+	if failed > 0 {
+		if !fix {
+			fmt.Printf("%d 个文件未通过检查，格式问题可以用 leptjson check --fix 修正\n", failed)
+		}
+		exitCLI(1)
+	}
+}
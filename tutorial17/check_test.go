@@ -0,0 +1,86 @@
+package leptjson
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	if d := UnifiedDiff("a", "b", "x\ny\n", "x\ny\n", 3); d != "" {
+		t.Errorf("相同内容的差异 = %q", d)
+	}
+
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	b := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n"
+	want := "--- a\n+++ b\n" +
+		"@@ -1,6 +1,6 @@\n 1\n 2\n-3\n+three\n 4\n 5\n 6\n" +
+		"@@ -10,3 +10,4 @@\n 10\n 11\n 12\n+13\n"
+	if d := UnifiedDiff("a", "b", a, b, 3); d != want {
+		t.Errorf("UnifiedDiff =\n%s期望\n%s", d, want)
+	}
+
+	// 缺少结尾的换行符
+	want = "--- a\n+++ b\n@@ -1 +1 @@\n-}\n\\ No newline at end of file\n+}\n"
+	if d := UnifiedDiff("a", "b", "}", "}\n", 3); d != want {
+		t.Errorf("UnifiedDiff(缺少换行) =\n%q", d)
+	}
+}
+
+func TestCheckFile(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) string {
+		p := filepath.Join(root, name)
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	write(ProjectConfigName, `{"indent":2,"sortKeys":"alpha"}`)
+
+	good := write("good.json", "{\n  \"a\": 1,\n  \"b\": [\n    true\n  ]\n}\n")
+	if r := CheckFile(good); !r.OK() {
+		t.Errorf("good.json: %v\n%s", r.Err, r.Diff)
+	}
+
+	unsorted := write("unsorted.json", "{\n  \"b\": [\n    true\n  ],\n  \"a\": 1\n}")
+	r := CheckFile(unsorted)
+	if r.OK() || r.Err != nil || !strings.Contains(r.Diff, "+  \"a\": 1,") || !strings.Contains(r.Diff, "No newline") {
+		t.Errorf("unsorted.json 的差异:\n%s", r.Diff)
+	}
+	if r.Expected != "{\n  \"a\": 1,\n  \"b\": [\n    true\n  ]\n}\n" {
+		t.Errorf("规范格式 = %q", r.Expected)
+	}
+
+	if r := CheckFile(write("bad.json", `{"a":`)); r.Err == nil {
+		t.Error("无效的JSON应返回错误")
+	}
+	if r := CheckFile(filepath.Join(root, "missing.json")); r.Err == nil {
+		t.Error("不存在的文件应返回错误")
+	}
+}
+
+func TestFormatOutputPassesCheck(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, ProjectConfigName), []byte(`{"indent":4,"sortKeys":"alpha"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	input := filepath.Join(root, "input.json")
+	if err := os.WriteFile(input, []byte(`{"b":[true],"a":1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// format 使用与 check 相同的项目配置，输出的文件符合规范格式
+	defer func(saved ProjectConfig) { cliConfig = saved }(cliConfig)
+	config, err := LoadProjectConfig(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cliConfig = config
+	output := filepath.Join(root, "output.json")
+	runFormat([]string{"--quiet", input, output}, false)
+	if r := CheckFile(output); !r.OK() {
+		t.Errorf("format 的输出没有通过 check: %v\n%s", r.Err, r.Diff)
+	}
+}
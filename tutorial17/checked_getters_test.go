@@ -0,0 +1,44 @@
+package leptjson
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckedGetters(t *testing.T) {
+	f := &Value{}
+	SetBoolean(f, false)
+	if b, err := GetBooleanChecked(f); err != nil || b {
+		t.Errorf("GetBooleanChecked(false) = %v, %v", b, err)
+	}
+
+	n := &Value{}
+	SetNumber(n, 0)
+	_, err := GetBooleanChecked(n)
+	var wrongType *ErrWrongType
+	if !errors.As(err, &wrongType) {
+		t.Fatalf("非布尔值应当返回 *ErrWrongType, 实际为 %v", err)
+	}
+	if wrongType.Expected != "boolean" || wrongType.Actual != NUMBER {
+		t.Errorf("错误信息不正确: %+v", wrongType)
+	}
+	if err.Error() != "类型错误: 期望 boolean, 实际为 number" {
+		t.Errorf("错误消息 = %q", err.Error())
+	}
+
+	if num, err := GetNumberChecked(n); err != nil || num != 0 {
+		t.Errorf("GetNumberChecked(0) = %v, %v", num, err)
+	}
+	if _, err := GetNumberChecked(f); err == nil {
+		t.Error("GetNumberChecked(false) 应当返回错误")
+	}
+
+	s := &Value{}
+	SetString(s, "")
+	if str, err := GetStringChecked(s); err != nil || str != "" {
+		t.Errorf("GetStringChecked(\"\") = %q, %v", str, err)
+	}
+	if _, err := GetStringChecked(&Value{Type: NULL}); err == nil {
+		t.Error("GetStringChecked(null) 应当返回错误")
+	}
+}
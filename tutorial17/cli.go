@@ -1,18 +1,23 @@
 package leptjson
 
 import (
+	"context"
 	"encoding/csv"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"math"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 // 命令行工具的版本号
@@ -31,17 +36,22 @@ type JSONStats struct {
 	KeyCount     int    // 键的总数
 	MaxKeyLength int    // 最长键的长度
 	LongestKey   string // 最长的键
+
+	UniqueKeyCount   int // 不同的键的数量
+	InternedKeyBytes int // 键驻留节省的字节数（重复出现的键不再单独占用内存）
 }
 
-// 计算JSON的统计信息
-func calculateStats(v *Value) JSONStats {
+// CalculateStats 计算JSON的统计信息，TotalSize 由调用者按输入的大小设置
+func CalculateStats(v *Value) JSONStats {
 	stats := JSONStats{}
-	calculateStatsRecursive(v, &stats, 0)
+	seenKeys := make(map[string]bool)
+	calculateStatsRecursive(v, &stats, 0, seenKeys)
+	stats.UniqueKeyCount = len(seenKeys)
 	return stats
 }
 
 // 递归计算JSON统计信息
-func calculateStatsRecursive(v *Value, stats *JSONStats, depth int) {
+func calculateStatsRecursive(v *Value, stats *JSONStats, depth int, seenKeys map[string]bool) {
 	if v == nil {
 		return
 	}
@@ -64,7 +74,7 @@ func calculateStatsRecursive(v *Value, stats *JSONStats, depth int) {
 	case ARRAY:
 		stats.ArrayCount++
 		for _, elem := range v.A {
-			calculateStatsRecursive(elem, stats, depth+1)
+			calculateStatsRecursive(elem, stats, depth+1, seenKeys)
 		}
 	case OBJECT:
 		stats.ObjectCount++
@@ -77,74 +87,216 @@ func calculateStatsRecursive(v *Value, stats *JSONStats, depth int) {
 				stats.LongestKey = member.K
 			}
 
-			calculateStatsRecursive(member.V, stats, depth+1)
+			// 与解析时的键驻留规则一致，重复出现的短键共享同一个字符串
+			if seenKeys[member.K] && len(member.K) <= maxInternedKeyLength {
+				stats.InternedKeyBytes += len(member.K)
+			}
+			seenKeys[member.K] = true
+
+			calculateStatsRecursive(member.V, stats, depth+1, seenKeys)
 		}
 	}
 }
 
 // 格式化输出带有缩进的JSON
 func formatJSON(v *Value, indent string) (string, error) {
-	// 创建格式化选项
+	return formatJSONWithComparator(v, indent, nil)
+}
+
+// 格式化输出带有缩进的JSON，对象键按照比较函数排序（cmp为nil时保持原有顺序）
+func formatJSONWithComparator(v *Value, indent string, cmp KeyComparator) (string, error) {
+	return formatJSONWithWidth(v, indent, cmp, 0)
+}
+
+// 格式化输出带有缩进的JSON，width大于0时，能在第width列以内放下的数组和对象
+// 写在一行，如 [1, 2, 3] 和 {"x": 1, "y": 2}，放不下时才展开为多行；
+// 宽度按字符数计算，包括缩进、键名和后面的逗号
+func formatJSONWithWidth(v *Value, indent string, cmp KeyComparator, width int) (string, error) {
+	return formatJSONWithLayout(v, &formatLayout{indent: indent, cmp: cmp, width: width})
+}
+
+// formatLayout 是格式化的排版设置
+type formatLayout struct {
+	indent   string        // 每一级的缩进
+	cmp      KeyComparator // 对象键排序规则，为nil时保持原有顺序
+	width    int           // 最大行宽，为0时数组和对象总是展开为多行
+	comments *CommentMap   // 要重新输出的注释，为nil时不输出注释
+	style    OutputStyle   // 输出风格，STYLE_JSON5 时多行的数组和对象带尾随逗号，标识符形式的键不加引号
+}
+
+// 按排版设置格式化JSON
+func formatJSONWithLayout(v *Value, layout *formatLayout) (string, error) {
 	var result strings.Builder
-	formatJSONRecursive(&result, v, 0, indent)
+	vc := layout.comments.Get(v)
+	if vc != nil {
+		for _, comment := range vc.Leading {
+			result.WriteString(comment)
+			result.WriteString("\n")
+		}
+	}
+	formatJSONRecursive(&result, v, 0, layout, 0, 0)
+	if vc != nil && len(vc.Trailing) > 0 {
+		result.WriteString(" " + strings.Join(vc.Trailing, " "))
+	}
+	if layout.comments != nil {
+		for _, comment := range layout.comments.Footer {
+			result.WriteString("\n")
+			result.WriteString(comment)
+		}
+	}
 	return result.String(), nil
 }
 
-// 递归格式化JSON
-func formatJSONRecursive(out *strings.Builder, v *Value, level int, indent string) {
-	if v == nil {
-		out.WriteString("null")
+// 递归格式化JSON，column是值在行中开始的列，trailing是值后面同一行还要写的字符数
+//
+// 元素的前置注释和行尾注释由所在的数组或对象输出，值本身只输出右括号之前的注释。
+func formatJSONRecursive(out *strings.Builder, v *Value, level int, layout *formatLayout, column, trailing int) {
+	vc := layout.comments.Get(v)
+	dangling := vc != nil && len(vc.Dangling) > 0
+	if v == nil || (v.Type != ARRAY && v.Type != OBJECT) || (len(v.A)+len(v.O) == 0 && !dangling) {
+		out.WriteString(formatJSONScalar(v))
 		return
 	}
+	if layout.width > 0 && !dangling {
+		if line, ok := formatJSONInline(v, layout, layout.width-column-trailing); ok {
+			out.WriteString(line)
+			return
+		}
+	}
+
+	childIndent := strings.Repeat(layout.indent, level+1)
+	childColumn := utf8.RuneCountInString(childIndent)
+	opening, closing, n := "[\n", "]", len(v.A)
+	var members []Member
+	if v.Type == OBJECT {
+		members = sortedMembers(v.O, layout.cmp)
+		opening, closing, n = "{\n", "}", len(members)
+	}
+
+	out.WriteString(opening)
+	for i := 0; i < n; i++ {
+		var elem *Value
+		elemColumn := childColumn
+		if v.Type == ARRAY {
+			elem = v.A[i]
+		} else {
+			elem = members[i].V
+		}
+		ec := layout.comments.Get(elem)
+		if ec != nil {
+			for _, comment := range ec.Leading {
+				out.WriteString(childIndent + comment + "\n")
+			}
+		}
+		out.WriteString(childIndent)
+		if v.Type == OBJECT {
+			key := layout.formatKey(members[i].K)
+			out.WriteString(key)
+			out.WriteString(": ")
+			elemColumn += utf8.RuneCountInString(key) + 2
+		}
+		if i < n-1 || layout.style == STYLE_JSON5 {
+			formatJSONRecursive(out, elem, level+1, layout, elemColumn, 1)
+			out.WriteString(",")
+		} else {
+			formatJSONRecursive(out, elem, level+1, layout, elemColumn, 0)
+		}
+		if ec != nil && len(ec.Trailing) > 0 {
+			out.WriteString(" " + strings.Join(ec.Trailing, " "))
+		}
+		out.WriteString("\n")
+	}
+	if dangling {
+		for _, comment := range vc.Dangling {
+			out.WriteString(childIndent + comment + "\n")
+		}
+	}
+	out.WriteString(strings.Repeat(layout.indent, level))
+	out.WriteString(closing)
+}
+
+// formatKey 格式化对象键，JSON5 风格中标识符形式的键不加引号
+func (layout *formatLayout) formatKey(key string) string {
+	if layout.style == STYLE_JSON5 && isJSON5Identifier(key) {
+		return key
+	}
+	return formatJSONString(key)
+}
 
+// formatJSONScalar 格式化标量、空数组和空对象
+func formatJSONScalar(v *Value) string {
+	if v == nil {
+		return "null"
+	}
 	switch v.Type {
-	case NULL:
-		out.WriteString("null")
 	case TRUE:
-		out.WriteString("true")
+		return "true"
 	case FALSE:
-		out.WriteString("false")
+		return "false"
 	case NUMBER:
-		out.WriteString(fmt.Sprintf("%g", v.N))
+		return fmt.Sprintf("%g", v.N)
 	case STRING:
-		out.WriteString(formatJSONString(v.S))
+		return formatJSONString(GetString(v))
 	case ARRAY:
-		if len(v.A) == 0 {
-			out.WriteString("[]")
-			return
-		}
+		return "[]"
+	case OBJECT:
+		return "{}"
+	}
+	return "null"
+}
+
+// formatJSONInline 把 v 格式化为一行，超过 budget 个字符或其中的值带有注释时返回 false
+func formatJSONInline(v *Value, layout *formatLayout, budget int) (string, bool) {
+	var out strings.Builder
+	if !writeJSONInline(&out, v, layout, &budget) {
+		return "", false
+	}
+	return out.String(), true
+}
 
-		out.WriteString("[\n")
+// writeJSONInline 把 v 写为一行，剩余的字符数超出时立即停止并返回 false
+func writeJSONInline(out *strings.Builder, v *Value, layout *formatLayout, budget *int) bool {
+	write := func(s string) bool {
+		*budget -= utf8.RuneCountInString(s)
+		out.WriteString(s)
+		return *budget >= 0
+	}
+	// 注释需要单独成行或写在行尾，带有注释的元素不能写在一行中
+	element := func(elem *Value) bool {
+		return layout.comments.Get(elem) == nil && writeJSONInline(out, elem, layout, budget)
+	}
+	switch {
+	case v != nil && v.Type == ARRAY && len(v.A) > 0:
+		if !write("[") {
+			return false
+		}
 		for i, elem := range v.A {
-			out.WriteString(strings.Repeat(indent, level+1))
-			formatJSONRecursive(out, elem, level+1, indent)
-			if i < len(v.A)-1 {
-				out.WriteString(",")
+			if i > 0 && !write(", ") {
+				return false
+			}
+			if !element(elem) {
+				return false
 			}
-			out.WriteString("\n")
 		}
-		out.WriteString(strings.Repeat(indent, level))
-		out.WriteString("]")
-	case OBJECT:
-		if len(v.O) == 0 {
-			out.WriteString("{}")
-			return
+		return write("]")
+	case v != nil && v.Type == OBJECT && len(v.O) > 0:
+		if !write("{") {
+			return false
 		}
-
-		out.WriteString("{\n")
-		for i, member := range v.O {
-			out.WriteString(strings.Repeat(indent, level+1))
-			out.WriteString(formatJSONString(member.K))
-			out.WriteString(": ")
-			formatJSONRecursive(out, member.V, level+1, indent)
-			if i < len(v.O)-1 {
-				out.WriteString(",")
+		for i, member := range sortedMembers(v.O, layout.cmp) {
+			if i > 0 && !write(", ") {
+				return false
+			}
+			if !write(layout.formatKey(member.K)) || !write(": ") {
+				return false
+			}
+			if !element(member.V) {
+				return false
 			}
-			out.WriteString("\n")
 		}
-		out.WriteString(strings.Repeat(indent, level))
-		out.WriteString("}")
+		return write("}")
 	}
+	return write(formatJSONScalar(v))
 }
 
 // 格式化JSON字符串（添加引号和转义）
@@ -190,25 +342,109 @@ func minifyJSON(v *Value) (string, error) {
 	return jsonStr, nil
 }
 
+// DiffKind 是差异的种类
+type DiffKind int
+
+const (
+	DIFF_TYPE_MISMATCH  DiffKind = iota // 两边类型不同
+	DIFF_VALUE_CHANGED                  // 标量的值不同
+	DIFF_LENGTH_CHANGED                 // 数组长度不同
+	DIFF_REMOVED                        // 只在第一个文档中存在
+	DIFF_ADDED                          // 只在第二个文档中存在
+	DIFF_MOVED                          // 按键匹配的数组元素改变了相对顺序
+)
+
+// String 返回差异种类的名称
+func (k DiffKind) String() string {
+	switch k {
+	case DIFF_TYPE_MISMATCH:
+		return "type"
+	case DIFF_VALUE_CHANGED:
+		return "changed"
+	case DIFF_LENGTH_CHANGED:
+		return "length"
+	case DIFF_REMOVED:
+		return "removed"
+	case DIFF_ADDED:
+		return "added"
+	case DIFF_MOVED:
+		return "moved"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalJSON 将差异种类输出为名称
+func (k DiffKind) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + k.String() + `"`), nil
+}
+
+// Difference 是两个JSON文档之间的一处结构化差异
+//
+// Left 和 Right 指向两边文档中的值，不存在的一边为 nil；
+// DIFF_REMOVED 和 DIFF_ADDED 的 Path 是被删除或新增的成员本身的路径，分别在第一个和第二个文档中。
+// 按键匹配数组元素时同一个元素在两边的下标可能不同，此时 RightPath 是它在第二个文档中的路径。
+type Difference struct {
+	Path      string   `json:"path"`
+	RightPath string   `json:"rightPath,omitempty"`
+	Kind      DiffKind `json:"kind"`
+	Left      *Value   `json:"-"`
+	Right     *Value   `json:"-"`
+	Message   string   `json:"message"`
+}
+
 // 比较两个JSON文档，返回差异
 func compareJSON(v1, v2 *Value) []string {
+	return differenceMessages(CompareValues(v1, v2))
+}
+
+// differenceMessages 返回每处差异的描述
+func differenceMessages(diffs []Difference) []string {
 	differences := []string{}
-	compareJSONRecursive(v1, v2, "$", &differences)
+	for _, diff := range diffs {
+		differences = append(differences, diff.Message)
+	}
+	return differences
+}
+
+// CompareValues 比较两个JSON文档，按文档顺序返回结构化的差异
+func CompareValues(v1, v2 *Value) []Difference {
+	differences := []Difference{}
+	compareJSONRecursive(v1, v2, "$", "$", &compareContext{}, &differences)
 	return differences
 }
 
-// 递归比较JSON文档
-func compareJSONRecursive(v1, v2 *Value, path string, differences *[]string) {
+// 递归比较JSON文档，path 和 rightPath 分别是值在两个文档中的路径，
+// 只有按键匹配的数组元素位置不同时二者才不同
+func compareJSONRecursive(v1, v2 *Value, path, rightPath string, ctx *compareContext, differences *[]Difference) {
+	add := func(kind DiffKind, left, right *Value, format string, args ...interface{}) {
+		diff := Difference{
+			Path:    path,
+			Kind:    kind,
+			Left:    left,
+			Right:   right,
+			Message: fmt.Sprintf("路径 %s: "+format, append([]interface{}{path}, args...)...),
+		}
+		if rightPath != path {
+			diff.RightPath = rightPath
+		}
+		*differences = append(*differences, diff)
+	}
+
+	if ctx.ignored(v1, v2) {
+		return
+	}
+
 	// 检查类型是否相同
 	if v1 == nil || v2 == nil {
 		if (v1 == nil) != (v2 == nil) {
-			*differences = append(*differences, fmt.Sprintf("路径 %s: 一个为null，另一个不是", path))
+			add(DIFF_TYPE_MISMATCH, v1, v2, "一个为null，另一个不是")
 		}
 		return
 	}
 
 	if v1.Type != v2.Type {
-		*differences = append(*differences, fmt.Sprintf("路径 %s: 类型不匹配 (%s vs %s)", path, getValueTypeName(v1.Type), getValueTypeName(v2.Type)))
+		add(DIFF_TYPE_MISMATCH, v1, v2, "类型不匹配 (%s vs %s)", getValueTypeName(v1.Type), getValueTypeName(v2.Type))
 		return
 	}
 
@@ -220,24 +456,31 @@ func compareJSONRecursive(v1, v2 *Value, path string, differences *[]string) {
 		isV1True := v1.Type == TRUE
 		isV2True := v2.Type == TRUE
 		if isV1True != isV2True {
-			*differences = append(*differences, fmt.Sprintf("路径 %s: 布尔值不同 (%t vs %t)", path, isV1True, isV2True))
+			add(DIFF_VALUE_CHANGED, v1, v2, "布尔值不同 (%t vs %t)", isV1True, isV2True)
 		}
 	case NUMBER:
 		if v1.N != v2.N {
-			*differences = append(*differences, fmt.Sprintf("路径 %s: 数字不同 (%g vs %g)", path, v1.N, v2.N))
+			add(DIFF_VALUE_CHANGED, v1, v2, "数字不同 (%g vs %g)", v1.N, v2.N)
 		}
 	case STRING:
-		if v1.S != v2.S {
-			if len(v1.S) > 50 || len(v2.S) > 50 {
-				*differences = append(*differences, fmt.Sprintf("路径 %s: 字符串不同 (长度: %d vs %d)", path, len(v1.S), len(v2.S)))
+		if !canonicalEqual(GetString(v1), GetString(v2), ctx.form) {
+			if len(GetString(v1)) > 50 || len(GetString(v2)) > 50 {
+				add(DIFF_VALUE_CHANGED, v1, v2, "字符串不同 (长度: %d vs %d)", len(GetString(v1)), len(GetString(v2)))
 			} else {
-				*differences = append(*differences, fmt.Sprintf("路径 %s: 字符串不同 (\"%s\" vs \"%s\")", path, v1.S, v2.S))
+				add(DIFF_VALUE_CHANGED, v1, v2, "字符串不同 (\"%s\" vs \"%s\")", GetString(v1), GetString(v2))
 			}
 		}
 	case ARRAY:
+		if ctx.arrayKey != "" && compareKeyedArrays(v1, v2, path, rightPath, ctx, differences) {
+			return
+		}
+		if ctx.lcs && compareScalarArrays(v1, v2, path, rightPath, ctx, differences) {
+			return
+		}
+
 		// 检查数组长度
 		if len(v1.A) != len(v2.A) {
-			*differences = append(*differences, fmt.Sprintf("路径 %s: 数组长度不同 (%d vs %d)", path, len(v1.A), len(v2.A)))
+			add(DIFF_LENGTH_CHANGED, v1, v2, "数组长度不同 (%d vs %d)", len(v1.A), len(v2.A))
 		}
 
 		// 比较数组元素
@@ -247,33 +490,57 @@ func compareJSONRecursive(v1, v2 *Value, path string, differences *[]string) {
 		}
 
 		for i := 0; i < minLen; i++ {
-			compareJSONRecursive(v1.A[i], v2.A[i], fmt.Sprintf("%s[%d]", path, i), differences)
+			compareJSONRecursive(v1.A[i], v2.A[i], fmt.Sprintf("%s[%d]", path, i), fmt.Sprintf("%s[%d]", rightPath, i), ctx, differences)
 		}
 	case OBJECT:
-		// 创建v2的键映射，用于快速查找
+		// 创建v2的键映射，用于快速查找，键按规范化后的形式匹配
+		form := ctx.form
 		v2Keys := make(map[string]*Value)
 		for _, member := range v2.O {
-			v2Keys[member.K] = member.V
+			v2Keys[NormalizeString(member.K, form)] = member.V
 		}
 
 		// 检查v1中的每个键
 		for _, member := range v1.O {
-			v2Value, exists := v2Keys[member.K]
+			memberPath := fmt.Sprintf("%s.%s", path, member.K)
+			key := NormalizeString(member.K, form)
+			v2Value, exists := v2Keys[key]
 			if !exists {
-				*differences = append(*differences, fmt.Sprintf("路径 %s: 第一个JSON有键 '%s'，但第二个没有", path, member.K))
+				if ctx.ignoreLeft[member.V] {
+					continue
+				}
+				*differences = append(*differences, Difference{
+					Path:    memberPath,
+					Kind:    DIFF_REMOVED,
+					Left:    member.V,
+					Message: fmt.Sprintf("路径 %s: 第一个JSON有键 '%s'，但第二个没有", path, member.K),
+				})
 				continue
 			}
 
 			// 递归比较值
-			compareJSONRecursive(member.V, v2Value, fmt.Sprintf("%s.%s", path, member.K), differences)
+			compareJSONRecursive(member.V, v2Value, memberPath, fmt.Sprintf("%s.%s", rightPath, member.K), ctx, differences)
 
 			// 从v2Keys中删除已比较的键
-			delete(v2Keys, member.K)
+			delete(v2Keys, key)
 		}
 
-		// 检查v2中的剩余键（v1中不存在的键）
-		for k := range v2Keys {
-			*differences = append(*differences, fmt.Sprintf("路径 %s: 第二个JSON有键 '%s'，但第一个没有", path, k))
+		// 检查v2中的剩余键（v1中不存在的键），按第二个文档中的顺序输出
+		for _, member := range v2.O {
+			key := NormalizeString(member.K, form)
+			if _, remaining := v2Keys[key]; !remaining {
+				continue
+			}
+			delete(v2Keys, key)
+			if ctx.ignoreRight[member.V] {
+				continue
+			}
+			*differences = append(*differences, Difference{
+				Path:    fmt.Sprintf("%s.%s", rightPath, member.K),
+				Kind:    DIFF_ADDED,
+				Right:   member.V,
+				Message: fmt.Sprintf("路径 %s: 第二个JSON有键 '%s'，但第一个没有", path, member.K),
+			})
 		}
 	}
 }
@@ -325,6 +592,14 @@ func RunCLI() {
 	help := mainCmd.Bool("help", false, "显示帮助信息")
 	helpShort := mainCmd.Bool("h", false, "显示帮助信息")
 	version := mainCmd.Bool("version", false, "显示版本信息")
+	cpuProfile := mainCmd.String("cpuprofile", "", "将CPU分析数据写入文件")
+	memProfile := mainCmd.String("memprofile", "", "将内存分析数据写入文件")
+	traceFile := mainCmd.String("trace", "", "将执行追踪数据写入文件")
+	useMmap := mainCmd.Bool("mmap", false, "通过内存映射读取输入文件")
+	var headers headerFlags
+	mainCmd.Var(&headers, "header", "读取URL输入时附加的请求头，格式为 \"名称: 值\"，可以重复")
+	httpTimeout := mainCmd.Duration("http-timeout", cliFetchOptions.Timeout, "读取URL输入的超时时间")
+	maxFetchSize := mainCmd.Int64("max-fetch-size", 0, "读取URL输入时响应体的最大字节数")
 
 	// 解析全局选项
 	mainCmd.Parse(os.Args[1:])
@@ -349,6 +624,20 @@ func RunCLI() {
 
 	// 使用-v或--verbose都可以开启详细模式
 	verboseMode := *verbose || *verboseShort
+	cliUseMmap = *useMmap
+	if *maxFetchSize < 0 || *httpTimeout < 0 {
+		fmt.Println("错误: --max-fetch-size 和 --http-timeout 不能为负数")
+		exitCLI(1)
+	}
+	cliFetchOptions = FetchOptions{Headers: headers.header, Timeout: *httpTimeout, MaxBytes: *maxFetchSize}
+
+	// 从当前目录向上查找项目配置，作为各命令的默认设置
+	config, err := LoadProjectConfig(".")
+	if err != nil {
+		fmt.Printf("错误: 加载项目配置失败: %v\n", err)
+		exitCLI(1)
+	}
+	cliConfig = config
 
 	subCommand := args[0]
 	subArgs := args[1:]
@@ -359,346 +648,116 @@ func RunCLI() {
 		return
 	}
 
-	// 根据子命令执行对应的操作
-	switch subCommand {
-	case "parse":
-		runParse(subArgs, verboseMode)
-	case "format":
-		runFormat(subArgs, verboseMode)
-	case "minify":
-		runMinify(subArgs, verboseMode)
-	case "stats":
-		runStats(subArgs, verboseMode)
-	case "find":
-		runFind(subArgs, verboseMode)
-	case "path":
-		runPath(subArgs, verboseMode)
-	case "compare":
-		runCompare(subArgs, verboseMode)
-	case "validate":
-		runValidate(subArgs, verboseMode)
-	case "pointer":
-		runPointer(subArgs, verboseMode)
-	case "patch":
-		runPatch(subArgs, verboseMode)
-	case "merge-patch":
-		runMergePatch(subArgs, verboseMode)
-	default:
-		fmt.Printf("未知的命令: %s\n", subCommand)
-		printUsage()
+	// 开始性能分析，命令结束或提前退出时写入分析数据
+	stopProfiling, err := StartProfiling(ProfileOptions{
+		CPUProfile: *cpuProfile,
+		MemProfile: *memProfile,
+		Trace:      *traceFile,
+	})
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		exitCLI(1)
 	}
-}
-
-// 打印子命令的帮助信息
-func printSubcommandHelp(command string) {
-	switch command {
-	case "parse":
-		fmt.Println("leptjson parse - 解析并验证JSON文件")
-		fmt.Println("\n用法: leptjson parse FILE")
-		fmt.Println("\n参数:")
-		fmt.Println("  FILE    要解析的JSON文件路径")
-
-	case "format":
-		fmt.Println("leptjson format - 格式化JSON文件")
-		fmt.Println("\n用法: leptjson format [选项] FILE [OUTPUT]")
-		fmt.Println("\n选项:")
-		fmt.Println("  --indent=N    设置缩进空格数（默认为2）")
-		fmt.Println("\n参数:")
-		fmt.Println("  FILE          要格式化的JSON文件路径")
-		fmt.Println("  OUTPUT        输出文件路径（可选，默认为FILE.formatted.json）")
-
-	case "minify":
-		fmt.Println("leptjson minify - 最小化JSON文件")
-		fmt.Println("\n用法: leptjson minify FILE [OUTPUT]")
-		fmt.Println("\n参数:")
-		fmt.Println("  FILE          要最小化的JSON文件路径")
-		fmt.Println("  OUTPUT        输出文件路径（可选，默认为FILE.min.json）")
-
-	case "stats":
-		fmt.Println("leptjson stats - 显示JSON统计信息")
-		fmt.Println("\n用法: leptjson stats [选项] FILE")
-		fmt.Println("\n选项:")
-		fmt.Println("  --json        以JSON格式输出统计信息")
-		fmt.Println("\n参数:")
-		fmt.Println("  FILE          要分析的JSON文件路径")
-
-	case "find":
-		fmt.Println("leptjson find - 在JSON中查找特定路径的值")
-		fmt.Println("\n用法: leptjson find [选项] FILE JSONPATH")
-		fmt.Println("\n选项:")
-		fmt.Println("  --output=FORMAT    设置输出格式，可选值: compact, pretty, raw（默认为compact）")
-		fmt.Println("\n参数:")
-		fmt.Println("  FILE          要搜索的JSON文件路径")
-		fmt.Println("  JSONPATH      JSONPath表达式，如$.store.book[0].title")
-
-	case "compare":
-		fmt.Println("leptjson compare - 比较两个JSON文件")
-		fmt.Println("\n用法: leptjson compare [选项] FILE1 FILE2")
-		fmt.Println("\n选项:")
-		fmt.Println("  --json        以JSON格式输出差异")
-		fmt.Println("\n参数:")
-		fmt.Println("  FILE1         第一个JSON文件路径")
-		fmt.Println("  FILE2         第二个JSON文件路径")
-
-	case "validate":
-		fmt.Println("leptjson validate - 使用JSON Schema验证JSON文件")
-		fmt.Println("\n用法: leptjson validate [选项] SCHEMA FILE")
-		fmt.Println("\n选项:")
-		fmt.Println("  --format=FORMAT    设置输出格式，可选值: text, json（默认为text）")
-		fmt.Println("\n参数:")
-		fmt.Println("  SCHEMA             JSON Schema文件路径")
-		fmt.Println("  FILE               要验证的JSON文件路径")
-		fmt.Println("\n说明:")
-		fmt.Println("  该命令使用JSON Schema验证JSON文件的结构和内容。")
-		fmt.Println("  验证失败时会显示详细的错误信息。")
-		fmt.Println("  支持Draft-07版本的JSON Schema规范的主要功能。")
-
-	case "pointer":
-		fmt.Println("leptjson pointer - 使用JSON Pointer操作JSON文件")
-		fmt.Println("\n用法: leptjson pointer [选项] FILE POINTER")
-		fmt.Println("\n选项:")
-		fmt.Println("  --operation=OP    操作类型，可选值:")
-		fmt.Println("                      - get: 获取值（默认）")
-		fmt.Println("                      - add: 添加或替换值")
-		fmt.Println("                      - remove: 删除值")
-		fmt.Println("                      - replace: 替换值")
-		fmt.Println("  --value=JSON      用于add和replace操作的JSON值")
-		fmt.Println("  --output=FILE     保存修改后的JSON到指定文件")
-		fmt.Println("\n参数:")
-		fmt.Println("  FILE              要操作的JSON文件路径")
-		fmt.Println("  POINTER           JSON Pointer路径，如/users/0/name")
-		fmt.Println("\n说明:")
-		fmt.Println("  该命令实现了RFC 6901中定义的JSON Pointer，用于在JSON文档中定位和操作值。")
-		fmt.Println("  JSON Pointer以/开头，使用/分隔路径片段，如/foo/0/bar引用{\"foo\":[{\"bar\":42}]}中的42。")
-		fmt.Println("  ~0表示~，~1表示/。")
-
-	case "patch":
-		fmt.Println("leptjson patch - 使用JSON Patch修改JSON文件")
-		fmt.Println("\n用法: leptjson patch [选项] PATCH FILE [OUTPUT]")
-		fmt.Println("\n选项:")
-		fmt.Println("  --in-place         直接修改原文件，不创建新文件")
-		fmt.Println("  --test             仅测试补丁，不实际修改文件")
-		fmt.Println("\n参数:")
-		fmt.Println("  PATCH              包含JSON Patch操作的文件")
-		fmt.Println("  FILE               要修改的JSON文件")
-		fmt.Println("  OUTPUT             输出文件路径（可选）")
-		fmt.Println("\n说明:")
-		fmt.Println("  该命令实现了RFC 6902中定义的JSON Patch，用于修改JSON文档。")
-		fmt.Println("  JSON Patch是一组操作指令，如add、remove、replace、move、copy和test。")
-		fmt.Println("  每个操作都有一个'op'字段指定操作类型，以及一个'path'字段指定操作位置。")
-
-	case "merge-patch":
-		fmt.Println("leptjson merge-patch - 使用JSON Merge Patch合并JSON文件")
-		fmt.Println("\n用法: leptjson merge-patch [选项] PATCH FILE [OUTPUT]")
-		fmt.Println("\n选项:")
-		fmt.Println("  --in-place         直接修改原文件，不创建新文件")
-		fmt.Println("\n参数:")
-		fmt.Println("  PATCH              包含Merge Patch操作的JSON文件")
-		fmt.Println("  FILE               要修改的目标JSON文件")
-		fmt.Println("  OUTPUT             输出文件路径（可选，默认为FILE.merged.json）")
-		fmt.Println("\n说明:")
-		fmt.Println("  该命令实现了RFC 7396中定义的JSON Merge Patch，用于简化JSON文档的合并。")
-		fmt.Println("  与JSON Patch不同，JSON Merge Patch本身就是一个JSON对象，结构与目标文档类似。")
-		fmt.Println("  合并规则:")
-		fmt.Println("    - 如果补丁中的值为null，则从目标中删除该字段")
-		fmt.Println("    - 如果补丁中包含非null值，则替换目标中的相应值")
-		fmt.Println("    - 如果两边都是对象，则递归合并")
-		fmt.Println("    - 如果补丁中的值是数组，则完全替换目标中的数组")
-
-	case "path":
-		fmt.Println("leptjson path - 使用JSONPath查询JSON文件")
-		fmt.Println("\n用法: leptjson path [选项] FILE JSONPATH")
-		fmt.Println("\n选项:")
-		fmt.Println("  --output=FORMAT    设置输出格式，可选值: compact, pretty, raw, table（默认为pretty）")
-		fmt.Println("  --all              显示所有匹配的结果（默认只显示前10个）")
-		fmt.Println("  --csv=FILE         将结果输出为CSV文件")
-		fmt.Println("  --no-path          不在输出中显示路径信息")
-		fmt.Println("\n参数:")
-		fmt.Println("  FILE               要查询的JSON文件路径")
-		fmt.Println("  JSONPATH           JSONPath表达式，如$.store.book[*].author")
-		fmt.Println("\n说明:")
-		fmt.Println("  该命令使用JSONPath表达式从JSON文件中提取数据。")
-		fmt.Println("  JSONPath是一种用于从JSON文档中选择和提取数据的查询语言。")
-		fmt.Println("\n支持的JSONPath语法:")
-		fmt.Println("  $                  根对象或数组")
-		fmt.Println("  .property          子属性")
-		fmt.Println("  ['property']       子属性（带引号）")
-		fmt.Println("  [index]            数组索引")
-		fmt.Println("  [start:end:step]   数组切片")
-		fmt.Println("  *                  通配符，匹配所有属性或元素")
-		fmt.Println("  ..property         递归下降，匹配任意深度的属性")
-		fmt.Println("  [?(@.prop > 10)]   过滤表达式")
-		fmt.Println("  [?(@.prop)]        存在性检查")
-		fmt.Println("  [?(@.name == 'x')] 相等性检查")
-		fmt.Println("  ['a','b']          多属性选择")
+	cliCleanups = append(cliCleanups, stopProfiling)
+	defer runCLICleanups()
 
-	default:
-		fmt.Printf("未知的命令: %s\n", command)
+	// 根据子命令执行对应的操作
+	command := lookupCommand(subCommand)
+	if command == nil {
+		// 内置命令优先，找不到时运行 PATH 中的 leptjson-<命令> 插件
+		if plugin, ok := LookupPlugin(subCommand); ok {
+			runPlugin(plugin, subArgs, verboseMode)
+			return
+		}
+		fmt.Printf("未知的命令: %s\n", subCommand)
 		printUsage()
+		return
 	}
+	runCommand(command, subArgs, verboseMode)
 }
 
-// 打印用法信息
-func printUsage() {
-	fmt.Println("用法: leptjson [选项] 命令 [参数]")
-	fmt.Println("\n全局选项:")
-	fmt.Println("  --help, -h      显示帮助信息")
-	fmt.Println("  --verbose, -v   显示详细输出")
-	fmt.Println("  --version       显示版本信息")
-
-	fmt.Println("\n可用命令:")
-	fmt.Println("  parse           解析并验证JSON文件")
-	fmt.Println("  format          格式化JSON文件")
-	fmt.Println("  minify          最小化JSON文件")
-	fmt.Println("  stats           显示JSON统计信息")
-	fmt.Println("  find            在JSON中查找特定路径的值（简化版JSONPath）")
-	fmt.Println("  path            使用完整JSONPath语法查询JSON数据")
-	fmt.Println("  compare         比较两个JSON文件")
-	fmt.Println("  validate        使用JSON Schema验证JSON文件")
-	fmt.Println("  pointer         使用JSON Pointer操作JSON文件")
-	fmt.Println("  patch           使用JSON Patch修改JSON文件")
-	fmt.Println("  merge-patch     使用JSON Merge Patch合并JSON文件")
-
-	fmt.Println("\n命令详情:")
-
-	// parse命令
-	fmt.Println("\n  parse FILE")
-	fmt.Println("    解析并验证JSON文件的格式")
-	fmt.Println("    参数:")
-	fmt.Println("      FILE        要解析的JSON文件路径")
-
-	// format命令
-	fmt.Println("\n  format [选项] FILE [OUTPUT]")
-	fmt.Println("    格式化JSON文件，增加缩进和换行")
-	fmt.Println("    选项:")
-	fmt.Println("      --indent=N  设置缩进空格数（默认为2）")
-	fmt.Println("    参数:")
-	fmt.Println("      FILE        要格式化的JSON文件路径")
-	fmt.Println("      OUTPUT      输出文件路径（可选，默认为FILE.formatted.json）")
-
-	// minify命令
-	fmt.Println("\n  minify FILE [OUTPUT]")
-	fmt.Println("    最小化JSON文件，移除所有不必要的空白字符")
-	fmt.Println("    参数:")
-	fmt.Println("      FILE        要最小化的JSON文件路径")
-	fmt.Println("      OUTPUT      输出文件路径（可选，默认为FILE.min.json）")
-
-	// stats命令
-	fmt.Println("\n  stats [选项] FILE")
-	fmt.Println("    分析JSON文件并显示统计信息")
-	fmt.Println("    选项:")
-	fmt.Println("      --json      以JSON格式输出统计信息")
-	fmt.Println("    参数:")
-	fmt.Println("      FILE        要分析的JSON文件路径")
-
-	// find命令
-	fmt.Println("\n  find [选项] FILE JSONPATH")
-	fmt.Println("    使用JSONPath表达式在JSON文件中查找值")
-	fmt.Println("    选项:")
-	fmt.Println("      --output=FORMAT  设置输出格式，可选值: compact, pretty, raw（默认为compact）")
-	fmt.Println("    参数:")
-	fmt.Println("      FILE        要搜索的JSON文件路径")
-	fmt.Println("      JSONPATH    JSONPath表达式，如$.store.book[0].title")
-
-	// compare命令
-	fmt.Println("\n  compare [选项] FILE1 FILE2")
-	fmt.Println("    比较两个JSON文件并显示差异")
-	fmt.Println("    选项:")
-	fmt.Println("      --json      以JSON格式输出差异")
-	fmt.Println("    参数:")
-	fmt.Println("      FILE1       第一个JSON文件路径")
-	fmt.Println("      FILE2       第二个JSON文件路径")
-
-	// validate命令
-	fmt.Println("\n  validate [选项] SCHEMA FILE")
-	fmt.Println("    使用JSON Schema验证JSON文件")
-	fmt.Println("    选项:")
-	fmt.Println("      --format=FORMAT  设置输出格式，可选值: text, json（默认为text）")
-	fmt.Println("    参数:")
-	fmt.Println("      SCHEMA       JSON Schema文件路径")
-	fmt.Println("      FILE         要验证的JSON文件路径")
-
-	// pointer命令
-	fmt.Println("\n  pointer [选项] FILE POINTER")
-	fmt.Println("    使用JSON Pointer (RFC 6901)操作JSON文件")
-	fmt.Println("    选项:")
-	fmt.Println("      --operation=OP  操作类型：get(默认),add,remove,replace")
-	fmt.Println("      --value=JSON    用于add和replace操作的JSON值")
-	fmt.Println("      --output=FILE   保存修改后的JSON文件路径（默认覆盖原文件）")
-	fmt.Println("    参数:")
-	fmt.Println("      FILE         要操作的JSON文件路径")
-	fmt.Println("      POINTER      JSON Pointer路径，如/users/0/name")
-
-	// patch命令
-	fmt.Println("\n  patch [选项] PATCH FILE [OUTPUT]")
-	fmt.Println("    使用JSON Patch (RFC 6902)修改JSON文件")
-	fmt.Println("    选项:")
-	fmt.Println("      --in-place       直接修改原文件，不创建新文件")
-	fmt.Println("      --test           仅测试补丁，不实际修改文件")
-	fmt.Println("    参数:")
-	fmt.Println("      PATCH        包含JSON Patch操作的文件")
-	fmt.Println("      FILE         要修改的JSON文件")
-	fmt.Println("      OUTPUT       输出文件路径（可选）")
-
-	// merge-patch命令
-	fmt.Println("\n  merge-patch [选项] PATCH FILE [OUTPUT]")
-	fmt.Println("    使用JSON Merge Patch (RFC 7396)合并JSON文件")
-	fmt.Println("    选项:")
-	fmt.Println("      --in-place       直接修改原文件，不创建新文件")
-	fmt.Println("    参数:")
-	fmt.Println("      PATCH        包含Merge Patch操作的JSON文件")
-	fmt.Println("      FILE         要修改的目标JSON文件")
-	fmt.Println("      OUTPUT       输出文件路径（可选，默认为FILE.merged.json）")
-
-	// path命令
-	fmt.Println("\n  path [选项] FILE JSONPATH")
-	fmt.Println("    使用完整的JSONPath语法查询JSON文件")
-	fmt.Println("    选项:")
-	fmt.Println("      --output=FORMAT  设置输出格式: compact, pretty, raw, table")
-	fmt.Println("      --all            显示所有匹配结果(默认仅显示前10个)")
-	fmt.Println("      --csv=FILE       将结果保存为CSV文件")
-	fmt.Println("      --no-path        不在输出中显示路径信息")
-	fmt.Println("    参数:")
-	fmt.Println("      FILE           要查询的JSON文件路径")
-	fmt.Println("      JSONPATH       JSONPath表达式，如$..book[?(@.price<10)]")
-
-	fmt.Println("\n示例:")
-	fmt.Println("  leptjson parse data.json")
-	fmt.Println("  leptjson format --indent=2 data.json pretty.json")
-	fmt.Println("  leptjson minify large.json small.json")
-	fmt.Println("  leptjson stats --json data.json")
-	fmt.Println("  leptjson find --output=pretty data.json \"$.store.book[0].title\"")
-	fmt.Println("  leptjson path --output=table data.json \"$..book[?(@.price < 10)]\"")
-	fmt.Println("  leptjson compare original.json updated.json")
-	fmt.Println("  leptjson validate --format=json schema.json data.json")
-	fmt.Println("  leptjson pointer data.json \"/users/0/name\"")
-	fmt.Println("  leptjson pointer --operation=replace --value=\"John\" data.json \"/users/0/name\"")
-	fmt.Println("  leptjson patch patch.json data.json result.json")
-	fmt.Println("  leptjson merge-patch merge.json data.json result.json")
+// cliUseMmap 为 true 时 loadJSON 通过内存映射读取文件，由全局选项 --mmap 设置
+var cliUseMmap bool
 
-}
+// cliConfig 是从当前目录向上找到的项目配置，没有配置文件时为默认配置，由 RunCLI 设置
+var cliConfig = DefaultProjectConfig()
 
 // 从文件加载JSON
 func loadJSON(filename string, verbose bool) (*Value, error) {
+	return loadJSONWithProgress(filename, verbose, false)
+}
+
+// 从文件加载JSON，progress 为 true 时读取大文件会在标准错误输出上显示进度条
+func loadJSONWithProgress(filename string, verbose bool, progress bool) (*Value, error) {
 	if verbose {
 		fmt.Printf("正在读取文件: %s\n", filename)
 	}
 
+	if isURLInput(filename) {
+		return loadJSONFromURL(filename)
+	}
+	if _, ok := storageScheme(filename); ok {
+		return loadJSONFromStorage(filename)
+	}
+
+	// .properties 和 .ini 文件转换为嵌套对象，使比较、合并、验证等命令可以直接处理旧式配置
+	if parse := flatConfigParser(filename); parse != nil {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("无法打开文件: %w", err)
+		}
+		v, err := parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("解析配置文件失败: %w", err)
+		}
+		return v, nil
+	}
+
+	// 内存映射时文件内容按需换入，没有可显示的读取进度
+	if cliUseMmap {
+		info, err := os.Stat(filename)
+		if err != nil {
+			return nil, fmt.Errorf("无法打开文件: %w", err)
+		}
+		var v Value
+		if err := ParseFile(&v, filename, fileParseOptions(info.Size())); err != nil {
+			if _, ok := err.(ParseError); ok {
+				return nil, fmt.Errorf("解析JSON失败: %s", err)
+			}
+			return nil, fmt.Errorf("无法打开文件: %w", err)
+		}
+		return &v, nil
+	}
+
 	file, err := os.Open(filename)
 	if err != nil {
 		return nil, fmt.Errorf("无法打开文件: %w", err)
 	}
 	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("无法打开文件: %w", err)
+	}
+
+	var reader io.Reader = file
+	var bar *progressReader
+	if progress && shouldShowProgress(info.Size()) {
+		bar = newProgressReader(file, os.Stderr, info.Size())
+		reader = bar
+	}
 
 	// 读取文件内容
-	data, err := io.ReadAll(file)
+	data, err := io.ReadAll(reader)
+	if bar != nil {
+		bar.Finish()
+	}
 	if err != nil {
 		return nil, fmt.Errorf("读取文件失败: %w", err)
 	}
 
 	// 解析JSON
 	var v Value
-	parseErr := Parse(&v, string(data))
+	parseErr := ParseWithOptions(&v, string(data), fileParseOptions(int64(len(data))))
 	if parseErr != PARSE_OK {
 		return nil, fmt.Errorf("解析JSON失败: %s", parseErr)
 	}
@@ -706,6 +765,72 @@ func loadJSON(filename string, verbose bool) (*Value, error) {
 	return &v, nil
 }
 
+// fileParseOptions 返回解析 size 字节的本地文件使用的选项
+//
+// 命令行指定的本地文件是用户自己的输入，字符串长度、数组和对象的大小以及总大小都不会超过
+// 文件大小，所以仍是默认值的这几项限制放宽到文件大小，否则超过1MB的文件都无法处理。
+// 项目配置的 security 中改过的限制仍然有效，嵌套深度的限制保持不变。
+func fileParseOptions(size int64) ParseOptions {
+	options := cliConfig.ParseOptions()
+	defaults := DefaultParseOptions()
+	for _, limit := range []struct {
+		field        *int
+		defaultValue int
+	}{
+		{&options.MaxStringLength, defaults.MaxStringLength},
+		{&options.MaxArraySize, defaults.MaxArraySize},
+		{&options.MaxObjectSize, defaults.MaxObjectSize},
+		{&options.MaxTotalSize, defaults.MaxTotalSize},
+	} {
+		if *limit.field == limit.defaultValue && size > int64(*limit.field) {
+			*limit.field = int(size)
+		}
+	}
+	return options
+}
+
+// loadJSONWithComments 读取并解析允许注释的JSON文件，同时返回注释所属的值
+func loadJSONWithComments(filename string) (*Value, *CommentMap, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("无法打开文件: %w", err)
+	}
+	options := fileParseOptions(int64(len(data)))
+	options.AllowComments = true
+	options.Comments = NewCommentMap()
+	var v Value
+	if parseErr := ParseWithOptions(&v, string(data), options); parseErr != PARSE_OK {
+		return nil, nil, fmt.Errorf("解析JSON失败: %s", parseErr)
+	}
+	return &v, options.Comments, nil
+}
+
+// 下载并解析 http(s) URL 指向的JSON
+func loadJSONFromURL(rawURL string) (*Value, error) {
+	data, options, err := fetchCLIInput(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	var v Value
+	if parseErr := ParseWithOptions(&v, string(data), options); parseErr != PARSE_OK {
+		return nil, fmt.Errorf("解析JSON失败: %s", parseErr)
+	}
+	return &v, nil
+}
+
+// loadJSONFromStorage 读取并解析 s3://、gs:// 等对象存储中的文档
+func loadJSONFromStorage(uri string) (*Value, error) {
+	data, options, err := readCLIStorage(uri)
+	if err != nil {
+		return nil, err
+	}
+	var v Value
+	if parseErr := ParseWithOptions(&v, string(data), options); parseErr != PARSE_OK {
+		return nil, fmt.Errorf("解析JSON失败: %s", parseErr)
+	}
+	return &v, nil
+}
+
 // 保存JSON到文件
 func saveJSON(filename string, content string, verbose bool) error {
 	if verbose {
@@ -743,7 +868,7 @@ func runParse(args []string, verbose bool) {
 	_, err := loadJSON(filePath, verbose)
 	if err != nil {
 		fmt.Printf("解析失败: %s\n", err)
-		os.Exit(1)
+		exitCLI(1)
 	}
 
 	fmt.Println("文件格式有效")
@@ -757,11 +882,21 @@ func runFormat(args []string, verbose bool) {
 		return
 	}
 
-	// 解析indent选项
-	indentSpaces := 2
+	// 解析选项，缩进和键排序规则的默认值来自项目配置
+	indentSpaces := cliConfig.Indent
+	printWidth := cliConfig.PrintWidth         // 最大行宽，0表示数组和对象总是展开
+	keyCase := ""                              // 键命名风格
+	keyCaseExcludes := []string{}              // 不转换键名的路径
+	keyComparator := cliConfig.KeyComparator() // 对象键排序规则
+	quiet := false                             // 不显示进度条
+	keepComments := false                      // 允许注释并在输出中保留
+	style := STYLE_JSON                        // 输出风格
+	var preview []int                          // 预览的层数、元素数和字符数限制，为nil时不截断
 	fileArgs := args
 
-	for i, arg := range args {
+	for i := 0; i < len(fileArgs); i++ {
+		arg := fileArgs[i]
+
 		if strings.HasPrefix(arg, "--indent=") {
 			indentVal := strings.TrimPrefix(arg, "--indent=")
 			spaces, err := strconv.Atoi(indentVal)
@@ -771,24 +906,139 @@ func runFormat(args []string, verbose bool) {
 			}
 			indentSpaces = spaces
 			// 从参数列表中移除选项
-			fileArgs = append(args[:i], args[i+1:]...)
-			break
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+
+		if strings.HasPrefix(arg, "--print-width=") {
+			widthVal := strings.TrimPrefix(arg, "--print-width=")
+			width, err := strconv.Atoi(widthVal)
+			if err != nil || width < 0 {
+				fmt.Printf("错误: 无效的行宽: %s\n", widthVal)
+				return
+			}
+			printWidth = width
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+
+		if strings.HasPrefix(arg, "--key-case=") {
+			keyCase = strings.TrimPrefix(arg, "--key-case=")
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+
+		if strings.HasPrefix(arg, "--key-case-exclude=") {
+			keyCaseExcludes = append(keyCaseExcludes, strings.TrimPrefix(arg, "--key-case-exclude="))
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+
+		if arg == "--sort-keys" || strings.HasPrefix(arg, "--sort-keys=") {
+			name := "alpha"
+			if strings.HasPrefix(arg, "--sort-keys=") {
+				name = strings.TrimPrefix(arg, "--sort-keys=")
+			}
+			cmp, ok := LookupKeyComparator(name)
+			if !ok {
+				fmt.Printf("错误: 未注册的键排序规则: %s\n", name)
+				return
+			}
+			keyComparator = cmp
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+
+		if strings.HasPrefix(arg, "--key-order=") {
+			keys := strings.Split(strings.TrimPrefix(arg, "--key-order="), ",")
+			keyComparator = NewKeyOrderComparator(keys...)
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+
+		if arg == "--quiet" {
+			quiet = true
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+
+		if arg == "--comments" {
+			keepComments = true
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+
+		if strings.HasPrefix(arg, "--style=") {
+			parsed, err := ParseOutputStyle(strings.TrimPrefix(arg, "--style="))
+			if err != nil {
+				fmt.Printf("错误: %s\n", err)
+				fmt.Println("有效的风格: json, json5")
+				return
+			}
+			style = parsed
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+
+		if arg == "--preview" || strings.HasPrefix(arg, "--preview=") {
+			limits, err := parsePreviewLimits(strings.TrimPrefix(arg, "--preview="))
+			if arg == "--preview" {
+				limits, err = parsePreviewLimits(defaultPreviewLimits)
+			}
+			if err != nil {
+				fmt.Printf("错误: %s\n", err)
+				return
+			}
+			preview = limits
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
 		}
 	}
 
 	if len(fileArgs) < 1 || len(fileArgs) > 2 {
 		fmt.Println("错误: format命令需要1-2个文件参数")
-		fmt.Println("\n用法: leptjson format [--indent=SPACES] FILE [OUTPUT]")
+		fmt.Println("\n用法: leptjson format [--indent=SPACES] [--key-case=STYLE] FILE [OUTPUT]")
+		return
+	}
+
+	// 转换键名和截断都会生成新的值，关联到原来的值的注释无法保留
+	if keepComments && (keyCase != "" || preview != nil) {
+		fmt.Println("错误: --comments 不能与 --key-case 或 --preview 同时使用")
 		return
 	}
 
+	// 验证键命名风格
+	var keyStyle KeyCase
+	if keyCase != "" {
+		style, err := ParseKeyCase(keyCase)
+		if err != nil {
+			fmt.Printf("错误: %s\n", err)
+			fmt.Println("有效的风格: camel, snake, kebab, pascal")
+			return
+		}
+		keyStyle = style
+	}
+
 	inputFile := fileArgs[0]
 	outputFile := ""
 	if len(fileArgs) == 2 {
 		outputFile = fileArgs[1]
-	} else {
-		// 默认输出文件名
+	} else if preview == nil {
+		// 默认输出文件名，预览默认输出到标准输出
 		outputFile = inputFile + ".formatted.json"
+		if style == STYLE_JSON5 {
+			outputFile += "5"
+		}
 	}
 
 	if verbose {
@@ -796,27 +1046,55 @@ func runFormat(args []string, verbose bool) {
 	}
 
 	// 加载JSON
-	v, err := loadJSON(inputFile, verbose)
+	var v *Value
+	var comments *CommentMap
+	var err error
+	if keepComments {
+		v, comments, err = loadJSONWithComments(inputFile)
+	} else {
+		v, err = loadJSONWithProgress(inputFile, verbose, !quiet)
+	}
 	if err != nil {
 		fmt.Printf("格式化失败: %s\n", err)
-		os.Exit(1)
+		exitCLI(1)
+	}
+
+	// 转换键命名风格
+	if keyCase != "" {
+		v, err = ConvertKeys(v, keyStyle, keyCaseExcludes...)
+		if err != nil {
+			fmt.Printf("转换键命名风格失败: %s\n", err)
+			exitCLI(1)
+		}
+	}
+
+	if preview != nil {
+		v = Preview(v, preview[0], preview[1], preview[2])
 	}
 
 	// 生成缩进字符串
 	indent := strings.Repeat(" ", indentSpaces)
 
 	// 格式化JSON
-	formatted, err := formatJSON(v, indent)
+	formatted, err := formatJSONWithLayout(v, &formatLayout{indent: indent, cmp: keyComparator, width: printWidth, comments: comments, style: style})
 	if err != nil {
 		fmt.Printf("格式化失败: %s\n", err)
-		os.Exit(1)
+		exitCLI(1)
 	}
 
-	// 保存结果
+	if outputFile == "" {
+		fmt.Println(formatted)
+		return
+	}
+
+	// 保存结果，结尾换行与 check 使用的项目配置一致
+	if cliConfig.FinalNewline {
+		formatted += "\n"
+	}
 	err = saveJSON(outputFile, formatted, verbose)
 	if err != nil {
 		fmt.Printf("保存结果失败: %s\n", err)
-		os.Exit(1)
+		exitCLI(1)
 	}
 
 	fmt.Printf("格式化完成: %s\n", outputFile)
@@ -824,16 +1102,29 @@ func runFormat(args []string, verbose bool) {
 
 // runMinify 运行minify命令
 func runMinify(args []string, verbose bool) {
-	if len(args) < 1 || len(args) > 2 {
+	// 解析选项
+	quiet := false
+	fileArgs := args
+
+	for i := 0; i < len(fileArgs); i++ {
+		if fileArgs[i] == "--quiet" {
+			quiet = true
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+	}
+
+	if len(fileArgs) < 1 || len(fileArgs) > 2 {
 		fmt.Println("错误: minify命令需要1-2个文件参数")
-		fmt.Println("\n用法: leptjson minify FILE [OUTPUT]")
+		fmt.Println("\n用法: leptjson minify [--quiet] FILE [OUTPUT]")
 		return
 	}
 
-	inputFile := args[0]
+	inputFile := fileArgs[0]
 	outputFile := ""
-	if len(args) == 2 {
-		outputFile = args[1]
+	if len(fileArgs) == 2 {
+		outputFile = fileArgs[1]
 	} else {
 		// 默认输出文件名
 		outputFile = inputFile + ".min.json"
@@ -844,24 +1135,27 @@ func runMinify(args []string, verbose bool) {
 	}
 
 	// 加载JSON
-	v, err := loadJSON(inputFile, verbose)
+	v, err := loadJSONWithProgress(inputFile, verbose, !quiet)
 	if err != nil {
 		fmt.Printf("最小化失败: %s\n", err)
-		os.Exit(1)
+		exitCLI(1)
 	}
 
 	// 最小化JSON
 	minified, err := minifyJSON(v)
 	if err != nil {
 		fmt.Printf("最小化失败: %s\n", err)
-		os.Exit(1)
+		exitCLI(1)
 	}
 
 	// 保存结果
+	if cliConfig.FinalNewline {
+		minified += "\n"
+	}
 	err = saveJSON(outputFile, minified, verbose)
 	if err != nil {
 		fmt.Printf("保存结果失败: %s\n", err)
-		os.Exit(1)
+		exitCLI(1)
 	}
 
 	fmt.Printf("最小化完成: %s\n", outputFile)
@@ -871,20 +1165,26 @@ func runMinify(args []string, verbose bool) {
 func runStats(args []string, verbose bool) {
 	// 解析选项和参数
 	jsonOutput := false
+	quiet := false
 	fileArgs := args
 
-	for i, arg := range args {
-		if arg == "--json" {
+	for i := 0; i < len(fileArgs); i++ {
+		switch fileArgs[i] {
+		case "--json":
 			jsonOutput = true
-			// 从参数列表中移除选项
-			fileArgs = append(args[:i], args[i+1:]...)
-			break
+		case "--quiet":
+			quiet = true
+		default:
+			continue
 		}
+		// 从参数列表中移除选项
+		fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+		i--
 	}
 
 	if len(fileArgs) != 1 {
 		fmt.Println("错误: stats命令需要一个文件参数")
-		fmt.Println("\n用法: leptjson stats [--json] FILE")
+		fmt.Println("\n用法: leptjson stats [--json] [--quiet] FILE")
 		return
 	}
 
@@ -894,14 +1194,14 @@ func runStats(args []string, verbose bool) {
 	}
 
 	// 加载JSON
-	v, err := loadJSON(filePath, verbose)
+	v, err := loadJSONWithProgress(filePath, verbose, !quiet)
 	if err != nil {
 		fmt.Printf("分析失败: %s\n", err)
-		os.Exit(1)
+		exitCLI(1)
 	}
 
 	// 计算统计信息
-	stats := calculateStats(v)
+	stats := CalculateStats(v)
 
 	// 输出统计信息
 	if jsonOutput {
@@ -909,7 +1209,7 @@ func runStats(args []string, verbose bool) {
 		statsJSON, err := json.MarshalIndent(stats, "", "  ")
 		if err != nil {
 			fmt.Printf("生成JSON统计信息失败: %s\n", err)
-			os.Exit(1)
+			exitCLI(1)
 		}
 		fmt.Println(string(statsJSON))
 	} else {
@@ -922,6 +1222,10 @@ func runStats(args []string, verbose bool) {
 		fmt.Printf("布尔值数量: %d\n", stats.BooleanCount)
 		fmt.Printf("null值数量: %d\n", stats.NullCount)
 		fmt.Printf("总键数量: %d\n", stats.KeyCount)
+		fmt.Printf("不同键数量: %d\n", stats.UniqueKeyCount)
+		if stats.InternedKeyBytes > 0 {
+			fmt.Printf("键驻留节省: %d字节\n", stats.InternedKeyBytes)
+		}
 		fmt.Printf("最大深度: %d\n", stats.MaxDepth)
 		if stats.MaxKeyLength > 0 {
 			fmt.Printf("最长键: '%s' (%d字符)\n", stats.LongestKey, stats.MaxKeyLength)
@@ -929,6 +1233,91 @@ func runStats(args []string, verbose bool) {
 	}
 }
 
+// runAudit 运行audit命令
+func runAudit(args []string, verbose bool) {
+	outputFormat := "text"
+	options := DefaultAuditOptions()
+	fileArgs := args
+
+	for i := 0; i < len(fileArgs); i++ {
+		arg := fileArgs[i]
+		var target *int
+		switch {
+		case strings.HasPrefix(arg, "--format="):
+			outputFormat = strings.TrimPrefix(arg, "--format=")
+			if outputFormat != "text" && outputFormat != "json" {
+				fmt.Printf("错误: 无效的输出格式: %s\n", outputFormat)
+				fmt.Println("有效的格式: text, json")
+				return
+			}
+		case strings.HasPrefix(arg, "--max-depth="):
+			target = &options.MaxDepth
+		case strings.HasPrefix(arg, "--max-string="):
+			target = &options.MaxStringLength
+		case strings.HasPrefix(arg, "--max-expansion="):
+			target = &options.MaxRefExpansion
+		default:
+			continue
+		}
+		if target != nil {
+			n, err := strconv.Atoi(arg[strings.Index(arg, "=")+1:])
+			if err != nil || n <= 0 {
+				fmt.Printf("错误: 无效的阈值: %s\n", arg)
+				return
+			}
+			*target = n
+		}
+		// 从参数列表中移除选项
+		fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+		i--
+	}
+
+	if len(fileArgs) != 1 {
+		fmt.Println("错误: audit命令需要一个文件参数")
+		fmt.Println("\n用法: leptjson audit [选项] FILE")
+		return
+	}
+
+	inputFile := fileArgs[0]
+	if verbose {
+		fmt.Printf("检查文件: %s\n", inputFile)
+	}
+
+	// 读取原始内容，不经过默认的解析限制
+	data, err := os.ReadFile(inputFile)
+	if err != nil {
+		fmt.Printf("读取文件失败: %s\n", err)
+		exitCLI(1)
+	}
+
+	report := Audit(string(data), options)
+
+	if outputFormat == "json" {
+		reportJSON, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			fmt.Printf("生成JSON结果失败: %s\n", err)
+			exitCLI(1)
+		}
+		fmt.Println(string(reportJSON))
+	} else {
+		fmt.Printf("风险等级: %s（%d 个值，最大嵌套深度 %d）\n", report.Risk, report.Values, report.MaxDepth)
+		if len(report.Findings) == 0 {
+			fmt.Println("没有发现可疑的结构")
+		}
+		for i, f := range report.Findings {
+			fmt.Printf("%d. [%s] %s %s: %s\n", i+1, f.Risk, f.Kind, f.Path, f.Message)
+		}
+		if report.Truncated {
+			fmt.Printf("发现过多，只显示前 %d 项\n", len(report.Findings))
+		}
+	}
+
+	// 高风险时设置退出码
+	if report.Risk == RISK_HIGH {
+		exitCLI(2)
+	}
+}
+
 // runFind 运行find命令
 func runFind(args []string, verbose bool) {
 	// 解析选项和参数
@@ -967,14 +1356,14 @@ func runFind(args []string, verbose bool) {
 	v, err := loadJSON(filePath, verbose)
 	if err != nil {
 		fmt.Printf("查找失败: %s\n", err)
-		os.Exit(1)
+		exitCLI(1)
 	}
 
 	// 执行JSONPath搜索
 	result, err := findByPath(v, jsonPath)
 	if err != nil {
 		fmt.Printf("查找失败: %s\n", err)
-		os.Exit(1)
+		exitCLI(1)
 	}
 
 	// 输出结果
@@ -984,7 +1373,7 @@ func runFind(args []string, verbose bool) {
 		output, err := minifyJSON(result)
 		if err != nil {
 			fmt.Printf("生成输出失败: %s\n", err)
-			os.Exit(1)
+			exitCLI(1)
 		}
 		fmt.Println(output)
 	case "pretty":
@@ -992,14 +1381,14 @@ func runFind(args []string, verbose bool) {
 		output, err := formatJSON(result, "  ")
 		if err != nil {
 			fmt.Printf("生成输出失败: %s\n", err)
-			os.Exit(1)
+			exitCLI(1)
 		}
 		fmt.Println(output)
 	case "raw":
 		// 原始值输出
 		switch result.Type {
 		case STRING:
-			fmt.Println(result.S)
+			fmt.Println(GetString(result))
 		case NUMBER:
 			fmt.Println(result.N)
 		case TRUE:
@@ -1013,7 +1402,7 @@ func runFind(args []string, verbose bool) {
 			output, err := formatJSON(result, "  ")
 			if err != nil {
 				fmt.Printf("生成输出失败: %s\n", err)
-				os.Exit(1)
+				exitCLI(1)
 			}
 			fmt.Println(output)
 		}
@@ -1023,21 +1412,47 @@ func runFind(args []string, verbose bool) {
 // runCompare 运行compare命令
 func runCompare(args []string, verbose bool) {
 	// 解析选项和参数
-	jsonOutput := false
+	outputFormat := "text"
+	var options CompareOptions
 	fileArgs := args
 
-	for i, arg := range args {
-		if arg == "--json" {
-			jsonOutput = true
-			// 从参数列表中移除选项
-			fileArgs = append(args[:i], args[i+1:]...)
-			break
+	for i := 0; i < len(fileArgs); i++ {
+		arg := fileArgs[i]
+		switch {
+		case arg == "--json":
+			outputFormat = "json"
+		case strings.HasPrefix(arg, "--normalize="):
+			form, err := ParseNormalizationForm(strings.TrimPrefix(arg, "--normalize="))
+			if err != nil {
+				fmt.Printf("错误: %s\n", err)
+				exitCLI(1)
+				return
+			}
+			options.Normalization = form
+		case arg == "--lcs":
+			options.LCSArrays = true
+		case strings.HasPrefix(arg, "--array-key="):
+			options.ArrayKey = strings.TrimPrefix(arg, "--array-key=")
+		case strings.HasPrefix(arg, "--ignore="):
+			options.IgnorePaths = append(options.IgnorePaths, strings.TrimPrefix(arg, "--ignore="))
+		case strings.HasPrefix(arg, "--output="):
+			outputFormat = strings.TrimPrefix(arg, "--output=")
+			if outputFormat != "text" && outputFormat != "json" && outputFormat != "html" {
+				fmt.Printf("错误: 无效的输出格式: %s\n", outputFormat)
+				fmt.Println("有效的格式: text, json, html")
+				return
+			}
+		default:
+			continue
 		}
+		// 从参数列表中移除选项
+		fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+		i--
 	}
 
 	if len(fileArgs) != 2 {
 		fmt.Println("错误: compare命令需要两个文件参数")
-		fmt.Println("\n用法: leptjson compare [--json] FILE1 FILE2")
+		fmt.Println("\n用法: leptjson compare [--json] [--output=FORMAT] [--normalize=FORM] [--ignore=PATH]... [--array-key=KEY] [--lcs] FILE1 FILE2")
 		return
 	}
 
@@ -1052,17 +1467,30 @@ func runCompare(args []string, verbose bool) {
 	v1, err := loadJSON(file1, verbose)
 	if err != nil {
 		fmt.Printf("加载第一个文件失败: %s\n", err)
-		os.Exit(1)
+		exitCLI(1)
 	}
 
 	v2, err := loadJSON(file2, verbose)
 	if err != nil {
 		fmt.Printf("加载第二个文件失败: %s\n", err)
-		os.Exit(1)
+		exitCLI(1)
 	}
 
 	// 比较JSON
-	differences := compareJSON(v1, v2)
+	compared, err := CompareValuesWithOptions(v1, v2, options)
+	if err != nil {
+		fmt.Printf("错误: %s\n", err)
+		exitCLI(1)
+	}
+	if outputFormat == "html" {
+		// HTML报告即使没有差异也输出，便于作为CI产物保存
+		if err := WriteCompareHTML(os.Stdout, file1, file2, v1, v2, compared); err != nil {
+			fmt.Fprintf(os.Stderr, "生成HTML报告失败: %s\n", err)
+			exitCLI(1)
+		}
+		return
+	}
+	differences := differenceMessages(compared)
 
 	// 输出差异
 	if len(differences) == 0 {
@@ -1070,12 +1498,12 @@ func runCompare(args []string, verbose bool) {
 		return
 	}
 
-	if jsonOutput {
+	if outputFormat == "json" {
 		// 以JSON格式输出差异
 		diffJSON, err := json.MarshalIndent(differences, "", "  ")
 		if err != nil {
 			fmt.Printf("生成JSON差异报告失败: %s\n", err)
-			os.Exit(1)
+			exitCLI(1)
 		}
 		fmt.Println(string(diffJSON))
 	} else {
@@ -1171,36 +1599,128 @@ func findByPath(v *Value, path string) (*Value, error) {
 
 // 验证结果结构
 type ValidationResult struct {
-	Valid   bool     `json:"valid"`
-	Errors  []string `json:"errors,omitempty"`
-	Message string   `json:"message,omitempty"`
+	Valid   bool              `json:"valid"`
+	Errors  []string          `json:"errors,omitempty"`
+	Issues  []ValidationIssue `json:"issues,omitempty"` // 与 Errors 一一对应的结构化错误
+	Message string            `json:"message,omitempty"`
+}
+
+// ValidationIssue 是一条结构化的验证错误
+type ValidationIssue struct {
+	Path    string                 `json:"path"`             // 出错的位置，如 $.items[0].name
+	Keyword string                 `json:"keyword"`          // 未满足的Schema关键字，如 required、minimum
+	Message string                 `json:"message"`          // 可读的错误描述
+	Params  map[string]interface{} `json:"params,omitempty"` // 生成描述用到的参数，如 actual、limit
+}
+
+// 创建验证错误，描述在所属的Schema层级统一生成
+func newValidationIssue(path, keyword string, params map[string]interface{}) ValidationIssue {
+	return ValidationIssue{Path: path, Keyword: keyword, Params: params}
 }
 
-// JSON Schema验证的实现函数
+// JSON Schema验证的实现函数，使用默认语言的错误描述
 func validateWithSchema(schema, data *Value) ValidationResult {
-	// 创建验证结果
-	result := ValidationResult{
-		Valid: true,
+	translator, _ := LookupValidationTranslator(DefaultValidationLanguage)
+	return ValidateWithTranslator(schema, data, translator)
+}
+
+// ValidateWithTranslator 使用 schema 验证 data，错误描述由 translator 生成
+//
+// schema 中的 x-errorMessage 优先于 translator。translator 为 nil 时使用默认语言。
+// 只能解析文档内部的 $ref，无法解析的引用作为验证错误报告。
+func ValidateWithTranslator(schema, data *Value, translator ValidationTranslator) ValidationResult {
+	return validateWithContext(schema, data, newSchemaContext(translator, newRefResolver(schema, "", nil)))
+}
+
+// ValidationOptions 是 ValidateWithOptions 的选项
+type ValidationOptions struct {
+	Translator ValidationTranslator // 错误描述的翻译器，为 nil 时使用默认语言
+	Loader     SchemaLoader         // 加载外部 $ref 的加载器，为 nil 时只能解析文档内部的引用
+	BaseURI    string               // schema 自身的 URI，相对 $ref 以它为基准
+	Budget     EvalBudget           // 验证的时间和步数限制，超出时返回 *BudgetError
+}
+
+// ValidateWithOptions 使用 schema 验证 data
+//
+// 验证之前先解析 schema 中的全部 $ref，任何引用无法解析时返回错误。
+func ValidateWithOptions(schema, data *Value, options ValidationOptions) (ValidationResult, error) {
+	return validateWithOptionsContext(context.Background(), schema, data, options)
+}
+
+// validateWithOptionsContext 实现 ValidateWithOptions，ctx 被取消时中止验证
+func validateWithOptionsContext(ctx context.Context, schema, data *Value, options ValidationOptions) (ValidationResult, error) {
+	refs := newRefResolver(schema, options.BaseURI, options.Loader)
+	if err := refs.preload(); err != nil {
+		return ValidationResult{}, err
+	}
+	budget, cancel := newEvalBudget(ctx, "Schema 验证", options.Budget)
+	defer cancel()
+	sctx := newSchemaContext(options.Translator, refs)
+	sctx.budget = budget
+	result := validateWithContext(schema, data, sctx)
+	if err := budget.failed(); err != nil {
+		return ValidationResult{}, err
+	}
+	return result, nil
+}
+
+// schemaContext 是一次验证中不变的状态
+type schemaContext struct {
+	translator ValidationTranslator
+	refs       *refResolver
+	budget     *evalBudget // 为 nil 时不限制
+}
+
+// 创建验证状态，translator 为 nil 时使用默认语言
+func newSchemaContext(translator ValidationTranslator, refs *refResolver) *schemaContext {
+	if translator == nil {
+		translator, _ = LookupValidationTranslator(DefaultValidationLanguage)
 	}
+	return &schemaContext{translator: translator, refs: refs}
+}
 
+// 执行验证并汇总结果
+func validateWithContext(schema, data *Value, ctx *schemaContext) ValidationResult {
 	// 调用JSON Schema验证函数
-	schemaErrs := validateJSONSchema(schema, data, "$")
-	if len(schemaErrs) > 0 {
+	return newValidationResult(validateJSONSchema(schema, data, "$", ctx), ctx.translator)
+}
+
+// 根据验证错误创建验证结果
+func newValidationResult(issues []ValidationIssue, translator ValidationTranslator) ValidationResult {
+	result := ValidationResult{
+		Valid: true,
+	}
+	if len(issues) > 0 {
 		result.Valid = false
-		result.Errors = schemaErrs
-		if len(schemaErrs) == 1 {
-			result.Message = "发现1个验证错误"
-		} else {
-			result.Message = fmt.Sprintf("发现%d个验证错误", len(schemaErrs))
+		result.Issues = issues
+		for _, issue := range issues {
+			result.Errors = append(result.Errors, issue.Message)
 		}
+		result.Message = translator.Summary(len(issues))
 	}
-
 	return result
 }
 
 // 实际的JSON Schema验证逻辑
-func validateJSONSchema(schema, data *Value, path string) []string {
-	errors := []string{}
+func validateJSONSchema(schema, data *Value, path string, ctx *schemaContext) []ValidationIssue {
+	// 超出限制后不再继续验证，由调用者报告错误
+	if ctx.budget.step() != nil {
+		return nil
+	}
+
+	errors := []ValidationIssue{} // 当前层级的错误
+	nested := []ValidationIssue{} // 子元素的错误，已经生成了描述
+
+	// 有$ref时使用引用的Schema，忽略并列的关键字
+	if ref := findObjectKey(schema, "$ref"); ref != nil && ref.Type == STRING {
+		target, err := ctx.refs.resolve(schema)
+		if err != nil {
+			issue := newValidationIssue(path, "$ref", map[string]interface{}{"ref": GetString(ref), "error": err.Error()})
+			issue.Message = issueMessage(schema, issue, ctx.translator)
+			return []ValidationIssue{issue}
+		}
+		return validateJSONSchema(target, data, path, ctx)
+	}
 
 	// 检查类型验证
 	if typeSchema := findObjectKey(schema, "type"); typeSchema != nil {
@@ -1214,19 +1734,19 @@ func validateJSONSchema(schema, data *Value, path string) []string {
 		// 数值验证
 		if minimumSchema := findObjectKey(schema, "minimum"); minimumSchema != nil && minimumSchema.Type == NUMBER {
 			if data.N < minimumSchema.N {
-				errors = append(errors, fmt.Sprintf("位于'%s'的数值%g小于最小值%g", path, data.N, minimumSchema.N))
+				errors = append(errors, newValidationIssue(path, "minimum", map[string]interface{}{"actual": data.N, "limit": minimumSchema.N}))
 			}
 		}
 		if maximumSchema := findObjectKey(schema, "maximum"); maximumSchema != nil && maximumSchema.Type == NUMBER {
 			if data.N > maximumSchema.N {
-				errors = append(errors, fmt.Sprintf("位于'%s'的数值%g大于最大值%g", path, data.N, maximumSchema.N))
+				errors = append(errors, newValidationIssue(path, "maximum", map[string]interface{}{"actual": data.N, "limit": maximumSchema.N}))
 			}
 		}
 		if multipleOfSchema := findObjectKey(schema, "multipleOf"); multipleOfSchema != nil && multipleOfSchema.Type == NUMBER && multipleOfSchema.N > 0 {
 			// 检查是否是multipleOf的倍数
 			remainder := math.Mod(data.N, multipleOfSchema.N)
 			if math.Abs(remainder) > 1e-10 { // 使用小误差范围来处理浮点数比较
-				errors = append(errors, fmt.Sprintf("位于'%s'的数值%g不是%g的倍数", path, data.N, multipleOfSchema.N))
+				errors = append(errors, newValidationIssue(path, "multipleOf", map[string]interface{}{"actual": data.N, "limit": multipleOfSchema.N}))
 			}
 		}
 
@@ -1234,22 +1754,41 @@ func validateJSONSchema(schema, data *Value, path string) []string {
 		// 字符串验证
 		if minLengthSchema := findObjectKey(schema, "minLength"); minLengthSchema != nil && minLengthSchema.Type == NUMBER {
 			minLen := int(minLengthSchema.N)
-			if len(data.S) < minLen {
-				errors = append(errors, fmt.Sprintf("位于'%s'的字符串长度%d小于最小长度%d", path, len(data.S), minLen))
+			if len(GetString(data)) < minLen {
+				errors = append(errors, newValidationIssue(path, "minLength", map[string]interface{}{"actual": len(GetString(data)), "limit": minLen}))
 			}
 		}
 		if maxLengthSchema := findObjectKey(schema, "maxLength"); maxLengthSchema != nil && maxLengthSchema.Type == NUMBER {
 			maxLen := int(maxLengthSchema.N)
-			if len(data.S) > maxLen {
-				errors = append(errors, fmt.Sprintf("位于'%s'的字符串长度%d大于最大长度%d", path, len(data.S), maxLen))
+			if len(GetString(data)) > maxLen {
+				errors = append(errors, newValidationIssue(path, "maxLength", map[string]interface{}{"actual": len(GetString(data)), "limit": maxLen}))
 			}
 		}
 		if patternSchema := findObjectKey(schema, "pattern"); patternSchema != nil && patternSchema.Type == STRING {
-			pattern := patternSchema.S
-			matched, err := regexp.MatchString(pattern, data.S)
+			pattern := GetString(patternSchema)
+			matched, err := regexp.MatchString(pattern, GetString(data))
 			if err != nil || !matched {
-				errors = append(errors, fmt.Sprintf("位于'%s'的字符串不匹配正则表达式'%s'", path, pattern))
+				errors = append(errors, newValidationIssue(path, "pattern", map[string]interface{}{"pattern": pattern}))
+			}
+		}
+		if formatSchema := findObjectKey(schema, "format"); formatSchema != nil && formatSchema.Type == STRING {
+			if !checkFormat(GetString(formatSchema), GetString(data)) {
+				errors = append(errors, newValidationIssue(path, "format", map[string]interface{}{"format": GetString(formatSchema)}))
+			}
+		}
+
+		// 解码嵌入的文档，并用contentSchema验证
+		contentDoc, keyword, err := decodeContent(schema, GetString(data))
+		if err != nil {
+			params := map[string]interface{}{"error": err.Error()}
+			if keyword == "contentEncoding" {
+				params["encoding"] = GetString(findObjectKey(schema, "contentEncoding"))
+			} else {
+				params["mediaType"] = GetString(findObjectKey(schema, "contentMediaType"))
 			}
+			errors = append(errors, newValidationIssue(path, keyword, params))
+		} else if contentSchema := findObjectKey(schema, "contentSchema"); contentDoc != nil && contentSchema != nil {
+			nested = append(nested, validateJSONSchema(contentSchema, contentDoc, path+contentPathSuffix, ctx)...)
 		}
 
 	case ARRAY:
@@ -1257,13 +1796,13 @@ func validateJSONSchema(schema, data *Value, path string) []string {
 		if minItemsSchema := findObjectKey(schema, "minItems"); minItemsSchema != nil && minItemsSchema.Type == NUMBER {
 			minItems := int(minItemsSchema.N)
 			if len(data.A) < minItems {
-				errors = append(errors, fmt.Sprintf("位于'%s'的数组元素数量%d小于最小数量%d", path, len(data.A), minItems))
+				errors = append(errors, newValidationIssue(path, "minItems", map[string]interface{}{"actual": len(data.A), "limit": minItems}))
 			}
 		}
 		if maxItemsSchema := findObjectKey(schema, "maxItems"); maxItemsSchema != nil && maxItemsSchema.Type == NUMBER {
 			maxItems := int(maxItemsSchema.N)
 			if len(data.A) > maxItems {
-				errors = append(errors, fmt.Sprintf("位于'%s'的数组元素数量%d大于最大数量%d", path, len(data.A), maxItems))
+				errors = append(errors, newValidationIssue(path, "maxItems", map[string]interface{}{"actual": len(data.A), "limit": maxItems}))
 			}
 		}
 
@@ -1273,8 +1812,8 @@ func validateJSONSchema(schema, data *Value, path string) []string {
 				// 所有项使用相同的schema
 				for i, item := range data.A {
 					itemPath := fmt.Sprintf("%s[%d]", path, i)
-					itemErrors := validateJSONSchema(itemsSchema, item, itemPath)
-					errors = append(errors, itemErrors...)
+					itemErrors := validateJSONSchema(itemsSchema, item, itemPath, ctx)
+					nested = append(nested, itemErrors...)
 				}
 			}
 		}
@@ -1284,9 +1823,9 @@ func validateJSONSchema(schema, data *Value, path string) []string {
 		if requiredSchema := findObjectKey(schema, "required"); requiredSchema != nil && requiredSchema.Type == ARRAY {
 			for _, reqVal := range requiredSchema.A {
 				if reqVal.Type == STRING {
-					requiredProp := reqVal.S
+					requiredProp := GetString(reqVal)
 					if !hasObjectKey(data, requiredProp) {
-						errors = append(errors, fmt.Sprintf("位于'%s'的对象缺少必需的属性'%s'", path, requiredProp))
+						errors = append(errors, newValidationIssue(path, "required", map[string]interface{}{"property": requiredProp}))
 					}
 				}
 			}
@@ -1298,39 +1837,47 @@ func validateJSONSchema(schema, data *Value, path string) []string {
 				propName := schemaProp.K
 				if propValue := findObjectKeyValue(data, propName); propValue != nil {
 					propPath := fmt.Sprintf("%s.%s", path, propName)
-					propErrors := validateJSONSchema(schemaProp.V, propValue, propPath)
-					errors = append(errors, propErrors...)
+					propErrors := validateJSONSchema(schemaProp.V, propValue, propPath, ctx)
+					nested = append(nested, propErrors...)
 				}
 			}
 		}
 	}
 
-	return errors
+	// 当前层级的错误使用这一层Schema的x-errorMessage
+	for i := range errors {
+		errors[i].Message = issueMessage(schema, errors[i], ctx.translator)
+	}
+	return append(errors, nested...)
 }
 
 // 验证数据类型
-func validateType(typeSchema, data *Value, path string) []string {
-	errors := []string{}
+func validateType(typeSchema, data *Value, path string) []ValidationIssue {
+	errors := []ValidationIssue{}
 
 	// 类型可以是单个类型或类型数组
 	if typeSchema.Type == STRING {
-		expectedType := typeSchema.S
+		expectedType := GetString(typeSchema)
 		if !matchesType(data, expectedType) {
-			errors = append(errors, fmt.Sprintf("位于'%s'的值类型为'%s'，而不是预期的'%s'",
-				path, getValueTypeName(data.Type), expectedType))
+			errors = append(errors, newValidationIssue(path, "type", map[string]interface{}{
+				"actual": getValueTypeName(data.Type), "expected": expectedType}))
 		}
 	} else if typeSchema.Type == ARRAY {
 		// 类型是数组时，值必须匹配其中一种类型
 		matched := false
+		allowed := []string{}
 		for _, typeVal := range typeSchema.A {
-			if typeVal.Type == STRING && matchesType(data, typeVal.S) {
-				matched = true
-				break
+			if typeVal.Type == STRING {
+				allowed = append(allowed, GetString(typeVal))
+				if matchesType(data, GetString(typeVal)) {
+					matched = true
+					break
+				}
 			}
 		}
 		if !matched {
-			errors = append(errors, fmt.Sprintf("位于'%s'的值类型'%s'不在允许的类型列表中",
-				path, getValueTypeName(data.Type)))
+			errors = append(errors, newValidationIssue(path, "type", map[string]interface{}{
+				"actual": getValueTypeName(data.Type), "expected": allowed}))
 		}
 	}
 
@@ -1384,30 +1931,81 @@ func findObjectKeyValue(v *Value, key string) *Value {
 	return findObjectKey(v, key)
 }
 
+// defaultSchemaCacheDir 返回远程Schema的默认缓存目录，无法确定时不缓存
+func defaultSchemaCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "leptjson", "schemas")
+}
+
 // runValidate 实现validate命令
 func runValidate(args []string, verbose bool) {
 	// 解析选项和参数
 	outputFormat := "text" // 默认为文本格式
+	lang := DefaultValidationLanguage
+	loader := &URLSchemaLoader{CacheDir: defaultSchemaCacheDir()}
 	fileArgs := args
 
-	for i, arg := range args {
-		if strings.HasPrefix(arg, "--format=") {
-			outputFormat = strings.TrimPrefix(arg, "--format=")
+	for i := 0; i < len(fileArgs); i++ {
+		arg := fileArgs[i]
+		if strings.HasPrefix(arg, "--lang=") {
+			lang = strings.TrimPrefix(arg, "--lang=")
+			// 从参数列表中移除选项
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+		if strings.HasPrefix(arg, "--schema-cache=") {
+			loader.CacheDir = strings.TrimPrefix(arg, "--schema-cache=")
+			// 从参数列表中移除选项
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+		if arg == "--offline" {
+			loader.Offline = true
+			// 从参数列表中移除选项
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+		// --output 是 --format 的别名
+		if strings.HasPrefix(arg, "--format=") || strings.HasPrefix(arg, "--output=") {
+			outputFormat = arg[strings.Index(arg, "=")+1:]
 			// 检查输出格式是否有效
-			if outputFormat != "text" && outputFormat != "json" {
+			if outputFormat != "text" && outputFormat != "json" && outputFormat != "html" {
 				fmt.Printf("错误: 无效的输出格式: %s\n", outputFormat)
-				fmt.Println("有效的格式: text, json")
+				fmt.Println("有效的格式: text, json, html")
 				return
 			}
 			// 从参数列表中移除选项
-			fileArgs = append(args[:i], args[i+1:]...)
-			break
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+		}
+	}
+
+	// 只给出数据文件时使用项目配置中为它映射的Schema
+	if len(fileArgs) == 1 {
+		if schema, ok := cliConfig.SchemaFor(fileArgs[0]); ok {
+			fileArgs = []string{schema, fileArgs[0]}
+		} else {
+			fmt.Printf("错误: 项目配置中没有适用于 '%s' 的Schema\n", fileArgs[0])
+			return
 		}
 	}
 
 	if len(fileArgs) != 2 {
 		fmt.Println("错误: validate命令需要两个文件参数")
-		fmt.Println("\n用法: leptjson validate [--format=FORMAT] SCHEMA FILE")
+		fmt.Println("\n用法: leptjson validate [--format=FORMAT] [--lang=LANG] [SCHEMA] FILE")
+		return
+	}
+
+	translator, ok := LookupValidationTranslator(lang)
+	if !ok {
+		fmt.Printf("错误: 未知的语言: %s\n", lang)
+		fmt.Printf("可用的语言: %s\n", strings.Join(ValidationLanguages(), ", "))
 		return
 	}
 
@@ -1422,26 +2020,46 @@ func runValidate(args []string, verbose bool) {
 	schema, err := loadJSON(schemaFile, verbose)
 	if err != nil {
 		fmt.Printf("加载Schema失败: %s\n", err)
-		os.Exit(1)
+		exitCLI(1)
 	}
 
 	// 加载数据文件
 	data, err := loadJSON(dataFile, verbose)
 	if err != nil {
 		fmt.Printf("加载数据文件失败: %s\n", err)
-		os.Exit(1)
+		exitCLI(1)
+	}
+
+	// 相对$ref以Schema文件所在位置为基准
+	baseURI, err := SchemaFileURI(schemaFile)
+	if err != nil {
+		fmt.Printf("无法确定Schema路径: %s\n", err)
+		exitCLI(1)
 	}
 
 	// 执行验证
-	result := validateWithSchema(schema, data)
+	result, err := ValidateWithOptions(schema, data, ValidationOptions{
+		Translator: translator,
+		Loader:     loader,
+		BaseURI:    baseURI,
+	})
+	if err != nil {
+		fmt.Printf("解析Schema引用失败: %s\n", err)
+		exitCLI(1)
+	}
 
 	// 输出验证结果
-	if outputFormat == "json" {
+	if outputFormat == "html" {
+		if err := WriteValidationHTML(os.Stdout, dataFile, data, result); err != nil {
+			fmt.Fprintf(os.Stderr, "生成HTML报告失败: %s\n", err)
+			exitCLI(1)
+		}
+	} else if outputFormat == "json" {
 		// JSON格式输出
 		resultJSON, err := json.MarshalIndent(result, "", "  ")
 		if err != nil {
 			fmt.Printf("生成JSON结果失败: %s\n", err)
-			os.Exit(1)
+			exitCLI(1)
 		}
 		fmt.Println(string(resultJSON))
 	} else {
@@ -1458,77 +2076,411 @@ func runValidate(args []string, verbose bool) {
 
 	// 如果验证失败，设置退出码
 	if !result.Valid {
-		os.Exit(2) // 使用非0的退出码表示验证失败
+		exitCLI(2) // 使用非0的退出码表示验证失败
 	}
 }
 
-// JSON Pointer解析器
-type CliJSONPointer struct {
-	Tokens []string
-}
+// 运行prune命令
+func runPrune(args []string, verbose bool) {
+	outputFile := "" // 输出文件，为空时输出到标准输出
+	fileArgs := args
 
-// 创建新的JSON Pointer
-func NewJSONPointer(pointer string) (*CliJSONPointer, error) {
-	if pointer == "" {
-		// 空指针指向根
-		return &CliJSONPointer{Tokens: []string{}}, nil
+	for i := 0; i < len(fileArgs); i++ {
+		arg := fileArgs[i]
+		if strings.HasPrefix(arg, "--output=") {
+			outputFile = strings.TrimPrefix(arg, "--output=")
+			// 从参数列表中移除
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+		}
 	}
 
-	if !strings.HasPrefix(pointer, "/") {
-		return nil, fmt.Errorf("JSON Pointer必须以/开头: %s", pointer)
+	if len(fileArgs) != 2 {
+		fmt.Println("错误: prune命令需要两个文件参数")
+		fmt.Println("\n用法: leptjson prune [--output=FILE] SCHEMA FILE")
+		return
 	}
 
-	// 分割指针并解码token
-	tokens := strings.Split(pointer[1:], "/")
-	for i, token := range tokens {
-		// 解码 ~1 为 / 和 ~0 为 ~
-		token = strings.ReplaceAll(token, "~1", "/")
-		token = strings.ReplaceAll(token, "~0", "~")
-		tokens[i] = token
+	schemaFile := fileArgs[0]
+	dataFile := fileArgs[1]
+
+	if verbose {
+		fmt.Printf("使用Schema '%s' 修剪文件 '%s'\n", schemaFile, dataFile)
 	}
 
-	return &CliJSONPointer{Tokens: tokens}, nil
-}
+	// 加载Schema文件
+	schema, err := loadJSON(schemaFile, verbose)
+	if err != nil {
+		fmt.Printf("加载Schema失败: %s\n", err)
+		exitCLI(1)
+	}
 
-// 解析JSON Pointer并返回引用的值
-func ResolvePointer(doc *Value, pointer *CliJSONPointer) (*Value, []*Value, error) {
-	if doc == nil || pointer == nil {
-		return nil, nil, fmt.Errorf("文档或指针为空")
+	// 加载数据文件
+	data, err := loadJSON(dataFile, verbose)
+	if err != nil {
+		fmt.Printf("加载数据文件失败: %s\n", err)
+		exitCLI(1)
 	}
 
-	current := doc
-	parents := []*Value{}
+	removed, err := Prune(data, schema)
+	if err != nil {
+		fmt.Printf("修剪失败: %s\n", err)
+		exitCLI(1)
+	}
 
-	// 如果是根指针，直接返回
-	if len(pointer.Tokens) == 0 {
-		return current, parents, nil
+	jsonStr, err := formatJSON(data, "  ")
+	if err != nil {
+		fmt.Printf("格式化JSON失败: %s\n", err)
+		exitCLI(1)
 	}
 
-	// 遍历指针的每个token
-	for i, token := range pointer.Tokens {
-		switch current.Type {
-		case OBJECT:
-			found := false
-			for _, member := range current.O {
-				if member.K == token {
-					parents = append(parents, current)
-					current = member.V
-					found = true
-					break
-				}
-			}
-			if !found {
-				return nil, nil, fmt.Errorf("对象不包含键 '%s' (在token %d)", token, i)
-			}
+	if outputFile == "" {
+		fmt.Println(jsonStr)
+		return
+	}
+	if err := saveJSON(outputFile, jsonStr, verbose); err != nil {
+		fmt.Printf("保存文件失败: %s\n", err)
+		exitCLI(1)
+	}
+	fmt.Printf("已删除 %d 个未声明的属性并保存到 %s\n", removed, outputFile)
+}
+
+// 实现string-table命令
+func runStringTable(args []string, verbose bool) {
+	options := StringTableOptions{}
+	decode := false
+	var fileArgs []string
+
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "--min-count=") || strings.HasPrefix(arg, "--min-length="):
+			name := arg[2:strings.Index(arg, "=")]
+			value := arg[strings.Index(arg, "=")+1:]
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				fmt.Printf("错误: 无效的%s值: %s\n", name, value)
+				return
+			}
+			if name == "min-count" {
+				options.MinCount = n
+			} else {
+				options.MinLength = n
+			}
+		case arg == "--decode":
+			decode = true
+		default:
+			fileArgs = append(fileArgs, arg)
+		}
+	}
+
+	if len(fileArgs) < 1 || len(fileArgs) > 2 {
+		fmt.Println("错误: string-table命令需要1-2个文件参数")
+		fmt.Println("\n用法: leptjson string-table [--decode] [--min-count=N] [--min-length=N] FILE [OUTPUT]")
+		return
+	}
+
+	doc, err := loadJSON(fileArgs[0], verbose)
+	if err != nil {
+		fmt.Printf("加载文件失败: %s\n", err)
+		exitCLI(1)
+	}
+
+	var result *Value
+	if decode {
+		if result, err = DecodeStringTable(doc); err != nil {
+			fmt.Printf("还原失败: %s\n", err)
+			exitCLI(1)
+		}
+	} else {
+		result = EncodeStringTable(doc, options)
+		if verbose {
+			fmt.Printf("字典中有 %d 个字符串\n", len(GetObjectValueByKey(result, StringTableKey).A))
+		}
+	}
+	text, errCode := Stringify(result)
+	if errCode != STRINGIFY_OK {
+		fmt.Printf("序列化失败: %s\n", errCode)
+		exitCLI(1)
+	}
+
+	if len(fileArgs) == 1 {
+		fmt.Println(text)
+		return
+	}
+	if err := saveJSON(fileArgs[1], text, verbose); err != nil {
+		fmt.Printf("保存文件失败: %s\n", err)
+		exitCLI(1)
+	}
+}
+
+// 实现dedup命令
+func runDedup(args []string, verbose bool) {
+	options := DuplicateOptions{}
+	top := 20
+	jsonOutput := false
+	apply := false
+	outputFile := ""
+	var fileArgs []string
+
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "--min-size=") || strings.HasPrefix(arg, "--top="):
+			name := arg[2:strings.Index(arg, "=")]
+			value := arg[strings.Index(arg, "=")+1:]
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				fmt.Printf("错误: 无效的%s值: %s\n", name, value)
+				return
+			}
+			if name == "min-size" {
+				options.MinSize = n
+			} else {
+				top = n
+			}
+		case arg == "--json":
+			jsonOutput = true
+		case arg == "--apply":
+			apply = true
+		case strings.HasPrefix(arg, "--output="):
+			outputFile = strings.TrimPrefix(arg, "--output=")
+		default:
+			fileArgs = append(fileArgs, arg)
+		}
+	}
+
+	if len(fileArgs) != 1 {
+		fmt.Println("错误: dedup命令需要一个文件参数")
+		fmt.Println("\n用法: leptjson dedup [--min-size=N] [--top=N] [--json] [--apply] [--output=FILE] FILE")
+		return
+	}
+
+	doc, err := loadJSON(fileArgs[0], verbose)
+	if err != nil {
+		fmt.Printf("加载文件失败: %s\n", err)
+		exitCLI(1)
+	}
+
+	var result string
+	if apply {
+		replaced := Deduplicate(doc, options)
+		if verbose {
+			fmt.Printf("替换了 %d 个重复的子树\n", replaced)
+		}
+		result, err = formatJSON(doc, "  ")
+		if err != nil {
+			fmt.Printf("格式化JSON失败: %s\n", err)
+			exitCLI(1)
+		}
+	} else {
+		report := FindDuplicates(doc, options)
+		if jsonOutput {
+			if top > 0 && len(report.Groups) > top {
+				report.Groups = report.Groups[:top]
+			}
+			reportJSON, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				fmt.Printf("生成JSON报告失败: %s\n", err)
+				exitCLI(1)
+			}
+			result = string(reportJSON)
+		} else {
+			result = strings.TrimSuffix(FormatDuplicateReport(report, top), "\n")
+		}
+	}
+
+	if outputFile == "" {
+		fmt.Println(result)
+		return
+	}
+	if err := saveJSON(outputFile, result, verbose); err != nil {
+		fmt.Printf("保存文件失败: %s\n", err)
+		exitCLI(1)
+	}
+}
+
+// format --preview 不带值时的限制：展开4层，每个数组或对象最多20个元素，字符串最多200个字符
+const defaultPreviewLimits = "4,20,200"
+
+// parsePreviewLimits 解析 DEPTH,ITEMS,CHARS 形式的预览限制，0 表示不限制
+func parsePreviewLimits(spec string) ([]int, error) {
+	parts := strings.Split(spec, ",")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("无效的预览限制: %s（格式为 DEPTH,ITEMS,CHARS）", spec)
+	}
+	limits := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("无效的预览限制: %s（格式为 DEPTH,ITEMS,CHARS）", spec)
+		}
+		limits[i] = n
+	}
+	return limits, nil
+}
+
+// 实现anonymize命令
+func runAnonymize(args []string, verbose bool) {
+	options := AnonymizeOptions{}
+	outputFile := ""
+	inPlace := false
+	var fileArgs []string
+
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "--seed="):
+			options.Seed = strings.TrimPrefix(arg, "--seed=")
+		case strings.HasPrefix(arg, "--keep="):
+			options.KeepPaths = append(options.KeepPaths, strings.TrimPrefix(arg, "--keep="))
+		case arg == "--keep-numbers":
+			options.KeepNumbers = true
+		case strings.HasPrefix(arg, "--output="):
+			outputFile = strings.TrimPrefix(arg, "--output=")
+		case arg == "--in-place":
+			inPlace = true
+		default:
+			fileArgs = append(fileArgs, arg)
+		}
+	}
+
+	if len(fileArgs) == 0 || (len(fileArgs) > 1 && !inPlace) || (inPlace && outputFile != "") {
+		fmt.Println("错误: anonymize命令需要一个文件参数，多个文件时需要 --in-place")
+		fmt.Println("\n用法: leptjson anonymize [--seed=KEY] [--keep=PATH]... [--keep-numbers] [--output=FILE | --in-place] FILE...")
+		return
+	}
+
+	// 所有文件共用一个 Anonymizer，文件之间的引用关系保持一致
+	anonymizer := NewAnonymizer(options)
+	for _, file := range fileArgs {
+		doc, err := loadJSON(file, verbose)
+		if err != nil {
+			fmt.Printf("加载文件失败: %s\n", err)
+			exitCLI(1)
+		}
+		if err := anonymizer.Anonymize(doc); err != nil {
+			fmt.Printf("匿名化失败: %s\n", err)
+			exitCLI(1)
+		}
+		jsonStr, err := formatJSON(doc, "  ")
+		if err != nil {
+			fmt.Printf("格式化JSON失败: %s\n", err)
+			exitCLI(1)
+		}
+
+		target := outputFile
+		if inPlace {
+			target = file
+		}
+		if target == "" {
+			fmt.Println(jsonStr)
+			continue
+		}
+		if err := saveJSON(target, jsonStr, verbose); err != nil {
+			fmt.Printf("保存文件失败: %s\n", err)
+			exitCLI(1)
+		}
+		if verbose {
+			fmt.Printf("已匿名化 %s\n", target)
+		}
+	}
+}
+
+// JSON Pointer解析器
+type CliJSONPointer struct {
+	Tokens []string
+	// AllowNegativeIndex 为真时数组索引可以是负数，-1 表示最后一个元素；
+	// 这不是 RFC 6901 的一部分，默认关闭
+	AllowNegativeIndex bool
+}
+
+// 解析数组索引，负数索引转换为从数组开头计算的位置
+//
+// "-" 表示数组末尾之后的位置，需要调用者在此之前单独处理。
+func (p *CliJSONPointer) arrayIndex(token string, length int) (int, error) {
+	index, ok := parseArrayIndexToken(token, p.AllowNegativeIndex)
+	if !ok {
+		return 0, fmt.Errorf("无效的数组索引: %s", token)
+	}
+	if index < 0 {
+		index += length
+		if index < 0 {
+			return 0, fmt.Errorf("数组索引超出范围: %s", token)
+		}
+	}
+	return index, nil
+}
+
+// 返回父路径的指针，保留解析选项
+func (p *CliJSONPointer) parent() *CliJSONPointer {
+	return &CliJSONPointer{Tokens: p.Tokens[:len(p.Tokens)-1], AllowNegativeIndex: p.AllowNegativeIndex}
+}
+
+// 创建新的JSON Pointer
+func NewJSONPointer(pointer string) (*CliJSONPointer, error) {
+	if pointer == "" {
+		// 空指针指向根
+		return &CliJSONPointer{Tokens: []string{}}, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("JSON Pointer必须以/开头: %s", pointer)
+	}
+
+	// 分割指针并解码token
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		// 解码 ~1 为 / 和 ~0 为 ~
+		token = strings.ReplaceAll(token, "~1", "/")
+		token = strings.ReplaceAll(token, "~0", "~")
+		tokens[i] = token
+	}
+
+	return &CliJSONPointer{Tokens: tokens}, nil
+}
+
+// 解析JSON Pointer并返回引用的值
+func ResolvePointer(doc *Value, pointer *CliJSONPointer) (*Value, []*Value, error) {
+	if doc == nil || pointer == nil {
+		return nil, nil, fmt.Errorf("文档或指针为空")
+	}
+
+	current := doc
+	parents := []*Value{}
+
+	// 如果是根指针，直接返回
+	if len(pointer.Tokens) == 0 {
+		return current, parents, nil
+	}
+
+	// 遍历指针的每个token
+	for i, token := range pointer.Tokens {
+		switch current.Type {
+		case OBJECT:
+			found := false
+			for _, member := range current.O {
+				if member.K == token {
+					parents = append(parents, current)
+					current = member.V
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, nil, fmt.Errorf("对象不包含键 '%s' (在token %d)", token, i)
+			}
 
 		case ARRAY:
+			// "-" 指向数组末尾之后，不引用任何已有元素
+			if token == "-" {
+				return nil, nil, fmt.Errorf("数组索引 '-' 指向最后一个元素之后，不存在 (在token %d)", i)
+			}
+
 			// 尝试将token解析为数组索引
-			index, err := strconv.Atoi(token)
+			index, err := pointer.arrayIndex(token, len(current.A))
 			if err != nil {
-				return nil, nil, fmt.Errorf("无效的数组索引 '%s' (在token %d): %v", token, i, err)
+				return nil, nil, fmt.Errorf("%v (在token %d)", err, i)
 			}
 
-			if index < 0 || index >= len(current.A) {
+			if index >= len(current.A) {
 				return nil, nil, fmt.Errorf("数组索引 %d 超出范围 [0-%d] (在token %d)",
 					index, len(current.A)-1, i)
 			}
@@ -1555,7 +2507,7 @@ func PointerAdd(doc *Value, pointer *CliJSONPointer, value *Value) error {
 
 	// 获取父值
 	lastToken := pointer.Tokens[len(pointer.Tokens)-1]
-	parentPointer := &CliJSONPointer{Tokens: pointer.Tokens[:len(pointer.Tokens)-1]}
+	parentPointer := pointer.parent()
 
 	parent, _, err := ResolvePointer(doc, parentPointer)
 	if err != nil {
@@ -1577,11 +2529,6 @@ func PointerAdd(doc *Value, pointer *CliJSONPointer, value *Value) error {
 		parent.O = append(parent.O, Member{K: lastToken, V: value})
 
 	case ARRAY:
-		index, err := strconv.Atoi(lastToken)
-		if err != nil {
-			return fmt.Errorf("无效的数组索引: %s", lastToken)
-		}
-
 		// 检查是否是末尾的特殊值"-"
 		if lastToken == "-" {
 			// 添加到数组末尾
@@ -1589,8 +2536,13 @@ func PointerAdd(doc *Value, pointer *CliJSONPointer, value *Value) error {
 			return nil
 		}
 
+		index, err := pointer.arrayIndex(lastToken, len(parent.A))
+		if err != nil {
+			return err
+		}
+
 		// 检查索引是否在有效范围内
-		if index < 0 || index > len(parent.A) {
+		if index > len(parent.A) {
 			return fmt.Errorf("数组索引超出范围: %d", index)
 		}
 
@@ -1642,13 +2594,13 @@ func PointerRemove(doc *Value, pointer *CliJSONPointer) error {
 		return fmt.Errorf("对象不包含键: %s", lastToken)
 
 	case ARRAY:
-		index, err := strconv.Atoi(lastToken)
+		index, err := pointer.arrayIndex(lastToken, len(parent.A))
 		if err != nil {
-			return fmt.Errorf("无效的数组索引: %s", lastToken)
+			return err
 		}
 
 		// 检查索引是否在有效范围内
-		if index < 0 || index >= len(parent.A) {
+		if index >= len(parent.A) {
 			return fmt.Errorf("数组索引超出范围: %d", index)
 		}
 
@@ -1673,7 +2625,7 @@ func PointerReplace(doc *Value, pointer *CliJSONPointer, value *Value) error {
 
 	// 获取父值
 	lastToken := pointer.Tokens[len(pointer.Tokens)-1]
-	parentPointer := &CliJSONPointer{Tokens: pointer.Tokens[:len(pointer.Tokens)-1]}
+	parentPointer := pointer.parent()
 
 	parent, _, err := ResolvePointer(doc, parentPointer)
 	if err != nil {
@@ -1697,13 +2649,13 @@ func PointerReplace(doc *Value, pointer *CliJSONPointer, value *Value) error {
 		}
 
 	case ARRAY:
-		index, err := strconv.Atoi(lastToken)
+		index, err := pointer.arrayIndex(lastToken, len(parent.A))
 		if err != nil {
-			return fmt.Errorf("无效的数组索引: %s", lastToken)
+			return err
 		}
 
 		// 检查索引是否在有效范围内
-		if index < 0 || index >= len(parent.A) {
+		if index >= len(parent.A) {
 			return fmt.Errorf("数组索引超出范围: %d", index)
 		}
 
@@ -1717,6 +2669,56 @@ func PointerReplace(doc *Value, pointer *CliJSONPointer, value *Value) error {
 	return nil
 }
 
+// 执行移动操作（RFC 6902 move）：从 from 删除值并添加到 pointer
+func PointerMove(doc *Value, from, pointer *CliJSONPointer) error {
+	// 不能把值移动到它自己的子路径中
+	if len(from.Tokens) < len(pointer.Tokens) && pointerHasPrefix(pointer, from) {
+		return fmt.Errorf("不能将值移动到它自己的子路径中")
+	}
+
+	value, _, err := ResolvePointer(doc, from)
+	if err != nil {
+		return fmt.Errorf("源路径错误: %v", err)
+	}
+	// 源和目标相同时文档不变
+	if len(from.Tokens) == len(pointer.Tokens) && pointerHasPrefix(pointer, from) {
+		return nil
+	}
+
+	if err := PointerRemove(doc, from); err != nil {
+		return fmt.Errorf("删除源失败: %v", err)
+	}
+	if err := PointerAdd(doc, pointer, value); err != nil {
+		return fmt.Errorf("添加到目标失败: %v", err)
+	}
+	return nil
+}
+
+// 执行复制操作（RFC 6902 copy）：将 from 处的值深拷贝后添加到 pointer
+func PointerCopy(doc *Value, from, pointer *CliJSONPointer) error {
+	value, _, err := ResolvePointer(doc, from)
+	if err != nil {
+		return fmt.Errorf("源路径错误: %v", err)
+	}
+	if err := PointerAdd(doc, pointer, cloneValue(value)); err != nil {
+		return fmt.Errorf("添加到目标失败: %v", err)
+	}
+	return nil
+}
+
+// 判断 prefix 的所有token是否都是 pointer 的前缀
+func pointerHasPrefix(pointer, prefix *CliJSONPointer) bool {
+	if len(prefix.Tokens) > len(pointer.Tokens) {
+		return false
+	}
+	for i, token := range prefix.Tokens {
+		if pointer.Tokens[i] != token {
+			return false
+		}
+	}
+	return true
+}
+
 // 解析JSON值字符串
 func parseJSONValue(jsonStr string) (*Value, error) {
 	var value Value
@@ -1730,10 +2732,13 @@ func parseJSONValue(jsonStr string) (*Value, error) {
 // 运行pointer命令
 func runPointer(args []string, verbose bool) {
 	// 默认选项
-	operation := "get" // 默认操作是获取
-	jsonValue := ""    // add和replace操作的值
-	outputFile := ""   // 输出文件
-	fileArgs := args   // 不包含选项的参数
+	operation := "get"     // 默认操作是获取
+	jsonValue := ""        // add和replace操作的值
+	fromPointer := ""      // move和copy操作的源路径
+	hasFrom := false       // 空字符串也是有效的源路径（根），需要单独记录
+	negativeIndex := false // 是否允许负数数组索引
+	outputFile := ""       // 输出文件
+	fileArgs := args       // 不包含选项的参数
 
 	// 解析选项
 	for i := 0; i < len(args); i++ {
@@ -1742,9 +2747,10 @@ func runPointer(args []string, verbose bool) {
 		if strings.HasPrefix(arg, "--operation=") {
 			operation = strings.TrimPrefix(arg, "--operation=")
 			// 验证操作类型
-			if operation != "get" && operation != "add" && operation != "remove" && operation != "replace" {
+			if operation != "get" && operation != "add" && operation != "remove" && operation != "replace" &&
+				operation != "move" && operation != "copy" {
 				fmt.Printf("错误: 无效的操作类型: %s\n", operation)
-				fmt.Println("有效的操作: get, add, remove, replace")
+				fmt.Println("有效的操作: get, add, remove, replace, move, copy")
 				return
 			}
 			// 从参数列表中移除
@@ -1761,6 +2767,23 @@ func runPointer(args []string, verbose bool) {
 			continue
 		}
 
+		if arg == "--negative-index" {
+			negativeIndex = true
+			// 从参数列表中移除
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+
+		if strings.HasPrefix(arg, "--from=") {
+			fromPointer = strings.TrimPrefix(arg, "--from=")
+			hasFrom = true
+			// 从参数列表中移除
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+
 		if strings.HasPrefix(arg, "--output=") {
 			outputFile = strings.TrimPrefix(arg, "--output=")
 			// 从参数列表中移除
@@ -1781,7 +2804,7 @@ func runPointer(args []string, verbose bool) {
 	pointerStr := fileArgs[1]
 
 	// 默认输出到原文件
-	if outputFile == "" && (operation == "add" || operation == "remove" || operation == "replace") {
+	if outputFile == "" && operation != "get" {
 		outputFile = inputFile
 	}
 
@@ -1791,6 +2814,12 @@ func runPointer(args []string, verbose bool) {
 		return
 	}
 
+	// 验证move和copy操作需要from参数
+	if (operation == "move" || operation == "copy") && !hasFrom {
+		fmt.Printf("错误: %s操作需要--from选项\n", operation)
+		return
+	}
+
 	if verbose {
 		fmt.Printf("对文件 '%s' 执行 %s 操作，pointer: '%s'\n", inputFile, operation, pointerStr)
 	}
@@ -1799,15 +2828,16 @@ func runPointer(args []string, verbose bool) {
 	doc, err := loadJSON(inputFile, verbose)
 	if err != nil {
 		fmt.Printf("加载JSON文档失败: %s\n", err)
-		os.Exit(1)
+		exitCLI(1)
 	}
 
 	// 解析JSON Pointer
 	pointer, err := NewJSONPointer(pointerStr)
 	if err != nil {
 		fmt.Printf("解析JSON Pointer失败: %s\n", err)
-		os.Exit(1)
+		exitCLI(1)
 	}
+	pointer.AllowNegativeIndex = negativeIndex
 
 	// 根据操作类型执行不同的操作
 	switch operation {
@@ -1816,14 +2846,14 @@ func runPointer(args []string, verbose bool) {
 		value, _, err := ResolvePointer(doc, pointer)
 		if err != nil {
 			fmt.Printf("解析指针失败: %s\n", err)
-			os.Exit(1)
+			exitCLI(1)
 		}
 
 		// 格式化并输出结果
 		result, err := formatJSON(value, "  ")
 		if err != nil {
 			fmt.Printf("格式化结果失败: %s\n", err)
-			os.Exit(1)
+			exitCLI(1)
 		}
 
 		fmt.Println(result)
@@ -1833,13 +2863,13 @@ func runPointer(args []string, verbose bool) {
 		valueObj, err := parseJSONValue(jsonValue)
 		if err != nil {
 			fmt.Printf("解析JSON值失败: %s\n", err)
-			os.Exit(1)
+			exitCLI(1)
 		}
 
 		// 执行添加操作
 		if err := PointerAdd(doc, pointer, valueObj); err != nil {
 			fmt.Printf("添加值失败: %s\n", err)
-			os.Exit(1)
+			exitCLI(1)
 		}
 
 		// 保存修改后的文档
@@ -1847,12 +2877,12 @@ func runPointer(args []string, verbose bool) {
 			jsonStr, err := formatJSON(doc, "  ")
 			if err != nil {
 				fmt.Printf("格式化JSON失败: %s\n", err)
-				os.Exit(1)
+				exitCLI(1)
 			}
 
 			if err := saveJSON(outputFile, jsonStr, verbose); err != nil {
 				fmt.Printf("保存文件失败: %s\n", err)
-				os.Exit(1)
+				exitCLI(1)
 			}
 
 			fmt.Printf("已成功添加值并保存到 %s\n", outputFile)
@@ -1862,7 +2892,7 @@ func runPointer(args []string, verbose bool) {
 		// 删除值
 		if err := PointerRemove(doc, pointer); err != nil {
 			fmt.Printf("删除值失败: %s\n", err)
-			os.Exit(1)
+			exitCLI(1)
 		}
 
 		// 保存修改后的文档
@@ -1870,12 +2900,12 @@ func runPointer(args []string, verbose bool) {
 			jsonStr, err := formatJSON(doc, "  ")
 			if err != nil {
 				fmt.Printf("格式化JSON失败: %s\n", err)
-				os.Exit(1)
+				exitCLI(1)
 			}
 
 			if err := saveJSON(outputFile, jsonStr, verbose); err != nil {
 				fmt.Printf("保存文件失败: %s\n", err)
-				os.Exit(1)
+				exitCLI(1)
 			}
 
 			fmt.Printf("已成功删除值并保存到 %s\n", outputFile)
@@ -1886,13 +2916,13 @@ func runPointer(args []string, verbose bool) {
 		valueObj, err := parseJSONValue(jsonValue)
 		if err != nil {
 			fmt.Printf("解析JSON值失败: %s\n", err)
-			os.Exit(1)
+			exitCLI(1)
 		}
 
 		// 执行替换操作
 		if err := PointerReplace(doc, pointer, valueObj); err != nil {
 			fmt.Printf("替换值失败: %s\n", err)
-			os.Exit(1)
+			exitCLI(1)
 		}
 
 		// 保存修改后的文档
@@ -1900,48 +2930,222 @@ func runPointer(args []string, verbose bool) {
 			jsonStr, err := formatJSON(doc, "  ")
 			if err != nil {
 				fmt.Printf("格式化JSON失败: %s\n", err)
-				os.Exit(1)
+				exitCLI(1)
 			}
 
 			if err := saveJSON(outputFile, jsonStr, verbose); err != nil {
 				fmt.Printf("保存文件失败: %s\n", err)
-				os.Exit(1)
+				exitCLI(1)
 			}
 
 			fmt.Printf("已成功替换值并保存到 %s\n", outputFile)
 		}
-	}
-}
 
-// JSON Patch操作类型
-const (
-	OpAdd     = "add"
-	OpRemove  = "remove"
-	OpReplace = "replace"
-	OpMove    = "move"
-	OpCopy    = "copy"
-	OpTest    = "test"
-)
+	case "move", "copy":
+		// 解析源路径
+		from, err := NewJSONPointer(fromPointer)
+		if err != nil {
+			fmt.Printf("解析源JSON Pointer失败: %s\n", err)
+			exitCLI(1)
+		}
+		from.AllowNegativeIndex = negativeIndex
 
-// JSON Patch操作
-type CliPatchOperation struct {
-	Op    string `json:"op"`
-	Path  string `json:"path"`
-	From  string `json:"from,omitempty"`
-	Value *Value `json:"value,omitempty"`
-}
+		// 执行移动或复制操作
+		action := "移动"
+		if operation == "move" {
+			err = PointerMove(doc, from, pointer)
+		} else {
+			action = "复制"
+			err = PointerCopy(doc, from, pointer)
+		}
+		if err != nil {
+			fmt.Printf("%s值失败: %s\n", action, err)
+			exitCLI(1)
+		}
 
-// 解析JSON Patch
-func parsePatch(patchDoc *Value) ([]CliPatchOperation, error) {
-	if patchDoc.Type != ARRAY {
-		return nil, fmt.Errorf("JSON Patch必须是操作数组")
-	}
+		// 保存修改后的文档
+		if outputFile != "" {
+			jsonStr, err := formatJSON(doc, "  ")
+			if err != nil {
+				fmt.Printf("格式化JSON失败: %s\n", err)
+				exitCLI(1)
+			}
 
-	operations := make([]CliPatchOperation, 0, len(patchDoc.A))
+			if err := saveJSON(outputFile, jsonStr, verbose); err != nil {
+				fmt.Printf("保存文件失败: %s\n", err)
+				exitCLI(1)
+			}
 
-	for i, op := range patchDoc.A {
-		if op.Type != OBJECT {
-			return nil, fmt.Errorf("Patch操作 #%d 必须是对象", i+1)
+			fmt.Printf("已成功%s值并保存到 %s\n", action, outputFile)
+		}
+	}
+}
+
+// 运行edit命令
+func runEdit(args []string, verbose bool) {
+	// 默认选项
+	pathExpr := ""         // 要修改的值的JSONPath表达式
+	setValue := ""         // 替换成的JSON值
+	hasSet := false        // 空字符串不是有效的JSON，但需要区分是否给出了--set
+	deleteMatches := false // 是否删除匹配的值
+	pathOpts := JSONPathOptions{}
+	outputFile := "" // 输出文件
+	fileArgs := args // 不包含选项的参数
+
+	// 解析选项
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if strings.HasPrefix(arg, "--path=") {
+			pathExpr = strings.TrimPrefix(arg, "--path=")
+			// 从参数列表中移除
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+
+		if strings.HasPrefix(arg, "--set=") {
+			setValue = strings.TrimPrefix(arg, "--set=")
+			hasSet = true
+			// 从参数列表中移除
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+
+		if arg == "--delete" {
+			deleteMatches = true
+			// 从参数列表中移除
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+
+		if arg == "--rfc9535" {
+			pathOpts.RFC9535 = true
+			// 从参数列表中移除
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+
+		if strings.HasPrefix(arg, "--output=") {
+			outputFile = strings.TrimPrefix(arg, "--output=")
+			// 从参数列表中移除
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+	}
+
+	// 检查必要的参数
+	if len(fileArgs) != 1 {
+		fmt.Println("错误: edit命令需要一个参数")
+		fmt.Println("\n用法: leptjson edit --path=EXPR (--set=JSON | --delete) [选项] FILE")
+		return
+	}
+	if pathExpr == "" {
+		fmt.Println("错误: edit命令需要--path选项")
+		return
+	}
+	if hasSet == deleteMatches {
+		fmt.Println("错误: 必须且只能指定--set和--delete中的一个")
+		return
+	}
+
+	inputFile := fileArgs[0]
+
+	// 默认输出到原文件
+	if outputFile == "" {
+		outputFile = inputFile
+	}
+
+	if verbose {
+		fmt.Printf("修改文件 '%s' 中匹配 %s 的值\n", inputFile, pathExpr)
+	}
+
+	// 加载JSON文档
+	doc, err := loadJSON(inputFile, verbose)
+	if err != nil {
+		fmt.Printf("加载JSON文档失败: %s\n", err)
+		exitCLI(1)
+	}
+
+	// 解析JSONPath
+	path, err := NewJSONPathWithOptions(pathExpr, pathOpts)
+	if err != nil {
+		fmt.Printf("解析JSONPath失败: %s\n", err)
+		exitCLI(1)
+	}
+
+	// 执行删除或替换
+	count := 0
+	action := "删除"
+	if deleteMatches {
+		count, err = path.Delete(doc)
+	} else {
+		action = "修改"
+		valueObj, parseErr := parseJSONValue(setValue)
+		if parseErr != nil {
+			fmt.Printf("解析JSON值失败: %s\n", parseErr)
+			exitCLI(1)
+		}
+		count, err = path.Set(doc, valueObj)
+	}
+	if err != nil {
+		fmt.Printf("%s值失败: %s\n", action, err)
+		exitCLI(1)
+	}
+
+	if count == 0 {
+		fmt.Println("没有找到匹配的结果，文件未修改")
+		return
+	}
+
+	// 保存修改后的文档
+	jsonStr, err := formatJSON(doc, "  ")
+	if err != nil {
+		fmt.Printf("格式化JSON失败: %s\n", err)
+		exitCLI(1)
+	}
+
+	if err := saveJSON(outputFile, jsonStr, verbose); err != nil {
+		fmt.Printf("保存文件失败: %s\n", err)
+		exitCLI(1)
+	}
+
+	fmt.Printf("已%s %d 个值并保存到 %s\n", action, count, outputFile)
+}
+
+// JSON Patch操作类型
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+	OpMove    = "move"
+	OpCopy    = "copy"
+	OpTest    = "test"
+)
+
+// JSON Patch操作
+type CliPatchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	From  string `json:"from,omitempty"`
+	Value *Value `json:"value,omitempty"`
+}
+
+// 解析JSON Patch
+func parsePatch(patchDoc *Value) ([]CliPatchOperation, error) {
+	if patchDoc.Type != ARRAY {
+		return nil, fmt.Errorf("JSON Patch必须是操作数组")
+	}
+
+	operations := make([]CliPatchOperation, 0, len(patchDoc.A))
+
+	for i, op := range patchDoc.A {
+		if op.Type != OBJECT {
+			return nil, fmt.Errorf("Patch操作 #%d 必须是对象", i+1)
 		}
 
 		// 获取操作类型
@@ -1958,31 +3162,31 @@ func parsePatch(patchDoc *Value) ([]CliPatchOperation, error) {
 
 		// 创建操作对象
 		operation := CliPatchOperation{
-			Op:   opType.S,
-			Path: pathVal.S,
+			Op:   GetString(opType),
+			Path: GetString(pathVal),
 		}
 
 		// 根据操作类型处理额外字段
-		switch opType.S {
+		switch GetString(opType) {
 		case OpAdd, OpReplace, OpTest:
 			valueVal := findObjectKeyValue(op, "value")
 			if valueVal == nil {
-				return nil, fmt.Errorf("Patch操作 #%d (%s) 缺少必需的 'value' 字段", i+1, opType.S)
+				return nil, fmt.Errorf("Patch操作 #%d (%s) 缺少必需的 'value' 字段", i+1, GetString(opType))
 			}
 			operation.Value = valueVal
 
 		case OpMove, OpCopy:
 			fromVal := findObjectKeyValue(op, "from")
 			if fromVal == nil || fromVal.Type != STRING {
-				return nil, fmt.Errorf("Patch操作 #%d (%s) 缺少有效的 'from' 字段", i+1, opType.S)
+				return nil, fmt.Errorf("Patch操作 #%d (%s) 缺少有效的 'from' 字段", i+1, GetString(opType))
 			}
-			operation.From = fromVal.S
+			operation.From = GetString(fromVal)
 
 		case OpRemove:
 			// remove只需要path
 
 		default:
-			return nil, fmt.Errorf("Patch操作 #%d 包含无效的操作类型: %s", i+1, opType.S)
+			return nil, fmt.Errorf("Patch操作 #%d 包含无效的操作类型: %s", i+1, GetString(opType))
 		}
 
 		operations = append(operations, operation)
@@ -1992,8 +3196,25 @@ func parsePatch(patchDoc *Value) ([]CliPatchOperation, error) {
 }
 
 // 应用JSON Patch
+//
+// 所有操作都在文档的副本上执行，全部成功后才写回doc，任何一个操作失败时doc保持不变。
+// testOnly为true时完整地模拟执行补丁（后续操作能看到前面操作的结果），但不写回doc。
 func applyPatch(doc *Value, operations []CliPatchOperation, testOnly bool) error {
-	// 执行所有操作
+	working := &Value{}
+	Copy(working, doc)
+
+	if err := applyPatchOperations(working, operations); err != nil {
+		return err
+	}
+
+	if !testOnly {
+		Move(doc, working)
+	}
+	return nil
+}
+
+// 依次执行补丁操作，遇到错误立即返回
+func applyPatchOperations(doc *Value, operations []CliPatchOperation) error {
 	for i, op := range operations {
 		// 解析路径
 		path, err := NewJSONPointer(op.Path)
@@ -2004,94 +3225,40 @@ func applyPatch(doc *Value, operations []CliPatchOperation, testOnly bool) error
 		// 根据操作类型执行不同的操作
 		switch op.Op {
 		case OpAdd:
-			if testOnly {
-				continue
-			}
 			if err := PointerAdd(doc, path, op.Value); err != nil {
 				return fmt.Errorf("操作 #%d (add): %v", i+1, err)
 			}
 
 		case OpRemove:
-			if testOnly {
-				// 在测试模式下，只检查路径是否存在
-				_, _, err := ResolvePointer(doc, path)
-				if err != nil {
-					return fmt.Errorf("操作 #%d (remove): %v", i+1, err)
-				}
-			} else {
-				if err := PointerRemove(doc, path); err != nil {
-					return fmt.Errorf("操作 #%d (remove): %v", i+1, err)
-				}
+			if err := PointerRemove(doc, path); err != nil {
+				return fmt.Errorf("操作 #%d (remove): %v", i+1, err)
 			}
 
 		case OpReplace:
-			if testOnly {
-				// 在测试模式下，只检查路径是否存在
-				_, _, err := ResolvePointer(doc, path)
-				if err != nil {
-					return fmt.Errorf("操作 #%d (replace): %v", i+1, err)
-				}
-			} else {
-				if err := PointerReplace(doc, path, op.Value); err != nil {
-					return fmt.Errorf("操作 #%d (replace): %v", i+1, err)
-				}
+			if err := PointerReplace(doc, path, op.Value); err != nil {
+				return fmt.Errorf("操作 #%d (replace): %v", i+1, err)
 			}
 
 		case OpMove:
-			if testOnly {
-				continue
-			}
-
 			// 解析源路径
 			fromPath, err := NewJSONPointer(op.From)
 			if err != nil {
 				return fmt.Errorf("操作 #%d: 无效的源路径 '%s': %v", i+1, op.From, err)
 			}
 
-			// 获取源值
-			fromValue, _, err := ResolvePointer(doc, fromPath)
-			if err != nil {
-				return fmt.Errorf("操作 #%d (move): 源路径错误: %v", i+1, err)
-			}
-
-			// 复制源值
-			valueClone := &Value{}
-			*valueClone = *fromValue
-
-			// 移除源
-			if err := PointerRemove(doc, fromPath); err != nil {
-				return fmt.Errorf("操作 #%d (move): 删除源失败: %v", i+1, err)
-			}
-
-			// 添加到目标
-			if err := PointerAdd(doc, path, valueClone); err != nil {
-				return fmt.Errorf("操作 #%d (move): 添加到目标失败: %v", i+1, err)
+			if err := PointerMove(doc, fromPath, path); err != nil {
+				return fmt.Errorf("操作 #%d (move): %v", i+1, err)
 			}
 
 		case OpCopy:
-			if testOnly {
-				continue
-			}
-
 			// 解析源路径
 			fromPath, err := NewJSONPointer(op.From)
 			if err != nil {
 				return fmt.Errorf("操作 #%d: 无效的源路径 '%s': %v", i+1, op.From, err)
 			}
 
-			// 获取源值
-			fromValue, _, err := ResolvePointer(doc, fromPath)
-			if err != nil {
-				return fmt.Errorf("操作 #%d (copy): 源路径错误: %v", i+1, err)
-			}
-
-			// 复制源值
-			valueClone := &Value{}
-			*valueClone = *fromValue
-
-			// 添加到目标
-			if err := PointerAdd(doc, path, valueClone); err != nil {
-				return fmt.Errorf("操作 #%d (copy): 添加到目标失败: %v", i+1, err)
+			if err := PointerCopy(doc, fromPath, path); err != nil {
+				return fmt.Errorf("操作 #%d (copy): %v", i+1, err)
 			}
 
 		case OpTest:
@@ -2140,7 +3307,7 @@ func ValuesEqual(a, b *Value) bool {
 	case NUMBER:
 		return a.N == b.N
 	case STRING:
-		return a.S == b.S
+		return GetString(a) == GetString(b)
 	case ARRAY:
 		if len(a.A) != len(b.A) {
 			return false
@@ -2180,6 +3347,8 @@ func runPatch(args []string, verbose bool) {
 	// 解析选项
 	inPlace := false
 	testOnly := false
+	inverseFile := ""
+	colorMode := cliConfig.Color
 	fileArgs := args
 
 	for i := 0; i < len(args); i++ {
@@ -2200,6 +3369,28 @@ func runPatch(args []string, verbose bool) {
 			i--
 			continue
 		}
+
+		if strings.HasPrefix(arg, "--inverse=") {
+			inverseFile = strings.TrimPrefix(arg, "--inverse=")
+			// 从参数列表中移除
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+
+		if strings.HasPrefix(arg, "--color=") {
+			colorMode = strings.TrimPrefix(arg, "--color=")
+			// 从参数列表中移除
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+	}
+
+	color, err := useColor(colorMode, os.Stdout)
+	if err != nil {
+		fmt.Printf("错误: %s\n", err)
+		return
 	}
 
 	// 检查必要的参数
@@ -2236,14 +3427,14 @@ func runPatch(args []string, verbose bool) {
 	patchDoc, err := loadJSON(patchFile, verbose)
 	if err != nil {
 		fmt.Printf("加载补丁失败: %s\n", err)
-		os.Exit(1)
+		exitCLI(1)
 	}
 
 	// 解析补丁操作
 	operations, err := parsePatch(patchDoc)
 	if err != nil {
 		fmt.Printf("解析补丁失败: %s\n", err)
-		os.Exit(1)
+		exitCLI(1)
 	}
 
 	if verbose {
@@ -2257,31 +3448,79 @@ func runPatch(args []string, verbose bool) {
 	targetDoc, err := loadJSON(targetFile, verbose)
 	if err != nil {
 		fmt.Printf("加载目标文件失败: %s\n", err)
-		os.Exit(1)
+		exitCLI(1)
 	}
 
-	// 应用补丁
-	err = applyPatch(targetDoc, operations, testOnly)
-	if err != nil {
-		fmt.Printf("应用补丁失败: %s\n", err)
-		os.Exit(1)
+	// 计算逆补丁（在应用补丁之前，基于原始文档计算）
+	var inverse *JSONPatch
+	if inverseFile != "" && !testOnly {
+		libPatch, err := NewJSONPatch(patchDoc)
+		if err != nil {
+			fmt.Printf("解析补丁失败: %s\n", err)
+			exitCLI(1)
+		}
+		inverse, err = libPatch.ApplyWithInverse(cloneValue(targetDoc))
+		if err != nil {
+			fmt.Printf("生成逆补丁失败: %s\n", err)
+			exitCLI(1)
+		}
 	}
 
 	if testOnly {
+		// 在副本上应用补丁，并显示与原文档的差异
+		patched := cloneValue(targetDoc)
+		if err := applyPatch(patched, operations, false); err != nil {
+			fmt.Printf("应用补丁失败: %s\n", err)
+			exitCLI(1)
+		}
 		fmt.Println("补丁测试成功: 所有操作都可以成功应用")
+
+		differences := CompareValues(targetDoc, patched)
+		if len(differences) == 0 {
+			fmt.Println("补丁不会修改文档")
+			return
+		}
+		fmt.Printf("\n将产生 %d 处变更:\n", len(differences))
+		WritePatchPreview(os.Stdout, differences, color)
 		return
 	}
 
+	// 应用补丁
+	err = applyPatch(targetDoc, operations, false)
+	if err != nil {
+		fmt.Printf("应用补丁失败: %s\n", err)
+		exitCLI(1)
+	}
+
+	// 保存逆补丁
+	if inverse != nil {
+		inverseStr, err := inverse.String()
+		if err != nil {
+			fmt.Printf("序列化逆补丁失败: %s\n", err)
+			exitCLI(1)
+		}
+		inverseDoc := &Value{}
+		Parse(inverseDoc, inverseStr)
+		inverseJSON, _ := formatJSON(inverseDoc, "  ")
+		if err := saveJSON(inverseFile, inverseJSON, verbose); err != nil {
+			fmt.Printf("保存逆补丁失败: %s\n", err)
+			exitCLI(1)
+		}
+		if verbose {
+			fmt.Printf("逆补丁已保存到 %s\n", inverseFile)
+		}
+	}
+
 	// 保存结果
 	resultJSON, err := formatJSON(targetDoc, "  ")
 	if err != nil {
 		fmt.Printf("格式化结果失败: %s\n", err)
-		os.Exit(1)
+		exitCLI(1)
 	}
 
 	if err := saveJSON(outputFile, resultJSON, verbose); err != nil {
 		fmt.Printf("保存结果失败: %s\n", err)
-		os.Exit(1)
+		exitCLI(1)
 	}
 
 	fmt.Printf("补丁应用成功: 输出保存到 %s\n", outputFile)
@@ -2400,138 +3639,1040 @@ func runMergePatch(args []string, verbose bool) {
 	patchDoc, err := loadJSON(patchFile, verbose)
 	if err != nil {
 		fmt.Printf("加载Merge Patch失败: %s\n", err)
-		os.Exit(1)
+		exitCLI(1)
 	}
 
 	// 加载目标文件
 	targetDoc, err := loadJSON(targetFile, verbose)
 	if err != nil {
 		fmt.Printf("加载目标文件失败: %s\n", err)
-		os.Exit(1)
+		exitCLI(1)
 	}
 
 	// 应用Merge Patch
 	if err := applyMergePatch(targetDoc, patchDoc); err != nil {
 		fmt.Printf("应用Merge Patch失败: %s\n", err)
-		os.Exit(1)
+		exitCLI(1)
 	}
 
 	// 保存结果
 	resultJSON, err := formatJSON(targetDoc, "  ")
 	if err != nil {
 		fmt.Printf("格式化结果失败: %s\n", err)
-		os.Exit(1)
+		exitCLI(1)
 	}
 
 	if err := saveJSON(outputFile, resultJSON, verbose); err != nil {
 		fmt.Printf("保存结果失败: %s\n", err)
-		os.Exit(1)
+		exitCLI(1)
 	}
 
 	fmt.Printf("Merge Patch应用成功: 输出保存到 %s\n", outputFile)
 }
 
-// 实现runPath命令
-func runPath(args []string, verbose bool) {
+// 实现gen-codec命令
+func runGenCodec(args []string, verbose bool) {
 	// 解析选项
-	outputFormat := "pretty" // 默认为美化输出
-	showAll := false         // 默认只显示前10个结果
-	csvFile := ""            // CSV输出文件
-	showPath := true         // 显示路径信息
+	var typeNames []string
+	outputFile := ""
 	fileArgs := args
 
-	for i := 0; i < len(args); i++ {
-		arg := args[i]
+	for i := 0; i < len(fileArgs); i++ {
+		arg := fileArgs[i]
 
-		if strings.HasPrefix(arg, "--output=") {
-			outputFormat = strings.TrimPrefix(arg, "--output=")
-			// 验证输出格式
-			if outputFormat != "compact" && outputFormat != "pretty" &&
-				outputFormat != "raw" && outputFormat != "table" {
-				fmt.Printf("错误: 无效的输出格式: %s\n", outputFormat)
-				fmt.Println("有效的格式: compact, pretty, raw, table")
-				return
+		if strings.HasPrefix(arg, "--type=") {
+			for _, name := range strings.Split(strings.TrimPrefix(arg, "--type="), ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					typeNames = append(typeNames, name)
+				}
 			}
-			// 从参数列表中移除
-			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
-			i--
-			continue
-		}
-
-		if arg == "--all" {
-			showAll = true
-			// 从参数列表中移除
-			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
-			i--
-			continue
-		}
-
-		if strings.HasPrefix(arg, "--csv=") {
-			csvFile = strings.TrimPrefix(arg, "--csv=")
-			// 从参数列表中移除
 			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
 			i--
 			continue
 		}
 
-		if arg == "--no-path" {
-			showPath = false
-			// 从参数列表中移除
+		if strings.HasPrefix(arg, "--output=") {
+			outputFile = strings.TrimPrefix(arg, "--output=")
 			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
 			i--
 			continue
 		}
 	}
 
-	// 检查必要参数
-	if len(fileArgs) != 2 {
-		fmt.Println("错误: path命令需要两个参数")
-		fmt.Println("\n用法: leptjson path [选项] FILE JSONPATH")
+	// 检查必要的参数
+	if len(fileArgs) != 1 {
+		fmt.Println("错误: gen-codec命令需要1个参数")
+		fmt.Println("\n用法: leptjson gen-codec [选项] FILE.go")
 		return
 	}
 
-	filePath := fileArgs[0]
-	jsonPathExpr := fileArgs[1]
-
-	if verbose {
-		fmt.Printf("在文件 %s 中查询 JSONPath: %s\n", filePath, jsonPathExpr)
+	inputFile := fileArgs[0]
+	if outputFile == "" {
+		outputFile = strings.TrimSuffix(inputFile, ".go") + "_leptjson.go"
 	}
 
-	// 加载JSON
-	doc, err := loadJSON(filePath, verbose)
-	if err != nil {
-		fmt.Printf("加载JSON失败: %s\n", err)
-		os.Exit(1)
+	if verbose {
+		fmt.Printf("正在读取文件: %s\n", inputFile)
 	}
-
-	// 解析JSONPath并执行查询
-	path, err := NewJSONPath(jsonPathExpr)
+	src, err := os.ReadFile(inputFile)
 	if err != nil {
-		fmt.Printf("解析JSONPath失败: %s\n", err)
-		os.Exit(1)
+		fmt.Printf("读取文件失败: %s\n", err)
+		exitCLI(1)
 	}
 
-	results, err := path.Query(doc)
+	code, err := GenerateCodec(inputFile, src, typeNames)
 	if err != nil {
-		fmt.Printf("执行查询失败: %s\n", err)
-		os.Exit(1)
+		fmt.Printf("生成代码失败: %s\n", err)
+		exitCLI(1)
 	}
 
-	// 显示结果数量
-	totalResults := len(results)
-	if verbose {
-		fmt.Printf("找到 %d 个匹配结果\n", totalResults)
+	if err := saveJSON(outputFile, string(code), verbose); err != nil {
+		fmt.Printf("保存结果失败: %s\n", err)
+		exitCLI(1)
 	}
 
-	// 如果没有结果，提前返回
-	if totalResults == 0 {
-		fmt.Println("没有找到匹配的结果")
-		return
-	}
+	fmt.Printf("代码生成成功: 输出保存到 %s\n", outputFile)
+}
+
+// 实现serve命令
+func runServe(args []string, verbose bool) {
+	// 解析选项
+	addr := ":8080"
+	options := DefaultServerOptions()
+	documentFile, schemaFile, save := "", "", false
+
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "--addr="):
+			addr = strings.TrimPrefix(arg, "--addr=")
+		case strings.HasPrefix(arg, "--max-body="):
+			value := strings.TrimPrefix(arg, "--max-body=")
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || n <= 0 {
+				fmt.Printf("错误: 无效的请求体大小: %s\n", value)
+				return
+			}
+			options.MaxBodyBytes = n
+			// 请求体大小限制放宽时，解析的总大小限制随之放宽
+			if n > int64(options.ParseOptions.MaxTotalSize) {
+				options.ParseOptions.MaxTotalSize = int(n)
+			}
+		case strings.HasPrefix(arg, "--max-read-rate="):
+			value := strings.TrimPrefix(arg, "--max-read-rate=")
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || n <= 0 {
+				fmt.Printf("错误: 无效的读取速度: %s\n", value)
+				return
+			}
+			options.ReadBytesPerSecond = n
+		case strings.HasPrefix(arg, "--eval-timeout="):
+			value := strings.TrimPrefix(arg, "--eval-timeout=")
+			d, err := time.ParseDuration(value)
+			if err != nil || d < 0 {
+				fmt.Printf("错误: 无效的求值超时: %s\n", value)
+				return
+			}
+			options.EvalBudget.Timeout = d
+		case strings.HasPrefix(arg, "--max-eval-steps="):
+			value := strings.TrimPrefix(arg, "--max-eval-steps=")
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				fmt.Printf("错误: 无效的求值步数: %s\n", value)
+				return
+			}
+			options.EvalBudget.MaxSteps = n
+		case strings.HasPrefix(arg, "--document="):
+			documentFile = strings.TrimPrefix(arg, "--document=")
+		case strings.HasPrefix(arg, "--schema="):
+			schemaFile = strings.TrimPrefix(arg, "--schema=")
+		case arg == "--save":
+			save = true
+		case strings.HasPrefix(arg, "--allow-origin="):
+			options.AllowedOrigins = append(options.AllowedOrigins, strings.TrimPrefix(arg, "--allow-origin="))
+		default:
+			fmt.Printf("错误: 未知的参数: %s\n", arg)
+			fmt.Println("\n用法: leptjson serve [--addr=ADDR] [--max-body=BYTES] [--max-read-rate=BYTES] [--eval-timeout=DURATION] [--max-eval-steps=N] [--document=FILE [--schema=FILE] [--save] [--allow-origin=ORIGIN]...]")
+			return
+		}
+	}
+	if documentFile == "" && (schemaFile != "" || save) {
+		fmt.Println("错误: --schema 和 --save 需要与 --document 一起使用")
+		return
+	}
+
+	if documentFile != "" {
+		v, err := loadJSON(documentFile, verbose)
+		if err != nil {
+			fmt.Printf("错误: %s\n", err)
+			exitCLI(1)
+			return
+		}
+		if schemaFile != "" {
+			if options.DocumentSchema, err = loadJSON(schemaFile, verbose); err != nil {
+				fmt.Printf("错误: %s\n", err)
+				exitCLI(1)
+				return
+			}
+			result, err := ValidateWithOptions(options.DocumentSchema, v, ValidationOptions{})
+			if err != nil {
+				fmt.Printf("错误: %s\n", err)
+				exitCLI(1)
+				return
+			}
+			if !result.Valid {
+				fmt.Printf("错误: %s 不符合 Schema: %s\n", documentFile, strings.Join(result.Errors, "; "))
+				exitCLI(1)
+				return
+			}
+		}
+		options.Document = NewDocument(v)
+		if save {
+			// 每次修改后写回文件，监听器按修改顺序调用
+			options.Document.OnChange(func(ChangeEvent) {
+				content, _ := formatJSON(options.Document.Snapshot(), "  ")
+				if err := saveJSON(documentFile, content, verbose); err != nil {
+					fmt.Printf("错误: 保存文档失败: %s\n", err)
+				}
+			})
+		}
+	}
+
+	var handler http.Handler = NewServer(options)
+	if verbose {
+		server := handler
+		handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Printf("%s %s\n", r.Method, r.URL.Path)
+			server.ServeHTTP(w, r)
+		})
+	}
+
+	fmt.Printf("LeptJSON 服务正在监听 %s\n", addr)
+	if err := http.ListenAndServe(addr, handler); err != nil {
+		fmt.Printf("服务启动失败: %s\n", err)
+		exitCLI(1)
+	}
+}
+
+// 实现pipeline命令
+func runPipeline(args []string, verbose bool) {
+	options := PipelineOptions{ParseOptions: cliConfig.ParseOptions()}
+	output, deadLetter, filterExpr, transformFile := "-", "", "", ""
+	printStats := verbose
+	var inputArgs []string
+
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "--filter="):
+			filterExpr = strings.TrimPrefix(arg, "--filter=")
+		case strings.HasPrefix(arg, "--transform="):
+			transformFile = strings.TrimPrefix(arg, "--transform=")
+		case strings.HasPrefix(arg, "--workers="):
+			value := strings.TrimPrefix(arg, "--workers=")
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				fmt.Fprintf(os.Stderr, "错误: 无效的workers值: %s\n", value)
+				exitCLI(1)
+			}
+			options.Workers = n
+		case strings.HasPrefix(arg, "--output="):
+			output = strings.TrimPrefix(arg, "--output=")
+		case strings.HasPrefix(arg, "--dead-letter="):
+			deadLetter = strings.TrimPrefix(arg, "--dead-letter=")
+		case arg == "--stats":
+			printStats = true
+		default:
+			inputArgs = append(inputArgs, arg)
+		}
+	}
+	if len(inputArgs) > 1 {
+		fmt.Fprintln(os.Stderr, "错误: pipeline命令最多需要一个输入参数")
+		fmt.Fprintln(os.Stderr, "\n用法: leptjson pipeline [--filter=EXPR] [--transform=FILE] [--workers=N] [--output=OUTPUT] [--dead-letter=OUTPUT] [--stats] [INPUT]")
+		exitCLI(1)
+	}
+	input := "-"
+	if len(inputArgs) == 1 {
+		input = inputArgs[0]
+	}
+
+	if filterExpr != "" {
+		filter, err := CompileRecordFilter(filterExpr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+			exitCLI(1)
+		}
+		options.Filter = filter
+	}
+	if transformFile != "" {
+		spec, err := loadJSON(transformFile, false)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "加载转换描述失败: %s\n", err)
+			exitCLI(1)
+		}
+		if options.Transform, err = ParseTransformSpec(spec); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+			exitCLI(1)
+		}
+	}
+
+	// Ctrl-C 时停止读取，已经处理的记录照常写出，Kafka 消费者实例被删除
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var closers []func() error
+	closeAll := func() error {
+		var first error
+		for i := len(closers) - 1; i >= 0; i-- {
+			if err := closers[i](); err != nil && first == nil {
+				first = err
+			}
+		}
+		return first
+	}
+	fail := func(format string, a ...interface{}) {
+		fmt.Fprintf(os.Stderr, format, a...)
+		closeAll()
+		exitCLI(1)
+	}
+
+	maxLine := 0
+	if options.ParseOptions.EnabledSecurity {
+		maxLine = options.ParseOptions.MaxTotalSize
+	}
+	src, err := OpenRecordSource(ctx, input, maxLine)
+	if err != nil {
+		fail("打开输入失败: %s\n", err)
+	}
+	closers = append(closers, src.Close)
+	dst, err := OpenRecordSink(ctx, output)
+	if err != nil {
+		fail("打开输出失败: %s\n", err)
+	}
+	closers = append(closers, dst.Close)
+	if deadLetter != "" {
+		dl, err := OpenRecordSink(ctx, deadLetter)
+		if err != nil {
+			fail("打开死信输出失败: %s\n", err)
+		}
+		closers = append(closers, dl.Close)
+		options.DeadLetter = dl
+	}
+
+	stats, err := RunPipeline(ctx, src, dst, options)
+	// 先关闭输出，使已经处理的记录写出
+	if cerr := closeAll(); err == nil && cerr != nil {
+		err = fmt.Errorf("关闭输出失败: %w", cerr)
+	}
+	closers = nil
+	if printStats {
+		fmt.Fprintf(os.Stderr, "读取 %d 条记录，输出 %d 条，过滤 %d 条，失败 %d 条\n",
+			stats.Read, stats.Written, stats.Filtered, stats.Failed)
+	}
+	if err != nil && ctx.Err() != nil {
+		fail("流水线被中断\n")
+	}
+	if err != nil {
+		fail("流水线失败: %s\n", err)
+	}
+}
+
+// 实现to-sql命令
+func runToSQL(args []string, verbose bool) {
+	tableName, pathExpr, sqliteFile, outputFile := "", "", "", ""
+	options := FlattenOptions{}
+	var fileArgs []string
+
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "--table="):
+			tableName = strings.TrimPrefix(arg, "--table=")
+		case strings.HasPrefix(arg, "--path="):
+			pathExpr = strings.TrimPrefix(arg, "--path=")
+		case strings.HasPrefix(arg, "--separator="):
+			options.Separator = strings.TrimPrefix(arg, "--separator=")
+		case strings.HasPrefix(arg, "--sqlite="):
+			sqliteFile = strings.TrimPrefix(arg, "--sqlite=")
+		case strings.HasPrefix(arg, "--output="):
+			outputFile = strings.TrimPrefix(arg, "--output=")
+		default:
+			fileArgs = append(fileArgs, arg)
+		}
+	}
+
+	if len(fileArgs) != 1 {
+		fmt.Println("错误: to-sql命令需要一个文件参数")
+		fmt.Println("\n用法: leptjson to-sql [--table=NAME] [--path=EXPR] [--separator=SEP] [--sqlite=DB] [--output=FILE] FILE")
+		return
+	}
+	if tableName == "" {
+		base := filepath.Base(fileArgs[0])
+		tableName = strings.TrimSuffix(base, filepath.Ext(base))
+	}
+
+	doc, err := loadJSON(fileArgs[0], verbose)
+	if err != nil {
+		fmt.Printf("加载文件失败: %s\n", err)
+		exitCLI(1)
+	}
+	records, err := selectRecords(doc, pathExpr)
+	if err != nil {
+		fmt.Printf("错误: %s\n", err)
+		exitCLI(1)
+	}
+
+	table, err := FlattenTable(tableName, records, options)
+	if err != nil {
+		fmt.Printf("转换失败: %s\n", err)
+		exitCLI(1)
+	}
+	if verbose {
+		fmt.Fprintf(os.Stderr, "表 %s: %d 列，%d 行\n", tableName, len(table.Columns), len(table.Rows))
+	}
+
+	if sqliteFile != "" {
+		if err := table.WriteSQLite(sqliteFile); err != nil {
+			fmt.Printf("写入SQLite数据库失败: %s\n", err)
+			exitCLI(1)
+		}
+		if verbose {
+			fmt.Printf("已写入 %s\n", sqliteFile)
+		}
+		return
+	}
+
+	var out io.Writer = os.Stdout
+	if outputFile != "" {
+		f, err := os.Create(outputFile)
+		if err != nil {
+			fmt.Printf("无法创建文件: %s\n", err)
+			exitCLI(1)
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := table.WriteSQL(out); err != nil {
+		fmt.Printf("输出SQL失败: %s\n", err)
+		exitCLI(1)
+	}
+}
+
+// selectRecords 用 JSONPath 从文档中选取记录，pathExpr 为空时返回整个文档
+//
+// 只匹配到一个数组时使用这个数组的元素，否则每个匹配结果是一条记录。
+func selectRecords(doc *Value, pathExpr string) (*Value, error) {
+	if pathExpr == "" {
+		return doc, nil
+	}
+	jp, err := NewJSONPath(pathExpr)
+	if err != nil {
+		return nil, fmt.Errorf("无效的JSONPath: %s", err)
+	}
+	results, err := jp.Query(doc)
+	if err != nil {
+		return nil, fmt.Errorf("查询失败: %s", err)
+	}
+	if len(results) == 1 && results[0].Type == ARRAY {
+		return results[0], nil
+	}
+	records := &Value{}
+	SetArray(records, len(results))
+	for _, r := range results {
+		Copy(PushBackArrayElement(records), r)
+	}
+	return records, nil
+}
+
+// exportFormats 是 export 命令支持的格式和对应的默认扩展名
+var exportFormats = map[string]string{
+	"arrow":        ".arrow",
+	"arrow-stream": ".arrows",
+	"parquet":      ".parquet",
+}
+
+// 实现export命令
+func runExport(args []string, verbose bool) {
+	format, pathExpr, outputFile := "", "", ""
+	showSchema := false
+	options := ColumnarOptions{}
+	var fileArgs []string
+
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "--format="):
+			format = strings.TrimPrefix(arg, "--format=")
+		case strings.HasPrefix(arg, "--path="):
+			pathExpr = strings.TrimPrefix(arg, "--path=")
+		case strings.HasPrefix(arg, "--sample="):
+			n, err := strconv.Atoi(strings.TrimPrefix(arg, "--sample="))
+			if err != nil || n <= 0 {
+				fmt.Printf("错误: 无效的采样记录数: %s\n", arg)
+				exitCLI(1)
+			}
+			options.SampleSize = n
+		case strings.HasPrefix(arg, "--batch-size="):
+			n, err := strconv.Atoi(strings.TrimPrefix(arg, "--batch-size="))
+			if err != nil || n <= 0 {
+				fmt.Printf("错误: 无效的批次大小: %s\n", arg)
+				exitCLI(1)
+			}
+			options.BatchSize = n
+		case strings.HasPrefix(arg, "--separator="):
+			options.Separator = strings.TrimPrefix(arg, "--separator=")
+		case strings.HasPrefix(arg, "--output="):
+			outputFile = strings.TrimPrefix(arg, "--output=")
+		case arg == "--schema":
+			showSchema = true
+		default:
+			fileArgs = append(fileArgs, arg)
+		}
+	}
+
+	if len(fileArgs) != 1 || (outputFile == "" && !showSchema) {
+		fmt.Println("错误: export命令需要一个文件参数和 --output 选项")
+		fmt.Println("\n用法: leptjson export [--format=arrow|arrow-stream|parquet] [--path=EXPR] [--sample=N] [--batch-size=N] [--separator=SEP] [--schema] --output=OUT FILE")
+		return
+	}
+	if format == "" && outputFile != "" {
+		// 根据输出文件的扩展名选择格式
+		ext := strings.ToLower(filepath.Ext(outputFile))
+		for name, e := range exportFormats {
+			if ext == e {
+				format = name
+			}
+		}
+		if ext == ".feather" {
+			format = "arrow"
+		}
+	}
+	if _, ok := exportFormats[format]; !ok && !showSchema {
+		if format == "" {
+			fmt.Println("错误: 无法根据输出文件的扩展名确定格式，请使用 --format=arrow、arrow-stream 或 parquet")
+		} else {
+			fmt.Printf("错误: 不支持的格式 %q，请使用 arrow、arrow-stream 或 parquet\n", format)
+		}
+		exitCLI(1)
+	}
+
+	doc, err := loadJSON(fileArgs[0], verbose)
+	if err != nil {
+		fmt.Printf("加载文件失败: %s\n", err)
+		exitCLI(1)
+	}
+	records, err := selectRecords(doc, pathExpr)
+	if err != nil {
+		fmt.Printf("错误: %s\n", err)
+		exitCLI(1)
+	}
+
+	if showSchema {
+		fields, err := InferColumnarSchema(records, options)
+		if err != nil {
+			fmt.Printf("推断schema失败: %s\n", err)
+			exitCLI(1)
+		}
+		data, _ := json.MarshalIndent(fields, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+
+	table, err := BuildColumnarTable(records, options)
+	if err != nil {
+		fmt.Printf("转换失败: %s\n", err)
+		exitCLI(1)
+	}
+	if verbose {
+		fmt.Fprintf(os.Stderr, "%d 列，%d 行，%d 个批次\n", len(table.Fields), table.Rows, len(table.Batches))
+	}
+
+	var out io.Writer = os.Stdout
+	if outputFile != "-" {
+		f, err := os.Create(outputFile)
+		if err != nil {
+			fmt.Printf("无法创建文件: %s\n", err)
+			exitCLI(1)
+		}
+		defer f.Close()
+		out = f
+	}
+	switch format {
+	case "arrow":
+		err = table.WriteArrow(out)
+	case "arrow-stream":
+		err = table.WriteArrowStream(out)
+	default:
+		err = table.WriteParquet(out)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "输出%s失败: %s\n", format, err)
+		exitCLI(1)
+	}
+	if verbose && outputFile != "-" {
+		fmt.Printf("已写入 %s\n", outputFile)
+	}
+}
+
+// 实现validate-proto命令
+func runValidateProto(args []string, verbose bool) {
+	descriptorFile, messageName, outputFormat := "", "", "text"
+	options := ProtoJSONOptions{}
+	var fileArgs []string
+
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "--descriptor="):
+			descriptorFile = strings.TrimPrefix(arg, "--descriptor=")
+		case strings.HasPrefix(arg, "--message="):
+			messageName = strings.TrimPrefix(arg, "--message=")
+		case arg == "--ignore-unknown":
+			options.IgnoreUnknownFields = true
+		case strings.HasPrefix(arg, "--format="):
+			outputFormat = strings.TrimPrefix(arg, "--format=")
+			if outputFormat != "text" && outputFormat != "json" {
+				fmt.Printf("错误: 无效的输出格式: %s\n", outputFormat)
+				fmt.Println("有效的格式: text, json")
+				return
+			}
+		default:
+			fileArgs = append(fileArgs, arg)
+		}
+	}
+
+	if descriptorFile == "" || (messageName != "" && len(fileArgs) != 1) {
+		fmt.Println("错误: validate-proto命令需要 --descriptor、--message 选项和一个文件参数")
+		fmt.Println("\n用法: leptjson validate-proto --descriptor=DESC --message=NAME [--ignore-unknown] [--format=FORMAT] FILE")
+		return
+	}
+
+	descriptor, err := os.ReadFile(descriptorFile)
+	if err != nil {
+		fmt.Printf("读取描述符集失败: %s\n", err)
+		exitCLI(1)
+	}
+	registry, err := ParseProtoDescriptorSet(descriptor)
+	if err != nil {
+		fmt.Printf("错误: %s\n", err)
+		exitCLI(1)
+	}
+	if messageName == "" {
+		fmt.Println("描述符集中的消息类型:")
+		for _, name := range registry.MessageNames() {
+			fmt.Printf("  %s\n", name)
+		}
+		return
+	}
+
+	data, err := loadJSON(fileArgs[0], verbose)
+	if err != nil {
+		fmt.Printf("加载数据文件失败: %s\n", err)
+		exitCLI(1)
+	}
+	result, err := registry.ValidateProtoJSON(data, messageName, options)
+	if err != nil {
+		fmt.Printf("错误: %s\n", err)
+		exitCLI(1)
+	}
+
+	if outputFormat == "json" {
+		resultJSON, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			fmt.Printf("生成JSON结果失败: %s\n", err)
+			exitCLI(1)
+		}
+		fmt.Println(string(resultJSON))
+	} else if result.Valid {
+		fmt.Printf("验证通过: 文件符合消息 %s 的JSON映射\n", messageName)
+	} else {
+		fmt.Printf("验证失败: %s\n", result.Message)
+		for i, err := range result.Errors {
+			fmt.Printf("%d. %s\n", i+1, err)
+		}
+	}
+	if !result.Valid {
+		exitCLI(2)
+	}
+}
+
+// 实现avro命令
+func runAvro(args []string, verbose bool) {
+	validate, normalize := false, false
+	outputFormat, outputFile := "text", ""
+	var fileArgs []string
+
+	for _, arg := range args {
+		switch {
+		case arg == "--validate":
+			validate = true
+		case arg == "--normalize":
+			normalize = true
+		case strings.HasPrefix(arg, "--format="):
+			outputFormat = strings.TrimPrefix(arg, "--format=")
+			if outputFormat != "text" && outputFormat != "json" {
+				fmt.Printf("错误: 无效的输出格式: %s\n", outputFormat)
+				fmt.Println("有效的格式: text, json")
+				return
+			}
+		case strings.HasPrefix(arg, "--output="):
+			outputFile = strings.TrimPrefix(arg, "--output=")
+		default:
+			fileArgs = append(fileArgs, arg)
+		}
+	}
+
+	expected := 1
+	if validate || normalize {
+		expected = 2
+	}
+	if (validate && normalize) || len(fileArgs) != expected {
+		fmt.Println("错误: avro命令需要一个schema文件参数，使用 --validate 或 --normalize 时还需要一个数据文件参数")
+		fmt.Println("\n用法: leptjson avro [--validate [--format=FORMAT] | --normalize] [--output=FILE] SCHEMA [FILE]")
+		return
+	}
+
+	schemaDoc, err := loadJSON(fileArgs[0], verbose)
+	if err != nil {
+		fmt.Printf("加载schema失败: %s\n", err)
+		exitCLI(1)
+	}
+	schema, err := ParseAvroSchema(schemaDoc)
+	if err != nil {
+		fmt.Printf("解析Avro schema失败: %s\n", err)
+		exitCLI(1)
+	}
+
+	var output *Value
+	if validate || normalize {
+		data, err := loadJSON(fileArgs[1], verbose)
+		if err != nil {
+			fmt.Printf("加载数据文件失败: %s\n", err)
+			exitCLI(1)
+		}
+		if validate {
+			result := ValidateAvroJSON(schema, data)
+			if outputFormat == "json" {
+				resultJSON, _ := json.MarshalIndent(result, "", "  ")
+				fmt.Println(string(resultJSON))
+			} else if result.Valid {
+				fmt.Println("验证通过: 文件是有效的Avro JSON编码")
+			} else {
+				fmt.Printf("验证失败: %s\n", result.Message)
+				for i, err := range result.Errors {
+					fmt.Printf("%d. %s\n", i+1, err)
+				}
+			}
+			if !result.Valid {
+				exitCLI(2)
+			}
+			return
+		}
+		if output, err = NormalizeAvroJSON(schema, data); err != nil {
+			fmt.Printf("转换失败: %s\n", err)
+			exitCLI(1)
+		}
+	} else {
+		output = AvroToJSONSchema(schema)
+	}
+
+	jsonStr, err := formatJSON(output, "  ")
+	if err != nil {
+		fmt.Printf("格式化JSON失败: %s\n", err)
+		exitCLI(1)
+	}
+	if outputFile == "" {
+		fmt.Println(jsonStr)
+		return
+	}
+	if err := saveJSON(outputFile, jsonStr, verbose); err != nil {
+		fmt.Printf("保存文件失败: %s\n", err)
+		exitCLI(1)
+	}
+}
+
+// 实现graph命令
+func runGraph(args []string, verbose bool) {
+	// 解析选项
+	options := DefaultDOTOptions()
+	outputFile := ""
+	fileArgs := args
+
+	for i := 0; i < len(fileArgs); i++ {
+		arg := fileArgs[i]
+
+		if arg == "-o" {
+			if i+1 >= len(fileArgs) {
+				fmt.Println("错误: -o 需要一个文件参数")
+				return
+			}
+			outputFile = fileArgs[i+1]
+			fileArgs = append(fileArgs[:i], fileArgs[i+2:]...)
+			i--
+			continue
+		}
+
+		if strings.HasPrefix(arg, "--output=") {
+			outputFile = strings.TrimPrefix(arg, "--output=")
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+
+		if strings.HasPrefix(arg, "--max-depth=") || strings.HasPrefix(arg, "--max-children=") {
+			name := arg[2:strings.Index(arg, "=")]
+			value := arg[strings.Index(arg, "=")+1:]
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				fmt.Printf("错误: 无效的%s值: %s\n", name, value)
+				return
+			}
+			if name == "max-depth" {
+				options.MaxDepth = n
+			} else {
+				options.MaxChildren = n
+			}
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+
+		if arg == "--no-scalars" {
+			options.IncludeScalars = false
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+	}
+
+	if len(fileArgs) != 1 {
+		fmt.Println("错误: graph命令需要1个文件参数")
+		fmt.Println("\n用法: leptjson graph [-o FILE] [--max-depth=N] [--max-children=N] [--no-scalars] FILE")
+		return
+	}
+
+	doc, err := loadJSON(fileArgs[0], verbose)
+	if err != nil {
+		fmt.Printf("加载文件失败: %s\n", err)
+		exitCLI(1)
+	}
+
+	dot := ToDOT(doc, options)
+	if outputFile == "" {
+		fmt.Print(dot)
+		return
+	}
+	if err := saveJSON(outputFile, dot, verbose); err != nil {
+		fmt.Printf("保存结果失败: %s\n", err)
+		exitCLI(1)
+	}
+	fmt.Printf("DOT图已保存到 %s\n", outputFile)
+}
+
+// 实现runPath命令
+func runPath(args []string, verbose bool) {
+	// 解析选项
+	outputFormat := "pretty" // 默认为美化输出
+	showAll := false         // 默认只显示前10个结果
+	csvFile := ""            // CSV输出文件
+	xlsxFile := ""           // Excel输出文件
+	showPath := true         // 显示路径信息
+	queryOpts := QueryOptions{}
+	aggregations := []string{} // 聚合说明，如 sum:@.price
+	pathOpts := JSONPathOptions{}
+	fileArgs := args
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if strings.HasPrefix(arg, "--output=") {
+			outputFormat = strings.TrimPrefix(arg, "--output=")
+			// 验证输出格式
+			if outputFormat != "compact" && outputFormat != "pretty" &&
+				outputFormat != "raw" && outputFormat != "table" {
+				fmt.Printf("错误: 无效的输出格式: %s\n", outputFormat)
+				fmt.Println("有效的格式: compact, pretty, raw, table")
+				return
+			}
+			// 从参数列表中移除
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+
+		if arg == "--all" {
+			showAll = true
+			// 从参数列表中移除
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+
+		if strings.HasPrefix(arg, "--csv=") {
+			csvFile = strings.TrimPrefix(arg, "--csv=")
+			// 从参数列表中移除
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+
+		if strings.HasPrefix(arg, "--xlsx=") {
+			xlsxFile = strings.TrimPrefix(arg, "--xlsx=")
+			// 从参数列表中移除
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+
+		if arg == "--no-path" {
+			showPath = false
+			// 从参数列表中移除
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+
+		if strings.HasPrefix(arg, "--offset=") || strings.HasPrefix(arg, "--limit=") {
+			name := arg[2:strings.Index(arg, "=")]
+			n, err := strconv.Atoi(arg[strings.Index(arg, "=")+1:])
+			if err != nil || n < 0 {
+				fmt.Printf("错误: 无效的%s值: %s\n", name, arg)
+				return
+			}
+			if name == "offset" {
+				queryOpts.Offset = n
+			} else {
+				queryOpts.Limit = n
+			}
+			// 从参数列表中移除
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+
+		if strings.HasPrefix(arg, "--sort-by=") {
+			queryOpts.SortBy = strings.TrimPrefix(arg, "--sort-by=")
+			queryOpts.Sort = true
+			// 从参数列表中移除
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+
+		if strings.HasPrefix(arg, "--sort-as=") {
+			mode, err := ParseSortMode(strings.TrimPrefix(arg, "--sort-as="))
+			if err != nil {
+				fmt.Printf("错误: %s\n", err)
+				fmt.Println("有效的排序方式: auto, numeric, string")
+				return
+			}
+			queryOpts.SortMode = mode
+			// 从参数列表中移除
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+
+		if arg == "--desc" {
+			queryOpts.Descending = true
+			queryOpts.Sort = true
+			// 从参数列表中移除
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+
+		if strings.HasPrefix(arg, "--agg=") {
+			aggregations = append(aggregations, strings.TrimPrefix(arg, "--agg="))
+			// 从参数列表中移除
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+
+		if arg == "--rfc9535" {
+			pathOpts.RFC9535 = true
+			// 从参数列表中移除
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+	}
+
+	// 检查必要参数
+	if len(fileArgs) != 2 {
+		fmt.Println("错误: path命令需要两个参数")
+		fmt.Println("\n用法: leptjson path [选项] FILE JSONPATH")
+		return
+	}
+
+	filePath := fileArgs[0]
+	jsonPathExpr := fileArgs[1]
+
+	if verbose {
+		fmt.Printf("在文件 %s 中查询 JSONPath: %s\n", filePath, jsonPathExpr)
+	}
+
+	// 加载JSON
+	doc, err := loadJSON(filePath, verbose)
+	if err != nil {
+		fmt.Printf("加载JSON失败: %s\n", err)
+		exitCLI(1)
+	}
+
+	// 解析JSONPath并执行查询
+	path, err := NewJSONPathWithOptions(jsonPathExpr, pathOpts)
+	if err != nil {
+		fmt.Printf("解析JSONPath失败: %s\n", err)
+		exitCLI(1)
+	}
+
+	// 聚合计算使用全部匹配结果，不受分页选项影响
+	if len(aggregations) > 0 {
+		results, err := path.Query(doc)
+		if err != nil {
+			fmt.Printf("执行查询失败: %s\n", err)
+			exitCLI(1)
+		}
+		for _, spec := range aggregations {
+			aggResult, err := Aggregate(results, spec)
+			if err != nil {
+				fmt.Printf("聚合计算失败: %s\n", err)
+				exitCLI(1)
+			}
+			output, _ := minifyJSON(aggResult)
+			if aggResult.Type == OBJECT {
+				output, _ = formatJSON(aggResult, "  ")
+			}
+			fmt.Printf("%s = %s\n", spec, output)
+		}
+		return
+	}
+
+	results, totalResults, err := path.QueryWithOptions(doc, queryOpts)
+	if err != nil {
+		fmt.Printf("执行查询失败: %s\n", err)
+		exitCLI(1)
+	}
+
+	// 显示结果数量
+	if verbose {
+		fmt.Printf("找到 %d 个匹配结果\n", totalResults)
+	}
+
+	// 如果没有结果，提前返回
+	if totalResults == 0 {
+		fmt.Println("没有找到匹配的结果")
+		return
+	}
 
-	// 限制结果数量（除非使用--all选项）
 	displayResults := results
-	if !showAll && totalResults > 10 {
+	resultBase := queryOpts.Offset // 结果编号从偏移量之后开始
+	if queryOpts.Offset > 0 || queryOpts.Limit > 0 {
+		// 使用分页选项时按分页显示
+		if len(results) == 0 {
+			fmt.Printf("偏移量 %d 超出了结果范围（共 %d 个匹配项）\n", queryOpts.Offset, totalResults)
+			return
+		}
+		fmt.Printf("显示第 %d-%d 个结果（共 %d 个匹配项）\n",
+			queryOpts.Offset+1, queryOpts.Offset+len(results), totalResults)
+	} else if !showAll && totalResults > 10 {
+		// 限制结果数量（除非使用--all选项）
 		displayResults = results[:10]
 		fmt.Printf("显示前10个结果（共 %d 个匹配项）。使用 --all 查看所有结果。\n", totalResults)
 	}
@@ -2540,10 +4681,17 @@ func runPath(args []string, verbose bool) {
 	if csvFile != "" {
 		if err := saveResultsAsCSV(displayResults, csvFile); err != nil {
 			fmt.Printf("保存CSV失败: %s\n", err)
-			os.Exit(1)
+			exitCLI(1)
 		}
 		fmt.Printf("结果已保存到CSV文件: %s\n", csvFile)
 	}
+	if xlsxFile != "" {
+		if err := saveResultsAsXLSX(displayResults, xlsxFile); err != nil {
+			fmt.Printf("保存xlsx失败: %s\n", err)
+			exitCLI(1)
+		}
+		fmt.Printf("结果已保存到Excel文件: %s\n", xlsxFile)
+	}
 
 	// 根据输出格式显示结果
 	switch outputFormat {
@@ -2552,11 +4700,11 @@ func runPath(args []string, verbose bool) {
 		for i, result := range displayResults {
 			output, err := minifyJSON(result)
 			if err != nil {
-				fmt.Printf("格式化结果 #%d 失败: %s\n", i+1, err)
+				fmt.Printf("格式化结果 #%d 失败: %s\n", resultBase+i+1, err)
 				continue
 			}
 			if showPath {
-				fmt.Printf("结果 #%d: %s\n", i+1, output)
+				fmt.Printf("结果 #%d: %s\n", resultBase+i+1, output)
 			} else {
 				fmt.Println(output)
 			}
@@ -2567,11 +4715,11 @@ func runPath(args []string, verbose bool) {
 		for i, result := range displayResults {
 			output, err := formatJSON(result, "  ")
 			if err != nil {
-				fmt.Printf("格式化结果 #%d 失败: %s\n", i+1, err)
+				fmt.Printf("格式化结果 #%d 失败: %s\n", resultBase+i+1, err)
 				continue
 			}
 			if showPath {
-				fmt.Printf("结果 #%d:\n%s\n", i+1, output)
+				fmt.Printf("结果 #%d:\n%s\n", resultBase+i+1, output)
 			} else {
 				fmt.Println(output)
 			}
@@ -2581,12 +4729,12 @@ func runPath(args []string, verbose bool) {
 		// 原始值输出
 		for i, result := range displayResults {
 			if showPath {
-				fmt.Printf("结果 #%d: ", i+1)
+				fmt.Printf("结果 #%d: ", resultBase+i+1)
 			}
 
 			switch result.Type {
 			case STRING:
-				fmt.Println(result.S)
+				fmt.Println(GetString(result))
 			case NUMBER:
 				fmt.Println(result.N)
 			case TRUE:
@@ -2612,22 +4760,13 @@ func runPath(args []string, verbose bool) {
 	}
 }
 
-// 将结果保存为CSV文件
-func saveResultsAsCSV(results []*Value, filename string) error {
-	// 创建CSV文件
-	file, err := os.Create(filename)
-	if err != nil {
-		return fmt.Errorf("创建CSV文件失败: %w", err)
-	}
-	defer file.Close()
-
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
-
-	// 如果结果是对象数组，尝试提取所有键作为标题
+// resultsTable 把查询结果整理为表格
+//
+// 结果中有对象时，所有对象的键按字母顺序作为表头，每个对象是一行，缺少的键为 nil，
+// 其他结果被忽略；没有对象时没有表头，所有结果组成一行。
+func resultsTable(results []*Value) ([]string, [][]*Value) {
 	allKeys := make(map[string]bool)
 	objectResults := []*Value{}
-
 	for _, result := range results {
 		if result.Type == OBJECT {
 			objectResults = append(objectResults, result)
@@ -2637,50 +4776,77 @@ func saveResultsAsCSV(results []*Value, filename string) error {
 		}
 	}
 
-	if len(objectResults) > 0 {
-		// 将所有键排序，以确保一致的列顺序
-		headers := make([]string, 0, len(allKeys))
-		for key := range allKeys {
-			headers = append(headers, key)
+	if len(objectResults) == 0 {
+		return nil, [][]*Value{results}
+	}
+
+	// 将所有键排序，以确保一致的列顺序
+	headers := make([]string, 0, len(allKeys))
+	for key := range allKeys {
+		headers = append(headers, key)
+	}
+	sort.Strings(headers)
+
+	rows := make([][]*Value, len(objectResults))
+	for r, obj := range objectResults {
+		row := make([]*Value, len(headers))
+		for i, key := range headers {
+			row[i] = GetObjectValueByKey(obj, key)
 		}
-		sort.Strings(headers)
+		rows[r] = row
+	}
+	return headers, rows
+}
+
+// 将结果保存为CSV文件
+func saveResultsAsCSV(results []*Value, filename string) error {
+	// 创建CSV文件
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("创建CSV文件失败: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
 
+	headers, rows := resultsTable(results)
+	if headers != nil {
 		// 写入标题行
 		if err := writer.Write(headers); err != nil {
 			return fmt.Errorf("写入CSV标题失败: %w", err)
 		}
-
-		// 写入每个对象的值
-		for _, obj := range objectResults {
-			row := make([]string, len(headers))
-			for i, key := range headers {
-				// 查找对象中的键
-				for _, member := range obj.O {
-					if member.K == key {
-						// 将值转换为字符串
-						row[i] = valueToString(member.V)
-						break
-					}
-				}
-			}
-			if err := writer.Write(row); err != nil {
-				return fmt.Errorf("写入CSV行失败: %w", err)
+	}
+	for _, values := range rows {
+		row := make([]string, len(values))
+		for i, v := range values {
+			// 对象缺少的键留空
+			if v != nil {
+				row[i] = valueToString(v)
 			}
 		}
-	} else {
-		// 对于简单值，直接写入
-		row := make([]string, len(results))
-		for i, result := range results {
-			row[i] = valueToString(result)
-		}
 		if err := writer.Write(row); err != nil {
-			return fmt.Errorf("写入CSV数据失败: %w", err)
+			return fmt.Errorf("写入CSV行失败: %w", err)
 		}
 	}
 
 	return nil
 }
 
+// 将结果保存为Excel电子表格，表头与CSV相同
+func saveResultsAsXLSX(results []*Value, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("创建xlsx文件失败: %w", err)
+	}
+	headers, rows := resultsTable(results)
+	if err := WriteXLSX(file, "Results", headers, rows); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
 // 将结果打印为表格
 func printResultsAsTable(results []*Value) {
 	// 表格输出仅对对象数组有意义
@@ -2793,7 +4959,7 @@ func valueToString(v *Value) string {
 	case NUMBER:
 		return fmt.Sprintf("%g", v.N)
 	case STRING:
-		return v.S
+		return GetString(v)
 	case OBJECT:
 		// 对于对象，返回键数量摘要
 		return fmt.Sprintf("{...} (%d keys)", len(v.O))
@@ -0,0 +1,993 @@
+// cli_commands.go - 命令行子命令表，统一生成帮助信息并检查选项
+package leptjson
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// cliFlag 是子命令的一个选项
+type cliFlag struct {
+	Name     string // 选项名，如 "--indent"
+	Value    string // 值的占位符，如 "N"；为空时是不带值的开关
+	Optional bool   // 值可以省略，如 --sort-keys[=NAME]
+	Short    string // 单字母别名，如 "-o"，值是下一个参数
+	Usage    string // 说明，可以有多行
+}
+
+// display 返回选项在帮助信息中的写法
+func (f cliFlag) display() string {
+	name := f.Name
+	switch {
+	case f.Value != "" && f.Optional:
+		name += "[=" + f.Value + "]"
+	case f.Value != "":
+		name += "=" + f.Value
+	}
+	if f.Short != "" {
+		name = f.Short + " " + f.Value + ", " + name
+	}
+	return name
+}
+
+// cliArg 是子命令的一个位置参数
+type cliArg struct {
+	Name  string
+	Usage string
+}
+
+// cliCommand 描述一个子命令，帮助信息和选项检查都由它生成
+type cliCommand struct {
+	Name     string
+	Summary  string    // 一句话说明，用于命令列表和帮助信息的标题
+	Usage    string    // 命令名之后的用法，如 "[选项] FILE [OUTPUT]"
+	Flags    []cliFlag // 命令接受的选项，调用 Run 之前检查
+	Args     []cliArg
+	Details  string   // 附加在帮助信息末尾的说明，按原样输出
+	Examples []string // 用法示例，不含开头的 "leptjson "
+	Run      func(args []string, verbose bool)
+}
+
+// lookupFlag 按选项名或别名查找选项
+func (c *cliCommand) lookupFlag(name string) *cliFlag {
+	for i := range c.Flags {
+		if c.Flags[i].Name == name || (c.Flags[i].Short != "" && c.Flags[i].Short == name) {
+			return &c.Flags[i]
+		}
+	}
+	return nil
+}
+
+// checkFlags 检查参数中的选项都是命令声明过的，并且值的写法正确
+//
+// 以单个 - 开头但不是已声明别名的参数（如负数）按位置参数处理。
+func (c *cliCommand) checkFlags(args []string) error {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			continue
+		}
+		name, hasValue := arg, false
+		if eq := strings.Index(arg, "="); eq >= 0 {
+			name, hasValue = arg[:eq], true
+		}
+		flag := c.lookupFlag(name)
+		if flag == nil {
+			if strings.HasPrefix(arg, "--") {
+				return fmt.Errorf("%s命令不支持选项 %s", c.Name, name)
+			}
+			continue
+		}
+		switch {
+		case name == flag.Short:
+			if hasValue || i+1 >= len(args) {
+				return fmt.Errorf("选项 %s 需要一个%s参数", name, flag.Value)
+			}
+			i++
+		case flag.Value == "" && hasValue:
+			return fmt.Errorf("选项 %s 不接受值", name)
+		case flag.Value != "" && !hasValue && !flag.Optional:
+			return fmt.Errorf("选项 %s 需要一个值，如 %s=%s", name, name, flag.Value)
+		}
+	}
+	return nil
+}
+
+// 帮助信息中选项和参数说明的起始列
+const (
+	helpColumnMin = 14
+	helpColumnMax = 20
+)
+
+// helpColumn 返回能容纳这些名字的说明起始列
+func helpColumn(names []string) int {
+	column := helpColumnMin
+	for _, name := range names {
+		if len(name)+2 > column && len(name)+2 <= helpColumnMax {
+			column = len(name) + 2
+		}
+	}
+	return column
+}
+
+// writeHelpItems 输出选项或参数列表，说明从 column 列开始，名字过长时说明从下一行开始
+func writeHelpItems(w io.Writer, indent string, column int, names, usages []string) {
+	pad := strings.Repeat(" ", column)
+	for i, name := range names {
+		lines := strings.Split(usages[i], "\n")
+		if len(name)+2 > column {
+			fmt.Fprintf(w, "%s%s\n%s%s%s\n", indent, name, indent, pad, lines[0])
+		} else {
+			fmt.Fprintf(w, "%s%-*s%s\n", indent, column, name, lines[0])
+		}
+		for _, line := range lines[1:] {
+			fmt.Fprintf(w, "%s%s%s\n", indent, pad, line)
+		}
+	}
+}
+
+// flagItems 返回选项在帮助信息中的写法和说明，brief 为 true 时只取说明的第一行
+func (c *cliCommand) flagItems(brief bool) (names, usages []string) {
+	for _, flag := range c.Flags {
+		usage := flag.Usage
+		if brief {
+			usage = strings.SplitN(usage, "\n", 2)[0]
+		}
+		names = append(names, flag.display())
+		usages = append(usages, usage)
+	}
+	return names, usages
+}
+
+// argItems 返回位置参数的名字和说明
+func (c *cliCommand) argItems() (names, usages []string) {
+	for _, arg := range c.Args {
+		names = append(names, arg.Name)
+		usages = append(usages, arg.Usage)
+	}
+	return names, usages
+}
+
+// writeHelp 输出命令的完整帮助信息
+func (c *cliCommand) writeHelp(w io.Writer) {
+	fmt.Fprintf(w, "leptjson %s - %s\n", c.Name, c.Summary)
+	fmt.Fprintf(w, "\n用法: leptjson %s %s\n", c.Name, c.Usage)
+	flagNames, flagUsages := c.flagItems(false)
+	argNames, argUsages := c.argItems()
+	// 选项和参数的说明对齐到同一列
+	column := helpColumn(append(append([]string{}, flagNames...), argNames...))
+	if len(flagNames) > 0 {
+		fmt.Fprintln(w, "\n选项:")
+		writeHelpItems(w, "  ", column, flagNames, flagUsages)
+	}
+	if len(argNames) > 0 {
+		fmt.Fprintln(w, "\n参数:")
+		writeHelpItems(w, "  ", column, argNames, argUsages)
+	}
+	if c.Details != "" {
+		fmt.Fprint(w, c.Details)
+	}
+	if len(c.Examples) > 0 {
+		fmt.Fprintln(w, "\n示例:")
+		for _, example := range c.Examples {
+			fmt.Fprintf(w, "  leptjson %s\n", example)
+		}
+	}
+}
+
+// lookupCommand 按名字查找子命令
+func lookupCommand(name string) *cliCommand {
+	for _, c := range cliCommands {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// runCommand 检查选项后执行子命令
+func runCommand(c *cliCommand, args []string, verbose bool) {
+	if err := c.checkFlags(args); err != nil {
+		fmt.Printf("错误: %s\n", err)
+		fmt.Printf("\n用法: leptjson %s %s\n", c.Name, c.Usage)
+		fmt.Printf("使用 leptjson %s --help 查看所有选项\n", c.Name)
+		exitCLI(1)
+	}
+	c.Run(args, verbose)
+}
+
+// 打印子命令的帮助信息
+func printSubcommandHelp(command string) {
+	c := lookupCommand(command)
+	if c == nil {
+		// 插件自己输出帮助信息
+		if plugin, ok := LookupPlugin(command); ok {
+			runPlugin(plugin, []string{"--help"}, false)
+			return
+		}
+		fmt.Printf("未知的命令: %s\n", command)
+		printUsage()
+		return
+	}
+	c.writeHelp(os.Stdout)
+}
+
+// 打印用法信息
+func printUsage() {
+	writeUsage(os.Stdout)
+}
+
+// writeUsage 输出全局选项、命令列表和每个命令的概要
+func writeUsage(w io.Writer) {
+	fmt.Fprintln(w, "用法: leptjson [选项] 命令 [参数]")
+	fmt.Fprintln(w, "\n全局选项:")
+	fmt.Fprintln(w, "  --help, -h      显示帮助信息")
+	fmt.Fprintln(w, "  --verbose, -v   显示详细输出")
+	fmt.Fprintln(w, "  --version       显示版本信息")
+	fmt.Fprintln(w, "  --cpuprofile=FILE  将CPU分析数据写入FILE（go tool pprof 查看）")
+	fmt.Fprintln(w, "  --memprofile=FILE  命令结束时将内存分析数据写入FILE")
+	fmt.Fprintln(w, "  --trace=FILE       将执行追踪数据写入FILE（go tool trace 查看）")
+	fmt.Fprintln(w, "  --mmap             通过内存映射读取输入文件，减少解析大文件时的内存占用")
+	fmt.Fprintln(w, "  --header=\"名称: 值\" 读取http(s) URL输入时附加的请求头，可以重复")
+	fmt.Fprintln(w, "  --http-timeout=DURATION  读取URL输入的超时时间（默认30s）")
+	fmt.Fprintln(w, "  --max-fetch-size=BYTES   URL响应体的最大字节数（默认为解析的总大小限制）")
+	fmt.Fprintln(w, "\n输入文件可以是 http:// 或 https:// URL，命令会先下载再处理。")
+	fmt.Fprintln(w, "用 -tags s3 或 -tags gcs 编译时还可以直接读取 s3://bucket/key 和 gs://bucket/object，")
+	fmt.Fprintln(w, "凭证分别来自 AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY 和 GOOGLE_OAUTH_ACCESS_TOKEN 环境变量。")
+
+	fmt.Fprintln(w, "\n可用命令:")
+	for _, c := range cliCommands {
+		fmt.Fprintf(w, "  %-15s %s\n", c.Name, c.Summary)
+	}
+	if plugins := FindPlugins(); len(plugins) > 0 {
+		fmt.Fprintln(w, "\n插件命令（PATH中的leptjson-<命令>）:")
+		for _, plugin := range plugins {
+			if lookupCommand(plugin.Name) == nil {
+				fmt.Fprintf(w, "  %-15s %s\n", plugin.Name, plugin.Path)
+			}
+		}
+	}
+
+	fmt.Fprintln(w, "\n命令详情（使用 leptjson 命令 --help 查看完整说明）:")
+	for _, c := range cliCommands {
+		fmt.Fprintf(w, "\n  %s %s\n", c.Name, c.Usage)
+		fmt.Fprintf(w, "    %s\n", c.Summary)
+		if len(c.Flags) > 0 {
+			names, usages := c.flagItems(true)
+			fmt.Fprintln(w, "    选项:")
+			writeHelpItems(w, "      ", helpColumn(names), names, usages)
+		}
+	}
+
+	fmt.Fprintln(w, "\n示例:")
+	for _, c := range cliCommands {
+		for _, example := range c.Examples {
+			fmt.Fprintf(w, "  leptjson %s\n", example)
+		}
+	}
+	fmt.Fprintln(w, "  leptjson --cpuprofile=cpu.out stats huge.json")
+	fmt.Fprintln(w, "  leptjson --mmap minify huge.json")
+	fmt.Fprintln(w, "  leptjson --header=\"Authorization: Bearer $TOKEN\" path https://api.example.com/data '$.items[*].id'")
+}
+
+// 常用选项的说明
+var (
+	quietFlag       = cliFlag{Name: "--quiet", Usage: "读取大文件时不显示进度条"}
+	langFlag        = cliFlag{Name: "--lang", Value: "LANG", Usage: "错误描述的语言，可选值: zh, en（默认为zh）"}
+	offlineFlag     = cliFlag{Name: "--offline", Usage: "不访问网络，远程$ref只能来自缓存，否则验证失败"}
+	schemaCacheFlag = cliFlag{Name: "--schema-cache", Value: "DIR", Usage: "远程$ref的缓存目录（默认为用户缓存目录下的leptjson/schemas）"}
+	inPlaceFlag     = cliFlag{Name: "--in-place", Usage: "直接修改原文件，不创建新文件"}
+)
+
+// cliCommands 是所有子命令，按命令列表中的顺序排列
+var cliCommands = []*cliCommand{
+	{
+		Name:     "parse",
+		Summary:  "解析并验证JSON文件",
+		Usage:    "FILE",
+		Args:     []cliArg{{"FILE", "要解析的JSON文件路径"}},
+		Examples: []string{"parse data.json"},
+		Run:      runParse,
+	},
+	{
+		Name:    "format",
+		Summary: "格式化JSON文件，增加缩进和换行",
+		Usage:   "[选项] FILE [OUTPUT]",
+		Flags: []cliFlag{
+			{Name: "--indent", Value: "N", Usage: "设置缩进空格数（默认为2，或项目配置中的indent）"},
+			{Name: "--print-width", Value: "N", Usage: "能在N列以内放下的数组和对象写在一行（默认为项目配置中的printWidth）\n如 [1, 2, 3]，0表示总是展开为多行"},
+			{Name: "--key-case", Value: "STYLE", Usage: "转换对象键的命名风格，可选值: camel, snake, kebab, pascal"},
+			{Name: "--key-case-exclude", Value: "POINTER", Usage: "不转换该JSON Pointer指向的成员及其子树（可重复）"},
+			{Name: "--sort-keys", Value: "NAME", Optional: true, Usage: "按已注册的排序规则输出对象键（默认alpha，按字典序）\nnatural 中的数字按数值排序（item2 在 item10 之前），collate 忽略大小写和重音"},
+			{Name: "--key-order", Value: "KEY1,KEY2,...", Usage: "指定的键按顺序排在最前面，其余键按字典序排列"},
+			{Name: "--preview", Value: "DEPTH,ITEMS,CHARS", Optional: true, Usage: "截断输出用于预览（默认4,20,200），没有OUTPUT时输出到标准输出\n最多展开DEPTH层、每个数组或对象保留ITEMS个元素、字符串保留CHARS个字符，0表示不限制"},
+			{Name: "--comments", Usage: "允许 // 和 /* */ 注释，并把注释输出在所属的值之前或同一行的末尾"},
+			{Name: "--style", Value: "STYLE", Usage: "输出风格: json（默认）或 json5\njson5 在多行的数组和对象末尾加逗号，标识符形式的键不加引号"},
+			quietFlag,
+		},
+		Args: []cliArg{
+			{"FILE", "要格式化的JSON文件路径"},
+			{"OUTPUT", "输出文件路径（可选，默认为FILE.formatted.json，json5 风格时为FILE.formatted.json5）"},
+		},
+		Examples: []string{
+			"format --indent=2 data.json pretty.json",
+			"format --print-width=80 data.json",
+			"format --comments tsconfig.json tsconfig.json",
+			"format --style=json5 --comments config.json config.json5",
+			"format --preview=3,5,80 huge.json",
+		},
+		Run: runFormat,
+	},
+	{
+		Name:    "minify",
+		Summary: "最小化JSON文件，移除所有不必要的空白字符",
+		Usage:   "[选项] FILE [OUTPUT]",
+		Flags:   []cliFlag{quietFlag},
+		Args: []cliArg{
+			{"FILE", "要最小化的JSON文件路径"},
+			{"OUTPUT", "输出文件路径（可选，默认为FILE.min.json）"},
+		},
+		Examples: []string{"minify large.json small.json"},
+		Run:      runMinify,
+	},
+	{
+		Name:    "string-table",
+		Summary: "把重复的字符串值提取到字典中以缩小文件，或还原",
+		Usage:   "[选项] FILE [OUTPUT]",
+		Flags: []cliFlag{
+			{Name: "--decode", Usage: "还原字符串表编码的文件"},
+			{Name: "--min-count", Value: "N", Usage: "至少出现N次的字符串才放入字典（默认2）"},
+			{Name: "--min-length", Value: "N", Usage: "至少N字节的字符串才放入字典（默认0）"},
+		},
+		Args: []cliArg{
+			{"FILE", "要编码或还原的JSON文件路径"},
+			{"OUTPUT", "输出文件路径（可选，默认输出到标准输出）"},
+		},
+		Details: `
+说明:
+  编码结果为紧凑的 {"strings": [...], "data": ...}，data 中的 "#下标" 引用字典中的字符串，
+  原本以 # 开头的字符串前面再加一个 #。只有替换后总长度变短的字符串才放入字典。
+`,
+		Examples: []string{
+			"string-table catalog.json catalog.st.json",
+			"string-table --decode catalog.st.json",
+		},
+		Run: runStringTable,
+	},
+	{
+		Name:    "stats",
+		Summary: "显示JSON统计信息",
+		Usage:   "[选项] FILE",
+		Flags: []cliFlag{
+			{Name: "--json", Usage: "以JSON格式输出统计信息"},
+			quietFlag,
+		},
+		Args:     []cliArg{{"FILE", "要分析的JSON文件路径"}},
+		Examples: []string{"stats --json data.json"},
+		Run:      runStats,
+	},
+	{
+		Name:    "audit",
+		Summary: "检查JSON中可疑的结构并给出风险报告",
+		Usage:   "[选项] FILE",
+		Flags: []cliFlag{
+			{Name: "--format", Value: "FORMAT", Usage: "设置输出格式，可选值: text, json（默认为text）"},
+			{Name: "--max-depth", Value: "N", Usage: "嵌套深度超过N时报告（默认64）"},
+			{Name: "--max-string", Value: "N", Usage: "字符串或键超过N字节时报告（默认65536）"},
+			{Name: "--max-expansion", Value: "N", Usage: "$ref展开后超过N个值时报告（默认100000）"},
+		},
+		Args: []cliArg{{"FILE", "要检查的JSON文件路径"}},
+		Details: `
+说明:
+  检查过深的嵌套、过长的字符串、超出范围的数字、重复的键、无效的UTF-8，
+  以及通过文档内部的$ref指数级展开的结构，并给出风险等级。
+  不受默认解析限制的约束，风险等级为high时退出码为2。
+`,
+		Examples: []string{"audit --format=json upload.json"},
+		Run:      runAudit,
+	},
+	{
+		Name:    "dedup",
+		Summary: "查找重复的子树，或把重复的子树替换为$ref引用",
+		Usage:   "[选项] FILE",
+		Flags: []cliFlag{
+			{Name: "--min-size", Value: "N", Usage: "只处理紧凑序列化后至少N字节的数组和对象（默认0）"},
+			{Name: "--top", Value: "N", Usage: "最多列出N组重复（默认20，0表示全部）"},
+			{Name: "--json", Usage: "以JSON格式输出报告"},
+			{Name: "--apply", Usage: "输出去重后的文档，而不是报告"},
+			{Name: "--output", Value: "FILE", Usage: "保存结果到指定文件（默认输出到标准输出）"},
+		},
+		Args: []cliArg{{"FILE", "要分析的JSON文件路径"}},
+		Details: `
+说明:
+  相等按Equal的语义判断，对象的键顺序不同也算重复。只报告最外层的重复，
+  可节省的字节数是 --apply 实际节省的字节数。--apply 保留第一次出现的子树，
+  之后的每一次出现都替换为 {"$ref": "#/指向第一次出现的JSON Pointer"}。
+`,
+		Examples: []string{
+			"dedup --min-size=100 catalog.json",
+			"dedup --apply --output=catalog.dedup.json catalog.json",
+		},
+		Run: runDedup,
+	},
+	{
+		Name:    "find",
+		Summary: "在JSON中查找特定路径的值（简化版JSONPath）",
+		Usage:   "[选项] FILE JSONPATH",
+		Flags: []cliFlag{
+			{Name: "--output", Value: "FORMAT", Usage: "设置输出格式，可选值: compact, pretty, raw（默认为compact）"},
+		},
+		Args: []cliArg{
+			{"FILE", "要搜索的JSON文件路径"},
+			{"JSONPATH", "JSONPath表达式，如$.store.book[0].title"},
+		},
+		Examples: []string{`find --output=pretty data.json "$.store.book[0].title"`},
+		Run:      runFind,
+	},
+	{
+		Name:    "path",
+		Summary: "使用完整JSONPath语法查询JSON数据",
+		Usage:   "[选项] FILE JSONPATH",
+		Flags: []cliFlag{
+			{Name: "--output", Value: "FORMAT", Usage: "设置输出格式，可选值: compact, pretty, raw, table（默认为pretty）"},
+			{Name: "--all", Usage: "显示所有匹配的结果（默认只显示前10个）"},
+			{Name: "--csv", Value: "FILE", Usage: "将结果输出为CSV文件"},
+			{Name: "--xlsx", Value: "FILE", Usage: "将结果输出为Excel电子表格，表头与CSV相同，数字和布尔值保留类型"},
+			{Name: "--no-path", Usage: "不在输出中显示路径信息"},
+			{Name: "--sort-by", Value: "EXPR", Usage: "按相对于每个结果的表达式排序，如 @.price（@ 表示结果本身）"},
+			{Name: "--sort-as", Value: "MODE", Usage: "排序键的比较方式: auto, numeric, string（默认为auto）"},
+			{Name: "--desc", Usage: "降序排序"},
+			{Name: "--offset", Value: "N", Usage: "跳过前N个结果"},
+			{Name: "--limit", Value: "N", Usage: "最多显示N个结果"},
+			{Name: "--agg", Value: "FUNC[:EXPR]", Usage: "对所有结果做聚合计算并输出，可重复。FUNC可选: count, sum, min, max, avg, group\nEXPR相对于每个结果求值，如 --agg=sum:@.price、--agg=group:@.category"},
+			{Name: "--rfc9535", Usage: "严格按RFC 9535解析和求值，不支持 =~、in、nin、keys() 等扩展"},
+		},
+		Args: []cliArg{
+			{"FILE", "要查询的JSON文件路径"},
+			{"JSONPATH", "JSONPath表达式，如$.store.book[*].author"},
+		},
+		Details: `
+说明:
+  该命令使用JSONPath表达式从JSON文件中提取数据。
+  JSONPath是一种用于从JSON文档中选择和提取数据的查询语言。
+
+支持的JSONPath语法:
+  $                  根对象或数组
+  .property          子属性
+  ['property']       子属性（带引号）
+  [index]            数组索引
+  [start:end:step]   数组切片
+  *                  通配符，匹配所有属性或元素
+  ..property         递归下降，匹配任意深度的属性
+  [?(@.prop > 10)]   过滤表达式
+  [?(@.prop)]        存在性检查
+  [?(@.name == 'x')] 相等性检查
+  [?(@.a < 1 && !@.b)] 比较运算 == != < <= > >= 和逻辑运算 && || !
+  [?(@.name =~ /^a/i)] 正则表达式匹配
+  [?(@.s in ['a','b'])] 列表成员检查，nin 表示不在列表中
+  [?(length(@.tags) > 2)] 函数: length, count, keys, value, match, search
+  ['a','b']          多属性选择
+
+  使用 --rfc9535 时还支持任意选择器的并集（如 [0,'a',1:3]），切片、负索引和
+  函数的类型检查都遵循RFC 9535，match() 和 search() 使用I-Regexp语法。
+`,
+		Examples: []string{`path --output=table data.json "$..book[?(@.price < 10)]"`},
+		Run:      runPath,
+	},
+	{
+		Name:    "compare",
+		Summary: "比较两个JSON文件并显示差异",
+		Usage:   "[选项] FILE1 FILE2",
+		Flags: []cliFlag{
+			{Name: "--json", Usage: "以JSON格式输出差异，等同于 --output=json"},
+			{Name: "--output", Value: "FORMAT", Usage: "设置输出格式，可选值: text, json, html（默认为text）\nhtml 输出左右并排、高亮差异的独立HTML页面"},
+			{Name: "--normalize", Value: "FORM", Usage: "字符串和键按 Unicode 规范化形式比较，可选值: nfc, nfd\n组合与分解形式（如 \"é\" 和 \"e\" + U+0301）不再报告为差异"},
+			{Name: "--ignore", Value: "PATH", Usage: "不比较匹配的值及其子树（可重复），PATH 是JSONPath或JSON Pointer\n如 $..updatedAt 或 /meta/requestId"},
+			{Name: "--array-key", Value: "KEY", Usage: "对象数组的元素按键 KEY 的值（如 id）而不是下标匹配\n报告新增、删除和移动的元素"},
+			{Name: "--lcs", Usage: "标量数组按最长公共子序列比较，只报告插入、删除、修改和移动的元素"},
+		},
+		Args: []cliArg{
+			{"FILE1", "第一个JSON文件路径"},
+			{"FILE2", "第二个JSON文件路径"},
+		},
+		Examples: []string{
+			"compare original.json updated.json",
+			"compare --output=html original.json updated.json > report.html",
+			"compare --normalize=nfc export.json import.json",
+			"compare --ignore='$..timestamp' --ignore=/meta/requestId expected.json actual.json",
+			"compare --array-key=id users-before.json users-after.json",
+		},
+		Run: runCompare,
+	},
+	{
+		Name:    "check",
+		Summary: "检查JSON文件是否有效并符合项目的规范格式",
+		Usage:   "[选项] FILE...",
+		Flags: []cliFlag{
+			{Name: "--fix", Usage: "将不符合规范格式的文件改写为规范格式"},
+		},
+		Args: []cliArg{{"FILE", "要检查的JSON文件路径，可以有多个"}},
+		Details: `
+说明:
+  规范格式由从文件所在目录向上找到的第一个.leptjsonrc决定，例如
+  {"indent": 2, "sortKeys": "alpha", "keyOrder": ["name"], "finalNewline": true}，
+  没有配置文件时使用2个空格缩进、保持键顺序、以换行结尾。
+  不符合时输出统一格式的差异，有文件无效或不符合时退出码为1，可用作pre-commit钩子。
+`,
+		Examples: []string{"check --fix config/*.json"},
+		Run:      runCheck,
+	},
+	{
+		Name:    "config",
+		Summary: "显示当前目录生效的项目配置",
+		Usage:   "[FILE]",
+		Args:    []cliArg{{"FILE", "可选，同时显示适用于该文件的Schema"}},
+		Details: `
+说明:
+  项目配置是从当前目录向上找到的第一个.leptjsonrc或leptjson.config.json，例如
+  {"indent": 4, "color": "never", "security": {"maxDepth": 100},
+   "schemas": {"config/**/*.json": "schemas/config.schema.json"}}。
+  indent、sortKeys、keyOrder是format的默认值，color是patch等命令的默认值，
+  security中的maxDepth、maxStringLength、maxArraySize、maxObjectSize、maxTotalSize
+  限制读取的输入文件，schemas中的文件在validate只给出FILE时使用对应的Schema。
+  模式和Schema路径都相对于配置文件所在目录。
+`,
+		Examples: []string{"config config/app.json"},
+		Run:      runConfig,
+	},
+	{
+		Name:    "validate",
+		Summary: "使用JSON Schema验证JSON文件",
+		Usage:   "[选项] [SCHEMA] FILE",
+		Flags: []cliFlag{
+			{Name: "--format", Value: "FORMAT", Usage: "设置输出格式，可选值: text, json, html（默认为text）"},
+			{Name: "--output", Value: "FORMAT", Usage: "同 --format"},
+			langFlag,
+			offlineFlag,
+			schemaCacheFlag,
+		},
+		Args: []cliArg{
+			{"SCHEMA", "JSON Schema文件路径，省略时使用项目配置schemas中的映射"},
+			{"FILE", "要验证的JSON文件路径"},
+		},
+		Details: `
+说明:
+  该命令使用JSON Schema验证JSON文件的结构和内容。
+  验证失败时会显示详细的错误信息。
+  Schema中的x-errorMessage可以为该层的错误指定描述，字符串用于所有错误，
+  对象按关键字指定，如{"minimum": "年龄不能小于{limit}"}。
+  $ref可以引用本地文件和http(s) URL，相对路径以Schema文件所在位置为基准。
+  支持Draft-07版本的JSON Schema规范的主要功能。
+`,
+		Examples: []string{
+			"validate --format=json schema.json data.json",
+			"validate --lang=en schema.json data.json",
+			"validate --offline --schema-cache=.schemas schema.json data.json",
+		},
+		Run: runValidate,
+	},
+	{
+		Name:    "validate-tree",
+		Summary: "并发验证目录树中的JSON文件并输出汇总报告",
+		Usage:   "[选项] SCHEMA DIR",
+		Flags: []cliFlag{
+			{Name: "--pattern", Value: "GLOB", Usage: "要验证的文件，相对于DIR，** 匹配任意层目录（默认为**/*.json）"},
+			{Name: "--schema-name", Value: "NAME", Usage: "目录中的Schema文件名，文件使用最近的上层目录中的该Schema，没有时使用SCHEMA"},
+			{Name: "--jobs", Value: "N", Usage: "同时验证的文件数（默认为CPU数）"},
+			{Name: "--format", Value: "FORMAT", Usage: "输出格式，可选值: json, text（默认为json）"},
+			langFlag,
+			offlineFlag,
+			schemaCacheFlag,
+		},
+		Args: []cliArg{
+			{"SCHEMA", "默认的JSON Schema文件路径"},
+			{"DIR", "要遍历的目录"},
+		},
+		Details: `
+说明:
+  输出包含每个文件结果的汇总报告。有文件验证失败或无法验证时退出码为2。
+`,
+		Examples: []string{"validate-tree --schema-name=schema.json --format=text schema.json configs/"},
+		Run:      runValidateTree,
+	},
+	{
+		Name:    "prune",
+		Summary: "删除JSON文件中Schema没有声明的属性",
+		Usage:   "[选项] SCHEMA FILE",
+		Flags: []cliFlag{
+			{Name: "--output", Value: "FILE", Usage: "保存修剪后的JSON到指定文件（默认输出到标准输出）"},
+		},
+		Args: []cliArg{
+			{"SCHEMA", "JSON Schema文件路径"},
+			{"FILE", "要修剪的JSON文件路径"},
+		},
+		Details: `
+说明:
+  对象只保留properties和patternProperties中声明的属性；
+  additionalProperties为true或模式时保留额外的属性。
+  没有声明任何属性的对象保持不变。该命令不做验证。
+`,
+		Examples: []string{"prune --output=public.json schema.json response.json"},
+		Run:      runPrune,
+	},
+	{
+		Name:    "anonymize",
+		Summary: "把JSON文件中的字符串和数字替换为逼真的假数据",
+		Usage:   "[选项] FILE...",
+		Flags: []cliFlag{
+			{Name: "--seed", Value: "KEY", Usage: "生成假数据的密钥，相同的密钥总是得到相同的结果（默认随机）"},
+			{Name: "--keep", Value: "PATH", Usage: "不替换匹配的值及其子树（可重复），PATH 是JSONPath或JSON Pointer"},
+			{Name: "--keep-numbers", Usage: "数字保持不变"},
+			{Name: "--output", Value: "FILE", Usage: "保存结果到指定文件（默认输出到标准输出），只能用于一个文件"},
+			inPlaceFlag,
+		},
+		Args: []cliArg{{"FILE", "要匿名化的JSON文件路径，多个文件时需要 --in-place"}},
+		Details: `
+说明:
+  对象的键、数组长度、值的类型、布尔值和null保持不变。假数据保留原值的格式：
+  字符数、大小写和标点位置不变，邮箱仍是邮箱，UUID仍是UUID，日期仍是有效日期。
+  同一次运行的所有文件中，相同的值总是替换为相同的假数据，ID之间的引用关系仍然成立。
+`,
+		Examples: []string{
+			"anonymize --keep='$..status' --output=fixture.json response.json",
+			"anonymize --seed=fixtures --in-place users.json orders.json",
+		},
+		Run: runAnonymize,
+	},
+	{
+		Name:    "pointer",
+		Summary: "使用JSON Pointer (RFC 6901)操作JSON文件",
+		Usage:   "[选项] FILE POINTER",
+		Flags: []cliFlag{
+			{Name: "--operation", Value: "OP", Usage: "操作类型: get（默认）、add、remove、replace、move 或 copy\n  - get: 获取值（默认）\n  - add: 添加或替换值\n  - remove: 删除值\n  - replace: 替换值\n  - move: 将--from处的值移动到POINTER\n  - copy: 将--from处的值复制到POINTER"},
+			{Name: "--value", Value: "JSON", Usage: "用于add和replace操作的JSON值"},
+			{Name: "--from", Value: "POINTER", Usage: "用于move和copy操作的源路径"},
+			{Name: "--negative-index", Usage: "允许负数数组索引，-1表示最后一个元素（RFC 6901的扩展）"},
+			{Name: "--output", Value: "FILE", Usage: "保存修改后的JSON到指定文件"},
+		},
+		Args: []cliArg{
+			{"FILE", "要操作的JSON文件路径"},
+			{"POINTER", "JSON Pointer路径，如/users/0/name"},
+		},
+		Details: `
+说明:
+  该命令实现了RFC 6901中定义的JSON Pointer，用于在JSON文档中定位和操作值。
+  JSON Pointer以/开头，使用/分隔路径片段，如/foo/0/bar引用{"foo":[{"bar":42}]}中的42。
+  ~0表示~，~1表示/。数组索引不能有前导零；add和move的目标可以用-表示追加到数组末尾。
+`,
+		Examples: []string{
+			`pointer data.json "/users/0/name"`,
+			`pointer --operation=replace --value="John" data.json "/users/0/name"`,
+			`pointer --operation=move --from=/draft data.json "/published"`,
+		},
+		Run: runPointer,
+	},
+	{
+		Name:    "edit",
+		Summary: "按JSONPath批量删除或修改JSON文件中的值",
+		Usage:   "--path=EXPR (--set=JSON | --delete) [选项] FILE",
+		Flags: []cliFlag{
+			{Name: "--path", Value: "EXPR", Usage: "要修改的值的JSONPath表达式（必需）"},
+			{Name: "--set", Value: "JSON", Usage: "将所有匹配的值替换为JSON值"},
+			{Name: "--delete", Usage: "删除所有匹配的值"},
+			{Name: "--rfc9535", Usage: "严格按RFC 9535解析JSONPath"},
+			{Name: "--output", Value: "FILE", Usage: "保存修改后的JSON到指定文件（默认覆盖原文件）"},
+		},
+		Args: []cliArg{{"FILE", "要修改的JSON文件路径"}},
+		Details: `
+说明:
+  JSONPath只能匹配已存在的值，--set不会创建新的成员。
+  位于另一个匹配之内的匹配随外层一起删除或替换。文档根节点不能删除。
+`,
+		Examples: []string{
+			`edit --path="$.items[?(@.done == true)]" --delete todo.json`,
+			`edit --path="$..password" --set='"***"' users.json`,
+		},
+		Run: runEdit,
+	},
+	{
+		Name:    "patch",
+		Summary: "使用JSON Patch (RFC 6902)修改JSON文件",
+		Usage:   "[选项] PATCH FILE [OUTPUT]",
+		Flags: []cliFlag{
+			inPlaceFlag,
+			{Name: "--test", Usage: "完整模拟应用补丁并显示每个路径修改前后的值，不实际修改文件"},
+			{Name: "--color", Value: "MODE", Usage: "预览是否使用颜色: auto, always, never（默认为auto，或项目配置中的color）"},
+			{Name: "--inverse", Value: "FILE", Usage: "将撤销此补丁的逆补丁保存到FILE"},
+		},
+		Args: []cliArg{
+			{"PATCH", "包含JSON Patch操作的文件"},
+			{"FILE", "要修改的JSON文件"},
+			{"OUTPUT", "输出文件路径（可选）"},
+		},
+		Details: `
+说明:
+  该命令实现了RFC 6902中定义的JSON Patch，用于修改JSON文档。
+  JSON Patch是一组操作指令，如add、remove、replace、move、copy和test。
+  每个操作都有一个'op'字段指定操作类型，以及一个'path'字段指定操作位置。
+`,
+		Examples: []string{"patch patch.json data.json result.json"},
+		Run:      runPatch,
+	},
+	{
+		Name:    "merge-patch",
+		Summary: "使用JSON Merge Patch (RFC 7396)合并JSON文件",
+		Usage:   "[选项] PATCH FILE [OUTPUT]",
+		Flags:   []cliFlag{inPlaceFlag},
+		Args: []cliArg{
+			{"PATCH", "包含Merge Patch操作的JSON文件"},
+			{"FILE", "要修改的目标JSON文件"},
+			{"OUTPUT", "输出文件路径（可选，默认为FILE.merged.json）"},
+		},
+		Details: `
+说明:
+  该命令实现了RFC 7396中定义的JSON Merge Patch，用于简化JSON文档的合并。
+  与JSON Patch不同，JSON Merge Patch本身就是一个JSON对象，结构与目标文档类似。
+  合并规则:
+    - 如果补丁中的值为null，则从目标中删除该字段
+    - 如果补丁中包含非null值，则替换目标中的相应值
+    - 如果两边都是对象，则递归合并
+    - 如果补丁中的值是数组，则完全替换目标中的数组
+`,
+		Examples: []string{"merge-patch merge.json data.json result.json"},
+		Run:      runMergePatch,
+	},
+	{
+		Name:    "merge-driver",
+		Summary: "作为git merge driver结构化地三方合并JSON文件",
+		Usage:   "[选项] BASE OURS THEIRS [PATH]",
+		Flags: []cliFlag{
+			{Name: "--indent", Value: "N", Usage: "输出的缩进空格数（默认沿用OURS的缩进）"},
+			{Name: "--units", Usage: "按大小比较 {\"value\": 5, \"unit\": \"GiB\"} 形式的数值，并作为整体合并"},
+			{Name: "--unit-table", Value: "FILE", Usage: "添加单位的JSON文件，格式为 {\"量纲\": {\"单位\": 系数}}，隐含 --units"},
+		},
+		Args: []cliArg{
+			{"BASE", "共同祖先（git的%O）"},
+			{"OURS", "当前分支的版本（git的%A），合并结果写回该文件"},
+			{"THEIRS", "另一分支的版本（git的%B）"},
+			{"PATH", "文件在仓库中的路径（git的%P，可选，用于输出信息）"},
+		},
+		Details: `
+配置:
+  git config merge.leptjson.name "structural JSON merge"
+  git config merge.leptjson.driver "leptjson merge-driver %O %A %B %P"
+  echo '*.json merge=leptjson' >> .gitattributes
+
+说明:
+  对象按键合并，双方修改不同的键时自动合并；双方对同一个值做了不同修改时，
+  该值被替换为 {"<<<<<<< ours": ..., "||||||| base": ..., ">>>>>>> theirs": ...}，
+  退出码为1，git将文件标记为冲突。任何版本无法解析时OURS保持不变。
+  使用 --units 时，一方把 1024 MiB 改写为 1 GiB 不算修改，一方修改数值、另一方
+  只换了写法时采用修改后的数值；双方都修改了大小时是冲突，不会把一方的value
+  和另一方的unit拼在一起。
+`,
+		Run: runMergeDriver,
+	},
+	{
+		Name:    "gen-codec",
+		Summary: "为Go结构体生成免反射的序列化代码",
+		Usage:   "[选项] FILE.go",
+		Flags: []cliFlag{
+			{Name: "--type", Value: "NAME,...", Usage: "只为指定的结构体生成代码（默认为所有带有 //leptjson:codec 注释的结构体）"},
+			{Name: "--output", Value: "FILE", Usage: "输出文件路径（默认为FILE_leptjson.go）"},
+		},
+		Args: []cliArg{{"FILE.go", "包含结构体定义的Go源文件"}},
+		Details: `
+说明:
+  为每个结构体生成 MarshalLeptJSON/UnmarshalLeptJSON 方法，以及调用它们的
+  ToLeptJSON/FromLeptJSON，使 Marshal/Unmarshal 处理这些类型时不再反射。
+  字符串、布尔、数字及其指针和切片，以及同一文件中生成了代码的结构体被直接读写，
+  其他类型的字段退回到反射。暂不支持嵌入字段以及 string、inline 选项。
+`,
+		Examples: []string{"gen-codec models.go"},
+		Run:      runGenCodec,
+	},
+	{
+		Name:    "serve",
+		Summary: "启动提供验证、格式化、补丁和查询的HTTP服务",
+		Usage:   "[选项]",
+		Flags: []cliFlag{
+			{Name: "--addr", Value: "ADDR", Usage: "监听地址（默认为:8080）"},
+			{Name: "--max-body", Value: "BYTES", Usage: "请求体的最大字节数（默认为解析选项的MaxTotalSize，即1MB）"},
+			{Name: "--max-read-rate", Value: "BYTES", Usage: "每个请求体每秒最多读取的字节数（默认不限速）"},
+			{Name: "--eval-timeout", Value: "DURATION", Usage: "每个验证或查询请求的最长求值时间（默认5s，0表示不限制）"},
+			{Name: "--max-eval-steps", Value: "N", Usage: "每个验证或查询请求最多求值的步数（默认1000000，0表示不限制）"},
+			{Name: "--document", Value: "FILE", Usage: "通过 /ws 实时同步的文档"},
+			{Name: "--schema", Value: "FILE", Usage: "验证客户端通过 /ws 修改后的文档"},
+			{Name: "--save", Usage: "每次修改后把文档写回 --document 指定的文件"},
+			{Name: "--allow-origin", Value: "ORIGIN", Usage: "允许连接 /ws 的网页来源，可以重复指定，*表示任何来源（默认只允许同源）"},
+		},
+		Details: `
+端点（只接受POST请求）:
+  /validate          请求体 {"schema": ..., "data": ...}，返回验证结果
+  /format            请求体为任意JSON文档，支持 ?indent=N&sort-keys=NAME
+  /patch             请求体 {"patch": [...], "document": ...}，返回修改后的文档
+  /query             请求体 {"path": "$..x", "document": ...}，返回 {"results": [...]}
+  /metrics           GET请求，以Prometheus文本格式返回请求体的解析指标
+  /ws                指定 --document 时提供，WebSocket 连接，实时同步文档
+
+/ws 协议（每条消息都是JSON文本）:
+  服务端 -> 客户端   {"type": "snapshot", "version": N, "document": ...}   连接后的第一条消息
+                     {"type": "patch", "version": N, "patch": [...]}      文档每次被修改
+                     {"type": "ack", "version": N} 或 {"type": "error", "message": "..."}
+  客户端 -> 服务端   {"type": "patch", "patch": [...], "version": N, "id": ...}
+                     version 可选，文档已经不是这个版本时拒绝；id 可选，原样放在应答中
+
+说明:
+  所有请求体和 /ws 消息都按默认的安全限制解析，超过大小限制的请求返回413。
+  修改后的文档不符合 --schema 时修改被拒绝，文档保持不变。
+  浏览器发起的跨源 /ws 握手返回403，除非来源由 --allow-origin 指定。
+`,
+		Examples: []string{"serve --addr=127.0.0.1:8080", "serve --document=dashboard.json --schema=dashboard.schema.json --save"},
+		Run:      runServe,
+	},
+	{
+		Name:    "pipeline",
+		Summary: "逐条过滤和转换NDJSON记录，失败的记录写入死信输出",
+		Usage:   "[选项] [INPUT]",
+		Flags: []cliFlag{
+			{Name: "--filter", Value: "EXPR", Usage: "只保留满足过滤表达式的记录，语法同JSONPath过滤器，如 '@.status == \"active\"'"},
+			{Name: "--transform", Value: "FILE", Usage: "转换描述文件，见下方说明"},
+			{Name: "--workers", Value: "N", Usage: "并发处理记录的goroutine数量（默认为CPU数量）"},
+			{Name: "--output", Value: "OUTPUT", Usage: "输出位置（默认为 -，即标准输出）"},
+			{Name: "--dead-letter", Value: "OUTPUT", Usage: "失败记录的输出位置，不指定时第一条失败的记录使命令失败"},
+			{Name: "--stats", Usage: "结束时在标准错误输出上打印处理的记录数量"},
+		},
+		Args: []cliArg{{"INPUT", "NDJSON输入（默认为 -，即标准输入），可以是文件、URL或对象存储"}},
+		Details: `
+转换描述（按顺序执行，各部分都可以省略）:
+  {
+    "patch":   [...],                   JSON Patch
+    "delete":  ["$..password"],         删除所有匹配JSON Path的值
+    "units":   {"bytes": "MiB"},        把 {"value": 5, "unit": "GB"} 形式的数值换算为目标单位
+    "keyCase": "snake",                 转换对象键的命名风格
+    "select":  {"id": "$.id", "name": "$.user.name"}
+  }
+  select 把记录投影为新对象：匹配一个值时取这个值，匹配多个值时取数组，没有匹配时省略。
+  units 的内置量纲为 bytes（B、MB、MiB等）、duration（ms、s、h等）和 frequency，
+  可以用 "unitTable": {"bytes": {"block": 4096}} 添加单位。
+
+死信输出每行一条失败的记录:
+  {"position": "第3行", "error": "解析失败: ...", "record": "原始文本"}
+
+说明:
+  记录并发处理，但按输入顺序输出。每条记录按默认的安全限制单独解析。
+  用 -tags kafka 编译时，INPUT 和 OUTPUT 可以是 kafka://REST代理主机:端口/主题，
+  通过 Kafka REST Proxy 读写；查询参数 group=NAME 指定消费者组，follow=1 持续读取新记录，
+  否则连续 idle=DURATION（默认5s）没有新记录时结束。
+`,
+		Examples: []string{
+			"pipeline --filter='@.level == \"error\"' --output=errors.ndjson app.ndjson",
+			"pipeline --transform=spec.json --dead-letter=failed.ndjson < events.ndjson > out.ndjson",
+		},
+		Run: runPipeline,
+	},
+	{
+		Name:    "to-sql",
+		Summary: "把对象数组展平为关系表，输出SQL语句或写入SQLite数据库",
+		Usage:   "[选项] FILE",
+		Flags: []cliFlag{
+			{Name: "--table", Value: "NAME", Usage: "表名（默认为FILE的文件名去掉扩展名）"},
+			{Name: "--path", Value: "EXPR", Usage: "用JSONPath选取记录，如 $.data.items（默认为整个文档）"},
+			{Name: "--separator", Value: "SEP", Usage: "嵌套对象的键连接成列名时使用的分隔符（默认为_）"},
+			{Name: "--sqlite", Value: "DB", Usage: "直接写入新的SQLite数据库文件，不输出SQL语句"},
+			{Name: "--output", Value: "FILE", Usage: "保存SQL语句到指定文件（默认输出到标准输出）"},
+		},
+		Args: []cliArg{{"FILE", "包含对象数组的JSON文件路径"}},
+		Details: `
+说明:
+  嵌套的对象展开为多列（如 user.name 成为 user_name），数组作为JSON文本保存。
+  列类型根据所有非null值推断：全是整数或布尔值时为INTEGER，全是数字时为REAL，
+  其余为TEXT。某条记录没有的列为NULL。
+  --sqlite 不需要安装SQLite，生成的数据库只包含这一张表；文件已经存在时报错。
+`,
+		Examples: []string{
+			"to-sql --table=items items.json > items.sql",
+			"to-sql --path='$.data[*]' --sqlite=report.db report.json",
+		},
+		Run: runToSQL,
+	},
+	{
+		Name:    "export",
+		Summary: "把对象数组导出为Apache Arrow或Parquet列式文件",
+		Usage:   "[选项] --output=OUT FILE",
+		Flags: []cliFlag{
+			{Name: "--format", Value: "FORMAT", Usage: "arrow（IPC文件）、arrow-stream（IPC流）或parquet（默认根据OUT的扩展名选择）"},
+			{Name: "--output", Value: "OUT", Usage: "输出文件路径，- 表示标准输出"},
+			{Name: "--path", Value: "EXPR", Usage: "用JSONPath选取记录，如 $.data.items（默认为整个文档）"},
+			{Name: "--sample", Value: "N", Usage: "用前N条记录推断schema（默认1000）"},
+			{Name: "--batch-size", Value: "N", Usage: "每个记录批次或行组的最大行数（默认65536）"},
+			{Name: "--separator", Value: "SEP", Usage: "嵌套对象的键连接成列名时使用的分隔符（默认为_）"},
+			{Name: "--schema", Usage: "只输出推断出的schema，不导出数据"},
+		},
+		Args: []cliArg{{"FILE", "包含对象数组的JSON文件路径"}},
+		Details: `
+说明:
+  嵌套的对象展开为多列，数组作为JSON文本保存。schema 根据采样的记录推断：
+  只有整数的列为int64，只有数字的列为float64，只有布尔值的列为bool，其余为utf8，
+  所有列都可以为null。采样之外的记录出现新的列或不符合列类型时报错，
+  可以增大 --sample。扩展名 .arrow/.feather、.arrows 和 .parquet 会自动选择格式。
+  Parquet 文件不压缩，每个批次是一个行组。
+`,
+		Examples: []string{
+			"export --output=items.parquet items.json",
+			"export --format=arrow-stream --path='$.data[*]' --output=- report.json | consumer",
+			"export --schema --sample=10000 events.json",
+		},
+		Run: runExport,
+	},
+	{
+		Name:    "validate-proto",
+		Summary: "按protobuf消息的proto3 JSON映射验证JSON文件",
+		Usage:   "[选项] --descriptor=DESC --message=NAME FILE",
+		Flags: []cliFlag{
+			{Name: "--descriptor", Value: "DESC", Usage: "protoc --descriptor_set_out 生成的描述符集文件"},
+			{Name: "--message", Value: "NAME", Usage: "消息类型的完整名称，如 pkg.v1.User；省略时列出所有消息类型"},
+			{Name: "--ignore-unknown", Usage: "允许描述符中没有定义的字段"},
+			{Name: "--format", Value: "FORMAT", Usage: "设置输出格式，可选值: text, json（默认为text）"},
+		},
+		Args: []cliArg{{"FILE", "要验证的JSON文件路径"}},
+		Details: `
+说明:
+  检查字段名（JSON名称或.proto中的字段名）、字段类型、枚举值、oneof，
+  以及Timestamp、Duration、Any、包装类型等知名类型的JSON形式。
+  null表示字段的默认值。64位整数超过2^53时应该使用字符串。
+  描述符集需要包含引用的所有类型，生成时使用:
+    protoc --include_imports --descriptor_set_out=api.pb api.proto
+  验证失败时退出码为2。
+`,
+		Examples: []string{
+			"validate-proto --descriptor=api.pb --message=shop.v1.Order order.json",
+			"validate-proto --descriptor=api.pb",
+		},
+		Run: runValidateProto,
+	},
+	{
+		Name:    "avro",
+		Summary: "把Avro schema转换为JSON Schema，验证或生成Avro的JSON编码",
+		Usage:   "[选项] SCHEMA [FILE]",
+		Flags: []cliFlag{
+			{Name: "--validate", Usage: "验证FILE是否是Avro的JSON编码，union的值需要包装"},
+			{Name: "--normalize", Usage: "把普通JSON文件FILE转换为Avro的JSON编码"},
+			{Name: "--format", Value: "FORMAT", Usage: "--validate 的输出格式，可选值: text, json（默认为text）"},
+			{Name: "--output", Value: "FILE", Usage: "输出文件路径（默认输出到标准输出）"},
+		},
+		Args: []cliArg{
+			{"SCHEMA", "Avro schema文件（.avsc）路径"},
+			{"FILE", "使用 --validate 或 --normalize 时要处理的JSON文件路径"},
+		},
+		Details: `
+说明:
+  不带选项时输出与schema等价的Draft-07 JSON Schema，描述union不包装的普通JSON，
+  命名类型放在definitions中。--normalize 按schema把普通JSON中union的值包装为
+  {"类型名": 值}，字段按schema的顺序排列并补上默认值，结果可以直接交给Avro的
+  JSON解码器。--validate 验证失败时退出码为2。
+`,
+		Examples: []string{
+			"avro user.avsc > user.schema.json",
+			"avro --validate user.avsc message.json",
+			"avro --normalize --output=message.avro.json user.avsc user.json",
+		},
+		Run: runAvro,
+	},
+	{
+		Name:    "graph",
+		Summary: "将JSON结构输出为Graphviz DOT图",
+		Usage:   "[选项] FILE",
+		Flags: []cliFlag{
+			{Name: "--output", Value: "FILE", Short: "-o", Usage: "输出文件路径（默认输出到标准输出）"},
+			{Name: "--max-depth", Value: "N", Usage: "最多展开N层（默认不限制）"},
+			{Name: "--max-children", Value: "N", Usage: "每个对象或数组最多显示N个成员（默认50，0表示不限制）"},
+			{Name: "--no-scalars", Usage: "只显示对象和数组，不显示标量"},
+		},
+		Args: []cliArg{{"FILE", "要可视化的JSON文件路径"}},
+		Details: `
+说明:
+  对象和数组是节点，边的标签是键或数组下标。可用 dot -Tsvg out.dot -o out.svg 渲染。
+`,
+		Examples: []string{"graph data.json -o data.dot"},
+		Run:      runGraph,
+	},
+}
@@ -0,0 +1,94 @@
+package leptjson
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCLICommandTable(t *testing.T) {
+	names := make(map[string]bool)
+	for _, c := range cliCommands {
+		if names[c.Name] {
+			t.Errorf("命令 %s 重复", c.Name)
+		}
+		names[c.Name] = true
+		if c.Summary == "" || c.Usage == "" || c.Run == nil {
+			t.Errorf("命令 %s 缺少说明、用法或实现", c.Name)
+		}
+
+		flags := make(map[string]bool)
+		var help bytes.Buffer
+		c.writeHelp(&help)
+		for _, flag := range c.Flags {
+			if flags[flag.Name] {
+				t.Errorf("命令 %s 的选项 %s 重复", c.Name, flag.Name)
+			}
+			flags[flag.Name] = true
+			if !strings.HasPrefix(flag.Name, "--") {
+				t.Errorf("命令 %s 的选项 %s 应以 -- 开头", c.Name, flag.Name)
+			}
+			if !strings.Contains(help.String(), flag.display()) {
+				t.Errorf("命令 %s 的帮助信息中没有选项 %s", c.Name, flag.display())
+			}
+			// 命令概要只显示说明的第一行，第一行必须是完整的一句
+			first := strings.SplitN(flag.Usage, "\n", 2)[0]
+			if strings.HasSuffix(first, "，") || strings.HasSuffix(first, "、") || strings.HasSuffix(first, ":") ||
+				strings.Count(first, "（") != strings.Count(first, "）") {
+				t.Errorf("命令 %s 的选项 %s 的说明第一行不完整: %s", c.Name, flag.Name, first)
+			}
+		}
+	}
+
+	var usage bytes.Buffer
+	writeUsage(&usage)
+	for _, c := range cliCommands {
+		if !strings.Contains(usage.String(), "\n  "+c.Name+" "+c.Usage+"\n") {
+			t.Errorf("用法信息中没有命令 %s", c.Name)
+		}
+	}
+}
+
+func TestCLICheckFlags(t *testing.T) {
+	format := lookupCommand("format")
+	graph := lookupCommand("graph")
+	if format == nil || graph == nil || lookupCommand("no-such-command") != nil {
+		t.Fatal("lookupCommand 结果错误")
+	}
+
+	tests := []struct {
+		command *cliCommand
+		args    []string
+		ok      bool
+	}{
+		{format, []string{"--indent=4", "--quiet", "in.json", "out.json"}, true},
+		{format, []string{"--sort-keys", "in.json"}, true},
+		{format, []string{"--sort-keys=alpha", "in.json"}, true},
+		{format, []string{"in.json", "-"}, true},
+		{format, []string{"--indnet=4", "in.json"}, false},
+		{format, []string{"--indent", "in.json"}, false},
+		{format, []string{"--quiet=yes", "in.json"}, false},
+		{graph, []string{"-o", "--out.dot", "in.json"}, true},
+		{graph, []string{"in.json", "-o"}, false},
+		{graph, []string{"-x", "in.json"}, true},
+	}
+	for _, tt := range tests {
+		err := tt.command.checkFlags(tt.args)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s checkFlags(%q) = %v, 期望成功: %v", tt.command.Name, tt.args, err, tt.ok)
+		}
+	}
+}
+
+func TestWriteHelpItems(t *testing.T) {
+	var out bytes.Buffer
+	names := []string{"--a=N", "--a-very-long-option=VALUE"}
+	writeHelpItems(&out, "  ", helpColumn(names), names, []string{"第一行\n第二行", "说明"})
+	want := "  --a=N         第一行\n" +
+		"                第二行\n" +
+		"  --a-very-long-option=VALUE\n" +
+		"                说明\n"
+	if out.String() != want {
+		t.Errorf("writeHelpItems = %q, 期望 %q", out.String(), want)
+	}
+}
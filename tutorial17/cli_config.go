@@ -0,0 +1,43 @@
+// cli_config.go - 显示当前目录生效的项目配置
+package leptjson
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// effectiveConfig 是config命令输出的内容
+type effectiveConfig struct {
+	ProjectConfig
+	File   string `json:"file,omitempty"`   // 查询的文件
+	Schema string `json:"schema,omitempty"` // 适用于该文件的Schema
+}
+
+// runConfig 实现config命令
+func runConfig(args []string, verbose bool) {
+	if len(args) > 1 {
+		fmt.Println("错误: config命令最多需要一个文件参数")
+		fmt.Println("\n用法: leptjson config [FILE]")
+		return
+	}
+
+	output := effectiveConfig{ProjectConfig: cliConfig}
+	if len(args) == 1 {
+		output.File = args[0]
+		output.Schema, _ = cliConfig.SchemaFor(args[0])
+	}
+	if verbose {
+		if cliConfig.Path == "" {
+			fmt.Printf("没有找到%s或%s，使用默认配置\n", ProjectConfigName, ProjectConfigAltName)
+		} else {
+			fmt.Printf("使用配置文件: %s\n", cliConfig.Path)
+		}
+	}
+
+	configJSON, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		fmt.Printf("生成JSON失败: %s\n", err)
+		exitCLI(1)
+	}
+	fmt.Println(string(configJSON))
+}
@@ -0,0 +1,190 @@
+// cli_fetch.go - 命令行的输入文件可以是 http(s) URL
+package leptjson
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// FetchOptions 控制 FetchURL 如何下载文档
+type FetchOptions struct {
+	Headers  http.Header   // 附加的请求头，如 Authorization
+	Timeout  time.Duration // 整个请求（包括读取响应体）的最长时间，为0时不限制
+	MaxBytes int64         // 响应体的最大字节数，为0时不限制
+	Client   *http.Client  // 为 nil 时使用 http.DefaultClient 的传输
+}
+
+// cliFetchOptions 是命令行读取 URL 输入时的选项，由全局选项 --header、--http-timeout 和 --max-fetch-size 设置
+var cliFetchOptions = FetchOptions{Timeout: 30 * time.Second}
+
+// isURLInput 判断命令行参数是否是 http 或 https URL
+func isURLInput(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// FetchURL 用 GET 请求下载 rawURL 的内容
+//
+// 响应体通过 MaxBytesReader 读取，超过 MaxBytes 时返回 *MaxBytesError，
+// 响应头中的 Content-Length 已经超过限制时不读取响应体。状态码不是 2xx 时返回错误。
+func FetchURL(ctx context.Context, rawURL string, options FetchOptions) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("无效的URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("不支持的URL协议: %s", u.Scheme)
+	}
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "leptjson/"+Version)
+	for name, values := range options.Headers {
+		req.Header.Del(name)
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+
+	client := options.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("请求超时（%s）: %s", options.Timeout, rawURL)
+		}
+		return nil, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("请求失败: %s 返回 %s", rawURL, resp.Status)
+	}
+
+	var body io.Reader = resp.Body
+	if options.MaxBytes > 0 {
+		if resp.ContentLength > options.MaxBytes {
+			return nil, &MaxBytesError{Limit: options.MaxBytes}
+		}
+		body = MaxBytesReader(body, options.MaxBytes)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("请求超时（%s）: %s", options.Timeout, rawURL)
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// parseHeaderFlag 解析 "Name: value" 形式的请求头
+func parseHeaderFlag(header string) (string, string, error) {
+	i := strings.IndexByte(header, ':')
+	if i <= 0 {
+		return "", "", fmt.Errorf("无效的请求头 %q，应为 \"名称: 值\"", header)
+	}
+	name := strings.TrimSpace(header[:i])
+	if name == "" || strings.ContainsAny(name, " \t") {
+		return "", "", fmt.Errorf("无效的请求头名称 %q", name)
+	}
+	return name, strings.TrimSpace(header[i+1:]), nil
+}
+
+// headerFlags 收集可以重复出现的 --header 选项
+type headerFlags struct {
+	header http.Header
+}
+
+func (h *headerFlags) String() string {
+	return ""
+}
+
+func (h *headerFlags) Set(value string) error {
+	name, v, err := parseHeaderFlag(value)
+	if err != nil {
+		return err
+	}
+	if h.header == nil {
+		h.header = make(http.Header)
+	}
+	h.header.Add(name, v)
+	return nil
+}
+
+// fetchCLIInput 下载命令行中的 URL 输入，返回内容和解析时使用的选项
+//
+// 没有设置 --max-fetch-size 时大小限制与解析的总大小限制相同；设置了更大的限制时，
+// 解析的总大小限制随之放宽，否则下载下来也无法解析。
+func fetchCLIInput(rawURL string) ([]byte, ParseOptions, error) {
+	options, parseOptions := cliFetchLimits()
+	data, err := FetchURL(context.Background(), rawURL, options)
+	var tooLarge *MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return nil, parseOptions, fmt.Errorf("下载 %s 失败: 响应超过大小限制: 最多%d字节（可用 --max-fetch-size 调整）", rawURL, tooLarge.Limit)
+	}
+	if err != nil {
+		return nil, parseOptions, fmt.Errorf("下载 %s 失败: %w", rawURL, err)
+	}
+	return data, parseOptions, nil
+}
+
+// cliFetchLimits 返回命令行下载输入时的选项和解析选项，大小限制的规则见 fetchCLIInput
+func cliFetchLimits() (FetchOptions, ParseOptions) {
+	parseOptions := cliConfig.ParseOptions()
+	options := cliFetchOptions
+	if options.MaxBytes == 0 && parseOptions.EnabledSecurity {
+		options.MaxBytes = int64(parseOptions.MaxTotalSize)
+	}
+	if options.MaxBytes > int64(parseOptions.MaxTotalSize) {
+		parseOptions.MaxTotalSize = int(options.MaxBytes)
+	}
+	return options, parseOptions
+}
+
+// readCLIStorage 通过 OpenStorage 读取命令行中的 s3://、gs:// 等输入，
+// 超时和大小限制与 URL 输入相同
+func readCLIStorage(uri string) ([]byte, ParseOptions, error) {
+	options, parseOptions := cliFetchLimits()
+	ctx := context.Background()
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+	r, err := OpenStorage(ctx, uri)
+	if err != nil {
+		return nil, parseOptions, fmt.Errorf("读取 %s 失败: %w", uri, err)
+	}
+	defer r.Close()
+	var body io.Reader = r
+	if options.MaxBytes > 0 {
+		body = MaxBytesReader(body, options.MaxBytes)
+	}
+	data, err := io.ReadAll(body)
+	var tooLarge *MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return nil, parseOptions, fmt.Errorf("读取 %s 失败: 对象超过大小限制: 最多%d字节（可用 --max-fetch-size 调整）", uri, tooLarge.Limit)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, parseOptions, fmt.Errorf("读取 %s 超时（%s）", uri, options.Timeout)
+	}
+	if err != nil {
+		return nil, parseOptions, fmt.Errorf("读取 %s 失败: %w", uri, err)
+	}
+	return data, parseOptions, nil
+}
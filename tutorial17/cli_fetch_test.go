@@ -0,0 +1,98 @@
+package leptjson
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFetchURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/data":
+			if r.Header.Get("Authorization") != "Bearer t" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			io.WriteString(w, `{"items": [{"id": 1}, {"id": 2}]}`)
+		case "/large":
+			// 不设置 Content-Length，只能在读取时发现超过限制
+			w.(http.Flusher).Flush()
+			io.WriteString(w, `"`+strings.Repeat("x", 100)+`"`)
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+			io.WriteString(w, `{}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	headers := http.Header{"Authorization": {"Bearer t"}}
+	data, err := FetchURL(context.Background(), server.URL+"/data", FetchOptions{Headers: headers, MaxBytes: 1024})
+	if err != nil || !strings.Contains(string(data), `"items"`) {
+		t.Errorf("FetchURL = %s, %v", data, err)
+	}
+	if _, err := FetchURL(context.Background(), server.URL+"/data", FetchOptions{}); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("没有请求头时应该返回401错误: %v", err)
+	}
+	if _, err := FetchURL(context.Background(), server.URL+"/missing", FetchOptions{}); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("不存在的路径应该返回404错误: %v", err)
+	}
+
+	var tooLarge *MaxBytesError
+	if _, err := FetchURL(context.Background(), server.URL+"/large", FetchOptions{MaxBytes: 50}); !errors.As(err, &tooLarge) || tooLarge.Limit != 50 {
+		t.Errorf("超过大小限制应该返回 *MaxBytesError: %v", err)
+	}
+	if _, err := FetchURL(context.Background(), server.URL+"/data", FetchOptions{Headers: headers, MaxBytes: 10}); !errors.As(err, &tooLarge) {
+		t.Errorf("Content-Length 超过大小限制应该返回 *MaxBytesError: %v", err)
+	}
+	if _, err := FetchURL(context.Background(), server.URL+"/slow", FetchOptions{Timeout: 50 * time.Millisecond}); err == nil || !strings.Contains(err.Error(), "超时") {
+		t.Errorf("超时应该返回错误: %v", err)
+	}
+	if _, err := FetchURL(context.Background(), "ftp://example.com/x", FetchOptions{}); err == nil {
+		t.Errorf("不支持的协议应该返回错误")
+	}
+}
+
+func TestLoadJSONFromURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"user": "`+r.Header.Get("X-User")+`"}`)
+	}))
+	defer server.Close()
+
+	saved := cliFetchOptions
+	defer func() { cliFetchOptions = saved }()
+	headers := &headerFlags{}
+	if err := headers.Set("X-User: alice"); err != nil {
+		t.Fatal(err)
+	}
+	cliFetchOptions = FetchOptions{Headers: headers.header, Timeout: time.Second}
+
+	v, err := loadJSON(server.URL+"/config.json", false)
+	if err != nil {
+		t.Fatalf("loadJSON 失败: %v", err)
+	}
+	if s, _ := Stringify(v); s != `{"user":"alice"}` {
+		t.Errorf("loadJSON = %s", s)
+	}
+
+	cliFetchOptions.MaxBytes = 5
+	if _, err := loadJSON(server.URL, false); err == nil || !strings.Contains(err.Error(), "--max-fetch-size") {
+		t.Errorf("超过大小限制的错误应该提示 --max-fetch-size: %v", err)
+	}
+
+	for _, header := range []string{"no-colon", ": x", "a b: c"} {
+		if err := headers.Set(header); err == nil {
+			t.Errorf("无效的请求头 %q 应该返回错误", header)
+		}
+	}
+	if !isURLInput("HTTPS://x") || isURLInput("data.json") || isURLInput("http.json") {
+		t.Errorf("isURLInput 判断错误")
+	}
+}
@@ -0,0 +1,146 @@
+// cli_merge_driver.go - 作为 git merge driver 对JSON文件做结构化三方合并
+package leptjson
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// detectIndent 返回JSON文本中第一个缩进行使用的缩进，没有缩进时返回两个空格
+func detectIndent(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed != "" && len(trimmed) < len(line) {
+			return line[:len(line)-len(trimmed)]
+		}
+	}
+	return "  "
+}
+
+// loadMergeVersion 读取合并的一个版本，空文件（双方各自新增文件时的共同祖先）返回 nil
+func loadMergeVersion(filename string) (*Value, string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, "", fmt.Errorf("无法打开文件: %w", err)
+	}
+	text := string(data)
+	if strings.TrimSpace(text) == "" {
+		return nil, text, nil
+	}
+	v := &Value{}
+	if err := Parse(v, text); err != PARSE_OK {
+		return nil, text, fmt.Errorf("解析JSON失败: %s", err)
+	}
+	return v, text, nil
+}
+
+// runMergeDriver 实现merge-driver命令
+//
+// git 调用时传入共同祖先 %O、当前分支 %A 和另一分支 %B 的临时文件，合并结果写回 %A。
+// 退出码为 0 表示合并成功，非 0 表示有冲突，git 会把文件标记为冲突等待手工解决。
+func runMergeDriver(args []string, verbose bool) {
+	indent := ""
+	var units *UnitTable // 不为 nil 时按大小比较带单位的数值
+	fileArgs := args
+	for i := 0; i < len(fileArgs); i++ {
+		arg := fileArgs[i]
+		if arg == "--units" {
+			if units == nil {
+				units = DefaultUnitTable()
+			}
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+		if strings.HasPrefix(arg, "--unit-table=") {
+			spec, err := loadJSON(strings.TrimPrefix(arg, "--unit-table="), false)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "错误: 加载单位表失败: %s\n", err)
+				exitCLI(1)
+			}
+			if units == nil {
+				units = DefaultUnitTable()
+			}
+			if err := units.Load(spec); err != nil {
+				fmt.Fprintf(os.Stderr, "错误: %s\n", err)
+				exitCLI(1)
+			}
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+			continue
+		}
+		if strings.HasPrefix(arg, "--indent=") {
+			n, err := strconv.Atoi(strings.TrimPrefix(arg, "--indent="))
+			if err != nil || n < 0 {
+				fmt.Fprintf(os.Stderr, "错误: 无效的缩进值: %s\n", strings.TrimPrefix(arg, "--indent="))
+				exitCLI(1)
+			}
+			indent = strings.Repeat(" ", n)
+			fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+			i--
+		}
+	}
+
+	// 第四个参数是可选的 %P，只用于输出信息
+	if len(fileArgs) != 3 && len(fileArgs) != 4 {
+		fmt.Fprintln(os.Stderr, "错误: merge-driver命令需要3个文件参数")
+		fmt.Fprintln(os.Stderr, "\n用法: leptjson merge-driver [--indent=N] [--units] [--unit-table=FILE] BASE OURS THEIRS [PATH]")
+		exitCLI(1)
+	}
+	baseFile, oursFile, theirsFile := fileArgs[0], fileArgs[1], fileArgs[2]
+	name := oursFile
+	if len(fileArgs) == 4 {
+		name = fileArgs[3]
+	}
+
+	// 任何一个版本无法解析时保留 %A 不变并报告冲突，由用户手工合并
+	base, _, err := loadMergeVersion(baseFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: 加载共同祖先失败: %s\n", name, err)
+		exitCLI(1)
+	}
+	ours, oursText, err := loadMergeVersion(oursFile)
+	if err != nil || ours == nil {
+		fmt.Fprintf(os.Stderr, "%s: 加载当前版本失败: %v\n", name, err)
+		exitCLI(1)
+	}
+	theirs, _, err := loadMergeVersion(theirsFile)
+	if err != nil || theirs == nil {
+		fmt.Fprintf(os.Stderr, "%s: 加载另一分支的版本失败: %v\n", name, err)
+		exitCLI(1)
+	}
+
+	merged, conflicts := Merge3WithOptions(base, ours, theirs, Merge3Options{Units: units})
+	if indent == "" {
+		indent = detectIndent(oursText)
+	}
+	text, err := formatJSON(merged, indent)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: 格式化结果失败: %s\n", name, err)
+		exitCLI(1)
+	}
+	if strings.HasSuffix(oursText, "\n") {
+		text += "\n"
+	}
+	if err := os.WriteFile(oursFile, []byte(text), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: 写入合并结果失败: %s\n", name, err)
+		exitCLI(1)
+	}
+
+	if len(conflicts) > 0 {
+		fmt.Fprintf(os.Stderr, "%s: %d 处冲突，已用冲突标记对象标出:\n", name, len(conflicts))
+		for _, c := range conflicts {
+			path := c.Path
+			if path == "" {
+				path = "/"
+			}
+			fmt.Fprintf(os.Stderr, "  %s\n", path)
+		}
+		exitCLI(1)
+	}
+	if verbose {
+		fmt.Fprintf(os.Stderr, "%s: 自动合并成功\n", name)
+	}
+}
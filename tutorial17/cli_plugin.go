@@ -0,0 +1,37 @@
+// cli_plugin.go - 命令行运行插件子命令
+package leptjson
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// runPlugin 运行插件，插件继承标准输入输出，退出码作为 leptjson 的退出码
+func runPlugin(plugin Plugin, args []string, verbose bool) {
+	cmd := exec.Command(plugin.Path, args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	env := os.Environ()
+	if verbose {
+		env = append(env, PluginEnvVerbose+"=1")
+	}
+	env = append(env, PluginEnvConfig+"="+cliConfig.Path)
+	if self, err := os.Executable(); err == nil {
+		env = append(env, PluginEnvExecutable+"="+self)
+	}
+	cmd.Env = env
+
+	if verbose {
+		fmt.Fprintf(os.Stderr, "运行插件: %s\n", plugin.Path)
+	}
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+			exitCLI(exitErr.ExitCode())
+		}
+		fmt.Printf("错误: 运行插件 %s 失败: %s\n", plugin.Name, err)
+		exitCLI(1)
+	}
+}
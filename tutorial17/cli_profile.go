@@ -0,0 +1,96 @@
+// cli_profile.go - 命令行工具的性能分析支持
+package leptjson
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+)
+
+// ProfileOptions 指定性能分析数据的输出文件，为空表示不采集
+type ProfileOptions struct {
+	CPUProfile string // CPU 分析数据，可用 go tool pprof 查看
+	MemProfile string // 命令结束时的堆内存分析数据
+	Trace      string // 执行追踪数据，可用 go tool trace 查看
+}
+
+// cliCleanups 是命令结束前需要执行的清理函数，exitCLI 提前退出时也会执行
+var cliCleanups []func()
+
+// exitCLI 执行清理函数后退出，保证提前退出时性能分析数据也被完整写入
+func exitCLI(code int) {
+	runCLICleanups()
+	os.Exit(code)
+}
+
+// runCLICleanups 按注册的相反顺序执行清理函数
+func runCLICleanups() {
+	cleanups := cliCleanups
+	cliCleanups = nil
+	for i := len(cleanups) - 1; i >= 0; i-- {
+		cleanups[i]()
+	}
+}
+
+// StartProfiling 按选项开始采集性能分析数据，返回的函数停止采集并写入文件
+func StartProfiling(opts ProfileOptions) (stop func(), err error) {
+	var stops []func()
+	stopAll := func() {
+		for i := len(stops) - 1; i >= 0; i-- {
+			stops[i]()
+		}
+		stops = nil
+	}
+
+	if opts.CPUProfile != "" {
+		f, err := os.Create(opts.CPUProfile)
+		if err != nil {
+			return nil, fmt.Errorf("无法创建CPU分析文件: %v", err)
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("无法开始CPU分析: %v", err)
+		}
+		stops = append(stops, func() {
+			pprof.StopCPUProfile()
+			f.Close()
+		})
+	}
+
+	if opts.Trace != "" {
+		f, err := os.Create(opts.Trace)
+		if err != nil {
+			stopAll()
+			return nil, fmt.Errorf("无法创建追踪文件: %v", err)
+		}
+		if err := trace.Start(f); err != nil {
+			f.Close()
+			stopAll()
+			return nil, fmt.Errorf("无法开始执行追踪: %v", err)
+		}
+		stops = append(stops, func() {
+			trace.Stop()
+			f.Close()
+		})
+	}
+
+	if opts.MemProfile != "" {
+		// 内存分析在结束时写入，提前创建文件以便尽早发现路径错误
+		f, err := os.Create(opts.MemProfile)
+		if err != nil {
+			stopAll()
+			return nil, fmt.Errorf("无法创建内存分析文件: %v", err)
+		}
+		stops = append(stops, func() {
+			runtime.GC()
+			if err := pprof.WriteHeapProfile(f); err != nil {
+				fmt.Fprintf(os.Stderr, "写入内存分析数据失败: %v\n", err)
+			}
+			f.Close()
+		})
+	}
+
+	return stopAll, nil
+}
@@ -0,0 +1,56 @@
+package leptjson
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStartProfiling(t *testing.T) {
+	dir := t.TempDir()
+	opts := ProfileOptions{
+		CPUProfile: filepath.Join(dir, "cpu.out"),
+		MemProfile: filepath.Join(dir, "mem.out"),
+		Trace:      filepath.Join(dir, "trace.out"),
+	}
+	stop, err := StartProfiling(opts)
+	if err != nil {
+		t.Fatalf("StartProfiling 失败: %v", err)
+	}
+	var v Value
+	for i := 0; i < 100; i++ {
+		Parse(&v, `{"a":[1,2,3,{"b":"c"}]}`)
+	}
+	stop()
+
+	for _, file := range []string{opts.CPUProfile, opts.MemProfile, opts.Trace} {
+		info, err := os.Stat(file)
+		if err != nil {
+			t.Fatalf("分析文件未创建: %v", err)
+		}
+		if info.Size() == 0 {
+			t.Errorf("分析文件 %s 为空", filepath.Base(file))
+		}
+	}
+
+	// 无法创建文件时返回错误，且已开始的采集被停止
+	opts.Trace = filepath.Join(dir, "missing", "trace.out")
+	if _, err := StartProfiling(opts); err == nil {
+		t.Error("无法创建追踪文件时应当返回错误")
+	}
+	if stop, err := StartProfiling(ProfileOptions{CPUProfile: filepath.Join(dir, "cpu2.out")}); err != nil {
+		t.Errorf("之前失败的调用应当已停止CPU分析: %v", err)
+	} else {
+		stop()
+	}
+}
+
+func TestRunCLICleanups(t *testing.T) {
+	var order []int
+	cliCleanups = append(cliCleanups, func() { order = append(order, 1) }, func() { order = append(order, 2) })
+	runCLICleanups()
+	runCLICleanups()
+	if len(order) != 2 || order[0] != 2 || order[1] != 1 {
+		t.Errorf("清理函数应当按相反顺序执行且只执行一次: %v", order)
+	}
+}
@@ -2,6 +2,8 @@ package leptjson
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -46,7 +48,7 @@ func TestCalculateStats(t *testing.T) {
 	SetString(nestedField, "nested value")
 
 	// 计算统计信息
-	stats := calculateStats(v)
+	stats := CalculateStats(v)
 
 	// 验证统计信息
 	if stats.ObjectCount != 2 {
@@ -85,6 +87,18 @@ func TestCalculateStats(t *testing.T) {
 		t.Errorf("最长键错误: 期望 'very_long_field_name' (20字符), 实际 '%s' (%d字符)",
 			stats.LongestKey, stats.MaxKeyLength)
 	}
+
+	if stats.UniqueKeyCount != 7 || stats.InternedKeyBytes != 0 {
+		t.Errorf("键驻留统计错误: 不同键 %d, 节省 %d字节", stats.UniqueKeyCount, stats.InternedKeyBytes)
+	}
+
+	// 重复的键计入节省的字节数
+	repeated := &Value{}
+	Parse(repeated, `[{"id":1,"name":"a"},{"id":2,"name":"b"},{"id":3}]`)
+	stats = CalculateStats(repeated)
+	if stats.UniqueKeyCount != 2 || stats.InternedKeyBytes != 2*len("id")+len("name") {
+		t.Errorf("键驻留统计错误: 不同键 %d, 节省 %d字节", stats.UniqueKeyCount, stats.InternedKeyBytes)
+	}
 }
 
 func TestFormatJSON(t *testing.T) {
@@ -113,6 +127,39 @@ func TestFormatJSON(t *testing.T) {
 	}
 }
 
+func TestFormatJSONWithWidth(t *testing.T) {
+	v := mustParse(t, `{"name":"leptjson","tags":["json","parser"],"point":{"x":1,"y":2},"rows":[[1,2,3],[4,5,6]],"empty":[]}`)
+	tests := []struct {
+		width int
+		want  string
+	}{
+		// 整个文档能放下时写在一行
+		{120, `{"name": "leptjson", "tags": ["json", "parser"], "point": {"x": 1, "y": 2}, "rows": [[1, 2, 3], [4, 5, 6]], "empty": []}`},
+		// 放不下时展开外层，内层按各自所在的列判断；"rows" 一行正好33列（包括逗号）
+		{33, "{\n  \"name\": \"leptjson\",\n  \"tags\": [\"json\", \"parser\"],\n  \"point\": {\"x\": 1, \"y\": 2},\n  \"rows\": [[1, 2, 3], [4, 5, 6]],\n  \"empty\": []\n}"},
+		{29, "{\n  \"name\": \"leptjson\",\n  \"tags\": [\"json\", \"parser\"],\n  \"point\": {\"x\": 1, \"y\": 2},\n  \"rows\": [\n    [1, 2, 3],\n    [4, 5, 6]\n  ],\n  \"empty\": []\n}"},
+		{28, "{\n  \"name\": \"leptjson\",\n  \"tags\": [\n    \"json\",\n    \"parser\"\n  ],\n  \"point\": {\"x\": 1, \"y\": 2},\n  \"rows\": [\n    [1, 2, 3],\n    [4, 5, 6]\n  ],\n  \"empty\": []\n}"},
+	}
+	for _, tt := range tests {
+		got, err := formatJSONWithWidth(v, "  ", nil, tt.width)
+		if err != nil || got != tt.want {
+			t.Errorf("formatJSONWithWidth(%d) =\n%s\n期望\n%s", tt.width, got, tt.want)
+		}
+	}
+
+	// 宽度为0时与 formatJSON 相同
+	got, _ := formatJSONWithWidth(v, "  ", nil, 0)
+	if want, _ := formatJSON(v, "  "); got != want {
+		t.Errorf("formatJSONWithWidth(0) =\n%s\n期望\n%s", got, want)
+	}
+
+	// 宽度按字符数而不是字节数计算
+	cjk := mustParse(t, `["测试","数据"]`)
+	if got, _ := formatJSONWithWidth(cjk, "  ", nil, 14); got != `["测试", "数据"]` {
+		t.Errorf("formatJSONWithWidth(cjk) = %s", got)
+	}
+}
+
 func TestCompareJSON(t *testing.T) {
 	// 创建两个相似但有差异的JSON值
 	v1 := &Value{}
@@ -800,3 +847,242 @@ func TestFindByPath(t *testing.T) {
 		})
 	}
 }
+
+// 测试CLI补丁应用的原子性和--test模式
+func TestApplyPatchAtomicity(t *testing.T) {
+	const original = `{"name":"a","list":[1,2]}`
+
+	parseOps := func(patchJSON string) []CliPatchOperation {
+		patchDoc := &Value{}
+		if err := Parse(patchDoc, patchJSON); err != PARSE_OK {
+			t.Fatalf("解析补丁失败: %v", err)
+		}
+		operations, err := parsePatch(patchDoc)
+		if err != nil {
+			t.Fatalf("解析JSON Patch失败: %v", err)
+		}
+		return operations
+	}
+
+	// 中途失败的补丁不应修改文档
+	doc := &Value{}
+	Parse(doc, original)
+	ops := parseOps(`[{"op":"replace","path":"/name","value":"b"},{"op":"remove","path":"/missing"}]`)
+	if err := applyPatch(doc, ops, false); err == nil {
+		t.Error("期望补丁应用失败")
+	}
+	if got, _ := Stringify(doc); got != original {
+		t.Errorf("失败的补丁修改了文档: %s", got)
+	}
+
+	// --test 模式下后续操作能看到前面操作的结果，但不修改文档
+	ops = parseOps(`[{"op":"add","path":"/extra","value":1},{"op":"test","path":"/extra","value":1}]`)
+	if err := applyPatch(doc, ops, true); err != nil {
+		t.Errorf("测试模式应用补丁失败: %v", err)
+	}
+	if got, _ := Stringify(doc); got != original {
+		t.Errorf("测试模式修改了文档: %s", got)
+	}
+}
+
+// 测试JSON Pointer的move和copy操作
+func TestPointerMoveCopy(t *testing.T) {
+	pointer := func(s string) *CliJSONPointer {
+		p, err := NewJSONPointer(s)
+		if err != nil {
+			t.Fatalf("解析指针 %q 失败: %v", s, err)
+		}
+		return p
+	}
+
+	tests := []struct {
+		name     string
+		op       string
+		from     string
+		path     string
+		expected string // 为空表示期望失败
+	}{
+		{"移动对象成员", "move", "/a", "/b/c", `{"b":{"c":1},"list":[1,2,3]}`},
+		{"移动数组元素", "move", "/list/0", "/list/2", `{"a":1,"b":{},"list":[2,3,1]}`},
+		{"移动到自身", "move", "/b", "/b", `{"a":1,"b":{},"list":[1,2,3]}`},
+		{"移动到子路径", "move", "/b", "/b/x", ""},
+		{"源不存在", "move", "/missing", "/x", ""},
+		{"复制到数组", "copy", "/a", "/list/1", `{"a":1,"b":{},"list":[1,1,2,3]}`},
+		{"复制根", "copy", "", "/b/root", `{"a":1,"b":{"root":{"a":1,"b":{},"list":[1,2,3]}},"list":[1,2,3]}`},
+		{"复制到缺失的父路径", "copy", "/a", "/x/y", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := &Value{}
+			Parse(doc, `{"a":1,"b":{},"list":[1,2,3]}`)
+
+			var err error
+			if tt.op == "move" {
+				err = PointerMove(doc, pointer(tt.from), pointer(tt.path))
+			} else {
+				err = PointerCopy(doc, pointer(tt.from), pointer(tt.path))
+			}
+			if tt.expected == "" {
+				if err == nil {
+					t.Errorf("期望失败")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("操作失败: %v", err)
+			}
+			if got, _ := Stringify(doc); got != tt.expected {
+				t.Errorf("结果 = %s, 期望 %s", got, tt.expected)
+			}
+		})
+	}
+
+	// 复制得到的是独立的副本
+	doc := &Value{}
+	Parse(doc, `{"src":{"list":[1]}}`)
+	if err := PointerCopy(doc, pointer("/src"), pointer("/dst")); err != nil {
+		t.Fatal(err)
+	}
+	if err := PointerAdd(doc, pointer("/dst/list/1"), &Value{Type: TRUE}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := Stringify(doc); got != `{"src":{"list":[1]},"dst":{"list":[1,true]}}` {
+		t.Errorf("复制的值与源共享数据: %s", got)
+	}
+}
+
+// 测试CLI JSON Pointer对数组令牌的处理：-、前导零和负数索引
+func TestCliPointerArrayTokens(t *testing.T) {
+	tests := []struct {
+		name     string
+		op       string
+		pointer  string
+		negative bool
+		expected string // get 的结果或修改后的文档，为空表示期望失败
+	}{
+		{"读取元素", "get", "/list/2", false, `"c"`},
+		{"读取 -", "get", "/list/-", false, ""},
+		{"读取前导零", "get", "/list/01", false, ""},
+		{"读取负数索引未启用", "get", "/list/-1", false, ""},
+		{"读取最后一个", "get", "/list/-1", true, `"c"`},
+		{"读取倒数第三个", "get", "/list/-3", true, `"a"`},
+		{"负数索引越界", "get", "/list/-4", true, ""},
+		{"负数零", "get", "/list/-0", true, ""},
+		{"嵌套负数索引", "get", "/nested/-1/-1", true, `2`},
+		{"追加", "add", "/list/-", false, `{"list":["a","b","c","x"],"nested":[[1,2]]}`},
+		{"在长度处添加", "add", "/list/3", false, `{"list":["a","b","c","x"],"nested":[[1,2]]}`},
+		{"添加前导零", "add", "/list/01", false, ""},
+		{"在最后一个之前插入", "add", "/list/-1", true, `{"list":["a","b","x","c"],"nested":[[1,2]]}`},
+		{"删除 -", "remove", "/list/-", false, ""},
+		{"删除前导零", "remove", "/list/00", false, ""},
+		{"删除最后一个", "remove", "/list/-1", true, `{"list":["a","b"],"nested":[[1,2]]}`},
+		{"替换 -", "replace", "/list/-", false, ""},
+		{"替换第一个", "replace", "/list/-3", true, `{"list":["x","b","c"],"nested":[[1,2]]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := &Value{}
+			Parse(doc, `{"list":["a","b","c"],"nested":[[1,2]]}`)
+			pointer, err := NewJSONPointer(tt.pointer)
+			if err != nil {
+				t.Fatalf("解析指针失败: %v", err)
+			}
+			pointer.AllowNegativeIndex = tt.negative
+
+			x := &Value{}
+			SetString(x, "x")
+			var got string
+			switch tt.op {
+			case "get":
+				var v *Value
+				v, _, err = ResolvePointer(doc, pointer)
+				if err == nil {
+					got, _ = Stringify(v)
+				}
+			case "add":
+				err = PointerAdd(doc, pointer, x)
+			case "remove":
+				err = PointerRemove(doc, pointer)
+			case "replace":
+				err = PointerReplace(doc, pointer, x)
+			}
+			if tt.op != "get" {
+				got, _ = Stringify(doc)
+			}
+
+			if tt.expected == "" {
+				if err == nil {
+					t.Errorf("期望失败，实际得到 %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("操作失败: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("结果 = %s, 期望 %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMinifyLargeFile(t *testing.T) {
+	// 足够大、会显示进度条的文件不受默认的1MB等安全限制
+	dir := t.TempDir()
+	input := filepath.Join(dir, "large.json")
+	output := filepath.Join(dir, "large.min.json")
+	// 超过默认限制的数组元素数量、字符串长度和总大小
+	item := `"` + strings.Repeat("x", 3400) + `",`
+	content := "[" + strings.Repeat(item, DefaultParseOptions().MaxArraySize) + `"` + strings.Repeat("y", 9000) + `"]`
+	if len(content) < progressMinSize {
+		t.Fatalf("测试文件只有 %d 字节", len(content))
+	}
+	if err := os.WriteFile(input, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	runMinify([]string{input, output}, false)
+	got, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("没有生成输出文件: %v", err)
+	}
+	if len(got) != len(content)+1 {
+		t.Errorf("输出大小 = %d, 期望 %d", len(got), len(content)+1)
+	}
+}
+
+func TestFileParseOptions(t *testing.T) {
+	defer func(saved ProjectConfig) { cliConfig = saved }(cliConfig)
+	cliConfig = DefaultProjectConfig()
+	options := fileParseOptions(5 << 20)
+	if options.MaxTotalSize != 5<<20 || options.MaxStringLength != 5<<20 || options.MaxDepth != DefaultParseOptions().MaxDepth {
+		t.Errorf("fileParseOptions = %+v", options)
+	}
+
+	// 项目配置中指定的限制不被放宽
+	cliConfig.Security.MaxTotalSize = 2 << 20
+	if options := fileParseOptions(5 << 20); options.MaxTotalSize != 2<<20 {
+		t.Errorf("MaxTotalSize = %d, 期望 %d", options.MaxTotalSize, 2<<20)
+	}
+}
+
+func TestLoadJSONMmapLargeFile(t *testing.T) {
+	defer func(saved bool) { cliUseMmap = saved }(cliUseMmap)
+	cliUseMmap = true
+
+	// 内存映射读取的文件同样不受默认的1MB限制
+	file := filepath.Join(t.TempDir(), "large.json")
+	content := `{"data": "` + strings.Repeat("x", DefaultParseOptions().MaxTotalSize) + `"}`
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	v, err := loadJSON(file, false)
+	if err != nil {
+		t.Fatalf("loadJSON(--mmap) 失败: %v", err)
+	}
+	if s := GetString(GetObjectValueByKey(v, "data")); len(s) != DefaultParseOptions().MaxTotalSize {
+		t.Errorf("data 的长度 = %d", len(s))
+	}
+}
@@ -0,0 +1,350 @@
+// cli_validate_tree.go - 并发验证目录树中的所有JSON文件
+package leptjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultTreePattern 是 ValidateTree 默认验证的文件
+const DefaultTreePattern = "**/*.json"
+
+// TreeValidationOptions 是 ValidateTree 的选项
+type TreeValidationOptions struct {
+	// 要验证的文件，是相对于根目录、以 / 分隔的 path.Match 模式，
+	// 单独的 ** 匹配任意层目录；为空时使用 DefaultTreePattern
+	Pattern string
+	// 非空时，每个文件使用所在目录或最近的上层目录（不超出根目录）中
+	// 这个名字的Schema文件，都没有时使用默认Schema；这些Schema文件本身不验证
+	SchemaName string
+	// 同时验证的文件数，不大于 0 时为 CPU 数
+	Workers int
+	// 验证选项，BaseURI 为空时以各Schema文件所在位置为基准
+	Validation ValidationOptions
+}
+
+// TreeFileResult 是目录树中一个文件的验证结果
+type TreeFileResult struct {
+	Path   string            `json:"path"`             // 相对于根目录的路径
+	Schema string            `json:"schema,omitempty"` // 使用的Schema文件
+	Valid  bool              `json:"valid"`
+	Error  string            `json:"error,omitempty"`  // 无法读取、解析文件或Schema时的错误
+	Issues []ValidationIssue `json:"issues,omitempty"` // 验证错误
+}
+
+// TreeValidationReport 是 ValidateTree 的汇总报告
+type TreeValidationReport struct {
+	Root    string           `json:"root"`
+	Files   int              `json:"files"`   // 验证的文件数
+	Valid   int              `json:"valid"`   // 通过验证的文件数
+	Invalid int              `json:"invalid"` // 不符合Schema的文件数
+	Failed  int              `json:"failed"`  // 无法验证的文件数
+	Results []TreeFileResult `json:"results"` // 按路径排列的每个文件的结果
+}
+
+// OK 判断是否所有文件都通过了验证
+func (r *TreeValidationReport) OK() bool {
+	return r.Invalid == 0 && r.Failed == 0
+}
+
+// treeSchema 是加载后的Schema文件
+type treeSchema struct {
+	schema  *Value
+	baseURI string
+	err     error
+}
+
+// ValidateTree 遍历 root 下与模式匹配的文件，并发地用Schema验证它们
+//
+// schemaFile 是默认Schema，为空时没有目录Schema的文件记为无法验证；它在 root 之下时本身不被验证。
+// 单个文件读取、解析或验证失败只记录在它的结果中；模式无效或无法遍历目录时返回错误。
+func ValidateTree(schemaFile, root string, options TreeValidationOptions) (*TreeValidationReport, error) {
+	pattern := options.Pattern
+	if pattern == "" {
+		pattern = DefaultTreePattern
+	}
+	if err := checkTreePattern(pattern); err != nil {
+		return nil, err
+	}
+
+	// 默认Schema在 root 之下时不把它当作数据验证
+	var schemaInfo fs.FileInfo
+	if schemaFile != "" {
+		schemaInfo, _ = os.Stat(schemaFile)
+	}
+
+	var files []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || (options.SchemaName != "" && d.Name() == options.SchemaName) {
+			return nil
+		}
+		if schemaInfo != nil {
+			if info, err := d.Info(); err == nil && os.SameFile(schemaInfo, info) {
+				return nil
+			}
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		if rel = filepath.ToSlash(rel); matchTreePattern(pattern, rel) {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 先在当前 goroutine 中为每个文件找到并加载Schema，验证时只读
+	schemas := make(map[string]*treeSchema)
+	loadSchema := func(file string) *treeSchema {
+		if s, ok := schemas[file]; ok {
+			return s
+		}
+		s := &treeSchema{}
+		if s.schema, s.err = loadJSON(file, false); s.err == nil && options.Validation.BaseURI == "" {
+			s.baseURI, s.err = SchemaFileURI(file)
+		}
+		schemas[file] = s
+		return s
+	}
+	dirSchemas := make(map[string]string)
+	schemaFiles := make([]string, len(files))
+	for i, rel := range files {
+		schemaFiles[i] = schemaFile
+		if options.SchemaName != "" {
+			if found := findDirSchema(root, path.Dir(rel), options.SchemaName, dirSchemas); found != "" {
+				schemaFiles[i] = found
+			}
+		}
+		if schemaFiles[i] != "" {
+			loadSchema(schemaFiles[i])
+		}
+	}
+
+	report := &TreeValidationReport{Root: root, Files: len(files), Results: make([]TreeFileResult, len(files))}
+	workers := options.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				report.Results[i] = validateTreeFile(root, files[i], schemaFiles[i], schemas[schemaFiles[i]], options.Validation)
+			}
+		}()
+	}
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, result := range report.Results {
+		switch {
+		case result.Error != "":
+			report.Failed++
+		case result.Valid:
+			report.Valid++
+		default:
+			report.Invalid++
+		}
+	}
+	return report, nil
+}
+
+// validateTreeFile 验证一个文件
+func validateTreeFile(root, rel, schemaFile string, schema *treeSchema, options ValidationOptions) TreeFileResult {
+	result := TreeFileResult{Path: rel, Schema: schemaFile}
+	if schema == nil {
+		result.Error = "没有适用的Schema"
+		return result
+	}
+	if schema.err != nil {
+		result.Error = fmt.Sprintf("加载Schema失败: %s", schema.err)
+		return result
+	}
+	data, err := loadJSON(filepath.Join(root, filepath.FromSlash(rel)), false)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if options.BaseURI == "" {
+		options.BaseURI = schema.baseURI
+	}
+	validation, err := ValidateWithOptions(schema.schema, data, options)
+	if err != nil {
+		result.Error = fmt.Sprintf("解析Schema引用失败: %s", err)
+		return result
+	}
+	result.Valid = validation.Valid
+	result.Issues = validation.Issues
+	return result
+}
+
+// findDirSchema 从 dir 开始向上查找名为 name 的Schema文件，返回它的路径，dir 是相对于 root 的路径
+//
+// cache 记录已经查找过的目录，同一目录下的文件只查找一次。
+func findDirSchema(root, dir, name string, cache map[string]string) string {
+	if found, ok := cache[dir]; ok {
+		return found
+	}
+	found := filepath.Join(root, filepath.FromSlash(dir), name)
+	if info, err := os.Stat(found); err != nil || info.IsDir() {
+		found = ""
+		if dir != "." {
+			found = findDirSchema(root, path.Dir(dir), name, cache)
+		}
+	}
+	cache[dir] = found
+	return found
+}
+
+// checkTreePattern 检查模式的语法
+func checkTreePattern(pattern string) error {
+	for _, segment := range strings.Split(pattern, "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			return fmt.Errorf("无效的文件模式 %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// matchTreePattern 判断以 / 分隔的相对路径 name 是否与模式匹配，单独的 ** 匹配任意层目录
+func matchTreePattern(pattern, name string) bool {
+	return matchTreeSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchTreeSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchTreeSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// runValidateTree 实现validate-tree命令
+func runValidateTree(args []string, verbose bool) {
+	outputFormat := "json"
+	lang := DefaultValidationLanguage
+	loader := &URLSchemaLoader{CacheDir: defaultSchemaCacheDir()}
+	var options TreeValidationOptions
+	fileArgs := args
+
+	for i := 0; i < len(fileArgs); i++ {
+		arg := fileArgs[i]
+		switch {
+		case strings.HasPrefix(arg, "--pattern="):
+			options.Pattern = strings.TrimPrefix(arg, "--pattern=")
+		case strings.HasPrefix(arg, "--schema-name="):
+			options.SchemaName = strings.TrimPrefix(arg, "--schema-name=")
+		case strings.HasPrefix(arg, "--jobs="):
+			n, err := strconv.Atoi(strings.TrimPrefix(arg, "--jobs="))
+			if err != nil || n < 1 {
+				fmt.Printf("错误: 无效的--jobs值: %s\n", strings.TrimPrefix(arg, "--jobs="))
+				return
+			}
+			options.Workers = n
+		case strings.HasPrefix(arg, "--lang="):
+			lang = strings.TrimPrefix(arg, "--lang=")
+		case strings.HasPrefix(arg, "--schema-cache="):
+			loader.CacheDir = strings.TrimPrefix(arg, "--schema-cache=")
+		case arg == "--offline":
+			loader.Offline = true
+		case strings.HasPrefix(arg, "--format="):
+			outputFormat = strings.TrimPrefix(arg, "--format=")
+			if outputFormat != "text" && outputFormat != "json" {
+				fmt.Printf("错误: 无效的输出格式: %s\n", outputFormat)
+				fmt.Println("有效的格式: text, json")
+				return
+			}
+		default:
+			continue
+		}
+		// 从参数列表中移除选项
+		fileArgs = append(fileArgs[:i], fileArgs[i+1:]...)
+		i--
+	}
+
+	if len(fileArgs) != 2 {
+		fmt.Println("错误: validate-tree命令需要Schema文件和目录两个参数")
+		fmt.Println("\n用法: leptjson validate-tree [--pattern=GLOB] [--schema-name=NAME] SCHEMA DIR")
+		return
+	}
+
+	translator, ok := LookupValidationTranslator(lang)
+	if !ok {
+		fmt.Printf("错误: 未知的语言: %s\n", lang)
+		fmt.Printf("可用的语言: %s\n", strings.Join(ValidationLanguages(), ", "))
+		return
+	}
+	options.Validation = ValidationOptions{Translator: translator, Loader: loader}
+
+	schemaFile, root := fileArgs[0], fileArgs[1]
+	if verbose {
+		fmt.Printf("使用Schema '%s' 验证目录 '%s'\n", schemaFile, root)
+	}
+	report, err := ValidateTree(schemaFile, root, options)
+	if err != nil {
+		fmt.Printf("错误: %s\n", err)
+		exitCLI(1)
+	}
+
+	if outputFormat == "json" {
+		reportJSON, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			fmt.Printf("生成JSON报告失败: %s\n", err)
+			exitCLI(1)
+		}
+		fmt.Println(string(reportJSON))
+	} else {
+		for _, result := range report.Results {
+			switch {
+			case result.Error != "":
+				fmt.Printf("错误 %s: %s\n", result.Path, result.Error)
+			case !result.Valid:
+				fmt.Printf("失败 %s\n", result.Path)
+				for _, issue := range result.Issues {
+					fmt.Printf("  %s: %s\n", issue.Path, issue.Message)
+				}
+			case verbose:
+				fmt.Printf("通过 %s\n", result.Path)
+			}
+		}
+		fmt.Printf("共 %d 个文件: %d 个通过, %d 个失败, %d 个无法验证\n",
+			report.Files, report.Valid, report.Invalid, report.Failed)
+	}
+
+	if !report.OK() {
+		exitCLI(2)
+	}
+}
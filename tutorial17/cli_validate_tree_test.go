@@ -0,0 +1,126 @@
+package leptjson
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTreeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMatchTreePattern(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"**/*.json", "a.json", true},
+		{"**/*.json", "x/y/a.json", true},
+		{"**/*.json", "a.yaml", false},
+		{"*.json", "x/a.json", false},
+		{"config/**/app.json", "config/app.json", true},
+		{"config/**/app.json", "config/a/b/app.json", true},
+		{"config/**/app.json", "other/app.json", false},
+		{"**", "any/thing", true},
+	}
+	for _, tt := range tests {
+		if got := matchTreePattern(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("matchTreePattern(%q, %q) = %v, 期望 %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+	if err := checkTreePattern("a/[x"); err == nil {
+		t.Error("checkTreePattern 应拒绝无效的模式")
+	}
+}
+
+func TestValidateTree(t *testing.T) {
+	root := t.TempDir()
+	schemaFile := filepath.Join(root, "default.schema")
+	writeTreeFiles(t, root, map[string]string{
+		"default.schema":           `{"type":"object","required":["name"]}`,
+		"a.json":                   `{"name":"a"}`,
+		"b.json":                   `{"other":1}`,
+		"broken.json":              `{"name":`,
+		"notes.txt":                `not json`,
+		"services/schema.json":     `{"type":"object","required":["port"]}`,
+		"services/api.json":        `{"port":80}`,
+		"services/web/site.json":   `{"name":"web"}`,
+		"services/bad/schema.json": `{"type":`,
+		"services/bad/x.json":      `{}`,
+	})
+
+	report, err := ValidateTree(schemaFile, root, TreeValidationOptions{SchemaName: "schema.json", Workers: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := make(map[string]TreeFileResult)
+	for _, r := range report.Results {
+		results[r.Path] = r
+	}
+	if len(results) != 6 || report.Files != 6 {
+		t.Fatalf("验证的文件 = %+v", report.Results)
+	}
+	if _, ok := results["services/schema.json"]; ok {
+		t.Error("目录Schema本身不应被验证")
+	}
+	if !results["a.json"].Valid || results["b.json"].Valid || len(results["b.json"].Issues) != 1 {
+		t.Errorf("默认Schema的结果: %+v %+v", results["a.json"], results["b.json"])
+	}
+	if results["broken.json"].Error == "" {
+		t.Error("无法解析的文件应记录错误")
+	}
+	// 子目录没有自己的Schema时使用上层目录的
+	if r := results["services/web/site.json"]; r.Valid || r.Schema != filepath.Join(root, "services", "schema.json") {
+		t.Errorf("services/web/site.json = %+v", r)
+	}
+	if !results["services/api.json"].Valid {
+		t.Errorf("services/api.json = %+v", results["services/api.json"])
+	}
+	if results["services/bad/x.json"].Error == "" {
+		t.Error("目录Schema无法解析时应记录错误")
+	}
+	if report.Valid != 2 || report.Invalid != 2 || report.Failed != 2 || report.OK() {
+		t.Errorf("汇总 = %d/%d/%d", report.Valid, report.Invalid, report.Failed)
+	}
+
+	report, err = ValidateTree(schemaFile, root, TreeValidationOptions{Pattern: "services/**/*.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Files != 5 { // 没有设置 SchemaName 时 schema.json 也被验证
+		t.Errorf("services/**/*.json 匹配了 %d 个文件", report.Files)
+	}
+	if _, err := ValidateTree(schemaFile, root, TreeValidationOptions{Pattern: "[x"}); err == nil {
+		t.Error("无效的模式应返回错误")
+	}
+	if _, err := ValidateTree(schemaFile, filepath.Join(root, "missing"), TreeValidationOptions{}); err == nil {
+		t.Error("不存在的目录应返回错误")
+	}
+}
+
+func TestValidateTreeSkipsDefaultSchema(t *testing.T) {
+	root := t.TempDir()
+	writeTreeFiles(t, root, map[string]string{
+		"schema.json": `{"type":"object","required":["name"]}`,
+		"a.json":      `{"name":"a"}`,
+	})
+
+	// 默认Schema在目录中且没有设置 SchemaName 时，它本身不被验证
+	report, err := ValidateTree(filepath.Join(root, "schema.json"), root, TreeValidationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Files != 1 || report.Results[0].Path != "a.json" || !report.OK() {
+		t.Errorf("验证的文件 = %+v", report.Results)
+	}
+}
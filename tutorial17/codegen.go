@@ -0,0 +1,449 @@
+// codegen.go - 为结构体生成免反射的序列化代码
+package leptjson
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// CodecAnnotation 写在结构体类型声明的注释中，标记需要生成序列化代码的类型
+const CodecAnnotation = "leptjson:codec"
+
+// codecImportPath 是生成的代码引用的leptjson包路径
+const codecImportPath = "github.com/Cactusinhand/go-json-tutorial/tutorial17"
+
+// codecStruct 描述一个需要生成代码的结构体
+type codecStruct struct {
+	name   string
+	fields []codecField
+}
+
+// codecField 描述结构体中参与序列化的一个字段
+type codecField struct {
+	goName    string
+	key       string
+	omitEmpty bool
+	typ       ast.Expr
+}
+
+// GenerateCodec 解析Go源文件，为其中的结构体生成 MarshalLeptJSON/UnmarshalLeptJSON 方法
+//
+// typeNames 为空时处理所有注释中带有 leptjson:codec 的结构体。
+// 生成的方法只对字符串、布尔、数字、它们的指针和切片，以及同样生成了代码的结构体
+// 直接读写，其他类型的字段退回到 MarshalValue/UnmarshalValue。
+// 同时生成 ToLeptJSON/FromLeptJSON，使 Marshal 和 Unmarshal 处理这些类型的指针时也不再反射。
+// 与 Unmarshal 不同，生成的代码只按键名精确匹配字段。
+func GenerateCodec(filename string, src []byte, typeNames []string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("解析Go源文件失败: %w", err)
+	}
+
+	wanted := make(map[string]bool)
+	for _, name := range typeNames {
+		wanted[name] = true
+	}
+
+	var structs []codecStruct
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+			annotated := hasCodecAnnotation(gen.Doc) || hasCodecAnnotation(ts.Doc)
+			if len(wanted) > 0 && !wanted[ts.Name.Name] || len(wanted) == 0 && !annotated {
+				continue
+			}
+			delete(wanted, ts.Name.Name)
+
+			cs, err := newCodecStruct(ts.Name.Name, st)
+			if err != nil {
+				return nil, err
+			}
+			structs = append(structs, cs)
+		}
+	}
+	for name := range wanted {
+		return nil, fmt.Errorf("找不到结构体类型: %s", name)
+	}
+	if len(structs) == 0 {
+		return nil, fmt.Errorf("%s 中没有带有 //%s 注释的结构体", filename, CodecAnnotation)
+	}
+
+	g := &codecGenerator{generated: make(map[string]bool)}
+	for _, cs := range structs {
+		g.generated[cs.name] = true
+	}
+
+	for _, cs := range structs {
+		g.generateStruct(cs)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by leptjson gen-codec. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", file.Name.Name)
+	out.WriteString("import (\n")
+	// 没有需要报告错误的字段时不引入 fmt
+	if bytes.Contains(g.buf.Bytes(), []byte("fmt.")) {
+		out.WriteString("\t\"fmt\"\n\n")
+	}
+	fmt.Fprintf(&out, "\tleptjson %q\n)\n", codecImportPath)
+	out.Write(g.buf.Bytes())
+
+	formatted, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("格式化生成的代码失败: %w", err)
+	}
+	return formatted, nil
+}
+
+// hasCodecAnnotation 判断注释中是否带有 leptjson:codec 标记
+func hasCodecAnnotation(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		text := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
+		if text == CodecAnnotation {
+			return true
+		}
+	}
+	return false
+}
+
+// newCodecStruct 按照与 typeFields 相同的 tag 规则收集字段
+func newCodecStruct(name string, st *ast.StructType) (codecStruct, error) {
+	cs := codecStruct{name: name}
+	for _, field := range st.Fields.List {
+		tag := ""
+		if field.Tag != nil {
+			unquoted, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				return cs, fmt.Errorf("%s: 无效的 struct tag %s", name, field.Tag.Value)
+			}
+			tag = reflect.StructTag(unquoted).Get("json")
+		}
+		if tag == "-" {
+			continue
+		}
+		tagParts := strings.Split(tag, ",")
+		for _, option := range tagParts[1:] {
+			if option == "string" || option == "inline" {
+				return cs, fmt.Errorf("%s: gen-codec 不支持 %s 选项，请改用 Marshal/Unmarshal", name, option)
+			}
+		}
+		if len(field.Names) == 0 {
+			return cs, fmt.Errorf("%s: gen-codec 不支持嵌入字段 %s，请改用 Marshal/Unmarshal", name, types.ExprString(field.Type))
+		}
+
+		for _, ident := range field.Names {
+			if !ident.IsExported() {
+				continue
+			}
+			f := codecField{goName: ident.Name, key: tagParts[0], typ: field.Type}
+			if f.key == "" {
+				f.key = ident.Name
+			}
+			for _, option := range tagParts[1:] {
+				if option == "omitempty" {
+					f.omitEmpty = true
+				}
+			}
+			cs.fields = append(cs.fields, f)
+		}
+	}
+	return cs, nil
+}
+
+// codecGenerator 保存生成代码的状态
+type codecGenerator struct {
+	buf       bytes.Buffer
+	generated map[string]bool // 同一文件中生成了代码的结构体
+	tmp       int             // 用于生成不重复的临时变量名
+}
+
+// printf 输出一行代码
+func (g *codecGenerator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+	g.buf.WriteByte('\n')
+}
+
+// newVar 返回一个不重复的临时变量名
+func (g *codecGenerator) newVar(prefix string) string {
+	g.tmp++
+	return fmt.Sprintf("%s%d", prefix, g.tmp)
+}
+
+// basicKind 返回内置类型对应的JSON类型：string、boolean、number，其他类型返回空字符串
+func basicKind(expr ast.Expr) string {
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return ""
+	}
+	switch ident.Name {
+	case "string":
+		return "string"
+	case "bool":
+		return "boolean"
+	case "int", "int8", "int16", "int32", "int64",
+		"uint", "uint8", "uint16", "uint32", "uint64", "uintptr",
+		"float32", "float64", "byte", "rune":
+		return "number"
+	default:
+		return ""
+	}
+}
+
+// isGeneratedStruct 判断类型是否为同一文件中生成了代码的结构体
+func (g *codecGenerator) isGeneratedStruct(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && g.generated[ident.Name]
+}
+
+// isDirect 判断类型能否不经反射直接读写
+func (g *codecGenerator) isDirect(expr ast.Expr) bool {
+	return basicKind(expr) != "" || g.isGeneratedStruct(expr)
+}
+
+// generateStruct 为一个结构体生成全部方法
+func (g *codecGenerator) generateStruct(cs codecStruct) {
+	g.printf("\n// MarshalLeptJSON 将 %s 转换为JSON值", cs.name)
+	g.printf("func (x *%s) MarshalLeptJSON() (*leptjson.Value, error) {", cs.name)
+	g.printf("v := &leptjson.Value{}")
+	g.printf("leptjson.SetObject(v)")
+	for _, f := range cs.fields {
+		g.marshalField(f)
+	}
+	g.printf("return v, nil")
+	g.printf("}")
+
+	g.printf("\n// UnmarshalLeptJSON 从JSON值还原 %s，没有对应字段的键被忽略", cs.name)
+	g.printf("func (x *%s) UnmarshalLeptJSON(v *leptjson.Value) error {", cs.name)
+	g.printf("if leptjson.GetType(v) != leptjson.OBJECT {")
+	g.printf("return &leptjson.ErrWrongType{Expected: \"object\", Actual: leptjson.GetType(v)}")
+	g.printf("}")
+	g.printf("for i := 0; i < leptjson.GetObjectSize(v); i++ {")
+	g.printf("m := leptjson.GetObjectValue(v, i)")
+	g.printf("switch leptjson.GetObjectKey(v, i) {")
+	for _, f := range cs.fields {
+		g.printf("case %q:", f.key)
+		g.unmarshalInto("x."+f.goName, "m", f.typ, strconv.Quote(f.key))
+	}
+	g.printf("}")
+	g.printf("}")
+	g.printf("return nil")
+	g.printf("}")
+
+	g.printf("\n// ToLeptJSON 实现 leptjson.LeptMarshaler")
+	g.printf("func (x *%s) ToLeptJSON() (*leptjson.Value, error) {", cs.name)
+	g.printf("return x.MarshalLeptJSON()")
+	g.printf("}")
+	g.printf("\n// FromLeptJSON 实现 leptjson.LeptUnmarshaler")
+	g.printf("func (x *%s) FromLeptJSON(v *leptjson.Value) error {", cs.name)
+	g.printf("return x.UnmarshalLeptJSON(v)")
+	g.printf("}")
+}
+
+// marshalField 生成序列化一个字段的代码
+func (g *codecGenerator) marshalField(f codecField) {
+	expr := "x." + f.goName
+	cond := ""
+	if f.omitEmpty {
+		cond = omitEmptyCondition(expr, f.typ)
+	}
+	if cond != "" {
+		g.printf("if %s {", cond)
+	} else {
+		g.printf("{")
+	}
+	g.printf("fv := leptjson.SetObjectValue(v, %q)", f.key)
+	g.marshalInto("fv", expr, f.typ, f.goName)
+	g.printf("}")
+}
+
+// omitEmptyCondition 返回字段不为空的判断条件，规则与 isEmptyValue 相同
+func omitEmptyCondition(expr string, typ ast.Expr) string {
+	switch t := typ.(type) {
+	case *ast.StarExpr, *ast.InterfaceType:
+		return expr + " != nil"
+	case *ast.ArrayType, *ast.MapType:
+		return "len(" + expr + ") != 0"
+	case *ast.Ident:
+		switch basicKind(t) {
+		case "string":
+			return expr + ` != ""`
+		case "boolean":
+			return expr
+		case "number":
+			return expr + " != 0"
+		}
+	}
+	return ""
+}
+
+// marshalInto 生成将 expr 写入 dst 的代码，dst 是 *leptjson.Value 类型的变量
+func (g *codecGenerator) marshalInto(dst, expr string, typ ast.Expr, label string) {
+	switch t := typ.(type) {
+	case *ast.Ident:
+		switch basicKind(t) {
+		case "string":
+			g.printf("leptjson.SetString(%s, %s)", dst, expr)
+			return
+		case "boolean":
+			g.printf("leptjson.SetBoolean(%s, %s)", dst, expr)
+			return
+		case "number":
+			g.printf("leptjson.SetNumber(%s, float64(%s))", dst, expr)
+			return
+		}
+		if g.generated[t.Name] {
+			g.marshalNested(dst, expr, label)
+			return
+		}
+	case *ast.StarExpr:
+		if g.isDirect(t.X) {
+			g.printf("if %s == nil {", expr)
+			g.printf("leptjson.SetNull(%s)", dst)
+			g.printf("} else {")
+			if g.isGeneratedStruct(t.X) {
+				g.marshalNested(dst, expr, label)
+			} else {
+				g.marshalInto(dst, "*"+expr, t.X, label)
+			}
+			g.printf("}")
+			return
+		}
+	case *ast.ArrayType:
+		if t.Len == nil && g.isDirect(t.Elt) {
+			elem := g.newVar("elem")
+			item := g.newVar("item")
+			g.printf("leptjson.SetArray(%s, len(%s))", dst, expr)
+			g.printf("for _, %s := range %s {", elem, expr)
+			g.printf("%s := leptjson.PushBackArrayElement(%s)", item, dst)
+			g.marshalInto(item, elem, t.Elt, label)
+			g.printf("}")
+			return
+		}
+	}
+
+	// 其他类型退回到反射
+	result := g.newVar("fv")
+	g.printf("%s, err := leptjson.MarshalValue(%s)", result, expr)
+	g.printf("if err != nil {")
+	g.printf("return nil, fmt.Errorf(\"序列化字段 %s 失败: %%w\", err)", label)
+	g.printf("}")
+	g.printf("leptjson.Move(%s, %s)", dst, result)
+}
+
+// marshalNested 生成调用嵌套结构体 MarshalLeptJSON 的代码
+func (g *codecGenerator) marshalNested(dst, expr, label string) {
+	result := g.newVar("fv")
+	g.printf("%s, err := %s.MarshalLeptJSON()", result, expr)
+	g.printf("if err != nil {")
+	g.printf("return nil, fmt.Errorf(\"序列化字段 %s 失败: %%w\", err)", label)
+	g.printf("}")
+	g.printf("leptjson.Move(%s, %s)", dst, result)
+}
+
+// unmarshalInto 生成将 src 写入 target 的代码
+//
+// src 是 *leptjson.Value 类型的变量，label 是生成错误信息中字段名的Go表达式。
+// 与 Unmarshal 相同，null 将指针和切片置为nil，其他类型保持不变。
+func (g *codecGenerator) unmarshalInto(target, src string, typ ast.Expr, label string) {
+	wrongType := func(expected string) {
+		g.printf("return fmt.Errorf(\"字段 %%s: %%w\", %s, &leptjson.ErrWrongType{Expected: %q, Actual: leptjson.GetType(%s)})", label, expected, src)
+	}
+
+	switch t := typ.(type) {
+	case *ast.Ident:
+		if basicKind(t) != "" {
+			g.printf("if leptjson.GetType(%s) != leptjson.NULL {", src)
+			g.unmarshalBasic(target, src, t, label)
+			g.printf("}")
+			return
+		}
+		if g.generated[t.Name] {
+			g.printf("if leptjson.GetType(%s) != leptjson.NULL {", src)
+			g.unmarshalNested(target, src, label)
+			g.printf("}")
+			return
+		}
+	case *ast.StarExpr:
+		if g.isDirect(t.X) {
+			g.printf("if leptjson.GetType(%s) == leptjson.NULL {", src)
+			g.printf("%s = nil", target)
+			g.printf("} else {")
+			g.printf("if %s == nil {", target)
+			g.printf("%s = new(%s)", target, types.ExprString(t.X))
+			g.printf("}")
+			if g.isGeneratedStruct(t.X) {
+				g.unmarshalNested(target, src, label)
+			} else {
+				g.unmarshalBasic("*"+target, src, t.X.(*ast.Ident), label)
+			}
+			g.printf("}")
+			return
+		}
+	case *ast.ArrayType:
+		if t.Len == nil && g.isDirect(t.Elt) {
+			index := g.newVar("j")
+			elem := g.newVar("e")
+			g.printf("if leptjson.GetType(%s) == leptjson.NULL {", src)
+			g.printf("%s = nil", target)
+			g.printf("} else if leptjson.GetType(%s) != leptjson.ARRAY {", src)
+			wrongType("array")
+			g.printf("} else {")
+			g.printf("%s = make(%s, leptjson.GetArraySize(%s))", target, types.ExprString(t), src)
+			g.printf("for %s := range %s {", index, target)
+			g.printf("%s := leptjson.GetArrayElement(%s, %s)", elem, src, index)
+			g.unmarshalInto(fmt.Sprintf("%s[%s]", target, index), elem, t.Elt,
+				fmt.Sprintf("fmt.Sprintf(\"%%s[%%d]\", %s, %s)", label, index))
+			g.printf("}")
+			g.printf("}")
+			return
+		}
+	}
+
+	// 其他类型退回到反射
+	g.printf("if err := leptjson.UnmarshalValue(%s, &%s, leptjson.UnmarshalOptions{}); err != nil {", src, target)
+	g.printf("return fmt.Errorf(\"字段 %%s: %%w\", %s, err)", label)
+	g.printf("}")
+}
+
+// unmarshalBasic 生成读取字符串、布尔或数字的代码，src 不能为 null
+func (g *codecGenerator) unmarshalBasic(target, src string, t *ast.Ident, label string) {
+	kind := basicKind(t)
+	getter := map[string]string{"string": "GetStringChecked", "boolean": "GetBooleanChecked", "number": "GetNumberChecked"}[kind]
+	result := g.newVar("val")
+	g.printf("%s, err := leptjson.%s(%s)", result, getter, src)
+	g.printf("if err != nil {")
+	g.printf("return fmt.Errorf(\"字段 %%s: %%w\", %s, err)", label)
+	g.printf("}")
+	if kind == "number" {
+		g.printf("%s = %s(%s)", target, t.Name, result)
+	} else {
+		g.printf("%s = %s", target, result)
+	}
+}
+
+// unmarshalNested 生成调用嵌套结构体 UnmarshalLeptJSON 的代码
+func (g *codecGenerator) unmarshalNested(target, src, label string) {
+	g.printf("if err := %s.UnmarshalLeptJSON(%s); err != nil {", target, src)
+	g.printf("return fmt.Errorf(\"字段 %%s: %%w\", %s, err)", label)
+	g.printf("}")
+}
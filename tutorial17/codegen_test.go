@@ -0,0 +1,77 @@
+package leptjson
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestGenerateCodecUpToDate 确保 internal/codectest 中提交的生成代码与生成器的输出一致
+func TestGenerateCodecUpToDate(t *testing.T) {
+	src, err := os.ReadFile("internal/codectest/types.go")
+	if err != nil {
+		t.Fatalf("读取源文件失败: %v", err)
+	}
+	expected, err := os.ReadFile("internal/codectest/types_leptjson.go")
+	if err != nil {
+		t.Fatalf("读取生成的文件失败: %v", err)
+	}
+
+	got, err := GenerateCodec("types.go", src, nil)
+	if err != nil {
+		t.Fatalf("GenerateCodec 失败: %v", err)
+	}
+	if string(got) != string(expected) {
+		t.Error("生成的代码已过期，请在 internal/codectest 中运行 leptjson gen-codec types.go")
+	}
+	if strings.Contains(string(got), "Untouched") {
+		t.Error("没有 leptjson:codec 注释的结构体不应生成代码")
+	}
+}
+
+func TestGenerateCodecSelectTypes(t *testing.T) {
+	src := []byte(`package models
+
+type Point struct {
+	X, Y float64
+	Name string ` + "`json:\"name,omitempty\"`" + `
+}
+`)
+	got, err := GenerateCodec("models.go", src, []string{"Point"})
+	if err != nil {
+		t.Fatalf("GenerateCodec 失败: %v", err)
+	}
+	for _, want := range []string{
+		"package models",
+		"func (x *Point) MarshalLeptJSON() (*leptjson.Value, error)",
+		"func (x *Point) UnmarshalLeptJSON(v *leptjson.Value) error",
+		`leptjson.SetObjectValue(v, "X")`,
+		`if x.Name != "" {`,
+		`case "Y":`,
+	} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("生成的代码中缺少 %q", want)
+		}
+	}
+}
+
+func TestGenerateCodecErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		types []string
+	}{
+		{"没有注释", "package p\ntype A struct{ X int }\n", nil},
+		{"找不到类型", "package p\ntype A struct{ X int }\n", []string{"B"}},
+		{"嵌入字段", "package p\ntype B struct{}\n//leptjson:codec\ntype A struct{ B }\n", nil},
+		{"string选项", "package p\n//leptjson:codec\ntype A struct{ X int `json:\"x,string\"` }\n", nil},
+		{"语法错误", "package p\ntype A struct{", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := GenerateCodec("p.go", []byte(tt.src), tt.types); err == nil {
+				t.Error("应当返回错误")
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+// columnar.go - 把对象数组转换为列式的记录批次，供 Arrow 和 Parquet 输出使用
+package leptjson
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ColumnType 是列式输出中一列的类型
+type ColumnType int
+
+// 列类型常量
+const (
+	COLUMN_INT64   ColumnType = iota // 64位有符号整数
+	COLUMN_FLOAT64                   // 64位浮点数
+	COLUMN_BOOL                      // 布尔值
+	COLUMN_UTF8                      // UTF-8字符串、嵌套的数组，以及类型混杂的列
+)
+
+// String 返回类型的名称
+func (t ColumnType) String() string {
+	switch t {
+	case COLUMN_INT64:
+		return "int64"
+	case COLUMN_FLOAT64:
+		return "float64"
+	case COLUMN_BOOL:
+		return "bool"
+	case COLUMN_UTF8:
+		return "utf8"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalJSON 将列类型编码为名称字符串
+func (t ColumnType) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(t.String())), nil
+}
+
+// ColumnField 是 schema 中的一列，所有列都可以为 null
+type ColumnField struct {
+	Name string     `json:"name"`
+	Path string     `json:"path"` // 列对应的成员在记录中的 JSON Pointer
+	Type ColumnType `json:"type"`
+}
+
+// ColumnData 是一个批次中一列的数据
+//
+// Valid[i] 为 false 表示第 i 行是 null。与列类型对应的切片长度等于批次的行数，
+// null 行的位置是零值。
+type ColumnData struct {
+	Valid     []bool
+	NullCount int
+	Int64s    []int64
+	Float64s  []float64
+	Bools     []bool
+	Strings   []string
+}
+
+// ColumnBatch 是若干行按列存放的数据，对应 Arrow 的记录批次和 Parquet 的行组
+type ColumnBatch struct {
+	Length  int
+	Columns []*ColumnData
+}
+
+// ColumnarTable 是转换为列式存储的对象数组
+type ColumnarTable struct {
+	Fields  []ColumnField
+	Batches []*ColumnBatch
+	Rows    int
+}
+
+// ColumnarOptions 控制 BuildColumnarTable 如何推断 schema 和划分批次
+type ColumnarOptions struct {
+	SampleSize int    // 用于推断 schema 的记录数，<=0 时使用 DefaultColumnarSampleSize
+	BatchSize  int    // 每个批次的最大行数，<=0 时使用 DefaultColumnarBatchSize
+	Separator  string // 嵌套对象的键连接成列名时使用的分隔符，为空时使用 "_"
+}
+
+// 默认的采样记录数和批次大小
+const (
+	DefaultColumnarSampleSize = 1000
+	DefaultColumnarBatchSize  = 65536
+)
+
+// InferColumnarSchema 根据前 SampleSize 条记录推断 schema
+//
+// 与 FlattenTable 一样，嵌套的对象展开为多列，数组和空对象作为JSON文本。
+// 只有整数的列为 int64，只有数字的列为 float64，只有布尔值的列为 bool，
+// 其余（包括全是 null 的列）为 utf8。列名不区分大小写地保持唯一。
+func InferColumnarSchema(records *Value, options ColumnarOptions) ([]ColumnField, error) {
+	if records == nil || records.Type != ARRAY {
+		return nil, fmt.Errorf("只能把对象数组转换为列式数据")
+	}
+	if len(records.A) == 0 {
+		return nil, fmt.Errorf("没有可以推断 schema 的记录")
+	}
+	sample := options.SampleSize
+	if sample <= 0 {
+		sample = DefaultColumnarSampleSize
+	}
+	if sample > len(records.A) {
+		sample = len(records.A)
+	}
+	separator := options.Separator
+	if separator == "" {
+		separator = "_"
+	}
+
+	var fields []ColumnField
+	var kinds []columnKinds
+	index := make(map[string]int)
+	taken := make(map[string]bool)
+	for i, record := range records.A[:sample] {
+		if record.Type != OBJECT {
+			return nil, fmt.Errorf("第%d个元素不是对象", i+1)
+		}
+		err := walkFlattened(record, func(path []string, v *Value) error {
+			pointer := pointerFromTokens(path)
+			col, ok := index[pointer]
+			if !ok {
+				col = len(fields)
+				index[pointer] = col
+				fields = append(fields, ColumnField{Name: uniqueColumnName(strings.Join(path, separator), taken), Path: pointer})
+				kinds = append(kinds, columnKinds{})
+			}
+			kinds[col].observe(v)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("记录中没有任何列")
+	}
+	for i := range fields {
+		fields[i].Type = kinds[i].columnarType()
+	}
+	return fields, nil
+}
+
+// columnarType 把观察到的值的种类映射为列式类型
+func (k columnKinds) columnarType() ColumnType {
+	switch {
+	case k.text || k.composite:
+		return COLUMN_UTF8
+	case k.boolean:
+		if k.integer || k.real {
+			return COLUMN_UTF8
+		}
+		return COLUMN_BOOL
+	case k.real:
+		return COLUMN_FLOAT64
+	case k.integer:
+		return COLUMN_INT64
+	default:
+		return COLUMN_UTF8
+	}
+}
+
+// BuildColumnarTable 推断 schema，再把所有记录按批次转换为列式数据
+//
+// 采样之外的记录必须符合推断出的 schema：出现新的列，或者值无法保存为列的类型时
+// 返回错误，此时可以增大 SampleSize。整数可以放入 float64 列，任何值都可以
+// 作为JSON文本放入 utf8 列，缺少的列为 null。
+func BuildColumnarTable(records *Value, options ColumnarOptions) (*ColumnarTable, error) {
+	fields, err := InferColumnarSchema(records, options)
+	if err != nil {
+		return nil, err
+	}
+	batchSize := options.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultColumnarBatchSize
+	}
+	index := make(map[string]int, len(fields))
+	for i, f := range fields {
+		index[f.Path] = i
+	}
+
+	table := &ColumnarTable{Fields: fields, Rows: len(records.A)}
+	for start := 0; start < len(records.A); start += batchSize {
+		end := start + batchSize
+		if end > len(records.A) {
+			end = len(records.A)
+		}
+		batch := newColumnBatch(fields, end-start)
+		for row := start; row < end; row++ {
+			record := records.A[row]
+			if record.Type != OBJECT {
+				return nil, fmt.Errorf("第%d个元素不是对象", row+1)
+			}
+			err := walkFlattened(record, func(path []string, v *Value) error {
+				pointer := pointerFromTokens(path)
+				col, ok := index[pointer]
+				if !ok {
+					return fmt.Errorf("第%d个元素的成员 %s 不在推断出的 schema 中，请增大采样的记录数", row+1, pointer)
+				}
+				if err := batch.Columns[col].set(row-start, v, fields[col].Type); err != nil {
+					return fmt.Errorf("第%d个元素的成员 %s: %v", row+1, pointer, err)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		for _, c := range batch.Columns {
+			for _, ok := range c.Valid {
+				if !ok {
+					c.NullCount++
+				}
+			}
+		}
+		table.Batches = append(table.Batches, batch)
+	}
+	return table, nil
+}
+
+// newColumnBatch 创建一个所有值都是 null 的批次
+func newColumnBatch(fields []ColumnField, length int) *ColumnBatch {
+	batch := &ColumnBatch{Length: length, Columns: make([]*ColumnData, len(fields))}
+	for i, f := range fields {
+		c := &ColumnData{Valid: make([]bool, length)}
+		switch f.Type {
+		case COLUMN_INT64:
+			c.Int64s = make([]int64, length)
+		case COLUMN_FLOAT64:
+			c.Float64s = make([]float64, length)
+		case COLUMN_BOOL:
+			c.Bools = make([]bool, length)
+		default:
+			c.Strings = make([]string, length)
+		}
+		batch.Columns[i] = c
+	}
+	return batch
+}
+
+// set 把一个 JSON 值保存到第 row 行
+func (c *ColumnData) set(row int, v *Value, t ColumnType) error {
+	if v.Type == NULL {
+		return nil
+	}
+	switch t {
+	case COLUMN_INT64:
+		if v.Type != NUMBER || !isSQLInteger(v.N) {
+			return fmt.Errorf("不是 int64 列需要的整数")
+		}
+		c.Int64s[row] = int64(v.N)
+	case COLUMN_FLOAT64:
+		if v.Type != NUMBER {
+			return fmt.Errorf("不是 float64 列需要的数字")
+		}
+		c.Float64s[row] = v.N
+	case COLUMN_BOOL:
+		if v.Type != TRUE && v.Type != FALSE {
+			return fmt.Errorf("不是 bool 列需要的布尔值")
+		}
+		c.Bools[row] = v.Type == TRUE
+	default:
+		if v.Type == STRING {
+			c.Strings[row] = GetString(v)
+		} else {
+			c.Strings[row], _ = Stringify(v)
+		}
+	}
+	c.Valid[row] = true
+	return nil
+}
@@ -0,0 +1,110 @@
+package leptjson
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestInferColumnarSchema(t *testing.T) {
+	records := mustParse(t, `[
+		{"id": 1, "price": 9.5, "ok": true, "user": {"name": "x"}, "tags": ["a"], "mixed": 1, "none": null},
+		{"id": 2, "price": 10, "ok": false, "user": {"name": "y"}, "mixed": false},
+		{"id": 3.5}
+	]`)
+	fields, err := InferColumnarSchema(records, ColumnarOptions{SampleSize: 2})
+	if err != nil {
+		t.Fatalf("InferColumnarSchema 失败: %v", err)
+	}
+	// 只采样前两条记录，id 仍然是 int64
+	expected := []ColumnField{
+		{"id", "/id", COLUMN_INT64},
+		{"price", "/price", COLUMN_FLOAT64},
+		{"ok", "/ok", COLUMN_BOOL},
+		{"user_name", "/user/name", COLUMN_UTF8},
+		{"tags", "/tags", COLUMN_UTF8},
+		{"mixed", "/mixed", COLUMN_UTF8},
+		{"none", "/none", COLUMN_UTF8},
+	}
+	if len(fields) != len(expected) {
+		t.Fatalf("schema = %+v, 期望 %+v", fields, expected)
+	}
+	for i, f := range expected {
+		if fields[i] != f {
+			t.Errorf("第%d列 = %+v, 期望 %+v", i+1, fields[i], f)
+		}
+	}
+	data, _ := json.Marshal(fields[1])
+	if string(data) != `{"name":"price","path":"/price","type":"float64"}` {
+		t.Errorf("列的JSON = %s", data)
+	}
+
+	for _, text := range []string{`{"a": 1}`, `[]`, `[{}]`, `[1]`} {
+		if _, err := InferColumnarSchema(mustParse(t, text), ColumnarOptions{}); err == nil {
+			t.Errorf("InferColumnarSchema(%s) 应该返回错误", text)
+		}
+	}
+}
+
+func TestBuildColumnarTable(t *testing.T) {
+	records := mustParse(t, `[
+		{"id": 1, "score": 1.5, "name": "a", "ok": true},
+		{"id": 2, "score": 2, "name": null},
+		{"id": null, "score": 3.25, "name": {"x": [1]}, "ok": false},
+		{"id": 4}
+	]`)
+	table, err := BuildColumnarTable(records, ColumnarOptions{BatchSize: 3})
+	if err != nil {
+		t.Fatalf("BuildColumnarTable 失败: %v", err)
+	}
+	if table.Rows != 4 || len(table.Batches) != 2 || table.Batches[0].Length != 3 || table.Batches[1].Length != 1 {
+		t.Fatalf("行数 %d，批次 %d", table.Rows, len(table.Batches))
+	}
+
+	first := table.Batches[0]
+	id := first.Columns[0]
+	if id.NullCount != 1 || id.Valid[2] || id.Int64s[1] != 2 {
+		t.Errorf("id 列 = %+v", id)
+	}
+	if score := first.Columns[1]; score.NullCount != 0 || score.Float64s[1] != 2 || score.Float64s[2] != 3.25 {
+		t.Errorf("score 列 = %+v", score)
+	}
+	// 嵌套对象在采样中出现后，name_x 成为单独的列
+	if len(table.Fields) != 5 || table.Fields[4].Name != "name_x" || table.Fields[2].Type != COLUMN_UTF8 {
+		t.Errorf("schema = %+v", table.Fields)
+	}
+	if x := first.Columns[4]; x.Strings[2] != "[1]" || x.NullCount != 2 {
+		t.Errorf("name_x 列 = %+v", x)
+	}
+	if ok := table.Batches[1].Columns[3]; ok.NullCount != 1 || ok.Valid[0] {
+		t.Errorf("第二个批次的 ok 列 = %+v", ok)
+	}
+}
+
+func TestBuildColumnarTableSchemaMismatch(t *testing.T) {
+	tests := []struct {
+		json     string
+		expected string
+	}{
+		{`[{"a": 1}, {"a": 1.5}]`, "int64"},
+		{`[{"a": 1}, {"b": 1}]`, "不在推断出的 schema 中"},
+		{`[{"a": true}, {"a": 1}]`, "bool"},
+		{`[{"a": 1.5}, {"a": "x"}]`, "float64"},
+		{`[{"a": 1}, 2]`, "第2个元素不是对象"},
+	}
+	for _, tt := range tests {
+		_, err := BuildColumnarTable(mustParse(t, tt.json), ColumnarOptions{SampleSize: 1})
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("BuildColumnarTable(%s) 的错误 = %v, 期望包含 %q", tt.json, err, tt.expected)
+		}
+	}
+
+	// 整数可以放入 float64 列，任何值都可以放入 utf8 列
+	table, err := BuildColumnarTable(mustParse(t, `[{"a": 1.5, "b": "x"}, {"a": 2, "b": [true]}]`), ColumnarOptions{SampleSize: 1})
+	if err != nil {
+		t.Fatalf("BuildColumnarTable 失败: %v", err)
+	}
+	if c := table.Batches[0].Columns[1]; c.Strings[1] != "[true]" {
+		t.Errorf("utf8 列 = %+v", c)
+	}
+}
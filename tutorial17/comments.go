@@ -0,0 +1,105 @@
+// comments.go - 解析时保留注释，把注释关联到最近的值，格式化时重新输出
+package leptjson
+
+import "strings"
+
+// ValueComments 是关联到一个值的注释，每条注释保留原来的 // 或 /* */ 标记
+type ValueComments struct {
+	Leading  []string // 值之前单独成行的注释，对象成员的注释写在键之前
+	Trailing []string // 值（以及后面的逗号）之后同一行的注释
+	Dangling []string // 数组或对象中最后一个元素之后、右括号之前单独成行的注释
+}
+
+// CommentMap 记录解析时遇到的注释所属的值
+//
+// 设置为 ParseOptions.Comments 并打开 AllowComments 后，解析器把每条注释关联到最近的值：
+//
+//	comments := NewCommentMap()
+//	opts := DefaultParseOptions()
+//	opts.AllowComments = true
+//	opts.Comments = comments
+//	ParseWithOptions(v, text, opts)
+//	formatted, _ := FormatWithComments(v, "  ", comments)
+//
+// 和 NumberLiterals 一样以值的地址为键，值被 Copy 或 Move 到其他位置后注释不会跟随。
+type CommentMap struct {
+	values map[*Value]*ValueComments
+	Footer []string // 整个文档之后单独成行的注释
+}
+
+// NewCommentMap 创建空的注释记录
+func NewCommentMap() *CommentMap {
+	return &CommentMap{values: make(map[*Value]*ValueComments)}
+}
+
+// Get 返回关联到 v 的注释，没有注释时返回 nil
+func (m *CommentMap) Get(v *Value) *ValueComments {
+	if m == nil {
+		return nil
+	}
+	return m.values[v]
+}
+
+// Len 返回有注释的值的数量，不包括 Footer
+func (m *CommentMap) Len() int {
+	return len(m.values)
+}
+
+// entry 返回 v 的注释记录，不存在时创建
+func (m *CommentMap) entry(v *Value) *ValueComments {
+	vc := m.values[v]
+	if vc == nil {
+		vc = &ValueComments{}
+		m.values[v] = vc
+	}
+	return vc
+}
+
+// FormatWithComments 格式化输出带有缩进的JSON，并在原来的位置重新输出 comments 中的注释
+//
+// indent 为空时使用两个空格。输出不再是标准JSON，需要打开 AllowComments 才能重新解析。
+func FormatWithComments(v *Value, indent string, comments *CommentMap) (string, error) {
+	if indent == "" {
+		indent = "  "
+	}
+	return formatJSONWithLayout(v, &formatLayout{indent: indent, comments: comments})
+}
+
+// recordComment 记录从 start 到当前位置的注释
+//
+// 与上一个值在同一行、中间只有逗号和空白的注释是该值的行尾注释，
+// 其余的注释暂存起来，留给下一个开始解析的值或即将结束的数组和对象。
+func (c *parseContext) recordComment(start int) {
+	text := strings.TrimRight(c.json[start:c.index], "\r")
+	if c.lastValue != nil {
+		between := c.json[c.trailFrom:start]
+		if !strings.Contains(between, "\n") && strings.Trim(between, " \t\r,") == "" {
+			vc := c.options.Comments.entry(c.lastValue)
+			vc.Trailing = append(vc.Trailing, text)
+			c.trailFrom = c.index
+			return
+		}
+	}
+	c.pendingComments = append(c.pendingComments, text)
+}
+
+// beginCommentedValue 把暂存的注释作为 v 的前置注释
+func (c *parseContext) beginCommentedValue(v *Value) {
+	if len(c.pendingComments) > 0 {
+		vc := c.options.Comments.entry(v)
+		vc.Leading = append(vc.Leading, c.pendingComments...)
+		c.pendingComments = c.pendingComments[:0]
+	}
+	c.lastValue = nil
+}
+
+// endCommentedValue 在 v 解析完成后调用，数组和对象中暂存的注释是右括号之前的注释
+func (c *parseContext) endCommentedValue(v *Value) {
+	if len(c.pendingComments) > 0 && (v.Type == ARRAY || v.Type == OBJECT) {
+		vc := c.options.Comments.entry(v)
+		vc.Dangling = append(vc.Dangling, c.pendingComments...)
+		c.pendingComments = c.pendingComments[:0]
+	}
+	c.lastValue = v
+	c.trailFrom = c.index
+}
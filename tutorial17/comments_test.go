@@ -0,0 +1,119 @@
+package leptjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+// parseWithComments 解析允许注释的JSON并返回注释记录
+func parseWithComments(t *testing.T, json string) (*Value, *CommentMap) {
+	t.Helper()
+	opts := DefaultParseOptions()
+	opts.AllowComments = true
+	opts.Comments = NewCommentMap()
+	v := &Value{}
+	if err := ParseWithOptions(v, json, opts); err != PARSE_OK {
+		t.Fatalf("解析失败: %s", err.Error())
+	}
+	return v, opts.Comments
+}
+
+func TestCommentAttachment(t *testing.T) {
+	v, comments := parseWithComments(t, `// 服务配置
+{
+  // 监听地址
+  "host": "0.0.0.0", // 所有网卡
+  "ports": [80, /* 备用 */ 8080 // HTTP
+    // 以后添加 443
+  ],
+  "debug": /* 开发环境 */ false
+} // 结束
+// 文件末尾`)
+
+	tests := []struct {
+		path string
+		want *ValueComments
+	}{
+		{"", &ValueComments{Leading: []string{"// 服务配置"}, Trailing: []string{"// 结束"}}},
+		{"/host", &ValueComments{Leading: []string{"// 监听地址"}, Trailing: []string{"// 所有网卡"}}},
+		{"/ports", &ValueComments{Dangling: []string{"// 以后添加 443"}}},
+		{"/ports/0", &ValueComments{Trailing: []string{"/* 备用 */"}}},
+		{"/ports/1", &ValueComments{Trailing: []string{"// HTTP"}}},
+		{"/debug", &ValueComments{Leading: []string{"/* 开发环境 */"}}},
+	}
+	for _, tt := range tests {
+		p, _ := ParseJSONPointer(tt.path)
+		target, err := p.Get(v)
+		if err != POINTER_OK {
+			t.Fatalf("Get(%q): %s", tt.path, err)
+		}
+		if got := comments.Get(target); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Get(%q) = %+v, 期望 %+v", tt.path, got, tt.want)
+		}
+	}
+	if comments.Len() != len(tests) {
+		t.Errorf("Len() = %d, 期望 %d", comments.Len(), len(tests))
+	}
+	if !reflect.DeepEqual(comments.Footer, []string{"// 文件末尾"}) {
+		t.Errorf("Footer = %q", comments.Footer)
+	}
+}
+
+func TestCommentsIgnoredWithoutMap(t *testing.T) {
+	opts := DefaultParseOptions()
+	opts.AllowComments = true
+	v := &Value{}
+	if err := ParseWithOptions(v, `{"a": 1 /* x */}`, opts); err != PARSE_OK {
+		t.Fatalf("解析失败: %s", err.Error())
+	}
+}
+
+func TestFormatWithComments(t *testing.T) {
+	v, comments := parseWithComments(t, `// 服务配置
+{"host": "0.0.0.0", // 所有网卡
+ // 端口
+ "ports": [80, 443], "tags": [ /* 暂无 */ ],
+ "debug": false} // 结束
+// 文件末尾`)
+	want := `// 服务配置
+{
+  "host": "0.0.0.0", // 所有网卡
+  // 端口
+  "ports": [
+    80,
+    443
+  ],
+  "tags": [
+    /* 暂无 */
+  ],
+  "debug": false
+} // 结束
+// 文件末尾`
+	got, err := FormatWithComments(v, "  ", comments)
+	if err != nil || got != want {
+		t.Errorf("FormatWithComments =\n%s\n期望\n%s", got, want)
+	}
+
+	// 输出可以重新解析，注释保持在原来的位置
+	v2, comments2 := parseWithComments(t, got)
+	if again, _ := FormatWithComments(v2, "  ", comments2); again != want {
+		t.Errorf("重新格式化 =\n%s\n期望\n%s", again, want)
+	}
+
+	// 按行宽排版时，带有注释的数组和对象仍然展开为多行
+	got, _ = formatJSONWithLayout(v, &formatLayout{indent: "  ", width: 80, comments: comments})
+	want = `// 服务配置
+{
+  "host": "0.0.0.0", // 所有网卡
+  // 端口
+  "ports": [80, 443],
+  "tags": [
+    /* 暂无 */
+  ],
+  "debug": false
+} // 结束
+// 文件末尾`
+	if got != want {
+		t.Errorf("formatJSONWithLayout(width=80) =\n%s\n期望\n%s", got, want)
+	}
+}
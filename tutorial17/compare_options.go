@@ -0,0 +1,217 @@
+// compare_options.go - 可配置的文档比较（规范化、忽略路径、数组元素的匹配方式）
+package leptjson
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CompareOptions 控制 CompareValuesWithOptions 的比较方式，零值与 CompareValues 相同
+type CompareOptions struct {
+	Normalization NormalizationForm // 字符串值和对象键按此规范化形式比较，组合与分解形式不再报告为差异
+
+	// 不比较的路径，JSONPath（以 $ 开头，如 $..updatedAt）或 JSON Pointer（如 /meta/requestId）。
+	// 在任一文档中匹配的值及其子树都被跳过，包括只在一边存在的成员；
+	// 不存在的 JSON Pointer 被忽略。适合跳过时间戳、请求ID这类每次都不同的字段。
+	IgnorePaths []string
+
+	// 数组元素的标识键，如 "id"。两边数组的元素都是带有该键的对象且键值（标量）互不相同时，
+	// 元素按键值而不是下标匹配，报告新增、删除和改变了相对顺序（DIFF_MOVED）的元素；
+	// 不满足条件的数组仍按下标比较。
+	ArrayKey string
+
+	// 标量数组按最长公共子序列比较，报告插入、删除、修改和移动的元素，
+	// 而不是从第一处不同开始逐个下标报告差异
+	LCSArrays bool
+}
+
+// compareContext 是一次比较中不变的状态
+type compareContext struct {
+	form        NormalizationForm
+	arrayKey    string
+	lcs         bool
+	ignoreLeft  map[*Value]bool // 第一个文档中跳过的值
+	ignoreRight map[*Value]bool // 第二个文档中跳过的值
+}
+
+// ignored 判断这对值是否被忽略
+func (ctx *compareContext) ignored(v1, v2 *Value) bool {
+	return ctx.ignoreLeft[v1] || ctx.ignoreRight[v2]
+}
+
+// CompareValuesWithOptions 按选项比较两个JSON文档，按文档顺序返回结构化的差异
+//
+// IgnorePaths 中有无效的路径时返回错误。
+func CompareValuesWithOptions(v1, v2 *Value, options CompareOptions) ([]Difference, error) {
+	ctx := &compareContext{form: options.Normalization, arrayKey: options.ArrayKey, lcs: options.LCSArrays}
+	if len(options.IgnorePaths) > 0 {
+		var err error
+		if ctx.ignoreLeft, err = resolveIgnorePaths(v1, options.IgnorePaths); err != nil {
+			return nil, err
+		}
+		if ctx.ignoreRight, err = resolveIgnorePaths(v2, options.IgnorePaths); err != nil {
+			return nil, err
+		}
+	}
+	differences := []Difference{}
+	compareJSONRecursive(v1, v2, "$", "$", ctx, &differences)
+	return differences, nil
+}
+
+// resolveIgnorePaths 返回 doc 中被 paths 匹配的值
+func resolveIgnorePaths(doc *Value, paths []string) (map[*Value]bool, error) {
+	ignored := make(map[*Value]bool)
+	if doc == nil {
+		return ignored, nil
+	}
+	for _, path := range paths {
+		if strings.HasPrefix(path, "$") {
+			jp, err := NewJSONPath(path)
+			if err != nil {
+				return nil, fmt.Errorf("无效的忽略路径 %s: %v", path, err)
+			}
+			matches, err := jp.Query(doc)
+			if err != nil {
+				return nil, fmt.Errorf("无效的忽略路径 %s: %v", path, err)
+			}
+			for _, v := range matches {
+				ignored[v] = true
+			}
+			continue
+		}
+		pointer, err := NewJSONPointer(path)
+		if err != nil {
+			return nil, fmt.Errorf("无效的忽略路径 %s: 必须是以 $ 开头的JSONPath或以 / 开头的JSON Pointer", path)
+		}
+		if v, _, err := ResolvePointer(doc, pointer); err == nil {
+			ignored[v] = true
+		}
+	}
+	return ignored, nil
+}
+
+// elementKeys 返回数组每个元素的标识键值（紧凑JSON文本），有元素不满足 ArrayKey 的条件时返回 nil
+func elementKeys(elements []*Value, key string) []string {
+	keys := make([]string, len(elements))
+	seen := make(map[string]bool, len(elements))
+	for i, e := range elements {
+		if e == nil || e.Type != OBJECT {
+			return nil
+		}
+		id := findObjectKey(e, key)
+		if id == nil || id.Type == ARRAY || id.Type == OBJECT {
+			return nil
+		}
+		text, err := Stringify(id)
+		if err != STRINGIFY_OK || seen[text] {
+			return nil
+		}
+		seen[text] = true
+		keys[i] = text
+	}
+	return keys
+}
+
+// compareKeyedArrays 按标识键匹配两个数组的元素并比较，数组不能按键匹配时返回 false
+//
+// 两边都有的元素中，不在最长递增子序列（按第二个文档中的下标）上的被报告为移动，
+// 这样插入或删除一个元素不会使后面的所有元素都被视为移动。
+func compareKeyedArrays(v1, v2 *Value, path, rightPath string, ctx *compareContext, differences *[]Difference) bool {
+	leftKeys := elementKeys(v1.A, ctx.arrayKey)
+	rightKeys := elementKeys(v2.A, ctx.arrayKey)
+	if leftKeys == nil || rightKeys == nil {
+		return false
+	}
+	rightIndex := make(map[string]int, len(rightKeys))
+	for j, key := range rightKeys {
+		rightIndex[key] = j
+	}
+
+	// matched[i] 是第一个数组的第 i 个元素在第二个数组中的下标，没有时为 -1
+	matched := make([]int, len(leftKeys))
+	var order []int
+	for i, key := range leftKeys {
+		matched[i] = -1
+		if j, ok := rightIndex[key]; ok {
+			matched[i] = j
+			order = append(order, j)
+		}
+	}
+	stable := longestIncreasing(order)
+
+	label := func(key string) string { return ctx.arrayKey + "=" + key }
+	inLeft := make(map[string]bool, len(leftKeys))
+	for i, key := range leftKeys {
+		inLeft[key] = true
+		left := v1.A[i]
+		leftPath := fmt.Sprintf("%s[%d]", path, i)
+		j := matched[i]
+		if j < 0 {
+			if !ctx.ignoreLeft[left] {
+				*differences = append(*differences, Difference{
+					Path:    leftPath,
+					Kind:    DIFF_REMOVED,
+					Left:    left,
+					Message: fmt.Sprintf("路径 %s: 第一个JSON有元素 %s，但第二个没有", path, label(key)),
+				})
+			}
+			continue
+		}
+		right := v2.A[j]
+		elementRightPath := fmt.Sprintf("%s[%d]", rightPath, j)
+		if ctx.ignored(left, right) {
+			continue
+		}
+		if !stable[j] {
+			*differences = append(*differences, Difference{
+				Path:      leftPath,
+				RightPath: elementRightPath,
+				Kind:      DIFF_MOVED,
+				Left:      left,
+				Right:     right,
+				Message:   fmt.Sprintf("路径 %s: 元素 %s 从下标 %d 移动到 %d", path, label(key), i, j),
+			})
+		}
+		compareJSONRecursive(left, right, leftPath, elementRightPath, ctx, differences)
+	}
+
+	for j, key := range rightKeys {
+		if inLeft[key] || ctx.ignoreRight[v2.A[j]] {
+			continue
+		}
+		*differences = append(*differences, Difference{
+			Path:    fmt.Sprintf("%s[%d]", rightPath, j),
+			Kind:    DIFF_ADDED,
+			Right:   v2.A[j],
+			Message: fmt.Sprintf("路径 %s: 第二个JSON有元素 %s，但第一个没有", rightPath, label(key)),
+		})
+	}
+	return true
+}
+
+// longestIncreasing 返回 values 的一个最长严格递增子序列中包含的值
+func longestIncreasing(values []int) map[int]bool {
+	// tails[k] 是长度为 k+1 的递增子序列的最小结尾在 values 中的下标
+	var tails []int
+	prev := make([]int, len(values))
+	for i, v := range values {
+		k := sort.Search(len(tails), func(k int) bool { return values[tails[k]] >= v })
+		prev[i] = -1
+		if k > 0 {
+			prev[i] = tails[k-1]
+		}
+		if k == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[k] = i
+		}
+	}
+	result := make(map[int]bool, len(tails))
+	if len(tails) > 0 {
+		for i := tails[len(tails)-1]; i >= 0; i = prev[i] {
+			result[values[i]] = true
+		}
+	}
+	return result
+}
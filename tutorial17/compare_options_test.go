@@ -0,0 +1,103 @@
+package leptjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareIgnorePaths(t *testing.T) {
+	left, right := &Value{}, &Value{}
+	if err := Parse(left, `{"id":1,"meta":{"requestId":"a1","at":"10:00"},"items":[{"n":1,"updatedAt":1},{"n":2,"updatedAt":2}],"old":true}`); err != PARSE_OK {
+		t.Fatal(err)
+	}
+	if err := Parse(right, `{"id":1,"meta":{"requestId":"b2","at":"11:00"},"items":[{"n":1,"updatedAt":3},{"n":3,"updatedAt":4}],"new":true}`); err != PARSE_OK {
+		t.Fatal(err)
+	}
+
+	paths := func(options CompareOptions) []string {
+		t.Helper()
+		diffs, err := CompareValuesWithOptions(left, right, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result := []string{}
+		for _, d := range diffs {
+			result = append(result, d.Path)
+		}
+		return result
+	}
+
+	if got, want := paths(CompareOptions{}), []string{"$.meta.requestId", "$.meta.at", "$.items[0].updatedAt", "$.items[1].n", "$.items[1].updatedAt", "$.old", "$.new"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("不忽略 = %q, 期望 %q", got, want)
+	}
+	got := paths(CompareOptions{IgnorePaths: []string{"/meta", "$..updatedAt", "/old", "$.new", "/missing/key"}})
+	if want := []string{"$.items[1].n"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("忽略后 = %q, 期望 %q", got, want)
+	}
+	if got := paths(CompareOptions{IgnorePaths: []string{""}}); len(got) != 0 {
+		t.Errorf("忽略根 = %q", got)
+	}
+
+	for _, path := range []string{"meta", "$["} {
+		if _, err := CompareValuesWithOptions(left, right, CompareOptions{IgnorePaths: []string{path}}); err == nil {
+			t.Errorf("无效路径 %q 应返回错误", path)
+		}
+	}
+}
+
+func TestCompareArrayKey(t *testing.T) {
+	left, right := &Value{}, &Value{}
+	if err := Parse(left, `{"users":[{"id":1,"name":"a"},{"id":2,"name":"b"},{"id":3,"name":"c"},{"id":4,"name":"d"}]}`); err != PARSE_OK {
+		t.Fatal(err)
+	}
+	if err := Parse(right, `{"users":[{"id":5,"name":"e"},{"id":1,"name":"A"},{"id":3,"name":"C"},{"id":4,"name":"d"},{"id":2,"name":"b"}]}`); err != PARSE_OK {
+		t.Fatal(err)
+	}
+	diffs, err := CompareValuesWithOptions(left, right, CompareOptions{ArrayKey: "id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	type summary struct {
+		Kind            DiffKind
+		Path, RightPath string
+	}
+	var got []summary
+	for _, d := range diffs {
+		got = append(got, summary{d.Kind, d.Path, d.RightPath})
+	}
+	// 新增的 id=5 使其他元素的下标都变了，但只有 id=2 改变了相对顺序
+	want := []summary{
+		{DIFF_VALUE_CHANGED, "$.users[0].name", "$.users[1].name"},
+		{DIFF_MOVED, "$.users[1]", "$.users[4]"},
+		{DIFF_VALUE_CHANGED, "$.users[2].name", ""},
+		{DIFF_ADDED, "$.users[0]", ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("按键比较 = %+v, 期望 %+v", got, want)
+	}
+
+	// 元素缺少键或键重复时按下标比较
+	for _, doc := range []string{`[{"id":1},{"name":"x"}]`, `[{"id":1},{"id":1}]`, `[1,2]`} {
+		a, b := &Value{}, &Value{}
+		if err := Parse(a, doc); err != PARSE_OK {
+			t.Fatal(err)
+		}
+		if err := Parse(b, `[{"id":1}]`); err != PARSE_OK {
+			t.Fatal(err)
+		}
+		diffs, _ := CompareValuesWithOptions(a, b, CompareOptions{ArrayKey: "id"})
+		if len(diffs) == 0 || diffs[0].Kind != DIFF_LENGTH_CHANGED {
+			t.Errorf("%s: 应按下标比较, 得到 %+v", doc, diffs)
+		}
+	}
+}
+
+func TestLongestIncreasing(t *testing.T) {
+	got := longestIncreasing([]int{3, 0, 1, 4, 2, 5})
+	if want := map[int]bool{0: true, 1: true, 2: true, 5: true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("longestIncreasing = %v, 期望 %v", got, want)
+	}
+	if got := longestIncreasing(nil); len(got) != 0 {
+		t.Errorf("空序列 = %v", got)
+	}
+}
@@ -0,0 +1,433 @@
+// Package crdt 实现可以合并的JSON文档（实验性）
+//
+// 每个副本独立地修改自己的文档，之后交换状态并用 Merge 合并，无论合并的顺序和次数如何，
+// 所有副本最终得到相同的文档，适合离线编辑后再同步的场景：
+//
+//	a := crdt.New("alice")
+//	a.Set("/title", title)
+//	b, _ := crdt.Load("bob", a.State()) // bob 从 alice 的状态开始
+//	a.Set("/title", t1)                 // 双方离线修改
+//	b.Set("/tags/-", tag)
+//	a.Merge(b.State())                  // 交换状态后两边相同
+//	b.Merge(a.State())
+//
+// 文档的每个值都带有因果元数据，时间戳是 Lamport 时钟加副本名：
+//   - 对象的每个成员是一个“最后写入者获胜”（LWW）寄存器，并发修改同一个键时时间戳大的获胜，
+//     删除也是一次写入，会留下墓碑
+//   - 数组是 RGA（Replicated Growable Array）：每个元素记住插入时位于它前面的元素，
+//     并发插入到同一位置的元素按时间戳排列；删除的元素保留为墓碑，删除优先于并发的替换
+//
+// 根必须是对象。状态和墓碑会随修改不断增长，这是一个教学用的实验实现，没有垃圾回收。
+// Document 不是并发安全的，不能被多个goroutine同时使用。
+package crdt
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	leptjson "github.com/Cactusinhand/go-json-tutorial/tutorial17"
+)
+
+// stamp 是 Lamport 时间戳，计数相同时按副本名排序，所以任何两个不同的写入都有确定的先后
+type stamp struct {
+	counter uint64
+	replica string
+}
+
+// less 判断 s 是否早于 o
+func (s stamp) less(o stamp) bool {
+	if s.counter != o.counter {
+		return s.counter < o.counter
+	}
+	return s.replica < o.replica
+}
+
+// isZero 判断是否是表示数组开头的零时间戳
+func (s stamp) isZero() bool {
+	return s.counter == 0 && s.replica == ""
+}
+
+// String 返回 "计数@副本" 形式的时间戳
+func (s stamp) String() string {
+	return strconv.FormatUint(s.counter, 10) + "@" + s.replica
+}
+
+// parseStamp 解析 "计数@副本" 形式的时间戳
+func parseStamp(s string) (stamp, error) {
+	i := strings.IndexByte(s, '@')
+	if i < 0 {
+		return stamp{}, fmt.Errorf("无效的时间戳: %q", s)
+	}
+	counter, err := strconv.ParseUint(s[:i], 10, 64)
+	if err != nil || counter == 0 {
+		return stamp{}, fmt.Errorf("无效的时间戳: %q", s)
+	}
+	return stamp{counter, s[i+1:]}, nil
+}
+
+type nodeKind int
+
+const (
+	scalarNode nodeKind = iota
+	objectNode
+	arrayNode
+)
+
+// node 是文档中的一个值
+type node struct {
+	kind   nodeKind
+	value  *leptjson.Value   // 标量的值
+	fields map[string]*field // 对象的成员，包括已删除的
+	elems  []*element        // 数组的元素，按 RGA 顺序排列，包括已删除的
+}
+
+// field 是对象成员的 LWW 寄存器
+type field struct {
+	ts   stamp
+	node *node // 为 nil 时成员已被删除
+}
+
+// element 是 RGA 数组中的一个元素
+type element struct {
+	id      stamp // 插入元素的操作，元素的唯一标识
+	after   stamp // 插入时位于它前面的元素，零值表示数组开头
+	ts      stamp // 最后一次设置值的操作
+	node    *node
+	deleted bool
+}
+
+// Document 是一个副本上的可合并文档
+type Document struct {
+	replica string
+	clock   uint64
+	root    *node
+}
+
+// New 创建副本 replica 上的空文档（空对象）
+//
+// 每个副本的名称必须唯一，否则并发的修改可能得到相同的时间戳。
+func New(replica string) *Document {
+	return &Document{replica: replica, root: &node{kind: objectNode, fields: make(map[string]*field)}}
+}
+
+// FromValue 创建内容为 v 的文档，v 必须是对象
+//
+// 其他副本应该用 Load 从这个文档的状态开始，而不是各自调用 FromValue：
+// 分别创建的相同内容有不同的时间戳，合并后数组中的元素会重复。
+func FromValue(replica string, v *leptjson.Value) (*Document, error) {
+	if v == nil || v.Type != leptjson.OBJECT {
+		return nil, fmt.Errorf("文档的根必须是对象")
+	}
+	d := New(replica)
+	d.root = d.build(v)
+	return d, nil
+}
+
+// Replica 返回副本的名称
+func (d *Document) Replica() string {
+	return d.replica
+}
+
+// tick 推进时钟，返回一个新的时间戳
+func (d *Document) tick() stamp {
+	d.clock++
+	return stamp{d.clock, d.replica}
+}
+
+// build 把 v 转换为节点，每个对象成员和数组元素使用新的时间戳
+func (d *Document) build(v *leptjson.Value) *node {
+	switch v.Type {
+	case leptjson.OBJECT:
+		n := &node{kind: objectNode, fields: make(map[string]*field, len(v.O))}
+		for _, m := range v.O {
+			f := &field{ts: d.tick()}
+			f.node = d.build(m.V)
+			n.fields[m.K] = f
+		}
+		return n
+	case leptjson.ARRAY:
+		n := &node{kind: arrayNode}
+		var after stamp
+		for _, e := range v.A {
+			id := d.tick()
+			n.elems = append(n.elems, &element{id: id, after: after, ts: id, node: d.build(e)})
+			after = id
+		}
+		return n
+	default:
+		value := &leptjson.Value{}
+		leptjson.Copy(value, v)
+		return &node{kind: scalarNode, value: value}
+	}
+}
+
+// Value 返回文档当前的内容，对象的键按字典序排列
+func (d *Document) Value() *leptjson.Value {
+	return d.root.toValue()
+}
+
+func (n *node) toValue() *leptjson.Value {
+	v := &leptjson.Value{}
+	switch n.kind {
+	case objectNode:
+		leptjson.SetObject(v)
+		for _, key := range n.liveKeys() {
+			leptjson.Copy(leptjson.SetObjectValue(v, key), n.fields[key].node.toValue())
+		}
+	case arrayNode:
+		visible := n.visible()
+		leptjson.SetArray(v, len(visible))
+		for _, e := range visible {
+			v.A = append(v.A, e.node.toValue())
+		}
+	default:
+		leptjson.Copy(v, n.value)
+	}
+	return v
+}
+
+// liveKeys 返回对象中未删除的键，按字典序排列
+func (n *node) liveKeys() []string {
+	keys := make([]string, 0, len(n.fields))
+	for key, f := range n.fields {
+		if f.node != nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// visible 返回数组中未删除的元素
+func (n *node) visible() []*element {
+	var visible []*element
+	for _, e := range n.elems {
+		if !e.deleted {
+			visible = append(visible, e)
+		}
+	}
+	return visible
+}
+
+// lookup 返回 JSON 指针令牌指向的节点
+func (d *Document) lookup(tokens []string) (*node, error) {
+	n := d.root
+	for i, token := range tokens {
+		switch n.kind {
+		case objectNode:
+			f := n.fields[token]
+			if f == nil || f.node == nil {
+				return nil, fmt.Errorf("路径 %s 不存在", pointerPrefix(tokens, i+1))
+			}
+			n = f.node
+		case arrayNode:
+			visible := n.visible()
+			index, err := arrayIndex(token, len(visible)-1)
+			if err != nil {
+				return nil, fmt.Errorf("路径 %s: %v", pointerPrefix(tokens, i+1), err)
+			}
+			n = visible[index].node
+		default:
+			return nil, fmt.Errorf("路径 %s 不是对象或数组", pointerPrefix(tokens, i))
+		}
+	}
+	return n, nil
+}
+
+// arrayIndex 解析不大于 max 的数组下标
+func arrayIndex(token string, max int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || token != strconv.Itoa(index) {
+		return 0, fmt.Errorf("无效的数组下标 %q", token)
+	}
+	if index > max {
+		return 0, fmt.Errorf("数组下标 %d 超出范围", index)
+	}
+	return index, nil
+}
+
+// pointerPrefix 返回前 n 个令牌组成的 JSON 指针
+func pointerPrefix(tokens []string, n int) string {
+	var sb strings.Builder
+	for _, token := range tokens[:n] {
+		sb.WriteByte('/')
+		sb.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+	return sb.String()
+}
+
+// parent 解析指针，返回父节点和最后一个令牌
+func (d *Document) parent(pointer string) (*node, string, error) {
+	p, err := leptjson.NewJSONPointer(pointer)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(p.Tokens) == 0 {
+		return nil, "", fmt.Errorf("不能替换或删除根对象")
+	}
+	n := len(p.Tokens) - 1
+	parent, err := d.lookup(p.Tokens[:n])
+	if err != nil {
+		return nil, "", err
+	}
+	if parent.kind == scalarNode {
+		return nil, "", fmt.Errorf("路径 %s 不是对象或数组", pointerPrefix(p.Tokens, n))
+	}
+	return parent, p.Tokens[n], nil
+}
+
+// Set 根据 JSON 指针设置值：对象的键存在时替换、不存在时添加，
+// 数组下标处插入元素（"-" 表示追加），与 leptjson.Document.Set 相同
+func (d *Document) Set(pointer string, v *leptjson.Value) error {
+	parent, token, err := d.parent(pointer)
+	if err != nil {
+		return err
+	}
+	if parent.kind == objectNode {
+		f := &field{ts: d.tick()}
+		f.node = d.build(v)
+		parent.fields[token] = f
+		return nil
+	}
+
+	visible := parent.visible()
+	index := len(visible)
+	if token != "-" {
+		if index, err = arrayIndex(token, len(visible)); err != nil {
+			return err
+		}
+	}
+	e := &element{id: d.tick()}
+	e.ts = e.id
+	if index > 0 {
+		e.after = visible[index-1].id
+	}
+	e.node = d.build(v)
+	parent.elems = integrate(parent.elems, e)
+	return nil
+}
+
+// Replace 替换已经存在的对象成员或数组元素
+func (d *Document) Replace(pointer string, v *leptjson.Value) error {
+	parent, token, err := d.parent(pointer)
+	if err != nil {
+		return err
+	}
+	if parent.kind == objectNode {
+		if f := parent.fields[token]; f == nil || f.node == nil {
+			return fmt.Errorf("路径 %s 不存在", pointer)
+		}
+		return d.Set(pointer, v)
+	}
+	visible := parent.visible()
+	index, err := arrayIndex(token, len(visible)-1)
+	if err != nil {
+		return err
+	}
+	e := visible[index]
+	e.ts = d.tick()
+	e.node = d.build(v)
+	return nil
+}
+
+// Remove 删除对象成员或数组元素
+func (d *Document) Remove(pointer string) error {
+	parent, token, err := d.parent(pointer)
+	if err != nil {
+		return err
+	}
+	if parent.kind == objectNode {
+		if f := parent.fields[token]; f == nil || f.node == nil {
+			return fmt.Errorf("路径 %s 不存在", pointer)
+		}
+		parent.fields[token] = &field{ts: d.tick()}
+		return nil
+	}
+	visible := parent.visible()
+	index, err := arrayIndex(token, len(visible)-1)
+	if err != nil {
+		return err
+	}
+	visible[index].deleted = true
+	return nil
+}
+
+// integrate 按 RGA 的规则把元素插入到有序的元素列表中
+//
+// 元素位于 after 之后；after 之后已经有时间戳更大的元素（并发插入到同一位置的元素，
+// 或者在它们后面插入的元素）时，新元素排在它们后面。调用者保证 after 在列表中。
+func integrate(elems []*element, e *element) []*element {
+	pos := 0
+	if !e.after.isZero() {
+		for i, x := range elems {
+			if x.id == e.after {
+				pos = i + 1
+				break
+			}
+		}
+	}
+	for pos < len(elems) && e.id.less(elems[pos].id) {
+		pos++
+	}
+	elems = append(elems, nil)
+	copy(elems[pos+1:], elems[pos:])
+	elems[pos] = e
+	return elems
+}
+
+// Merge 合并另一个副本的状态（State 的结果），合并后包含双方的所有修改
+//
+// 合并满足交换律、结合律和幂等律，所以副本可以按任意顺序、任意次数交换状态。
+// 状态无效时返回错误，文档保持不变。
+func (d *Document) Merge(remoteState *leptjson.Value) error {
+	remote, err := Load(d.replica, remoteState)
+	if err != nil {
+		return err
+	}
+	mergeNode(d.root, remote.root)
+	if remote.clock > d.clock {
+		d.clock = remote.clock
+	}
+	return nil
+}
+
+// mergeNode 把同一次写入产生的远程节点合并到本地节点
+func mergeNode(local, remote *node) {
+	if local.kind != remote.kind {
+		return
+	}
+	switch local.kind {
+	case objectNode:
+		for key, rf := range remote.fields {
+			lf := local.fields[key]
+			switch {
+			case lf == nil || lf.ts.less(rf.ts):
+				local.fields[key] = rf
+			case lf.ts == rf.ts && lf.node != nil && rf.node != nil:
+				mergeNode(lf.node, rf.node)
+			}
+		}
+	case arrayNode:
+		byID := make(map[stamp]*element, len(local.elems))
+		for _, e := range local.elems {
+			byID[e.id] = e
+		}
+		// 远程列表中每个元素都排在它的 after 之后，所以按顺序插入时 after 总是已经存在
+		for _, re := range remote.elems {
+			le := byID[re.id]
+			if le == nil {
+				local.elems = integrate(local.elems, re)
+				byID[re.id] = re
+				continue
+			}
+			le.deleted = le.deleted || re.deleted
+			if le.ts.less(re.ts) {
+				le.ts, le.node = re.ts, re.node
+			} else if le.ts == re.ts {
+				mergeNode(le.node, re.node)
+			}
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package crdt
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	leptjson "github.com/Cactusinhand/go-json-tutorial/tutorial17"
+)
+
+func parse(t *testing.T, json string) *leptjson.Value {
+	t.Helper()
+	v := &leptjson.Value{}
+	if err := leptjson.Parse(v, json); err != leptjson.PARSE_OK {
+		t.Fatalf("解析 %s 失败: %v", json, err)
+	}
+	return v
+}
+
+func stringify(v *leptjson.Value) string {
+	s, _ := leptjson.Stringify(v)
+	return s
+}
+
+// sync 让两个副本交换状态
+func sync(t *testing.T, a, b *Document) {
+	t.Helper()
+	if err := a.Merge(b.State()); err != nil {
+		t.Fatalf("合并 %s 的状态失败: %v", b.Replica(), err)
+	}
+	if err := b.Merge(a.State()); err != nil {
+		t.Fatalf("合并 %s 的状态失败: %v", a.Replica(), err)
+	}
+}
+
+func TestOfflineEditsConverge(t *testing.T) {
+	a, err := FromValue("alice", parse(t, `{"title": "Draft", "tags": ["x", "y"], "meta": {"n": 1}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Load("bob", a.State())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 双方离线修改
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(a.Set("/title", parse(t, `"Alice's title"`)))
+	must(a.Set("/tags/1", parse(t, `"a"`)))
+	must(a.Set("/meta/n", parse(t, `2`)))
+	must(a.Remove("/tags/0"))
+	must(b.Set("/title", parse(t, `"Bob's title"`)))
+	must(b.Set("/tags/1", parse(t, `"b"`)))
+	must(b.Replace("/tags/0", parse(t, `"X"`)))
+	must(b.Set("/meta/m", parse(t, `true`)))
+	must(b.Remove("/meta/n"))
+
+	sync(t, a, b)
+	got := stringify(a.Value())
+	if other := stringify(b.Value()); got != other {
+		t.Fatalf("合并后不一致: %s 和 %s", got, other)
+	}
+	// 时间戳计数相同时副本名大的获胜，b 删除 n 的时钟更大；删除优先于并发的替换；
+	// 同一位置的并发插入按时间戳排列
+	expected := `{"meta":{"m":true},"tags":["b","a","y"],"title":"Bob's title"}`
+	if got != expected {
+		t.Errorf("合并结果 = %s, 期望 %s", got, expected)
+	}
+}
+
+func TestLastWriterWins(t *testing.T) {
+	a := New("a")
+	a.Set("/k", parse(t, `1`))
+	b, _ := Load("b", a.State())
+	b.Set("/k", parse(t, `2`))
+	b.Set("/k", parse(t, `3`)) // b 的时钟更大，获胜
+	a.Set("/k", parse(t, `4`))
+	sync(t, a, b)
+	if got := stringify(a.Value()); got != `{"k":3}` {
+		t.Errorf("合并结果 = %s", got)
+	}
+
+	// 删除之后的写入获胜
+	a.Remove("/k")
+	b.Merge(a.State())
+	b.Set("/k", parse(t, `{"x": [1]}`))
+	a.Merge(b.State())
+	if got := stringify(a.Value()); got != `{"k":{"x":[1]}}` {
+		t.Errorf("合并结果 = %s", got)
+	}
+}
+
+func TestNestedEditsMerge(t *testing.T) {
+	a := New("a")
+	a.Set("/doc", parse(t, `{"items": []}`))
+	b, _ := Load("b", a.State())
+	a.Set("/doc/items/-", parse(t, `{"id": 1}`))
+	b.Set("/doc/items/-", parse(t, `{"id": 2}`))
+	b.Set("/doc/owner", parse(t, `"bob"`))
+	sync(t, a, b)
+	a.Set("/doc/items/0/done", parse(t, `true`))
+	b.Set("/doc/items/1/done", parse(t, `false`))
+	sync(t, a, b)
+	expected := `{"doc":{"items":[{"done":true,"id":2},{"done":false,"id":1}],"owner":"bob"}}`
+	if got := stringify(a.Value()); got != expected || stringify(b.Value()) != expected {
+		t.Errorf("合并结果 = %s 和 %s, 期望 %s", got, stringify(b.Value()), expected)
+	}
+}
+
+func TestMergeIdempotent(t *testing.T) {
+	a := New("a")
+	a.Set("/list", parse(t, `[1, 2, 3]`))
+	state := a.State()
+	b, _ := Load("b", state)
+	b.Merge(state)
+	b.Merge(b.State())
+	if stringify(b.State().O[2].V) != stringify(state.O[2].V) {
+		t.Errorf("重复合并改变了状态: %s", stringify(b.State()))
+	}
+}
+
+func TestRandomEditsConverge(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	base := New("r0")
+	base.Set("/list", parse(t, `[]`))
+	base.Set("/obj", parse(t, `{}`))
+	replicas := []*Document{base}
+	for i := 1; i < 3; i++ {
+		d, _ := Load(fmt.Sprintf("r%d", i), base.State())
+		replicas = append(replicas, d)
+	}
+	keys := []string{"a", "b", "c"}
+	for step := 0; step < 500; step++ {
+		d := replicas[r.Intn(len(replicas))]
+		list := d.Value().O[0].V
+		n := len(list.A)
+		value := parse(t, fmt.Sprintf(`%d`, step))
+		switch r.Intn(6) {
+		case 0, 1:
+			d.Set(fmt.Sprintf("/list/%d", r.Intn(n+1)), value)
+		case 2:
+			if n > 0 {
+				d.Remove(fmt.Sprintf("/list/%d", r.Intn(n)))
+			}
+		case 3:
+			if n > 0 {
+				d.Replace(fmt.Sprintf("/list/%d", r.Intn(n)), value)
+			}
+		case 4:
+			d.Set("/obj/"+keys[r.Intn(len(keys))], value)
+		case 5:
+			d.Remove("/obj/" + keys[r.Intn(len(keys))])
+		}
+		if r.Intn(10) == 0 {
+			sync(t, replicas[r.Intn(len(replicas))], replicas[r.Intn(len(replicas))])
+		}
+	}
+	// 按不同的顺序把所有状态合并到一起
+	for i := range replicas {
+		for j := range replicas {
+			if i != j {
+				replicas[i].Merge(replicas[j].State())
+			}
+		}
+	}
+	for i := range replicas {
+		replicas[i].Merge(replicas[len(replicas)-1].State())
+	}
+	expected := stringify(replicas[0].Value())
+	for _, d := range replicas[1:] {
+		if got := stringify(d.Value()); got != expected {
+			t.Fatalf("副本 %s 与 r0 不一致:\n%s\n%s", d.Replica(), got, expected)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	d := New("a")
+	d.Set("/n", parse(t, `1`))
+	d.Set("/list", parse(t, `[1]`))
+	for _, err := range []error{
+		d.Set("", parse(t, `{}`)),
+		d.Set("/missing/x", parse(t, `1`)),
+		d.Set("/n/x", parse(t, `1`)),
+		d.Set("/list/5", parse(t, `1`)),
+		d.Replace("/missing", parse(t, `1`)),
+		d.Replace("/list/1", parse(t, `1`)),
+		d.Remove("/list/01"),
+		d.Remove("/missing"),
+	} {
+		if err == nil {
+			t.Errorf("期望返回错误")
+		}
+	}
+	if _, err := FromValue("a", parse(t, `[1]`)); err == nil {
+		t.Errorf("根不是对象时应该返回错误")
+	}
+
+	for _, state := range []string{
+		`[]`,
+		`{"clock": -1, "root": {"object": {}}}`,
+		`{"clock": 1, "root": {"array": []}}`,
+		`{"clock": 1, "root": {"object": {"k": {"ts": "x"}}}}`,
+		`{"clock": 2, "root": {"object": {"k": {"ts": "1@a", "node": {"array": [{"id": "2@a", "after": "1@b", "ts": "2@a", "node": {"value": 1}}]}}}}}`,
+		`{"clock": 2, "root": {"object": {"k": {"ts": "1@a", "node": {"value": [1]}}}}}`,
+	} {
+		if err := d.Merge(parse(t, state)); err == nil {
+			t.Errorf("无效的状态 %s 应该返回错误", state)
+		}
+	}
+	if got := stringify(d.Value()); got != `{"list":[1],"n":1}` {
+		t.Errorf("合并失败后文档改变: %s", got)
+	}
+}
@@ -0,0 +1,192 @@
+// state.go - 文档状态与JSON之间的转换
+package crdt
+
+import (
+	"fmt"
+	"sort"
+
+	leptjson "github.com/Cactusinhand/go-json-tutorial/tutorial17"
+)
+
+// State 返回包含全部元数据（时间戳和墓碑）的文档状态，可以序列化后发送给其他副本
+//
+// 状态的结构为：
+//
+//	{"replica": "alice", "clock": 12, "root": 节点}
+//
+// 节点是 {"value": 标量}、{"object": {"键": {"ts": "3@alice", "node": 节点}}}
+// 或 {"array": [{"id": "4@alice", "after": "2@bob", "ts": "4@alice", "node": 节点}]}，
+// 删除的成员没有 node，删除的元素带有 "deleted": true，数组开头的元素没有 after。
+func (d *Document) State() *leptjson.Value {
+	state := &leptjson.Value{}
+	leptjson.SetObject(state)
+	leptjson.SetString(leptjson.SetObjectValue(state, "replica"), d.replica)
+	leptjson.SetNumber(leptjson.SetObjectValue(state, "clock"), float64(d.clock))
+	leptjson.Copy(leptjson.SetObjectValue(state, "root"), d.root.state())
+	return state
+}
+
+func (n *node) state() *leptjson.Value {
+	v := &leptjson.Value{}
+	leptjson.SetObject(v)
+	switch n.kind {
+	case objectNode:
+		fields := leptjson.SetObjectValue(v, "object")
+		leptjson.SetObject(fields)
+		keys := make([]string, 0, len(n.fields))
+		for key := range n.fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			f := n.fields[key]
+			fv := leptjson.SetObjectValue(fields, key)
+			leptjson.SetObject(fv)
+			leptjson.SetString(leptjson.SetObjectValue(fv, "ts"), f.ts.String())
+			if f.node != nil {
+				leptjson.Copy(leptjson.SetObjectValue(fv, "node"), f.node.state())
+			}
+		}
+	case arrayNode:
+		elems := leptjson.SetObjectValue(v, "array")
+		leptjson.SetArray(elems, len(n.elems))
+		for _, e := range n.elems {
+			ev := &leptjson.Value{}
+			leptjson.SetObject(ev)
+			leptjson.SetString(leptjson.SetObjectValue(ev, "id"), e.id.String())
+			if !e.after.isZero() {
+				leptjson.SetString(leptjson.SetObjectValue(ev, "after"), e.after.String())
+			}
+			leptjson.SetString(leptjson.SetObjectValue(ev, "ts"), e.ts.String())
+			if e.deleted {
+				leptjson.SetBoolean(leptjson.SetObjectValue(ev, "deleted"), true)
+			}
+			leptjson.Copy(leptjson.SetObjectValue(ev, "node"), e.node.state())
+			elems.A = append(elems.A, ev)
+		}
+	default:
+		leptjson.Copy(leptjson.SetObjectValue(v, "value"), n.value)
+	}
+	return v
+}
+
+// Load 从其他副本的状态创建副本 replica 上的文档
+func Load(replica string, state *leptjson.Value) (*Document, error) {
+	if state == nil || state.Type != leptjson.OBJECT {
+		return nil, fmt.Errorf("状态必须是对象")
+	}
+	clock := leptjson.GetObjectValueByKey(state, "clock")
+	if clock == nil || clock.Type != leptjson.NUMBER || clock.N < 0 || clock.N != float64(uint64(clock.N)) {
+		return nil, fmt.Errorf("状态的 clock 必须是非负整数")
+	}
+	rootState := leptjson.GetObjectValueByKey(state, "root")
+	if rootState == nil {
+		return nil, fmt.Errorf("状态缺少 root")
+	}
+	root, err := loadNode(rootState, "root")
+	if err != nil {
+		return nil, err
+	}
+	if root.kind != objectNode {
+		return nil, fmt.Errorf("文档的根必须是对象")
+	}
+	return &Document{replica: replica, clock: uint64(clock.N), root: root}, nil
+}
+
+// loadNode 解析节点的状态，path 用于错误信息
+func loadNode(v *leptjson.Value, path string) (*node, error) {
+	if v.Type != leptjson.OBJECT || len(v.O) != 1 {
+		return nil, fmt.Errorf("%s: 节点必须是只有 value、object 或 array 一个成员的对象", path)
+	}
+	m := v.O[0]
+	switch m.K {
+	case "value":
+		if m.V.Type == leptjson.OBJECT || m.V.Type == leptjson.ARRAY {
+			return nil, fmt.Errorf("%s: value 必须是标量", path)
+		}
+		value := &leptjson.Value{}
+		leptjson.Copy(value, m.V)
+		return &node{kind: scalarNode, value: value}, nil
+
+	case "object":
+		if m.V.Type != leptjson.OBJECT {
+			return nil, fmt.Errorf("%s: object 必须是对象", path)
+		}
+		n := &node{kind: objectNode, fields: make(map[string]*field, len(m.V.O))}
+		for _, fm := range m.V.O {
+			fieldPath := path + "." + fm.K
+			ts, err := loadStamp(fm.V, "ts", fieldPath)
+			if err != nil {
+				return nil, err
+			}
+			f := &field{ts: ts}
+			if child := leptjson.GetObjectValueByKey(fm.V, "node"); child != nil {
+				if f.node, err = loadNode(child, fieldPath); err != nil {
+					return nil, err
+				}
+			}
+			n.fields[fm.K] = f
+		}
+		return n, nil
+
+	case "array":
+		if m.V.Type != leptjson.ARRAY {
+			return nil, fmt.Errorf("%s: array 必须是数组", path)
+		}
+		n := &node{kind: arrayNode}
+		seen := make(map[stamp]bool, len(m.V.A))
+		for i, ev := range m.V.A {
+			elemPath := fmt.Sprintf("%s[%d]", path, i)
+			if ev.Type != leptjson.OBJECT {
+				return nil, fmt.Errorf("%s: 元素必须是对象", elemPath)
+			}
+			e := &element{}
+			var err error
+			if e.id, err = loadStamp(ev, "id", elemPath); err != nil {
+				return nil, err
+			}
+			if e.ts, err = loadStamp(ev, "ts", elemPath); err != nil {
+				return nil, err
+			}
+			if leptjson.GetObjectValueByKey(ev, "after") != nil {
+				if e.after, err = loadStamp(ev, "after", elemPath); err != nil {
+					return nil, err
+				}
+				// Merge 依赖 after 排在元素之前
+				if !seen[e.after] {
+					return nil, fmt.Errorf("%s: after %s 不在元素之前", elemPath, e.after)
+				}
+			}
+			if seen[e.id] {
+				return nil, fmt.Errorf("%s: 重复的 id %s", elemPath, e.id)
+			}
+			seen[e.id] = true
+			if deleted := leptjson.GetObjectValueByKey(ev, "deleted"); deleted != nil {
+				e.deleted = deleted.Type == leptjson.TRUE
+			}
+			child := leptjson.GetObjectValueByKey(ev, "node")
+			if child == nil {
+				return nil, fmt.Errorf("%s: 缺少 node", elemPath)
+			}
+			if e.node, err = loadNode(child, elemPath); err != nil {
+				return nil, err
+			}
+			n.elems = append(n.elems, e)
+		}
+		return n, nil
+	}
+	return nil, fmt.Errorf("%s: 未知的节点类型 %q", path, m.K)
+}
+
+// loadStamp 读取对象 v 中名为 key 的时间戳
+func loadStamp(v *leptjson.Value, key, path string) (stamp, error) {
+	s := leptjson.GetObjectValueByKey(v, key)
+	if s == nil || s.Type != leptjson.STRING {
+		return stamp{}, fmt.Errorf("%s: 缺少时间戳 %s", path, key)
+	}
+	ts, err := parseStamp(leptjson.GetString(s))
+	if err != nil {
+		return stamp{}, fmt.Errorf("%s: %v", path, err)
+	}
+	return ts, nil
+}
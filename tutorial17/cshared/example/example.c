@@ -0,0 +1,50 @@
+// example.c - 调用 libleptjson 的示例，也是 make cshared-smoke 使用的冒烟测试
+//
+// cc -o example example.c -I DIR -L DIR -lleptjson && LD_LIBRARY_PATH=DIR ./example（DIR 是共享库所在目录）
+#include <stdio.h>
+#include <string.h>
+#include "libleptjson.h"
+
+static int failures = 0;
+
+static void expect(int ok, const char *what, const char *result) {
+	if (!ok) {
+		fprintf(stderr, "失败: %s (result = %s)\n", what, result ? result : "NULL");
+		failures++;
+	}
+}
+
+int main(void) {
+	char *result = NULL;
+	int code;
+
+	code = ParseJSON("{ \"a\" : [1, 2] }", &result);
+	expect(code == LEPTJSON_OK && strcmp(result, "{\"a\":[1,2]}") == 0, "ParseJSON", result);
+	LeptjsonFree(result);
+
+	code = ParseJSON("[1,", &result);
+	expect(code == LEPTJSON_PARSE_ERROR && strlen(result) > 0, "ParseJSON 无效输入", result);
+	LeptjsonFree(result);
+
+	code = FormatJSON("{\"a\":1}", 2, &result);
+	expect(code == LEPTJSON_OK && strcmp(result, "{\n  \"a\": 1\n}") == 0, "FormatJSON", result);
+	LeptjsonFree(result);
+
+	const char *schema = "{\"type\":\"object\",\"required\":[\"name\"]}";
+	code = ValidateJSON((char *)schema, "{\"name\":\"x\"}", NULL, &result);
+	expect(code == LEPTJSON_OK, "ValidateJSON 有效文档", result);
+	LeptjsonFree(result);
+
+	code = ValidateJSON((char *)schema, "{}", "en", &result);
+	expect(code == LEPTJSON_INVALID && strstr(result, "\"keyword\":\"required\"") != NULL, "ValidateJSON 无效文档", result);
+	LeptjsonFree(result);
+
+	code = ValidateJSON("{", "{}", NULL, &result);
+	expect(code == LEPTJSON_SCHEMA_ERROR, "ValidateJSON 无效Schema", result);
+	LeptjsonFree(result);
+
+	if (failures == 0) {
+		printf("libleptjson smoke test: ok\n");
+	}
+	return failures == 0 ? 0 : 1;
+}
@@ -0,0 +1,132 @@
+// Command cshared 以 C ABI 导出解析、格式化和验证函数，用 -buildmode=c-shared 构建为共享库
+//
+//	go build -buildmode=c-shared -o libleptjson.so ./tutorial17/cshared
+//
+// 同时生成的 libleptjson.h 声明了下面的函数和返回码。所有输入都是以 NUL 结尾的
+// UTF-8 字符串；*result 总会被设置为新分配的字符串（成功时为结果，失败时为错误描述），
+// 调用者用完后必须通过 LeptjsonFree 释放。函数可以在多个线程中同时调用。
+package main
+
+/*
+#include <stdlib.h>
+
+enum {
+	LEPTJSON_OK = 0,            // 成功，ValidateJSON 中表示文档符合Schema
+	LEPTJSON_INVALID = 1,       // 文档不符合Schema，*result 是验证错误的JSON数组
+	LEPTJSON_PARSE_ERROR = 2,   // 输入不是有效的JSON
+	LEPTJSON_SCHEMA_ERROR = 3,  // Schema不是有效的JSON，或者其中的 $ref 无法解析
+	LEPTJSON_ARGUMENT_ERROR = 4 // 参数为 NULL 或超出范围
+};
+*/
+import "C"
+
+import (
+	"encoding/json"
+	"strings"
+	"unsafe"
+
+	leptjson "github.com/Cactusinhand/go-json-tutorial/tutorial17"
+)
+
+// setResult 把 s 复制到C堆上并写入 *result
+func setResult(result **C.char, s string) {
+	if result != nil {
+		*result = C.CString(s)
+	}
+}
+
+// parseInput 把C字符串解析为JSON值
+func parseInput(input *C.char) (*leptjson.Value, string) {
+	v := &leptjson.Value{}
+	if err := leptjson.Parse(v, C.GoString(input)); err != leptjson.PARSE_OK {
+		return nil, err.Error()
+	}
+	return v, ""
+}
+
+// ParseJSON 解析JSON文本，成功时 *result 为紧凑格式的JSON
+//
+//export ParseJSON
+func ParseJSON(input *C.char, result **C.char) C.int {
+	return FormatJSON(input, 0, result)
+}
+
+// FormatJSON 以 indent 个空格缩进格式化JSON文本，indent 为 0 时输出紧凑格式
+//
+//export FormatJSON
+func FormatJSON(input *C.char, indent C.int, result **C.char) C.int {
+	if input == nil || indent < 0 || indent > 16 {
+		setResult(result, "参数无效")
+		return C.LEPTJSON_ARGUMENT_ERROR
+	}
+	v, errMsg := parseInput(input)
+	if v == nil {
+		setResult(result, errMsg)
+		return C.LEPTJSON_PARSE_ERROR
+	}
+	text, err := leptjson.StringifyWithOptions(v, leptjson.StringifyOptions{Indent: strings.Repeat(" ", int(indent))})
+	if err != leptjson.STRINGIFY_OK {
+		setResult(result, err.Error())
+		return C.LEPTJSON_PARSE_ERROR
+	}
+	setResult(result, text)
+	return C.LEPTJSON_OK
+}
+
+// ValidateJSON 用JSON Schema验证文档，lang 为错误描述的语言（"zh" 或 "en"），可以为 NULL
+//
+// 返回 LEPTJSON_INVALID 时 *result 是 [{"path": ..., "keyword": ..., "message": ...}] 形式的JSON数组。
+//
+//export ValidateJSON
+func ValidateJSON(schema, input, lang *C.char, result **C.char) C.int {
+	if schema == nil || input == nil {
+		setResult(result, "参数无效")
+		return C.LEPTJSON_ARGUMENT_ERROR
+	}
+	language := leptjson.DefaultValidationLanguage
+	if lang != nil {
+		language = C.GoString(lang)
+	}
+	translator, ok := leptjson.LookupValidationTranslator(language)
+	if !ok {
+		setResult(result, "未知的语言: "+language)
+		return C.LEPTJSON_ARGUMENT_ERROR
+	}
+
+	s, errMsg := parseInput(schema)
+	if s == nil {
+		setResult(result, errMsg)
+		return C.LEPTJSON_SCHEMA_ERROR
+	}
+	data, errMsg := parseInput(input)
+	if data == nil {
+		setResult(result, errMsg)
+		return C.LEPTJSON_PARSE_ERROR
+	}
+	validation, err := leptjson.ValidateWithOptions(s, data, leptjson.ValidationOptions{Translator: translator})
+	if err != nil {
+		setResult(result, err.Error())
+		return C.LEPTJSON_SCHEMA_ERROR
+	}
+	if validation.Valid {
+		setResult(result, "")
+		return C.LEPTJSON_OK
+	}
+	issues, merr := json.Marshal(validation.Issues)
+	if merr != nil {
+		setResult(result, merr.Error())
+		return C.LEPTJSON_SCHEMA_ERROR
+	}
+	setResult(result, string(issues))
+	return C.LEPTJSON_INVALID
+}
+
+// LeptjsonFree 释放其他函数通过 result 返回的字符串
+//
+//export LeptjsonFree
+func LeptjsonFree(s *C.char) {
+	C.free(unsafe.Pointer(s))
+}
+
+// c-shared 构建模式要求有 main 函数，但它不会被调用
+func main() {}
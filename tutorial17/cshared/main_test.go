@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// TestCSharedExample 构建共享库并运行 example/example.c，检查导出的C接口
+func TestCSharedExample(t *testing.T) {
+	if testing.Short() {
+		t.Skip("构建共享库较慢")
+	}
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("只在 Linux 和 macOS 上测试")
+	}
+	cc := os.Getenv("CC")
+	if cc == "" {
+		cc = "cc"
+	}
+	if _, err := exec.LookPath(cc); err != nil {
+		t.Skip("没有C编译器")
+	}
+
+	dir := t.TempDir()
+	run := func(name string, args ...string) {
+		t.Helper()
+		cmd := exec.Command(name, args...)
+		cmd.Env = append(os.Environ(), "CGO_ENABLED=1", "LD_LIBRARY_PATH="+dir, "DYLD_LIBRARY_PATH="+dir)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%s %v: %v\n%s", name, args, err, out)
+		}
+	}
+	run("go", "build", "-buildmode=c-shared", "-o", filepath.Join(dir, "libleptjson.so"), ".")
+	example := filepath.Join(dir, "example")
+	run(cc, "-o", example, filepath.Join("example", "example.c"), "-I", dir, "-L", dir, "-lleptjson")
+	run(example)
+}
@@ -0,0 +1,224 @@
+// dedup.go - 查找重复的子树，并可以把重复的子树替换为 $ref 引用
+package leptjson
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DuplicateOptions 控制 FindDuplicates 和 Deduplicate 处理哪些子树
+type DuplicateOptions struct {
+	MinSize int // 只处理紧凑序列化后至少这么多字节的数组和对象，不大于 0 时处理所有非空的数组和对象
+}
+
+// DuplicateGroup 是文档中相等的一组子树
+type DuplicateGroup struct {
+	Count   int    `json:"count"`   // 出现的次数
+	Size    int    `json:"size"`    // 每一份紧凑序列化后的字节数
+	Saving  int    `json:"saving"`  // 把重复替换为 $ref 引用可以节省的字节数
+	Example string `json:"example"` // 第一次出现的路径，如 $.items[0].vendor
+	Value   *Value `json:"-"`       // 第一次出现的子树
+}
+
+// DuplicateReport 是 FindDuplicates 的结果
+type DuplicateReport struct {
+	TotalSize int              `json:"totalSize"` // 整个文档紧凑序列化后的字节数
+	Saving    int              `json:"saving"`    // Deduplicate 可以节省的字节数
+	Groups    []DuplicateGroup `json:"groups"`    // 按 Saving 从大到小排列
+}
+
+// subtreeHash 是子树内容的摘要，对象的键顺序不影响摘要，与 Equal 的语义一致
+type subtreeHash [sha256.Size]byte
+
+// subtreeNode 是遍历时记录的一个数组或对象
+type subtreeNode struct {
+	value   *Value
+	path    string // JSONPath 形式的路径，用于报告
+	pointer string // JSON Pointer，用于引用
+	hash    subtreeHash
+	size    int // 紧凑序列化后的字节数
+	end     int // 子树中最后一个节点之后的下标，节点按先序排列
+}
+
+// subtreeIndex 是文档中所有数组和对象的摘要
+type subtreeIndex struct {
+	nodes  []subtreeNode // 按文档顺序（先序）排列
+	counts map[subtreeHash]int
+	size   int // 根的字节数
+}
+
+// indexSubtrees 计算 v 中每个数组和对象的摘要和紧凑序列化的字节数
+func indexSubtrees(v *Value) *subtreeIndex {
+	index := &subtreeIndex{counts: make(map[subtreeHash]int)}
+	_, index.size = index.add(v, "$", "")
+	return index
+}
+
+// add 记录 v 及其中的数组和对象，返回 v 的摘要和字节数
+func (index *subtreeIndex) add(v *Value, path, pointer string) (subtreeHash, int) {
+	if v.Type != ARRAY && v.Type != OBJECT {
+		text, _ := Stringify(v)
+		return sha256.Sum256([]byte(text)), len(text)
+	}
+
+	self := len(index.nodes)
+	index.nodes = append(index.nodes, subtreeNode{value: v, path: path, pointer: pointer})
+	h := sha256.New()
+	size := 2
+	if v.Type == ARRAY {
+		h.Write([]byte{'['})
+		for i, e := range v.A {
+			childHash, childSize := index.add(e, fmt.Sprintf("%s[%d]", path, i), fmt.Sprintf("%s/%d", pointer, i))
+			h.Write(childHash[:])
+			size += childSize
+		}
+		if len(v.A) > 1 {
+			size += len(v.A) - 1
+		}
+	} else {
+		type member struct {
+			key  string
+			hash subtreeHash
+		}
+		members := make([]member, len(v.O))
+		for i, m := range v.O {
+			childHash, childSize := index.add(m.V, path+"."+m.K, pointer+"/"+escapeJSONPointerToken(m.K))
+			keyText, _ := Stringify(&Value{Type: STRING, S: m.K})
+			members[i] = member{keyText, childHash}
+			size += len(keyText) + 1 + childSize
+		}
+		if len(v.O) > 1 {
+			size += len(v.O) - 1
+		}
+		sort.Slice(members, func(i, j int) bool { return members[i].key < members[j].key })
+		h.Write([]byte{'{'})
+		for _, m := range members {
+			h.Write([]byte(m.key))
+			h.Write(m.hash[:])
+		}
+	}
+
+	node := &index.nodes[self]
+	copy(node.hash[:], h.Sum(nil))
+	node.size = size
+	node.end = len(index.nodes)
+	index.counts[node.hash]++
+	return node.hash, size
+}
+
+// replacements 按文档顺序找出去重时要替换的子树，对每一处调用 fn(被替换的节点, 保留的节点)
+//
+// 第一次出现的子树保留，之后的每一次出现都被替换，除非引用比子树本身还长；
+// 被替换的子树中的内容不再单独处理，所以保留的节点总是在结果中存在。
+func (index *subtreeIndex) replacements(options DuplicateOptions, fn func(node, kept *subtreeNode)) {
+	kept := make(map[subtreeHash]*subtreeNode)
+	for i := 0; i < len(index.nodes); i++ {
+		node := &index.nodes[i]
+		if index.counts[node.hash] < 2 || node.size < options.MinSize ||
+			(len(node.value.A) == 0 && len(node.value.O) == 0) {
+			continue
+		}
+		first, ok := kept[node.hash]
+		if !ok {
+			kept[node.hash] = node
+			continue
+		}
+		// 引用比子树本身还长时不替换
+		if node.size > refSize(first.pointer) {
+			fn(node, first)
+			i = node.end - 1
+		}
+	}
+}
+
+// refSize 返回指向 pointer 的 $ref 对象紧凑序列化后的字节数
+func refSize(pointer string) int {
+	text, _ := Stringify(&Value{Type: STRING, S: "#" + pointer})
+	return len(`{"$ref":}`) + len(text)
+}
+
+// FindDuplicates 找出 v 中重复出现的数组和对象
+//
+// 相等按 Equal 的语义判断，对象的键顺序不同也算重复。Saving 是 Deduplicate 实际节省的字节数，
+// 所以一个子树的所有重复都位于另一组重复之内时（如重复的 vendor 对象中的 address），
+// 它不单独报告，因为去掉外层的重复就去掉了它。
+func FindDuplicates(v *Value, options DuplicateOptions) *DuplicateReport {
+	report := &DuplicateReport{Groups: []DuplicateGroup{}}
+	if v == nil {
+		return report
+	}
+	index := indexSubtrees(v)
+	report.TotalSize = index.size
+
+	groups := make(map[subtreeHash]int)
+	index.replacements(options, func(node, kept *subtreeNode) {
+		g, ok := groups[node.hash]
+		if !ok {
+			g = len(report.Groups)
+			groups[node.hash] = g
+			report.Groups = append(report.Groups, DuplicateGroup{
+				Count:   index.counts[node.hash],
+				Size:    node.size,
+				Example: kept.path,
+				Value:   kept.value,
+			})
+		}
+		saving := node.size - refSize(kept.pointer)
+		report.Groups[g].Saving += saving
+		report.Saving += saving
+	})
+	sort.SliceStable(report.Groups, func(i, j int) bool { return report.Groups[i].Saving > report.Groups[j].Saving })
+	return report
+}
+
+// Deduplicate 原地把 v 中重复的数组和对象替换为指向第一次出现的引用，返回替换的数量
+//
+// 引用的形式与 JSON Schema 相同，如 {"$ref": "#/items/0/vendor"}。按文档顺序，
+// 第一次出现的子树保持不变，之后的每一次出现都被替换，除非引用比子树本身还长。
+// 文档本身已经包含 $ref 成员时，结果中的引用无法与之区分。
+func Deduplicate(v *Value, options DuplicateOptions) int {
+	if v == nil {
+		return 0
+	}
+	var targets []*Value
+	var refs []string
+	indexSubtrees(v).replacements(options, func(node, kept *subtreeNode) {
+		targets = append(targets, node.value)
+		refs = append(refs, "#"+kept.pointer)
+	})
+	// 全部找出后再替换，替换不会影响其他节点的路径
+	for i, target := range targets {
+		SetObject(target)
+		SetString(SetObjectValue(target, "$ref"), refs[i])
+	}
+	return len(targets)
+}
+
+// FormatDuplicateReport 把报告格式化为文本，最多列出 top 组（不大于 0 时列出全部）
+func FormatDuplicateReport(report *DuplicateReport, top int) string {
+	var sb strings.Builder
+	if len(report.Groups) == 0 {
+		sb.WriteString("没有重复的子树\n")
+		return sb.String()
+	}
+	groups := report.Groups
+	if top > 0 && len(groups) > top {
+		groups = groups[:top]
+	}
+	sb.WriteString("重复的子树（按可节省的字节数排列）:\n")
+	for i, g := range groups {
+		fmt.Fprintf(&sb, "  %d. 出现 %d 次，每次 %d 字节，可节省 %d 字节\n", i+1, g.Count, g.Size, g.Saving)
+		fmt.Fprintf(&sb, "     例如 %s: %s\n", g.Example, previewValue(g.Value))
+	}
+	if len(groups) < len(report.Groups) {
+		fmt.Fprintf(&sb, "  ...还有 %d 组\n", len(report.Groups)-len(groups))
+	}
+	percent := 0.0
+	if report.TotalSize > 0 {
+		percent = float64(report.Saving) * 100 / float64(report.TotalSize)
+	}
+	fmt.Fprintf(&sb, "共 %d 组重复，去重可节省 %d / %d 字节（%.1f%%）\n", len(report.Groups), report.Saving, report.TotalSize, percent)
+	return sb.String()
+}
@@ -0,0 +1,135 @@
+package leptjson
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// expandDedupRefs 返回把 Deduplicate 生成的引用展开后的副本
+func expandDedupRefs(t *testing.T, root, v *Value) *Value {
+	t.Helper()
+	if v.Type == OBJECT && len(v.O) == 1 && v.O[0].K == "$ref" {
+		pointer, err := NewJSONPointer(strings.TrimPrefix(GetString(v.O[0].V), "#"))
+		if err != nil {
+			t.Fatalf("无效的引用 %s: %v", GetString(v.O[0].V), err)
+		}
+		target, _, err := ResolvePointer(root, pointer)
+		if err != nil {
+			t.Fatalf("无法解析引用 %s: %v", GetString(v.O[0].V), err)
+		}
+		return expandDedupRefs(t, root, target)
+	}
+	result := &Value{}
+	switch v.Type {
+	case ARRAY:
+		SetArray(result, len(v.A))
+		for _, e := range v.A {
+			result.A = append(result.A, expandDedupRefs(t, root, e))
+		}
+	case OBJECT:
+		SetObject(result)
+		for _, m := range v.O {
+			result.O = append(result.O, Member{K: m.K, V: expandDedupRefs(t, root, m.V)})
+		}
+	default:
+		Copy(result, v)
+	}
+	return result
+}
+
+func TestFindDuplicates(t *testing.T) {
+	vendor := `{"name": "Acme Corporation", "address": {"city": "Springfield", "zip": "12345"}}`
+	doc := mustParse(t, fmt.Sprintf(`{"items": [
+		{"sku": "a", "vendor": %s},
+		{"sku": "b", "vendor": %s},
+		{"sku": "c", "vendor": {"address": {"zip": "12345", "city": "Springfield"}, "name": "Acme Corporation"}},
+		{"sku": "d", "tags": [], "dims": [1, 2]},
+		{"sku": "e", "tags": [], "dims": [1, 2]}
+	]}`, vendor, vendor))
+
+	report := FindDuplicates(doc, DuplicateOptions{})
+	text, _ := Stringify(doc)
+	if report.TotalSize != len(text) {
+		t.Errorf("TotalSize = %d, 期望 %d", report.TotalSize, len(text))
+	}
+	if len(report.Groups) != 1 {
+		t.Fatalf("Groups = %+v, 期望只有 vendor 一组", report.Groups)
+	}
+	g := report.Groups[0]
+	vendorText, _ := Stringify(mustParse(t, vendor))
+	if g.Count != 3 || g.Size != len(vendorText) || g.Example != "$.items[0].vendor" {
+		t.Errorf("Group = %+v", g)
+	}
+	if g.Saving != 2*(len(vendorText)-len(`{"$ref":"#/items/0/vendor"}`)) || report.Saving != g.Saving {
+		t.Errorf("Saving = %d/%d", g.Saving, report.Saving)
+	}
+
+	if got := FindDuplicates(doc, DuplicateOptions{MinSize: 1000}); len(got.Groups) != 0 {
+		t.Errorf("MinSize 应该排除较小的子树: %+v", got.Groups)
+	}
+	if !strings.Contains(FormatDuplicateReport(report, 0), "出现 3 次") {
+		t.Errorf("FormatDuplicateReport = %s", FormatDuplicateReport(report, 0))
+	}
+}
+
+func TestDeduplicate(t *testing.T) {
+	doc := mustParse(t, `{"a": {"x": {"long": "aaaaaaaaaaaaaaaaaaaaaaaaaaaa"}, "y": 1}, "b": {"x": {"long": "aaaaaaaaaaaaaaaaaaaaaaaaaaaa"}, "y": 1}, "c": {"long": "aaaaaaaaaaaaaaaaaaaaaaaaaaaa"}}`)
+	original := &Value{}
+	Copy(original, doc)
+	before, _ := Stringify(doc)
+	report := FindDuplicates(doc, DuplicateOptions{})
+
+	if n := Deduplicate(doc, DuplicateOptions{}); n != 2 {
+		t.Errorf("Deduplicate = %d, 期望 2", n)
+	}
+	after, _ := Stringify(doc)
+	expected := `{"a":{"x":{"long":"aaaaaaaaaaaaaaaaaaaaaaaaaaaa"},"y":1},"b":{"$ref":"#/a"},"c":{"$ref":"#/a/x"}}`
+	if after != expected {
+		t.Errorf("Deduplicate 结果 = %s, 期望 %s", after, expected)
+	}
+	if report.Saving != len(before)-len(after) {
+		t.Errorf("Saving = %d, 实际节省 %d", report.Saving, len(before)-len(after))
+	}
+	if !Equal(expandDedupRefs(t, doc, doc), original) {
+		t.Errorf("展开引用后与原文档不同")
+	}
+}
+
+func TestDeduplicateRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	var build func(depth int) string
+	build = func(depth int) string {
+		if depth == 0 || r.Intn(3) == 0 {
+			return fmt.Sprintf(`"value-%d"`, r.Intn(3))
+		}
+		n := r.Intn(3) + 1
+		parts := make([]string, n)
+		if r.Intn(2) == 0 {
+			for i := range parts {
+				parts[i] = build(depth - 1)
+			}
+			return "[" + strings.Join(parts, ",") + "]"
+		}
+		for i := range parts {
+			parts[i] = fmt.Sprintf(`"k/%d":%s`, i, build(depth-1))
+		}
+		return "{" + strings.Join(parts, ",") + "}"
+	}
+	for i := 0; i < 300; i++ {
+		doc := mustParse(t, build(5))
+		original := &Value{}
+		Copy(original, doc)
+		before, _ := Stringify(doc)
+		report := FindDuplicates(doc, DuplicateOptions{})
+		Deduplicate(doc, DuplicateOptions{})
+		after, _ := Stringify(doc)
+		if report.Saving != len(before)-len(after) {
+			t.Fatalf("%s: Saving = %d, 实际节省 %d", before, report.Saving, len(before)-len(after))
+		}
+		if !Equal(expandDedupRefs(t, doc, doc), original) {
+			t.Fatalf("%s: 展开引用后与原文档不同: %s", before, after)
+		}
+	}
+}
@@ -0,0 +1,380 @@
+// document.go - 并发安全的JSON文档
+package leptjson
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ChangeEvent 描述一次对文档的修改
+type ChangeEvent struct {
+	Op      string   // 修改类型: set, remove, patch, update
+	Paths   []string // 被修改的路径 (JSON Pointer)，空字符串表示整个文档
+	Version uint64   // 修改后的文档版本号
+}
+
+// ChangeListener 文档修改的回调函数
+type ChangeListener func(event ChangeEvent)
+
+// Document 是一个可以被多个goroutine同时读写的JSON文档
+//
+// 读操作（Get/Query/Read）持有读锁，写操作（Set/Remove/Patch/Update）持有写锁。
+// Get 和 Query 返回的是深拷贝，调用者可以随意修改而不影响文档。
+// 每次成功的写操作都会递增版本号并通知已注册的监听器。
+type Document struct {
+	mu      sync.RWMutex
+	root    *Value
+	version uint64
+	cache   *QueryCache // 为 nil 时不缓存查询结果
+	journal *Journal    // 为 nil 时不记录修改
+
+	stringMu    sync.Mutex      // 保护 stringCache，String 只持有读锁
+	stringCache *stringifyCache // 为 nil 时每次完整序列化
+
+	listenersMu sync.Mutex
+	listeners   map[int]ChangeListener
+	nextID      int
+
+	notifyMu   sync.Mutex
+	notifyCond *sync.Cond // 保证通知按版本号顺序送达
+	notified   uint64     // 已经通知完成的版本号
+}
+
+// NewDocument 创建一个包含v的深拷贝的文档，v为nil时文档为null
+func NewDocument(v *Value) *Document {
+	root := &Value{}
+	if v != nil {
+		Copy(root, v)
+	}
+	d := &Document{
+		root:      root,
+		listeners: make(map[int]ChangeListener),
+	}
+	d.notifyCond = sync.NewCond(&d.notifyMu)
+	return d
+}
+
+// ParseDocument 解析JSON字符串并创建文档
+func ParseDocument(json string) (*Document, error) {
+	v := &Value{}
+	if err := Parse(v, json); err != PARSE_OK {
+		return nil, err
+	}
+	return NewDocument(v), nil
+}
+
+// Version 返回文档当前的版本号，每次成功修改后加一
+func (d *Document) Version() uint64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.version
+}
+
+// SetQueryCache 让 Get、Query 和 Validate 使用查询缓存，为 nil 时不缓存
+//
+// 缓存可以被多个文档共享。文档被修改后，它在缓存中的条目随即被删除。
+func (d *Document) SetQueryCache(cache *QueryCache) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cache != nil {
+		d.cache.dropDocument(d)
+	}
+	d.cache = cache
+}
+
+// cacheKey 返回文档当前版本的缓存键，调用者必须持有锁
+func (d *Document) cacheKey(kind int, query string) queryCacheKey {
+	return queryCacheKey{doc: d, version: d.version, kind: kind, query: query}
+}
+
+// Get 返回JSON指针所指值的深拷贝
+func (d *Document) Get(pointer string) (*Value, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.cache != nil {
+		return d.cache.get(d.cacheKey(cacheKindPointer, pointer), d.root)
+	}
+
+	p, err := ParseJSONPointer(pointer)
+	if err != POINTER_OK {
+		return nil, err
+	}
+	value, err := p.Get(d.root)
+	if err != POINTER_OK {
+		return nil, err
+	}
+	result := &Value{}
+	Copy(result, value)
+	return result, nil
+}
+
+// Snapshot 返回整个文档的深拷贝
+func (d *Document) Snapshot() *Value {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	result := &Value{}
+	Copy(result, d.root)
+	return result
+}
+
+// snapshotWithVersion 返回整个文档的深拷贝和对应的版本号
+func (d *Document) snapshotWithVersion() (*Value, uint64) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	result := &Value{}
+	Copy(result, d.root)
+	return result, d.version
+}
+
+// String 返回文档的JSON字符串
+func (d *Document) String() string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.stringCache != nil {
+		d.stringMu.Lock()
+		defer d.stringMu.Unlock()
+		return d.stringCache.stringify(d.root)
+	}
+
+	s, _ := Stringify(d.root)
+	return s
+}
+
+// Query 使用JSONPath查询文档，返回匹配值的深拷贝
+func (d *Document) Query(path string) ([]*Value, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.cache != nil {
+		return d.cache.query(d.cacheKey(cacheKindPath, path), d.root)
+	}
+
+	jp, err := NewJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := jp.Query(d.root)
+	if err != nil {
+		return nil, err
+	}
+	return copyValues(matches), nil
+}
+
+// Validate 使用 schema 验证文档，设置了查询缓存时缓存验证结果
+func (d *Document) Validate(schema *Value) ValidationResult {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.cache != nil {
+		return d.cache.validate(d.cacheKey(cacheKindSchema, schemaCacheKey(schema)), schema, d.root)
+	}
+	return validateWithSchema(schema, d.root)
+}
+
+// Read 在读锁保护下调用fn，fn不得修改或保留传入的值
+func (d *Document) Read(fn func(root *Value)) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	fn(d.root)
+}
+
+// Set 根据JSON指针设置值：对象键存在时替换、不存在时添加，
+// 数组索引处插入元素（"-" 表示追加），空指针替换整个文档
+func (d *Document) Set(pointer string, value *Value) error {
+	p, err := ParseJSONPointer(pointer)
+	if err != POINTER_OK {
+		return err
+	}
+
+	op := PatchOperation{Op: "add", Path: pointer, Value: value}
+	if len(p.tokens) == 0 {
+		op.Op = "replace"
+	}
+
+	d.mu.Lock()
+	undo := d.undoLocked(op)
+	if len(p.tokens) == 0 {
+		Copy(d.root, value)
+	} else if err := p.Insert(d.root, value); err != POINTER_OK {
+		d.mu.Unlock()
+		return err
+	}
+	if err := d.journalLocked(&JSONPatch{Operations: []PatchOperation{op}}, undo); err != nil {
+		d.mu.Unlock()
+		return err
+	}
+	if len(p.tokens) == 0 {
+		d.resetDirtyLocked()
+	} else {
+		d.markDirtyLocked([]deltaStep{{op: "add", path: p.tokens}})
+	}
+	event := d.commitLocked("set", pointer)
+	d.mu.Unlock()
+
+	d.notify(event)
+	return nil
+}
+
+// Remove 根据JSON指针删除值
+func (d *Document) Remove(pointer string) error {
+	p, err := ParseJSONPointer(pointer)
+	if err != POINTER_OK {
+		return err
+	}
+
+	op := PatchOperation{Op: "remove", Path: pointer}
+
+	d.mu.Lock()
+	undo := d.undoLocked(op)
+	if err := p.Remove(d.root); err != POINTER_OK {
+		d.mu.Unlock()
+		return err
+	}
+	if err := d.journalLocked(&JSONPatch{Operations: []PatchOperation{op}}, undo); err != nil {
+		d.mu.Unlock()
+		return err
+	}
+	d.markDirtyLocked([]deltaStep{{op: "remove", path: p.tokens}})
+	event := d.commitLocked("remove", pointer)
+	d.mu.Unlock()
+
+	d.notify(event)
+	return nil
+}
+
+// Patch 应用JSON Patch，任何一个操作失败时文档保持不变
+func (d *Document) Patch(patch *JSONPatch) error {
+	if patch == nil {
+		return fmt.Errorf("补丁不能为空")
+	}
+
+	d.mu.Lock()
+	var inverse *JSONPatch
+	var err error
+	if d.journal != nil {
+		// 写入日志失败时用逆补丁撤销
+		inverse, err = patch.ApplyWithInverse(d.root)
+	} else {
+		err = patch.Apply(d.root)
+	}
+	if err != nil {
+		d.mu.Unlock()
+		return err
+	}
+	if err := d.journalLocked(patch, func() { inverse.Apply(d.root) }); err != nil {
+		d.mu.Unlock()
+		return err
+	}
+	if steps, ok := deltaSteps(patch); ok {
+		d.markDirtyLocked(steps)
+	} else {
+		d.resetDirtyLocked()
+	}
+	event := d.commitLocked("patch", patchPaths(patch)...)
+	d.mu.Unlock()
+
+	d.notify(event)
+	return nil
+}
+
+// Update 在写锁保护下调用fn直接修改文档。fn返回错误时文档恢复原状，
+// 由于无法得知修改了哪些路径，事件中的路径为整个文档
+func (d *Document) Update(fn func(root *Value) error) error {
+	d.mu.Lock()
+	working := &Value{}
+	Copy(working, d.root)
+	if err := fn(working); err != nil {
+		d.mu.Unlock()
+		return err
+	}
+	if d.journal != nil {
+		patch, err := CreatePatch(d.root, working)
+		if err == nil {
+			err = d.journalLocked(patch, func() {})
+		}
+		if err != nil {
+			d.mu.Unlock()
+			return err
+		}
+	}
+	d.root = working
+	d.resetDirtyLocked()
+	event := d.commitLocked("update", "")
+	d.mu.Unlock()
+
+	d.notify(event)
+	return nil
+}
+
+// OnChange 注册修改监听器，返回用于取消注册的函数
+//
+// 监听器在写锁释放后按修改顺序同步调用，可以安全地读取文档，
+// 但不应在监听器中修改文档，否则会导致死锁。
+func (d *Document) OnChange(listener ChangeListener) (cancel func()) {
+	d.listenersMu.Lock()
+	id := d.nextID
+	d.nextID++
+	d.listeners[id] = listener
+	d.listenersMu.Unlock()
+
+	return func() {
+		d.listenersMu.Lock()
+		delete(d.listeners, id)
+		d.listenersMu.Unlock()
+	}
+}
+
+// commitLocked 递增版本号并生成修改事件，调用者必须持有写锁
+func (d *Document) commitLocked(op string, paths ...string) ChangeEvent {
+	if d.cache != nil {
+		d.cache.dropDocument(d)
+	}
+	d.version++
+	return ChangeEvent{Op: op, Paths: paths, Version: d.version}
+}
+
+// notify 将事件发送给所有监听器，先提交的修改先通知
+func (d *Document) notify(event ChangeEvent) {
+	d.notifyMu.Lock()
+	defer d.notifyMu.Unlock()
+	for d.notified != event.Version-1 {
+		d.notifyCond.Wait()
+	}
+	defer func() {
+		d.notified = event.Version
+		d.notifyCond.Broadcast()
+	}()
+
+	d.listenersMu.Lock()
+	ids := make([]int, 0, len(d.listeners))
+	for id := range d.listeners {
+		ids = append(ids, id)
+	}
+	listeners := make([]ChangeListener, 0, len(ids))
+	sort.Ints(ids)
+	for _, id := range ids {
+		listeners = append(listeners, d.listeners[id])
+	}
+	d.listenersMu.Unlock()
+
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
+
+// patchPaths 返回补丁会修改的所有路径
+func patchPaths(patch *JSONPatch) []string {
+	var paths []string
+	for _, op := range patch.Operations {
+		switch op.Op {
+		case "test":
+			// test 操作不修改文档
+		case "move":
+			paths = append(paths, op.From, op.Path)
+		default:
+			paths = append(paths, op.Path)
+		}
+	}
+	return paths
+}
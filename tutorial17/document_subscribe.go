@@ -0,0 +1,122 @@
+// document_subscribe.go - 按路径订阅文档修改
+package leptjson
+
+import (
+	"strconv"
+)
+
+// PathEvent 表示订阅路径所在子树发生的修改
+type PathEvent struct {
+	Path    string // 订阅的路径
+	Op      string // 触发修改的操作类型
+	Version uint64 // 修改后的文档版本号
+	Exists  bool   // 订阅路径当前是否存在
+	Value   *Value // 订阅路径当前值的深拷贝，不存在时为nil
+}
+
+// Subscription 表示一个路径订阅
+//
+// C 的缓冲区大小为1，消费者处理不及时时旧事件会被新事件覆盖，
+// 因此读取到的总是最新的状态，适合配置热加载等场景。
+type Subscription struct {
+	C      <-chan PathEvent
+	c      chan PathEvent
+	cancel func()
+}
+
+// Close 取消订阅，之后不会再有新事件写入 C
+func (s *Subscription) Close() {
+	s.cancel()
+}
+
+// Subscribe 订阅指定路径的修改，返回的通道在该路径所在子树被修改时收到事件
+//
+// 修改路径与订阅路径存在祖先/后代关系时都会触发通知：替换 "/features" 会通知
+// "/features/flagX" 的订阅者，修改 "/features/flagX/rollout" 同样会通知。
+// 数组元素的插入和删除会使后续元素的索引移动，因此对数组元素的修改会通知该数组下的所有订阅者。
+func (d *Document) Subscribe(pointer string) (*Subscription, error) {
+	c := make(chan PathEvent, 1)
+	sub := &Subscription{C: c, c: c}
+
+	cancel, err := d.SubscribeFunc(pointer, func(event PathEvent) {
+		// 丢弃尚未读取的旧事件，保证最新事件能够写入
+		select {
+		case <-sub.c:
+		default:
+		}
+		sub.c <- event
+	})
+	if err != nil {
+		return nil, err
+	}
+	sub.cancel = cancel
+	return sub, nil
+}
+
+// SubscribeFunc 订阅指定路径的修改，事件通过回调函数同步送达
+//
+// 回调的限制与 OnChange 相同：可以读取文档，但不能修改文档。
+func (d *Document) SubscribeFunc(pointer string, fn func(event PathEvent)) (cancel func(), err error) {
+	p, errCode := ParseJSONPointer(pointer)
+	if errCode != POINTER_OK {
+		return nil, errCode
+	}
+	subscribed := p.tokens
+
+	return d.OnChange(func(event ChangeEvent) {
+		if !changeAffects(event.Paths, subscribed) {
+			return
+		}
+
+		pathEvent := PathEvent{Path: pointer, Op: event.Op, Version: event.Version}
+		if value, err := d.Get(pointer); err == nil {
+			pathEvent.Exists = true
+			pathEvent.Value = value
+		}
+		fn(pathEvent)
+	}), nil
+}
+
+// changeAffects 判断修改路径中是否有路径影响订阅的子树
+func changeAffects(changed []string, subscribed []string) bool {
+	for _, path := range changed {
+		p, err := ParseJSONPointer(path)
+		if err != POINTER_OK {
+			// 无法解析的路径，保守地认为有影响
+			return true
+		}
+		tokens := p.tokens
+
+		// 数组元素的修改可能移动兄弟元素的索引，视为修改了整个数组
+		if n := len(tokens); n > 0 && isArrayIndexToken(tokens[n-1]) {
+			tokens = tokens[:n-1]
+		}
+
+		if isTokenPrefix(tokens, subscribed) || isTokenPrefix(subscribed, tokens) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTokenPrefix 判断prefix是否为tokens的前缀（包括相等）
+func isTokenPrefix(prefix, tokens []string) bool {
+	if len(prefix) > len(tokens) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != tokens[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// isArrayIndexToken 判断令牌是否可能是数组索引
+func isArrayIndexToken(token string) bool {
+	if token == "-" {
+		return true
+	}
+	_, err := strconv.Atoi(token)
+	return err == nil
+}
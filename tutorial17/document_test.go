@@ -0,0 +1,233 @@
+package leptjson
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestDocumentGetSetRemove(t *testing.T) {
+	doc, err := ParseDocument(`{"name":"app","servers":["a","b"]}`)
+	if err != nil {
+		t.Fatalf("ParseDocument 失败: %v", err)
+	}
+
+	v := &Value{}
+	SetString(v, "c")
+	if err := doc.Set("/servers/-", v); err != nil {
+		t.Fatalf("Set 失败: %v", err)
+	}
+	if err := doc.Remove("/name"); err != nil {
+		t.Fatalf("Remove 失败: %v", err)
+	}
+	if got := doc.String(); got != `{"servers":["a","b","c"]}` {
+		t.Errorf("文档内容 = %s", got)
+	}
+	if doc.Version() != 2 {
+		t.Errorf("版本号 = %d, 期望 2", doc.Version())
+	}
+
+	// Get 返回的是深拷贝
+	servers, err := doc.Get("/servers")
+	if err != nil {
+		t.Fatalf("Get 失败: %v", err)
+	}
+	SetNull(servers)
+	if got := doc.String(); got != `{"servers":["a","b","c"]}` {
+		t.Errorf("修改Get的结果影响了文档: %s", got)
+	}
+
+	if _, err := doc.Get("/missing"); err != POINTER_KEY_NOT_FOUND {
+		t.Errorf("Get 不存在的键返回 %v", err)
+	}
+	if err := doc.Remove("/missing"); err != POINTER_KEY_NOT_FOUND {
+		t.Errorf("Remove 不存在的键返回 %v", err)
+	}
+	if doc.Version() != 2 {
+		t.Error("失败的操作不应改变版本号")
+	}
+
+	results, err := doc.Query("$.servers[*]")
+	if err != nil || len(results) != 3 {
+		t.Errorf("Query 返回 %d 个结果, 错误: %v", len(results), err)
+	}
+}
+
+func TestDocumentPatchIsAtomic(t *testing.T) {
+	doc, _ := ParseDocument(`{"a":1,"b":2}`)
+
+	patch, err := NewJSONPatchFromString(`[
+		{"op":"replace","path":"/a","value":10},
+		{"op":"remove","path":"/missing"}
+	]`)
+	if err != nil {
+		t.Fatalf("解析补丁失败: %v", err)
+	}
+	if err := doc.Patch(patch); err == nil {
+		t.Fatal("期望补丁应用失败")
+	}
+	if got := doc.String(); got != `{"a":1,"b":2}` {
+		t.Errorf("失败的补丁修改了文档: %s", got)
+	}
+
+	patch, _ = NewJSONPatchFromString(`[{"op":"move","from":"/a","path":"/c"}]`)
+	var events []ChangeEvent
+	cancel := doc.OnChange(func(e ChangeEvent) { events = append(events, e) })
+	if err := doc.Patch(patch); err != nil {
+		t.Fatalf("Patch 失败: %v", err)
+	}
+	cancel()
+	doc.Remove("/b")
+
+	if len(events) != 1 {
+		t.Fatalf("收到 %d 个事件, 期望 1", len(events))
+	}
+	if events[0].Op != "patch" || len(events[0].Paths) != 2 || events[0].Paths[0] != "/a" || events[0].Paths[1] != "/c" {
+		t.Errorf("事件内容错误: %+v", events[0])
+	}
+}
+
+func TestDocumentUpdate(t *testing.T) {
+	doc, _ := ParseDocument(`{"n":1}`)
+
+	err := doc.Update(func(root *Value) error {
+		SetNumber(GetObjectValueByKey(root, "n"), 2)
+		return fmt.Errorf("中止")
+	})
+	if err == nil || doc.String() != `{"n":1}` {
+		t.Errorf("Update 失败时文档应保持不变: %s", doc.String())
+	}
+
+	doc.Update(func(root *Value) error {
+		SetNumber(GetObjectValueByKey(root, "n"), 2)
+		return nil
+	})
+	if doc.String() != `{"n":2}` {
+		t.Errorf("Update 结果 = %s", doc.String())
+	}
+}
+
+func TestDocumentConcurrentAccess(t *testing.T) {
+	doc, _ := ParseDocument(`{"counter":[]}`)
+
+	var mu sync.Mutex
+	var versions []uint64
+	doc.OnChange(func(e ChangeEvent) {
+		mu.Lock()
+		versions = append(versions, e.Version)
+		mu.Unlock()
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			v := &Value{}
+			SetNumber(v, float64(i))
+			doc.Set("/counter/-", v)
+		}(i)
+		go func() {
+			defer wg.Done()
+			doc.Get("/counter")
+			doc.Query("$.counter[*]")
+		}()
+	}
+	wg.Wait()
+
+	counter, _ := doc.Get("/counter")
+	if len(counter.A) != 10 {
+		t.Errorf("数组长度 = %d, 期望 10", len(counter.A))
+	}
+	for i, version := range versions {
+		if version != uint64(i+1) {
+			t.Fatalf("事件顺序错误: %v", versions)
+		}
+	}
+}
+
+func TestDocumentSubscribe(t *testing.T) {
+	doc, _ := ParseDocument(`{"features":{"flagX":true,"flagY":false},"list":[1,2,3]}`)
+
+	var got []PathEvent
+	cancel, err := doc.SubscribeFunc("/features/flagX", func(e PathEvent) {
+		got = append(got, e)
+	})
+	if err != nil {
+		t.Fatalf("SubscribeFunc 失败: %v", err)
+	}
+
+	off := &Value{Type: FALSE}
+	doc.Set("/features/flagY", off) // 兄弟节点，不通知
+	doc.Set("/features/flagX", off) // 订阅路径本身
+	patch, _ := NewJSONPatchFromString(`[{"op":"remove","path":"/features"}]`)
+	doc.Patch(patch) // 祖先节点被删除
+	cancel()
+	doc.Set("/features", off)
+
+	if len(got) != 2 {
+		t.Fatalf("收到 %d 个事件, 期望 2: %+v", len(got), got)
+	}
+	if !got[0].Exists || got[0].Value.Type != FALSE || got[0].Op != "set" {
+		t.Errorf("第一个事件错误: %+v", got[0])
+	}
+	if got[1].Exists || got[1].Value != nil || got[1].Op != "patch" {
+		t.Errorf("第二个事件错误: %+v", got[1])
+	}
+
+	if _, err := doc.SubscribeFunc("bad", func(PathEvent) {}); err != POINTER_INVALID_FORMAT {
+		t.Errorf("无效路径返回 %v", err)
+	}
+}
+
+func TestChangeAffects(t *testing.T) {
+	tests := []struct {
+		changed    string
+		subscribed string
+		want       bool
+	}{
+		{"/a/b", "/a/b", true},
+		{"/a", "/a/b", true},
+		{"/a/b/c", "/a/b", true},
+		{"/a/c", "/a/b", false},
+		{"", "/a", true},
+		{"/a", "", true},
+		{"/list/0", "/list/2", true},
+		{"/list/-", "/list/2/name", true},
+		{"/list/0", "/other", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.changed+"=>"+tt.subscribed, func(t *testing.T) {
+			p, _ := ParseJSONPointer(tt.subscribed)
+			if got := changeAffects([]string{tt.changed}, p.tokens); got != tt.want {
+				t.Errorf("changeAffects(%q, %q) = %v, 期望 %v", tt.changed, tt.subscribed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubscriptionChannelKeepsLatest(t *testing.T) {
+	doc, _ := ParseDocument(`{"n":0}`)
+	sub, err := doc.Subscribe("/n")
+	if err != nil {
+		t.Fatalf("Subscribe 失败: %v", err)
+	}
+	defer sub.Close()
+
+	for i := 1; i <= 5; i++ {
+		v := &Value{}
+		SetNumber(v, float64(i))
+		doc.Set("/n", v)
+	}
+
+	event := <-sub.C
+	if event.Version != 5 || event.Value.N != 5 {
+		t.Errorf("期望收到最新事件, 实际: %+v", event)
+	}
+	select {
+	case e := <-sub.C:
+		t.Errorf("不应有更多事件: %+v", e)
+	default:
+	}
+}
@@ -38,11 +38,12 @@ func (e *EnhancedError) Error() string {
 
 // ParseOptions 定义解析选项
 type ParseOptions struct {
-	MaxDepth          int  // 最大嵌套深度
-	AllowComments     bool // 是否允许注释
-	AllowTrailing     bool // 是否允许尾随逗号
-	StrictMode        bool // 严格模式（更严格的检查）
-	RecoverFromErrors bool // 是否从非致命错误恢复
+	MaxDepth          int         // 最大嵌套深度
+	AllowComments     bool        // 是否允许注释
+	Comments          *CommentMap // 不为nil且允许注释时，记录每条注释所属的值，见 comments.go
+	AllowTrailing     bool        // 是否允许尾随逗号
+	StrictMode        bool        // 严格模式（更严格的检查）
+	RecoverFromErrors bool        // 是否从非致命错误恢复
 
 	// 新增安全选项
 	MaxStringLength int     // 最大字符串长度
@@ -52,6 +53,23 @@ type ParseOptions struct {
 	MaxNumberValue  float64 // 最大数字值
 	MinNumberValue  float64 // 最小数字值
 	EnabledSecurity bool    // 是否启用安全检查
+
+	DisableKeyInterning bool // 关闭默认的键驻留，每个对象键使用独立的字符串
+
+	// 键过滤，按精确匹配或 path.Match 的通配符匹配所有层级的对象键，为空时不过滤
+	DeniedKeys         []string // 任意一层对象中匹配的成员在解析时被跳过
+	AllowedKeys        []string // 非空时根对象只保留匹配的成员，嵌套的对象不受影响
+	RejectFilteredKeys bool     // 遇到被过滤的键时返回 PARSE_KEY_NOT_ALLOWED 而不是跳过
+
+	StringChunkThreshold int // 字符串值超过这么多字节时分块保存，避免分配大块连续内存，0 表示不分块
+
+	Normalization NormalizationForm // 把字符串值和对象键转换为指定的 Unicode 规范化形式，设置后不再分块保存字符串
+
+	Growth GrowthPolicy // 数组和对象的扩容策略，零值为默认的翻倍策略，见 SuggestGrowthPolicy
+
+	// 解析钩子，为nil时使用默认行为
+	InternKey     func(key string) string                   // 对象键的驻留函数，返回的字符串作为键保存，见 StringInterner
+	NumberHandler func(v *Value, literal string) ParseError // 数字处理函数，接收数字的原始文本并负责设置 v
 }
 
 // DefaultParseOptions 返回默认解析选项
@@ -82,6 +100,8 @@ const (
 	PARSE_MAX_TOTAL_SIZE_EXCEEDED    ParseError = 19
 	PARSE_NUMBER_RANGE_EXCEEDED      ParseError = 20
 	PARSE_SECURITY_VIOLATION         ParseError = 21
+	PARSE_KEY_NOT_ALLOWED            ParseError = 22
+	PARSE_CANCELLED                  ParseError = 23
 )
 
 // createEnhancedError 创建详细的错误信息
@@ -193,6 +213,10 @@ func GetErrorMessage(code ParseError) string {
 		return "数值超出允许范围"
 	case PARSE_SECURITY_VIOLATION:
 		return "安全策略违规"
+	case PARSE_KEY_NOT_ALLOWED:
+		return "对象键不被允许"
+	case PARSE_CANCELLED:
+		return "解析被取消"
 	default:
 		return "未知错误"
 	}
@@ -0,0 +1,98 @@
+// equal_options.go - 可配置的相等比较（数值容差、忽略数组顺序、数字字符串）
+package leptjson
+
+import (
+	"math"
+)
+
+// EqualOptions 控制 EqualWithOptions 的比较方式，零值与 Equal 相同
+type EqualOptions struct {
+	FloatEpsilon         float64           // 两个数字之差的绝对值不超过它时视为相等
+	IgnoreArrayOrder     bool              // 数组按多重集合比较，元素顺序不同也相等
+	CoerceNumericStrings bool              // 内容是JSON数字的字符串与数值相同的数字相等，如 "42" 和 42
+	Normalization        NormalizationForm // 字符串值和对象键按此规范化形式比较，见 EqualNormalized
+}
+
+// EqualWithOptions 按选项判断两个JSON值是否相等
+//
+// 适合比较API响应这类不需要逐字节相同的文档：
+//
+//	EqualWithOptions(got, want, EqualOptions{FloatEpsilon: 1e-9, IgnoreArrayOrder: true})
+//
+// 选项对所有层级生效。IgnoreArrayOrder 为 true 时，数组的每个元素都必须与另一边
+// 不同的元素一一对应，[1, 1, 2] 与 [1, 2, 2] 不相等。
+func EqualWithOptions(lhs, rhs *Value, options EqualOptions) bool {
+	return equalValues(lhs, rhs, &options)
+}
+
+// numbersEqual 判断两个数字在容差内是否相等
+func (o *EqualOptions) numbersEqual(a, b float64) bool {
+	return a == b || math.Abs(a-b) <= o.FloatEpsilon
+}
+
+// numericStringEqual 判断一个数字和一个数字字符串是否相等
+func numericStringEqual(lhs, rhs *Value, opts *EqualOptions) bool {
+	if lhs.Type == STRING {
+		lhs, rhs = rhs, lhs
+	}
+	if lhs.Type != NUMBER || rhs.Type != STRING {
+		return false
+	}
+	n, ok := numericString(GetString(rhs))
+	return ok && opts.numbersEqual(lhs.N, n)
+}
+
+// numericString 按JSON数字的语法解析字符串，不接受前后空白、十六进制和 NaN 等写法
+func numericString(s string) (float64, bool) {
+	if s == "" || !(s[0] == '-' || isDigit(s[0])) || !isDigit(s[len(s)-1]) {
+		return 0, false
+	}
+	var v Value
+	if ParseWithOptions(&v, s, ParseOptions{}) != PARSE_OK || v.Type != NUMBER {
+		return 0, false
+	}
+	return v.N, true
+}
+
+// unorderedEqual 判断两个等长数组的元素能否一一对应相等
+//
+// 容差使相等关系不具有传递性，贪心匹配可能失败，所以用增广路径求二分图的完美匹配。
+func unorderedEqual(lhs, rhs []*Value, opts *EqualOptions) bool {
+	n := len(lhs)
+	edges := make([][]int, n)
+	for i := range lhs {
+		for j := range rhs {
+			if equalValues(lhs[i], rhs[j], opts) {
+				edges[i] = append(edges[i], j)
+			}
+		}
+		if len(edges[i]) == 0 {
+			return false
+		}
+	}
+
+	match := make([]int, n) // match[j] 是与 rhs[j] 对应的 lhs 下标
+	for j := range match {
+		match[j] = -1
+	}
+	var augment func(i int, seen []bool) bool
+	augment = func(i int, seen []bool) bool {
+		for _, j := range edges[i] {
+			if seen[j] {
+				continue
+			}
+			seen[j] = true
+			if match[j] < 0 || augment(match[j], seen) {
+				match[j] = i
+				return true
+			}
+		}
+		return false
+	}
+	for i := 0; i < n; i++ {
+		if !augment(i, make([]bool, n)) {
+			return false
+		}
+	}
+	return true
+}
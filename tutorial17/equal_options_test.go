@@ -0,0 +1,48 @@
+package leptjson
+
+import (
+	"testing"
+)
+
+func TestEqualWithOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    string
+		options EqualOptions
+		want    bool
+	}{
+		{"默认与 Equal 相同", `{"a":[1,2]}`, `{"a":[1,2]}`, EqualOptions{}, true},
+		{"默认不忽略顺序", `[1,2]`, `[2,1]`, EqualOptions{}, false},
+		{"数值容差", `{"x":0.30000000000000004}`, `{"x":0.3}`, EqualOptions{FloatEpsilon: 1e-9}, true},
+		{"超出容差", `1.5`, `1.6`, EqualOptions{FloatEpsilon: 0.01}, false},
+		{"没有容差", `0.30000000000000004`, `0.3`, EqualOptions{}, false},
+		{"忽略数组顺序", `[{"id":2},{"id":1}]`, `[{"id":1},{"id":2}]`, EqualOptions{IgnoreArrayOrder: true}, true},
+		{"嵌套数组也忽略顺序", `{"a":[[1,2],[3]]}`, `{"a":[[3],[2,1]]}`, EqualOptions{IgnoreArrayOrder: true}, true},
+		{"重复元素必须一一对应", `[1,1,2]`, `[1,2,2]`, EqualOptions{IgnoreArrayOrder: true}, false},
+		{"长度不同", `[1,2]`, `[1,2,2]`, EqualOptions{IgnoreArrayOrder: true}, false},
+		// 贪心匹配会把 1.05 配给 1.1，导致另一边的 1.1 没有对应的元素
+		{"容差下需要重新匹配", `[1.05,1.1]`, `[1.1,1.0]`, EqualOptions{IgnoreArrayOrder: true, FloatEpsilon: 0.06}, true},
+		{"数字字符串", `{"id":"42","n":-1.5e3}`, `{"id":42,"n":"-1500"}`, EqualOptions{CoerceNumericStrings: true}, true},
+		{"不转换时类型不同", `"42"`, `42`, EqualOptions{}, false},
+		{"非数字字符串", `"42abc"`, `42`, EqualOptions{CoerceNumericStrings: true}, false},
+		{"带空白的字符串", `" 42"`, `42`, EqualOptions{CoerceNumericStrings: true}, false},
+		{"非JSON语法", `"0x2A"`, `42`, EqualOptions{CoerceNumericStrings: true}, false},
+		{"字符串之间不转换", `"42"`, `"42.0"`, EqualOptions{CoerceNumericStrings: true}, false},
+		{"字符串规范化", "\"e\\u0301\"", "\"\\u00e9\"", EqualOptions{Normalization: NORMALIZE_NFC}, true},
+	}
+	for _, tt := range tests {
+		a, b := &Value{}, &Value{}
+		if err := Parse(a, tt.a); err != PARSE_OK {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if err := Parse(b, tt.b); err != PARSE_OK {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := EqualWithOptions(a, b, tt.options); got != tt.want {
+			t.Errorf("%s: EqualWithOptions(%s, %s) = %v, 期望 %v", tt.name, tt.a, tt.b, got, tt.want)
+		}
+		if got := EqualWithOptions(b, a, tt.options); got != tt.want {
+			t.Errorf("%s: 交换参数后结果不同", tt.name)
+		}
+	}
+}
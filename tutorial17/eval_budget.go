@@ -0,0 +1,107 @@
+// eval_budget.go - 限制 JSONPath 查询和 Schema 验证的时间和步数
+package leptjson
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// EvalBudget 限制一次 JSONPath 查询或 Schema 验证的开销，零值表示不限制
+//
+// 正则过滤器作用于巨大的数组时，一次查询可能运行数秒；设置限制后，
+// 单个恶意查询不会拖住整个服务。
+type EvalBudget struct {
+	Timeout  time.Duration // 最长求值时间
+	MaxSteps int           // 最多求值的步数：JSONPath 访问的节点数，或 Schema 验证的子 schema 数
+}
+
+// ErrStepLimitExceeded 表示求值步数超过了 EvalBudget.MaxSteps
+var ErrStepLimitExceeded = errors.New("求值步数超过限制")
+
+// BudgetError 表示求值因为取消、超时或步数超过限制而中止
+//
+// Err 是 ErrStepLimitExceeded、context.DeadlineExceeded 或 context.Canceled，
+// 可以用 errors.Is 判断。
+type BudgetError struct {
+	Op    string // 被中止的操作，如 "JSONPath 查询"
+	Steps int    // 中止前已经执行的步数
+	Err   error
+}
+
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("%s已中止（执行了%d步）: %v", e.Op, e.Steps, e.Err)
+}
+
+// Unwrap 返回中止的原因
+func (e *BudgetError) Unwrap() error {
+	return e.Err
+}
+
+// evalBudget 是一次求值中的限制状态，为 nil 时不做限制
+type evalBudget struct {
+	ctx      context.Context
+	op       string
+	maxSteps int
+	steps    int
+	err      error // 第一次超出限制时的错误，之后的检查都返回它
+}
+
+// newEvalBudget 创建求值的限制状态，没有任何限制时返回 nil
+//
+// 调用者必须在求值结束后调用返回的 cancel。
+func newEvalBudget(ctx context.Context, op string, limits EvalBudget) (*evalBudget, context.CancelFunc) {
+	cancel := func() {}
+	if limits.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, limits.Timeout)
+	}
+	if ctx.Done() == nil && limits.MaxSteps <= 0 {
+		return nil, cancel
+	}
+	return &evalBudget{ctx: ctx, op: op, maxSteps: limits.MaxSteps}, cancel
+}
+
+// step 记录一步求值，超出限制时返回 *BudgetError
+func (b *evalBudget) step() error {
+	if b == nil || b.err != nil {
+		return b.failed()
+	}
+	b.steps++
+	if b.maxSteps > 0 && b.steps > b.maxSteps {
+		b.err = &BudgetError{Op: b.op, Steps: b.steps - 1, Err: ErrStepLimitExceeded}
+	} else if b.steps%cancelCheckInterval == 0 && b.ctx.Err() != nil {
+		b.err = &BudgetError{Op: b.op, Steps: b.steps, Err: b.ctx.Err()}
+	}
+	return b.err
+}
+
+// failed 返回已经发生的超出限制的错误
+func (b *evalBudget) failed() error {
+	if b == nil {
+		return nil
+	}
+	return b.err
+}
+
+// QueryContext 与 Query 相同，但在求值过程中定期检查 ctx
+//
+// ctx 被取消或超时后返回 *BudgetError，并且满足 errors.Is(err, ctx.Err())。
+func (jp *JSONPath) QueryContext(ctx context.Context, doc *Value) ([]*Value, error) {
+	return jp.QueryWithBudget(ctx, doc, EvalBudget{})
+}
+
+// QueryWithBudget 与 QueryContext 相同，并且限制求值的时间和步数
+func (jp *JSONPath) QueryWithBudget(ctx context.Context, doc *Value, limits EvalBudget) ([]*Value, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, &BudgetError{Op: "JSONPath 查询", Err: err}
+	}
+	budget, cancel := newEvalBudget(ctx, "JSONPath 查询", limits)
+	defer cancel()
+	if budget == nil {
+		return jp.Query(doc)
+	}
+	q := *jp
+	q.budget = budget
+	return q.Query(doc)
+}
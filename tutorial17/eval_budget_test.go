@@ -0,0 +1,93 @@
+package leptjson
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueryWithBudgetSteps(t *testing.T) {
+	doc := &Value{}
+	Parse(doc, largeArray(100))
+
+	jp, _ := NewJSONPath("$..a")
+	if results, err := jp.QueryWithBudget(context.Background(), doc, EvalBudget{MaxSteps: 100000}); err != nil || len(results) != 100 {
+		t.Errorf("足够的步数: %d, %v", len(results), err)
+	}
+
+	_, err := jp.QueryWithBudget(context.Background(), doc, EvalBudget{MaxSteps: 50})
+	var budgetErr *BudgetError
+	if !errors.As(err, &budgetErr) || !errors.Is(err, ErrStepLimitExceeded) || budgetErr.Steps != 50 {
+		t.Errorf("超出步数 error = %v", err)
+	}
+	if !strings.Contains(err.Error(), "JSONPath 查询已中止") {
+		t.Errorf("错误描述 = %q", err.Error())
+	}
+
+	rfc, _ := NewJSONPathWithOptions(`$[?match(@.a[1], "x")]`, JSONPathOptions{RFC9535: true})
+	if _, err := rfc.QueryWithBudget(context.Background(), doc, EvalBudget{MaxSteps: 10}); !errors.Is(err, ErrStepLimitExceeded) {
+		t.Errorf("RFC 9535 过滤器超出步数 error = %v", err)
+	}
+}
+
+func TestQueryWithBudgetTimeout(t *testing.T) {
+	doc := &Value{}
+	Parse(doc, largeArray(5000))
+	jp, _ := NewJSONPath(`$[?(@.a[1] =~ /^(x|y)*$/)]`)
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	if _, err := jp.QueryWithBudget(ctx, doc, EvalBudget{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("已经超时的 context error = %v", err)
+	}
+
+	_, err := jp.QueryWithBudget(newCancelAfterContext(), doc, EvalBudget{Timeout: time.Hour})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("求值过程中取消 error = %v", err)
+	}
+}
+
+func TestValidateWithBudget(t *testing.T) {
+	schema, data := &Value{}, &Value{}
+	Parse(schema, `{"type":"array","items":{"type":"object","properties":{"a":{"type":"array","items":{"type":["number","string"]}}}}}`)
+	Parse(data, largeArray(100))
+
+	result, err := ValidateWithOptions(schema, data, ValidationOptions{Budget: EvalBudget{MaxSteps: 100000}})
+	if err != nil || !result.Valid {
+		t.Errorf("足够的步数: %+v, %v", result, err)
+	}
+	_, err = ValidateWithOptions(schema, data, ValidationOptions{Budget: EvalBudget{MaxSteps: 20}})
+	var budgetErr *BudgetError
+	if !errors.As(err, &budgetErr) || budgetErr.Op != "Schema 验证" || !errors.Is(err, ErrStepLimitExceeded) {
+		t.Errorf("超出步数 error = %v", err)
+	}
+
+	Parse(data, largeArray(2000))
+	if _, err := ValidateContext(newCancelAfterContext(), schema, data, ValidationOptions{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("验证过程中取消 error = %v", err)
+	}
+}
+
+func TestServerEvalBudget(t *testing.T) {
+	options := DefaultServerOptions()
+	options.EvalBudget = EvalBudget{MaxSteps: 10}
+	s := NewServer(options)
+
+	status, body := serverRequest(t, s, http.MethodPost, "/query", `{"path":"$..a","document":`+largeArray(20)+`}`)
+	if status != http.StatusUnprocessableEntity || !strings.Contains(body, "求值步数超过限制") {
+		t.Errorf("查询: %d %s", status, body)
+	}
+	status, body = serverRequest(t, s, http.MethodPost, "/validate", `{"schema":{"items":{"type":"object"}},"data":`+largeArray(20)+`}`)
+	if status != http.StatusUnprocessableEntity || !strings.Contains(body, "Schema 验证已中止") {
+		t.Errorf("验证: %d %s", status, body)
+	}
+
+	// 默认限制足够处理普通请求
+	s = NewServer(DefaultServerOptions())
+	if status, body := serverRequest(t, s, http.MethodPost, "/query", `{"path":"$..a","document":`+largeArray(20)+`}`); status != http.StatusOK {
+		t.Errorf("默认限制: %d %s", status, body)
+	}
+}
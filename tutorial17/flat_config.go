@@ -0,0 +1,426 @@
+// flat_config.go - Java properties 和 INI 配置文件与 Value 之间的转换
+package leptjson
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ParseProperties 将 Java properties 文本转换为嵌套对象，键按 '.' 拆分
+//
+// 支持 '#' 和 '!' 注释、'='、':' 或空白分隔的键值、行尾 '\' 续行，以及
+// \t、\n、\r、\f、\uXXXX 等转义。所有值都是字符串，重复的键以最后一次为准。
+//
+//	server.port=8080
+//	server.host = localhost
+//
+// 转换为 {"server": {"port": "8080", "host": "localhost"}}。
+func ParseProperties(text string) (*Value, error) {
+	root := &Value{}
+	SetObject(root)
+
+	lines := splitConfigLines(text)
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := strings.TrimLeft(lines[i], " \t\f")
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+
+		// 续行：行尾有奇数个反斜杠时与下一行拼接，下一行的前导空白被忽略
+		for endsWithContinuation(line) && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + strings.TrimLeft(lines[i], " \t\f")
+		}
+		if endsWithContinuation(line) {
+			line = line[:len(line)-1]
+		}
+
+		rawKey, rawValue := splitPropertyLine(line)
+		key, err := unescapeProperty(rawKey)
+		if err != nil {
+			return nil, fmt.Errorf("第%d行: %v", lineNo, err)
+		}
+		value, err := unescapeProperty(rawValue)
+		if err != nil {
+			return nil, fmt.Errorf("第%d行: %v", lineNo, err)
+		}
+		if err := setDottedKey(root, key, value); err != nil {
+			return nil, fmt.Errorf("第%d行: %v", lineNo, err)
+		}
+	}
+	return root, nil
+}
+
+// ParseINI 将 INI 文本转换为嵌套对象
+//
+// 节名和键都按 '.' 拆分，[database.primary] 下的 host=a 转换为
+// {"database": {"primary": {"host": "a"}}}；第一个节之前的键位于顶层。
+// 支持 ';' 和 '#' 注释，'=' 或 ':' 分隔键值，值两端的空白和双引号被去除。
+func ParseINI(text string) (*Value, error) {
+	root := &Value{}
+	SetObject(root)
+	section := ""
+
+	for i, raw := range splitConfigLines(text) {
+		lineNo := i + 1
+		line := strings.TrimSpace(raw)
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			if line[len(line)-1] != ']' {
+				return nil, fmt.Errorf("第%d行: 节名缺少 ']'", lineNo)
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if section == "" {
+				return nil, fmt.Errorf("第%d行: 节名为空", lineNo)
+			}
+			if err := ensureDottedObject(root, section); err != nil {
+				return nil, fmt.Errorf("第%d行: %v", lineNo, err)
+			}
+			continue
+		}
+
+		sep := strings.IndexAny(line, "=:")
+		if sep < 0 {
+			return nil, fmt.Errorf("第%d行: 缺少 '=' 或 ':'", lineNo)
+		}
+		key := strings.TrimSpace(line[:sep])
+		value := strings.TrimSpace(line[sep+1:])
+		if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+			value = value[1 : len(value)-1]
+		}
+		if section != "" {
+			key = section + "." + key
+		}
+		if err := setDottedKey(root, key, value); err != nil {
+			return nil, fmt.Errorf("第%d行: %v", lineNo, err)
+		}
+	}
+	return root, nil
+}
+
+// StringifyProperties 将对象展开为 Java properties 文本，是 ParseProperties 的逆操作
+//
+// 嵌套对象的键用 '.' 连接，数组元素使用下标作为键（如 hosts.0），
+// 数字和布尔值转换为文本，null 转换为空字符串。空数组和空对象被忽略。
+func StringifyProperties(v *Value) (string, error) {
+	if v == nil || v.Type != OBJECT {
+		return "", fmt.Errorf("只能转换对象")
+	}
+	var sb strings.Builder
+	err := flattenConfig(v, "", func(key, value string) error {
+		sb.WriteString(escapeProperty(key, true))
+		sb.WriteByte('=')
+		sb.WriteString(escapeProperty(value, false))
+		sb.WriteByte('\n')
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// StringifyINI 将对象转换为 INI 文本，是 ParseINI 的逆操作
+//
+// 顶层的标量写在第一个节之前，顶层对象成为节，更深的对象成为 [a.b] 形式的子节。
+func StringifyINI(v *Value) (string, error) {
+	if v == nil || v.Type != OBJECT {
+		return "", fmt.Errorf("只能转换对象")
+	}
+	var sb strings.Builder
+	if err := writeINISection(&sb, v, ""); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// writeINISection 先写出节内的标量和数组，再递归写出子节
+func writeINISection(sb *strings.Builder, v *Value, section string) error {
+	wroteHeader := section == ""
+	for _, member := range v.O {
+		if member.V.Type == OBJECT {
+			continue
+		}
+		if err := checkConfigKey(member.K); err != nil {
+			return err
+		}
+		if !wroteHeader {
+			writeINIHeader(sb, section)
+			wroteHeader = true
+		}
+		err := flattenConfig(member.V, member.K, func(key, value string) error {
+			if strings.ContainsAny(value, "\n\r") {
+				return fmt.Errorf("键 '%s' 的值包含换行，无法表示为INI", key)
+			}
+			fmt.Fprintf(sb, "%s = %s\n", key, quoteINIValue(value))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// 空对象也写出节名，使 ParseINI 可以还原
+	if !wroteHeader && len(v.O) == 0 {
+		writeINIHeader(sb, section)
+	}
+
+	for _, member := range v.O {
+		if member.V.Type != OBJECT {
+			continue
+		}
+		if err := checkConfigKey(member.K); err != nil {
+			return err
+		}
+		child := member.K
+		if section != "" {
+			child = section + "." + member.K
+		}
+		if err := writeINISection(sb, member.V, child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeINIHeader(sb *strings.Builder, section string) {
+	if sb.Len() > 0 {
+		sb.WriteByte('\n')
+	}
+	fmt.Fprintf(sb, "[%s]\n", section)
+}
+
+// quoteINIValue 为首尾有空白或以引号包围的值加上双引号，使其能被 ParseINI 还原
+func quoteINIValue(value string) string {
+	if value != strings.TrimSpace(value) || (len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"') {
+		return `"` + value + `"`
+	}
+	return value
+}
+
+// flattenConfig 将值展开为以 '.' 连接的键和文本值
+func flattenConfig(v *Value, prefix string, emit func(key, value string) error) error {
+	join := func(segment string) string {
+		if prefix == "" {
+			return segment
+		}
+		return prefix + "." + segment
+	}
+
+	switch v.Type {
+	case OBJECT:
+		for _, member := range v.O {
+			if err := checkConfigKey(member.K); err != nil {
+				return err
+			}
+			if err := flattenConfig(member.V, join(member.K), emit); err != nil {
+				return err
+			}
+		}
+	case ARRAY:
+		for i, element := range v.A {
+			if err := flattenConfig(element, join(strconv.Itoa(i)), emit); err != nil {
+				return err
+			}
+		}
+	case STRING:
+		return emit(prefix, GetString(v))
+	case NUMBER:
+		return emit(prefix, strconv.FormatFloat(v.N, 'g', -1, 64))
+	case TRUE:
+		return emit(prefix, "true")
+	case FALSE:
+		return emit(prefix, "false")
+	default:
+		return emit(prefix, "")
+	}
+	return nil
+}
+
+// checkConfigKey 检查键能否在扁平格式中无歧义地表示
+func checkConfigKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("键不能为空")
+	}
+	if strings.ContainsAny(key, ".\n\r") {
+		return fmt.Errorf("键 '%s' 包含 '.' 或换行，无法表示为扁平配置", key)
+	}
+	return nil
+}
+
+// setDottedKey 将 key 按 '.' 拆分，在 root 中逐级创建对象并设置字符串值
+func setDottedKey(root *Value, key string, value string) error {
+	segments := strings.Split(key, ".")
+	parent := root
+	for i, segment := range segments {
+		if segment == "" {
+			return fmt.Errorf("键 '%s' 包含空的部分", key)
+		}
+		child, exists := FindObjectKey(parent, segment)
+		last := i == len(segments)-1
+		switch {
+		case !exists:
+			child = SetObjectValue(parent, segment)
+			if !last {
+				SetObject(child)
+			}
+		case last && child.Type == OBJECT:
+			return fmt.Errorf("键 '%s' 与已有的同名分组冲突", key)
+		case !last && child.Type != OBJECT:
+			return fmt.Errorf("键 '%s' 与已有的键 '%s' 冲突", key, strings.Join(segments[:i+1], "."))
+		}
+		if last {
+			SetString(child, value)
+		}
+		parent = child
+	}
+	return nil
+}
+
+// ensureDottedObject 确保节名指向的对象存在
+func ensureDottedObject(root *Value, section string) error {
+	parent := root
+	for _, segment := range strings.Split(section, ".") {
+		if segment == "" {
+			return fmt.Errorf("节名 '%s' 包含空的部分", section)
+		}
+		child, exists := FindObjectKey(parent, segment)
+		if !exists {
+			child = SetObjectValue(parent, segment)
+			SetObject(child)
+		} else if child.Type != OBJECT {
+			return fmt.Errorf("节名 '%s' 与已有的键冲突", section)
+		}
+		parent = child
+	}
+	return nil
+}
+
+// splitConfigLines 按 \n、\r\n 或 \r 拆分行
+func splitConfigLines(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.Split(strings.ReplaceAll(text, "\r", "\n"), "\n")
+}
+
+// endsWithContinuation 判断行尾是否有奇数个反斜杠
+func endsWithContinuation(line string) bool {
+	n := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// splitPropertyLine 在第一个未转义的 '='、':' 或空白处拆分键和值
+func splitPropertyLine(line string) (string, string) {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '=', ':':
+			return line[:i], strings.TrimLeft(line[i+1:], " \t\f")
+		case ' ', '\t', '\f':
+			rest := strings.TrimLeft(line[i:], " \t\f")
+			if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+				rest = strings.TrimLeft(rest[1:], " \t\f")
+			}
+			return line[:i], rest
+		}
+	}
+	return line, ""
+}
+
+// unescapeProperty 处理 properties 的转义序列
+func unescapeProperty(s string) (string, error) {
+	if strings.IndexByte(s, '\\') < 0 {
+		return s, nil
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if ch != '\\' || i+1 >= len(s) {
+			sb.WriteByte(ch)
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			sb.WriteByte('\t')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'u':
+			if i+4 >= len(s) {
+				return "", fmt.Errorf("不完整的 \\u 转义")
+			}
+			code, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("无效的 \\u 转义: %s", s[i+1:i+5])
+			}
+			sb.WriteRune(rune(code))
+			i += 4
+		default:
+			// 其他字符（包括 \\、\=、\:、\ 和 \#）表示字符本身
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String(), nil
+}
+
+// escapeProperty 转义 properties 中的特殊字符，键中的空白、'=' 和 ':' 也需要转义
+func escapeProperty(s string, isKey bool) string {
+	var sb strings.Builder
+	for i, r := range s {
+		switch r {
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\f':
+			sb.WriteString(`\f`)
+		case '=', ':', '#', '!':
+			if isKey {
+				sb.WriteByte('\\')
+			}
+			sb.WriteRune(r)
+		case ' ':
+			// 值开头的空白会被忽略，需要转义
+			if isKey || i == 0 {
+				sb.WriteByte('\\')
+			}
+			sb.WriteRune(r)
+		default:
+			if r == utf8.RuneError || r < 0x20 {
+				fmt.Fprintf(&sb, `\u%04x`, r)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// flatConfigParsers 按扩展名选择扁平配置的解析函数
+var flatConfigParsers = map[string]func(string) (*Value, error){
+	".properties": ParseProperties,
+	".ini":        ParseINI,
+}
+
+// flatConfigParser 返回文件对应的扁平配置解析函数，不是扁平配置文件时返回 nil
+func flatConfigParser(filename string) func(string) (*Value, error) {
+	return flatConfigParsers[strings.ToLower(filepath.Ext(filename))]
+}
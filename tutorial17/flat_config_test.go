@@ -0,0 +1,186 @@
+package leptjson
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseProperties(t *testing.T) {
+	input := "# 注释\n" +
+		"! 另一种注释\n" +
+		"server.port=8080\n" +
+		"server.host : localhost\n" +
+		"  server.name   my\\ app\n" +
+		"message = hello \\\n" +
+		"          world\n" +
+		"path=C:\\\\temp\\tdir\n" +
+		"greeting=\\u4f60\\u597d\n" +
+		"key\\=with\\:sep=v\n" +
+		"empty\n" +
+		"server.port=9090\r\n" +
+		"trailing=\\\\\n"
+	v, err := ParseProperties(input)
+	if err != nil {
+		t.Fatalf("ParseProperties 失败: %v", err)
+	}
+	got, _ := Stringify(v)
+	expected := `{"server":{"port":"9090","host":"localhost","name":"my app"},"message":"hello world",` +
+		`"path":"C:\\temp\tdir","greeting":"你好","key=with:sep":"v","empty":"","trailing":"\\"}`
+	if got != expected {
+		t.Errorf("ParseProperties = %s\n期望 %s", got, expected)
+	}
+
+	tests := []string{
+		"a=1\na.b=2",
+		"a.b=1\na=2",
+		"a..b=1",
+		"a=\\u12",
+		"a=\\uzzzz",
+	}
+	for _, input := range tests {
+		if _, err := ParseProperties(input); err == nil {
+			t.Errorf("ParseProperties(%q) 应当返回错误", input)
+		}
+	}
+}
+
+func TestParseINI(t *testing.T) {
+	input := `; 全局设置
+name = demo
+
+[database]
+host = "  db.local  "
+port: 5432
+
+[database.replica]
+host = replica.local
+
+# 注释
+[features]
+beta.enabled = true
+`
+	v, err := ParseINI(input)
+	if err != nil {
+		t.Fatalf("ParseINI 失败: %v", err)
+	}
+	got, _ := Stringify(v)
+	expected := `{"name":"demo","database":{"host":"  db.local  ","port":"5432","replica":{"host":"replica.local"}},"features":{"beta":{"enabled":"true"}}}`
+	if got != expected {
+		t.Errorf("ParseINI = %s\n期望 %s", got, expected)
+	}
+
+	tests := []string{
+		"[a\nx=1",
+		"[]",
+		"just text",
+		"a=1\n[a]",
+		"[a]\nb=1\n[a.b]",
+	}
+	for _, input := range tests {
+		if _, err := ParseINI(input); err == nil {
+			t.Errorf("ParseINI(%q) 应当返回错误", input)
+		}
+	}
+}
+
+func TestStringifyProperties(t *testing.T) {
+	v := &Value{}
+	Parse(v, `{"server":{"port":8080,"host":" local","tags":["a","b"]},"key=x":"a=b#c","debug":false,"none":null,"text":"line\nnext"}`)
+	got, err := StringifyProperties(v)
+	if err != nil {
+		t.Fatalf("StringifyProperties 失败: %v", err)
+	}
+	expected := "server.port=8080\n" +
+		"server.host=\\ local\n" +
+		"server.tags.0=a\n" +
+		"server.tags.1=b\n" +
+		"key\\=x=a=b#c\n" +
+		"debug=false\n" +
+		"none=\n" +
+		"text=line\\nnext\n"
+	if got != expected {
+		t.Errorf("StringifyProperties =\n%s\n期望\n%s", got, expected)
+	}
+
+	// 往返后字符串值保持不变
+	back, err := ParseProperties(got)
+	if err != nil {
+		t.Fatalf("ParseProperties 失败: %v", err)
+	}
+	if host := GetObjectValueByKey(GetObjectValueByKey(back, "server"), "host"); host.S != " local" {
+		t.Errorf("往返后的值 = %q", host.S)
+	}
+	if text := GetObjectValueByKey(back, "text"); text.S != "line\nnext" {
+		t.Errorf("往返后的值 = %q", text.S)
+	}
+
+	for _, input := range []string{`[1]`, `{"a.b":1}`, `{"":1}`} {
+		Parse(v, input)
+		if _, err := StringifyProperties(v); err == nil {
+			t.Errorf("StringifyProperties(%s) 应当返回错误", input)
+		}
+	}
+}
+
+func TestStringifyINI(t *testing.T) {
+	v := &Value{}
+	Parse(v, `{"name":"demo","database":{"host":" db ","replica":{"host":"r"},"port":5432},"empty":{},"ids":[1,2]}`)
+	got, err := StringifyINI(v)
+	if err != nil {
+		t.Fatalf("StringifyINI 失败: %v", err)
+	}
+	expected := "name = demo\n" +
+		"ids.0 = 1\n" +
+		"ids.1 = 2\n" +
+		"\n[database]\n" +
+		"host = \" db \"\n" +
+		"port = 5432\n" +
+		"\n[database.replica]\n" +
+		"host = r\n" +
+		"\n[empty]\n"
+	if got != expected {
+		t.Errorf("StringifyINI =\n%s\n期望\n%s", got, expected)
+	}
+
+	back, err := ParseINI(got)
+	if err != nil {
+		t.Fatalf("ParseINI 失败: %v", err)
+	}
+	if s, _ := Stringify(back); s != `{"name":"demo","ids":{"0":"1","1":"2"},"database":{"host":" db ","port":"5432","replica":{"host":"r"}},"empty":{}}` {
+		t.Errorf("往返结果 = %s", s)
+	}
+
+	Parse(v, `{"a":{"b":"x\ny"}}`)
+	if _, err := StringifyINI(v); err == nil {
+		t.Error("包含换行的值应当返回错误")
+	}
+}
+
+func TestLoadFlatConfigFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"app.properties": "db.host=localhost\ndb.port=5432\n",
+		"app.INI":        "[db]\nhost=localhost\nport=5432\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		v, err := loadJSON(path, false)
+		if err != nil {
+			t.Fatalf("loadJSON(%s) 失败: %v", name, err)
+		}
+		if got, _ := Stringify(v); got != `{"db":{"host":"localhost","port":"5432"}}` {
+			t.Errorf("loadJSON(%s) = %s", name, got)
+		}
+	}
+
+	path := filepath.Join(dir, "bad.ini")
+	os.WriteFile(path, []byte("[broken"), 0644)
+	if _, err := loadJSON(path, false); err == nil || !strings.Contains(err.Error(), "配置文件") {
+		t.Errorf("无效的INI文件应当返回错误: %v", err)
+	}
+}
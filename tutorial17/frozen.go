@@ -0,0 +1,319 @@
+// frozen.go - 不可变的JSON值快照（写时复制）
+package leptjson
+
+// FrozenValue 是JSON值的只读视图
+//
+// 通过 Freeze 创建的视图不会再被修改，因此可以在多个goroutine之间安全共享。
+// 需要修改时调用 CloneMutable 获取可写副本，副本只复制修改路径上的节点，
+// 未修改的子树在新旧快照之间共享。
+type FrozenValue struct {
+	v *Value
+}
+
+// MutableValue 是 FrozenValue 的可写副本
+//
+// 写操作按路径复制节点（path copying），不会影响任何已经冻结的快照。
+// MutableValue 本身不是并发安全的，同一时间只应由一个写者使用。
+type MutableValue struct {
+	root  *Value
+	owned map[*Value]bool // 本副本私有、可以原地修改的节点
+}
+
+// Freeze 深拷贝v并返回其只读视图，之后对v的修改不会影响该视图
+func Freeze(v *Value) FrozenValue {
+	if v == nil {
+		return FrozenValue{v: &Value{Type: NULL}}
+	}
+	root := &Value{}
+	Copy(root, v)
+	return FrozenValue{v: root}
+}
+
+// IsValid 判断视图是否指向一个值（零值FrozenValue无效）
+func (f FrozenValue) IsValid() bool {
+	return f.v != nil
+}
+
+// Type 返回值类型
+func (f FrozenValue) Type() ValueType {
+	if f.v == nil {
+		return NULL
+	}
+	return f.v.Type
+}
+
+// GetBoolean 返回布尔值
+func (f FrozenValue) GetBoolean() bool {
+	return f.v != nil && f.v.Type == TRUE
+}
+
+// GetNumber 返回数字值
+func (f FrozenValue) GetNumber() float64 {
+	if f.v == nil {
+		return 0
+	}
+	return f.v.N
+}
+
+// GetString 返回字符串值
+func (f FrozenValue) GetString() string {
+	if f.v == nil {
+		return ""
+	}
+	return GetString(f.v)
+}
+
+// Len 返回数组元素个数或对象成员个数
+func (f FrozenValue) Len() int {
+	if f.v == nil {
+		return 0
+	}
+	switch f.v.Type {
+	case ARRAY:
+		return len(f.v.A)
+	case OBJECT:
+		return len(f.v.O)
+	default:
+		return 0
+	}
+}
+
+// Index 返回数组中指定索引的元素，索引越界时返回无效视图
+func (f FrozenValue) Index(i int) FrozenValue {
+	if f.v == nil || f.v.Type != ARRAY || i < 0 || i >= len(f.v.A) {
+		return FrozenValue{}
+	}
+	return FrozenValue{v: f.v.A[i]}
+}
+
+// Member 返回对象中指定位置的成员
+func (f FrozenValue) Member(i int) (string, FrozenValue) {
+	if f.v == nil || f.v.Type != OBJECT || i < 0 || i >= len(f.v.O) {
+		return "", FrozenValue{}
+	}
+	return f.v.O[i].K, FrozenValue{v: f.v.O[i].V}
+}
+
+// Find 根据键查找对象成员
+func (f FrozenValue) Find(key string) (FrozenValue, bool) {
+	if f.v == nil {
+		return FrozenValue{}, false
+	}
+	value, ok := FindObjectKey(f.v, key)
+	if !ok {
+		return FrozenValue{}, false
+	}
+	return FrozenValue{v: value}, true
+}
+
+// Get 根据JSON指针获取子值的只读视图
+func (f FrozenValue) Get(pointer string) (FrozenValue, error) {
+	p, err := ParseJSONPointer(pointer)
+	if err != POINTER_OK {
+		return FrozenValue{}, err
+	}
+	if f.v == nil {
+		return FrozenValue{}, POINTER_INVALID_TARGET
+	}
+	value, err := p.Get(f.v)
+	if err != POINTER_OK {
+		return FrozenValue{}, err
+	}
+	return FrozenValue{v: value}, nil
+}
+
+// Stringify 将快照转换为JSON字符串
+func (f FrozenValue) Stringify() (string, StringifyError) {
+	return Stringify(f.v)
+}
+
+// Equal 判断两个快照是否相等，共享同一节点时无需遍历
+func (f FrozenValue) Equal(other FrozenValue) bool {
+	if f.v == other.v {
+		return true
+	}
+	if f.v == nil || other.v == nil {
+		return false
+	}
+	return Equal(f.v, other.v)
+}
+
+// Thaw 返回快照的可变深拷贝，可以使用普通的 Value API 进行修改
+func (f FrozenValue) Thaw() *Value {
+	result := &Value{}
+	if f.v == nil {
+		return result
+	}
+	Copy(result, f.v)
+	return result
+}
+
+// CloneMutable 创建一个与快照共享全部节点的可写副本，复制开销为O(1)
+func (f FrozenValue) CloneMutable() *MutableValue {
+	root := f.v
+	if root == nil {
+		root = &Value{Type: NULL}
+	}
+	return &MutableValue{root: root, owned: make(map[*Value]bool)}
+}
+
+// Get 根据JSON指针获取当前值的只读视图
+//
+// 返回的视图在下一次写操作之后可能失效，需要长期持有时应先调用 Freeze。
+func (m *MutableValue) Get(pointer string) (FrozenValue, error) {
+	return FrozenValue{v: m.root}.Get(pointer)
+}
+
+// Set 根据JSON指针设置值
+//
+// 对象中的键存在时替换，不存在时添加；数组索引必须在范围内，
+// 使用 "-" 或等于数组长度的索引时追加到末尾。空指针替换整个文档。
+func (m *MutableValue) Set(pointer string, value *Value) error {
+	p, err := ParseJSONPointer(pointer)
+	if err != POINTER_OK {
+		return err
+	}
+
+	newValue := &Value{}
+	Copy(newValue, value)
+
+	if len(p.tokens) == 0 {
+		m.root = newValue
+		m.owned[newValue] = true
+		return nil
+	}
+
+	parent, err := m.ownPath(p.tokens[:len(p.tokens)-1])
+	if err != POINTER_OK {
+		return err
+	}
+
+	lastToken := p.tokens[len(p.tokens)-1]
+	switch parent.Type {
+	case ARRAY:
+		if lastToken == "-" {
+			parent.A = append(parent.A, newValue)
+			return nil
+		}
+		index, ok := parseArrayIndexToken(lastToken, false)
+		if !ok || index > len(parent.A) {
+			return POINTER_INDEX_OUT_OF_RANGE
+		}
+		if index == len(parent.A) {
+			parent.A = append(parent.A, newValue)
+		} else {
+			parent.A[index] = newValue
+		}
+	case OBJECT:
+		for i := range parent.O {
+			if parent.O[i].K == lastToken {
+				parent.O[i].V = newValue
+				return nil
+			}
+		}
+		parent.O = append(parent.O, Member{K: lastToken, V: newValue})
+	default:
+		return POINTER_INVALID_TARGET
+	}
+	return nil
+}
+
+// Remove 根据JSON指针删除值
+func (m *MutableValue) Remove(pointer string) error {
+	p, err := ParseJSONPointer(pointer)
+	if err != POINTER_OK {
+		return err
+	}
+	// 不能删除根节点
+	if len(p.tokens) == 0 {
+		return POINTER_INVALID_TARGET
+	}
+
+	parent, err := m.ownPath(p.tokens[:len(p.tokens)-1])
+	if err != POINTER_OK {
+		return err
+	}
+
+	lastToken := p.tokens[len(p.tokens)-1]
+	switch parent.Type {
+	case ARRAY:
+		index, ok := parseArrayIndexToken(lastToken, false)
+		if !ok || index >= len(parent.A) {
+			return POINTER_INDEX_OUT_OF_RANGE
+		}
+		// 父节点的切片是私有的，可以直接在原地删除
+		parent.A = append(parent.A[:index], parent.A[index+1:]...)
+	case OBJECT:
+		for i := range parent.O {
+			if parent.O[i].K == lastToken {
+				parent.O = append(parent.O[:i], parent.O[i+1:]...)
+				return nil
+			}
+		}
+		return POINTER_KEY_NOT_FOUND
+	default:
+		return POINTER_INVALID_TARGET
+	}
+	return nil
+}
+
+// Freeze 将当前状态冻结为新的快照
+//
+// 之后对 MutableValue 的修改会重新复制路径上的节点，不会影响返回的快照。
+func (m *MutableValue) Freeze() FrozenValue {
+	m.owned = make(map[*Value]bool)
+	return FrozenValue{v: m.root}
+}
+
+// ownPath 沿路径复制所有共享节点，返回路径末端的私有节点
+func (m *MutableValue) ownPath(tokens []string) (*Value, JSONPointerError) {
+	m.root = m.own(m.root)
+	current := m.root
+
+	for _, token := range tokens {
+		switch current.Type {
+		case ARRAY:
+			index, ok := parseArrayIndexToken(token, false)
+			if !ok || index >= len(current.A) {
+				return nil, POINTER_INDEX_OUT_OF_RANGE
+			}
+			current.A[index] = m.own(current.A[index])
+			current = current.A[index]
+		case OBJECT:
+			found := false
+			for i := range current.O {
+				if current.O[i].K == token {
+					current.O[i].V = m.own(current.O[i].V)
+					current = current.O[i].V
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, POINTER_KEY_NOT_FOUND
+			}
+		default:
+			return nil, POINTER_INVALID_TARGET
+		}
+	}
+	return current, POINTER_OK
+}
+
+// own 返回节点的私有版本：已私有的节点直接返回，否则做一次浅拷贝
+func (m *MutableValue) own(v *Value) *Value {
+	if m.owned[v] {
+		return v
+	}
+
+	clone := &Value{Type: v.Type, N: v.N, S: v.S, chunks: v.chunks}
+	if v.A != nil {
+		clone.A = make([]*Value, len(v.A), len(v.A)+1)
+		copy(clone.A, v.A)
+	}
+	if v.O != nil {
+		clone.O = make([]Member, len(v.O), len(v.O)+1)
+		copy(clone.O, v.O)
+	}
+	m.owned[clone] = true
+	return clone
+}
@@ -0,0 +1,137 @@
+package leptjson
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestFreezeIsolation(t *testing.T) {
+	v := &Value{}
+	if err := Parse(v, `{"a":[1,2],"b":"x"}`); err != PARSE_OK {
+		t.Fatalf("解析失败: %s", err.Error())
+	}
+
+	frozen := Freeze(v)
+
+	// 修改原始值不应影响快照
+	SetString(GetObjectValueByKey(v, "b"), "changed")
+	if got, _ := frozen.Stringify(); got != `{"a":[1,2],"b":"x"}` {
+		t.Errorf("快照被原始值的修改影响: %s", got)
+	}
+
+	a, ok := frozen.Find("a")
+	if !ok || a.Type() != ARRAY || a.Len() != 2 || a.Index(1).GetNumber() != 2 {
+		t.Errorf("读取快照内容错误")
+	}
+	if a.Index(5).IsValid() {
+		t.Error("越界索引应当返回无效视图")
+	}
+
+	// Thaw 返回的是深拷贝
+	thawed := frozen.Thaw()
+	SetNull(thawed)
+	if frozen.Type() != OBJECT {
+		t.Error("修改Thaw的结果不应影响快照")
+	}
+}
+
+func TestCloneMutable(t *testing.T) {
+	v := &Value{}
+	Parse(v, `{"config":{"name":"a","tags":["x"]},"data":{"large":[1,2,3]}}`)
+	base := Freeze(v)
+
+	m := base.CloneMutable()
+	name := &Value{}
+	SetString(name, "b")
+	if err := m.Set("/config/name", name); err != nil {
+		t.Fatalf("Set 失败: %v", err)
+	}
+	if err := m.Set("/config/tags/-", name); err != nil {
+		t.Fatalf("Set 追加失败: %v", err)
+	}
+	if err := m.Remove("/config/tags/0"); err != nil {
+		t.Fatalf("Remove 失败: %v", err)
+	}
+	next := m.Freeze()
+
+	if got, _ := base.Stringify(); got != `{"config":{"name":"a","tags":["x"]},"data":{"large":[1,2,3]}}` {
+		t.Errorf("原快照被修改: %s", got)
+	}
+	if got, _ := next.Stringify(); got != `{"config":{"name":"b","tags":["b"]},"data":{"large":[1,2,3]}}` {
+		t.Errorf("新快照内容错误: %s", got)
+	}
+
+	// 未修改的子树应当共享
+	baseData, _ := base.Find("data")
+	nextData, _ := next.Find("data")
+	if baseData.v != nextData.v {
+		t.Error("未修改的子树没有被共享")
+	}
+
+	// Freeze 之后继续写入不应影响已冻结的快照
+	if err := m.Remove("/config"); err != nil {
+		t.Fatalf("Remove 失败: %v", err)
+	}
+	if _, ok := next.Find("config"); !ok {
+		t.Error("冻结后的写入影响了快照")
+	}
+}
+
+func TestMutableValueErrors(t *testing.T) {
+	v := &Value{}
+	Parse(v, `{"a":[1],"s":"x"}`)
+	m := Freeze(v).CloneMutable()
+	one := &Value{Type: NULL}
+
+	tests := []struct {
+		name string
+		op   func() error
+		want JSONPointerError
+	}{
+		{"无效指针", func() error { return m.Set("a", one) }, POINTER_INVALID_FORMAT},
+		{"索引越界", func() error { return m.Set("/a/3", one) }, POINTER_INDEX_OUT_OF_RANGE},
+		{"键不存在", func() error { return m.Remove("/missing") }, POINTER_KEY_NOT_FOUND},
+		{"中间路径不存在", func() error { return m.Set("/x/y", one) }, POINTER_KEY_NOT_FOUND},
+		{"标量无法遍历", func() error { return m.Set("/s/0", one) }, POINTER_INVALID_TARGET},
+		{"删除根节点", func() error { return m.Remove("") }, POINTER_INVALID_TARGET},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.op(); err != tt.want {
+				t.Errorf("错误 = %v, 期望 %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFrozenConcurrentReaders(t *testing.T) {
+	v := &Value{}
+	Parse(v, `{"items":[1,2,3,4,5]}`)
+	snapshot := Freeze(v)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				items, _ := snapshot.Get("/items")
+				_ = items.Index(j % 5).GetNumber()
+			}
+		}()
+	}
+
+	// 写者在副本上修改，读者不受影响
+	m := snapshot.CloneMutable()
+	for i := 0; i < 100; i++ {
+		n := &Value{}
+		SetNumber(n, float64(i))
+		m.Set("/items/0", n)
+	}
+	wg.Wait()
+
+	if first, _ := snapshot.Get("/items/0"); first.GetNumber() != 1 {
+		t.Errorf("快照被写者修改: %v", first.GetNumber())
+	}
+}
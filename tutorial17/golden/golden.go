@@ -0,0 +1,124 @@
+// Package golden 实现基于 .golden 文件的快照测试
+//
+// 测试把结果与 testdata 目录下保存的期望文档比较，结果有意改变时用 -update 重写期望：
+//
+//	func TestReport(t *testing.T) {
+//		got := buildReport()
+//		golden.Compare(t, "report", got) // 比较 testdata/report.golden
+//	}
+//
+//	go test ./... -update
+//
+// 文件以规范形式保存：对象键按字典序排列、字符串和键转换为 NFC、两个空格缩进，
+// 所以键顺序或 Unicode 形式的变化不会使快照改变。比较是结构化的，
+// 失败时按路径列出新增、删除、修改和移动的值，而不是逐行比较文本。
+package golden
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	leptjson "github.com/Cactusinhand/go-json-tutorial/tutorial17"
+)
+
+// 为 true 时 Compare 用实际结果重写 .golden 文件
+var update = flag.Bool("update", false, "用实际结果重写 .golden 文件")
+
+// Dir 是 .golden 文件所在的目录，相对于测试运行时的工作目录（即被测包的目录）
+var Dir = "testdata"
+
+// Path 返回名为 name 的快照文件的路径，name 可以包含 / 以使用子目录
+func Path(name string) string {
+	return filepath.Join(Dir, filepath.FromSlash(name)+".golden")
+}
+
+// Canonical 返回 v 的规范序列化结果，v 不会被修改
+//
+// 对象键按字典序排列，字符串和键转换为 NFC，缩进两个空格，以换行结尾。
+func Canonical(v *leptjson.Value) ([]byte, error) {
+	if v == nil {
+		return nil, fmt.Errorf("JSON 值不能为 nil")
+	}
+	canonical := &leptjson.Value{}
+	leptjson.Copy(canonical, v)
+	leptjson.NormalizeValue(canonical, leptjson.NORMALIZE_NFC)
+	s, err := leptjson.StringifyWithOptions(canonical, leptjson.StringifyOptions{
+		Indent:        "  ",
+		KeyComparator: leptjson.AlphabeticalKeyComparator,
+	})
+	if err != leptjson.STRINGIFY_OK {
+		return nil, fmt.Errorf("序列化失败: %v", err)
+	}
+	return []byte(s + "\n"), nil
+}
+
+// Update 把 got 的规范形式写入名为 name 的快照文件，必要时创建目录
+func Update(t testing.TB, name string, got *leptjson.Value) {
+	t.Helper()
+	data, err := Canonical(got)
+	if err != nil {
+		t.Fatalf("golden %s: %v", name, err)
+		return
+	}
+	path := Path(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("golden %s: %v", name, err)
+		return
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("golden %s: %v", name, err)
+	}
+}
+
+// Compare 断言 got 与名为 name 的快照相等，不相等时列出每一处差异
+//
+// 使用 -update 运行测试时改为调用 Update 重写快照。
+func Compare(t testing.TB, name string, got *leptjson.Value) {
+	t.Helper()
+	if *update {
+		Update(t, name, got)
+		return
+	}
+	if got == nil {
+		t.Fatalf("golden %s: JSON 值不能为 nil", name)
+		return
+	}
+	path := Path(name)
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.Fatalf("golden %s: 快照 %s 不存在，使用 -update 运行测试以生成", name, path)
+		return
+	}
+	if err != nil {
+		t.Fatalf("golden %s: %v", name, err)
+		return
+	}
+	want := &leptjson.Value{}
+	if perr := leptjson.Parse(want, string(data)); perr != leptjson.PARSE_OK {
+		t.Fatalf("golden %s: 解析快照 %s 失败: %v", name, path, perr)
+		return
+	}
+	if leptjson.EqualNormalized(want, got, leptjson.NORMALIZE_NFC) {
+		return
+	}
+
+	diffs, err := leptjson.CompareValuesWithOptions(want, got, leptjson.CompareOptions{
+		Normalization: leptjson.NORMALIZE_NFC,
+		LCSArrays:     true,
+	})
+	if err != nil {
+		t.Fatalf("golden %s: %v", name, err)
+		return
+	}
+	lines := make([]string, len(diffs))
+	for i, d := range diffs {
+		lines[i] = "  " + d.Message
+	}
+	t.Errorf("golden %s: 结果与快照 %s 不同（快照 → 实际）:\n%s\n结果有意改变时使用 -update 运行测试以更新快照",
+		name, path, strings.Join(lines, "\n"))
+}
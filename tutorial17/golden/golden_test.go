@@ -0,0 +1,109 @@
+package golden
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	leptjson "github.com/Cactusinhand/go-json-tutorial/tutorial17"
+)
+
+// recorder 记录断言的失败信息而不使测试失败
+type recorder struct {
+	testing.TB
+	errors []string
+	fatal  bool
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.fatal = true
+	r.Errorf(format, args...)
+}
+
+func mustParse(t *testing.T, json string) *leptjson.Value {
+	t.Helper()
+	v := &leptjson.Value{}
+	if err := leptjson.Parse(v, json); err != leptjson.PARSE_OK {
+		t.Fatalf("解析 %s 失败: %v", json, err)
+	}
+	return v
+}
+
+// useTempDir 让快照写入临时目录，测试结束后恢复
+func useTempDir(t *testing.T) {
+	old := Dir
+	Dir = t.TempDir()
+	t.Cleanup(func() { Dir = old })
+}
+
+func TestCanonical(t *testing.T) {
+	v := mustParse(t, `{"b": [1, {"z": 1, "a": "e\u0301"}], "a": null}`)
+	data, err := Canonical(v)
+	if err != nil {
+		t.Fatalf("Canonical 失败: %v", err)
+	}
+	expected := "{\n  \"a\": null,\n  \"b\": [\n    1,\n    {\n      \"a\": \"\u00e9\",\n      \"z\": 1\n    }\n  ]\n}\n"
+	if string(data) != expected {
+		t.Errorf("Canonical = %q, 期望 %q", data, expected)
+	}
+	if leptjson.GetString(v.O[0].V.A[1].O[1].V) != "e\u0301" {
+		t.Errorf("Canonical 不应该修改传入的值")
+	}
+}
+
+func TestUpdateAndCompare(t *testing.T) {
+	useTempDir(t)
+	Update(t, "nested/report", mustParse(t, `{"id": 1, "items": ["a", "b", "c"]}`))
+
+	data, err := ioutil.ReadFile(Path("nested/report"))
+	if err != nil {
+		t.Fatalf("读取快照失败: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "{\n  \"id\": 1,") {
+		t.Errorf("快照内容 = %q", data)
+	}
+
+	// 键顺序和 Unicode 形式不影响比较
+	r := &recorder{TB: t}
+	Compare(r, "nested/report", mustParse(t, `{"items": ["a", "b", "c"], "id": 1}`))
+	if len(r.errors) != 0 {
+		t.Errorf("相同的结果不应该失败: %v", r.errors)
+	}
+
+	r = &recorder{TB: t}
+	Compare(r, "nested/report", mustParse(t, `{"id": 2, "items": ["a", "x", "b", "c"]}`))
+	if len(r.errors) != 1 || r.fatal {
+		t.Fatalf("不同的结果应该 Errorf 一次，得到 %v", r.errors)
+	}
+	for _, s := range []string{"$.id", "1 vs 2", "第二个JSON有元素 \"x\"", "-update"} {
+		if !strings.Contains(r.errors[0], s) {
+			t.Errorf("失败信息中没有 %q:\n%s", s, r.errors[0])
+		}
+	}
+}
+
+func TestCompareMissing(t *testing.T) {
+	useTempDir(t)
+	r := &recorder{TB: t}
+	Compare(r, "missing", mustParse(t, `{}`))
+	if !r.fatal || !strings.Contains(r.errors[0], "-update") {
+		t.Errorf("快照不存在时应该 Fatalf 并提示 -update，得到 %v", r.errors)
+	}
+}
+
+func TestCompareUpdateFlag(t *testing.T) {
+	useTempDir(t)
+	*update = true
+	defer func() { *update = false }()
+
+	Compare(t, "flag", mustParse(t, `[1, 2]`))
+	*update = false
+	Compare(t, "flag", mustParse(t, `[1, 2]`))
+}
@@ -0,0 +1,139 @@
+// growth_policy.go - 数组和对象容量不足时的扩容策略
+package leptjson
+
+import (
+	"sync/atomic"
+)
+
+// GrowthStrategy 是容量不足时计算新容量的方式
+type GrowthStrategy int
+
+const (
+	GROWTH_DOUBLE  GrowthStrategy = iota // 容量翻倍（默认），扩容次数最少
+	GROWTH_GRADUAL                       // 容量增加 1/4，峰值内存更低
+	GROWTH_FIXED                         // 每次增加固定的 Increment 个
+)
+
+// GrowthPolicy 决定数组和对象的初始容量和扩容方式
+//
+// 零值是默认策略：从小容量开始翻倍。包含上百万个元素的数组在翻倍时，
+// 新旧两个切片同时存在，峰值内存可能是实际需要的三倍；已知文档的大致
+// 规模时，设置初始容量或使用更平缓的策略可以降低峰值内存。
+type GrowthPolicy struct {
+	Strategy       GrowthStrategy
+	Increment      int // GROWTH_FIXED 每次增加的数量，不大于 0 时按 1 处理
+	ArrayCapacity  int // 数组第一次分配的容量，0 表示由策略决定
+	ObjectCapacity int // 对象第一次分配的容量，0 表示由策略决定
+}
+
+// isDefault 判断是否为默认策略，解析时默认策略使用切片池
+func (p GrowthPolicy) isDefault() bool {
+	return p == GrowthPolicy{}
+}
+
+// next 返回容量 capacity 不足时的新容量，initial 是第一次分配的容量提示
+func (p GrowthPolicy) next(capacity, initial int) int {
+	if capacity == 0 && initial > 0 {
+		return initial
+	}
+	switch p.Strategy {
+	case GROWTH_GRADUAL:
+		return capacity + maxInt(1, capacity/4)
+	case GROWTH_FIXED:
+		return capacity + maxInt(1, p.Increment)
+	default:
+		return maxInt(1, capacity*2)
+	}
+}
+
+// growthPolicyHolder 包装 GrowthPolicy，使 atomic.Value 中始终保存同一种类型
+type growthPolicyHolder struct {
+	policy GrowthPolicy
+}
+
+var defaultGrowthPolicy atomic.Value // growthPolicyHolder
+
+// SetGrowthPolicy 设置 PushBackArrayElement 和 SetObjectValue 的扩容策略
+//
+// 解析时的扩容策略由 ParseOptions.Growth 单独设置。
+func SetGrowthPolicy(policy GrowthPolicy) {
+	defaultGrowthPolicy.Store(growthPolicyHolder{policy: policy})
+}
+
+// currentGrowthPolicy 返回当前的扩容策略，没有设置时返回默认策略
+func currentGrowthPolicy() GrowthPolicy {
+	holder, _ := defaultGrowthPolicy.Load().(growthPolicyHolder)
+	return holder.policy
+}
+
+// SuggestGrowthPolicy 根据已有的同类文档推荐解析时使用的扩容策略
+//
+// 初始容量取样本中数组长度和对象成员数的平均值（向上取整）；样本中有超过
+// 65536 个元素的数组或对象时使用 GROWTH_GRADUAL，以降低大数组扩容时的峰值内存。
+func SuggestGrowthPolicy(samples ...*Value) GrowthPolicy {
+	var arrays, elems, objects, members, largest int
+	var walk func(v *Value)
+	walk = func(v *Value) {
+		switch v.Type {
+		case ARRAY:
+			arrays++
+			elems += len(v.A)
+			largest = maxInt(largest, len(v.A))
+			for _, elem := range v.A {
+				walk(elem)
+			}
+		case OBJECT:
+			objects++
+			members += len(v.O)
+			largest = maxInt(largest, len(v.O))
+			for _, m := range v.O {
+				walk(m.V)
+			}
+		}
+	}
+	for _, sample := range samples {
+		if sample != nil {
+			walk(sample)
+		}
+	}
+
+	var policy GrowthPolicy
+	if arrays > 0 {
+		policy.ArrayCapacity = (elems + arrays - 1) / arrays
+	}
+	if objects > 0 {
+		policy.ObjectCapacity = (members + objects - 1) / objects
+	}
+	if largest > 1<<16 {
+		policy.Strategy = GROWTH_GRADUAL
+	}
+	return policy
+}
+
+// appendValue 按 ParseOptions.Growth 向数组追加元素
+func (c *parseContext) appendValue(s []*Value, v *Value) []*Value {
+	policy := c.options.Growth
+	if policy.isDefault() {
+		return appendValue(s, v)
+	}
+	if len(s) == cap(s) {
+		grown := make([]*Value, len(s), policy.next(cap(s), policy.ArrayCapacity))
+		copy(grown, s)
+		s = grown
+	}
+	return append(s, v)
+}
+
+// appendMember 按 ParseOptions.Growth 向对象追加成员
+func (c *parseContext) appendMember(s []Member, m Member) []Member {
+	policy := c.options.Growth
+	if policy.isDefault() {
+		return appendMember(s, m)
+	}
+	if len(s) == cap(s) {
+		grown := make([]Member, len(s), policy.next(cap(s), policy.ObjectCapacity))
+		copy(grown, s)
+		s = grown
+	}
+	return append(s, m)
+}
@@ -0,0 +1,116 @@
+package leptjson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGrowthPolicyNext(t *testing.T) {
+	tests := []struct {
+		policy   GrowthPolicy
+		capacity int
+		initial  int
+		want     int
+	}{
+		{GrowthPolicy{}, 0, 0, 1},
+		{GrowthPolicy{}, 8, 0, 16},
+		{GrowthPolicy{}, 0, 100, 100},
+		{GrowthPolicy{}, 100, 100, 200},
+		{GrowthPolicy{Strategy: GROWTH_GRADUAL}, 0, 0, 1},
+		{GrowthPolicy{Strategy: GROWTH_GRADUAL}, 3, 0, 4},
+		{GrowthPolicy{Strategy: GROWTH_GRADUAL}, 1000, 0, 1250},
+		{GrowthPolicy{Strategy: GROWTH_FIXED, Increment: 64}, 0, 0, 64},
+		{GrowthPolicy{Strategy: GROWTH_FIXED, Increment: 64}, 128, 0, 192},
+		{GrowthPolicy{Strategy: GROWTH_FIXED}, 5, 0, 6},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.next(tt.capacity, tt.initial); got != tt.want {
+			t.Errorf("%+v.next(%d, %d) = %d, 期望 %d", tt.policy, tt.capacity, tt.initial, got, tt.want)
+		}
+	}
+}
+
+func TestSetGrowthPolicy(t *testing.T) {
+	defer SetGrowthPolicy(GrowthPolicy{})
+	SetGrowthPolicy(GrowthPolicy{Strategy: GROWTH_FIXED, Increment: 10, ArrayCapacity: 3, ObjectCapacity: 2})
+
+	arr := &Value{}
+	SetArray(arr, 0)
+	var caps []int
+	for i := 0; i < 5; i++ {
+		SetNumber(PushBackArrayElement(arr), float64(i))
+		caps = append(caps, cap(arr.A))
+	}
+	if want := []int{3, 3, 3, 13, 13}; !equalInts(caps, want) {
+		t.Errorf("数组容量变化 = %v, 期望 %v", caps, want)
+	}
+
+	obj := &Value{}
+	SetObject(obj)
+	for _, key := range []string{"a", "b", "c"} {
+		SetNull(SetObjectValue(obj, key))
+	}
+	if cap(obj.O) != 12 {
+		t.Errorf("对象容量 = %d, 期望 12", cap(obj.O))
+	}
+}
+
+func TestParseWithGrowthPolicy(t *testing.T) {
+	json := `[` + strings.Repeat(`{"a":1,"b":[1,2,3]},`, 99) + `{"a":1,"b":[1,2,3]}]`
+	options := DefaultParseOptions()
+	options.MaxTotalSize = len(json)
+	for _, policy := range []GrowthPolicy{
+		{},
+		{Strategy: GROWTH_GRADUAL},
+		{Strategy: GROWTH_FIXED, Increment: 7},
+		{ArrayCapacity: 3, ObjectCapacity: 2},
+	} {
+		options.Growth = policy
+		v := &Value{}
+		if err := ParseWithOptions(v, json, options); err != PARSE_OK {
+			t.Fatalf("%+v: %v", policy, err)
+		}
+		if s, _ := Stringify(v); s != json {
+			t.Errorf("%+v: 解析结果 = %s", policy, s)
+		}
+		if policy.ArrayCapacity == 3 && cap(findObjectKey(v.A[0], "b").A) != 3 {
+			t.Errorf("内层数组容量 = %d, 期望 3", cap(findObjectKey(v.A[0], "b").A))
+		}
+	}
+}
+
+func TestSuggestGrowthPolicy(t *testing.T) {
+	v := &Value{}
+	if err := Parse(v, `{"a":[1,2,3,4],"b":[1],"c":{"x":1}}`); err != PARSE_OK {
+		t.Fatal(err)
+	}
+	// 两个数组平均 2.5 个元素，两个对象平均 2 个成员
+	want := GrowthPolicy{ArrayCapacity: 3, ObjectCapacity: 2}
+	if got := SuggestGrowthPolicy(v); got != want {
+		t.Errorf("SuggestGrowthPolicy = %+v, 期望 %+v", got, want)
+	}
+
+	large := &Value{}
+	SetArray(large, 1<<16+1)
+	for i := 0; i < 1<<16+1; i++ {
+		PushBackArrayElement(large)
+	}
+	if got := SuggestGrowthPolicy(large, nil); got.Strategy != GROWTH_GRADUAL || got.ArrayCapacity != 1<<16+1 {
+		t.Errorf("SuggestGrowthPolicy(大数组) = %+v", got)
+	}
+	if got := SuggestGrowthPolicy(); got != (GrowthPolicy{}) {
+		t.Errorf("SuggestGrowthPolicy() = %+v", got)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,237 @@
+// html_report.go - 将比较和验证结果渲染为独立的HTML报告
+package leptjson
+
+import (
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// reportLine 是报告中格式化后的一行JSON
+type reportLine struct {
+	Path  string // 该行对应的值的路径，与 Difference 和 ValidationIssue 的路径格式相同
+	Text  string
+	Class string // 高亮样式，空表示不高亮
+}
+
+// reportPane 是并排显示的一侧文档
+type reportPane struct {
+	Name  string
+	Lines []reportLine
+}
+
+// compareReport 是比较报告模板的数据
+type compareReport struct {
+	Title       string
+	Left, Right reportPane
+	Differences []Difference
+}
+
+// validationReport 是验证报告模板的数据
+type validationReport struct {
+	Title    string
+	Document reportPane
+	Result   ValidationResult
+}
+
+// WriteCompareHTML 将两个文档的差异写为独立的HTML页面
+//
+// 页面左右并排显示两个格式化后的文档，差异所在的行按种类高亮，下方列出所有差异。
+// 页面不引用任何外部资源，可以直接保存为文件或作为CI产物上传。
+func WriteCompareHTML(w io.Writer, leftName, rightName string, left, right *Value, differences []Difference) error {
+	leftMarks := map[string]string{}
+	rightMarks := map[string]string{}
+	for _, diff := range differences {
+		class := "diff-" + diff.Kind.String()
+		if diff.Left != nil {
+			leftMarks[diff.Path] = class
+		}
+		if diff.Right != nil {
+			if diff.RightPath != "" {
+				rightMarks[diff.RightPath] = class
+			} else {
+				rightMarks[diff.Path] = class
+			}
+		}
+	}
+
+	report := compareReport{
+		Title:       leftName + " ↔ " + rightName,
+		Left:        reportPane{Name: leftName, Lines: renderReportLines(left, leftMarks)},
+		Right:       reportPane{Name: rightName, Lines: renderReportLines(right, rightMarks)},
+		Differences: differences,
+	}
+	return compareReportTemplate.Execute(w, report)
+}
+
+// WriteValidationHTML 将Schema验证结果写为独立的HTML页面
+//
+// 页面显示格式化后的文档，验证失败的位置高亮，下方列出所有错误。
+func WriteValidationHTML(w io.Writer, name string, data *Value, result ValidationResult) error {
+	marks := map[string]string{}
+	for _, issue := range result.Issues {
+		marks[issue.Path] = "issue"
+	}
+
+	report := validationReport{
+		Title:    name,
+		Document: reportPane{Name: name, Lines: renderReportLines(data, marks)},
+		Result:   result,
+	}
+	return validationReportTemplate.Execute(w, report)
+}
+
+// renderReportLines 将值格式化为带路径的行，marks 中的路径及其子树使用对应的样式
+func renderReportLines(v *Value, marks map[string]string) []reportLine {
+	var lines []reportLine
+	if v != nil {
+		appendReportLines(&lines, v, "$", "", "", 0, marks, "")
+	}
+	return lines
+}
+
+// appendReportLines 追加 v 对应的行，prefix 是对象成员的键，suffix 是行尾的逗号
+func appendReportLines(lines *[]reportLine, v *Value, path, prefix, suffix string, depth int, marks map[string]string, inherited string) {
+	indent := strings.Repeat("  ", depth)
+	class := inherited
+	if mark, ok := marks[path]; ok {
+		class = mark
+	}
+	// 数组长度不同时只高亮数组本身，子元素各自比较
+	childClass := class
+	if class == "diff-length" {
+		childClass = inherited
+	}
+	line := func(text string, class string) {
+		*lines = append(*lines, reportLine{Path: path, Text: indent + prefix + text, Class: class})
+	}
+
+	switch v.Type {
+	case OBJECT:
+		if len(v.O) == 0 {
+			line("{}"+suffix, class)
+			return
+		}
+		line("{", class)
+		for i, member := range v.O {
+			comma := ","
+			if i == len(v.O)-1 {
+				comma = ""
+			}
+			appendReportLines(lines, member.V, path+"."+member.K, formatJSONString(member.K)+": ", comma, depth+1, marks, childClass)
+		}
+		*lines = append(*lines, reportLine{Path: path, Text: indent + "}" + suffix, Class: class})
+	case ARRAY:
+		if len(v.A) == 0 {
+			line("[]"+suffix, class)
+			return
+		}
+		line("[", class)
+		for i, element := range v.A {
+			comma := ","
+			if i == len(v.A)-1 {
+				comma = ""
+			}
+			appendReportLines(lines, element, path+"["+strconv.Itoa(i)+"]", "", comma, depth+1, marks, childClass)
+		}
+		*lines = append(*lines, reportLine{Path: path, Text: indent + "]" + suffix, Class: class})
+	default:
+		text, err := Stringify(v)
+		if err != STRINGIFY_OK {
+			text = "null"
+		}
+		line(text+suffix, class)
+	}
+}
+
+// reportFuncs 是报告模板使用的函数，inc 将从0开始的序号转换为从1开始
+var reportFuncs = template.FuncMap{
+	"inc": func(i int) int { return i + 1 },
+}
+
+// reportStyle 是两种报告共用的样式
+const reportStyle = `
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 24px; color: #24292e; }
+h1 { font-size: 20px; }
+.summary { margin-bottom: 16px; }
+.ok { color: #22863a; } .fail { color: #cb2431; }
+.panes { display: flex; gap: 16px; align-items: flex-start; }
+.pane { flex: 1; min-width: 0; border: 1px solid #d1d5da; border-radius: 4px; overflow: auto; }
+.pane h2 { font-size: 14px; margin: 0; padding: 8px; background: #f6f8fa; border-bottom: 1px solid #d1d5da; }
+.code { font-family: SFMono-Regular, Consolas, Menlo, monospace; font-size: 12px; border-collapse: collapse; width: 100%; }
+.code td { padding: 0 8px; white-space: pre; }
+.code td.ln { color: #959da5; text-align: right; user-select: none; width: 1%; }
+.diff-changed, .diff-type { background: #fff5b1; }
+.diff-removed { background: #ffeef0; }
+.diff-added { background: #e6ffed; }
+.diff-length { background: #f1f8ff; }
+.diff-moved { background: #f5f0ff; }
+.issue { background: #ffeef0; }
+table.list { border-collapse: collapse; margin-top: 24px; font-size: 13px; }
+table.list th, table.list td { border: 1px solid #d1d5da; padding: 4px 8px; text-align: left; vertical-align: top; }
+table.list code { white-space: pre-wrap; }
+`
+
+var compareReportTemplate = template.Must(template.New("compare").Funcs(reportFuncs).Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>JSON比较报告 - {{.Title}}</title>
+<style>` + reportStyle + `</style>
+</head>
+<body>
+<h1>JSON比较报告</h1>
+{{if .Differences}}<p class="summary fail">发现 {{len .Differences}} 处差异</p>{{else}}<p class="summary ok">文件相同</p>{{end}}
+<div class="panes">
+{{template "pane" .Left}}
+{{template "pane" .Right}}
+</div>
+{{if .Differences}}
+<table class="list">
+<tr><th>#</th><th>路径</th><th>种类</th><th>说明</th></tr>
+{{range $i, $d := .Differences}}<tr class="diff-{{$d.Kind}}"><td>{{inc $i}}</td><td><code>{{$d.Path}}</code></td><td>{{$d.Kind}}</td><td>{{$d.Message}}</td></tr>
+{{end}}</table>
+{{end}}
+</body>
+</html>
+{{define "pane"}}<div class="pane">
+<h2>{{.Name}}</h2>
+<table class="code">
+{{range $i, $l := .Lines}}<tr{{if $l.Class}} class="{{$l.Class}}"{{end}} title="{{$l.Path}}"><td class="ln">{{inc $i}}</td><td>{{$l.Text}}</td></tr>
+{{end}}</table>
+</div>{{end}}
+`))
+
+var validationReportTemplate = template.Must(template.New("validation").Funcs(reportFuncs).Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>Schema验证报告 - {{.Title}}</title>
+<style>` + reportStyle + `</style>
+</head>
+<body>
+<h1>Schema验证报告</h1>
+{{if .Result.Valid}}<p class="summary ok">验证通过: 文件符合Schema定义</p>{{else}}<p class="summary fail">验证失败: {{.Result.Message}}</p>{{end}}
+<div class="panes">
+<div class="pane">
+<h2>{{.Document.Name}}</h2>
+<table class="code">
+{{range $i, $l := .Document.Lines}}<tr{{if $l.Class}} class="{{$l.Class}}"{{end}} title="{{$l.Path}}"><td class="ln">{{inc $i}}</td><td>{{$l.Text}}</td></tr>
+{{end}}</table>
+</div>
+</div>
+{{if .Result.Issues}}
+<table class="list">
+<tr><th>#</th><th>路径</th><th>关键字</th><th>说明</th></tr>
+{{range $i, $e := .Result.Issues}}<tr><td>{{inc $i}}</td><td><code>{{$e.Path}}</code></td><td>{{$e.Keyword}}</td><td>{{$e.Message}}</td></tr>
+{{end}}</table>
+{{else if .Result.Errors}}
+<table class="list">
+<tr><th>#</th><th>说明</th></tr>
+{{range $i, $e := .Result.Errors}}<tr><td>{{inc $i}}</td><td>{{$e}}</td></tr>
+{{end}}</table>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,136 @@
+package leptjson
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCompareValues(t *testing.T) {
+	v1, v2 := &Value{}, &Value{}
+	Parse(v1, `{"a":1,"b":[1,2],"c":"x","gone":true}`)
+	Parse(v2, `{"a":2,"b":[1],"c":[],"new":null,"z":0}`)
+
+	diffs := CompareValues(v1, v2)
+	expected := []struct {
+		path string
+		kind DiffKind
+	}{
+		{"$.a", DIFF_VALUE_CHANGED},
+		{"$.b", DIFF_LENGTH_CHANGED},
+		{"$.c", DIFF_TYPE_MISMATCH},
+		{"$.gone", DIFF_REMOVED},
+		{"$.new", DIFF_ADDED},
+		{"$.z", DIFF_ADDED},
+	}
+	if len(diffs) != len(expected) {
+		t.Fatalf("差异数量 = %d, 期望 %d: %+v", len(diffs), len(expected), diffs)
+	}
+	for i, e := range expected {
+		if diffs[i].Path != e.path || diffs[i].Kind != e.kind {
+			t.Errorf("差异%d = %s %s, 期望 %s %s", i, diffs[i].Path, diffs[i].Kind, e.path, e.kind)
+		}
+	}
+	if diffs[3].Left == nil || diffs[3].Right != nil {
+		t.Errorf("删除的成员应该只有 Left")
+	}
+	if diffs[4].Left != nil || diffs[4].Right == nil {
+		t.Errorf("新增的成员应该只有 Right")
+	}
+
+	// 字符串形式的结果保持不变
+	messages := compareJSON(v1, v2)
+	if messages[3] != "路径 $: 第一个JSON有键 'gone'，但第二个没有" {
+		t.Errorf("compareJSON = %q", messages[3])
+	}
+
+	out, err := json.Marshal(diffs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), `"kind":"changed"`) {
+		t.Errorf("JSON = %s", out)
+	}
+}
+
+func TestValidationIssues(t *testing.T) {
+	schema, data := &Value{}, &Value{}
+	Parse(schema, `{"type":"object","required":["id"],"properties":{"age":{"type":"number","minimum":0}}}`)
+	Parse(data, `{"age":-1}`)
+
+	result := validateWithSchema(schema, data)
+	if result.Valid || len(result.Issues) != len(result.Errors) {
+		t.Fatalf("结果 = %+v", result)
+	}
+	keywords := map[string]string{}
+	for i, issue := range result.Issues {
+		keywords[issue.Keyword] = issue.Path
+		if issue.Message != result.Errors[i] {
+			t.Errorf("Issues[%d].Message = %q, Errors[%d] = %q", i, issue.Message, i, result.Errors[i])
+		}
+	}
+	if keywords["required"] != "$" || keywords["minimum"] != "$.age" {
+		t.Errorf("关键字 = %v", keywords)
+	}
+}
+
+func TestWriteCompareHTML(t *testing.T) {
+	v1, v2 := &Value{}, &Value{}
+	Parse(v1, `{"name":"<b>old</b>","keep":1,"gone":{"x":1}}`)
+	Parse(v2, `{"name":"new","keep":1,"extra":true}`)
+
+	var sb strings.Builder
+	if err := WriteCompareHTML(&sb, "a.json", "b.json", v1, v2, CompareValues(v1, v2)); err != nil {
+		t.Fatal(err)
+	}
+	page := sb.String()
+
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"发现 3 处差异",
+		`<tr class="diff-changed" title="$.name">`,
+		`<tr class="diff-removed" title="$.gone">`,
+		`<tr class="diff-removed" title="$.gone.x">`,
+		`<tr class="diff-added" title="$.extra">`,
+		`<tr title="$.keep">`,
+		"&lt;b&gt;old&lt;/b&gt;",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("HTML中缺少 %q", want)
+		}
+	}
+	if strings.Contains(page, "<b>old</b>") {
+		t.Errorf("文档内容没有转义")
+	}
+}
+
+func TestWriteValidationHTML(t *testing.T) {
+	schema, data := &Value{}, &Value{}
+	Parse(schema, `{"type":"object","properties":{"tags":{"type":"array","items":{"type":"string"}}}}`)
+	Parse(data, `{"tags":["a",2]}`)
+
+	result := validateWithSchema(schema, data)
+	var sb strings.Builder
+	if err := WriteValidationHTML(&sb, "data.json", data, result); err != nil {
+		t.Fatal(err)
+	}
+	page := sb.String()
+	for _, want := range []string{
+		"验证失败",
+		`<tr class="issue" title="$.tags[1]">`,
+		`<tr title="$.tags[0]">`,
+		"<td>type</td>",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("HTML中缺少 %q", want)
+		}
+	}
+
+	valid := validateWithSchema(schema, data)
+	valid.Valid, valid.Issues, valid.Errors = true, nil, nil
+	sb.Reset()
+	WriteValidationHTML(&sb, "data.json", data, valid)
+	if !strings.Contains(sb.String(), "验证通过") {
+		t.Errorf("验证通过的报告缺少提示")
+	}
+}
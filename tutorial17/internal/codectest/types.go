@@ -0,0 +1,38 @@
+// Package codectest 包含用于测试 leptjson gen-codec 生成代码的类型
+//
+// types_leptjson.go 由以下命令生成:
+//
+//	leptjson gen-codec types.go
+package codectest
+
+// Address 是嵌套在 Customer 中的地址
+//
+//leptjson:codec
+type Address struct {
+	City string `json:"city"`
+	Zip  string `json:"zip,omitempty"`
+}
+
+// Customer 覆盖了生成代码支持的各种字段类型
+//
+//leptjson:codec
+type Customer struct {
+	ID       int64             `json:"id"`
+	Name     string            `json:"name"`
+	Active   bool              `json:"active"`
+	Score    float64           `json:"score,omitempty"`
+	Nickname *string           `json:"nickname"`
+	Tags     []string          `json:"tags"`
+	Address  Address           `json:"address"`
+	Previous *Address          `json:"previous,omitempty"`
+	History  []Address         `json:"history"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Extra    interface{}       `json:"extra"`
+	Internal string            `json:"-"`
+	note     string
+}
+
+// Untouched 没有 leptjson:codec 注释，不会生成代码
+type Untouched struct {
+	Value int
+}
@@ -0,0 +1,277 @@
+// Code generated by leptjson gen-codec. DO NOT EDIT.
+
+package codectest
+
+import (
+	"fmt"
+
+	leptjson "github.com/Cactusinhand/go-json-tutorial/tutorial17"
+)
+
+// MarshalLeptJSON 将 Address 转换为JSON值
+func (x *Address) MarshalLeptJSON() (*leptjson.Value, error) {
+	v := &leptjson.Value{}
+	leptjson.SetObject(v)
+	{
+		fv := leptjson.SetObjectValue(v, "city")
+		leptjson.SetString(fv, x.City)
+	}
+	if x.Zip != "" {
+		fv := leptjson.SetObjectValue(v, "zip")
+		leptjson.SetString(fv, x.Zip)
+	}
+	return v, nil
+}
+
+// UnmarshalLeptJSON 从JSON值还原 Address，没有对应字段的键被忽略
+func (x *Address) UnmarshalLeptJSON(v *leptjson.Value) error {
+	if leptjson.GetType(v) != leptjson.OBJECT {
+		return &leptjson.ErrWrongType{Expected: "object", Actual: leptjson.GetType(v)}
+	}
+	for i := 0; i < leptjson.GetObjectSize(v); i++ {
+		m := leptjson.GetObjectValue(v, i)
+		switch leptjson.GetObjectKey(v, i) {
+		case "city":
+			if leptjson.GetType(m) != leptjson.NULL {
+				val1, err := leptjson.GetStringChecked(m)
+				if err != nil {
+					return fmt.Errorf("字段 %s: %w", "city", err)
+				}
+				x.City = val1
+			}
+		case "zip":
+			if leptjson.GetType(m) != leptjson.NULL {
+				val2, err := leptjson.GetStringChecked(m)
+				if err != nil {
+					return fmt.Errorf("字段 %s: %w", "zip", err)
+				}
+				x.Zip = val2
+			}
+		}
+	}
+	return nil
+}
+
+// ToLeptJSON 实现 leptjson.LeptMarshaler
+func (x *Address) ToLeptJSON() (*leptjson.Value, error) {
+	return x.MarshalLeptJSON()
+}
+
+// FromLeptJSON 实现 leptjson.LeptUnmarshaler
+func (x *Address) FromLeptJSON(v *leptjson.Value) error {
+	return x.UnmarshalLeptJSON(v)
+}
+
+// MarshalLeptJSON 将 Customer 转换为JSON值
+func (x *Customer) MarshalLeptJSON() (*leptjson.Value, error) {
+	v := &leptjson.Value{}
+	leptjson.SetObject(v)
+	{
+		fv := leptjson.SetObjectValue(v, "id")
+		leptjson.SetNumber(fv, float64(x.ID))
+	}
+	{
+		fv := leptjson.SetObjectValue(v, "name")
+		leptjson.SetString(fv, x.Name)
+	}
+	{
+		fv := leptjson.SetObjectValue(v, "active")
+		leptjson.SetBoolean(fv, x.Active)
+	}
+	if x.Score != 0 {
+		fv := leptjson.SetObjectValue(v, "score")
+		leptjson.SetNumber(fv, float64(x.Score))
+	}
+	{
+		fv := leptjson.SetObjectValue(v, "nickname")
+		if x.Nickname == nil {
+			leptjson.SetNull(fv)
+		} else {
+			leptjson.SetString(fv, *x.Nickname)
+		}
+	}
+	{
+		fv := leptjson.SetObjectValue(v, "tags")
+		leptjson.SetArray(fv, len(x.Tags))
+		for _, elem3 := range x.Tags {
+			item4 := leptjson.PushBackArrayElement(fv)
+			leptjson.SetString(item4, elem3)
+		}
+	}
+	{
+		fv := leptjson.SetObjectValue(v, "address")
+		fv5, err := x.Address.MarshalLeptJSON()
+		if err != nil {
+			return nil, fmt.Errorf("序列化字段 Address 失败: %w", err)
+		}
+		leptjson.Move(fv, fv5)
+	}
+	if x.Previous != nil {
+		fv := leptjson.SetObjectValue(v, "previous")
+		if x.Previous == nil {
+			leptjson.SetNull(fv)
+		} else {
+			fv6, err := x.Previous.MarshalLeptJSON()
+			if err != nil {
+				return nil, fmt.Errorf("序列化字段 Previous 失败: %w", err)
+			}
+			leptjson.Move(fv, fv6)
+		}
+	}
+	{
+		fv := leptjson.SetObjectValue(v, "history")
+		leptjson.SetArray(fv, len(x.History))
+		for _, elem7 := range x.History {
+			item8 := leptjson.PushBackArrayElement(fv)
+			fv9, err := elem7.MarshalLeptJSON()
+			if err != nil {
+				return nil, fmt.Errorf("序列化字段 History 失败: %w", err)
+			}
+			leptjson.Move(item8, fv9)
+		}
+	}
+	if len(x.Labels) != 0 {
+		fv := leptjson.SetObjectValue(v, "labels")
+		fv10, err := leptjson.MarshalValue(x.Labels)
+		if err != nil {
+			return nil, fmt.Errorf("序列化字段 Labels 失败: %w", err)
+		}
+		leptjson.Move(fv, fv10)
+	}
+	{
+		fv := leptjson.SetObjectValue(v, "extra")
+		fv11, err := leptjson.MarshalValue(x.Extra)
+		if err != nil {
+			return nil, fmt.Errorf("序列化字段 Extra 失败: %w", err)
+		}
+		leptjson.Move(fv, fv11)
+	}
+	return v, nil
+}
+
+// UnmarshalLeptJSON 从JSON值还原 Customer，没有对应字段的键被忽略
+func (x *Customer) UnmarshalLeptJSON(v *leptjson.Value) error {
+	if leptjson.GetType(v) != leptjson.OBJECT {
+		return &leptjson.ErrWrongType{Expected: "object", Actual: leptjson.GetType(v)}
+	}
+	for i := 0; i < leptjson.GetObjectSize(v); i++ {
+		m := leptjson.GetObjectValue(v, i)
+		switch leptjson.GetObjectKey(v, i) {
+		case "id":
+			if leptjson.GetType(m) != leptjson.NULL {
+				val12, err := leptjson.GetNumberChecked(m)
+				if err != nil {
+					return fmt.Errorf("字段 %s: %w", "id", err)
+				}
+				x.ID = int64(val12)
+			}
+		case "name":
+			if leptjson.GetType(m) != leptjson.NULL {
+				val13, err := leptjson.GetStringChecked(m)
+				if err != nil {
+					return fmt.Errorf("字段 %s: %w", "name", err)
+				}
+				x.Name = val13
+			}
+		case "active":
+			if leptjson.GetType(m) != leptjson.NULL {
+				val14, err := leptjson.GetBooleanChecked(m)
+				if err != nil {
+					return fmt.Errorf("字段 %s: %w", "active", err)
+				}
+				x.Active = val14
+			}
+		case "score":
+			if leptjson.GetType(m) != leptjson.NULL {
+				val15, err := leptjson.GetNumberChecked(m)
+				if err != nil {
+					return fmt.Errorf("字段 %s: %w", "score", err)
+				}
+				x.Score = float64(val15)
+			}
+		case "nickname":
+			if leptjson.GetType(m) == leptjson.NULL {
+				x.Nickname = nil
+			} else {
+				if x.Nickname == nil {
+					x.Nickname = new(string)
+				}
+				val16, err := leptjson.GetStringChecked(m)
+				if err != nil {
+					return fmt.Errorf("字段 %s: %w", "nickname", err)
+				}
+				*x.Nickname = val16
+			}
+		case "tags":
+			if leptjson.GetType(m) == leptjson.NULL {
+				x.Tags = nil
+			} else if leptjson.GetType(m) != leptjson.ARRAY {
+				return fmt.Errorf("字段 %s: %w", "tags", &leptjson.ErrWrongType{Expected: "array", Actual: leptjson.GetType(m)})
+			} else {
+				x.Tags = make([]string, leptjson.GetArraySize(m))
+				for j17 := range x.Tags {
+					e18 := leptjson.GetArrayElement(m, j17)
+					if leptjson.GetType(e18) != leptjson.NULL {
+						val19, err := leptjson.GetStringChecked(e18)
+						if err != nil {
+							return fmt.Errorf("字段 %s: %w", fmt.Sprintf("%s[%d]", "tags", j17), err)
+						}
+						x.Tags[j17] = val19
+					}
+				}
+			}
+		case "address":
+			if leptjson.GetType(m) != leptjson.NULL {
+				if err := x.Address.UnmarshalLeptJSON(m); err != nil {
+					return fmt.Errorf("字段 %s: %w", "address", err)
+				}
+			}
+		case "previous":
+			if leptjson.GetType(m) == leptjson.NULL {
+				x.Previous = nil
+			} else {
+				if x.Previous == nil {
+					x.Previous = new(Address)
+				}
+				if err := x.Previous.UnmarshalLeptJSON(m); err != nil {
+					return fmt.Errorf("字段 %s: %w", "previous", err)
+				}
+			}
+		case "history":
+			if leptjson.GetType(m) == leptjson.NULL {
+				x.History = nil
+			} else if leptjson.GetType(m) != leptjson.ARRAY {
+				return fmt.Errorf("字段 %s: %w", "history", &leptjson.ErrWrongType{Expected: "array", Actual: leptjson.GetType(m)})
+			} else {
+				x.History = make([]Address, leptjson.GetArraySize(m))
+				for j20 := range x.History {
+					e21 := leptjson.GetArrayElement(m, j20)
+					if leptjson.GetType(e21) != leptjson.NULL {
+						if err := x.History[j20].UnmarshalLeptJSON(e21); err != nil {
+							return fmt.Errorf("字段 %s: %w", fmt.Sprintf("%s[%d]", "history", j20), err)
+						}
+					}
+				}
+			}
+		case "labels":
+			if err := leptjson.UnmarshalValue(m, &x.Labels, leptjson.UnmarshalOptions{}); err != nil {
+				return fmt.Errorf("字段 %s: %w", "labels", err)
+			}
+		case "extra":
+			if err := leptjson.UnmarshalValue(m, &x.Extra, leptjson.UnmarshalOptions{}); err != nil {
+				return fmt.Errorf("字段 %s: %w", "extra", err)
+			}
+		}
+	}
+	return nil
+}
+
+// ToLeptJSON 实现 leptjson.LeptMarshaler
+func (x *Customer) ToLeptJSON() (*leptjson.Value, error) {
+	return x.MarshalLeptJSON()
+}
+
+// FromLeptJSON 实现 leptjson.LeptUnmarshaler
+func (x *Customer) FromLeptJSON(v *leptjson.Value) error {
+	return x.UnmarshalLeptJSON(v)
+}
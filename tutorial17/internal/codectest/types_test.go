@@ -0,0 +1,108 @@
+package codectest
+
+import (
+	"reflect"
+	"testing"
+
+	leptjson "github.com/Cactusinhand/go-json-tutorial/tutorial17"
+)
+
+// plainCustomer 与 Customer 结构相同但没有生成的方法，用于对比反射的结果
+type plainCustomer Customer
+
+func newTestCustomer() *Customer {
+	nickname := "ally"
+	return &Customer{
+		ID:       42,
+		Name:     "Alice",
+		Active:   true,
+		Nickname: &nickname,
+		Tags:     []string{"vip", "beta"},
+		Address:  Address{City: "Paris", Zip: "75001"},
+		History:  []Address{{City: "Lyon"}},
+		Labels:   map[string]string{"tier": "gold"},
+		Extra:    []interface{}{1.0, "x"},
+		Internal: "hidden",
+	}
+}
+
+func TestGeneratedMatchesReflection(t *testing.T) {
+	c := newTestCustomer()
+	generated, err := c.MarshalLeptJSON()
+	if err != nil {
+		t.Fatalf("MarshalLeptJSON 失败: %v", err)
+	}
+	got, _ := leptjson.Stringify(generated)
+
+	expected, err := leptjson.Marshal(plainCustomer(*c))
+	if err != nil {
+		t.Fatalf("Marshal 失败: %v", err)
+	}
+	if got != expected {
+		t.Errorf("生成代码的结果与反射不一致:\n生成: %s\n反射: %s", got, expected)
+	}
+
+	// Marshal 处理指针时使用生成的方法
+	if viaMarshal, _ := leptjson.Marshal(c); viaMarshal != expected {
+		t.Errorf("Marshal(&c) = %s", viaMarshal)
+	}
+}
+
+func TestGeneratedRoundTrip(t *testing.T) {
+	c := newTestCustomer()
+	s, _ := leptjson.Marshal(c)
+
+	var decoded Customer
+	if err := leptjson.Unmarshal(s, &decoded); err != nil {
+		t.Fatalf("Unmarshal 失败: %v", err)
+	}
+	c.Internal = ""
+	if !reflect.DeepEqual(&decoded, c) {
+		t.Errorf("往返结果不一致:\n%+v\n%+v", decoded, *c)
+	}
+
+	if err := leptjson.Unmarshal(`{"nickname":null,"previous":{"city":"Rome"},"tags":null}`, &decoded); err != nil {
+		t.Fatalf("Unmarshal 失败: %v", err)
+	}
+	if decoded.Nickname != nil || decoded.Tags != nil || decoded.Previous == nil || decoded.Previous.City != "Rome" {
+		t.Errorf("null 和指针处理错误: %+v", decoded)
+	}
+}
+
+func TestGeneratedUnmarshalErrors(t *testing.T) {
+	tests := []string{
+		`[]`,
+		`{"id":"1"}`,
+		`{"tags":["a",1]}`,
+		`{"address":{"city":true}}`,
+	}
+	for _, input := range tests {
+		v := &leptjson.Value{}
+		leptjson.Parse(v, input)
+		var c Customer
+		if err := c.UnmarshalLeptJSON(v); err == nil {
+			t.Errorf("UnmarshalLeptJSON(%s) 应当返回错误", input)
+		}
+	}
+}
+
+func BenchmarkMarshalCustomer(b *testing.B) {
+	c := newTestCustomer()
+	c.Labels = nil
+	c.Extra = nil
+
+	b.Run("Reflection", func(b *testing.B) {
+		b.ReportAllocs()
+		plain := plainCustomer(*c)
+		for i := 0; i < b.N; i++ {
+			leptjson.MarshalValue(plain)
+		}
+	})
+
+	b.Run("Generated", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			c.MarshalLeptJSON()
+		}
+	})
+}
@@ -0,0 +1,182 @@
+// Command gennorm 从 Unicode 字符数据库生成 leptjson 规范化使用的表
+//
+// 用法（通常通过 leptjson 包中的 go:generate 运行）:
+//
+//	go run ./internal/gennorm -ucd=DIR -version=14.0.0 -o unicode_norm_tables.go
+//
+// DIR 中需要有 UnicodeData.txt 和 CompositionExclusions.txt，可以从
+// https://www.unicode.org/Public/<version>/ucd/ 下载。只使用规范分解，兼容分解被忽略；
+// 谚文音节由算法处理，不出现在表中。
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// decomposition 是一个字符的规范分解
+type decomposition struct {
+	r       rune
+	runes   []rune
+	compose bool // 是否参与组合，即不在完全组合排除表中
+}
+
+func main() {
+	ucd := flag.String("ucd", "", "包含 UnicodeData.txt 和 CompositionExclusions.txt 的目录")
+	version := flag.String("version", "", "Unicode 版本，写入生成的文件")
+	output := flag.String("o", "unicode_norm_tables.go", "输出文件")
+	flag.Parse()
+	if *ucd == "" || *version == "" {
+		log.Fatal("必须指定 -ucd 和 -version")
+	}
+
+	classes, decomps, err := readUnicodeData(filepath.Join(*ucd, "UnicodeData.txt"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	exclusions, err := readExclusions(filepath.Join(*ucd, "CompositionExclusions.txt"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	for i := range decomps {
+		d := &decomps[i]
+		// 完全组合排除 = 单字符分解 + 非起始字符分解 + 显式排除
+		d.compose = len(d.runes) == 2 && classes[d.r] == 0 && classes[d.runes[0]] == 0 && !exclusions[d.r]
+	}
+
+	src, err := format.Source(generate(*version, classes, decomps))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*output, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// readUnicodeData 读取非零的规范组合类和规范分解
+func readUnicodeData(name string) (map[rune]uint8, []decomposition, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	classes := make(map[rune]uint8)
+	var decomps []decomposition
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Split(scanner.Text(), ";")
+		if len(fields) < 6 {
+			continue
+		}
+		r, err := parseRune(fields[0])
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s:%d: %v", name, line, err)
+		}
+		if ccc, _ := strconv.Atoi(fields[3]); ccc != 0 {
+			classes[r] = uint8(ccc)
+		}
+		if fields[5] == "" || strings.HasPrefix(fields[5], "<") {
+			continue
+		}
+		d := decomposition{r: r}
+		for _, hex := range strings.Fields(fields[5]) {
+			dr, err := parseRune(hex)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s:%d: %v", name, line, err)
+			}
+			d.runes = append(d.runes, dr)
+		}
+		if len(d.runes) > 2 {
+			return nil, nil, fmt.Errorf("%s:%d: 规范分解超过两个字符", name, line)
+		}
+		decomps = append(decomps, d)
+	}
+	return classes, decomps, scanner.Err()
+}
+
+// readExclusions 读取 CompositionExclusions.txt 中列出的字符
+func readExclusions(name string) (map[rune]bool, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	exclusions := make(map[rune]bool)
+	for i, line := range strings.Split(string(data), "\n") {
+		if j := strings.IndexByte(line, '#'); j >= 0 {
+			line = line[:j]
+		}
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		r, err := parseRune(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", name, i+1, err)
+		}
+		exclusions[r] = true
+	}
+	return exclusions, nil
+}
+
+func parseRune(hex string) (rune, error) {
+	n, err := strconv.ParseUint(strings.TrimSpace(hex), 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("无效的码点 %q", hex)
+	}
+	return rune(n), nil
+}
+
+// generate 输出Go源码：组合类按连续且值相同的区间合并
+func generate(version string, classes map[rune]uint8, decomps []decomposition) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by gennorm from Unicode %s. DO NOT EDIT.\n\n", version)
+	fmt.Fprintf(&buf, "package leptjson\n\n")
+	fmt.Fprintf(&buf, "// unicodeNormVersion 是规范化表对应的 Unicode 版本\n")
+	fmt.Fprintf(&buf, "const unicodeNormVersion = %q\n\n", version)
+
+	runes := make([]rune, 0, len(classes))
+	for r := range classes {
+		runes = append(runes, r)
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+	fmt.Fprintf(&buf, "// canonicalClasses 是非零的规范组合类，按码点排序\n")
+	fmt.Fprintf(&buf, "var canonicalClasses = []normClassRange{\n")
+	count := 0
+	for i := 0; i < len(runes); {
+		j := i
+		for j+1 < len(runes) && runes[j+1] == runes[j]+1 && classes[runes[j+1]] == classes[runes[i]] {
+			j++
+		}
+		fmt.Fprintf(&buf, "{0x%04X, 0x%04X, %d},", runes[i], runes[j], classes[runes[i]])
+		if count++; count%4 == 0 {
+			buf.WriteByte('\n')
+		}
+		i = j + 1
+	}
+	fmt.Fprintf(&buf, "\n}\n\n")
+
+	sort.Slice(decomps, func(i, j int) bool { return decomps[i].r < decomps[j].r })
+	fmt.Fprintf(&buf, "// canonicalDecompositions 是规范分解，单字符分解的第二个字符为0；compose 为 false 的不参与组合\n")
+	fmt.Fprintf(&buf, "var canonicalDecompositions = []normDecomposition{\n")
+	for i, d := range decomps {
+		second := rune(0)
+		if len(d.runes) == 2 {
+			second = d.runes[1]
+		}
+		fmt.Fprintf(&buf, "{0x%04X, 0x%04X, 0x%04X, %t},", d.r, d.runes[0], second, d.compose)
+		if (i+1)%3 == 0 {
+			buf.WriteByte('\n')
+		}
+	}
+	fmt.Fprintf(&buf, "\n}\n")
+	return buf.Bytes()
+}
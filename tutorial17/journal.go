@@ -0,0 +1,270 @@
+// journal.go - 只追加的文档修改日志，用于持久化和审计
+package leptjson
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JournalEntry 是日志中的一条记录，对应文档的一次修改
+type JournalEntry struct {
+	Version uint64     // 修改后的文档版本号
+	Time    time.Time  // 修改的时间
+	Patch   *JSONPatch // 修改的内容
+}
+
+// Journal 是保存在文件中的只追加的修改日志
+//
+// 每条记录是一行 JSON：{"version":3,"time":"2006-01-02T15:04:05Z","patch":[...]}，
+// 写入后立即同步到磁盘。文档通过 SetJournal 记录每次修改，重启后用 Replay
+// 从基准文档重放日志即可恢复，日志本身也是完整的修改历史。
+type Journal struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+	now  func() time.Time
+}
+
+// OpenJournal 打开日志文件，不存在时创建
+func OpenJournal(path string) (*Journal, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &Journal{path: path, file: file, now: time.Now}, nil
+}
+
+// Close 关闭日志文件
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.file.Close()
+}
+
+// Append 追加一条记录并同步到磁盘，entry.Time 为零值时使用当前时间
+func (j *Journal) Append(entry JournalEntry) error {
+	if entry.Patch == nil {
+		return fmt.Errorf("日志记录的补丁不能为空")
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if entry.Time.IsZero() {
+		entry.Time = j.now()
+	}
+	line, err := formatJournalEntry(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := j.file.WriteString(line); err != nil {
+		return fmt.Errorf("写入日志失败: %w", err)
+	}
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("同步日志失败: %w", err)
+	}
+	return nil
+}
+
+// Entries 读取日志中的所有记录
+func (j *Journal) Entries() ([]JournalEntry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.entriesLocked()
+}
+
+func (j *Journal) entriesLocked() ([]JournalEntry, error) {
+	f, err := os.Open(j.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadJournal(f)
+}
+
+// Compact 将所有记录合并为一条，记录的版本号和时间取最后一条
+//
+// 合并后的补丁经过 Optimize，对同一基准文档重放的结果与合并之前相同，
+// 但中间的修改历史不再保留。新的日志先写入临时文件，再替换原文件，
+// 中途失败时原日志保持不变。
+func (j *Journal) Compact() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	entries, err := j.entriesLocked()
+	if err != nil || len(entries) < 2 {
+		return err
+	}
+
+	combined := &JSONPatch{}
+	for _, entry := range entries {
+		combined.Operations = append(combined.Operations, entry.Patch.Operations...)
+	}
+	last := entries[len(entries)-1]
+	line, err := formatJournalEntry(JournalEntry{Version: last.Version, Time: last.Time, Patch: combined.Optimize()})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(j.path), filepath.Base(j.path)+".compact-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(line); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), j.path); err != nil {
+		return err
+	}
+
+	// 原文件已被替换，之后的记录追加到新文件
+	file, err := os.OpenFile(j.path, os.O_APPEND|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	j.file.Close()
+	j.file = file
+	return nil
+}
+
+// formatJournalEntry 将记录格式化为一行 JSON
+func formatJournalEntry(entry JournalEntry) (string, error) {
+	patch, err := entry.Patch.String()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`{"version":%d,"time":"%s","patch":%s}`+"\n",
+		entry.Version, entry.Time.UTC().Format(time.RFC3339Nano), patch), nil
+}
+
+// ReadJournal 从 r 读取日志记录
+//
+// 最后一行没有换行符并且无法解析时，视为写入过程中崩溃留下的不完整记录而忽略；
+// 其他无法解析的行返回错误。
+func ReadJournal(r io.Reader) ([]JournalEntry, error) {
+	var entries []JournalEntry
+	reader := bufio.NewReader(r)
+	for lineNo := 1; ; lineNo++ {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		complete := strings.HasSuffix(line, "\n")
+		if strings.TrimSpace(line) != "" {
+			entry, parseErr := parseJournalEntry(line)
+			if parseErr != nil {
+				if !complete {
+					break
+				}
+				return nil, fmt.Errorf("日志第 %d 行: %w", lineNo, parseErr)
+			}
+			entries = append(entries, entry)
+		}
+		if !complete {
+			break
+		}
+	}
+	return entries, nil
+}
+
+// parseJournalEntry 解析一行日志记录
+//
+// 日志由 Journal 自己写入，记录的大小只受写入时的文档限制，解析时不使用
+// 默认的安全限制，否则较大的值或合并后的记录会使日志无法读取。
+func parseJournalEntry(line string) (JournalEntry, error) {
+	options := DefaultParseOptions()
+	options.EnabledSecurity = false
+	v := &Value{}
+	if err := ParseWithOptions(v, strings.TrimSpace(line), options); err != PARSE_OK {
+		return JournalEntry{}, err
+	}
+	version := findObjectKey(v, "version")
+	stamp := findObjectKey(v, "time")
+	patchDoc := findObjectKey(v, "patch")
+	if version == nil || version.Type != NUMBER || stamp == nil || stamp.Type != STRING || patchDoc == nil {
+		return JournalEntry{}, fmt.Errorf("记录缺少 version、time 或 patch")
+	}
+	t, err := time.Parse(time.RFC3339Nano, GetString(stamp))
+	if err != nil {
+		return JournalEntry{}, fmt.Errorf("无效的时间: %w", err)
+	}
+	patch, err := NewJSONPatch(patchDoc)
+	if err != nil {
+		return JournalEntry{}, err
+	}
+	return JournalEntry{Version: uint64(version.N), Time: t, Patch: patch}, nil
+}
+
+// Replay 将日志中的所有记录依次应用到 base 的副本上，返回恢复的文档
+//
+// 文档的版本号为最后一条记录的版本号，之后的修改继续递增。
+func Replay(base *Value, journal *Journal) (*Document, error) {
+	entries, err := journal.Entries()
+	if err != nil {
+		return nil, err
+	}
+	d := NewDocument(base)
+	for i, entry := range entries {
+		if err := entry.Patch.Apply(d.root); err != nil {
+			return nil, fmt.Errorf("重放第 %d 条记录（版本 %d）失败: %w", i+1, entry.Version, err)
+		}
+		d.version = entry.Version
+	}
+	return d, nil
+}
+
+// SetJournal 让文档将之后的每次修改记录到日志中，为 nil 时不再记录
+//
+// Patch 记录补丁本身，Set 和 Remove 记录等价的 add 和 remove 操作，
+// Update 记录修改前后两个文档的差异。写入日志失败时修改被撤销，
+// 文档保持不变并返回错误。
+func (d *Document) SetJournal(journal *Journal) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.journal = journal
+}
+
+// undoLocked 在修改之前计算 op 的逆操作，返回撤销这次修改的函数，调用者必须持有写锁
+//
+// 没有设置日志时不需要撤销，返回 nil。
+func (d *Document) undoLocked(op PatchOperation) func() {
+	if d.journal == nil {
+		return nil
+	}
+	inverse, err := inverseOperation(d.root, &op)
+	if err != nil {
+		// 修改本身也会失败，不会用到撤销
+		return nil
+	}
+	return func() {
+		for i := range inverse {
+			applyOperation(d.root, &inverse[i])
+		}
+	}
+}
+
+// journalLocked 记录一次修改，调用者必须持有写锁；失败时调用 undo 撤销修改
+func (d *Document) journalLocked(patch *JSONPatch, undo func()) error {
+	if d.journal == nil {
+		return nil
+	}
+	if err := d.journal.Append(JournalEntry{Version: d.version + 1, Patch: patch}); err != nil {
+		if undo != nil {
+			undo()
+		}
+		return err
+	}
+	return nil
+}
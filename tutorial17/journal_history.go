@@ -0,0 +1,118 @@
+// journal_history.go - 根据修改日志查询文档的历史版本
+package leptjson
+
+import (
+	"fmt"
+	"time"
+)
+
+// History 是文档的修改历史，由基准文档和它之后的日志记录组成
+//
+// 基准文档的版本号为 0，之后每条记录对应一个版本。Compact 合并过的版本
+// 不再单独存在，只能访问合并后的最后一个版本。
+type History struct {
+	base    *Value
+	entries []JournalEntry
+}
+
+// History 读取日志，返回从 base 开始的修改历史
+func (j *Journal) History(base *Value) (*History, error) {
+	entries, err := j.Entries()
+	if err != nil {
+		return nil, err
+	}
+	h := &History{base: &Value{}, entries: entries}
+	if base != nil {
+		Copy(h.base, base)
+	}
+	return h, nil
+}
+
+// Versions 返回历史中所有可以访问的版本号，按从旧到新排列
+func (h *History) Versions() []uint64 {
+	versions := []uint64{0}
+	for _, entry := range h.entries {
+		versions = append(versions, entry.Version)
+	}
+	return versions
+}
+
+// VersionAt 返回时刻 t 的文档版本号，即 t 之前（含 t）最后一次修改之后的版本
+//
+// t 早于第一次修改时返回基准文档的版本号 0。
+func (h *History) VersionAt(t time.Time) uint64 {
+	var version uint64
+	for _, entry := range h.entries {
+		if entry.Time.After(t) {
+			break
+		}
+		version = entry.Version
+	}
+	return version
+}
+
+// StateAt 返回指定版本的文档
+func (h *History) StateAt(version uint64) (*Value, error) {
+	states, err := h.states(version)
+	if err != nil {
+		return nil, err
+	}
+	return states[0], nil
+}
+
+// DiffVersions 返回将版本 v1 的文档变为版本 v2 的文档的 JSON Patch
+//
+// v1 可以比 v2 新，此时返回的补丁撤销两者之间的修改。
+func (h *History) DiffVersions(v1, v2 uint64) (*JSONPatch, error) {
+	states, err := h.states(v1, v2)
+	if err != nil {
+		return nil, err
+	}
+	return CreatePatch(states[0], states[1])
+}
+
+// states 重放一次日志，返回每个版本的文档
+func (h *History) states(versions ...uint64) ([]*Value, error) {
+	var last uint64
+	for _, version := range versions {
+		if !h.hasVersion(version) {
+			return nil, fmt.Errorf("历史中没有版本 %d", version)
+		}
+		if version > last {
+			last = version
+		}
+	}
+
+	result := make([]*Value, len(versions))
+	current := &Value{}
+	Copy(current, h.base)
+	capture := func(version uint64) {
+		for i, v := range versions {
+			if v == version {
+				result[i] = &Value{}
+				Copy(result[i], current)
+			}
+		}
+	}
+	capture(0)
+	for i, entry := range h.entries {
+		if entry.Version > last {
+			break
+		}
+		if err := entry.Patch.Apply(current); err != nil {
+			return nil, fmt.Errorf("重放第 %d 条记录（版本 %d）失败: %w", i+1, entry.Version, err)
+		}
+		capture(entry.Version)
+	}
+	return result, nil
+}
+
+// hasVersion 判断历史中是否可以访问 version
+func (h *History) hasVersion(version uint64) bool {
+	for _, v := range h.Versions() {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
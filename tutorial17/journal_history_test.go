@@ -0,0 +1,70 @@
+package leptjson
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistoryVersions(t *testing.T) {
+	const base = `{"a":1}`
+	doc, journal := journalDocument(t, base)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tick := start
+	journal.now = func() time.Time {
+		tick = tick.Add(time.Minute)
+		return tick
+	}
+
+	for _, patch := range []string{
+		`[{"op":"add","path":"/b","value":[1]}]`,
+		`[{"op":"replace","path":"/a","value":2}]`,
+		`[{"op":"add","path":"/b/-","value":2},{"op":"remove","path":"/a"}]`,
+	} {
+		p, _ := NewJSONPatchFromString(patch)
+		if err := doc.Patch(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	baseValue := &Value{}
+	Parse(baseValue, base)
+	history, err := journal.History(baseValue)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	times := map[time.Duration]uint64{0: 0, time.Minute: 1, 90 * time.Second: 1, 3 * time.Minute: 3, time.Hour: 3}
+	for offset, want := range times {
+		if got := history.VersionAt(start.Add(offset)); got != want {
+			t.Errorf("VersionAt(+%v) = %d, 期望 %d", offset, got, want)
+		}
+	}
+
+	state, _ := history.StateAt(2)
+	if s, _ := Stringify(state); s != `{"a":2,"b":[1]}` {
+		t.Errorf("版本 2 = %s", s)
+	}
+
+	for _, tt := range []struct{ v1, v2 uint64 }{{0, 3}, {3, 0}, {1, 2}, {2, 2}} {
+		patch, err := history.DiffVersions(tt.v1, tt.v2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		from, _ := history.StateAt(tt.v1)
+		to, _ := history.StateAt(tt.v2)
+		if err := patch.Apply(from); err != nil || !Equal(from, to) {
+			t.Errorf("DiffVersions(%d, %d) 应用后不等于版本 %d: %v", tt.v1, tt.v2, tt.v2, err)
+		}
+	}
+
+	// 合并后中间的版本不再存在
+	journal.Compact()
+	history, _ = journal.History(baseValue)
+	if _, err := history.DiffVersions(1, 3); err == nil || !strings.Contains(err.Error(), "历史中没有版本 1") {
+		t.Errorf("已合并的版本: %v", err)
+	}
+	if versions := history.Versions(); len(versions) != 2 || versions[1] != 3 {
+		t.Errorf("Versions = %v", versions)
+	}
+}
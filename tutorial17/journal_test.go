@@ -0,0 +1,171 @@
+package leptjson
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// journalDocument 创建记录到临时日志的文档
+func journalDocument(t *testing.T, json string) (*Document, *Journal) {
+	t.Helper()
+	journal, err := OpenJournal(filepath.Join(t.TempDir(), "doc.journal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { journal.Close() })
+	doc, _ := ParseDocument(json)
+	doc.SetJournal(journal)
+	return doc, journal
+}
+
+func TestJournalReplay(t *testing.T) {
+	const base = `{"users":[{"name":"a"}],"count":1}`
+	doc, journal := journalDocument(t, base)
+	journal.now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	name := &Value{}
+	SetString(name, "b")
+	if err := doc.Set("/users/-", &Value{Type: OBJECT, O: []Member{{K: "name", V: name}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Remove("/count"); err != nil {
+		t.Fatal(err)
+	}
+	patch, _ := NewJSONPatchFromString(`[{"op":"replace","path":"/users/0/name","value":"c"},{"op":"test","path":"/users/1/name","value":"b"}]`)
+	if err := doc.Patch(patch); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Update(func(root *Value) error {
+		SetNumber(SetObjectValue(root, "total"), 2)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := journal.Entries()
+	if err != nil || len(entries) != 4 {
+		t.Fatalf("Entries = %d, %v", len(entries), err)
+	}
+	if entries[0].Version != 1 || entries[3].Version != 4 || !entries[0].Time.Equal(journal.now()) {
+		t.Errorf("记录 = %+v", entries[0])
+	}
+
+	baseValue := &Value{}
+	Parse(baseValue, base)
+	replayed, err := Replay(baseValue, journal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed.String() != doc.String() || replayed.Version() != doc.Version() {
+		t.Errorf("重放得到 %s (版本 %d), 期望 %s (版本 %d)", replayed.String(), replayed.Version(), doc.String(), doc.Version())
+	}
+
+	// 合并后只剩一条记录，重放结果不变，之后的记录继续追加
+	if err := journal.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := journal.Entries(); len(entries) != 1 || entries[0].Version != 4 {
+		t.Fatalf("合并后的记录 = %+v", entries)
+	}
+	if err := doc.Remove("/total"); err != nil {
+		t.Fatal(err)
+	}
+	replayed, err = Replay(baseValue, journal)
+	if err != nil || replayed.String() != doc.String() || replayed.Version() != 5 {
+		t.Errorf("合并后重放得到 %v, %v", replayed, err)
+	}
+}
+
+func TestJournalFailureKeepsDocumentUnchanged(t *testing.T) {
+	doc, journal := journalDocument(t, `{"a":[1,2]}`)
+	journal.file.Close()
+
+	before := doc.String()
+	value := &Value{}
+	SetNumber(value, 3)
+	patch, _ := NewJSONPatchFromString(`[{"op":"add","path":"/a/0","value":0}]`)
+	failures := []func() error{
+		func() error { return doc.Set("/a/1", value) },
+		func() error { return doc.Set("", value) },
+		func() error { return doc.Remove("/a/0") },
+		func() error { return doc.Patch(patch) },
+		func() error { return doc.Update(func(root *Value) error { root.O = nil; return nil }) },
+	}
+	for i, fn := range failures {
+		if err := fn(); err == nil || !strings.Contains(err.Error(), "写入日志失败") {
+			t.Errorf("修改 %d: %v", i, err)
+		}
+		if doc.String() != before || doc.Version() != 0 {
+			t.Errorf("修改 %d 之后文档 = %s, 版本 %d", i, doc.String(), doc.Version())
+		}
+	}
+}
+
+func TestReadJournal(t *testing.T) {
+	const entry = `{"version":1,"time":"2024-01-02T03:04:05Z","patch":[{"op":"remove","path":"/a"}]}`
+
+	// 崩溃留下的不完整的最后一行被忽略
+	entries, err := ReadJournal(strings.NewReader(entry + "\n" + `{"version":2,"ti`))
+	if err != nil || len(entries) != 1 {
+		t.Errorf("不完整的最后一行: %d, %v", len(entries), err)
+	}
+	if _, err := ReadJournal(strings.NewReader(entry + "\n{bad}\n" + entry + "\n")); err == nil || !strings.Contains(err.Error(), "日志第 2 行") {
+		t.Errorf("损坏的记录: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "j")
+	os.WriteFile(path, []byte(entry+"\n"), 0644)
+	journal, _ := OpenJournal(path)
+	defer journal.Close()
+	base := &Value{}
+	Parse(base, `{"b":1}`)
+	if _, err := Replay(base, journal); err == nil || !strings.Contains(err.Error(), "重放第 1 条记录") {
+		t.Errorf("无法应用的记录: %v", err)
+	}
+}
+
+func TestJournalLargeValue(t *testing.T) {
+	doc, journal := journalDocument(t, `{}`)
+	note := &Value{}
+	SetString(note, strings.Repeat("x", 9000))
+	if err := doc.Set("/note", note); err != nil {
+		t.Fatal(err)
+	}
+
+	// 超过默认解析限制的记录仍然可以重放
+	replayed, err := Replay(&Value{Type: OBJECT}, journal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed.String() != doc.String() {
+		t.Errorf("重放结果与文档不同")
+	}
+}
+
+func TestJournalCompactLarge(t *testing.T) {
+	doc, journal := journalDocument(t, `{"a":[]}`)
+	value := &Value{}
+	for i := 0; i < 12000; i++ {
+		SetNumber(value, float64(i))
+		if err := doc.Set("/a/-", value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 合并后的一条记录包含 12000 个元素，超过默认的数组大小限制
+	if err := journal.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	base := &Value{}
+	Parse(base, `{"a":[]}`)
+	replayed, err := Replay(base, journal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed.String() != doc.String() || replayed.Version() != 12000 {
+		t.Errorf("合并后重放得到版本 %d, 期望 %d", replayed.Version(), doc.Version())
+	}
+}
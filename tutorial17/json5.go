@@ -0,0 +1,61 @@
+// json5.go - 输出风格：标准JSON或 JSON5（尾随逗号、不加引号的标识符键）
+package leptjson
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// OutputStyle 表示字符串化和格式化的输出风格
+type OutputStyle int
+
+// 输出风格常量
+const (
+	STYLE_JSON  OutputStyle = iota // 标准JSON
+	STYLE_JSON5                    // JSON5：多行的数组和对象带尾随逗号，标识符形式的键不加引号
+)
+
+// String 返回输出风格的名称
+func (s OutputStyle) String() string {
+	switch s {
+	case STYLE_JSON:
+		return "json"
+	case STYLE_JSON5:
+		return "json5"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseOutputStyle 根据名称获取输出风格，支持 json 和 json5（不区分大小写）
+func ParseOutputStyle(name string) (OutputStyle, error) {
+	switch strings.ToLower(name) {
+	case "json":
+		return STYLE_JSON, nil
+	case "json5":
+		return STYLE_JSON5, nil
+	default:
+		return 0, fmt.Errorf("不支持的输出风格: %s", name)
+	}
+}
+
+// isJSON5Identifier 判断键能否在 JSON5 中不加引号：以字母、$ 或 _ 开头，
+// 后面是字母、数字、$ 或 _
+//
+// JSON5 允许 ECMAScript 的保留字作为键，所以 true、null 等也不需要引号。
+func isJSON5Identifier(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, r := range key {
+		if r == '$' || r == '_' || unicode.IsLetter(r) {
+			continue
+		}
+		if i > 0 && (unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Mc, r) || unicode.Is(unicode.Pc, r)) {
+			continue
+		}
+		return false
+	}
+	return true
+}
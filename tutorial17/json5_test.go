@@ -0,0 +1,65 @@
+package leptjson
+
+import "testing"
+
+func TestParseOutputStyle(t *testing.T) {
+	for name, want := range map[string]OutputStyle{"json": STYLE_JSON, "JSON5": STYLE_JSON5} {
+		got, err := ParseOutputStyle(name)
+		if err != nil || got != want {
+			t.Errorf("ParseOutputStyle(%q) = %v, %v, 期望 %v", name, got, err, want)
+		}
+	}
+	if _, err := ParseOutputStyle("yaml"); err == nil {
+		t.Error("ParseOutputStyle(\"yaml\") 应当返回错误")
+	}
+	if STYLE_JSON5.String() != "json5" {
+		t.Errorf("STYLE_JSON5.String() = %s, 期望 json5", STYLE_JSON5)
+	}
+}
+
+func TestIsJSON5Identifier(t *testing.T) {
+	tests := map[string]bool{
+		"name":     true,
+		"_private": true,
+		"$ref":     true,
+		"v2":       true,
+		"null":     true,
+		"名称":       true,
+		"":         false,
+		"2nd":      false,
+		"max-age":  false,
+		"a b":      false,
+		"a.b":      false,
+	}
+	for key, want := range tests {
+		if got := isJSON5Identifier(key); got != want {
+			t.Errorf("isJSON5Identifier(%q) = %v, 期望 %v", key, got, want)
+		}
+	}
+}
+
+func TestStringifyJSON5(t *testing.T) {
+	v := mustParse(t, `{"name":"leptjson","max-age":60,"tags":["a"],"empty":{}}`)
+
+	got, _ := StringifyWithOptions(v, StringifyOptions{Indent: "  ", Style: STYLE_JSON5})
+	want := "{\n  name: \"leptjson\",\n  \"max-age\": 60,\n  tags: [\n    \"a\",\n  ],\n  empty: {},\n}"
+	if got != want {
+		t.Errorf("StringifyWithOptions(json5) =\n%s\n期望\n%s", got, want)
+	}
+
+	// 紧凑格式不输出尾随逗号
+	got, _ = StringifyWithOptions(v, StringifyOptions{Style: STYLE_JSON5})
+	if want := `{name:"leptjson","max-age":60,tags:["a"],empty:{}}`; got != want {
+		t.Errorf("StringifyWithOptions(json5, 紧凑) = %s, 期望 %s", got, want)
+	}
+}
+
+func TestFormatJSON5(t *testing.T) {
+	v := mustParse(t, `{"name":"leptjson","point":{"x":1,"y":2},"list":[1,2,3,4,5,6,7,8]}`)
+	got, _ := formatJSONWithLayout(v, &formatLayout{indent: "  ", width: 24, style: STYLE_JSON5})
+	// 写在一行的数组和对象没有尾随逗号，行宽包括最后一个元素后面的逗号
+	want := "{\n  name: \"leptjson\",\n  point: {x: 1, y: 2},\n  list: [\n    1,\n    2,\n    3,\n    4,\n    5,\n    6,\n    7,\n    8,\n  ],\n}"
+	if got != want {
+		t.Errorf("formatJSONWithLayout(json5) =\n%s\n期望\n%s", got, want)
+	}
+}
@@ -142,18 +142,57 @@ func NewJSONPatchFromString(patchStr string) (*JSONPatch, error) {
 }
 
 // Apply 将 JSON Patch 应用到文档
+//
+// 按照 RFC 6902 的要求，补丁的应用是原子的：所有操作在文档的副本上执行，
+// 全部成功后才写回 doc，任何一个操作失败时 doc 保持不变。
 func (p *JSONPatch) Apply(doc *Value) error {
-	// 应用每个操作
+	_, err := p.apply(doc, false)
+	return err
+}
+
+// ApplyWithInverse 将 JSON Patch 应用到文档，并返回对应的逆补丁
+//
+// 将逆补丁应用到结果文档上可以恢复原始文档，可用于实现撤销/重做或补偿事务。
+// 与 Apply 一样，失败时 doc 保持不变，并且不返回逆补丁。
+func (p *JSONPatch) ApplyWithInverse(doc *Value) (*JSONPatch, error) {
+	return p.apply(doc, true)
+}
+
+// apply 在文档副本上依次执行所有操作，withInverse 为 true 时同时计算逆补丁
+func (p *JSONPatch) apply(doc *Value, withInverse bool) (*JSONPatch, error) {
+	working := &Value{}
+	Copy(working, doc)
+
+	var inverse []PatchOperation
 	for i, op := range p.Operations {
-		if err := applyOperation(doc, &op); err != nil {
+		var undo []PatchOperation
+		var inverseErr error
+		if withInverse {
+			// 逆操作必须基于操作执行前的文档计算
+			undo, inverseErr = inverseOperation(working, &op)
+		}
+
+		err := applyOperation(working, &op)
+		if err == nil && inverseErr != nil {
+			err = &PatchError{Operation: op.Op, Path: op.Path, Message: inverseErr.Error()}
+		}
+		if err != nil {
 			// 将错误包装成 PatchError，并添加操作索引
 			if patchErr, ok := err.(*PatchError); ok {
 				patchErr.Message = fmt.Sprintf("操作 %d: %s", i, patchErr.Message)
 			}
-			return err
+			return nil, err
 		}
+
+		// 后执行的操作先撤销
+		inverse = append(undo, inverse...)
 	}
-	return nil
+
+	Move(doc, working)
+	if !withInverse {
+		return nil, nil
+	}
+	return &JSONPatch{Operations: inverse}, nil
 }
 
 // applyOperation 应用单个 Patch 操作到文档
@@ -454,6 +493,11 @@ func diff(source, target *Value, path string, patch *JSONPatch) {
 
 // diffArray 比较两个数组并生成 patch 操作
 func diffArray(source, target *Value, path string, patch *JSONPatch) {
+	// 标量数组按最长公共子序列生成插入、删除和移动，中间插入一个元素时不必替换后面的所有元素
+	if diffScalarArray(source, target, path, patch) {
+		return
+	}
+
 	// 比较每个元素
 	maxLen := len(source.A)
 	if len(target.A) > maxLen {
@@ -0,0 +1,194 @@
+// json_patch_inverse.go - JSON Patch 逆补丁计算
+package leptjson
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// inverseOperation 根据操作执行前的文档计算撤销该操作的操作序列
+func inverseOperation(doc *Value, op *PatchOperation) ([]PatchOperation, error) {
+	path, err := ParseJSONPointer(op.Path)
+	if err != POINTER_OK {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "test":
+		return nil, nil
+
+	case "remove":
+		old, err := path.Get(doc)
+		if err != POINTER_OK {
+			return nil, err
+		}
+		return []PatchOperation{{Op: "add", Path: op.Path, Value: cloneValue(old)}}, nil
+
+	case "replace":
+		old, err := path.Get(doc)
+		if err != POINTER_OK {
+			return nil, err
+		}
+		return []PatchOperation{{Op: "replace", Path: op.Path, Value: cloneValue(old)}}, nil
+
+	case "add", "copy":
+		return inverseAdd(doc, path.tokens, nil)
+
+	case "move":
+		from, err := ParseJSONPointer(op.From)
+		if err != POINTER_OK {
+			return nil, err
+		}
+		return inverseMove(doc, from.tokens, path.tokens)
+
+	default:
+		return nil, fmt.Errorf("不支持的操作类型: %s", op.Op)
+	}
+}
+
+// inverseAdd 计算在 tokens 处添加值的逆操作
+//
+// removedFrom 不为 nil 时表示添加发生在删除 removedFrom 之后（move 操作），
+// tokens 是删除之后的文档中的路径，需要换算为当前文档中的路径后再查找。
+func inverseAdd(doc *Value, tokens []string, removedFrom []string) ([]PatchOperation, error) {
+	n := len(tokens)
+	if n == 0 {
+		return []PatchOperation{{Op: "replace", Path: "", Value: cloneValue(doc)}}, nil
+	}
+
+	parentTokens := tokens[:n-1]
+	lookupTokens := translateRemovedPath(doc, parentTokens, removedFrom)
+	parent, err := (&JSONPointer{tokens: lookupTokens}).Get(doc)
+	if err != POINTER_OK {
+		return nil, err
+	}
+
+	last := tokens[n-1]
+	switch parent.Type {
+	case ARRAY:
+		// 数组中的添加是插入，撤销时删除插入位置的元素
+		index := len(parent.A)
+		if removedFrom != nil && isSameArrayParent(lookupTokens, removedFrom) {
+			index--
+		}
+		if last != "-" {
+			i, err := strconv.Atoi(last)
+			if err != nil || i < 0 || i > index {
+				return nil, POINTER_INDEX_OUT_OF_RANGE
+			}
+			index = i
+		}
+		return []PatchOperation{{Op: "remove", Path: pointerFromTokens(append(copyTokens(parentTokens), strconv.Itoa(index)))}}, nil
+
+	case OBJECT:
+		path := pointerFromTokens(tokens)
+		if old, ok := FindObjectKey(parent, last); ok {
+			// 覆盖了已有的键，撤销时恢复旧值
+			return []PatchOperation{{Op: "replace", Path: path, Value: cloneValue(old)}}, nil
+		}
+		return []PatchOperation{{Op: "remove", Path: path}}, nil
+
+	default:
+		return nil, POINTER_INVALID_TARGET
+	}
+}
+
+// inverseMove 计算 move 操作的逆操作
+func inverseMove(doc *Value, from, to []string) ([]PatchOperation, error) {
+	if _, err := (&JSONPointer{tokens: from}).Get(doc); err != POINTER_OK {
+		return nil, err
+	}
+
+	// 移动到自身没有效果
+	if tokensEqual(from, to) {
+		return nil, nil
+	}
+
+	// 目标是源的祖先时，源所在的整个子树被替换，直接恢复目标的旧值即可
+	if isTokenPrefix(to, from) {
+		old, err := (&JSONPointer{tokens: to}).Get(doc)
+		if err != POINTER_OK {
+			return nil, err
+		}
+		return []PatchOperation{{Op: "replace", Path: pointerFromTokens(to), Value: cloneValue(old)}}, nil
+	}
+
+	// 先把值移回原处，再恢复被覆盖的值
+	added, err := inverseAdd(doc, to, from)
+	if err != nil {
+		return nil, err
+	}
+
+	undo := added[0]
+	inverse := []PatchOperation{}
+	switch undo.Op {
+	case "remove":
+		inverse = append(inverse, PatchOperation{Op: "move", From: undo.Path, Path: pointerFromTokens(from)})
+	case "replace":
+		// 目标原来存在于对象中：移回之后还要恢复旧值，路径需换算为移回后的文档中的路径
+		restoreTokens := append(copyTokens(translateRemovedPath(doc, to[:len(to)-1], from)), to[len(to)-1])
+		inverse = append(inverse,
+			PatchOperation{Op: "move", From: undo.Path, Path: pointerFromTokens(from)},
+			PatchOperation{Op: "add", Path: pointerFromTokens(restoreTokens), Value: undo.Value},
+		)
+	}
+	return inverse, nil
+}
+
+// translateRemovedPath 将删除 removed 之后的文档中的路径换算为删除之前的路径
+//
+// 删除数组元素会使其后的兄弟元素索引减一，因此经过这些兄弟元素的路径需要把索引加一。
+func translateRemovedPath(doc *Value, tokens []string, removed []string) []string {
+	if len(removed) == 0 {
+		return tokens
+	}
+
+	depth := len(removed) - 1
+	if len(tokens) <= depth || !isTokenPrefix(removed[:depth], tokens) {
+		return tokens
+	}
+
+	parent, err := (&JSONPointer{tokens: removed[:depth]}).Get(doc)
+	if err != POINTER_OK || parent.Type != ARRAY {
+		return tokens
+	}
+
+	removedIndex, err1 := strconv.Atoi(removed[depth])
+	index, err2 := strconv.Atoi(tokens[depth])
+	if err1 != nil || err2 != nil || index < removedIndex {
+		return tokens
+	}
+
+	translated := copyTokens(tokens)
+	translated[depth] = strconv.Itoa(index + 1)
+	return translated
+}
+
+// isSameArrayParent 判断 parentTokens 是否就是被删除元素所在的数组
+func isSameArrayParent(parentTokens, removed []string) bool {
+	return len(removed) > 0 && tokensEqual(parentTokens, removed[:len(removed)-1])
+}
+
+// tokensEqual 判断两个令牌列表是否相同
+func tokensEqual(a, b []string) bool {
+	return len(a) == len(b) && isTokenPrefix(a, b)
+}
+
+// copyTokens 复制令牌列表，避免 append 修改共享的底层数组
+func copyTokens(tokens []string) []string {
+	result := make([]string, len(tokens), len(tokens)+1)
+	copy(result, tokens)
+	return result
+}
+
+// pointerFromTokens 将令牌列表转换为 JSON Pointer 字符串
+func pointerFromTokens(tokens []string) string {
+	return (&JSONPointer{tokens: tokens}).String()
+}
+
+// cloneValue 返回值的深拷贝
+func cloneValue(v *Value) *Value {
+	result := &Value{}
+	Copy(result, v)
+	return result
+}
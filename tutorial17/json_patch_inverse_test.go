@@ -0,0 +1,154 @@
+package leptjson
+
+import (
+	"testing"
+)
+
+// 测试逆补丁：应用补丁后再应用逆补丁应当恢复原始文档
+func TestApplyWithInverse(t *testing.T) {
+	testCases := []struct {
+		name     string
+		document string
+		patch    string
+		inverse  string // 期望的逆补丁，为空时不检查
+	}{
+		{
+			name:     "添加对象成员",
+			document: `{"foo":"bar"}`,
+			patch:    `[{"op":"add","path":"/baz","value":"qux"}]`,
+			inverse:  `[{"op":"remove","path":"/baz"}]`,
+		},
+		{
+			name:     "覆盖对象成员",
+			document: `{"foo":"bar"}`,
+			patch:    `[{"op":"add","path":"/foo","value":"qux"}]`,
+			inverse:  `[{"op":"replace","path":"/foo","value":"bar"}]`,
+		},
+		{
+			name:     "追加数组元素",
+			document: `{"list":[1,2]}`,
+			patch:    `[{"op":"add","path":"/list/-","value":3}]`,
+			inverse:  `[{"op":"remove","path":"/list/2"}]`,
+		},
+		{
+			name:     "删除和替换",
+			document: `{"a":[1,2,3],"b":{"c":true}}`,
+			patch:    `[{"op":"remove","path":"/a/1"},{"op":"replace","path":"/b/c","value":false}]`,
+			inverse:  `[{"op":"replace","path":"/b/c","value":true},{"op":"add","path":"/a/1","value":2}]`,
+		},
+		{
+			name:     "替换整个文档",
+			document: `{"a":1}`,
+			patch:    `[{"op":"replace","path":"","value":[1]}]`,
+			inverse:  `[{"op":"replace","path":"","value":{"a":1}}]`,
+		},
+		{
+			name:     "移动对象成员",
+			document: `{"foo":{"bar":"baz"},"qux":{"corge":"grault"}}`,
+			patch:    `[{"op":"move","from":"/foo/bar","path":"/qux/thud"}]`,
+			inverse:  `[{"op":"move","from":"/qux/thud","path":"/foo/bar"}]`,
+		},
+		{
+			name:     "移动并覆盖",
+			document: `{"a":1,"b":2}`,
+			patch:    `[{"op":"move","from":"/a","path":"/b"}]`,
+		},
+		{
+			name:     "同一数组内移动",
+			document: `["x","y","z"]`,
+			patch:    `[{"op":"move","from":"/0","path":"/-"}]`,
+			inverse:  `[{"op":"move","from":"/2","path":"/0"}]`,
+		},
+		{
+			name:     "移动到后续元素内部",
+			document: `[{"v":1},{"v":2,"w":{}}]`,
+			patch:    `[{"op":"move","from":"/0/v","path":"/1/v"},{"op":"move","from":"/0","path":"/0/w/x"}]`,
+		},
+		{
+			name:     "移动改变兄弟元素索引",
+			document: `[1,{"k":"old"},3]`,
+			patch:    `[{"op":"move","from":"/0","path":"/0/k"}]`,
+		},
+		{
+			name:     "移动到祖先",
+			document: `{"a":{"b":{"c":1}}}`,
+			patch:    `[{"op":"move","from":"/a/b","path":"/a"}]`,
+			inverse:  `[{"op":"replace","path":"/a","value":{"b":{"c":1}}}]`,
+		},
+		{
+			name:     "复制",
+			document: `{"a":[1],"b":[]}`,
+			patch:    `[{"op":"copy","from":"/a","path":"/b/0"},{"op":"copy","from":"/a","path":"/a"}]`,
+		},
+		{
+			name:     "测试操作没有逆操作",
+			document: `{"a":1}`,
+			patch:    `[{"op":"test","path":"/a","value":1}]`,
+			inverse:  `[]`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := &Value{}
+			if err := Parse(doc, tc.document); err != PARSE_OK {
+				t.Fatalf("解析原始文档失败: %s", GetErrorMessage(err))
+			}
+			original := cloneValue(doc)
+
+			patch, err := NewJSONPatchFromString(tc.patch)
+			if err != nil {
+				t.Fatalf("创建 JSON Patch 失败: %v", err)
+			}
+
+			inverse, err := patch.ApplyWithInverse(doc)
+			if err != nil {
+				t.Fatalf("ApplyWithInverse 失败: %v", err)
+			}
+
+			// 与 Apply 的结果一致
+			expected := cloneValue(original)
+			if err := patch.Apply(expected); err != nil {
+				t.Fatalf("Apply 失败: %v", err)
+			}
+			if !Equal(doc, expected) {
+				t.Fatal("ApplyWithInverse 的结果与 Apply 不一致")
+			}
+
+			if tc.inverse != "" {
+				got, _ := inverse.String()
+				want, _ := NewJSONPatchFromString(tc.inverse)
+				wantStr, _ := want.String()
+				if got != wantStr {
+					t.Errorf("逆补丁不匹配\n期望: %s\n实际: %s", wantStr, got)
+				}
+			}
+
+			if err := inverse.Apply(doc); err != nil {
+				t.Fatalf("应用逆补丁失败: %v", err)
+			}
+			if !Equal(doc, original) {
+				docStr, _ := Stringify(doc)
+				originalStr, _ := Stringify(original)
+				t.Errorf("逆补丁没有恢复原始文档\n期望: %s\n实际: %s", originalStr, docStr)
+			}
+		})
+	}
+}
+
+func TestApplyWithInverseFailure(t *testing.T) {
+	doc := &Value{}
+	Parse(doc, `{"a":1}`)
+
+	patch, _ := NewJSONPatchFromString(`[{"op":"replace","path":"/a","value":2},{"op":"remove","path":"/b"}]`)
+	inverse, err := patch.ApplyWithInverse(doc)
+	if err == nil {
+		t.Fatal("期望错误，但没有得到错误")
+	}
+	if inverse != nil {
+		t.Error("失败时不应返回逆补丁")
+	}
+	if got, _ := Stringify(doc); got != `{"a":1}` {
+		t.Errorf("失败的补丁修改了文档: %s", got)
+	}
+}
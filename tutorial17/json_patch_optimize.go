@@ -0,0 +1,212 @@
+// json_patch_optimize.go - JSON Patch 优化与规范化
+package leptjson
+
+// OptimizePatch 合并冗余的补丁操作并规范化路径，返回新的操作列表
+//
+// 优化不依赖目标文档，对任何能成功应用原补丁的文档，优化后的补丁产生相同的结果
+// （对象成员的顺序可能不同）。目前执行的优化有：
+//   - 规范化 JSON Pointer 的转义（如 "/a~1b" 与原始写法统一）
+//   - 同一路径上的连续写入合并为一次，例如 add 后 replace 合并为 add、remove 后 add 合并为 replace
+//   - 删除父节点之前对其子树的修改被丢弃
+//   - 移动到自身的 move 操作被丢弃
+//
+// 在判断"连续"时，会跳过与两者路径无关、且路径中不包含数组索引的操作，
+// 相当于把后面的操作安全地前移到前一个操作旁边。
+func OptimizePatch(ops []PatchOperation) []PatchOperation {
+	result := make([]PatchOperation, 0, len(ops))
+	for _, op := range ops {
+		normalized := op
+		normalized.Path = normalizePointer(op.Path)
+		if op.Op == "move" || op.Op == "copy" {
+			normalized.From = normalizePointer(op.From)
+		}
+		if normalized.Op == "move" && normalized.From == normalized.Path {
+			continue
+		}
+		result = append(result, normalized)
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for j := 0; j < len(result); j++ {
+			if dropped := dropOverwrittenChildren(result, j); dropped > 0 {
+				result = removeOperations(result, j, dropped)
+				changed = true
+				break
+			}
+			if i, merged, ok := findMergeable(result, j); ok {
+				result[i] = merged
+				result = append(result[:j], result[j+1:]...)
+				changed = true
+				break
+			}
+		}
+	}
+	return result
+}
+
+// Optimize 返回优化后的补丁，不修改原补丁
+func (p *JSONPatch) Optimize() *JSONPatch {
+	return &JSONPatch{Operations: OptimizePatch(p.Operations)}
+}
+
+// findMergeable 向前查找可以与 ops[j] 合并的操作，返回其位置和合并后的操作
+func findMergeable(ops []PatchOperation, j int) (int, PatchOperation, bool) {
+	for i := j - 1; i >= 0; i-- {
+		if ops[i].Path == ops[j].Path {
+			merged, ok := mergeOperations(ops[i], ops[j])
+			return i, merged, ok
+		}
+		if !operationsCommute(ops[i], ops[j]) {
+			break
+		}
+	}
+	return 0, PatchOperation{}, false
+}
+
+// mergeOperations 合并同一路径上先后执行的两个操作
+func mergeOperations(first, second PatchOperation) (PatchOperation, bool) {
+	if isAppendPointer(second.Path) {
+		return PatchOperation{}, false
+	}
+	// 最后一个令牌不是数字时，目标一定是对象成员
+	objectMember := !isArrayIndexPointer(second.Path)
+
+	switch {
+	case first.Op == "add" && second.Op == "replace":
+		return PatchOperation{Op: "add", Path: second.Path, Value: second.Value}, true
+	case first.Op == "replace" && second.Op == "replace":
+		return second, true
+	case first.Op == "replace" && second.Op == "remove":
+		return second, true
+	case first.Op == "remove" && second.Op == "add":
+		// 数组中删除后在同一位置插入等价于替换；对象中键原本存在，同样等价于替换
+		return PatchOperation{Op: "replace", Path: second.Path, Value: second.Value}, true
+	case objectMember && first.Op == "add" && second.Op == "add":
+		return second, true
+	case objectMember && first.Op == "replace" && second.Op == "add":
+		return PatchOperation{Op: "replace", Path: second.Path, Value: second.Value}, true
+	}
+	return PatchOperation{}, false
+}
+
+// dropOverwrittenChildren 当 ops[j] 删除或替换某个节点时，统计紧邻其前、
+// 只修改该节点子树的操作数量，这些操作的效果会被 ops[j] 覆盖
+func dropOverwrittenChildren(ops []PatchOperation, j int) int {
+	if ops[j].Op != "remove" && ops[j].Op != "replace" {
+		return 0
+	}
+	parent, err := ParseJSONPointer(ops[j].Path)
+	if err != POINTER_OK {
+		return 0
+	}
+
+	dropped := 0
+	for i := j - 1; i >= 0; i-- {
+		if !isWriteWithinSubtree(ops[i], parent.tokens) {
+			break
+		}
+		dropped++
+	}
+	return dropped
+}
+
+// isWriteWithinSubtree 判断操作是否只读写 parent 的严格子树
+func isWriteWithinSubtree(op PatchOperation, parent []string) bool {
+	switch op.Op {
+	case "add", "replace", "remove":
+	case "move", "copy":
+		if !isStrictDescendant(op.From, parent) {
+			return false
+		}
+	default:
+		// test 操作可能失败，不能丢弃
+		return false
+	}
+	return isStrictDescendant(op.Path, parent)
+}
+
+// isStrictDescendant 判断 pointer 是否位于 parent 之下（不包括 parent 本身）
+func isStrictDescendant(pointer string, parent []string) bool {
+	p, err := ParseJSONPointer(pointer)
+	if err != POINTER_OK {
+		return false
+	}
+	return len(p.tokens) > len(parent) && isTokenPrefix(parent, p.tokens)
+}
+
+// removeOperations 删除 ops[j] 之前的 count 个操作
+func removeOperations(ops []PatchOperation, j, count int) []PatchOperation {
+	return append(ops[:j-count], ops[j:]...)
+}
+
+// operationsCommute 判断两个操作能否交换执行顺序
+//
+// 只有当两者涉及的路径互不为前缀，且都不包含可能是数组索引的令牌时才认为可以交换，
+// 因为数组的插入和删除会移动其他元素的索引。
+func operationsCommute(a, b PatchOperation) bool {
+	for _, pa := range operationPaths(a) {
+		for _, pb := range operationPaths(b) {
+			if pa == nil || pb == nil {
+				return false
+			}
+			if isTokenPrefix(pa, pb) || isTokenPrefix(pb, pa) {
+				return false
+			}
+			if hasArrayIndexToken(pa) || hasArrayIndexToken(pb) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// operationPaths 返回操作涉及的所有路径的令牌，无法解析的路径为nil
+func operationPaths(op PatchOperation) [][]string {
+	pointers := []string{op.Path}
+	if op.Op == "move" || op.Op == "copy" {
+		pointers = append(pointers, op.From)
+	}
+
+	paths := make([][]string, len(pointers))
+	for i, pointer := range pointers {
+		if p, err := ParseJSONPointer(pointer); err == POINTER_OK {
+			paths[i] = p.tokens
+		}
+	}
+	return paths
+}
+
+// hasArrayIndexToken 判断令牌列表中是否有可能是数组索引的令牌
+func hasArrayIndexToken(tokens []string) bool {
+	for _, token := range tokens {
+		if isArrayIndexToken(token) {
+			return true
+		}
+	}
+	return false
+}
+
+// isArrayIndexPointer 判断指针的最后一个令牌是否可能是数组索引
+func isArrayIndexPointer(pointer string) bool {
+	p, err := ParseJSONPointer(pointer)
+	if err != POINTER_OK || len(p.tokens) == 0 {
+		return false
+	}
+	return isArrayIndexToken(p.tokens[len(p.tokens)-1])
+}
+
+// isAppendPointer 判断指针是否指向数组末尾 ("-")
+func isAppendPointer(pointer string) bool {
+	p, err := ParseJSONPointer(pointer)
+	return err == POINTER_OK && len(p.tokens) > 0 && p.tokens[len(p.tokens)-1] == "-"
+}
+
+// normalizePointer 规范化 JSON Pointer 的转义，无法解析的指针保持原样
+func normalizePointer(pointer string) string {
+	p, err := ParseJSONPointer(pointer)
+	if err != POINTER_OK {
+		return pointer
+	}
+	return p.String()
+}
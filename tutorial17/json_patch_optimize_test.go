@@ -0,0 +1,109 @@
+package leptjson
+
+import (
+	"testing"
+)
+
+func TestOptimizePatch(t *testing.T) {
+	testCases := []struct {
+		name     string
+		document string
+		patch    string
+		expected string
+	}{
+		{
+			name:     "add后replace合并为add",
+			document: `{"a":1}`,
+			patch:    `[{"op":"add","path":"/b","value":1},{"op":"replace","path":"/b","value":2}]`,
+			expected: `[{"op":"add","path":"/b","value":2}]`,
+		},
+		{
+			name:     "跳过无关操作合并",
+			document: `{"a":1,"c":{}}`,
+			patch:    `[{"op":"replace","path":"/a","value":2},{"op":"add","path":"/c/d","value":true},{"op":"replace","path":"/a","value":3}]`,
+			expected: `[{"op":"replace","path":"/a","value":3},{"op":"add","path":"/c/d","value":true}]`,
+		},
+		{
+			name:     "删除父节点前的子节点修改被丢弃",
+			document: `{"a":{"b":1,"c":2},"d":0}`,
+			patch:    `[{"op":"remove","path":"/a/b"},{"op":"add","path":"/a/x","value":1},{"op":"move","from":"/a/c","path":"/a/y"},{"op":"remove","path":"/a"}]`,
+			expected: `[{"op":"remove","path":"/a"}]`,
+		},
+		{
+			name:     "remove后add合并为replace",
+			document: `{"list":[1,2,3]}`,
+			patch:    `[{"op":"remove","path":"/list/1"},{"op":"add","path":"/list/1","value":9}]`,
+			expected: `[{"op":"replace","path":"/list/1","value":9}]`,
+		},
+		{
+			name:     "replace后remove合并为remove",
+			document: `{"a":1}`,
+			patch:    `[{"op":"replace","path":"/a","value":2},{"op":"remove","path":"/a"}]`,
+			expected: `[{"op":"remove","path":"/a"}]`,
+		},
+		{
+			name:     "移动到自身被丢弃",
+			document: `{"a":1}`,
+			patch:    `[{"op":"move","from":"/a","path":"/a"}]`,
+			expected: `[]`,
+		},
+		{
+			name:     "数组索引的插入不能合并",
+			document: `{"list":[1]}`,
+			patch:    `[{"op":"add","path":"/list/0","value":2},{"op":"add","path":"/list/0","value":3}]`,
+			expected: `[{"op":"add","path":"/list/0","value":2},{"op":"add","path":"/list/0","value":3}]`,
+		},
+		{
+			name:     "数组操作阻止重排",
+			document: `{"a":1,"list":[1,2]}`,
+			patch:    `[{"op":"replace","path":"/a","value":2},{"op":"remove","path":"/list/0"},{"op":"replace","path":"/a","value":3}]`,
+			expected: `[{"op":"replace","path":"/a","value":2},{"op":"remove","path":"/list/0"},{"op":"replace","path":"/a","value":3}]`,
+		},
+		{
+			name:     "test操作阻止合并",
+			document: `{"a":1}`,
+			patch:    `[{"op":"replace","path":"/a","value":2},{"op":"test","path":"/a","value":2},{"op":"replace","path":"/a","value":3}]`,
+			expected: `[{"op":"replace","path":"/a","value":2},{"op":"test","path":"/a","value":2},{"op":"replace","path":"/a","value":3}]`,
+		},
+		{
+			name:     "规范化指针转义",
+			document: `{"~a":{"b/c":1}}`,
+			patch:    `[{"op":"replace","path":"/~0a/b~1c","value":2}]`,
+			expected: `[{"op":"replace","path":"/~0a/b~1c","value":2}]`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			patch, err := NewJSONPatchFromString(tc.patch)
+			if err != nil {
+				t.Fatalf("创建 JSON Patch 失败: %v", err)
+			}
+
+			optimized := patch.Optimize()
+			got, _ := optimized.String()
+			want, _ := NewJSONPatchFromString(tc.expected)
+			wantStr, _ := want.String()
+			if got != wantStr {
+				t.Errorf("优化结果不匹配\n期望: %s\n实际: %s", wantStr, got)
+			}
+
+			// 优化前后的补丁应用结果相同
+			doc1 := &Value{}
+			doc2 := &Value{}
+			Parse(doc1, tc.document)
+			Parse(doc2, tc.document)
+			if err := patch.Apply(doc1); err != nil {
+				t.Fatalf("应用原补丁失败: %v", err)
+			}
+			if err := optimized.Apply(doc2); err != nil {
+				t.Fatalf("应用优化后的补丁失败: %v", err)
+			}
+			if !Equal(doc1, doc2) {
+				s1, _ := Stringify(doc1)
+				s2, _ := Stringify(doc2)
+				t.Errorf("优化改变了补丁的结果\n原补丁: %s\n优化后: %s", s1, s2)
+			}
+		})
+	}
+}
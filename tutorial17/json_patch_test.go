@@ -257,6 +257,49 @@ func TestApplyPatch(t *testing.T) {
 	}
 }
 
+// 测试补丁应用的原子性：任何一个操作失败时文档保持不变
+func TestApplyPatchAtomic(t *testing.T) {
+	testCases := []struct {
+		name  string
+		patch string
+	}{
+		{
+			name:  "最后一个操作失败",
+			patch: `[{"op":"replace","path":"/a","value":10},{"op":"add","path":"/list/-","value":4},{"op":"remove","path":"/missing"}]`,
+		},
+		{
+			name:  "测试操作失败",
+			patch: `[{"op":"remove","path":"/list/0"},{"op":"test","path":"/list/0","value":1}]`,
+		},
+		{
+			name:  "替换整个文档后失败",
+			patch: `[{"op":"replace","path":"","value":[]},{"op":"add","path":"/x","value":1}]`,
+		},
+	}
+
+	const original = `{"a":1,"list":[1,2,3]}`
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := &Value{}
+			if err := Parse(doc, original); err != PARSE_OK {
+				t.Fatalf("解析原始文档失败: %s", GetErrorMessage(err))
+			}
+
+			patch, err := NewJSONPatchFromString(tc.patch)
+			if err != nil {
+				t.Fatalf("创建 JSON Patch 失败: %v", err)
+			}
+
+			if err := patch.Apply(doc); err == nil {
+				t.Fatal("期望错误，但没有得到错误")
+			}
+			if got, _ := Stringify(doc); got != original {
+				t.Errorf("失败的补丁修改了文档\n期望: %s\n实际: %s", original, got)
+			}
+		})
+	}
+}
+
 // 测试 JSON Patch 转字符串
 func TestJSONPatchString(t *testing.T) {
 	testCases := []struct {
@@ -42,6 +42,8 @@ const (
 type Token struct {
 	Type  TokenType // 令牌类型
 	Value string    // 令牌值
+
+	filter filterExpr // FILTER 令牌编译后的过滤表达式
 }
 
 // SliceInfo 存储数组切片信息
@@ -55,6 +57,10 @@ type SliceInfo struct {
 type JSONPath struct {
 	Path   string  // 原始路径表达式
 	Tokens []Token // 令牌列表
+
+	rfc *rfcQuery // RFC 9535 模式下编译后的查询，此时 Tokens 为空
+
+	budget *evalBudget // 本次求值的限制，只在 QueryWithBudget 使用的副本中设置
 }
 
 // NewJSONPath 解析 JSON Path 表达式并创建一个 JSONPath 对象
@@ -126,6 +132,15 @@ func (jp *JSONPath) parse() error {
 					// 通配符 [*]
 					jp.Tokens = append(jp.Tokens, Token{Type: WILDCARD, Value: "*"})
 					i++
+				} else if jp.Path[i] == '?' {
+					// 过滤表达式 [?(@.price < 10)] 或 [?@.price < 10]
+					end := findFilterEnd(jp.Path, i+1)
+					filter, err := compileFilter(jp.Path, i+1, end)
+					if err != nil {
+						return err
+					}
+					jp.Tokens = append(jp.Tokens, Token{Type: FILTER, Value: jp.Path[i+1 : end], filter: filter})
+					i = end
 				} else if jp.Path[i] == '\'' || jp.Path[i] == '"' {
 					// 带引号的属性名 ["name"] 或 ['name']
 					quote := jp.Path[i]
@@ -357,27 +372,57 @@ func (jp *JSONPath) Query(doc *Value) ([]*Value, error) {
 	}
 
 	// 从根节点开始查询
-	matches, err := jp.evaluate(doc, 0)
+	matches, err := jp.query(doc, doc)
 	if err != nil {
 		return nil, err
 	}
 	return matches, nil
 }
 
+// query 从 start 开始求值路径，root 是 $ 所指的文档根节点
+func (jp *JSONPath) query(start, root *Value) ([]*Value, error) {
+	nodes, err := jp.queryNodes(start, root)
+	if err != nil {
+		return nil, err
+	}
+	return nodeValues(nodes), nil
+}
+
+// queryNodes 与 query 相同，但同时返回每个结果相对于 start 的位置
+func (jp *JSONPath) queryNodes(start, root *Value) ([]queryNode, error) {
+	if jp.rfc != nil {
+		nodes, err := jp.rfc.evaluate(start, root, jp.budget)
+		if err := jp.budget.failed(); err != nil {
+			return nil, err
+		}
+		if err != nil {
+			return nil, &JSONPathError{Path: jp.Path, Message: err.Error()}
+		}
+		return nodes, nil
+	}
+	return jp.evaluate(queryNode{value: start}, root, 0)
+}
+
 // evaluate 从指定令牌索引开始评估路径
-func (jp *JSONPath) evaluate(current *Value, tokenIndex int) ([]*Value, error) {
+func (jp *JSONPath) evaluate(node queryNode, root *Value, tokenIndex int) ([]queryNode, error) {
+	if err := jp.budget.step(); err != nil {
+		return nil, err
+	}
+
 	// 基本情况：已处理所有令牌
 	if tokenIndex >= len(jp.Tokens) {
-		return []*Value{current}, nil
+		return []queryNode{node}, nil
 	}
 
+	current := node.value
+
 	// 获取当前令牌
 	token := jp.Tokens[tokenIndex]
 
 	switch token.Type {
 	case ROOT:
 		// $ 表示文档根节点，继续处理下一个令牌
-		return jp.evaluate(current, tokenIndex+1)
+		return jp.evaluate(node, root, tokenIndex+1)
 
 	case DOT:
 		// 确保下一个令牌是属性名或通配符
@@ -392,32 +437,26 @@ func (jp *JSONPath) evaluate(current *Value, tokenIndex int) ([]*Value, error) {
 		if nextToken.Type == PROPERTY {
 			// 处理对象属性访问
 			if current.Type != OBJECT {
-				return []*Value{}, nil // 不是对象，返回空结果
+				return []queryNode{}, nil // 不是对象，返回空结果
 			}
 
-			var value *Value
 			for i := 0; i < len(current.O); i++ {
 				if current.O[i].K == nextToken.Value {
-					value = current.O[i].V
-					break
+					return jp.evaluate(node.member(current.O[i]), root, tokenIndex+2)
 				}
 			}
 
-			if value == nil {
-				return []*Value{}, nil // 未找到属性
-			}
-
-			return jp.evaluate(value, tokenIndex+2)
+			return []queryNode{}, nil // 未找到属性
 
 		} else if nextToken.Type == WILDCARD {
 			// 处理通配符（返回所有属性）
 			if current.Type != OBJECT {
-				return []*Value{}, nil // 不是对象，返回空结果
+				return []queryNode{}, nil // 不是对象，返回空结果
 			}
 
-			var results []*Value
+			var results []queryNode
 			for i := 0; i < len(current.O); i++ {
-				subResults, err := jp.evaluate(current.O[i].V, tokenIndex+2)
+				subResults, err := jp.evaluate(node.member(current.O[i]), root, tokenIndex+2)
 				if err != nil {
 					return nil, err
 				}
@@ -442,7 +481,7 @@ func (jp *JSONPath) evaluate(current *Value, tokenIndex int) ([]*Value, error) {
 		}
 
 		// 递归处理当前节点及其所有子节点
-		return jp.findRecursive(current, tokenIndex+1)
+		return jp.findRecursive(node, root, tokenIndex+1)
 
 	case BRACKET_START:
 		// 方括号表达式 [...]
@@ -468,7 +507,7 @@ func (jp *JSONPath) evaluate(current *Value, tokenIndex int) ([]*Value, error) {
 		case INDEX:
 			// 数组索引 [0]
 			if current.Type != ARRAY {
-				return []*Value{}, nil // 不是数组，返回空结果
+				return []queryNode{}, nil // 不是数组，返回空结果
 			}
 
 			index, err := strconv.Atoi(bracketToken.Value)
@@ -486,35 +525,24 @@ func (jp *JSONPath) evaluate(current *Value, tokenIndex int) ([]*Value, error) {
 
 			// 索引范围检查
 			if index < 0 || index >= len(current.A) {
-				return []*Value{}, nil // 索引越界，返回空结果
+				return []queryNode{}, nil // 索引越界，返回空结果
 			}
 
-			return jp.evaluate(current.A[index], tokenIndex+3)
+			return jp.evaluate(node.element(index), root, tokenIndex+3)
 
 		case WILDCARD:
 			// 通配符 [*]
 			if current.Type != ARRAY && current.Type != OBJECT {
-				return []*Value{}, nil // 既不是数组也不是对象，返回空结果
+				return []queryNode{}, nil // 既不是数组也不是对象，返回空结果
 			}
 
-			var results []*Value
-
-			if current.Type == ARRAY {
-				for i := 0; i < len(current.A); i++ {
-					subResults, err := jp.evaluate(current.A[i], tokenIndex+3)
-					if err != nil {
-						return nil, err
-					}
-					results = append(results, subResults...)
-				}
-			} else { // OBJECT
-				for i := 0; i < len(current.O); i++ {
-					subResults, err := jp.evaluate(current.O[i].V, tokenIndex+3)
-					if err != nil {
-						return nil, err
-					}
-					results = append(results, subResults...)
+			var results []queryNode
+			for _, child := range childNodes(node) {
+				subResults, err := jp.evaluate(child, root, tokenIndex+3)
+				if err != nil {
+					return nil, err
 				}
+				results = append(results, subResults...)
 			}
 
 			return results, nil
@@ -522,27 +550,21 @@ func (jp *JSONPath) evaluate(current *Value, tokenIndex int) ([]*Value, error) {
 		case PROPERTY:
 			// 属性访问 ["name"]
 			if current.Type != OBJECT {
-				return []*Value{}, nil // 不是对象，返回空结果
+				return []queryNode{}, nil // 不是对象，返回空结果
 			}
 
-			var value *Value
 			for i := 0; i < len(current.O); i++ {
 				if current.O[i].K == bracketToken.Value {
-					value = current.O[i].V
-					break
+					return jp.evaluate(node.member(current.O[i]), root, tokenIndex+3)
 				}
 			}
 
-			if value == nil {
-				return []*Value{}, nil // 未找到属性
-			}
-
-			return jp.evaluate(value, tokenIndex+3)
+			return []queryNode{}, nil // 未找到属性
 
 		case SLICE:
 			// 切片 [start:end:step]
 			if current.Type != ARRAY {
-				return []*Value{}, nil // 不是数组，返回空结果
+				return []queryNode{}, nil // 不是数组，返回空结果
 			}
 
 			sliceInfo, err := parseSliceParams(bracketToken.Value)
@@ -553,7 +575,7 @@ func (jp *JSONPath) evaluate(current *Value, tokenIndex int) ([]*Value, error) {
 				}
 			}
 
-			var results []*Value
+			var results []queryNode
 			arrayLen := len(current.A)
 
 			// 调整负索引和默认结束索引
@@ -580,7 +602,7 @@ func (jp *JSONPath) evaluate(current *Value, tokenIndex int) ([]*Value, error) {
 			if step > 0 {
 				for i := start; i < end; i += step {
 					if i >= 0 && i < arrayLen {
-						subResults, err := jp.evaluate(current.A[i], tokenIndex+3)
+						subResults, err := jp.evaluate(node.element(i), root, tokenIndex+3)
 						if err != nil {
 							return nil, err
 						}
@@ -591,7 +613,7 @@ func (jp *JSONPath) evaluate(current *Value, tokenIndex int) ([]*Value, error) {
 				// 反向遍历
 				for i := start; i > end; i += step {
 					if i >= 0 && i < arrayLen {
-						subResults, err := jp.evaluate(current.A[i], tokenIndex+3)
+						subResults, err := jp.evaluate(node.element(i), root, tokenIndex+3)
 						if err != nil {
 							return nil, err
 						}
@@ -601,6 +623,28 @@ func (jp *JSONPath) evaluate(current *Value, tokenIndex int) ([]*Value, error) {
 			}
 
 			return results, nil
+
+		case FILTER:
+			// 过滤器 [?(...)] 依次测试数组元素或对象成员的值
+			var results []queryNode
+			for _, child := range childNodes(node) {
+				if err := jp.budget.step(); err != nil {
+					return nil, err
+				}
+				ok, err := bracketToken.filter.test(child.value, root)
+				if err != nil {
+					return nil, &JSONPathError{Path: jp.Path, Message: err.Error()}
+				}
+				if !ok {
+					continue
+				}
+				subResults, err := jp.evaluate(child, root, tokenIndex+3)
+				if err != nil {
+					return nil, err
+				}
+				results = append(results, subResults...)
+			}
+			return results, nil
 		}
 
 		return nil, &JSONPathError{
@@ -617,34 +661,30 @@ func (jp *JSONPath) evaluate(current *Value, tokenIndex int) ([]*Value, error) {
 }
 
 // findRecursive 递归查找匹配目标属性的所有节点
-func (jp *JSONPath) findRecursive(current *Value, tokenIndex int) ([]*Value, error) {
-	if current == nil {
-		return []*Value{}, nil
+func (jp *JSONPath) findRecursive(node queryNode, root *Value, tokenIndex int) ([]queryNode, error) {
+	if node.value == nil {
+		return []queryNode{}, nil
+	}
+	if err := jp.budget.step(); err != nil {
+		return nil, err
 	}
 
 	// 创建结果集合
-	var results []*Value
+	var results []queryNode
 
 	// 尝试从当前节点匹配
-	matches, err := jp.matchProperty(current, tokenIndex)
+	matches, err := jp.matchProperty(node, root, tokenIndex)
 	if err == nil && len(matches) > 0 {
 		results = append(results, matches...)
 	}
 
 	// 递归处理子节点
-	if current.Type == OBJECT {
-		for i := 0; i < len(current.O); i++ {
-			childResults, err := jp.findRecursive(current.O[i].V, tokenIndex)
-			if err == nil {
-				results = append(results, childResults...)
-			}
-		}
-	} else if current.Type == ARRAY {
-		for i := 0; i < len(current.A); i++ {
-			childResults, err := jp.findRecursive(current.A[i], tokenIndex)
-			if err == nil {
-				results = append(results, childResults...)
-			}
+	for _, child := range childNodes(node) {
+		childResults, err := jp.findRecursive(child, root, tokenIndex)
+		if err == nil {
+			results = append(results, childResults...)
+		} else if failed := jp.budget.failed(); failed != nil {
+			return nil, failed
 		}
 	}
 
@@ -652,58 +692,45 @@ func (jp *JSONPath) findRecursive(current *Value, tokenIndex int) ([]*Value, err
 }
 
 // matchProperty 尝试匹配当前节点的属性
-func (jp *JSONPath) matchProperty(current *Value, tokenIndex int) ([]*Value, error) {
+func (jp *JSONPath) matchProperty(node queryNode, root *Value, tokenIndex int) ([]queryNode, error) {
 	if tokenIndex >= len(jp.Tokens) {
-		return []*Value{}, nil
+		return []queryNode{}, nil
 	}
 
+	current := node.value
 	token := jp.Tokens[tokenIndex]
 
 	if token.Type == PROPERTY {
 		// 属性匹配
 		if current.Type != OBJECT {
-			return []*Value{}, nil
+			return []queryNode{}, nil
 		}
 
-		var value *Value
 		for i := 0; i < len(current.O); i++ {
 			if current.O[i].K == token.Value {
-				value = current.O[i].V
-				break
+				return jp.evaluate(node.member(current.O[i]), root, tokenIndex+1)
 			}
 		}
 
-		if value == nil {
-			return []*Value{}, nil
-		}
-
-		return jp.evaluate(value, tokenIndex+1)
+		return []queryNode{}, nil
 	} else if token.Type == WILDCARD {
-		// 通配符匹配
-		if current.Type == OBJECT {
-			var results []*Value
-			for i := 0; i < len(current.O); i++ {
-				childResults, err := jp.evaluate(current.O[i].V, tokenIndex+1)
-				if err == nil {
-					results = append(results, childResults...)
-				}
-			}
-			return results, nil
-		} else if current.Type == ARRAY {
-			var results []*Value
-			for i := 0; i < len(current.A); i++ {
-				childResults, err := jp.evaluate(current.A[i], tokenIndex+1)
-				if err == nil {
-					results = append(results, childResults...)
-				}
+		// 通配符匹配，对象的成员或数组的元素
+		var results []queryNode
+		for _, child := range childNodes(node) {
+			childResults, err := jp.evaluate(child, root, tokenIndex+1)
+			if err == nil {
+				results = append(results, childResults...)
+			} else if failed := jp.budget.failed(); failed != nil {
+				return nil, failed
 			}
-			return results, nil
 		}
-
-		return []*Value{}, nil
+		return results, nil
+	} else if token.Type == BRACKET_START {
+		// 方括号表达式，如 $..[0] 和 $..[?(@.price < 10)]
+		return jp.evaluate(node, root, tokenIndex)
 	}
 
-	return []*Value{}, nil
+	return []queryNode{}, nil
 }
 
 // QueryOne 返回第一个匹配的值，如果没有匹配则返回 nil
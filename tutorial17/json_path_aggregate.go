@@ -0,0 +1,194 @@
+// json_path_aggregate.go - JSONPath 查询结果的聚合计算
+package leptjson
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValueGroup 表示按键分组后的一组值
+type ValueGroup struct {
+	Key    string   // 分组键的字符串形式
+	Values []*Value // 属于该组的值，保持原有顺序
+}
+
+// CountValues 返回值的数量
+func CountValues(values []*Value) int {
+	return len(values)
+}
+
+// SumValues 返回所有数字值之和以及参与求和的数字个数，非数字值被忽略
+func SumValues(values []*Value) (float64, int) {
+	sum := 0.0
+	count := 0
+	for _, v := range values {
+		if v != nil && v.Type == NUMBER {
+			sum += v.N
+			count++
+		}
+	}
+	return sum, count
+}
+
+// AvgValues 返回所有数字值的平均数，没有数字值时返回 false
+func AvgValues(values []*Value) (float64, bool) {
+	sum, count := SumValues(values)
+	if count == 0 {
+		return 0, false
+	}
+	return sum / float64(count), true
+}
+
+// MinValue 返回最小的值，比较规则与 SORT_AUTO 排序相同
+func MinValue(values []*Value) (*Value, bool) {
+	return extremeValue(values, -1)
+}
+
+// MaxValue 返回最大的值，比较规则与 SORT_AUTO 排序相同
+func MaxValue(values []*Value) (*Value, bool) {
+	return extremeValue(values, 1)
+}
+
+// extremeValue 返回比较结果为 sign 方向的极值
+func extremeValue(values []*Value, sign int) (*Value, bool) {
+	var result *Value
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		if result == nil || compareSortKeys(v, result, SORT_AUTO)*sign > 0 {
+			result = v
+		}
+	}
+	return result, result != nil
+}
+
+// GroupValues 按相对于每个值求值的表达式（如 "@.category"）分组
+//
+// 分组按键第一次出现的顺序排列，没有分组键的值被忽略。
+func GroupValues(values []*Value, keyExpr string) ([]ValueGroup, error) {
+	keys, err := extractValues(values, keyExpr)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []ValueGroup
+	positions := make(map[string]int)
+	for i, key := range keys {
+		if key == nil {
+			continue
+		}
+		name := aggregateKeyString(key)
+		pos, exists := positions[name]
+		if !exists {
+			pos = len(groups)
+			positions[name] = pos
+			groups = append(groups, ValueGroup{Key: name})
+		}
+		groups[pos].Values = append(groups[pos].Values, values[i])
+	}
+	return groups, nil
+}
+
+// Aggregate 按照聚合说明计算结果，返回表示结果的JSON值
+//
+// spec 的格式为 "函数" 或 "函数:表达式"，函数可以是 count、sum、min、max、avg、group。
+// 表达式相对于每个值求值（如 "sum:@.price"），省略时直接使用值本身；
+// group 必须指定表达式，结果是 {分组键: 数量} 形式的对象。
+func Aggregate(values []*Value, spec string) (*Value, error) {
+	fn, expr := spec, ""
+	if i := strings.Index(spec, ":"); i >= 0 {
+		fn, expr = spec[:i], spec[i+1:]
+	}
+	fn = strings.ToLower(fn)
+
+	if fn == "group" {
+		if expr == "" {
+			return nil, fmt.Errorf("group 聚合需要指定分组表达式，如 group:@.category")
+		}
+		groups, err := GroupValues(values, expr)
+		if err != nil {
+			return nil, err
+		}
+		result := &Value{}
+		SetObject(result)
+		for _, group := range groups {
+			SetNumber(SetObjectValue(result, group.Key), float64(len(group.Values)))
+		}
+		return result, nil
+	}
+
+	operands, err := extractValues(values, expr)
+	if err != nil {
+		return nil, err
+	}
+	// 忽略没有对应字段的值
+	present := operands[:0:0]
+	for _, v := range operands {
+		if v != nil {
+			present = append(present, v)
+		}
+	}
+
+	result := &Value{}
+	switch fn {
+	case "count":
+		SetNumber(result, float64(CountValues(present)))
+	case "sum":
+		sum, _ := SumValues(present)
+		SetNumber(result, sum)
+	case "avg":
+		if avg, ok := AvgValues(present); ok {
+			SetNumber(result, avg)
+		} else {
+			SetNull(result)
+		}
+	case "min", "max":
+		var extreme *Value
+		var ok bool
+		if fn == "min" {
+			extreme, ok = MinValue(present)
+		} else {
+			extreme, ok = MaxValue(present)
+		}
+		if ok {
+			Copy(result, extreme)
+		} else {
+			SetNull(result)
+		}
+	default:
+		return nil, fmt.Errorf("不支持的聚合函数: %s（可选: count, sum, min, max, avg, group）", fn)
+	}
+	return result, nil
+}
+
+// extractValues 对每个值求相对表达式，表达式为空时返回值本身，未匹配的位置为nil
+func extractValues(values []*Value, expr string) ([]*Value, error) {
+	if expr == "" || expr == "@" || expr == "$" {
+		return values, nil
+	}
+	if strings.HasPrefix(expr, "@") {
+		expr = "$" + expr[1:]
+	}
+	jp, err := NewJSONPath(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*Value, len(values))
+	for i, v := range values {
+		if v != nil {
+			results[i], _ = jp.QueryOne(v)
+		}
+	}
+	return results, nil
+}
+
+// aggregateKeyString 返回分组键的字符串形式
+func aggregateKeyString(v *Value) string {
+	if v.Type == STRING {
+		return GetString(v)
+	}
+	s, _ := Stringify(v)
+	return s
+}
@@ -0,0 +1,83 @@
+package leptjson
+
+import (
+	"testing"
+)
+
+func TestAggregate(t *testing.T) {
+	doc := &Value{}
+	if err := Parse(doc, `[
+		{"category":"book","price":10},
+		{"category":"pen","price":2},
+		{"category":"book","price":5.5},
+		{"category":"pen"},
+		{"price":"n/a"}
+	]`); err != PARSE_OK {
+		t.Fatalf("解析失败: %s", err.Error())
+	}
+	results, _ := QueryString(doc, "$[*]")
+
+	tests := []struct {
+		spec     string
+		expected string
+		wantErr  bool
+	}{
+		{spec: "count", expected: `5`},
+		{spec: "count:@.price", expected: `4`},
+		{spec: "sum:$.price", expected: `17.5`},
+		{spec: "avg:@.price", expected: `5.833333333333333`},
+		{spec: "min:@.price", expected: `2`},
+		{spec: "max:@.price", expected: `"n/a"`},
+		{spec: "avg:@.missing", expected: `null`},
+		{spec: "min:@.missing", expected: `null`},
+		{spec: "group:@.category", expected: `{"book":2,"pen":2}`},
+		{spec: "group", wantErr: true},
+		{spec: "median:@.price", wantErr: true},
+		{spec: "sum:price", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := Aggregate(results, tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Aggregate(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if s, _ := Stringify(got); s != tt.expected {
+				t.Errorf("Aggregate(%q) = %s, 期望 %s", tt.spec, s, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAggregateFunctions(t *testing.T) {
+	doc := &Value{}
+	Parse(doc, `[3,"b",1,null,"a"]`)
+	values := doc.A
+
+	if CountValues(values) != 5 {
+		t.Errorf("CountValues = %d", CountValues(values))
+	}
+	if sum, n := SumValues(values); sum != 4 || n != 2 {
+		t.Errorf("SumValues = %v, %d", sum, n)
+	}
+	if avg, ok := AvgValues(values); !ok || avg != 2 {
+		t.Errorf("AvgValues = %v, %v", avg, ok)
+	}
+	if min, ok := MinValue(values); !ok || min.Type != NULL {
+		t.Errorf("MinValue 应当返回 null")
+	}
+	if max, ok := MaxValue(values); !ok || max.S != "b" {
+		t.Errorf("MaxValue 应当返回 \"b\"")
+	}
+	if _, ok := MaxValue(nil); ok {
+		t.Error("空列表没有最大值")
+	}
+
+	groups, err := GroupValues(values, "")
+	if err != nil || len(groups) != 5 || groups[0].Key != "3" || groups[1].Key != "b" {
+		t.Errorf("GroupValues 结果错误: %+v, %v", groups, err)
+	}
+}
@@ -0,0 +1,176 @@
+// json_path_edit.go - 按 JSON Path 批量删除和修改值
+package leptjson
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Delete 删除文档中所有匹配的值，返回删除的数量
+//
+// 同一个数组中的多个匹配按从后往前的顺序删除，因此不会互相影响索引。
+// 位于另一个匹配之内的匹配随外层一起删除，不单独计数。文档根节点不能删除。
+func (jp *JSONPath) Delete(doc *Value) (int, error) {
+	targets, err := jp.editTargets(doc)
+	if err != nil {
+		return 0, err
+	}
+	if len(targets) == 1 && len(targets[0]) == 0 {
+		return 0, fmt.Errorf("不能删除文档根节点")
+	}
+
+	count := 0
+	for i := len(targets) - 1; i >= 0; i-- {
+		steps := targets[i]
+		parent := resolveSteps(doc, steps[:len(steps)-1])
+		last := steps[len(steps)-1]
+		switch {
+		case parent == nil:
+			continue
+		case last.isIndex && parent.Type == ARRAY && last.index < len(parent.A):
+			EraseArrayElement(parent, last.index, 1)
+			count++
+		case !last.isIndex && parent.Type == OBJECT && RemoveObjectValueByKey(parent, last.name):
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Set 将文档中所有匹配的值替换为 value 的副本，返回替换的数量
+//
+// JSON Path 只能匹配已存在的值，因此 Set 不会创建新的成员或元素。
+// 位于另一个匹配之内的匹配随外层一起被替换，不单独计数。
+func (jp *JSONPath) Set(doc *Value, value *Value) (int, error) {
+	if value == nil {
+		return 0, fmt.Errorf("新值不能为空")
+	}
+	targets, err := jp.editTargets(doc)
+	if err != nil {
+		return 0, err
+	}
+
+	// 先复制一份，value 本身可能是文档中被替换的值
+	replacement := cloneValue(value)
+	count := 0
+	for _, steps := range targets {
+		if target := resolveSteps(doc, steps); target != nil {
+			Copy(target, replacement)
+			count++
+		}
+	}
+	return count, nil
+}
+
+// DeleteByPath 删除 v 中所有匹配 JSON Path 表达式的值，返回删除的数量
+func DeleteByPath(v *Value, expr string) (int, error) {
+	jp, err := NewJSONPath(expr)
+	if err != nil {
+		return 0, err
+	}
+	return jp.Delete(v)
+}
+
+// SetByPath 将 v 中所有匹配 JSON Path 表达式的值替换为 newValue 的副本，返回替换的数量
+func SetByPath(v *Value, expr string, newValue *Value) (int, error) {
+	jp, err := NewJSONPath(expr)
+	if err != nil {
+		return 0, err
+	}
+	return jp.Set(v, newValue)
+}
+
+// editTargets 执行查询并返回要修改的位置，每个位置是从根到节点的各步
+//
+// 结果按文档顺序排列并去重，嵌套在其他匹配之内的位置被省略。
+func (jp *JSONPath) editTargets(doc *Value) ([][]*queryLocation, error) {
+	if doc == nil {
+		return nil, fmt.Errorf("JSON 文档不能为空")
+	}
+	nodes, err := jp.queryNodes(doc, doc)
+	if err != nil {
+		return nil, err
+	}
+
+	all := make([][]*queryLocation, len(nodes))
+	for i, node := range nodes {
+		all[i] = locationSteps(node.loc)
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		return compareSteps(all[i], all[j]) < 0
+	})
+
+	// 排序后祖先紧挨在它的后代之前
+	var targets [][]*queryLocation
+	for _, steps := range all {
+		if n := len(targets); n > 0 && hasStepsPrefix(steps, targets[n-1]) {
+			continue
+		}
+		targets = append(targets, steps)
+	}
+	return targets, nil
+}
+
+// locationSteps 返回从根到位置的各步
+func locationSteps(loc *queryLocation) []*queryLocation {
+	var steps []*queryLocation
+	for l := loc; l != nil; l = l.parent {
+		steps = append(steps, l)
+	}
+	for i, j := 0, len(steps)-1; i < j; i, j = i+1, j-1 {
+		steps[i], steps[j] = steps[j], steps[i]
+	}
+	return steps
+}
+
+// compareSteps 逐步比较两个位置，数组索引按数值比较，前缀排在前面
+func compareSteps(a, b []*queryLocation) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		x, y := a[i], b[i]
+		switch {
+		case x.isIndex && y.isIndex:
+			if x.index != y.index {
+				return x.index - y.index
+			}
+		case x.isIndex != y.isIndex:
+			if x.isIndex {
+				return -1
+			}
+			return 1
+		default:
+			if c := strings.Compare(x.name, y.name); c != 0 {
+				return c
+			}
+		}
+	}
+	return len(a) - len(b)
+}
+
+// hasStepsPrefix 判断 steps 是否以 prefix 开头（包括相等）
+func hasStepsPrefix(steps, prefix []*queryLocation) bool {
+	return len(prefix) <= len(steps) && compareSteps(steps[:len(prefix)], prefix) == 0
+}
+
+// resolveSteps 从 doc 开始按各步找到对应的值，不存在时返回 nil
+func resolveSteps(doc *Value, steps []*queryLocation) *Value {
+	current := doc
+	for _, step := range steps {
+		if step.isIndex {
+			if current.Type != ARRAY || step.index >= len(current.A) {
+				return nil
+			}
+			current = current.A[step.index]
+			continue
+		}
+		if current.Type != OBJECT {
+			return nil
+		}
+		index := FindObjectIndex(current, step.name)
+		if index < 0 {
+			return nil
+		}
+		current = current.O[index].V
+	}
+	return current
+}
@@ -0,0 +1,114 @@
+package leptjson
+
+import (
+	"testing"
+)
+
+func TestDeleteByPath(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		path  string
+		count int
+		want  string
+	}{
+		{"过滤器", `{"items":[{"id":1,"done":true},{"id":2},{"id":3,"done":true}]}`,
+			"$.items[?(@.done == true)]", 2, `{"items":[{"id":2}]}`},
+		{"递归下降", `{"a":1,"b":{"a":2,"c":[{"a":3}]}}`, "$..a", 3, `{"b":{"c":[{}]}}`},
+		{"嵌套的匹配随外层删除", `{"x":{"x":{"x":1}},"y":2}`, "$..x", 1, `{"y":2}`},
+		{"切片", `[0,1,2,3,4,5]`, "$[1:5:2]", 2, `[0,2,4,5]`},
+		{"通配符", `{"a":[1,2,3]}`, "$.a[*]", 3, `{"a":[]}`},
+		{"没有匹配", `{"a":1}`, "$.b", 0, `{"a":1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := &Value{}
+			if err := Parse(doc, tt.doc); err != PARSE_OK {
+				t.Fatal(err)
+			}
+			count, err := DeleteByPath(doc, tt.path)
+			if err != nil {
+				t.Fatalf("DeleteByPath(%s) error = %v", tt.path, err)
+			}
+			text, _ := Stringify(doc)
+			if count != tt.count || text != tt.want {
+				t.Errorf("DeleteByPath(%s) = %d, %s, 期望 %d, %s", tt.path, count, text, tt.count, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeleteDuplicateMatches(t *testing.T) {
+	doc := &Value{}
+	Parse(doc, `["a","b","c","d"]`)
+	jp, err := NewJSONPathWithOptions("$[0,-1,0,2]", JSONPathOptions{RFC9535: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	count, err := jp.Delete(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, _ := Stringify(doc)
+	if count != 3 || text != `["b"]` {
+		t.Errorf("Delete = %d, %s", count, text)
+	}
+}
+
+func TestDeleteRoot(t *testing.T) {
+	doc := &Value{}
+	Parse(doc, `{"a":1}`)
+	if _, err := DeleteByPath(doc, "$"); err == nil {
+		t.Errorf("删除根节点应该失败")
+	}
+}
+
+func TestSetByPath(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		path  string
+		value string
+		count int
+		want  string
+	}{
+		{"递归下降", `{"a":{"price":1},"b":[{"price":2}]}`, "$..price", `0`, 2, `{"a":{"price":0},"b":[{"price":0}]}`},
+		{"过滤器", `[{"n":1},{"n":5}]`, "$[?(@.n > 2)].n", `"big"`, 1, `[{"n":1},{"n":"big"}]`},
+		{"嵌套的匹配随外层替换", `{"x":{"x":1}}`, "$..x", `{"x":2}`, 1, `{"x":{"x":2}}`},
+		{"根节点", `{"a":1}`, "$", `[true]`, 1, `[true]`},
+		{"不会创建成员", `{"a":1}`, "$.b", `2`, 0, `{"a":1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, value := &Value{}, &Value{}
+			Parse(doc, tt.doc)
+			Parse(value, tt.value)
+			count, err := SetByPath(doc, tt.path, value)
+			if err != nil {
+				t.Fatalf("SetByPath(%s) error = %v", tt.path, err)
+			}
+			text, _ := Stringify(doc)
+			if count != tt.count || text != tt.want {
+				t.Errorf("SetByPath(%s) = %d, %s, 期望 %d, %s", tt.path, count, text, tt.count, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetByPathValueFromDocument(t *testing.T) {
+	doc := &Value{}
+	Parse(doc, `{"template":{"v":1},"items":[{"t":0},{"t":0}]}`)
+
+	// 新值来自文档本身，每个匹配得到独立的副本
+	template, _ := FindObjectKey(doc, "template")
+	if _, err := SetByPath(doc, "$.items[*].t", template); err != nil {
+		t.Fatal(err)
+	}
+	items, _ := FindObjectKey(doc, "items")
+	first, _ := FindObjectKey(items.A[0], "t")
+	second, _ := FindObjectKey(items.A[1], "t")
+	SetNumber(first.O[0].V, 9)
+	if second.O[0].V.N != 1 || template.O[0].V.N != 1 {
+		t.Errorf("替换的值应该是独立的副本")
+	}
+}
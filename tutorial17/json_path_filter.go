@@ -0,0 +1,844 @@
+// json_path_filter.go - JSON Path 过滤表达式的词法分析、语法树和求值
+package leptjson
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// 过滤表达式的语法（与 RFC 9535 对齐，并支持 =~、in、nin 等常用扩展）：
+//
+//	or         = and *( "||" and )
+//	and        = unary *( "&&" unary )
+//	unary      = "!" unary / "(" or ")" / comparison
+//	comparison = operand [ op operand ]    ; op: == != < <= > >= =~ in nin
+//	operand    = 字面量 / 路径 / 函数调用 / 列表
+//	路径       = ("@" / "$") *段           ; 段可以包含嵌套的过滤器
+//	函数调用   = 名称 "(" [ operand *( "," operand ) ] ")"
+//	列表       = "[" [ 字面量 *( "," 字面量 ) ] "]"
+//
+// 单独出现的路径表示存在性测试；比较运算中的路径必须恰好匹配一个值，
+// 否则视为"不存在"，"不存在"只与"不存在"相等。
+
+// filterExpr 是返回真假的过滤表达式
+type filterExpr interface {
+	test(current, root *Value) (bool, error)
+}
+
+// filterOperand 是比较运算和函数的操作数，求值结果是一组值
+type filterOperand interface {
+	values(current, root *Value) ([]*Value, error)
+}
+
+// 过滤表达式的词法单元类型
+type filterTokenKind int
+
+const (
+	filterEOF filterTokenKind = iota
+	filterNumber
+	filterString
+	filterRegex
+	filterIdent
+	filterPath
+	filterOp
+)
+
+// filterToken 是过滤表达式中的一个词法单元
+type filterToken struct {
+	kind filterTokenKind
+	text string  // 运算符、标识符、路径的原文，或字符串、正则表达式解码后的内容
+	num  float64 // 数字字面量的值
+	pos  int     // 在完整路径表达式中的位置
+}
+
+// findFilterEnd 返回过滤表达式之后的 ']' 的位置，找不到时返回路径长度
+//
+// 引号中的字符和成对的括号不会结束过滤表达式，因此可以嵌套过滤器。
+func findFilterEnd(path string, start int) int {
+	depth := 0
+	for i := start; i < len(path); i++ {
+		switch path[i] {
+		case '\'', '"':
+			i = skipQuoted(path, i)
+		case '(', '[':
+			depth++
+		case ')':
+			depth--
+		case ']':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return len(path)
+}
+
+// skipQuoted 返回从 i 开始的带引号字符串的结束引号位置
+func skipQuoted(s string, i int) int {
+	quote := s[i]
+	for i++; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+		} else if s[i] == quote {
+			return i
+		}
+	}
+	return len(s)
+}
+
+// compileFilter 将 path[start:end] 中的过滤表达式编译为语法树
+func compileFilter(path string, start, end int) (filterExpr, error) {
+	tokens, err := lexFilter(path, start, end)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{path: path, tokens: tokens, end: end}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != filterEOF {
+		return nil, p.errorf(tok, "多余的内容 '%s'", tok.text)
+	}
+	return expr, nil
+}
+
+// lexFilter 将过滤表达式拆分为词法单元
+func lexFilter(path string, start, end int) ([]filterToken, error) {
+	var tokens []filterToken
+	s := path[:end]
+	i := start
+	for {
+		for i < end && (s[i] == ' ' || s[i] == '\t' || s[i] == '\n' || s[i] == '\r') {
+			i++
+		}
+		if i >= end {
+			tokens = append(tokens, filterToken{kind: filterEOF, pos: end})
+			return tokens, nil
+		}
+
+		c := s[i]
+		switch {
+		case c == '@' || c == '$':
+			j := scanFilterPath(s, i)
+			tokens = append(tokens, filterToken{kind: filterPath, text: s[i:j], pos: i})
+			i = j
+
+		case c == '\'' || c == '"':
+			j := skipQuoted(s, i)
+			if j >= end {
+				return nil, &JSONPathError{Path: path, Message: "未闭合的字符串", Index: i}
+			}
+			text, err := unquoteFilterString(s[i+1:j], c)
+			if err != nil {
+				return nil, &JSONPathError{Path: path, Message: err.Error(), Index: i}
+			}
+			tokens = append(tokens, filterToken{kind: filterString, text: text, pos: i})
+			i = j + 1
+
+		case c == '-' || (c >= '0' && c <= '9'):
+			j := i + 1
+			for j < end && (isDigit(s[j]) || s[j] == '.' || s[j] == 'e' || s[j] == 'E' ||
+				((s[j] == '+' || s[j] == '-') && (s[j-1] == 'e' || s[j-1] == 'E'))) {
+				j++
+			}
+			n, err := strconv.ParseFloat(s[i:j], 64)
+			if err != nil {
+				return nil, &JSONPathError{Path: path, Message: fmt.Sprintf("无效的数字 '%s'", s[i:j]), Index: i}
+			}
+			tokens = append(tokens, filterToken{kind: filterNumber, text: s[i:j], num: n, pos: i})
+			i = j
+
+		case c == '/':
+			// 正则表达式字面量 /pattern/flags，只能出现在 =~ 之后
+			j := i + 1
+			for j < end && s[j] != '/' {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= end {
+				return nil, &JSONPathError{Path: path, Message: "未闭合的正则表达式", Index: i}
+			}
+			pattern := s[i+1 : j]
+			j++
+			flags := ""
+			for j < end && (s[j] == 'i' || s[j] == 'm' || s[j] == 's') {
+				flags += string(s[j])
+				j++
+			}
+			if flags != "" {
+				pattern = "(?" + flags + ")" + pattern
+			}
+			tokens = append(tokens, filterToken{kind: filterRegex, text: pattern, pos: i})
+			i = j
+
+		case isValidPropertyNameStart(c):
+			j := i + 1
+			for j < end && isValidPropertyNameChar(s[j]) {
+				j++
+			}
+			tokens = append(tokens, filterToken{kind: filterIdent, text: s[i:j], pos: i})
+			i = j
+
+		default:
+			op := ""
+			for _, candidate := range []string{"==", "!=", "<=", ">=", "=~", "&&", "||", "<", ">", "!", "(", ")", "[", "]", ","} {
+				if strings.HasPrefix(s[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, &JSONPathError{Path: path, Message: fmt.Sprintf("过滤表达式中无法识别的字符 '%c'", c), Index: i}
+			}
+			tokens = append(tokens, filterToken{kind: filterOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// scanFilterPath 返回从 i 开始的 @ 或 $ 路径的结束位置
+func scanFilterPath(s string, i int) int {
+	i++ // 跳过 @ 或 $
+	for i < len(s) {
+		switch s[i] {
+		case '.':
+			i++
+			if i < len(s) && s[i] == '.' {
+				i++
+			}
+			if i < len(s) && s[i] == '*' {
+				i++
+				continue
+			}
+			for i < len(s) && isValidPropertyNameChar(s[i]) {
+				i++
+			}
+		case '[':
+			depth := 0
+			for ; i < len(s); i++ {
+				if s[i] == '\'' || s[i] == '"' {
+					i = skipQuoted(s, i)
+				} else if s[i] == '[' {
+					depth++
+				} else if s[i] == ']' {
+					depth--
+					if depth == 0 {
+						break
+					}
+				}
+			}
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// unquoteFilterString 解码过滤表达式中的字符串字面量
+func unquoteFilterString(s string, quote byte) (string, error) {
+	if strings.IndexByte(s, '\\') < 0 {
+		return s, nil
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i >= len(s) {
+			return "", fmt.Errorf("字符串以转义符结尾")
+		}
+		switch s[i] {
+		case '\\', '/', '\'', '"':
+			sb.WriteByte(s[i])
+		case 'b':
+			sb.WriteByte('\b')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 't':
+			sb.WriteByte('\t')
+		case 'u':
+			if i+4 >= len(s) {
+				return "", fmt.Errorf("无效的 \\u 转义")
+			}
+			r, err := strconv.ParseUint(s[i+1:i+5], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("无效的 \\u 转义")
+			}
+			sb.WriteRune(rune(r))
+			i += 4
+		default:
+			return "", fmt.Errorf("无效的转义字符 '\\%c'", s[i])
+		}
+	}
+	return sb.String(), nil
+}
+
+// filterParser 是过滤表达式的递归下降语法分析器
+type filterParser struct {
+	path   string
+	tokens []filterToken
+	pos    int
+	end    int
+}
+
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() filterToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != filterEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// accept 在下一个词法单元是指定运算符时消耗它
+func (p *filterParser) accept(op string) bool {
+	if tok := p.peek(); tok.kind == filterOp && tok.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) expect(op string) error {
+	if !p.accept(op) {
+		tok := p.peek()
+		return p.errorf(tok, "期望 '%s'", op)
+	}
+	return nil
+}
+
+func (p *filterParser) errorf(tok filterToken, format string, args ...interface{}) error {
+	return &JSONPathError{Path: p.path, Message: "过滤表达式: " + fmt.Sprintf(format, args...), Index: tok.pos}
+}
+
+func (p *filterParser) parseOr() (filterExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &filterLogical{or: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filterExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &filterLogical{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (filterExpr, error) {
+	if p.accept("!") {
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &filterNot{expr: expr}, nil
+	}
+	if p.accept("(") {
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return expr, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *filterParser) parseComparison() (filterExpr, error) {
+	start := p.peek()
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	tok := p.peek()
+	op := ""
+	switch {
+	case tok.kind == filterOp && (tok.text == "==" || tok.text == "!=" || tok.text == "<" ||
+		tok.text == "<=" || tok.text == ">" || tok.text == ">=" || tok.text == "=~"):
+		op = tok.text
+	case tok.kind == filterIdent && (tok.text == "in" || tok.text == "nin"):
+		op = tok.text
+	}
+	if op == "" {
+		// 没有比较运算符：路径是存在性测试，其他操作数必须是布尔值
+		return &filterTruthy{operand: left, isPath: start.kind == filterPath}, nil
+	}
+	p.next()
+
+	if op == "=~" {
+		return p.parseRegexMatch(left)
+	}
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return &filterComparison{op: op, left: left, right: right}, nil
+}
+
+// parseRegexMatch 解析 =~ 右侧的正则表达式，字面量在编译时检查
+func (p *filterParser) parseRegexMatch(left filterOperand) (filterExpr, error) {
+	tok := p.peek()
+	if tok.kind == filterRegex || tok.kind == filterString {
+		p.next()
+		re, err := regexp.Compile(tok.text)
+		if err != nil {
+			return nil, p.errorf(tok, "无效的正则表达式: %v", err)
+		}
+		return &filterRegexMatch{left: left, re: re}, nil
+	}
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return &filterRegexMatch{left: left, pattern: right}, nil
+}
+
+func (p *filterParser) parseOperand() (filterOperand, error) {
+	tok := p.next()
+	switch tok.kind {
+	case filterNumber:
+		v := &Value{}
+		SetNumber(v, tok.num)
+		return filterLiteral{v}, nil
+	case filterString:
+		v := &Value{}
+		SetString(v, tok.text)
+		return filterLiteral{v}, nil
+	case filterPath:
+		return p.compilePath(tok)
+	case filterIdent:
+		switch tok.text {
+		case "true":
+			return filterLiteral{&Value{Type: TRUE}}, nil
+		case "false":
+			return filterLiteral{&Value{Type: FALSE}}, nil
+		case "null":
+			return filterLiteral{&Value{Type: NULL}}, nil
+		}
+		if p.accept("(") {
+			return p.parseFunction(tok)
+		}
+		return nil, p.errorf(tok, "未知的标识符 '%s'", tok.text)
+	case filterOp:
+		if tok.text == "[" {
+			return p.parseList()
+		}
+	case filterRegex:
+		return nil, p.errorf(tok, "正则表达式只能用在 =~ 之后")
+	case filterEOF:
+		return nil, p.errorf(tok, "表达式不完整")
+	}
+	return nil, p.errorf(tok, "期望操作数，实际为 '%s'", tok.text)
+}
+
+// compilePath 将 @ 或 $ 开头的路径编译为 JSONPath
+func (p *filterParser) compilePath(tok filterToken) (filterOperand, error) {
+	relative := tok.text[0] == '@'
+	jp, err := NewJSONPath("$" + tok.text[1:])
+	if err != nil {
+		if pathErr, ok := err.(*JSONPathError); ok {
+			return nil, &JSONPathError{Path: p.path, Message: "过滤表达式: " + pathErr.Message, Index: tok.pos + pathErr.Index}
+		}
+		return nil, err
+	}
+	return &filterPathOperand{path: jp, relative: relative}, nil
+}
+
+// parseList 解析 [a, b, ...] 列表字面量
+func (p *filterParser) parseList() (filterOperand, error) {
+	list := &Value{}
+	SetArray(list, 0)
+	if p.accept("]") {
+		return filterLiteral{list}, nil
+	}
+	for {
+		elem, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		literal, ok := elem.(filterLiteral)
+		if !ok {
+			return nil, p.errorf(p.tokens[p.pos-1], "列表中只能包含字面量")
+		}
+		Copy(PushBackArrayElement(list), literal.v)
+		if p.accept("]") {
+			return filterLiteral{list}, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseFunction 解析函数调用的参数并检查参数数量
+func (p *filterParser) parseFunction(name filterToken) (filterOperand, error) {
+	fn, ok := filterFunctions[name.text]
+	if !ok {
+		return nil, p.errorf(name, "未知的函数 '%s'", name.text)
+	}
+	var args []filterOperand
+	if !p.accept(")") {
+		for {
+			arg, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.accept(")") {
+				break
+			}
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(args) != fn.arity {
+		return nil, p.errorf(name, "函数 %s() 需要%d个参数，实际为%d个", name.text, fn.arity, len(args))
+	}
+	return &filterCall{name: name.text, fn: fn, args: args}, nil
+}
+
+// filterLogical 是 && 或 ||，右侧只在需要时求值
+type filterLogical struct {
+	or          bool
+	left, right filterExpr
+}
+
+func (e *filterLogical) test(current, root *Value) (bool, error) {
+	ok, err := e.left.test(current, root)
+	if err != nil || ok == e.or {
+		return ok, err
+	}
+	return e.right.test(current, root)
+}
+
+// filterNot 是逻辑非
+type filterNot struct {
+	expr filterExpr
+}
+
+func (e *filterNot) test(current, root *Value) (bool, error) {
+	ok, err := e.expr.test(current, root)
+	return !ok, err
+}
+
+// filterTruthy 是单独出现的操作数：路径测试是否存在，其他操作数测试是否为 true
+type filterTruthy struct {
+	operand filterOperand
+	isPath  bool
+}
+
+func (e *filterTruthy) test(current, root *Value) (bool, error) {
+	values, err := e.operand.values(current, root)
+	if err != nil {
+		return false, err
+	}
+	if e.isPath {
+		return len(values) > 0, nil
+	}
+	return len(values) == 1 && values[0].Type == TRUE, nil
+}
+
+// filterComparison 是比较运算和 in、nin
+type filterComparison struct {
+	op          string
+	left, right filterOperand
+}
+
+func (e *filterComparison) test(current, root *Value) (bool, error) {
+	left, err := singularValue(e.left, current, root)
+	if err != nil {
+		return false, err
+	}
+	right, err := singularValue(e.right, current, root)
+	if err != nil {
+		return false, err
+	}
+
+	switch e.op {
+	case "==":
+		return filterEqual(left, right), nil
+	case "!=":
+		return !filterEqual(left, right), nil
+	case "<":
+		return filterLess(left, right), nil
+	case "<=":
+		return filterLess(left, right) || filterEqual(left, right), nil
+	case ">":
+		return filterLess(right, left), nil
+	case ">=":
+		return filterLess(right, left) || filterEqual(left, right), nil
+	case "in":
+		return filterContains(right, left), nil
+	case "nin":
+		return !filterContains(right, left), nil
+	}
+	return false, fmt.Errorf("不支持的运算符 %s", e.op)
+}
+
+// singularValue 返回操作数的单个值，值的数量不是1时返回 nil 表示不存在
+func singularValue(operand filterOperand, current, root *Value) (*Value, error) {
+	values, err := operand.values(current, root)
+	if err != nil || len(values) != 1 {
+		return nil, err
+	}
+	return values[0], nil
+}
+
+// filterEqual 比较两个值，"不存在"只与"不存在"相等
+func filterEqual(a, b *Value) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return ValuesEqual(a, b)
+}
+
+// filterLess 只比较两个数字或两个字符串，其他组合总是 false
+func filterLess(a, b *Value) bool {
+	if a == nil || b == nil || a.Type != b.Type {
+		return false
+	}
+	switch a.Type {
+	case NUMBER:
+		return a.N < b.N
+	case STRING:
+		return GetString(a) < GetString(b)
+	}
+	return false
+}
+
+// filterContains 判断数组 list 是否包含 v
+func filterContains(list, v *Value) bool {
+	if list == nil || v == nil || list.Type != ARRAY {
+		return false
+	}
+	for _, elem := range list.A {
+		if ValuesEqual(elem, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterRegexMatch 是 =~，左侧是字符串且包含正则表达式的匹配时为真
+type filterRegexMatch struct {
+	left    filterOperand
+	re      *regexp.Regexp // 字面量的正则表达式，编译时已检查
+	pattern filterOperand  // 运行时求值的正则表达式
+}
+
+func (e *filterRegexMatch) test(current, root *Value) (bool, error) {
+	left, err := singularValue(e.left, current, root)
+	if err != nil || left == nil || left.Type != STRING {
+		return false, err
+	}
+	re := e.re
+	if re == nil {
+		pattern, err := singularValue(e.pattern, current, root)
+		if err != nil || pattern == nil || pattern.Type != STRING {
+			return false, err
+		}
+		if re, err = regexp.Compile(GetString(pattern)); err != nil {
+			return false, fmt.Errorf("无效的正则表达式 '%s': %v", GetString(pattern), err)
+		}
+	}
+	return re.MatchString(GetString(left)), nil
+}
+
+// filterLiteral 是字面量操作数
+type filterLiteral struct {
+	v *Value
+}
+
+func (o filterLiteral) values(current, root *Value) ([]*Value, error) {
+	return []*Value{o.v}, nil
+}
+
+// filterPathOperand 是 @ 或 $ 开头的路径，结果可以有任意多个值
+type filterPathOperand struct {
+	path     *JSONPath
+	relative bool
+}
+
+func (o *filterPathOperand) values(current, root *Value) ([]*Value, error) {
+	start := root
+	if o.relative {
+		start = current
+	}
+	return o.path.query(start, root)
+}
+
+// filterFunction 是过滤表达式中可用的函数
+type filterFunction struct {
+	arity int
+	// call 的参数是每个操作数求值得到的值列表，返回 nil 表示结果不存在
+	call func(args [][]*Value) (*Value, error)
+}
+
+// filterFunctions 是可用的函数
+//
+//	length(v)        字符串的字符数、数组的元素数或对象的成员数
+//	count(nodes)     路径匹配的值的数量
+//	keys(v)          对象的键组成的数组
+//	value(nodes)     路径恰好匹配一个值时返回该值
+//	match(s, re)     字符串完全匹配正则表达式
+//	search(s, re)    字符串包含正则表达式的匹配
+var filterFunctions = map[string]filterFunction{
+	"length": {1, func(args [][]*Value) (*Value, error) {
+		v := singleArg(args[0])
+		if v == nil {
+			return nil, nil
+		}
+		n := 0
+		switch v.Type {
+		case STRING:
+			n = utf8.RuneCountInString(GetString(v))
+		case ARRAY:
+			n = len(v.A)
+		case OBJECT:
+			n = len(v.O)
+		default:
+			return nil, nil
+		}
+		return newFilterNumber(float64(n)), nil
+	}},
+	"count": {1, func(args [][]*Value) (*Value, error) {
+		return newFilterNumber(float64(len(args[0]))), nil
+	}},
+	"keys": {1, func(args [][]*Value) (*Value, error) {
+		v := singleArg(args[0])
+		if v == nil || v.Type != OBJECT {
+			return nil, nil
+		}
+		keys := &Value{}
+		SetArray(keys, len(v.O))
+		for _, member := range v.O {
+			SetString(PushBackArrayElement(keys), member.K)
+		}
+		return keys, nil
+	}},
+	"value": {1, func(args [][]*Value) (*Value, error) {
+		return singleArg(args[0]), nil
+	}},
+	"match": {2, func(args [][]*Value) (*Value, error) {
+		return regexFunction(args, true)
+	}},
+	"search": {2, func(args [][]*Value) (*Value, error) {
+		return regexFunction(args, false)
+	}},
+}
+
+// filterCall 是函数调用
+type filterCall struct {
+	name string
+	fn   filterFunction
+	args []filterOperand
+}
+
+func (o *filterCall) values(current, root *Value) ([]*Value, error) {
+	args := make([][]*Value, len(o.args))
+	for i, arg := range o.args {
+		values, err := arg.values(current, root)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = values
+	}
+	result, err := o.fn.call(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %v", o.name, err)
+	}
+	if result == nil {
+		return nil, nil
+	}
+	return []*Value{result}, nil
+}
+
+// singleArg 返回只有一个值的参数，否则返回 nil
+func singleArg(values []*Value) *Value {
+	if len(values) != 1 {
+		return nil
+	}
+	return values[0]
+}
+
+func newFilterNumber(n float64) *Value {
+	v := &Value{}
+	SetNumber(v, n)
+	return v
+}
+
+// regexFunction 实现 match() 和 search()，参数不是字符串时结果为 false
+func regexFunction(args [][]*Value, full bool) (*Value, error) {
+	s, pattern := singleArg(args[0]), singleArg(args[1])
+	if s == nil || pattern == nil || s.Type != STRING || pattern.Type != STRING {
+		return &Value{Type: FALSE}, nil
+	}
+	expr := GetString(pattern)
+	if full {
+		expr = "^(?:" + expr + ")$"
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("无效的正则表达式 '%s': %v", GetString(pattern), err)
+	}
+	if re.MatchString(GetString(s)) {
+		return &Value{Type: TRUE}, nil
+	}
+	return &Value{Type: FALSE}, nil
+}
+
+// filterChildren 返回过滤器要测试的值：数组的元素或对象成员的值
+func filterChildren(v *Value) []*Value {
+	switch v.Type {
+	case ARRAY:
+		return v.A
+	case OBJECT:
+		children := make([]*Value, len(v.O))
+		for i, member := range v.O {
+			children[i] = member.V
+		}
+		return children
+	}
+	return nil
+}
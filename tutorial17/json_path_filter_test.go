@@ -0,0 +1,133 @@
+// json_path_filter_test.go - JSON Path 过滤表达式测试
+package leptjson
+
+import (
+	"strings"
+	"testing"
+)
+
+const filterTestDoc = `{
+	"limit": 10,
+	"store": {
+		"book": [
+			{"title": "A", "price": 8.95, "tags": ["go", "json"], "isbn": "1"},
+			{"title": "Bb", "price": 12.99, "tags": []},
+			{"title": "C", "price": 8.99, "author": {"name": "Tolkien"}, "isbn": null}
+		],
+		"bicycle": {"color": "red", "price": 19.95}
+	}
+}`
+
+// 查询结果的紧凑JSON，用于比较
+func queryStrings(t *testing.T, doc *Value, path string) []string {
+	t.Helper()
+	results, err := QueryString(doc, path)
+	if err != nil {
+		t.Fatalf("QueryString(%s) error = %v", path, err)
+	}
+	out := []string{}
+	for _, r := range results {
+		s, _ := Stringify(r)
+		out = append(out, s)
+	}
+	return out
+}
+
+func TestJSONPathFilter(t *testing.T) {
+	doc := &Value{}
+	if err := Parse(doc, filterTestDoc); err != PARSE_OK {
+		t.Fatalf("解析测试文档失败: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"小于", "$.store.book[?(@.price < 10)].title", `"A","C"`},
+		{"大于等于", "$.store.book[?(@.price >= 8.99)].title", `"Bb","C"`},
+		{"不等于", "$.store.book[?(@.title != 'A')].title", `"Bb","C"`},
+		{"省略括号", "$.store.book[?@.price > 10].title", `"Bb"`},
+		{"存在性", "$.store.book[?(@.isbn)].title", `"A","C"`},
+		{"不存在", "$.store.book[?(!@.isbn)].title", `"Bb"`},
+		{"等于null", "$.store.book[?(@.isbn == null)].title", `"C"`},
+		{"与根比较", "$.store.book[?(@.price < $.limit)].title", `"A","C"`},
+		{"逻辑与", "$.store.book[?(@.price < 10 && @.isbn == '1')].title", `"A"`},
+		{"逻辑或", "$.store.book[?(@.price > 12 || @.author.name == \"Tolkien\")].title", `"Bb","C"`},
+		{"分组和非", "$.store.book[?(!(@.price < 9 || @.price > 12))]", ``},
+		{"正则字面量", "$.store.book[?(@.title =~ /^b/i)].title", `"Bb"`},
+		{"正则字符串", "$.store.book[?(@.title =~ 'b$')].title", `"Bb"`},
+		{"in列表", "$.store.book[?(@.title in ['A', 'C'])].title", `"A","C"`},
+		{"nin列表", "$.store.book[?(@.title nin ['A', 'C'])].title", `"Bb"`},
+		{"in数组值", "$.store.book[?('go' in @.tags)].title", `"A"`},
+		{"嵌套过滤器", "$.store.book[?(@.tags[?(@ == 'json')])].title", `"A"`},
+		{"length", "$.store.book[?(length(@.title) == 2)].title", `"Bb"`},
+		{"length数组", "$.store.book[?(length(@.tags) == 0)].title", `"Bb"`},
+		{"count", "$.store.book[?(count(@.*) > 3)].title", `"A","C"`},
+		{"keys", "$.store.book[?('author' in keys(@))].title", `"C"`},
+		{"value", "$.store.book[?(value(@.author.name) == 'Tolkien')].title", `"C"`},
+		{"match完全匹配", "$.store.book[?(match(@.title, 'B.'))].title", `"Bb"`},
+		{"match不是部分匹配", "$.store.book[?(match(@.title, 'B'))].title", ``},
+		{"search", "$.store.book[?(search(@.title, 'b'))].title", `"Bb"`},
+		{"对象成员", "$.store[?(@.color == 'red')].price", `19.95`},
+		{"递归下降", "$..[?(@.name)].name", `"Tolkien"`},
+		{"类型不同不比较大小", "$.store.book[?(@.title < 5)]", ``},
+		{"多个值不参与比较", "$.store.book[?(@.tags[*] == 'go')]", ``},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := strings.Join(queryStrings(t, doc, tt.path), ",")
+			if got != tt.want {
+				t.Errorf("%s = [%s], 期望 [%s]", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONPathFilterErrors(t *testing.T) {
+	tests := []struct {
+		path string
+		msg  string
+	}{
+		{"$[?(@.a ==)]", "期望操作数"},
+		{"$[?(@.a == 1]", "期望 ')'"},
+		{"$[?(@.a 'x')]", "期望 ')'"},
+		{"$[?(@.a =~ /(/)]", "无效的正则表达式"},
+		{"$[?(foo(@))]", "未知的函数"},
+		{"$[?(length(@, 1))]", "需要1个参数"},
+		{"$[?(@.a == 'x)]", "未闭合的字符串"},
+		{"$[?(@.a in [@.b])]", "只能包含字面量"},
+		{"$[?(@.a == bar)]", "未知的标识符"},
+		{"$[?(@.a == 1)", "未闭合的方括号"},
+		{"$[?(@.a # 1)]", "无法识别的字符"},
+	}
+
+	for _, tt := range tests {
+		_, err := NewJSONPath(tt.path)
+		if err == nil {
+			t.Errorf("NewJSONPath(%s) 期望失败", tt.path)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.msg) {
+			t.Errorf("NewJSONPath(%s) error = %v, 期望包含 %q", tt.path, err, tt.msg)
+		}
+	}
+}
+
+func TestFindFilterEnd(t *testing.T) {
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"$[?(@.a)]", 8},
+		{"$[?(@.a == ']')]", 15},
+		{"$[?(@.a[?(@.b)])]", 16},
+		{"$[?(@.a", 7},
+	}
+	for _, tt := range tests {
+		if got := findFilterEnd(tt.path, 3); got != tt.want {
+			t.Errorf("findFilterEnd(%s) = %d, 期望 %d", tt.path, got, tt.want)
+		}
+	}
+}
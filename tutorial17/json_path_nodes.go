@@ -0,0 +1,157 @@
+// json_path_nodes.go - JSON Path 结果的位置和规范化路径
+package leptjson
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PathResult 是带有位置的查询结果
+type PathResult struct {
+	Value *Value // 匹配的值，与文档共享
+	Path  string // 规范化路径（RFC 9535 第2.7节），如 $['store']['book'][0]
+
+	loc *queryLocation
+}
+
+// Pointer 返回结果的 JSON Pointer，如 /store/book/0，文档根节点返回空字符串
+//
+// 返回的指针可以直接用于 pointer 命令和 JSON Patch 操作。
+func (r PathResult) Pointer() string {
+	var tokens []string
+	for l := r.loc; l != nil; l = l.parent {
+		if l.isIndex {
+			tokens = append(tokens, strconv.Itoa(l.index))
+		} else {
+			tokens = append(tokens, escapeJSONPointerToken(l.name))
+		}
+	}
+	var sb strings.Builder
+	for i := len(tokens) - 1; i >= 0; i-- {
+		sb.WriteString("/" + tokens[i])
+	}
+	return sb.String()
+}
+
+// QueryWithPaths 执行查询，返回每个匹配的值及其规范化路径
+//
+// 结果的顺序与 Query 相同。同一个值被多次匹配时（如 $[0,0]）会出现多次。
+func (jp *JSONPath) QueryWithPaths(doc *Value) ([]PathResult, error) {
+	if doc == nil {
+		return nil, fmt.Errorf("JSON 文档不能为空")
+	}
+	nodes, err := jp.queryNodes(doc, doc)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]PathResult, len(nodes))
+	for i, node := range nodes {
+		results[i] = PathResult{Value: node.value, Path: normalizedPath(node.loc), loc: node.loc}
+	}
+	return results, nil
+}
+
+// QueryWithPathsString 是一个便捷函数，使用路径表达式查询并返回值及其规范化路径
+func QueryWithPathsString(doc *Value, path string) ([]PathResult, error) {
+	jp, err := NewJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return jp.QueryWithPaths(doc)
+}
+
+// queryNode 是查询结果中的一个节点：值及其在文档中的位置
+type queryNode struct {
+	value *Value
+	loc   *queryLocation
+}
+
+// queryLocation 以链表记录从查询起点到节点的每一步，根节点的位置是 nil
+type queryLocation struct {
+	parent  *queryLocation
+	name    string // 对象成员名
+	index   int    // 数组索引
+	isIndex bool
+}
+
+// normalizedPath 返回位置的规范化路径（RFC 9535 第2.7节），如 $['store']['book'][0]
+func normalizedPath(loc *queryLocation) string {
+	var steps []*queryLocation
+	for l := loc; l != nil; l = l.parent {
+		steps = append(steps, l)
+	}
+	var sb strings.Builder
+	sb.WriteByte('$')
+	for i := len(steps) - 1; i >= 0; i-- {
+		if steps[i].isIndex {
+			sb.WriteString("[" + strconv.Itoa(steps[i].index) + "]")
+			continue
+		}
+		sb.WriteString("['")
+		for _, r := range steps[i].name {
+			switch r {
+			case '\b':
+				sb.WriteString(`\b`)
+			case '\f':
+				sb.WriteString(`\f`)
+			case '\n':
+				sb.WriteString(`\n`)
+			case '\r':
+				sb.WriteString(`\r`)
+			case '\t':
+				sb.WriteString(`\t`)
+			case '\'':
+				sb.WriteString(`\'`)
+			case '\\':
+				sb.WriteString(`\\`)
+			default:
+				if r < 0x20 {
+					fmt.Fprintf(&sb, `\u%04x`, r)
+				} else {
+					sb.WriteRune(r)
+				}
+			}
+		}
+		sb.WriteString("']")
+	}
+	return sb.String()
+}
+
+// member 返回对象成员对应的子节点
+func (node queryNode) member(m Member) queryNode {
+	return queryNode{m.V, &queryLocation{parent: node.loc, name: m.K}}
+}
+
+// element 返回数组第 i 个元素对应的子节点
+func (node queryNode) element(i int) queryNode {
+	return queryNode{node.value.A[i], &queryLocation{parent: node.loc, index: i, isIndex: true}}
+}
+
+// nodeValues 返回节点列表中的值
+func nodeValues(nodes []queryNode) []*Value {
+	values := make([]*Value, len(nodes))
+	for i, node := range nodes {
+		values[i] = node.value
+	}
+	return values
+}
+
+// childNodes 返回数组的元素或对象的成员值
+func childNodes(node queryNode) []queryNode {
+	v := node.value
+	var children []queryNode
+	switch v.Type {
+	case ARRAY:
+		children = make([]queryNode, len(v.A))
+		for i := range v.A {
+			children[i] = node.element(i)
+		}
+	case OBJECT:
+		children = make([]queryNode, len(v.O))
+		for i, member := range v.O {
+			children[i] = node.member(member)
+		}
+	}
+	return children
+}
@@ -0,0 +1,83 @@
+package leptjson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQueryWithPaths(t *testing.T) {
+	doc := &Value{}
+	Parse(doc, `{"store":{"book":[{"title":"A","price":8},{"title":"B","price":12}],"it's":{"a/b":1}}}`)
+
+	tests := []struct {
+		path     string
+		paths    string
+		pointers string
+	}{
+		{"$", "$", ""},
+		{"$.store.book[*].title", "$['store']['book'][0]['title'],$['store']['book'][1]['title']",
+			"/store/book/0/title,/store/book/1/title"},
+		{"$.store.book[-1]", "$['store']['book'][1]", "/store/book/1"},
+		{"$.store.book[?(@.price > 10)]", "$['store']['book'][1]", "/store/book/1"},
+		{"$..price", "$['store']['book'][0]['price'],$['store']['book'][1]['price']",
+			"/store/book/0/price,/store/book/1/price"},
+		{"$.store[\"it's\"]['a/b']", `$['store']['it\'s']['a/b']`, "/store/it's/a~1b"},
+		{"$.store.book[0:1].title", "$['store']['book'][0]['title']", "/store/book/0/title"},
+	}
+	for _, tt := range tests {
+		results, err := QueryWithPathsString(doc, tt.path)
+		if err != nil {
+			t.Fatalf("QueryWithPathsString(%s) error = %v", tt.path, err)
+		}
+		var paths, pointers []string
+		for _, r := range results {
+			paths = append(paths, r.Path)
+			pointers = append(pointers, r.Pointer())
+		}
+		if got := strings.Join(paths, ","); got != tt.paths {
+			t.Errorf("%s 的路径 = %s, 期望 %s", tt.path, got, tt.paths)
+		}
+		if got := strings.Join(pointers, ","); got != tt.pointers {
+			t.Errorf("%s 的指针 = %s, 期望 %s", tt.path, got, tt.pointers)
+		}
+	}
+}
+
+func TestQueryWithPathsFeedsPointer(t *testing.T) {
+	doc := &Value{}
+	Parse(doc, `{"items":[{"id":1,"done":true},{"id":2},{"id":3,"done":true}]}`)
+
+	jp, err := NewJSONPathWithOptions("$.items[?@.done == true]", JSONPathOptions{RFC9535: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := jp.QueryWithPaths(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Path != "$['items'][0]" || results[1].Path != "$['items'][2]" {
+		t.Fatalf("结果 = %+v", results)
+	}
+
+	// 从后往前删除，前面的索引不受影响
+	for i := len(results) - 1; i >= 0; i-- {
+		pointer, err := ParseJSONPointer(results[i].Pointer())
+		if err != POINTER_OK {
+			t.Fatal(err)
+		}
+		if err := pointer.Remove(doc); err != POINTER_OK {
+			t.Fatalf("删除 %s 失败: %v", results[i].Pointer(), err)
+		}
+	}
+	text, _ := Stringify(doc)
+	if text != `{"items":[{"id":2}]}` {
+		t.Errorf("删除后 = %s", text)
+	}
+}
+
+func TestNormalizedPathEscapes(t *testing.T) {
+	loc := &queryLocation{parent: &queryLocation{name: "a\x01\n'\\"}, index: 2, isIndex: true}
+	if got := normalizedPath(loc); got != `$['a\u0001\n\'\\'][2]` {
+		t.Errorf("normalizedPath = %s", got)
+	}
+}
@@ -0,0 +1,209 @@
+// json_path_results.go - JSONPath 查询结果的排序与分页
+package leptjson
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SortMode 表示排序键的比较方式
+type SortMode int
+
+// 排序方式常量
+const (
+	SORT_AUTO    SortMode = iota // 按类型比较：null < false < true < 数字 < 字符串 < 数组 < 对象
+	SORT_NUMERIC                 // 按数字比较，字符串会被解析为数字
+	SORT_STRING                  // 按字符串比较，数字会被格式化为字符串
+)
+
+// ParseSortMode 根据名称获取排序方式
+func ParseSortMode(name string) (SortMode, error) {
+	switch strings.ToLower(name) {
+	case "", "auto":
+		return SORT_AUTO, nil
+	case "numeric", "number":
+		return SORT_NUMERIC, nil
+	case "string":
+		return SORT_STRING, nil
+	default:
+		return SORT_AUTO, &JSONPathError{Path: name, Message: "不支持的排序方式"}
+	}
+}
+
+// QueryOptions 查询结果的排序和分页选项
+type QueryOptions struct {
+	SortBy     string   // 排序键表达式，以 $ 或 @ 开头，相对于每个结果求值；为空时按结果本身排序
+	Sort       bool     // 是否排序，SortBy 不为空时自动启用
+	Descending bool     // 是否降序
+	SortMode   SortMode // 排序键的比较方式
+	Offset     int      // 跳过的结果数量
+	Limit      int      // 返回的最大结果数量，<= 0 表示不限制
+}
+
+// QueryWithOptions 执行查询并对结果排序、分页，同时返回分页前的结果总数
+//
+// 排序是稳定的，排序键相同的结果保持查询顺序，因此分页结果是确定的。
+func (jp *JSONPath) QueryWithOptions(doc *Value, opts QueryOptions) ([]*Value, int, error) {
+	results, err := jp.Query(doc)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if opts.Sort || opts.SortBy != "" {
+		results, err = SortResults(results, opts.SortBy, opts.Descending, opts.SortMode)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	return PageResults(results, opts.Offset, opts.Limit), len(results), nil
+}
+
+// SortResults 按排序键对结果进行稳定排序，返回新的切片
+//
+// by 是相对于每个结果求值的 JSONPath 表达式（如 "@.price"），为空时按结果本身排序。
+// 没有排序键的结果无论升序还是降序都排在最后。
+func SortResults(results []*Value, by string, descending bool, mode SortMode) ([]*Value, error) {
+	keys, err := extractValues(results, by)
+	if err != nil {
+		return nil, err
+	}
+
+	type sortEntry struct {
+		value *Value
+		key   *Value
+	}
+	entries := make([]sortEntry, len(results))
+	for i, result := range results {
+		entries[i] = sortEntry{value: result, key: keys[i]}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		ki, kj := entries[i].key, entries[j].key
+		// 缺失的键排在最后
+		if ki == nil || kj == nil {
+			return ki != nil && kj == nil
+		}
+		c := compareSortKeys(ki, kj, mode)
+		if descending {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	sorted := make([]*Value, len(entries))
+	for i, entry := range entries {
+		sorted[i] = entry.value
+	}
+	return sorted, nil
+}
+
+// PageResults 返回跳过 offset 个结果后的最多 limit 个结果，limit <= 0 表示不限制
+func PageResults(results []*Value, offset, limit int) []*Value {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(results) {
+		return []*Value{}
+	}
+	end := len(results)
+	if limit > 0 && offset+limit < end {
+		end = offset + limit
+	}
+	return results[offset:end]
+}
+
+// compareSortKeys 比较两个排序键，返回 -1、0 或 1
+func compareSortKeys(a, b *Value, mode SortMode) int {
+	switch mode {
+	case SORT_NUMERIC:
+		na, okA := sortKeyNumber(a)
+		nb, okB := sortKeyNumber(b)
+		switch {
+		case okA && okB:
+			return compareFloats(na, nb)
+		case okA:
+			// 无法转换为数字的键排在后面
+			return -1
+		case okB:
+			return 1
+		default:
+			return strings.Compare(sortKeyString(a), sortKeyString(b))
+		}
+	case SORT_STRING:
+		return strings.Compare(sortKeyString(a), sortKeyString(b))
+	default:
+		ra, rb := sortTypeRank(a), sortTypeRank(b)
+		if ra != rb {
+			return compareFloats(float64(ra), float64(rb))
+		}
+		switch a.Type {
+		case NUMBER:
+			return compareFloats(a.N, b.N)
+		case STRING:
+			return strings.Compare(GetString(a), GetString(b))
+		case ARRAY, OBJECT:
+			return strings.Compare(sortKeyString(a), sortKeyString(b))
+		default:
+			return 0
+		}
+	}
+}
+
+// sortTypeRank 返回类型在 SORT_AUTO 模式下的顺序
+func sortTypeRank(v *Value) int {
+	switch v.Type {
+	case NULL:
+		return 0
+	case FALSE:
+		return 1
+	case TRUE:
+		return 2
+	case NUMBER:
+		return 3
+	case STRING:
+		return 4
+	case ARRAY:
+		return 5
+	default:
+		return 6
+	}
+}
+
+// sortKeyNumber 将排序键转换为数字
+func sortKeyNumber(v *Value) (float64, bool) {
+	switch v.Type {
+	case NUMBER:
+		return v.N, true
+	case STRING:
+		n, err := strconv.ParseFloat(strings.TrimSpace(GetString(v)), 64)
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// sortKeyString 将排序键转换为字符串
+func sortKeyString(v *Value) string {
+	switch v.Type {
+	case STRING:
+		return GetString(v)
+	case NUMBER:
+		return strconv.FormatFloat(v.N, 'g', -1, 64)
+	default:
+		s, _ := Stringify(v)
+		return s
+	}
+}
+
+// compareFloats 比较两个浮点数，返回 -1、0 或 1
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
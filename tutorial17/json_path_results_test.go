@@ -0,0 +1,100 @@
+package leptjson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQueryWithOptions(t *testing.T) {
+	doc := &Value{}
+	if err := Parse(doc, `{"items":[
+		{"name":"c","price":10,"code":"10"},
+		{"name":"a","price":2.5,"code":"9"},
+		{"name":"d"},
+		{"name":"b","price":10,"code":"100"}
+	]}`); err != PARSE_OK {
+		t.Fatalf("解析失败: %s", err.Error())
+	}
+
+	tests := []struct {
+		name     string
+		opts     QueryOptions
+		expected string // 结果中 name 的顺序
+		total    int
+	}{
+		{"不排序", QueryOptions{}, "c,a,d,b", 4},
+		{"按价格升序，缺失的排最后，相同保持原顺序", QueryOptions{SortBy: "@.price"}, "a,c,b,d", 4},
+		{"按价格降序", QueryOptions{SortBy: "$.price", Descending: true}, "c,b,a,d", 4},
+		{"按字符串比较", QueryOptions{SortBy: "@.code", SortMode: SORT_STRING}, "c,b,a,d", 4},
+		{"按数字比较", QueryOptions{SortBy: "@.code", SortMode: SORT_NUMERIC}, "a,c,b,d", 4},
+		{"分页", QueryOptions{SortBy: "@.name", Offset: 1, Limit: 2}, "b,c", 4},
+		{"偏移量超出范围", QueryOptions{Offset: 10}, "", 4},
+		{"只限制数量", QueryOptions{Limit: 3}, "c,a,d", 4},
+	}
+
+	jp, err := NewJSONPath("$.items[*]")
+	if err != nil {
+		t.Fatalf("解析JSONPath失败: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, total, err := jp.QueryWithOptions(doc, tt.opts)
+			if err != nil {
+				t.Fatalf("QueryWithOptions 失败: %v", err)
+			}
+			if total != tt.total {
+				t.Errorf("总数 = %d, 期望 %d", total, tt.total)
+			}
+
+			names := make([]string, len(results))
+			for i, r := range results {
+				names[i] = GetObjectValueByKey(r, "name").S
+			}
+			if got := strings.Join(names, ","); got != tt.expected {
+				t.Errorf("结果顺序 = %s, 期望 %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSortResultsByValue(t *testing.T) {
+	doc := &Value{}
+	Parse(doc, `["b",3,null,true,"a",1,false,[1],{"k":1}]`)
+
+	results, _ := QueryString(doc, "$[*]")
+	sorted, err := SortResults(results, "", false, SORT_AUTO)
+	if err != nil {
+		t.Fatalf("SortResults 失败: %v", err)
+	}
+
+	var parts []string
+	for _, v := range sorted {
+		s, _ := Stringify(v)
+		parts = append(parts, s)
+	}
+	expected := `null,false,true,1,3,"a","b",[1],{"k":1}`
+	if got := strings.Join(parts, ","); got != expected {
+		t.Errorf("排序结果 = %s, 期望 %s", got, expected)
+	}
+
+	// 原切片不应被修改
+	if results[0].S != "b" {
+		t.Error("SortResults 修改了原始切片")
+	}
+
+	if _, err := SortResults(results, "price", false, SORT_AUTO); err == nil {
+		t.Error("无效的排序表达式应当返回错误")
+	}
+}
+
+func TestParseSortMode(t *testing.T) {
+	for name, want := range map[string]SortMode{"": SORT_AUTO, "Numeric": SORT_NUMERIC, "string": SORT_STRING} {
+		if got, err := ParseSortMode(name); err != nil || got != want {
+			t.Errorf("ParseSortMode(%q) = %v, %v", name, got, err)
+		}
+	}
+	if _, err := ParseSortMode("random"); err == nil {
+		t.Error("不支持的排序方式应当返回错误")
+	}
+}
@@ -0,0 +1,959 @@
+// json_path_rfc9535.go - 严格遵循 RFC 9535 的 JSON Path 解析和求值
+package leptjson
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// JSONPathOptions 控制 JSON Path 表达式的解析方式
+type JSONPathOptions struct {
+	// RFC9535 为 true 时严格按 RFC 9535 解析和求值：
+	//   - 支持并集 [a,b]、负索引和完整的切片语义
+	//   - 只接受标准语法，=~、in、nin、正则字面量和 keys() 等扩展是语法错误
+	//   - 比较运算中的路径必须是单值查询，函数参数和返回值做类型检查
+	//   - match() 和 search() 使用 I-Regexp（RFC 9485），无效的正则表达式结果为 false
+	RFC9535 bool
+}
+
+// NewJSONPathWithOptions 按选项解析 JSON Path 表达式
+func NewJSONPathWithOptions(path string, options JSONPathOptions) (*JSONPath, error) {
+	if !options.RFC9535 {
+		return NewJSONPath(path)
+	}
+	q, err := parseRFC9535(path)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONPath{Path: path, rfc: q}, nil
+}
+
+// rfcMaxInt 是 I-JSON 能精确表示的最大整数，索引和切片参数不能超出 ±rfcMaxInt
+const rfcMaxInt = 1<<53 - 1
+
+// rfcQuery 是编译后的 RFC 9535 查询
+type rfcQuery struct {
+	segments []rfcSegment
+}
+
+// rfcSegment 是查询中的一段：子段 [...] 或后代段 ..[...]
+type rfcSegment struct {
+	descendant bool
+	selectors  []rfcSelector
+}
+
+// rfcSelectorKind 是选择器的类型
+type rfcSelectorKind int
+
+const (
+	rfcName rfcSelectorKind = iota
+	rfcWildcard
+	rfcIndex
+	rfcSlice
+	rfcFilter
+)
+
+// rfcSelector 是段中的一个选择器
+type rfcSelector struct {
+	kind             rfcSelectorKind
+	name             string     // 名称选择器的成员名
+	index            int        // 索引选择器的索引
+	start, end, step int        // 切片参数
+	hasStart, hasEnd bool       // 切片是否给出了开始和结束
+	filter           filterExpr // 过滤选择器的表达式
+}
+
+// singular 判断查询是否为单值查询：只包含名称或索引选择器的子段
+func (q *rfcQuery) singular() bool {
+	for _, seg := range q.segments {
+		if seg.descendant || len(seg.selectors) != 1 {
+			return false
+		}
+		if kind := seg.selectors[0].kind; kind != rfcName && kind != rfcIndex {
+			return false
+		}
+	}
+	return true
+}
+
+// evaluate 从 start 开始依次应用每一段，root 是 $ 所指的文档根节点
+//
+// budget 不为 nil 时每访问一个节点检查一次。
+func (q *rfcQuery) evaluate(start, root *Value, budget *evalBudget) ([]queryNode, error) {
+	nodes := []queryNode{{value: start}}
+	for i := range q.segments {
+		seg := &q.segments[i]
+		var next []queryNode
+		for _, node := range nodes {
+			var err error
+			if seg.descendant {
+				next, err = seg.selectDescendants(node, root, next, budget)
+			} else {
+				next, err = seg.selectChildren(node, root, next, budget)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		nodes = next
+	}
+	return nodes, nil
+}
+
+// selectChildren 依次对节点应用段中的每个选择器
+func (seg *rfcSegment) selectChildren(node queryNode, root *Value, out []queryNode, budget *evalBudget) ([]queryNode, error) {
+	if err := budget.step(); err != nil {
+		return nil, err
+	}
+	for i := range seg.selectors {
+		var err error
+		if out, err = seg.selectors[i].apply(node, root, out, budget); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// selectDescendants 按文档顺序访问节点及其所有后代（先访问节点本身，再依次访问子节点），
+// 对每个节点应用段中的选择器
+func (seg *rfcSegment) selectDescendants(node queryNode, root *Value, out []queryNode, budget *evalBudget) ([]queryNode, error) {
+	out, err := seg.selectChildren(node, root, out, budget)
+	if err != nil {
+		return nil, err
+	}
+	for _, child := range childNodes(node) {
+		if out, err = seg.selectDescendants(child, root, out, budget); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// apply 对节点应用选择器，将选中的节点追加到 out
+func (sel *rfcSelector) apply(node queryNode, root *Value, out []queryNode, budget *evalBudget) ([]queryNode, error) {
+	v := node.value
+	switch sel.kind {
+	case rfcName:
+		if v.Type == OBJECT {
+			for _, member := range v.O {
+				if member.K == sel.name {
+					return append(out, node.member(member)), nil
+				}
+			}
+		}
+
+	case rfcWildcard:
+		out = append(out, childNodes(node)...)
+
+	case rfcIndex:
+		if v.Type == ARRAY {
+			i := sel.index
+			if i < 0 {
+				i += len(v.A)
+			}
+			if i >= 0 && i < len(v.A) {
+				out = append(out, node.element(i))
+			}
+		}
+
+	case rfcSlice:
+		if v.Type == ARRAY {
+			for _, i := range sel.sliceIndexes(len(v.A)) {
+				out = append(out, node.element(i))
+			}
+		}
+
+	case rfcFilter:
+		for _, child := range childNodes(node) {
+			if err := budget.step(); err != nil {
+				return nil, err
+			}
+			ok, err := sel.filter.test(child.value, root)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				out = append(out, child)
+			}
+		}
+	}
+	return out, nil
+}
+
+// sliceIndexes 按 RFC 9535 第2.3.4.2节计算切片在长度为 n 的数组中选中的索引
+func (sel *rfcSelector) sliceIndexes(n int) []int {
+	step := sel.step
+	if step == 0 {
+		return nil
+	}
+	normalize := func(i int) int {
+		if i < 0 {
+			return n + i
+		}
+		return i
+	}
+	clamp := func(i, lo, hi int) int {
+		if i < lo {
+			return lo
+		}
+		if i > hi {
+			return hi
+		}
+		return i
+	}
+
+	var indexes []int
+	if step > 0 {
+		lower, upper := 0, n
+		if sel.hasStart {
+			lower = clamp(normalize(sel.start), 0, n)
+		}
+		if sel.hasEnd {
+			upper = clamp(normalize(sel.end), 0, n)
+		}
+		for i := lower; i < upper; i += step {
+			indexes = append(indexes, i)
+		}
+	} else {
+		upper, lower := n-1, -1
+		if sel.hasStart {
+			upper = clamp(normalize(sel.start), -1, n-1)
+		}
+		if sel.hasEnd {
+			lower = clamp(normalize(sel.end), -1, n-1)
+		}
+		for i := upper; lower < i; i += step {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// rfcType 是 RFC 9535 函数扩展的类型系统
+type rfcType int
+
+const (
+	rfcValueType   rfcType = iota // JSON 值或"不存在"
+	rfcLogicalType                // 逻辑真假
+	rfcNodesType                  // 节点列表
+)
+
+// rfcFunction 是 RFC 9535 定义的函数扩展，求值复用 filterFunction
+type rfcFunction struct {
+	params []rfcType
+	result rfcType
+	fn     filterFunction
+}
+
+// rfcFunctions 是 RFC 9535 第2.4节定义的标准函数
+var rfcFunctions = map[string]rfcFunction{
+	"length": {[]rfcType{rfcValueType}, rfcValueType, filterFunctions["length"]},
+	"count":  {[]rfcType{rfcNodesType}, rfcValueType, filterFunctions["count"]},
+	"value":  {[]rfcType{rfcNodesType}, rfcValueType, filterFunctions["value"]},
+	"match": {[]rfcType{rfcValueType, rfcValueType}, rfcLogicalType, filterFunction{2, func(args [][]*Value) (*Value, error) {
+		return iregexpFunction(args, true), nil
+	}}},
+	"search": {[]rfcType{rfcValueType, rfcValueType}, rfcLogicalType, filterFunction{2, func(args [][]*Value) (*Value, error) {
+		return iregexpFunction(args, false), nil
+	}}},
+}
+
+// iregexpFunction 实现 RFC 9535 的 match() 和 search()，
+// 参数不是字符串或正则表达式无效时结果为 false
+func iregexpFunction(args [][]*Value, full bool) *Value {
+	s, pattern := singleArg(args[0]), singleArg(args[1])
+	if s == nil || pattern == nil || s.Type != STRING || pattern.Type != STRING {
+		return &Value{Type: FALSE}
+	}
+	expr := iregexpToGo(GetString(pattern))
+	if full {
+		expr = "^(?:" + expr + ")$"
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil || !re.MatchString(GetString(s)) {
+		return &Value{Type: FALSE}
+	}
+	return &Value{Type: TRUE}
+}
+
+// iregexpToGo 将 I-Regexp 转换为 Go 的正则表达式
+//
+// 两者的语法基本相同，区别在于 I-Regexp 中字符类之外的 "." 不匹配 \n 和 \r。
+func iregexpToGo(pattern string) string {
+	var sb strings.Builder
+	inClass := false
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '\\' && i+1 < len(pattern):
+			sb.WriteByte(c)
+			i++
+			c = pattern[i]
+		case c == '[':
+			inClass = true
+		case c == ']':
+			inClass = false
+		case c == '.' && !inClass:
+			sb.WriteString(`[^\n\r]`)
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+// rfcArgKind 是过滤表达式中操作数的语法类别，用于类型检查
+type rfcArgKind int
+
+const (
+	rfcArgLiteral rfcArgKind = iota
+	rfcArgQuery
+	rfcArgFunction
+)
+
+// rfcArg 是解析后的操作数及其类型信息
+type rfcArg struct {
+	operand  filterOperand
+	kind     rfcArgKind
+	singular bool    // 查询是否为单值查询
+	result   rfcType // 函数的返回类型
+}
+
+// isValue 判断操作数能否用作 ValueType：字面量、单值查询或返回 ValueType 的函数
+func (a rfcArg) isValue() bool {
+	switch a.kind {
+	case rfcArgLiteral:
+		return true
+	case rfcArgQuery:
+		return a.singular
+	}
+	return a.result == rfcValueType
+}
+
+// isNodes 判断操作数能否用作 NodesType：查询或返回 NodesType 的函数
+func (a rfcArg) isNodes() bool {
+	return a.kind == rfcArgQuery || (a.kind == rfcArgFunction && a.result == rfcNodesType)
+}
+
+// rfcParser 是 RFC 9535 语法的递归下降分析器
+type rfcParser struct {
+	path string
+	pos  int
+}
+
+// parseRFC9535 按 RFC 9535 的语法解析完整的查询，不允许前后有空白
+func parseRFC9535(path string) (*rfcQuery, error) {
+	p := &rfcParser{path: path}
+	if len(path) == 0 {
+		return nil, p.errorf("JSON Path 表达式不能为空")
+	}
+	if p.peek() != '$' {
+		return nil, p.errorf("JSON Path 必须以 $ 开始")
+	}
+	p.pos++
+	q, err := p.parseSegments()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.path) {
+		return nil, p.errorf("多余的内容 '%s'", p.path[p.pos:])
+	}
+	return q, nil
+}
+
+func (p *rfcParser) errorf(format string, args ...interface{}) error {
+	return &JSONPathError{Path: p.path, Message: fmt.Sprintf(format, args...), Index: p.pos}
+}
+
+// peek 返回当前字节，到达末尾时返回 0
+func (p *rfcParser) peek() byte {
+	if p.pos < len(p.path) {
+		return p.path[p.pos]
+	}
+	return 0
+}
+
+// skipBlank 跳过 RFC 9535 允许的空白：空格、制表符、换行和回车
+func (p *rfcParser) skipBlank() {
+	for p.pos < len(p.path) {
+		switch p.path[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// parseSegments 解析 $ 或 @ 之后的所有段，段之前可以有空白
+func (p *rfcParser) parseSegments() (*rfcQuery, error) {
+	q := &rfcQuery{}
+	for {
+		save := p.pos
+		p.skipBlank()
+		var seg rfcSegment
+		var err error
+		switch {
+		case strings.HasPrefix(p.path[p.pos:], ".."):
+			p.pos += 2
+			seg, err = p.parseShorthand(true)
+		case p.peek() == '.':
+			p.pos++
+			seg, err = p.parseShorthand(false)
+		case p.peek() == '[':
+			seg.selectors, err = p.parseBracketed()
+		default:
+			p.pos = save
+			return q, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		q.segments = append(q.segments, seg)
+	}
+}
+
+// parseShorthand 解析 . 或 .. 之后的成员名、通配符，后代段还可以跟方括号
+func (p *rfcParser) parseShorthand(descendant bool) (rfcSegment, error) {
+	seg := rfcSegment{descendant: descendant}
+	switch {
+	case p.peek() == '*':
+		p.pos++
+		seg.selectors = []rfcSelector{{kind: rfcWildcard}}
+		return seg, nil
+	case descendant && p.peek() == '[':
+		selectors, err := p.parseBracketed()
+		seg.selectors = selectors
+		return seg, err
+	}
+
+	start := p.pos
+	for p.pos < len(p.path) {
+		r, size := utf8.DecodeRuneInString(p.path[p.pos:])
+		if !isNameShorthandRune(r, p.pos > start) {
+			break
+		}
+		p.pos += size
+	}
+	if p.pos == start {
+		if descendant {
+			return seg, p.errorf(".. 后面必须跟成员名、通配符或方括号")
+		}
+		return seg, p.errorf(". 后面必须跟成员名或通配符")
+	}
+	seg.selectors = []rfcSelector{{kind: rfcName, name: p.path[start:p.pos]}}
+	return seg, nil
+}
+
+// isNameShorthandRune 判断 r 能否出现在 .name 形式的成员名中，数字不能作为首字符
+func isNameShorthandRune(r rune, notFirst bool) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_':
+		return true
+	case r >= '0' && r <= '9':
+		return notFirst
+	}
+	return r >= 0x80 && r != utf8.RuneError
+}
+
+// parseBracketed 解析 [selector, selector, ...]
+func (p *rfcParser) parseBracketed() ([]rfcSelector, error) {
+	p.pos++ // 跳过 [
+	var selectors []rfcSelector
+	for {
+		p.skipBlank()
+		sel, err := p.parseSelector()
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, sel)
+		p.skipBlank()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return selectors, nil
+		case 0:
+			return nil, p.errorf("未闭合的方括号")
+		default:
+			return nil, p.errorf("期望 ',' 或 ']'")
+		}
+	}
+}
+
+// parseSelector 解析方括号中的一个选择器
+func (p *rfcParser) parseSelector() (rfcSelector, error) {
+	c := p.peek()
+	switch {
+	case c == '\'' || c == '"':
+		name, err := p.parseStringLiteral()
+		return rfcSelector{kind: rfcName, name: name}, err
+	case c == '*':
+		p.pos++
+		return rfcSelector{kind: rfcWildcard}, nil
+	case c == '?':
+		p.pos++
+		p.skipBlank()
+		expr, err := p.parseLogicalOr()
+		return rfcSelector{kind: rfcFilter, filter: expr}, err
+	case c == '-' || c == ':' || isDigit(c):
+		return p.parseIndexOrSlice()
+	}
+	return rfcSelector{}, p.errorf("无效的选择器")
+}
+
+// parseIndexOrSlice 解析索引 [i] 或切片 [start:end:step]
+func (p *rfcParser) parseIndexOrSlice() (rfcSelector, error) {
+	sel := rfcSelector{kind: rfcSlice, step: 1}
+	var err error
+	if p.peek() != ':' {
+		if sel.start, err = p.parseInt(); err != nil {
+			return sel, err
+		}
+		sel.hasStart = true
+		p.skipBlank()
+		if p.peek() != ':' {
+			return rfcSelector{kind: rfcIndex, index: sel.start}, nil
+		}
+	}
+	p.pos++ // 跳过第一个 :
+	p.skipBlank()
+	if isIntStart(p.peek()) {
+		if sel.end, err = p.parseInt(); err != nil {
+			return sel, err
+		}
+		sel.hasEnd = true
+		p.skipBlank()
+	}
+	if p.peek() == ':' {
+		p.pos++
+		p.skipBlank()
+		if isIntStart(p.peek()) {
+			if sel.step, err = p.parseInt(); err != nil {
+				return sel, err
+			}
+		}
+	}
+	return sel, nil
+}
+
+func isIntStart(c byte) bool {
+	return c == '-' || isDigit(c)
+}
+
+// parseInt 解析整数：不允许前导零和 -0，绝对值不能超过 2^53-1
+func (p *rfcParser) parseInt() (int, error) {
+	start := p.pos
+	if p.peek() == '-' {
+		p.pos++
+	}
+	if !isDigit(p.peek()) {
+		return 0, p.errorf("期望整数")
+	}
+	if p.peek() == '0' {
+		p.pos++
+		if p.pos-start == 2 {
+			return 0, &JSONPathError{Path: p.path, Message: "整数不能是 -0", Index: start}
+		}
+		if isDigit(p.peek()) {
+			return 0, &JSONPathError{Path: p.path, Message: "整数不能有前导零", Index: start}
+		}
+		return 0, nil
+	}
+	for isDigit(p.peek()) {
+		p.pos++
+	}
+	n, err := strconv.ParseInt(p.path[start:p.pos], 10, 64)
+	if err != nil || n > rfcMaxInt || n < -rfcMaxInt {
+		return 0, &JSONPathError{Path: p.path, Message: fmt.Sprintf("整数 %s 超出范围", p.path[start:p.pos]), Index: start}
+	}
+	return int(n), nil
+}
+
+// parseStringLiteral 解析单引号或双引号字符串，只允许 RFC 9535 定义的转义
+func (p *rfcParser) parseStringLiteral() (string, error) {
+	quote := p.path[p.pos]
+	start := p.pos
+	p.pos++
+	var sb strings.Builder
+	for {
+		if p.pos >= len(p.path) {
+			return "", &JSONPathError{Path: p.path, Message: "未闭合的字符串", Index: start}
+		}
+		c := p.path[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return sb.String(), nil
+		case c == '\\':
+			if err := p.parseEscape(&sb, quote); err != nil {
+				return "", err
+			}
+		case c < 0x20:
+			return "", p.errorf("字符串中不能包含未转义的控制字符")
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+// parseEscape 解析字符串中的一个转义序列，\u 转义的代理对必须成对出现
+func (p *rfcParser) parseEscape(sb *strings.Builder, quote byte) error {
+	p.pos++ // 跳过 \
+	c := p.peek()
+	p.pos++
+	switch c {
+	case quote, '\\', '/':
+		sb.WriteByte(c)
+	case 'b':
+		sb.WriteByte('\b')
+	case 'f':
+		sb.WriteByte('\f')
+	case 'n':
+		sb.WriteByte('\n')
+	case 'r':
+		sb.WriteByte('\r')
+	case 't':
+		sb.WriteByte('\t')
+	case 'u':
+		r, err := p.parseHex4()
+		if err != nil {
+			return err
+		}
+		switch {
+		case r >= 0xD800 && r <= 0xDBFF:
+			if !strings.HasPrefix(p.path[p.pos:], `\u`) {
+				return p.errorf("缺少低代理项")
+			}
+			p.pos += 2
+			low, err := p.parseHex4()
+			if err != nil {
+				return err
+			}
+			if low < 0xDC00 || low > 0xDFFF {
+				return p.errorf("无效的低代理项")
+			}
+			r = 0x10000 + (r-0xD800)<<10 + (low - 0xDC00)
+		case r >= 0xDC00 && r <= 0xDFFF:
+			return p.errorf("单独的低代理项")
+		}
+		sb.WriteRune(r)
+	default:
+		p.pos--
+		return p.errorf("无效的转义字符")
+	}
+	return nil
+}
+
+func (p *rfcParser) parseHex4() (rune, error) {
+	if p.pos+4 > len(p.path) {
+		return 0, p.errorf("无效的 \\u 转义")
+	}
+	n, err := strconv.ParseUint(p.path[p.pos:p.pos+4], 16, 32)
+	if err != nil {
+		return 0, p.errorf("无效的 \\u 转义")
+	}
+	p.pos += 4
+	return rune(n), nil
+}
+
+// accept 跳过空白后在下一个内容是 s 时消耗它，否则回到原来的位置
+func (p *rfcParser) accept(s string) bool {
+	save := p.pos
+	p.skipBlank()
+	if strings.HasPrefix(p.path[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	p.pos = save
+	return false
+}
+
+func (p *rfcParser) parseLogicalOr() (filterExpr, error) {
+	left, err := p.parseLogicalAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		p.skipBlank()
+		right, err := p.parseLogicalAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &filterLogical{or: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *rfcParser) parseLogicalAnd() (filterExpr, error) {
+	left, err := p.parseBasicExpr()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		p.skipBlank()
+		right, err := p.parseBasicExpr()
+		if err != nil {
+			return nil, err
+		}
+		left = &filterLogical{left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseBasicExpr 解析括号表达式、比较表达式或测试表达式
+//
+// "!" 只能用于括号表达式和测试表达式，因此 !@.a == 1 是语法错误。
+func (p *rfcParser) parseBasicExpr() (filterExpr, error) {
+	if p.peek() == '!' {
+		p.pos++
+		p.skipBlank()
+		var expr filterExpr
+		var err error
+		if p.peek() == '(' {
+			expr, err = p.parseParenExpr()
+		} else {
+			expr, err = p.parseTestExpr()
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &filterNot{expr: expr}, nil
+	}
+	if p.peek() == '(' {
+		return p.parseParenExpr()
+	}
+
+	start := p.pos
+	left, err := p.parseComparable()
+	if err != nil {
+		return nil, err
+	}
+	op := p.parseComparisonOp()
+	if op == "" {
+		return p.testExpr(left, start)
+	}
+	if !left.isValue() {
+		return nil, &JSONPathError{Path: p.path, Message: "比较运算的操作数必须是字面量、单值查询或返回值的函数", Index: start}
+	}
+	p.skipBlank()
+	rightStart := p.pos
+	right, err := p.parseComparable()
+	if err != nil {
+		return nil, err
+	}
+	if !right.isValue() {
+		return nil, &JSONPathError{Path: p.path, Message: "比较运算的操作数必须是字面量、单值查询或返回值的函数", Index: rightStart}
+	}
+	return &filterComparison{op: op, left: left.operand, right: right.operand}, nil
+}
+
+// parseComparisonOp 跳过空白后解析比较运算符，没有运算符时返回空字符串且不移动位置
+func (p *rfcParser) parseComparisonOp() string {
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.accept(op) {
+			return op
+		}
+	}
+	return ""
+}
+
+func (p *rfcParser) parseParenExpr() (filterExpr, error) {
+	p.pos++ // 跳过 (
+	p.skipBlank()
+	expr, err := p.parseLogicalOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.accept(")") {
+		return nil, p.errorf("期望 ')'")
+	}
+	return expr, nil
+}
+
+// parseTestExpr 解析测试表达式：查询（测试是否存在）或返回 LogicalType、NodesType 的函数
+func (p *rfcParser) parseTestExpr() (filterExpr, error) {
+	start := p.pos
+	arg, err := p.parseComparable()
+	if err != nil {
+		return nil, err
+	}
+	return p.testExpr(arg, start)
+}
+
+// testExpr 将 start 处解析得到的操作数作为测试表达式
+func (p *rfcParser) testExpr(arg rfcArg, start int) (filterExpr, error) {
+	switch {
+	case arg.kind == rfcArgQuery:
+		return &filterTruthy{operand: arg.operand, isPath: true}, nil
+	case arg.kind == rfcArgFunction && arg.result == rfcLogicalType:
+		return &filterTruthy{operand: arg.operand}, nil
+	case arg.kind == rfcArgFunction && arg.result == rfcNodesType:
+		return &filterTruthy{operand: arg.operand, isPath: true}, nil
+	case arg.kind == rfcArgFunction:
+		return nil, &JSONPathError{Path: p.path, Message: "函数的返回值必须参与比较", Index: start}
+	}
+	return nil, &JSONPathError{Path: p.path, Message: "字面量必须参与比较", Index: start}
+}
+
+// parseComparable 解析字面量、查询或函数调用
+func (p *rfcParser) parseComparable() (rfcArg, error) {
+	c := p.peek()
+	switch {
+	case c == '@' || c == '$':
+		return p.parseFilterQuery()
+	case c == '\'' || c == '"':
+		s, err := p.parseStringLiteral()
+		if err != nil {
+			return rfcArg{}, err
+		}
+		v := &Value{}
+		SetString(v, s)
+		return rfcArg{operand: filterLiteral{v}}, nil
+	case isIntStart(c):
+		return p.parseNumberLiteral()
+	case c >= 'a' && c <= 'z':
+		start := p.pos
+		for c := p.peek(); (c >= 'a' && c <= 'z') || isDigit(c) || c == '_'; c = p.peek() {
+			p.pos++
+		}
+		name := p.path[start:p.pos]
+		if p.peek() == '(' {
+			return p.parseFunctionCall(name, start)
+		}
+		switch name {
+		case "true":
+			return rfcArg{operand: filterLiteral{&Value{Type: TRUE}}}, nil
+		case "false":
+			return rfcArg{operand: filterLiteral{&Value{Type: FALSE}}}, nil
+		case "null":
+			return rfcArg{operand: filterLiteral{&Value{Type: NULL}}}, nil
+		}
+		return rfcArg{}, &JSONPathError{Path: p.path, Message: fmt.Sprintf("未知的标识符 '%s'", name), Index: start}
+	case c == 0:
+		return rfcArg{}, p.errorf("表达式不完整")
+	}
+	return rfcArg{}, p.errorf("期望字面量、查询或函数调用")
+}
+
+// parseFilterQuery 解析过滤表达式中 @ 或 $ 开头的查询
+func (p *rfcParser) parseFilterQuery() (rfcArg, error) {
+	start := p.pos
+	relative := p.peek() == '@'
+	p.pos++
+	q, err := p.parseSegments()
+	if err != nil {
+		return rfcArg{}, err
+	}
+	jp := &JSONPath{Path: p.path[start:p.pos], rfc: q}
+	return rfcArg{
+		operand:  &filterPathOperand{path: jp, relative: relative},
+		kind:     rfcArgQuery,
+		singular: q.singular(),
+	}, nil
+}
+
+// parseNumberLiteral 解析 JSON 数字，允许 -0，不允许前导零和省略整数或小数部分的数字
+func (p *rfcParser) parseNumberLiteral() (rfcArg, error) {
+	start := p.pos
+	if p.peek() == '-' {
+		p.pos++
+	}
+	switch {
+	case p.peek() == '0':
+		p.pos++
+	case isDigit(p.peek()):
+		for isDigit(p.peek()) {
+			p.pos++
+		}
+	default:
+		return rfcArg{}, p.errorf("无效的数字")
+	}
+	if p.peek() == '.' {
+		p.pos++
+		if !isDigit(p.peek()) {
+			return rfcArg{}, p.errorf("小数点后必须有数字")
+		}
+		for isDigit(p.peek()) {
+			p.pos++
+		}
+	}
+	if c := p.peek(); c == 'e' || c == 'E' {
+		p.pos++
+		if c := p.peek(); c == '+' || c == '-' {
+			p.pos++
+		}
+		if !isDigit(p.peek()) {
+			return rfcArg{}, p.errorf("指数部分必须有数字")
+		}
+		for isDigit(p.peek()) {
+			p.pos++
+		}
+	}
+	n, err := strconv.ParseFloat(p.path[start:p.pos], 64)
+	if err != nil {
+		return rfcArg{}, &JSONPathError{Path: p.path, Message: fmt.Sprintf("无效的数字 '%s'", p.path[start:p.pos]), Index: start}
+	}
+	return rfcArg{operand: filterLiteral{newFilterNumber(n)}}, nil
+}
+
+// parseFunctionCall 解析函数调用并按函数的参数类型检查每个参数
+func (p *rfcParser) parseFunctionCall(name string, start int) (rfcArg, error) {
+	fn, ok := rfcFunctions[name]
+	if !ok {
+		return rfcArg{}, &JSONPathError{Path: p.path, Message: fmt.Sprintf("未知的函数 '%s'", name), Index: start}
+	}
+	p.pos++ // 跳过 (
+	var args []filterOperand
+	p.skipBlank()
+	if p.peek() != ')' {
+		for {
+			argStart := p.pos
+			arg, err := p.parseComparable()
+			if err != nil {
+				return rfcArg{}, err
+			}
+			if len(args) < len(fn.params) {
+				if err := checkFunctionArg(fn.params[len(args)], arg); err != nil {
+					return rfcArg{}, &JSONPathError{Path: p.path, Message: fmt.Sprintf("函数 %s() 的第%d个参数%s", name, len(args)+1, err), Index: argStart}
+				}
+			}
+			args = append(args, arg.operand)
+			if !p.accept(",") {
+				break
+			}
+			p.skipBlank()
+		}
+	}
+	if !p.accept(")") {
+		return rfcArg{}, p.errorf("期望 ')'")
+	}
+	if len(args) != len(fn.params) {
+		return rfcArg{}, &JSONPathError{Path: p.path, Message: fmt.Sprintf("函数 %s() 需要%d个参数，实际为%d个", name, len(fn.params), len(args)), Index: start}
+	}
+	return rfcArg{
+		operand: &filterCall{name: name, fn: fn.fn, args: args},
+		kind:    rfcArgFunction,
+		result:  fn.result,
+	}, nil
+}
+
+// checkFunctionArg 检查参数能否用作指定类型的参数
+func checkFunctionArg(param rfcType, arg rfcArg) error {
+	switch param {
+	case rfcValueType:
+		if !arg.isValue() {
+			return fmt.Errorf("必须是字面量、单值查询或返回值的函数")
+		}
+	case rfcNodesType:
+		if !arg.isNodes() {
+			return fmt.Errorf("必须是查询")
+		}
+	}
+	return nil
+}
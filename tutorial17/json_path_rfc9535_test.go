@@ -0,0 +1,191 @@
+// json_path_rfc9535_test.go - RFC 9535 模式的回归测试，可选运行官方一致性测试套件
+package leptjson
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+// complianceSuite 是 jsonpath-compliance-test-suite 的 cts.json 文件格式
+type complianceSuite struct {
+	Tests []complianceCase `json:"tests"`
+}
+
+type complianceCase struct {
+	Name            string            `json:"name"`
+	Selector        string            `json:"selector"`
+	Document        json.RawMessage   `json:"document"`
+	Result          json.RawMessage   `json:"result"`
+	Results         []json.RawMessage `json:"results"`
+	ResultPaths     []string          `json:"result_paths"`
+	ResultsPaths    [][]string        `json:"results_paths"`
+	InvalidSelector bool              `json:"invalid_selector"`
+}
+
+// TestRFC9535Compliance 运行 cts.json 格式的测试用例
+//
+// 默认使用 testdata/rfc9535_cases.json，其中是按 RFC 9535 手写的回归用例，只借用了
+// cts.json 的文件格式，并不是官方测试套件的内容，通过它们不能说明符合 RFC 9535。
+// 设置环境变量 JSONPATH_CTS 为官方 cts.json 的路径时运行完整的官方测试套件，如
+//
+//	JSONPATH_CTS=jsonpath-compliance-test-suite/cts.json go test -run TestRFC9535Compliance
+func TestRFC9535Compliance(t *testing.T) {
+	file := "testdata/rfc9535_cases.json"
+	if env := os.Getenv("JSONPATH_CTS"); env != "" {
+		file = env
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("读取测试套件失败: %v", err)
+	}
+	var suite complianceSuite
+	if err := json.Unmarshal(data, &suite); err != nil {
+		t.Fatalf("解析测试套件失败: %v", err)
+	}
+	if len(suite.Tests) == 0 {
+		t.Fatalf("测试套件 %s 中没有用例", file)
+	}
+
+	for _, tc := range suite.Tests {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			jp, err := NewJSONPathWithOptions(tc.Selector, JSONPathOptions{RFC9535: true})
+			if tc.InvalidSelector {
+				if err == nil {
+					t.Fatalf("%q 应该是无效的选择器", tc.Selector)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%q 解析失败: %v", tc.Selector, err)
+			}
+
+			doc := parseComplianceJSON(t, tc.Document)
+			nodes, err := jp.rfc.evaluate(doc, doc, nil)
+			if err != nil {
+				t.Fatalf("%q 求值失败: %v", tc.Selector, err)
+			}
+
+			// results 列出了对象成员顺序不同时所有可能的结果
+			expected, expectedPaths := tc.Results, tc.ResultsPaths
+			if len(expected) == 0 {
+				expected = []json.RawMessage{tc.Result}
+				expectedPaths = [][]string{tc.ResultPaths}
+			}
+			for i, want := range expected {
+				if !nodesMatch(parseComplianceJSON(t, want), nodes) {
+					continue
+				}
+				if i < len(expectedPaths) && expectedPaths[i] != nil {
+					if got := nodePaths(nodes); strings.Join(got, ",") != strings.Join(expectedPaths[i], ",") {
+						t.Errorf("%q 的规范化路径 = %v, 期望 %v", tc.Selector, got, expectedPaths[i])
+					}
+				}
+				return
+			}
+			got := &Value{}
+			SetArray(got, len(nodes))
+			for _, node := range nodes {
+				Copy(PushBackArrayElement(got), node.value)
+			}
+			text, _ := Stringify(got)
+			t.Errorf("%q 的结果 = %s, 期望 %s", tc.Selector, text, expected[0])
+		})
+	}
+}
+
+func parseComplianceJSON(t *testing.T, raw json.RawMessage) *Value {
+	t.Helper()
+	v := &Value{}
+	if err := Parse(v, string(raw)); err != PARSE_OK {
+		t.Fatalf("解析 %s 失败: %v", raw, err)
+	}
+	return v
+}
+
+func nodesMatch(want *Value, nodes []queryNode) bool {
+	if want.Type != ARRAY || len(want.A) != len(nodes) {
+		return false
+	}
+	for i, node := range nodes {
+		if !ValuesEqual(want.A[i], node.value) {
+			return false
+		}
+	}
+	return true
+}
+
+func nodePaths(nodes []queryNode) []string {
+	paths := make([]string, len(nodes))
+	for i, node := range nodes {
+		paths[i] = normalizedPath(node.loc)
+	}
+	return paths
+}
+
+func TestJSONPathOptionsDefault(t *testing.T) {
+	// 不启用 RFC 9535 时仍然支持扩展语法
+	jp, err := NewJSONPathWithOptions("$[?(@.a =~ /x/)]", JSONPathOptions{})
+	if err != nil {
+		t.Fatalf("默认模式应该支持 =~: %v", err)
+	}
+	if jp.rfc != nil {
+		t.Errorf("默认模式不应该使用 RFC 9535 解析器")
+	}
+
+	doc := &Value{}
+	Parse(doc, `{"items":[{"n":1},{"n":2},{"n":3}]}`)
+	jp, err = NewJSONPathWithOptions("$.items[-1:0:-1].n", JSONPathOptions{RFC9535: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := jp.Query(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].N != 3 || results[1].N != 2 {
+		t.Errorf("Query 结果数量 = %d", len(results))
+	}
+}
+
+func TestRFC9535Errors(t *testing.T) {
+	tests := []struct {
+		path string
+		msg  string
+	}{
+		{"", "不能为空"},
+		{"$[01]", "前导零"},
+		{"$[-0]", "-0"},
+		{"$[9007199254740992]", "超出范围"},
+		{"$.a b", "多余的内容"},
+		{"$[?@.* == 1]", "单值查询"},
+		{"$[?length(@.*) == 1]", "第1个参数"},
+		{"$[?length(@)]", "必须参与比较"},
+		{"$[?keys(@)]", "未知的函数"},
+		{"$['\\x']", "无效的转义字符"},
+		{"$[0", "未闭合的方括号"},
+	}
+	for _, tt := range tests {
+		_, err := NewJSONPathWithOptions(tt.path, JSONPathOptions{RFC9535: true})
+		if err == nil || !strings.Contains(err.Error(), tt.msg) {
+			t.Errorf("NewJSONPathWithOptions(%q) error = %v, 期望包含 %q", tt.path, err, tt.msg)
+		}
+	}
+}
+
+func TestIregexpToGo(t *testing.T) {
+	tests := map[string]string{
+		"a.b":     `a[^\n\r]b`,
+		`a\.b`:    `a\.b`,
+		"[.]":     "[.]",
+		`[\]].`:   `[\]][^\n\r]`,
+		`\p{Lu}+`: `\p{Lu}+`,
+	}
+	for in, want := range tests {
+		if got := iregexpToGo(in); got != want {
+			t.Errorf("iregexpToGo(%q) = %q, 期望 %q", in, got, want)
+		}
+	}
+}
@@ -42,6 +42,31 @@ func (e JSONPointerError) Error() string {
 	}
 }
 
+// parseArrayIndexToken 按 RFC 6901 解析数组索引令牌
+//
+// 索引只能是 "0" 或不以0开头的十进制数字，"01"、"+1"、" 1" 等都是无效的。
+// allowNegative 为真时还接受 "-1"、"-2" 等负数索引，返回负值，由调用者加上数组长度；
+// 表示数组末尾之后位置的 "-" 需要调用者在此之前单独处理。
+func parseArrayIndexToken(token string, allowNegative bool) (int, bool) {
+	digits := token
+	if allowNegative && strings.HasPrefix(token, "-") {
+		digits = token[1:]
+	}
+	if digits == "" || (digits[0] == '0' && (len(digits) > 1 || len(digits) != len(token))) {
+		return 0, false
+	}
+	for i := 0; i < len(digits); i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return 0, false
+		}
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil {
+		return 0, false
+	}
+	return index, true
+}
+
 // ParseJSONPointer 解析JSON指针字符串
 // 例如: "/foo/0/bar" => ["foo", "0", "bar"]
 func ParseJSONPointer(pointer string) (*JSONPointer, JSONPointerError) {
@@ -82,8 +107,8 @@ func (p *JSONPointer) Get(root *Value) (*Value, JSONPointerError) {
 		switch current.Type {
 		case ARRAY:
 			// 对于数组，token 必须是有效索引
-			index, err := strconv.Atoi(token)
-			if err != nil || index < 0 || index >= len(current.A) {
+			index, ok := parseArrayIndexToken(token, false)
+			if !ok || index >= len(current.A) {
 				return nil, POINTER_INDEX_OUT_OF_RANGE
 			}
 			current = current.A[index]
@@ -129,9 +154,9 @@ func (p *JSONPointer) Replace(root *Value, value *Value) JSONPointerError {
 
 	switch parent.Type {
 	case ARRAY:
-		index, parseErr := strconv.Atoi(lastToken)
+		index, ok := parseArrayIndexToken(lastToken, false)
 		// 对于替换，索引必须在现有范围内
-		if parseErr != nil || index < 0 || index >= len(parent.A) {
+		if !ok || index >= len(parent.A) {
 			return POINTER_INDEX_OUT_OF_RANGE
 		}
 
@@ -191,14 +216,13 @@ func (p *JSONPointer) Insert(root *Value, value *Value) JSONPointerError {
 
 	switch parent.Type {
 	case ARRAY:
-		indexStr := lastToken
 		isAppend := (lastToken == "-")
 		var index int
-		var parseErr error
 
 		if !isAppend {
-			index, parseErr = strconv.Atoi(indexStr)
-			if parseErr != nil || index < 0 {
+			var ok bool
+			index, ok = parseArrayIndexToken(lastToken, false)
+			if !ok {
 				return POINTER_INDEX_OUT_OF_RANGE
 			}
 			// 插入索引不能超过当前数组长度
@@ -261,8 +285,8 @@ func (p *JSONPointer) Remove(root *Value) JSONPointerError {
 
 	switch parent.Type {
 	case ARRAY:
-		index, err := strconv.Atoi(lastToken)
-		if err != nil || index < 0 || index >= len(parent.A) {
+		index, ok := parseArrayIndexToken(lastToken, false)
+		if !ok || index >= len(parent.A) {
 			return POINTER_INDEX_OUT_OF_RANGE
 		}
 		// 删除数组元素
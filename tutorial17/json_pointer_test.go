@@ -0,0 +1,94 @@
+package leptjson
+
+import "testing"
+
+func TestParseArrayIndexToken(t *testing.T) {
+	tests := []struct {
+		token         string
+		allowNegative bool
+		index         int
+		ok            bool
+	}{
+		{"0", false, 0, true},
+		{"7", false, 7, true},
+		{"10", false, 10, true},
+		{"01", false, 0, false},
+		{"00", false, 0, false},
+		{"", false, 0, false},
+		{"-", false, 0, false},
+		{"+1", false, 0, false},
+		{" 1", false, 0, false},
+		{"1e2", false, 0, false},
+		{"-1", false, 0, false},
+		{"99999999999999999999", false, 0, false},
+		{"-1", true, -1, true},
+		{"-12", true, -12, true},
+		{"-0", true, 0, false},
+		{"-01", true, 0, false},
+		{"-", true, 0, false},
+		{"--1", true, 0, false},
+	}
+
+	for _, tt := range tests {
+		index, ok := parseArrayIndexToken(tt.token, tt.allowNegative)
+		if ok != tt.ok || (ok && index != tt.index) {
+			t.Errorf("parseArrayIndexToken(%q, %t) = %d, %t, 期望 %d, %t",
+				tt.token, tt.allowNegative, index, ok, tt.index, tt.ok)
+		}
+	}
+}
+
+func TestJSONPointerArrayTokens(t *testing.T) {
+	tests := []struct {
+		name     string
+		pointer  string
+		insert   bool   // true 使用 Insert，否则使用 Get
+		expected string // Get 的结果或 Insert 之后的文档，为空表示期望失败
+	}{
+		{"读取元素", "/list/1", false, `"b"`},
+		{"前导零", "/list/01", false, ""},
+		{"读取 -", "/list/-", false, ""},
+		{"对象中的数字键", "/map/01", false, `1`},
+		{"插入到开头", "/list/0", true, `{"list":["x","a","b"],"map":{"01":1}}`},
+		{"追加", "/list/-", true, `{"list":["a","b","x"],"map":{"01":1}}`},
+		{"插入前导零", "/list/00", true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := &Value{}
+			Parse(doc, `{"list":["a","b"],"map":{"01":1}}`)
+			p, err := ParseJSONPointer(tt.pointer)
+			if err != POINTER_OK {
+				t.Fatalf("解析指针失败: %v", err)
+			}
+
+			var got string
+			if tt.insert {
+				x := &Value{}
+				SetString(x, "x")
+				err = p.Insert(doc, x)
+				got, _ = Stringify(doc)
+			} else {
+				var v *Value
+				v, err = p.Get(doc)
+				if err == POINTER_OK {
+					got, _ = Stringify(v)
+				}
+			}
+
+			if tt.expected == "" {
+				if err == POINTER_OK {
+					t.Errorf("期望失败，实际得到 %s", got)
+				}
+				return
+			}
+			if err != POINTER_OK {
+				t.Fatalf("操作失败: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("结果 = %s, 期望 %s", got, tt.expected)
+			}
+		})
+	}
+}
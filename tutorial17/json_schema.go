@@ -36,6 +36,8 @@ func (r *SchemaValidationResult) AddError(path, message string) {
 // JSONSchema 表示一个 JSON Schema 对象
 type JSONSchema struct {
 	Schema *Value // 存储 JSON Schema 的 Value 对象
+
+	refs *refResolver // 解析 $ref，为 nil 时只能解析文档内部的引用
 }
 
 // NewJSONSchema 创建一个新的 JSON Schema
@@ -62,8 +64,29 @@ func NewJSONSchemaFromValue(schema *Value) (*JSONSchema, error) {
 	return &JSONSchema{Schema: schema}, nil
 }
 
+// NewJSONSchemaWithLoader 创建可以解析外部 $ref 的 JSON Schema
+//
+// baseURI 是 schema 自身的 URI，相对 $ref 以它为基准，本地文件可以使用 SchemaFileURI。
+// 所有 $ref 在创建时解析，任何引用无法加载时返回错误。
+func NewJSONSchemaWithLoader(schema *Value, baseURI string, loader SchemaLoader) (*JSONSchema, error) {
+	js, err := NewJSONSchemaFromValue(schema)
+	if err != nil {
+		return nil, err
+	}
+	js.refs = newRefResolver(schema, baseURI, loader)
+	if err := js.refs.preload(); err != nil {
+		return nil, err
+	}
+	return js, nil
+}
+
 // Validate 根据 Schema 验证 JSON 数据
 func (js *JSONSchema) Validate(data *Value) *SchemaValidationResult {
+	if js.refs == nil {
+		local := *js
+		local.refs = newRefResolver(js.Schema, "", nil)
+		js = &local
+	}
 	result := &SchemaValidationResult{Valid: true}
 	js.validateValue(js.Schema, data, "", result)
 	return result
@@ -71,6 +94,17 @@ func (js *JSONSchema) Validate(data *Value) *SchemaValidationResult {
 
 // validateValue 是验证的核心递归函数
 func (js *JSONSchema) validateValue(schema, data *Value, path string, result *SchemaValidationResult) {
+	// 有 $ref 时使用引用的 Schema，忽略并列的关键字
+	if ref, found := FindObjectKey(schema, "$ref"); found && ref.Type == STRING {
+		target, err := js.refs.resolve(schema)
+		if err != nil {
+			result.AddError(path, fmt.Sprintf("无法解析引用: %v", err))
+			return
+		}
+		js.validateValue(target, data, path, result)
+		return
+	}
+
 	// 类型验证
 	if typeValue, found := FindObjectKey(schema, "type"); found {
 		js.validateType(typeValue, data, path, result)
@@ -354,30 +388,26 @@ func (js *JSONSchema) validateString(schema, data *Value, path string, result *S
 		}
 	}
 
-	// format 验证 (部分常见格式)
+	// format 验证，使用格式注册表，未注册的格式不做限制
 	if format, found := FindObjectKey(schema, "format"); found && format.Type == STRING {
 		formatName := GetString(format)
-		switch formatName {
-		case "email":
-			// 简单的电子邮件验证
-			emailRegex := regexp.MustCompile(`^[^@]+@[^@]+\.[^@]+$`)
-			if !emailRegex.MatchString(str) {
-				result.AddError(path, "字符串不是有效的电子邮件格式")
-			}
-		case "date-time":
-			// 简化的 ISO8601 日期时间验证
-			dateTimeRegex := regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(Z|[+-]\d{2}:\d{2})$`)
-			if !dateTimeRegex.MatchString(str) {
-				result.AddError(path, "字符串不是有效的 ISO8601 日期时间格式")
-			}
-		case "uri":
-			// 简单的 URI 验证
-			uriRegex := regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:.*$`)
-			if !uriRegex.MatchString(str) {
-				result.AddError(path, "字符串不是有效的 URI 格式")
-			}
+		if !checkFormat(formatName, str) {
+			result.AddError(path, "字符串不是有效的"+formatLabel(formatName))
+		}
+	}
+
+	// 解码嵌入的文档，并用 contentSchema 验证
+	contentDoc, keyword, err := decodeContent(schema, str)
+	switch {
+	case keyword == "contentEncoding":
+		encoding, _ := FindObjectKey(schema, "contentEncoding")
+		result.AddError(path, fmt.Sprintf("字符串不是有效的 %s 编码: %v", GetString(encoding), err))
+	case keyword == "contentMediaType":
+		result.AddError(path, fmt.Sprintf("内容不是有效的 JSON: %v", err))
+	case contentDoc != nil:
+		if contentSchema, found := FindObjectKey(schema, "contentSchema"); found {
+			js.validateValue(contentSchema, contentDoc, path+contentPathSuffix, result)
 		}
-		// 其他格式可以根据需要添加
 	}
 }
 
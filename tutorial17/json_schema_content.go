@@ -0,0 +1,49 @@
+// json_schema_content.go - contentEncoding 和 contentMediaType 关键字
+package leptjson
+
+import (
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"strings"
+)
+
+// contentPathSuffix 附加在字符串的路径之后，表示其中嵌入的文档，如 $.payload:content.id
+const contentPathSuffix = ":content"
+
+// decodeContent 按 schema 的 contentEncoding 和 contentMediaType 解码字符串 s
+//
+// 媒体类型是 JSON 时返回解析后的嵌入文档，否则返回 nil。解码或解析失败时
+// 返回未满足的关键字和原因。未知的编码和媒体类型不做检查。
+func decodeContent(schema *Value, s string) (doc *Value, keyword string, err error) {
+	content := s
+	if encoding, found := FindObjectKey(schema, "contentEncoding"); found && encoding.Type == STRING {
+		switch strings.ToLower(GetString(encoding)) {
+		case "base64":
+			decoded, derr := base64.StdEncoding.DecodeString(s)
+			if derr != nil {
+				return nil, "contentEncoding", derr
+			}
+			content = string(decoded)
+		}
+	}
+
+	mediaType, found := FindObjectKey(schema, "contentMediaType")
+	if !found || mediaType.Type != STRING || !isJSONMediaType(GetString(mediaType)) {
+		return nil, "", nil
+	}
+	doc = &Value{}
+	if perr := Parse(doc, content); perr != PARSE_OK {
+		return nil, "contentMediaType", fmt.Errorf("%v", perr)
+	}
+	return doc, "", nil
+}
+
+// isJSONMediaType 判断是否为 application/json 或带有 +json 后缀的媒体类型
+func isJSONMediaType(s string) bool {
+	mediaType, _, err := mime.ParseMediaType(s)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json")
+}
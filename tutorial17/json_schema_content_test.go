@@ -0,0 +1,74 @@
+package leptjson
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestContentKeywords(t *testing.T) {
+	schema := &Value{}
+	Parse(schema, `{"properties": {"payload": {
+		"type": "string",
+		"contentEncoding": "base64",
+		"contentMediaType": "application/json",
+		"contentSchema": {"type": "object", "required": ["event"], "properties": {"id": {"type": "number"}}}
+	}}}`)
+	encode := func(s string) string {
+		return `{"payload": "` + base64.StdEncoding.EncodeToString([]byte(s)) + `"}`
+	}
+
+	tests := []struct {
+		name     string
+		data     string
+		keywords []string
+		paths    []string
+	}{
+		{"有效", encode(`{"event": "push", "id": 1}`), nil, nil},
+		{"嵌入文档不符合", encode(`{"id": "x"}`), []string{"required", "type"},
+			[]string{"$.payload:content", "$.payload:content.id"}},
+		{"不是base64", `{"payload": "not base64!"}`, []string{"contentEncoding"}, []string{"$.payload"}},
+		{"不是JSON", encode(`{"event":`), []string{"contentMediaType"}, []string{"$.payload"}},
+	}
+	for _, tt := range tests {
+		data := &Value{}
+		Parse(data, tt.data)
+		result := validateWithSchema(schema, data)
+		var keywords, paths []string
+		for _, issue := range result.Issues {
+			keywords = append(keywords, issue.Keyword)
+			paths = append(paths, issue.Path)
+		}
+		if strings.Join(keywords, ",") != strings.Join(tt.keywords, ",") || strings.Join(paths, ",") != strings.Join(tt.paths, ",") {
+			t.Errorf("%s: 关键字 = %v, 路径 = %v", tt.name, keywords, paths)
+		}
+
+		js, _ := NewJSONSchemaFromValue(schema)
+		if lib := js.Validate(data); len(lib.Errors) != len(tt.keywords) {
+			t.Errorf("%s: JSONSchema 错误 = %v", tt.name, lib.Errors)
+		}
+	}
+}
+
+func TestContentMediaTypes(t *testing.T) {
+	tests := map[string]bool{
+		"application/json":                true,
+		"application/json; charset=utf-8": true,
+		"application/vnd.api+json":        true,
+		"text/plain":                      false,
+		"invalid/":                        false,
+	}
+	for mediaType, want := range tests {
+		if got := isJSONMediaType(mediaType); got != want {
+			t.Errorf("isJSONMediaType(%q) = %v", mediaType, got)
+		}
+	}
+
+	// 非JSON的媒体类型只检查编码
+	schema, data := &Value{}, &Value{}
+	Parse(schema, `{"contentEncoding": "base64", "contentMediaType": "image/png", "contentSchema": {"type": "number"}}`)
+	Parse(data, `"iVBORw0KGgo="`)
+	if result := validateWithSchema(schema, data); !result.Valid {
+		t.Errorf("结果 = %v", result.Errors)
+	}
+}
@@ -0,0 +1,329 @@
+// json_schema_delta.go - 应用补丁之后只重新验证受影响的部分
+package leptjson
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// ValidateDelta 使用 schema 验证应用了 patch 之后的 doc，只检查补丁修改过的部分
+//
+// doc 必须是已经应用了 patch 的文档，并且应用之前的文档通过了验证。添加、
+// 替换、移动和复制得到的值按所在位置的子 schema 完整验证；增减了成员或元素
+// 的对象和数组只检查自身这一层的关键字（type、required、minItems 等），
+// 不再进入其他没有变化的子元素。因此对大文档的小补丁只需要很少的时间，
+// 报告的错误与完整验证相同，按位置排序。
+//
+// 补丁替换整个文档或 schema 中的 $ref 无法解析时退回完整验证。
+func ValidateDelta(doc, schema *Value, patch *JSONPatch) ValidationResult {
+	ctx := newSchemaContext(nil, newRefResolver(schema, "", nil))
+	if patch == nil {
+		return ValidationResult{Valid: true}
+	}
+	steps, ok := deltaSteps(patch)
+	if !ok {
+		return validateWithContext(schema, doc, ctx)
+	}
+
+	issues := []ValidationIssue{}
+	_, checks := analyzeDelta(doc, steps)
+	for _, check := range checks {
+		found, ok := validateDeltaCheck(schema, doc, check, ctx)
+		if !ok {
+			return validateWithContext(schema, doc, ctx)
+		}
+		issues = append(issues, found...)
+	}
+	return newValidationResult(issues, ctx.translator)
+}
+
+// deltaStep 是补丁中的一个修改，move 拆分为 remove 和 add
+type deltaStep struct {
+	op   string // add、remove 或 replace
+	path []string
+}
+
+// deltaSteps 将补丁拆分为修改步骤，补丁修改整个文档或路径无效时返回 false
+func deltaSteps(patch *JSONPatch) ([]deltaStep, bool) {
+	var steps []deltaStep
+	for _, op := range patch.Operations {
+		if op.Op == "test" {
+			continue
+		}
+		path, ok := deltaPointer(op.Path)
+		if !ok {
+			return nil, false
+		}
+		switch op.Op {
+		case "add", "copy":
+			steps = append(steps, deltaStep{op: "add", path: path})
+		case "remove", "replace":
+			steps = append(steps, deltaStep{op: op.Op, path: path})
+		case "move":
+			from, ok := deltaPointer(op.From)
+			if !ok {
+				return nil, false
+			}
+			steps = append(steps, deltaStep{op: "remove", path: from}, deltaStep{op: "add", path: path})
+		default:
+			return nil, false
+		}
+	}
+	return steps, true
+}
+
+// deltaPointer 解析 JSON 指针，空指针（整个文档）和无效指针返回 false
+func deltaPointer(pointer string) ([]string, bool) {
+	p, err := ParseJSONPointer(pointer)
+	if err != POINTER_OK || len(p.tokens) == 0 {
+		return nil, false
+	}
+	return p.tokens, true
+}
+
+// 修改步骤对之前记录的路径的影响
+const (
+	deltaReplace = iota // 路径上的值被替换或删除，路径本身和其下的位置都不再需要检查
+	deltaInsert         // 在数组中插入了元素，之后的元素下标加一
+	deltaRemove         // 删除了数组元素，之后的元素下标减一
+)
+
+// deltaEffect 描述一个修改步骤如何改变文档中的路径
+type deltaEffect struct {
+	kind  int
+	path  []string // deltaReplace 时是被修改的位置，否则是数组的位置
+	index int      // 插入或删除的数组下标
+}
+
+// apply 将步骤之前的路径换算为步骤之后的路径，路径上的值被修改时返回 false
+func (e deltaEffect) apply(path []string) ([]string, bool) {
+	if !hasPathPrefix(path, e.path) {
+		return path, true
+	}
+	if e.kind == deltaReplace {
+		return nil, false
+	}
+	if len(path) == len(e.path) {
+		return path, true
+	}
+	index, ok := parseArrayIndexToken(path[len(e.path)], false)
+	if !ok {
+		return path, true
+	}
+	switch {
+	case e.kind == deltaInsert && index >= e.index:
+		index++
+	case e.kind == deltaRemove && index == e.index:
+		return nil, false
+	case e.kind == deltaRemove && index > e.index:
+		index--
+	default:
+		return path, true
+	}
+	shifted := append([]string(nil), path...)
+	shifted[len(e.path)] = strconv.Itoa(index)
+	return shifted, true
+}
+
+// mapDeltaPath 依次应用 effects，将路径换算为最终文档中的路径
+func mapDeltaPath(path []string, effects []deltaEffect) ([]string, bool) {
+	for _, e := range effects {
+		var ok bool
+		if path, ok = e.apply(path); !ok {
+			return nil, false
+		}
+	}
+	return path, true
+}
+
+// deltaCheck 是最终文档中需要重新验证的位置
+type deltaCheck struct {
+	path []string
+	deep bool // 为 true 时验证整个子树，否则只验证这一层的关键字
+}
+
+// analyzeDelta 返回每个步骤对路径的影响，以及最终文档中需要重新验证的位置
+//
+// 只有最终的文档可用，所以从最后一个步骤往前处理：每个步骤的路径经过之后
+// 所有步骤的换算，得到最终文档中的位置，并由此判断它修改的是数组还是对象。
+func analyzeDelta(doc *Value, steps []deltaStep) ([]deltaEffect, []deltaCheck) {
+	effects := make([]deltaEffect, len(steps))
+	var checks []deltaCheck
+	for i := len(steps) - 1; i >= 0; i-- {
+		step, later := steps[i], effects[i+1:]
+		parent := step.path[:len(step.path)-1]
+		effects[i] = deltaEffect{kind: deltaReplace, path: step.path}
+
+		if step.op != "replace" {
+			if container, ok := mapDeltaPath(parent, later); ok {
+				if node := valueAtPath(doc, container); node != nil && node.Type == ARRAY {
+					token := step.path[len(parent)]
+					index, ok := parseArrayIndexToken(token, false)
+					if token == "-" {
+						index, ok = arrayLengthAfter(parent, later, len(node.A))-1, true
+					}
+					if ok {
+						kind := deltaInsert
+						if step.op == "remove" {
+							kind = deltaRemove
+						}
+						effects[i] = deltaEffect{kind: kind, path: parent, index: index}
+						step.path = append(parent[:len(parent):len(parent)], strconv.Itoa(index))
+					}
+				}
+			}
+			if container, ok := mapDeltaPath(parent, later); ok {
+				checks = append(checks, deltaCheck{path: container})
+			}
+		}
+		if step.op != "remove" {
+			if path, ok := mapDeltaPath(step.path, later); ok {
+				checks = append(checks, deltaCheck{path: path, deep: true})
+			}
+		}
+	}
+	return effects, mergeDeltaChecks(checks)
+}
+
+// arrayLengthAfter 根据最终的长度推算之后的步骤执行之前数组的长度
+func arrayLengthAfter(path []string, later []deltaEffect, length int) int {
+	for _, e := range later {
+		if e.kind != deltaReplace && pathEqual(e.path, path) {
+			if e.kind == deltaInsert {
+				length--
+			} else {
+				length++
+			}
+		}
+		path, _ = e.apply(path)
+	}
+	return length
+}
+
+// mergeDeltaChecks 排序并去掉重复的位置和已经被完整验证的子树覆盖的位置
+func mergeDeltaChecks(checks []deltaCheck) []deltaCheck {
+	sort.SliceStable(checks, func(i, j int) bool {
+		if c := comparePaths(checks[i].path, checks[j].path); c != 0 {
+			return c < 0
+		}
+		return checks[i].deep && !checks[j].deep
+	})
+	var merged []deltaCheck
+	var cover []string // 最近一个完整验证的位置
+	for _, check := range checks {
+		if cover != nil && hasPathPrefix(check.path, cover) {
+			continue
+		}
+		if n := len(merged); n > 0 && pathEqual(merged[n-1].path, check.path) {
+			continue
+		}
+		merged = append(merged, check)
+		if check.deep {
+			cover = check.path
+		}
+	}
+	return merged
+}
+
+// validateDeltaCheck 找到位置对应的子 schema 并验证，无法处理时返回 false
+func validateDeltaCheck(schema, doc *Value, check deltaCheck, ctx *schemaContext) ([]ValidationIssue, bool) {
+	node, path := doc, "$"
+	for _, token := range check.path {
+		resolved, err := ctx.refs.resolve(schema)
+		if err != nil {
+			return nil, false
+		}
+		switch node.Type {
+		case ARRAY:
+			index, ok := parseArrayIndexToken(token, false)
+			if !ok || index >= len(node.A) {
+				return nil, false
+			}
+			node = node.A[index]
+			path = fmt.Sprintf("%s[%d]", path, index)
+			schema = findObjectKey(resolved, "items")
+		case OBJECT:
+			if node = findObjectKeyValue(node, token); node == nil {
+				return nil, false
+			}
+			path = fmt.Sprintf("%s.%s", path, token)
+			schema = findObjectKey(findObjectKey(resolved, "properties"), token)
+		default:
+			return nil, false
+		}
+		// 没有子 schema 的位置不受任何约束
+		if schema == nil || schema.Type != OBJECT {
+			return nil, true
+		}
+	}
+
+	if !check.deep {
+		resolved, err := ctx.refs.resolve(schema)
+		if err != nil {
+			return nil, false
+		}
+		schema = shallowSchema(resolved)
+	}
+	return validateJSONSchema(schema, node, path, ctx), true
+}
+
+// shallowSchema 返回去掉了子元素关键字的 schema，只验证这一层
+func shallowSchema(schema *Value) *Value {
+	shallow := &Value{Type: OBJECT}
+	for _, m := range schema.O {
+		if m.K != "properties" && m.K != "items" {
+			shallow.O = append(shallow.O, m)
+		}
+	}
+	return shallow
+}
+
+// valueAtPath 返回路径上的值，不存在时返回 nil
+func valueAtPath(root *Value, path []string) *Value {
+	node, err := (&JSONPointer{tokens: path}).Get(root)
+	if err != POINTER_OK {
+		return nil
+	}
+	return node
+}
+
+// hasPathPrefix 判断 prefix 是否是 path 本身或它的祖先
+func hasPathPrefix(path, prefix []string) bool {
+	return len(path) >= len(prefix) && pathEqual(path[:len(prefix)], prefix)
+}
+
+// pathEqual 判断两个路径是否相同
+func pathEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// comparePaths 逐个比较路径令牌，数组下标按数值比较，祖先排在子孙之前
+func comparePaths(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] == b[i] {
+			continue
+		}
+		x, okA := parseArrayIndexToken(a[i], false)
+		y, okB := parseArrayIndexToken(b[i], false)
+		if okA && okB {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+		if a[i] < b[i] {
+			return -1
+		}
+		return 1
+	}
+	return len(a) - len(b)
+}
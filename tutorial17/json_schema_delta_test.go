@@ -0,0 +1,112 @@
+package leptjson
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+const deltaSchema = `{
+	"type": "object",
+	"required": ["users"],
+	"properties": {
+		"users": {
+			"type": "array",
+			"maxItems": 4,
+			"items": {"$ref": "#/definitions/user"}
+		},
+		"owner": {"$ref": "#/definitions/user"}
+	},
+	"definitions": {
+		"user": {
+			"type": "object",
+			"required": ["name"],
+			"properties": {
+				"name": {"type": "string", "minLength": 1},
+				"age": {"type": "number", "minimum": 0}
+			}
+		}
+	}
+}`
+
+const deltaDoc = `{"users":[{"name":"a"},{"name":"b","age":3}],"owner":{"name":"c"},"extra":1}`
+
+// issueKeys 返回排序后的错误位置和关键字，便于与完整验证比较
+func issueKeys(result ValidationResult) string {
+	var keys []string
+	for _, issue := range result.Issues {
+		keys = append(keys, issue.Path+" "+issue.Keyword)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
+
+func TestValidateDeltaMatchesFullValidation(t *testing.T) {
+	schema := &Value{}
+	if err := Parse(schema, deltaSchema); err != PARSE_OK {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		patch string
+		want  string
+	}{
+		{"替换为无效值", `[{"op":"replace","path":"/users/1/age","value":-1}]`, "$.users[1].age minimum"},
+		{"添加无效元素", `[{"op":"add","path":"/users/0","value":{"age":1}}]`, "$.users[0] required"},
+		{"追加后再插入", `[{"op":"add","path":"/users/-","value":{"name":""}},{"op":"add","path":"/users/0","value":{"name":"x"}}]`, "$.users[3].name minLength"},
+		{"删除必需成员", `[{"op":"remove","path":"/owner/name"}]`, "$.owner required"},
+		{"超出数组长度", `[{"op":"add","path":"/users/-","value":{"name":"x"}},{"op":"add","path":"/users/-","value":{"name":"y"}},{"op":"add","path":"/users/-","value":{"name":"z"}}]`, "$.users maxItems"},
+		{"删除后插入", `[{"op":"remove","path":"/users/0"},{"op":"add","path":"/users/0","value":{"name":1}}]`, "$.users[0].name type"},
+		{"移动", `[{"op":"move","from":"/owner","path":"/users/1"}]`, ""},
+		{"移动到不受约束的位置", `[{"op":"move","from":"/users","path":"/extra"}]`, "$ required"},
+		{"复制无效值", `[{"op":"add","path":"/extra","value":{"age":-1}},{"op":"copy","from":"/extra","path":"/owner"}]`, "$.owner required, $.owner.age minimum"},
+		{"替换后修改内部", `[{"op":"replace","path":"/owner","value":{"name":"d"}},{"op":"remove","path":"/owner/name"}]`, "$.owner required"},
+		{"替换整个文档", `[{"op":"replace","path":"","value":{}}]`, "$ required"},
+		{"只有测试", `[{"op":"test","path":"/extra","value":1}]`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := &Value{}
+			Parse(doc, deltaDoc)
+			patch, err := NewJSONPatchFromString(tt.patch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := patch.Apply(doc); err != nil {
+				t.Fatal(err)
+			}
+
+			full := validateWithSchema(schema, doc)
+			delta := ValidateDelta(doc, schema, patch)
+			if got := issueKeys(delta); got != tt.want || got != issueKeys(full) {
+				t.Errorf("ValidateDelta = %q, 完整验证 = %q, 期望 %q", got, issueKeys(full), tt.want)
+			}
+			if delta.Valid != full.Valid || delta.Message != full.Message {
+				t.Errorf("ValidateDelta = %+v, 完整验证 = %+v", delta, full)
+			}
+		})
+	}
+}
+
+func TestValidateDeltaSkipsUntouchedSubtrees(t *testing.T) {
+	schema := &Value{}
+	Parse(schema, deltaSchema)
+	// 已有的无效元素不在补丁修改的范围内，不会被检查
+	doc := &Value{}
+	Parse(doc, `{"users":[{"age":-1},{"name":"b"}]}`)
+	patch, _ := NewJSONPatchFromString(`[{"op":"replace","path":"/users/1/name","value":""}]`)
+	patch.Apply(doc)
+
+	result := ValidateDelta(doc, schema, patch)
+	if got := issueKeys(result); got != "$.users[1].name minLength" {
+		t.Errorf("ValidateDelta = %q", got)
+	}
+	if full := issueKeys(validateWithSchema(schema, doc)); !strings.Contains(full, "$.users[0]") {
+		t.Errorf("完整验证 = %q", full)
+	}
+
+	if result := ValidateDelta(doc, schema, nil); !result.Valid {
+		t.Errorf("没有补丁时 = %+v", result)
+	}
+}
@@ -0,0 +1,176 @@
+// json_schema_format.go - JSON Schema format 关键字的格式注册表
+package leptjson
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FormatChecker 判断字符串是否符合某种格式
+type FormatChecker func(s string) bool
+
+var (
+	formatsMu sync.RWMutex
+	formats   = map[string]FormatChecker{
+		"date-time":     isDateTime,
+		"date":          isDate,
+		"email":         isEmail,
+		"hostname":      isHostname,
+		"ipv4":          isIPv4,
+		"ipv6":          isIPv6,
+		"uri":           isURI,
+		"uri-reference": isURIReference,
+		"uuid":          isUUID,
+		"regex":         isRegex,
+	}
+
+	// formatLabels 是内置格式在错误描述中的名称
+	formatLabels = map[string]string{
+		"date-time": "ISO8601 日期时间格式",
+		"date":      "日期格式",
+		"email":     "电子邮件格式",
+		"hostname":  "主机名格式",
+		"ipv4":      "IPv4 地址格式",
+		"ipv6":      "IPv6 地址格式",
+		"uri":       "URI 格式",
+		"uuid":      "UUID 格式",
+		"regex":     "正则表达式格式",
+	}
+)
+
+// RegisterFormat 注册名为 name 的格式，已存在时替换原来的检查函数
+//
+// 注册后 Schema 中的 "format": name 会使用 check 验证字符串，名称或检查函数为空时返回错误，如
+//
+//	if err := RegisterFormat("order-id", func(s string) bool {
+//		return strings.HasPrefix(s, "ORD-")
+//	}); err != nil {
+//		log.Fatal(err)
+//	}
+func RegisterFormat(name string, check FormatChecker) error {
+	if name == "" {
+		return fmt.Errorf("格式名称不能为空")
+	}
+	if check == nil {
+		return fmt.Errorf("格式检查函数不能为空")
+	}
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	formats[name] = check
+	return nil
+}
+
+// LookupFormat 返回名为 name 的格式的检查函数
+func LookupFormat(name string) (FormatChecker, bool) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	check, ok := formats[name]
+	return check, ok
+}
+
+// checkFormat 判断 s 是否符合格式 name，未注册的格式不做限制
+func checkFormat(name, s string) bool {
+	check, ok := LookupFormat(name)
+	return !ok || check(s)
+}
+
+// formatLabel 返回格式在错误描述中的名称
+func formatLabel(name string) string {
+	if label, ok := formatLabels[name]; ok {
+		return label
+	}
+	return name + " 格式"
+}
+
+// isDateTime 检查 RFC 3339 日期时间，如 2023-01-01T12:00:00Z
+func isDateTime(s string) bool {
+	_, err := time.Parse(time.RFC3339Nano, strings.ToUpper(s))
+	return err == nil
+}
+
+// isDate 检查 RFC 3339 日期，如 2023-01-01
+func isDate(s string) bool {
+	_, err := time.Parse("2006-01-02", s)
+	return err == nil
+}
+
+// isEmail 检查电子邮件地址，域名部分必须是有效的主机名
+func isEmail(s string) bool {
+	at := strings.LastIndexByte(s, '@')
+	if at <= 0 || at > 64 {
+		return false
+	}
+	if strings.ContainsAny(s[:at], " \t\r\n@") {
+		return false
+	}
+	domain := s[at+1:]
+	return strings.Contains(domain, ".") && isHostname(domain)
+}
+
+// isHostname 检查 RFC 1123 主机名
+func isHostname(s string) bool {
+	if s == "" || len(s) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(strings.TrimSuffix(s, "."), ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for i := 0; i < len(label); i++ {
+			c := label[i]
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isIPv4 检查点分十进制的 IPv4 地址，各段不能有前导零
+func isIPv4(s string) bool {
+	parts := strings.Split(s, ".")
+	if len(parts) != 4 {
+		return false
+	}
+	for _, part := range parts {
+		if len(part) > 1 && part[0] == '0' {
+			return false
+		}
+	}
+	return net.ParseIP(s) != nil
+}
+
+// isIPv6 检查 IPv6 地址
+func isIPv6(s string) bool {
+	return strings.Contains(s, ":") && net.ParseIP(s) != nil
+}
+
+// isURI 检查带有协议的绝对 URI
+func isURI(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && !strings.ContainsAny(s, " \t\r\n")
+}
+
+// isURIReference 检查 URI 或相对引用
+func isURIReference(s string) bool {
+	_, err := url.Parse(s)
+	return err == nil && !strings.ContainsAny(s, " \t\r\n")
+}
+
+var uuidRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// isUUID 检查 RFC 4122 UUID 的字符串形式
+func isUUID(s string) bool {
+	return uuidRegex.MatchString(s)
+}
+
+// isRegex 检查正则表达式是否有效
+func isRegex(s string) bool {
+	_, err := regexp.Compile(s)
+	return err == nil
+}
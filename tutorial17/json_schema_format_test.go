@@ -0,0 +1,93 @@
+package leptjson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuiltinFormats(t *testing.T) {
+	tests := []struct {
+		format  string
+		valid   []string
+		invalid []string
+	}{
+		{"date-time", []string{"2023-01-01T12:00:00Z", "2023-01-01T12:00:00.123+08:00", "2023-01-01t12:00:00z"},
+			[]string{"2023-01-01", "2023-13-01T12:00:00Z", "2023-01-01 12:00:00Z"}},
+		{"date", []string{"2024-02-29"}, []string{"2023-02-29", "2023-1-1"}},
+		{"email", []string{"user@example.com", "a.b+c@mail.example.org"},
+			[]string{"invalid-email", "@example.com", "user@localhost", "us er@example.com", "user@-bad.com"}},
+		{"hostname", []string{"example.com", "localhost", "a-b.c1"},
+			[]string{"", "-a.com", "a..com", "a_b.com", strings.Repeat("a", 64) + ".com"}},
+		{"ipv4", []string{"192.168.0.1", "0.0.0.0"}, []string{"256.1.1.1", "1.2.3", "01.2.3.4", "::1"}},
+		{"ipv6", []string{"::1", "2001:db8::8a2e:370:7334"}, []string{"1.2.3.4", "2001:db8::g", "fe80::1%eth0"}},
+		{"uri", []string{"https://example.com/a?b=c", "urn:isbn:0451450523"}, []string{"/relative/path", "http://a b"}},
+		{"uri-reference", []string{"/relative/path", "#frag"}, []string{"http://a b"}},
+		{"uuid", []string{"123e4567-e89b-12d3-a456-426614174000"}, []string{"123e4567e89b12d3a456426614174000", "123e4567-e89b-12d3-a456-42661417400z"}},
+		{"regex", []string{"^[a-z]+$"}, []string{"(unclosed"}},
+	}
+	for _, tt := range tests {
+		check, ok := LookupFormat(tt.format)
+		if !ok {
+			t.Errorf("应该内置 %s 格式", tt.format)
+			continue
+		}
+		for _, s := range tt.valid {
+			if !check(s) {
+				t.Errorf("%s: %q 应该有效", tt.format, s)
+			}
+		}
+		for _, s := range tt.invalid {
+			if check(s) {
+				t.Errorf("%s: %q 应该无效", tt.format, s)
+			}
+		}
+	}
+}
+
+func TestRegisterFormat(t *testing.T) {
+	if err := RegisterFormat("", func(string) bool { return true }); err == nil {
+		t.Error("RegisterFormat 应当拒绝空名称")
+	}
+	if err := RegisterFormat("test-nil", nil); err == nil {
+		t.Error("RegisterFormat 应当拒绝空的检查函数")
+	}
+	if _, ok := LookupFormat("test-nil"); ok {
+		t.Error("注册失败的格式不应当可以查找到")
+	}
+	if err := RegisterFormat("order-id", func(s string) bool {
+		return strings.HasPrefix(s, "ORD-") && len(s) == 10
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	js, err := NewJSONSchema(`{"properties":{"id":{"type":"string","format":"order-id"}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := &Value{}
+	Parse(data, `{"id":"ORD-123456"}`)
+	if result := js.Validate(data); !result.Valid {
+		t.Errorf("有效的订单号验证失败: %v", result.Errors)
+	}
+	Parse(data, `{"id":"123456"}`)
+	result := js.Validate(data)
+	if result.Valid || !strings.Contains(result.Errors[0].Message, "order-id 格式") {
+		t.Errorf("无效的订单号 = %+v", result)
+	}
+
+	// 命令行使用的验证器同样使用注册表
+	schema := &Value{}
+	Parse(schema, `{"properties":{"id":{"format":"order-id"},"ip":{"format":"ipv4"},"x":{"format":"unknown"}}}`)
+	Parse(data, `{"id":"x","ip":"1.2.3","x":"anything"}`)
+	cliResult := validateWithSchema(schema, data)
+	expected := []string{
+		"位于'$.id'的字符串不是有效的order-id 格式",
+		"位于'$.ip'的字符串不是有效的IPv4 地址格式",
+	}
+	if strings.Join(cliResult.Errors, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("描述 = %q", cliResult.Errors)
+	}
+	if cliResult.Issues[0].Keyword != "format" || cliResult.Issues[0].Params["format"] != "order-id" {
+		t.Errorf("Issues[0] = %+v", cliResult.Issues[0])
+	}
+}
@@ -0,0 +1,173 @@
+// json_schema_prune.go - 按 JSON Schema 删除未声明的属性
+package leptjson
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Prune 删除 doc 中 schema 没有声明的属性，返回删除的属性数量
+//
+// 对象的 schema 中出现 properties、patternProperties 或 additionalProperties
+// 时，只保留被 properties 或 patternProperties 声明的属性；additionalProperties
+// 为 true 或对象时额外的属性也会保留，为对象时继续按它修剪。三者都没有出现的
+// schema 不限制属性，对象保持不变。allOf、anyOf 和 oneOf 中的子模式声明的属性
+// 取并集。数组元素按 items 和 additionalItems 递归修剪。
+//
+// Prune 不做验证，不符合 schema 的值只要不是未声明的属性就会原样保留。
+func Prune(doc, schema *Value) (int, error) {
+	if doc == nil {
+		return 0, fmt.Errorf("JSON 文档不能为空")
+	}
+	if schema == nil || schema.Type != OBJECT {
+		return 0, fmt.Errorf("JSON Schema 必须是一个对象")
+	}
+	p := &pruner{patterns: make(map[string]*regexp.Regexp)}
+	p.prune(doc, []*Value{schema})
+	return p.removed, p.err
+}
+
+// Prune 删除 data 中 Schema 没有声明的属性，返回删除的属性数量
+func (js *JSONSchema) Prune(data *Value) (int, error) {
+	return Prune(data, js.Schema)
+}
+
+// pruner 保存一次修剪的状态
+type pruner struct {
+	patterns map[string]*regexp.Regexp // 已编译的 patternProperties
+	removed  int
+	err      error
+}
+
+// prune 按 schemas 修剪 v，v 需要同时满足 schemas 中的所有模式
+func (p *pruner) prune(v *Value, schemas []*Value) {
+	schemas = flattenSchemas(schemas, nil)
+	if len(schemas) == 0 {
+		return
+	}
+	switch v.Type {
+	case OBJECT:
+		p.pruneObject(v, schemas)
+	case ARRAY:
+		p.pruneArray(v, schemas)
+	}
+}
+
+// flattenSchemas 将 allOf、anyOf 和 oneOf 中的子模式展开到同一层
+func flattenSchemas(schemas, out []*Value) []*Value {
+	for _, schema := range schemas {
+		if schema == nil || schema.Type != OBJECT {
+			continue
+		}
+		out = append(out, schema)
+		for _, keyword := range []string{"allOf", "anyOf", "oneOf"} {
+			if sub, found := FindObjectKey(schema, keyword); found && sub.Type == ARRAY {
+				out = flattenSchemas(sub.A, out)
+			}
+		}
+	}
+	return out
+}
+
+// pruneObject 删除对象中未声明的属性并递归修剪保留的属性
+func (p *pruner) pruneObject(v *Value, schemas []*Value) {
+	restricted := false // 是否有模式限制了属性
+	allowExtra := false // 是否有模式允许额外的属性
+	var extraSchemas []*Value
+	for _, schema := range schemas {
+		for _, keyword := range []string{"properties", "patternProperties"} {
+			if _, found := FindObjectKey(schema, keyword); found {
+				restricted = true
+			}
+		}
+		if additional, found := FindObjectKey(schema, "additionalProperties"); found {
+			restricted = true
+			switch additional.Type {
+			case TRUE:
+				allowExtra = true
+			case OBJECT:
+				allowExtra = true
+				extraSchemas = append(extraSchemas, additional)
+			}
+		}
+	}
+	if !restricted {
+		return
+	}
+
+	for i := 0; i < len(v.O); {
+		member := v.O[i]
+		propSchemas, declared := p.memberSchemas(schemas, member.K)
+		if !declared {
+			if !allowExtra {
+				RemoveObjectValue(v, i)
+				p.removed++
+				continue
+			}
+			propSchemas = extraSchemas
+		}
+		p.prune(member.V, propSchemas)
+		i++
+	}
+}
+
+// memberSchemas 返回 properties 和 patternProperties 中适用于属性 key 的模式
+func (p *pruner) memberSchemas(schemas []*Value, key string) ([]*Value, bool) {
+	var result []*Value
+	declared := false
+	for _, schema := range schemas {
+		if props, found := FindObjectKey(schema, "properties"); found && props.Type == OBJECT {
+			if propSchema, found := FindObjectKey(props, key); found {
+				declared = true
+				result = append(result, propSchema)
+			}
+		}
+		if patternProps, found := FindObjectKey(schema, "patternProperties"); found && patternProps.Type == OBJECT {
+			for _, patternProp := range patternProps.O {
+				re := p.compile(patternProp.K)
+				if re != nil && re.MatchString(key) {
+					declared = true
+					result = append(result, patternProp.V)
+				}
+			}
+		}
+	}
+	return result, declared
+}
+
+// compile 编译 patternProperties 中的正则表达式，无效的模式不匹配任何属性
+func (p *pruner) compile(pattern string) *regexp.Regexp {
+	if re, ok := p.patterns[pattern]; ok {
+		return re
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil && p.err == nil {
+		p.err = fmt.Errorf("无效的属性名模式: %s", pattern)
+	}
+	p.patterns[pattern] = re
+	return re
+}
+
+// pruneArray 按 items 和 additionalItems 递归修剪数组元素
+func (p *pruner) pruneArray(v *Value, schemas []*Value) {
+	for i, elem := range v.A {
+		var elemSchemas []*Value
+		for _, schema := range schemas {
+			items, found := FindObjectKey(schema, "items")
+			if !found {
+				continue
+			}
+			switch {
+			case items.Type == OBJECT:
+				elemSchemas = append(elemSchemas, items)
+			case items.Type == ARRAY && i < len(items.A):
+				elemSchemas = append(elemSchemas, items.A[i])
+			case items.Type == ARRAY:
+				if additional, found := FindObjectKey(schema, "additionalItems"); found {
+					elemSchemas = append(elemSchemas, additional)
+				}
+			}
+		}
+		p.prune(elem, elemSchemas)
+	}
+}
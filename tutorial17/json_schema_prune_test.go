@@ -0,0 +1,87 @@
+package leptjson
+
+import (
+	"testing"
+)
+
+func TestPrune(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		doc     string
+		removed int
+		want    string
+	}{
+		{"未声明的属性", `{"properties":{"id":{},"name":{}}}`,
+			`{"id":1,"name":"a","password":"x"}`, 1, `{"id":1,"name":"a"}`},
+		{"additionalProperties为true", `{"properties":{"id":{}},"additionalProperties":true}`,
+			`{"id":1,"x":2}`, 0, `{"id":1,"x":2}`},
+		{"additionalProperties为模式", `{"properties":{"id":{}},"additionalProperties":{"properties":{"a":{}}}}`,
+			`{"id":1,"x":{"a":1,"b":2}}`, 1, `{"id":1,"x":{"a":1}}`},
+		{"没有限制属性", `{"type":"object"}`, `{"a":{"b":1}}`, 0, `{"a":{"b":1}}`},
+		{"patternProperties", `{"patternProperties":{"^x-":{}},"additionalProperties":false}`,
+			`{"x-a":1,"b":2}`, 1, `{"x-a":1}`},
+		{"嵌套对象", `{"properties":{"user":{"properties":{"name":{}}}}}`,
+			`{"user":{"name":"a","token":"t"}}`, 1, `{"user":{"name":"a"}}`},
+		{"数组元素", `{"items":{"properties":{"id":{}}}}`,
+			`[{"id":1,"x":1},{"id":2,"y":2}]`, 2, `[{"id":1},{"id":2}]`},
+		{"元组和additionalItems", `{"items":[{"properties":{"a":{}}}],"additionalItems":{"properties":{"b":{}}}}`,
+			`[{"a":1,"b":1},{"a":2,"b":2}]`, 2, `[{"a":1},{"b":2}]`},
+		{"allOf取并集", `{"allOf":[{"properties":{"a":{}}},{"properties":{"b":{}}}]}`,
+			`{"a":1,"b":2,"c":3}`, 1, `{"a":1,"b":2}`},
+		{"同一属性的多个模式", `{"properties":{"o":{"properties":{"a":{}}}},"anyOf":[{"properties":{"o":{"properties":{"b":{}}}}}]}`,
+			`{"o":{"a":1,"b":2,"c":3}}`, 1, `{"o":{"a":1,"b":2}}`},
+		{"不做验证", `{"properties":{"id":{"type":"string"}}}`, `{"id":1}`, 0, `{"id":1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, doc := &Value{}, &Value{}
+			if err := Parse(schema, tt.schema); err != PARSE_OK {
+				t.Fatal(err)
+			}
+			if err := Parse(doc, tt.doc); err != PARSE_OK {
+				t.Fatal(err)
+			}
+			removed, err := Prune(doc, schema)
+			if err != nil {
+				t.Fatalf("Prune error = %v", err)
+			}
+			text, _ := Stringify(doc)
+			if removed != tt.removed || text != tt.want {
+				t.Errorf("Prune = %d, %s, 期望 %d, %s", removed, text, tt.removed, tt.want)
+			}
+		})
+	}
+}
+
+func TestPruneResultIsValid(t *testing.T) {
+	js, err := NewJSONSchema(`{"type":"object","properties":{"id":{"type":"number"}},"additionalProperties":false}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := &Value{}
+	Parse(doc, `{"id":1,"internal":true}`)
+	if js.Validate(doc).Valid {
+		t.Fatalf("修剪前不应该通过验证")
+	}
+	if _, err := js.Prune(doc); err != nil {
+		t.Fatal(err)
+	}
+	if result := js.Validate(doc); !result.Valid {
+		t.Errorf("修剪后应该通过验证: %v", result.Errors)
+	}
+}
+
+func TestPruneErrors(t *testing.T) {
+	doc, schema := &Value{}, &Value{}
+	Parse(doc, `{"a":1}`)
+	Parse(schema, `true`)
+	if _, err := Prune(doc, schema); err == nil {
+		t.Errorf("schema 不是对象时应该失败")
+	}
+
+	Parse(schema, `{"patternProperties":{"(":{}}}`)
+	if _, err := Prune(doc, schema); err == nil {
+		t.Errorf("无效的属性名模式应该报错")
+	}
+}
@@ -0,0 +1,314 @@
+// json_schema_ref.go - 解析 JSON Schema 中的 $ref，支持本地文件和远程 URL
+package leptjson
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SchemaLoader 根据 URI 加载 $ref 引用的 Schema 文档
+//
+// uri 不包含片段，可以是 http(s) URL、file URL 或本地文件路径。
+type SchemaLoader interface {
+	Load(uri string) (*Value, error)
+}
+
+// maxRemoteSchemaBytes 是远程 Schema 文档的最大字节数
+const maxRemoteSchemaBytes = 10 << 20
+
+// maxRefHops 是连续跟随 $ref 的最大次数，超过时认为引用形成了循环
+const maxRefHops = 32
+
+// URLSchemaLoader 从本地文件和 http(s) URL 加载 Schema
+//
+// 设置 CacheDir 后远程文档保存在该目录中，再次加载时直接读取缓存。
+// Offline 为 true 时不访问网络，缓存中没有的远程文档加载失败，
+// 这样在 CI 中验证的结果不依赖网络状态。
+type URLSchemaLoader struct {
+	Client   *http.Client // 为 nil 时使用 30 秒超时的默认客户端
+	CacheDir string       // 远程文档的磁盘缓存目录，为空时不缓存
+	Offline  bool         // 离线模式，只使用本地文件和缓存
+}
+
+// Load 实现 SchemaLoader 接口
+func (l *URLSchemaLoader) Load(uri string) (*Value, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("无效的 URI '%s': %w", uri, err)
+	}
+
+	var data []byte
+	switch u.Scheme {
+	case "http", "https":
+		data, err = l.loadRemote(uri)
+	case "file":
+		data, err = os.ReadFile(filepath.FromSlash(u.Path))
+	case "":
+		data, err = os.ReadFile(uri)
+	default:
+		return nil, fmt.Errorf("不支持的 URI 协议: %s", u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Value{}
+	if perr := Parse(doc, string(data)); perr != PARSE_OK {
+		return nil, fmt.Errorf("解析 '%s' 失败: %v", uri, perr)
+	}
+	return doc, nil
+}
+
+// loadRemote 从缓存或网络读取远程文档
+func (l *URLSchemaLoader) loadRemote(uri string) ([]byte, error) {
+	cacheFile := ""
+	if l.CacheDir != "" {
+		sum := sha256.Sum256([]byte(uri))
+		cacheFile = filepath.Join(l.CacheDir, hex.EncodeToString(sum[:])+".json")
+		if data, err := os.ReadFile(cacheFile); err == nil {
+			return data, nil
+		}
+	}
+	if l.Offline {
+		return nil, fmt.Errorf("离线模式下缓存中没有 '%s'", uri)
+	}
+
+	client := l.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Get(uri)
+	if err != nil {
+		return nil, fmt.Errorf("下载 '%s' 失败: %w", uri, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("下载 '%s' 失败: %s", uri, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteSchemaBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("下载 '%s' 失败: %w", uri, err)
+	}
+	if len(data) > maxRemoteSchemaBytes {
+		return nil, fmt.Errorf("'%s' 超过 %d 字节", uri, maxRemoteSchemaBytes)
+	}
+
+	if cacheFile != "" {
+		if err := writeSchemaCache(cacheFile, data); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// writeSchemaCache 先写临时文件再重命名，避免并发读取到不完整的缓存
+func writeSchemaCache(file string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return fmt.Errorf("创建缓存目录失败: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".schema-*")
+	if err != nil {
+		return fmt.Errorf("写入缓存失败: %w", err)
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), file)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("写入缓存失败: %w", err)
+	}
+	return nil
+}
+
+// SchemaFileURI 返回本地 Schema 文件的 file URL，用作解析相对 $ref 的基准
+func SchemaFileURI(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	abs = filepath.ToSlash(abs)
+	if !strings.HasPrefix(abs, "/") {
+		abs = "/" + abs // Windows 盘符路径
+	}
+	return (&url.URL{Scheme: "file", Path: abs}).String(), nil
+}
+
+// refResolver 解析 $ref 并记录已经加载的文档
+type refResolver struct {
+	loader SchemaLoader
+	root   *Value
+	docs   map[string]*Value // 不含片段的 URI 到文档
+	owner  map[*Value]string // Schema 节点所在文档的 URI
+}
+
+// newRefResolver 以 root 作为 baseURI 处的文档创建解析器，loader 为 nil 时只能解析文档内部的引用
+func newRefResolver(root *Value, baseURI string, loader SchemaLoader) *refResolver {
+	r := &refResolver{
+		loader: loader,
+		root:   root,
+		docs:   make(map[string]*Value),
+		owner:  make(map[*Value]string),
+	}
+	r.addDocument(stripFragment(baseURI), root)
+	return r
+}
+
+// addDocument 记录文档及其中每个节点所在的文档
+func (r *refResolver) addDocument(uri string, doc *Value) {
+	r.docs[uri] = doc
+	var walk func(v *Value)
+	walk = func(v *Value) {
+		if _, seen := r.owner[v]; seen {
+			return
+		}
+		r.owner[v] = uri
+		for _, m := range v.O {
+			walk(m.V)
+		}
+		for _, e := range v.A {
+			walk(e)
+		}
+	}
+	walk(doc)
+}
+
+// resolve 跟随 schema 的 $ref 直到得到不含 $ref 的 Schema
+//
+// Draft 7 中与 $ref 并列的关键字会被忽略，因此直接返回引用的目标。
+func (r *refResolver) resolve(schema *Value) (*Value, error) {
+	for hops := 0; ; hops++ {
+		ref := findObjectKey(schema, "$ref")
+		if ref == nil || ref.Type != STRING {
+			return schema, nil
+		}
+		if hops == maxRefHops {
+			return nil, fmt.Errorf("$ref '%s' 形成了循环", GetString(ref))
+		}
+		target, err := r.lookup(r.owner[schema], GetString(ref))
+		if err != nil {
+			return nil, err
+		}
+		schema = target
+	}
+}
+
+// lookup 相对于 base 解析引用 ref 并返回目标节点
+func (r *refResolver) lookup(base, ref string) (*Value, error) {
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return nil, fmt.Errorf("无效的 $ref '%s': %w", ref, err)
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return nil, fmt.Errorf("无效的基准 URI '%s': %w", base, err)
+	}
+	target := baseURL.ResolveReference(refURL)
+	fragment := target.Fragment
+	docURI := stripFragment(target.String())
+
+	doc, ok := r.docs[docURI]
+	if !ok {
+		if r.loader == nil {
+			return nil, fmt.Errorf("无法加载 $ref '%s': 没有配置 Schema 加载器", ref)
+		}
+		doc, err = r.loader.Load(docURI)
+		if err != nil {
+			return nil, fmt.Errorf("无法加载 $ref '%s': %w", ref, err)
+		}
+		r.addDocument(docURI, doc)
+	}
+
+	if fragment == "" {
+		return doc, nil
+	}
+	if !strings.HasPrefix(fragment, "/") {
+		return nil, fmt.Errorf("不支持的 $ref 片段 '%s'，片段必须是 JSON Pointer", fragment)
+	}
+	pointer, perr := ParseJSONPointer(fragment)
+	if perr != POINTER_OK {
+		return nil, fmt.Errorf("无效的 $ref '%s': %v", ref, perr)
+	}
+	node, perr := pointer.Get(doc)
+	if perr != POINTER_OK {
+		return nil, fmt.Errorf("$ref '%s' 指向的位置不存在", ref)
+	}
+	return node, nil
+}
+
+// preload 解析所有文档中的全部 $ref，远程文档在验证之前加载完毕
+//
+// 任何引用无法解析时返回错误，而不是在验证时跳过。
+func (r *refResolver) preload() error {
+	visited := make(map[*Value]bool)
+	var walk func(v *Value, key string) error
+	walk = func(v *Value, key string) error {
+		if visited[v] {
+			return nil
+		}
+		visited[v] = true
+		if v.Type == OBJECT {
+			if ref := findObjectKey(v, "$ref"); ref != nil && ref.Type == STRING {
+				target, err := r.resolve(v)
+				if err != nil {
+					return err
+				}
+				if err := walk(target, ""); err != nil {
+					return err
+				}
+			}
+		}
+		for _, m := range v.O {
+			// properties 等关键字的成员名是属性名，不是关键字
+			if schemaDataKeywords[m.K] && !schemaMapKeywords[key] {
+				continue
+			}
+			if err := walk(m.V, m.K); err != nil {
+				return err
+			}
+		}
+		for _, e := range v.A {
+			if err := walk(e, ""); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(r.root, "")
+}
+
+// schemaDataKeywords 是值为数据而不是 Schema 的关键字，其中的 $ref 不是引用
+var schemaDataKeywords = map[string]bool{
+	"const":    true,
+	"enum":     true,
+	"default":  true,
+	"examples": true,
+}
+
+// schemaMapKeywords 是值为名称到 Schema 的映射的关键字
+var schemaMapKeywords = map[string]bool{
+	"properties":        true,
+	"patternProperties": true,
+	"definitions":       true,
+	"$defs":             true,
+	"dependencies":      true,
+}
+
+// stripFragment 去掉 URI 中的片段
+func stripFragment(uri string) string {
+	if i := strings.IndexByte(uri, '#'); i >= 0 {
+		return uri[:i]
+	}
+	return uri
+}
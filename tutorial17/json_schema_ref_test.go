@@ -0,0 +1,166 @@
+package leptjson
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestLocalRef(t *testing.T) {
+	schema, data := &Value{}, &Value{}
+	Parse(schema, `{
+		"definitions": {
+			"positive": {"type": "number", "minimum": 1},
+			"alias": {"$ref": "#/definitions/positive"},
+			"node": {"type": "object", "properties": {"child": {"$ref": "#/definitions/node"}, "n": {"$ref": "#/definitions/alias"}}}
+		},
+		"$ref": "#/definitions/node"
+	}`)
+	Parse(data, `{"n": 1, "child": {"n": 0, "child": {"n": "x"}}}`)
+
+	result := validateWithSchema(schema, data)
+	expected := []string{
+		"位于'$.child.child.n'的值类型为'string'，而不是预期的'number'",
+		"位于'$.child.n'的数值0小于最小值1",
+	}
+	if strings.Join(result.Errors, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("CLI 验证器 = %q", result.Errors)
+	}
+
+	js, err := NewJSONSchemaFromValue(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lib := js.Validate(data); lib.Valid || len(lib.Errors) != 2 {
+		t.Errorf("JSONSchema 验证结果 = %+v", lib)
+	}
+}
+
+func TestRefErrors(t *testing.T) {
+	tests := []struct {
+		schema string
+		msg    string
+	}{
+		{`{"$ref": "#/definitions/missing"}`, "不存在"},
+		{`{"$ref": "#"}`, "循环"},
+		{`{"$ref": "other.json"}`, "没有配置 Schema 加载器"},
+		{`{"$ref": "#anchor"}`, "必须是 JSON Pointer"},
+	}
+	for _, tt := range tests {
+		schema, data := &Value{}, &Value{}
+		Parse(schema, tt.schema)
+		Parse(data, `1`)
+		result := validateWithSchema(schema, data)
+		if result.Valid || result.Issues[0].Keyword != "$ref" || !strings.Contains(result.Errors[0], tt.msg) {
+			t.Errorf("%s: %+v", tt.schema, result)
+		}
+		if _, err := ValidateWithOptions(schema, data, ValidationOptions{}); err == nil || !strings.Contains(err.Error(), tt.msg) {
+			t.Errorf("%s: ValidateWithOptions error = %v", tt.schema, err)
+		}
+	}
+}
+
+func TestDataKeywordsAreNotRefs(t *testing.T) {
+	schema, data := &Value{}, &Value{}
+	Parse(schema, `{"const": {"$ref": "missing.json"}, "properties": {"const": {"$ref": "#/definitions/n"}}, "definitions": {"n": {"type": "number"}}}`)
+	Parse(data, `{"$ref": "missing.json"}`)
+	result, err := ValidateWithOptions(schema, data, ValidationOptions{})
+	if err != nil || !result.Valid {
+		t.Errorf("结果 = %+v, %v", result, err)
+	}
+}
+
+func TestFileRef(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "common"), 0755)
+	os.WriteFile(filepath.Join(dir, "common", "types.json"),
+		[]byte(`{"definitions": {"id": {"type": "string", "pattern": "^[0-9]+$"}, "user": {"$ref": "user.json"}}}`), 0644)
+	os.WriteFile(filepath.Join(dir, "common", "user.json"),
+		[]byte(`{"type": "object", "required": ["name"]}`), 0644)
+	schemaFile := filepath.Join(dir, "schema.json")
+	os.WriteFile(schemaFile, []byte(`{"properties": {
+		"id": {"$ref": "common/types.json#/definitions/id"},
+		"owner": {"$ref": "common/types.json#/definitions/user"}
+	}}`), 0644)
+
+	schema := &Value{}
+	text, _ := os.ReadFile(schemaFile)
+	Parse(schema, string(text))
+	base, err := SchemaFileURI(schemaFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := &Value{}
+	Parse(data, `{"id": "a1", "owner": {}}`)
+	result, err := ValidateWithOptions(schema, data, ValidationOptions{Loader: &URLSchemaLoader{}, BaseURI: base})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Issues) != 2 || result.Issues[0].Keyword != "pattern" || result.Issues[1].Keyword != "required" {
+		t.Errorf("结果 = %+v", result.Issues)
+	}
+
+	js, err := NewJSONSchemaWithLoader(schema, base, &URLSchemaLoader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lib := js.Validate(data); len(lib.Errors) != 2 {
+		t.Errorf("JSONSchema 验证结果 = %+v", lib.Errors)
+	}
+}
+
+func TestRemoteRefCacheAndOffline(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Path != "/schemas/age.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"type": "integer", "minimum": 0}`))
+	}))
+	defer server.Close()
+
+	schema, data := &Value{}, &Value{}
+	Parse(schema, `{"properties": {"age": {"$ref": "`+server.URL+`/schemas/age.json"}}}`)
+	Parse(data, `{"age": -1}`)
+	cache := t.TempDir()
+
+	// 离线且没有缓存时失败
+	offline := &URLSchemaLoader{CacheDir: cache, Offline: true}
+	if _, err := ValidateWithOptions(schema, data, ValidationOptions{Loader: offline}); err == nil || !strings.Contains(err.Error(), "离线模式") {
+		t.Fatalf("离线模式应该失败: %v", err)
+	}
+	if requests != 0 {
+		t.Fatalf("离线模式不应该访问网络")
+	}
+
+	// 在线加载后写入缓存
+	result, err := ValidateWithOptions(schema, data, ValidationOptions{Loader: &URLSchemaLoader{CacheDir: cache}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Valid || result.Issues[0].Keyword != "minimum" {
+		t.Errorf("结果 = %+v", result)
+	}
+
+	// 之后离线也能使用缓存
+	result, err = ValidateWithOptions(schema, data, ValidationOptions{Loader: offline})
+	if err != nil || result.Valid {
+		t.Errorf("离线使用缓存 = %+v, %v", result, err)
+	}
+	if requests != 1 {
+		t.Errorf("请求次数 = %d, 期望 1", requests)
+	}
+
+	// 远程文档不存在时返回错误
+	Parse(schema, `{"$ref": "`+server.URL+`/missing.json"}`)
+	if _, err := ValidateWithOptions(schema, data, ValidationOptions{Loader: &URLSchemaLoader{}}); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("不存在的远程文档 error = %v", err)
+	}
+}
@@ -0,0 +1,255 @@
+// Package jsontest 提供在测试中比较JSON文档的断言
+//
+// 断言失败时输出结构化的差异（哪个路径新增、删除或改变了什么），而不是两段很长的JSON文本：
+//
+//	func TestHandler(t *testing.T) {
+//		jsontest.AssertEqualJSON(t, `{"id": 1, "tags": ["a", "b"]}`, body, jsontest.Options{
+//			IgnorePaths: []string{"$..updatedAt"},
+//		})
+//		jsontest.AssertSubset(t, `{"status": "ok"}`, body)
+//		jsontest.AssertMatchesSchema(t, schema, body)
+//	}
+//
+// 文档参数可以是JSON文本（string 或 []byte）、*leptjson.Value，
+// 或者其他能被 leptjson.MarshalValue 转换的 Go 值（如 map[string]interface{}）。
+package jsontest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	leptjson "github.com/Cactusinhand/go-json-tutorial/tutorial17"
+)
+
+// Options 控制 AssertEqualJSON 的比较方式，零值表示严格相等
+type Options struct {
+	leptjson.EqualOptions // 数值容差、忽略数组顺序、数字字符串和 Unicode 规范化
+
+	// 不比较的路径，JSONPath（以 $ 开头）或 JSON Pointer（以 / 开头），
+	// 两边文档中匹配的值在比较前被删除；不存在的 JSON Pointer 被忽略
+	IgnorePaths []string
+
+	// 报告差异时对象数组的元素按此键匹配，见 leptjson.CompareOptions.ArrayKey
+	ArrayKey string
+}
+
+// AssertEqualJSON 断言 got 与 want 相等，不相等时列出每一处差异
+//
+// opts 最多使用一个，省略时按 leptjson.Equal 严格比较。
+func AssertEqualJSON(t testing.TB, want, got interface{}, opts ...Options) {
+	t.Helper()
+	var o Options
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	w, ok := toValue(t, "want", want)
+	if !ok {
+		return
+	}
+	g, ok := toValue(t, "got", got)
+	if !ok {
+		return
+	}
+	if len(o.IgnorePaths) > 0 {
+		if w, ok = prune(t, w, o.IgnorePaths); !ok {
+			return
+		}
+		if g, ok = prune(t, g, o.IgnorePaths); !ok {
+			return
+		}
+	}
+	if leptjson.EqualWithOptions(w, g, o.EqualOptions) {
+		return
+	}
+
+	diffs, err := leptjson.CompareValuesWithOptions(w, g, leptjson.CompareOptions{
+		Normalization: o.Normalization,
+		ArrayKey:      o.ArrayKey,
+		LCSArrays:     true,
+	})
+	if err != nil {
+		t.Fatalf("比较JSON失败: %v", err)
+		return
+	}
+	var lines []string
+	for _, d := range diffs {
+		// 在容差等选项下相等的值不是导致失败的原因
+		if d.Left != nil && d.Right != nil && d.Kind != leptjson.DIFF_MOVED &&
+			leptjson.EqualWithOptions(d.Left, d.Right, o.EqualOptions) {
+			continue
+		}
+		lines = append(lines, d.Message)
+	}
+	t.Errorf("JSON 不相等（期望 → 实际）:\n%s\n期望:\n%s\n实际:\n%s",
+		formatLines(lines), format(w), format(g))
+}
+
+// AssertSubset 断言 expected 是 actual 的子集，列出缺少或不同的值
+//
+// expected 中对象的每个成员都必须出现在 actual 的同一对象中，值也是子集，
+// actual 可以有更多成员；数组的长度必须相同，元素按下标逐个是子集；其他值必须相等。
+// 适合只检查响应中关心的字段：
+//
+//	jsontest.AssertSubset(t, `{"user": {"name": "alice"}}`, body)
+func AssertSubset(t testing.TB, expected, actual interface{}) {
+	t.Helper()
+	e, ok := toValue(t, "expected", expected)
+	if !ok {
+		return
+	}
+	a, ok := toValue(t, "actual", actual)
+	if !ok {
+		return
+	}
+	var problems []string
+	subset(e, a, "$", &problems)
+	if len(problems) > 0 {
+		t.Errorf("JSON 不是子集:\n%s\n期望包含:\n%s\n实际:\n%s",
+			formatLines(problems), format(e), format(a))
+	}
+}
+
+// subset 检查 e 是否是 a 的子集，把不满足的位置追加到 problems
+func subset(e, a *leptjson.Value, path string, problems *[]string) {
+	if e.Type != a.Type || (e.Type != leptjson.OBJECT && e.Type != leptjson.ARRAY) {
+		if !leptjson.Equal(e, a) {
+			*problems = append(*problems, fmt.Sprintf("路径 %s: 期望 %s，实际 %s", path, compact(e), compact(a)))
+		}
+		return
+	}
+	if e.Type == leptjson.ARRAY {
+		if len(e.A) != len(a.A) {
+			*problems = append(*problems, fmt.Sprintf("路径 %s: 期望 %d 个元素，实际 %d 个", path, len(e.A), len(a.A)))
+			return
+		}
+		for i := range e.A {
+			subset(e.A[i], a.A[i], fmt.Sprintf("%s[%d]", path, i), problems)
+		}
+		return
+	}
+	for _, m := range e.O {
+		memberPath := path + "." + m.K
+		v := leptjson.GetObjectValueByKey(a, m.K)
+		if v == nil {
+			*problems = append(*problems, fmt.Sprintf("路径 %s: 缺少成员，期望 %s", memberPath, compact(m.V)))
+			continue
+		}
+		subset(m.V, v, memberPath, problems)
+	}
+}
+
+// AssertMatchesSchema 断言 doc 满足 JSON Schema，列出每个验证错误的路径和描述
+func AssertMatchesSchema(t testing.TB, schema, doc interface{}) {
+	t.Helper()
+	s, ok := toValue(t, "schema", schema)
+	if !ok {
+		return
+	}
+	d, ok := toValue(t, "doc", doc)
+	if !ok {
+		return
+	}
+	result, err := leptjson.ValidateWithOptions(s, d, leptjson.ValidationOptions{})
+	if err != nil {
+		t.Fatalf("验证失败: %v", err)
+		return
+	}
+	if result.Valid {
+		return
+	}
+	var lines []string
+	for _, issue := range result.Issues {
+		lines = append(lines, fmt.Sprintf("%s: %s (%s)", issue.Path, issue.Message, issue.Keyword))
+	}
+	if len(lines) == 0 {
+		lines = result.Errors
+	}
+	t.Errorf("JSON 不满足 Schema:\n%s\n文档:\n%s", formatLines(lines), format(d))
+}
+
+// toValue 把断言的参数转换为 JSON 值，失败时报告错误并返回 false
+func toValue(t testing.TB, name string, doc interface{}) (*leptjson.Value, bool) {
+	t.Helper()
+	switch d := doc.(type) {
+	case *leptjson.Value:
+		if d == nil {
+			t.Fatalf("%s 不能为 nil", name)
+			return nil, false
+		}
+		return d, true
+	case string:
+		return parse(t, name, d)
+	case []byte:
+		return parse(t, name, string(d))
+	default:
+		v, err := leptjson.MarshalValue(doc)
+		if err != nil {
+			t.Fatalf("无法把 %s 转换为JSON: %v", name, err)
+			return nil, false
+		}
+		return v, true
+	}
+}
+
+// parse 解析 JSON 文本，失败时报告错误并返回 false
+func parse(t testing.TB, name, text string) (*leptjson.Value, bool) {
+	t.Helper()
+	v := &leptjson.Value{}
+	if err := leptjson.Parse(v, text); err != leptjson.PARSE_OK {
+		t.Fatalf("解析 %s 失败: %v", name, err)
+		return nil, false
+	}
+	return v, true
+}
+
+// prune 返回删除了 paths 所匹配的值的副本
+func prune(t testing.TB, v *leptjson.Value, paths []string) (*leptjson.Value, bool) {
+	t.Helper()
+	copied := &leptjson.Value{}
+	leptjson.Copy(copied, v)
+	for _, path := range paths {
+		if strings.HasPrefix(path, "$") {
+			if _, err := leptjson.DeleteByPath(copied, path); err != nil {
+				t.Fatalf("无效的忽略路径 %s: %v", path, err)
+				return nil, false
+			}
+			continue
+		}
+		pointer, err := leptjson.NewJSONPointer(path)
+		if err != nil {
+			t.Fatalf("无效的忽略路径 %s: 必须是以 $ 开头的JSONPath或以 / 开头的JSON Pointer", path)
+			return nil, false
+		}
+		if len(pointer.Tokens) == 0 {
+			continue
+		}
+		if _, _, err := leptjson.ResolvePointer(copied, pointer); err == nil {
+			leptjson.PointerRemove(copied, pointer)
+		}
+	}
+	return copied, true
+}
+
+// format 返回缩进的 JSON 文本
+func format(v *leptjson.Value) string {
+	s, err := leptjson.StringifyWithOptions(v, leptjson.StringifyOptions{Indent: "  "})
+	if err != leptjson.STRINGIFY_OK {
+		return fmt.Sprintf("<无法序列化: %v>", err)
+	}
+	return s
+}
+
+// compact 返回紧凑的 JSON 文本
+func compact(v *leptjson.Value) string {
+	s, err := leptjson.Stringify(v)
+	if err != leptjson.STRINGIFY_OK {
+		return fmt.Sprintf("<无法序列化: %v>", err)
+	}
+	return s
+}
+
+// formatLines 把每一项缩进为一行
+func formatLines(lines []string) string {
+	return "  " + strings.Join(lines, "\n  ")
+}
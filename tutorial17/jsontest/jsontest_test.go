@@ -0,0 +1,135 @@
+package jsontest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	leptjson "github.com/Cactusinhand/go-json-tutorial/tutorial17"
+)
+
+// recorder 记录断言的失败信息而不使测试失败
+type recorder struct {
+	testing.TB
+	errors []string
+	fatal  bool
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.fatal = true
+	r.Errorf(format, args...)
+}
+
+func (r *recorder) output() string {
+	return strings.Join(r.errors, "\n")
+}
+
+func TestAssertEqualJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		want, got interface{}
+		opts      Options
+		pass      bool
+		contains  []string
+	}{
+		{"相同", `{"a": 1, "b": [1, 2]}`, `{"b":[1,2],"a":1}`, Options{}, true, nil},
+		{"值不同", `{"a": 1, "b": "x"}`, `{"a": 2, "b": "x"}`, Options{}, false, []string{"$.a", "1 vs 2"}},
+		{"缺少成员", `{"a": 1, "b": 2}`, `{"a": 1}`, Options{}, false, []string{"第一个JSON有键 'b'"}},
+		{"数组插入", `[1, 2, 3]`, `[1, 9, 2, 3]`, Options{}, false, []string{"第二个JSON有元素 9"}},
+		{"容差", `{"x": 1.0}`, `{"x": 1.0000001}`, Options{EqualOptions: leptjson.EqualOptions{FloatEpsilon: 1e-6}}, true, nil},
+		{"忽略顺序", `[1, 2, 3]`, `[3, 1, 2]`, Options{EqualOptions: leptjson.EqualOptions{IgnoreArrayOrder: true}}, true, nil},
+		{"忽略路径", `{"id": 1, "meta": {"at": "x"}}`, `{"id": 1, "meta": {"at": "y"}}`, Options{IgnorePaths: []string{"$..at"}}, true, nil},
+		{"忽略指针", `{"id": 1, "requestId": "a"}`, `{"id": 1, "requestId": "b"}`, Options{IgnorePaths: []string{"/requestId", "/missing"}}, true, nil},
+		{"按键匹配", `[{"id": 1, "v": "a"}, {"id": 2, "v": "b"}]`, `[{"id": 2, "v": "b"}, {"id": 1, "v": "c"}]`,
+			Options{ArrayKey: "id"}, false, []string{"id=1", "$[0].v"}},
+		{"Go 值", map[string]interface{}{"a": 1.0}, []byte(`{"a": 1}`), Options{}, true, nil},
+	}
+	for _, tt := range tests {
+		r := &recorder{TB: t}
+		AssertEqualJSON(r, tt.want, tt.got, tt.opts)
+		if pass := len(r.errors) == 0; pass != tt.pass {
+			t.Errorf("%s: 通过 = %v, 期望 %v\n%s", tt.name, pass, tt.pass, r.output())
+			continue
+		}
+		for _, s := range tt.contains {
+			if !strings.Contains(r.output(), s) {
+				t.Errorf("%s: 失败信息中没有 %q:\n%s", tt.name, s, r.output())
+			}
+		}
+	}
+}
+
+func TestAssertEqualJSONInvalid(t *testing.T) {
+	r := &recorder{TB: t}
+	AssertEqualJSON(r, `{"a":`, `{}`)
+	if !r.fatal || !strings.Contains(r.output(), "want") {
+		t.Errorf("无效的JSON 应该 Fatalf，得到 %q", r.output())
+	}
+
+	r = &recorder{TB: t}
+	AssertEqualJSON(r, `{}`, `{}`, Options{IgnorePaths: []string{"bad"}})
+	if !r.fatal {
+		t.Errorf("无效的忽略路径应该 Fatalf")
+	}
+}
+
+func TestAssertEqualJSONDoesNotModifyInput(t *testing.T) {
+	want := &leptjson.Value{}
+	leptjson.Parse(want, `{"id": 1, "at": "x"}`)
+	AssertEqualJSON(t, want, `{"id": 1}`, Options{IgnorePaths: []string{"/at"}})
+	if leptjson.GetObjectValueByKey(want, "at") == nil {
+		t.Errorf("忽略路径不应该修改传入的值")
+	}
+}
+
+func TestAssertSubset(t *testing.T) {
+	tests := []struct {
+		expected, actual string
+		pass             bool
+		contains         []string
+	}{
+		{`{"a": 1}`, `{"a": 1, "b": 2}`, true, nil},
+		{`{"u": {"name": "alice"}}`, `{"u": {"name": "alice", "age": 30}, "x": null}`, true, nil},
+		{`{"items": [{"id": 1}, {"id": 2}]}`, `{"items": [{"id": 1, "n": "a"}, {"id": 2, "n": "b"}]}`, true, nil},
+		{`{"a": 1, "c": 3}`, `{"a": 2}`, false, []string{"路径 $.a: 期望 1，实际 2", "路径 $.c: 缺少成员"}},
+		{`{"items": [1]}`, `{"items": [1, 2]}`, false, []string{"期望 1 个元素，实际 2 个"}},
+		{`{"a": {"b": 1}}`, `{"a": [1]}`, false, []string{"路径 $.a"}},
+		{`"x"`, `"x"`, true, nil},
+	}
+	for _, tt := range tests {
+		r := &recorder{TB: t}
+		AssertSubset(r, tt.expected, tt.actual)
+		if pass := len(r.errors) == 0; pass != tt.pass {
+			t.Errorf("AssertSubset(%s, %s): 通过 = %v, 期望 %v\n%s", tt.expected, tt.actual, pass, tt.pass, r.output())
+			continue
+		}
+		for _, s := range tt.contains {
+			if !strings.Contains(r.output(), s) {
+				t.Errorf("AssertSubset(%s, %s): 失败信息中没有 %q:\n%s", tt.expected, tt.actual, s, r.output())
+			}
+		}
+	}
+}
+
+func TestAssertMatchesSchema(t *testing.T) {
+	schema := `{"type": "object", "required": ["name"], "properties": {"age": {"type": "integer", "minimum": 0}}}`
+
+	r := &recorder{TB: t}
+	AssertMatchesSchema(r, schema, `{"name": "alice", "age": 3}`)
+	if len(r.errors) != 0 {
+		t.Errorf("有效的文档不应该失败: %s", r.output())
+	}
+
+	r = &recorder{TB: t}
+	AssertMatchesSchema(r, schema, `{"age": -1}`)
+	out := r.output()
+	if len(r.errors) != 1 || !strings.Contains(out, "(required)") || !strings.Contains(out, "$.age") {
+		t.Errorf("失败信息应该列出每个验证错误，得到:\n%s", out)
+	}
+}
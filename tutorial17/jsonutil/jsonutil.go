@@ -0,0 +1,247 @@
+// Package jsonutil 以库的形式提供 leptjson 命令行的常用操作
+//
+// 每个操作从 io.Reader 读取输入、向 io.Writer 写出结果，并返回结构化的结果，
+// 其他 Go 程序可以直接调用而不必启动 leptjson 进程：
+//
+//	err := jsonutil.Format(os.Stdout, file, jsonutil.FormatOptions{Indent: 4})
+//	result, err := jsonutil.Validate(schema, data, jsonutil.ValidateOptions{})
+//
+// 所有操作的 Parse 选项为 nil 时按 leptjson.DefaultParseOptions() 解析输入。
+package jsonutil
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	leptjson "github.com/Cactusinhand/go-json-tutorial/tutorial17"
+)
+
+// InputError 是读取或解析某个输入时的错误
+type InputError struct {
+	Input string // 输入的名字，如 "document"、"schema"、"patch"
+	Err   error
+}
+
+func (e *InputError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Input, e.Err)
+}
+
+func (e *InputError) Unwrap() error {
+	return e.Err
+}
+
+// read 读取并解析一个输入，返回值和输入的字节数
+func read(r io.Reader, name string, parse *leptjson.ParseOptions) (*leptjson.Value, int, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, 0, &InputError{Input: name, Err: err}
+	}
+	options := leptjson.DefaultParseOptions()
+	if parse != nil {
+		options = *parse
+	}
+	v := &leptjson.Value{}
+	if err := leptjson.ParseWithOptions(v, string(data), options); err != leptjson.PARSE_OK {
+		return nil, len(data), &InputError{Input: name, Err: err}
+	}
+	return v, len(data), nil
+}
+
+// FormatOptions 是 Format 的选项
+type FormatOptions struct {
+	Indent        int                    // 缩进的空格数，不大于 0 时为 2
+	KeyComparator leptjson.KeyComparator // 对象键排序规则，为 nil 时保持原有顺序
+	FinalNewline  bool                   // 在结果末尾加换行符
+	Parse         *leptjson.ParseOptions
+}
+
+// Format 读取JSON文档并以缩进格式写出
+func Format(w io.Writer, r io.Reader, options FormatOptions) error {
+	v, _, err := read(r, "document", options.Parse)
+	if err != nil {
+		return err
+	}
+	return writeFormatted(w, v, options)
+}
+
+// writeFormatted 按选项写出 v
+func writeFormatted(w io.Writer, v *leptjson.Value, options FormatOptions) error {
+	indent := options.Indent
+	if indent <= 0 {
+		indent = 2
+	}
+	text, err := leptjson.StringifyWithOptions(v, leptjson.StringifyOptions{
+		Indent:        strings.Repeat(" ", indent),
+		KeyComparator: options.KeyComparator,
+	})
+	if err != leptjson.STRINGIFY_OK {
+		return fmt.Errorf("格式化失败: %s", err)
+	}
+	if options.FinalNewline {
+		text += "\n"
+	}
+	_, werr := io.WriteString(w, text)
+	return werr
+}
+
+// Minify 读取JSON文档并以紧凑格式写出
+func Minify(w io.Writer, r io.Reader, parse *leptjson.ParseOptions) error {
+	v, _, err := read(r, "document", parse)
+	if err != nil {
+		return err
+	}
+	return leptjson.StringifyTo(w, v)
+}
+
+// Stats 读取JSON文档并返回统计信息，TotalSize 是输入的字节数
+func Stats(r io.Reader, parse *leptjson.ParseOptions) (leptjson.JSONStats, error) {
+	v, size, err := read(r, "document", parse)
+	if err != nil {
+		return leptjson.JSONStats{}, err
+	}
+	stats := leptjson.CalculateStats(v)
+	stats.TotalSize = int64(size)
+	return stats, nil
+}
+
+// CompareResult 是 Compare 的结果
+type CompareResult struct {
+	Equal       bool
+	Differences []leptjson.Difference // 从 left 到 right 的差异，路径为 JSONPath 形式
+}
+
+// Compare 读取并比较两个JSON文档
+func Compare(left, right io.Reader, parse *leptjson.ParseOptions) (*CompareResult, error) {
+	l, _, err := read(left, "left", parse)
+	if err != nil {
+		return nil, err
+	}
+	r, _, err := read(right, "right", parse)
+	if err != nil {
+		return nil, err
+	}
+	differences := leptjson.CompareValues(l, r)
+	return &CompareResult{Equal: len(differences) == 0, Differences: differences}, nil
+}
+
+// ValidateOptions 是 Validate 的选项
+type ValidateOptions struct {
+	// 验证选项，Translator 为 nil 时使用默认语言的错误描述
+	Validation leptjson.ValidationOptions
+	Parse      *leptjson.ParseOptions
+}
+
+// Validate 读取Schema和JSON文档并验证；文档不符合Schema不是错误，结果的 Valid 为 false
+func Validate(schema, data io.Reader, options ValidateOptions) (leptjson.ValidationResult, error) {
+	s, _, err := read(schema, "schema", options.Parse)
+	if err != nil {
+		return leptjson.ValidationResult{}, err
+	}
+	d, _, err := read(data, "document", options.Parse)
+	if err != nil {
+		return leptjson.ValidationResult{}, err
+	}
+	validation := options.Validation
+	if validation.Translator == nil {
+		validation.Translator, _ = leptjson.LookupValidationTranslator(leptjson.DefaultValidationLanguage)
+	}
+	return leptjson.ValidateWithOptions(s, d, validation)
+}
+
+// PatchOptions 是 Patch 和 PatchFile 的选项
+type PatchOptions struct {
+	Format FormatOptions // 修改后的文档的输出格式，其中的 Parse 用于解析文档和补丁
+}
+
+// PatchResult 是应用 JSON Patch 的结果
+type PatchResult struct {
+	Document    *leptjson.Value       // 修改后的文档
+	Inverse     *leptjson.JSONPatch   // 撤销这次修改的逆补丁
+	Differences []leptjson.Difference // 文档修改前后的差异
+}
+
+// Patch 读取文档和 JSON Patch (RFC 6902)，把应用补丁后的文档写到 w
+//
+// 补丁中任何一个操作失败时不写出任何内容，返回错误。
+func Patch(w io.Writer, doc, patch io.Reader, options PatchOptions) (*PatchResult, error) {
+	result, err := applyPatch(doc, patch, options)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFormatted(w, result.Document, options.Format); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// applyPatch 解析文档和补丁并应用
+func applyPatch(doc, patch io.Reader, options PatchOptions) (*PatchResult, error) {
+	v, _, err := read(doc, "document", options.Format.Parse)
+	if err != nil {
+		return nil, err
+	}
+	p, _, err := read(patch, "patch", options.Format.Parse)
+	if err != nil {
+		return nil, err
+	}
+	jsonPatch, err := leptjson.NewJSONPatch(p)
+	if err != nil {
+		return nil, &InputError{Input: "patch", Err: err}
+	}
+
+	before := &leptjson.Value{}
+	leptjson.Copy(before, v)
+	inverse, err := jsonPatch.ApplyWithInverse(v)
+	if err != nil {
+		return nil, err
+	}
+	return &PatchResult{
+		Document:    v,
+		Inverse:     inverse,
+		Differences: leptjson.CompareValues(before, v),
+	}, nil
+}
+
+// PatchFile 对文件应用 JSON Patch 并写回原文件
+//
+// 结果先写入同一目录中的临时文件再替换原文件，失败时原文件保持不变。
+func PatchFile(filename string, patch io.Reader, options PatchOptions) (*PatchResult, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	result, err := applyPatch(file, patch, options)
+	file.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	if err := writeFormatted(tmp, result.Document, options.Format); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
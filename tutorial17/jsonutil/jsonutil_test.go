@@ -0,0 +1,150 @@
+package jsonutil
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	leptjson "github.com/Cactusinhand/go-json-tutorial/tutorial17"
+)
+
+func TestFormatAndMinify(t *testing.T) {
+	var out bytes.Buffer
+	err := Format(&out, strings.NewReader(`{"b":1,"a":[true]}`), FormatOptions{
+		Indent:        4,
+		KeyComparator: leptjson.AlphabeticalKeyComparator,
+		FinalNewline:  true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n    \"a\": [\n        true\n    ],\n    \"b\": 1\n}\n"; out.String() != want {
+		t.Errorf("Format = %q, 期望 %q", out.String(), want)
+	}
+
+	out.Reset()
+	if err := Minify(&out, strings.NewReader("{ \"a\" : [ 1 , 2 ] }"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != `{"a":[1,2]}` {
+		t.Errorf("Minify = %q", out.String())
+	}
+
+	err = Minify(&out, strings.NewReader(`{"a":`), nil)
+	var inputErr *InputError
+	if !errors.As(err, &inputErr) || inputErr.Input != "document" {
+		t.Errorf("Minify 无效输入的错误 = %v, 期望 *InputError", err)
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	options := leptjson.DefaultParseOptions()
+	options.MaxDepth = 1
+	if err := Minify(&bytes.Buffer{}, strings.NewReader(`[[1]]`), &options); err == nil {
+		t.Error("超过 MaxDepth 时应返回错误")
+	}
+}
+
+func TestStats(t *testing.T) {
+	input := `{"a":[1,"x",null],"b":{"a":false}}`
+	stats, err := Stats(strings.NewReader(input), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.TotalSize != int64(len(input)) || stats.ObjectCount != 2 || stats.ArrayCount != 1 ||
+		stats.NumberCount != 1 || stats.StringCount != 1 || stats.NullCount != 1 || stats.BooleanCount != 1 ||
+		stats.KeyCount != 3 || stats.UniqueKeyCount != 2 || stats.MaxDepth != 2 {
+		t.Errorf("Stats = %+v", stats)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	result, err := Compare(strings.NewReader(`{"a":1,"b":2}`), strings.NewReader(`{"b":2,"a":1}`), nil)
+	if err != nil || !result.Equal {
+		t.Fatalf("Compare = %+v, %v", result, err)
+	}
+	result, err = Compare(strings.NewReader(`{"a":1}`), strings.NewReader(`{"a":2,"c":3}`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Equal || len(result.Differences) != 2 {
+		t.Errorf("Compare = %+v", result)
+	}
+	if _, err := Compare(strings.NewReader(`{}`), strings.NewReader(`[`), nil); err == nil || !strings.HasPrefix(err.Error(), "right:") {
+		t.Errorf("Compare 无效输入的错误 = %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	schema := `{"type":"object","required":["name"]}`
+	result, err := Validate(strings.NewReader(schema), strings.NewReader(`{"name":"x"}`), ValidateOptions{})
+	if err != nil || !result.Valid {
+		t.Fatalf("Validate = %+v, %v", result, err)
+	}
+	result, err = Validate(strings.NewReader(schema), strings.NewReader(`{}`), ValidateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Valid || len(result.Issues) != 1 || result.Issues[0].Keyword != "required" {
+		t.Errorf("Validate = %+v", result)
+	}
+}
+
+func TestPatch(t *testing.T) {
+	var out bytes.Buffer
+	result, err := Patch(&out, strings.NewReader(`{"a":1}`),
+		strings.NewReader(`[{"op":"replace","path":"/a","value":2},{"op":"add","path":"/b","value":true}]`),
+		PatchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  \"a\": 2,\n  \"b\": true\n}"; out.String() != want {
+		t.Errorf("Patch 输出 %q, 期望 %q", out.String(), want)
+	}
+	if len(result.Differences) != 2 {
+		t.Errorf("Differences = %+v", result.Differences)
+	}
+	if err := result.Inverse.Apply(result.Document); err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := leptjson.Stringify(result.Document); s != `{"a":1}` {
+		t.Errorf("应用逆补丁后 = %s", s)
+	}
+
+	out.Reset()
+	if _, err := Patch(&out, strings.NewReader(`{}`), strings.NewReader(`[{"op":"remove","path":"/x"}]`), PatchOptions{}); err == nil || out.Len() != 0 {
+		t.Errorf("补丁失败时应返回错误且不输出内容: %v, %q", err, out.String())
+	}
+}
+
+func TestPatchFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(file, []byte(`{"debug":false}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	options := PatchOptions{Format: FormatOptions{FinalNewline: true}}
+	if _, err := PatchFile(file, strings.NewReader(`[{"op":"replace","path":"/debug","value":true}]`), options); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(file)
+	if want := "{\n  \"debug\": true\n}\n"; string(data) != want {
+		t.Errorf("文件内容 = %q, 期望 %q", data, want)
+	}
+	if info, err := os.Stat(file); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("文件权限应保持不变: %v, %v", info.Mode(), err)
+	}
+
+	// 补丁失败时文件保持不变，也不留下临时文件
+	if _, err := PatchFile(file, strings.NewReader(`[{"op":"test","path":"/debug","value":false}]`), options); err == nil {
+		t.Error("test 操作失败时应返回错误")
+	}
+	if again, _ := os.ReadFile(file); !bytes.Equal(again, data) {
+		t.Errorf("补丁失败后文件被修改: %q", again)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(file)); len(entries) != 1 {
+		t.Errorf("目录中有多余的文件: %v", entries)
+	}
+}
@@ -0,0 +1,201 @@
+// key_case.go - 对象键命名风格转换
+package leptjson
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// KeyCase 表示对象键的命名风格
+type KeyCase int
+
+// 键命名风格常量
+const (
+	KEY_CASE_CAMEL  KeyCase = iota // camelCase
+	KEY_CASE_SNAKE                 // snake_case
+	KEY_CASE_KEBAB                 // kebab-case
+	KEY_CASE_PASCAL                // PascalCase
+)
+
+// String 返回命名风格的名称
+func (k KeyCase) String() string {
+	switch k {
+	case KEY_CASE_CAMEL:
+		return "camelCase"
+	case KEY_CASE_SNAKE:
+		return "snake_case"
+	case KEY_CASE_KEBAB:
+		return "kebab-case"
+	case KEY_CASE_PASCAL:
+		return "PascalCase"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseKeyCase 根据名称获取命名风格，支持 camel/camelCase、snake/snake_case、
+// kebab/kebab-case、pascal/PascalCase 等写法（不区分大小写）
+func ParseKeyCase(name string) (KeyCase, error) {
+	switch strings.ToLower(name) {
+	case "camel", "camelcase":
+		return KEY_CASE_CAMEL, nil
+	case "snake", "snake_case":
+		return KEY_CASE_SNAKE, nil
+	case "kebab", "kebab-case":
+		return KEY_CASE_KEBAB, nil
+	case "pascal", "pascalcase":
+		return KEY_CASE_PASCAL, nil
+	default:
+		return 0, fmt.Errorf("不支持的键命名风格: %s", name)
+	}
+}
+
+// ConvertKeys 递归地将对象的所有键转换为指定的命名风格
+//
+// 返回转换后的新值，不修改原始值。excludePaths 是 JSON Pointer 列表（使用原始键名），
+// 指向的成员会保留自己的键名，并且其子树不做任何转换。
+// 如果同一个对象中有多个键转换后重名，返回错误。
+func ConvertKeys(v *Value, style KeyCase, excludePaths ...string) (*Value, error) {
+	if v == nil {
+		return nil, fmt.Errorf("JSON 值不能为空")
+	}
+
+	excluded := make(map[string]bool, len(excludePaths))
+	for _, p := range excludePaths {
+		if _, err := ParseJSONPointer(p); err != POINTER_OK {
+			return nil, fmt.Errorf("无效的排除路径 '%s': %v", p, err)
+		}
+		excluded[p] = true
+	}
+
+	result := &Value{}
+	if err := convertKeysRecursive(result, v, style, "", excluded); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// convertKeysRecursive 将 src 转换后写入 dst，path 为 src 在原文档中的 JSON Pointer
+func convertKeysRecursive(dst, src *Value, style KeyCase, path string, excluded map[string]bool) error {
+	switch src.Type {
+	case ARRAY:
+		SetArray(dst, len(src.A))
+		for i, elem := range src.A {
+			elemPath := fmt.Sprintf("%s/%d", path, i)
+			newElem := &Value{}
+			if excluded[elemPath] {
+				Copy(newElem, elem)
+			} else if err := convertKeysRecursive(newElem, elem, style, elemPath, excluded); err != nil {
+				return err
+			}
+			dst.A = append(dst.A, newElem)
+		}
+	case OBJECT:
+		SetObject(dst)
+		seen := make(map[string]string, len(src.O))
+		for _, m := range src.O {
+			memberPath := path + "/" + escapeJSONPointerToken(m.K)
+			newKey := m.K
+			newValue := &Value{}
+			if excluded[memberPath] {
+				Copy(newValue, m.V)
+			} else {
+				newKey = ConvertKey(m.K, style)
+				if err := convertKeysRecursive(newValue, m.V, style, memberPath, excluded); err != nil {
+					return err
+				}
+			}
+
+			if original, exists := seen[newKey]; exists {
+				return fmt.Errorf("位于 '%s' 的键 '%s' 和 '%s' 转换后都为 '%s'", path, original, m.K, newKey)
+			}
+			seen[newKey] = m.K
+			dst.O = append(dst.O, Member{K: newKey, V: newValue})
+		}
+	default:
+		Copy(dst, src)
+	}
+	return nil
+}
+
+// ConvertKey 将单个键转换为指定的命名风格
+//
+// 单词边界包括 '_'、'-'、'.'、空格以及大小写变化（如 "HTTPServer" => "HTTP" + "Server"），
+// 数字跟随在前一个单词之后。不包含任何字母或数字的键保持不变。
+func ConvertKey(key string, style KeyCase) string {
+	words := splitKeyWords(key)
+	if len(words) == 0 {
+		return key
+	}
+
+	var sb strings.Builder
+	for i, word := range words {
+		switch style {
+		case KEY_CASE_SNAKE, KEY_CASE_KEBAB:
+			if i > 0 {
+				if style == KEY_CASE_SNAKE {
+					sb.WriteByte('_')
+				} else {
+					sb.WriteByte('-')
+				}
+			}
+			sb.WriteString(strings.ToLower(word))
+		case KEY_CASE_CAMEL:
+			if i == 0 {
+				sb.WriteString(strings.ToLower(word))
+			} else {
+				sb.WriteString(titleWord(word))
+			}
+		case KEY_CASE_PASCAL:
+			sb.WriteString(titleWord(word))
+		default:
+			return key
+		}
+	}
+	return sb.String()
+}
+
+// splitKeyWords 将键拆分为单词列表
+func splitKeyWords(key string) []string {
+	runes := []rune(key)
+	var words []string
+	var current []rune
+
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, string(current))
+			current = current[:0]
+		}
+	}
+
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			// 分隔符
+			flush()
+			continue
+		}
+
+		if unicode.IsUpper(r) && len(current) > 0 {
+			prev := runes[i-1]
+			// 小写或数字后跟大写：fooBar => foo Bar
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) {
+				flush()
+			} else if unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+				// 连续大写后跟小写：HTTPServer => HTTP Server
+				flush()
+			}
+		}
+		current = append(current, r)
+	}
+	flush()
+
+	return words
+}
+
+// titleWord 将单词首字母大写，其余字母小写
+func titleWord(word string) string {
+	runes := []rune(strings.ToLower(word))
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
@@ -0,0 +1,131 @@
+package leptjson
+
+import (
+	"testing"
+)
+
+func TestConvertKey(t *testing.T) {
+	tests := []struct {
+		key   string
+		style KeyCase
+		want  string
+	}{
+		{"user_name", KEY_CASE_CAMEL, "userName"},
+		{"user-name", KEY_CASE_PASCAL, "UserName"},
+		{"userName", KEY_CASE_SNAKE, "user_name"},
+		{"UserName", KEY_CASE_KEBAB, "user-name"},
+		{"HTTPServer", KEY_CASE_SNAKE, "http_server"},
+		{"userID", KEY_CASE_SNAKE, "user_id"},
+		{"address2Line", KEY_CASE_KEBAB, "address2-line"},
+		{"  first name ", KEY_CASE_CAMEL, "firstName"},
+		{"already_snake", KEY_CASE_SNAKE, "already_snake"},
+		{"$$", KEY_CASE_CAMEL, "$$"},
+		{"", KEY_CASE_PASCAL, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key+"=>"+tt.style.String(), func(t *testing.T) {
+			if got := ConvertKey(tt.key, tt.style); got != tt.want {
+				t.Errorf("ConvertKey(%q, %s) = %q, 期望 %q", tt.key, tt.style, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseKeyCase(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    KeyCase
+		wantErr bool
+	}{
+		{"camel", KEY_CASE_CAMEL, false},
+		{"snake_case", KEY_CASE_SNAKE, false},
+		{"Kebab", KEY_CASE_KEBAB, false},
+		{"PascalCase", KEY_CASE_PASCAL, false},
+		{"upper", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseKeyCase(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseKeyCase(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("ParseKeyCase(%q) = %s, 期望 %s", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		style    KeyCase
+		excludes []string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "递归转换",
+			input:    `{"first_name":"a","home_address":{"zip_code":1},"tag_list":[{"tag_name":"x"}]}`,
+			style:    KEY_CASE_CAMEL,
+			expected: `{"firstName":"a","homeAddress":{"zipCode":1},"tagList":[{"tagName":"x"}]}`,
+		},
+		{
+			name:     "排除路径保留子树",
+			input:    `{"userName":"a","rawData":{"keepMe":{"innerKey":1}},"items":[{"itemId":1},{"itemId":2}]}`,
+			style:    KEY_CASE_SNAKE,
+			excludes: []string{"/rawData/keepMe", "/items/1"},
+			expected: `{"user_name":"a","raw_data":{"keepMe":{"innerKey":1}},"items":[{"item_id":1},{"itemId":2}]}`,
+		},
+		{
+			name:     "非对象值",
+			input:    `[1,"some_string",null]`,
+			style:    KEY_CASE_PASCAL,
+			expected: `[1,"some_string",null]`,
+		},
+		{
+			name:    "键冲突",
+			input:   `{"user_name":1,"userName":2}`,
+			style:   KEY_CASE_SNAKE,
+			wantErr: true,
+		},
+		{
+			name:     "无效的排除路径",
+			input:    `{"a":1}`,
+			style:    KEY_CASE_SNAKE,
+			excludes: []string{"a"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &Value{}
+			if err := Parse(v, tt.input); err != PARSE_OK {
+				t.Fatalf("解析输入失败: %s", err.Error())
+			}
+			original, _ := Stringify(v)
+
+			got, err := ConvertKeys(v, tt.style, tt.excludes...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConvertKeys() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			gotStr, _ := Stringify(got)
+			if gotStr != tt.expected {
+				t.Errorf("ConvertKeys() = %s, 期望 %s", gotStr, tt.expected)
+			}
+
+			// 原始值不应被修改
+			if after, _ := Stringify(v); after != original {
+				t.Errorf("原始值被修改: %s, 期望 %s", after, original)
+			}
+		})
+	}
+}
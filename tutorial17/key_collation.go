@@ -0,0 +1,120 @@
+// key_collation.go - 面向人阅读的键排序规则：自然排序和语言排序
+package leptjson
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NaturalKeyComparator 按自然顺序比较键，连续的数字按数值比较
+//
+// 例如 "item2" 排在 "item10" 之前，而字典序正好相反。数值相同时前导零少的在前
+// （"1" 在 "01" 之前），其余部分按字节比较。
+func NaturalKeyComparator(a, b string) bool {
+	return compareNatural(a, b) < 0
+}
+
+// compareNatural 按自然顺序比较 a 和 b，返回 -1、0 或 1
+func compareNatural(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if isDigit(a[i]) && isDigit(b[j]) {
+			si, sj := i, j
+			for i < len(a) && isDigit(a[i]) {
+				i++
+			}
+			for j < len(b) && isDigit(b[j]) {
+				j++
+			}
+			// 去掉前导零后，位数少的数值小，位数相同时按字节比较
+			na, nb := strings.TrimLeft(a[si:i], "0"), strings.TrimLeft(b[sj:j], "0")
+			if len(na) != len(nb) {
+				return compareInts(len(na), len(nb))
+			}
+			if c := strings.Compare(na, nb); c != 0 {
+				return c
+			}
+			continue
+		}
+		if a[i] != b[j] {
+			return compareInts(int(a[i]), int(b[j]))
+		}
+		i++
+		j++
+	}
+	if c := compareInts(len(a)-i, len(b)-j); c != 0 {
+		return c
+	}
+	// 只有前导零不同
+	if c := compareInts(len(a), len(b)); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// CollationKeyComparator 按语言习惯比较键，适合包含大小写和带重音字母的键
+//
+// 比较分三个层级：先忽略大小写和重音比较字母（"apple" < "Banana" < "cherry"），
+// 相同时不带重音的在前（"resume" < "résumé"），再相同时小写在前（"a" < "A"）。
+//
+// 它没有使用 golang.org/x/text/collate（本模块只依赖标准库），而是基于 NFD 分解和
+// Unicode 大小写映射的近似规则，不区分语言：
+//   - 能分解出组合重音的字母（拉丁、希腊、西里尔字母等，如 "é"、"ά"、"ё"、"ệ"）按上述规则排序
+//   - 不能分解的字母按码点排序，如 "ł" 和 "ø" 排在 "z" 之后
+//   - 没有扩展和缩约，"ß" 不等同于 "ss"，捷克语的 "ch" 也不作为一个字母
+//   - 没有特定语言的定制（如瑞典语中 "ä" 排在 "z" 之后），中日韩文字按码点排序
+func CollationKeyComparator(a, b string) bool {
+	return compareCollated(a, b) < 0
+}
+
+// compareCollated 按 CollationKeyComparator 的规则比较，返回 -1、0 或 1
+func compareCollated(a, b string) int {
+	if a == b {
+		return 0
+	}
+	da, db := NormalizeString(a, NORMALIZE_NFD), NormalizeString(b, NORMALIZE_NFD)
+	for _, level := range []func(rune) rune{collationPrimary, collationSecondary, swapCase} {
+		if c := strings.Compare(strings.Map(level, da), strings.Map(level, db)); c != 0 {
+			return c
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+// collationPrimary 去掉组合字符（重音）并转换为小写
+func collationPrimary(r rune) rune {
+	if unicode.Is(unicode.Mn, r) {
+		return -1
+	}
+	return unicode.ToLower(r)
+}
+
+// collationSecondary 只保留组合字符（重音），其余字符替换为 0
+//
+// 第一层级相同时两个字符串的基本字母一一对应，每个字母之前的 0 把重音分到各自的字母，
+// 没有重音的字母因此排在带重音的之前，与组合字符和下一个字母的编码大小无关。
+func collationSecondary(r rune) rune {
+	if unicode.Is(unicode.Mn, r) {
+		return r
+	}
+	return 0
+}
+
+// swapCase 交换大小写，使小写字母排在对应的大写字母之前
+func swapCase(r rune) rune {
+	if unicode.IsUpper(r) {
+		return unicode.ToLower(r)
+	}
+	return unicode.ToUpper(r)
+}
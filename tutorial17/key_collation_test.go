@@ -0,0 +1,79 @@
+package leptjson
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestNaturalKeyComparator(t *testing.T) {
+	keys := []string{"item10", "item2", "item1", "item02", "Item3", "item", "v1.10", "v1.9", "x"}
+	sort.SliceStable(keys, func(i, j int) bool { return NaturalKeyComparator(keys[i], keys[j]) })
+	want := []string{"Item3", "item", "item1", "item2", "item02", "item10", "v1.9", "v1.10", "x"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("自然排序 = %q, 期望 %q", keys, want)
+	}
+	if NaturalKeyComparator("a1", "a1") {
+		t.Error("相同的键不应排在前面")
+	}
+}
+
+func TestCollationKeyComparator(t *testing.T) {
+	keys := []string{"cherry", "Banana", "résumé", "apple", "resume", "Resume", "Äpfel", "zebra"}
+	sort.SliceStable(keys, func(i, j int) bool { return CollationKeyComparator(keys[i], keys[j]) })
+	want := []string{"Äpfel", "apple", "Banana", "cherry", "resume", "Resume", "résumé", "zebra"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("语言排序 = %q, 期望 %q", keys, want)
+	}
+	// 组合与分解形式在前三个层级上相同，都排在不带重音的形式之后
+	composed, decomposed := "r\u00e9sum\u00e9", "re\u0301sume\u0301"
+	if compareCollated(composed, decomposed) == 0 || !CollationKeyComparator("resume", decomposed) {
+		t.Error("分解形式的重音排序错误")
+	}
+}
+
+func TestCollationKeyComparatorScripts(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []string
+		want []string
+	}{
+		{"希腊字母", []string{"Ωμέγα", "άλφα", "Άλφα", "αλφα", "βήτα", "Βήτα"},
+			[]string{"αλφα", "άλφα", "Άλφα", "βήτα", "Βήτα", "Ωμέγα"}},
+		{"西里尔字母", []string{"ёлка", "Елка", "елка", "жук", "Ёж", "дом"},
+			[]string{"дом", "Ёж", "елка", "Елка", "ёлка", "жук"}},
+		{"越南语的多个重音", []string{"Việt", "viet", "việt", "Vương", "vuong", "văn"},
+			[]string{"văn", "viet", "việt", "Việt", "vuong", "Vương"}},
+		// 文档中说明的限制：不能分解的字母和 ß 按码点排序
+		{"不能分解的字母", []string{"łódź", "lodz", "zebra", "straße", "strasse", "strasze"},
+			[]string{"lodz", "strasse", "strasze", "straße", "zebra", "łódź"}},
+	}
+	for _, tt := range tests {
+		keys := append([]string(nil), tt.keys...)
+		sort.SliceStable(keys, func(i, j int) bool { return CollationKeyComparator(keys[i], keys[j]) })
+		if !reflect.DeepEqual(keys, tt.want) {
+			t.Errorf("%s: %q, 期望 %q", tt.name, keys, tt.want)
+		}
+	}
+}
+
+func TestSortKeysByName(t *testing.T) {
+	v := &Value{}
+	if err := Parse(v, `{"b10":1,"B2":2,"a":3}`); err != PARSE_OK {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"alpha":   `{"B2":2,"a":3,"b10":1}`,
+		"natural": `{"B2":2,"a":3,"b10":1}`,
+		"collate": `{"a":3,"b10":1,"B2":2}`,
+	}
+	for name, want := range tests {
+		cmp, ok := LookupKeyComparator(name)
+		if !ok {
+			t.Fatalf("%s 未注册", name)
+		}
+		if got, _ := StringifyWithOptions(v, StringifyOptions{KeyComparator: cmp}); got != want {
+			t.Errorf("%s: %s, 期望 %s", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+// key_filter.go - 解析时按允许/禁止列表过滤对象成员
+package leptjson
+
+import "path"
+
+// keyFiltered 判断键是否因 options 的 DeniedKeys 或 AllowedKeys 被过滤，root 表示成员属于根对象
+//
+// DeniedKeys 作用于每一层对象，优先于 AllowedKeys；AllowedKeys 只作用于根对象的成员，
+// 保留的成员的值整体保留，否则 {"a":{"b":1}} 只允许 a 时会得到 {"a":{}}。
+// 与解析后再删除相比，被过滤的成员从不进入结果，也不计入 MaxObjectSize，
+// 不会因为遗漏某一层的清理而泄漏。
+func keyFiltered(options *ParseOptions, key string, root bool) bool {
+	if len(options.DeniedKeys) > 0 && matchKeyPattern(options.DeniedKeys, key) {
+		return true
+	}
+	return root && len(options.AllowedKeys) > 0 && !matchKeyPattern(options.AllowedKeys, key)
+}
+
+// matchKeyPattern 判断键是否与任一模式精确相等或匹配其通配符，无效的模式只做精确匹配
+func matchKeyPattern(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if pattern == key {
+			return true
+		}
+		if matched, err := path.Match(pattern, key); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
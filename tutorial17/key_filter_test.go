@@ -0,0 +1,64 @@
+package leptjson
+
+import "testing"
+
+func TestKeyFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		denied   []string
+		allowed  []string
+		json     string
+		expected string
+	}{
+		{"禁止的键", []string{"__proto__", "*password*"}, nil,
+			`{"name":"a","__proto__":{"admin":true},"user":{"password":"x","old_password":"y","id":1}}`,
+			`{"name":"a","user":{"id":1}}`},
+		{"数组中的对象", []string{"secret"}, nil, `[{"secret":1,"a":2},{"secret":[{"secret":3}]}]`, `[{"a":2},{}]`},
+		{"允许的键", nil, []string{"id", "tags"}, `{"id":1,"name":"x","tags":["a"]}`, `{"id":1,"tags":["a"]}`},
+		{"允许的键只作用于根对象", nil, []string{"a"}, `{"a":{"b":1,"c":[{"d":2}]},"x":{"b":1}}`, `{"a":{"b":1,"c":[{"d":2}]}}`},
+		{"根是数组时不按允许的键过滤", nil, []string{"a"}, `[{"a":1,"b":2}]`, `[{"a":1,"b":2}]`},
+		{"禁止优先于允许", []string{"id"}, []string{"i?"}, `{"id":1,"ix":2}`, `{"ix":2}`},
+		{"无效的模式只做精确匹配", []string{"[a"}, nil, `{"[a":1,"a":2}`, `{"a":2}`},
+	}
+	for _, tt := range tests {
+		options := DefaultParseOptions()
+		options.DeniedKeys = tt.denied
+		options.AllowedKeys = tt.allowed
+		v := &Value{}
+		if err := ParseWithOptions(v, tt.json, options); err != PARSE_OK {
+			t.Errorf("%s: 解析失败: %v", tt.name, err)
+			continue
+		}
+		if got, _ := Stringify(v); got != tt.expected {
+			t.Errorf("%s: %s, 期望 %s", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestKeyFilterReject(t *testing.T) {
+	options := DefaultParseOptions()
+	options.DeniedKeys = []string{"__proto__"}
+	options.RejectFilteredKeys = true
+	v := &Value{}
+	if err := ParseWithOptions(v, `{"a":{"__proto__":{}}}`, options); err != PARSE_KEY_NOT_ALLOWED {
+		t.Errorf("错误 = %v, 期望 PARSE_KEY_NOT_ALLOWED", err)
+	}
+	if err := ParseWithOptions(v, `{"a":{"b":1}}`, options); err != PARSE_OK {
+		t.Errorf("没有被过滤的键: %v", err)
+	}
+}
+
+func TestKeyFilterStillChecksSyntaxAndLimits(t *testing.T) {
+	options := DefaultParseOptions()
+	options.DeniedKeys = []string{"skip"}
+	v := &Value{}
+	if err := ParseWithOptions(v, `{"skip":[1,}`, options); err == PARSE_OK {
+		t.Error("被跳过的值仍然需要是合法的JSON")
+	}
+
+	// 被跳过的成员不计入 MaxObjectSize
+	options.MaxObjectSize = 1
+	if err := ParseWithOptions(v, `{"skip":1,"a":2,"skip":3}`, options); err != PARSE_OK {
+		t.Errorf("解析失败: %v", err)
+	}
+}
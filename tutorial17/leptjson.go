@@ -6,9 +6,13 @@ import (
 
 	"bytes"
 	"fmt"
+	"io"
+	"math"
 	"reflect" // 引入 reflect 包
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // ValueType 表示JSON值的类型
@@ -53,7 +57,8 @@ type StringifyError int
 
 // 字符串化错误常量
 const (
-	STRINGIFY_OK StringifyError = iota // 字符串化成功
+	STRINGIFY_OK        StringifyError = iota // 字符串化成功
+	STRINGIFY_CANCELLED                       // 字符串化被取消
 )
 
 // Member 表示对象的成员（键值对）
@@ -69,6 +74,8 @@ type Value struct {
 	S    string    `json:"s"`    // 字符串值（当Type为STRING时有效）
 	A    []*Value  `json:"a"`    // 数组值（当Type为ARRAY时有效）
 	O    []Member  `json:"o"`    // 对象值（当Type为OBJECT时有效）
+
+	chunks *stringChunks // 分块保存的字符串，不为nil时S为空，见 ParseOptions.StringChunkThreshold
 }
 
 // String 返回Value的字符串表示
@@ -83,7 +90,7 @@ func (v Value) String() string {
 	case NUMBER:
 		return strconv.FormatFloat(v.N, 'f', -1, 64)
 	case STRING:
-		return "\"" + v.S + "\""
+		return "\"" + GetString(&v) + "\""
 	case ARRAY:
 		var sb strings.Builder
 		sb.WriteString("[")
@@ -127,8 +134,31 @@ func Parse(v *Value, json string) ParseError {
 // 3. 解析JSON值
 // 4. 跳过后续空白字符
 // 5. 检查是否还有额外内容（这将导致PARSE_ROOT_NOT_SINGULAR错误）
+//
+// 通过 SetLogger 设置日志或通过 SetMetrics 设置指标后，每次解析都会被记录。
 func ParseWithOptions(v *Value, json string, options ParseOptions) ParseError {
-	c := newContext(json, options)
+	return parseObserved(newContext(json, options), v)
+}
+
+// parseObserved 解析 c 中的JSON文本并按需记录日志和指标
+func parseObserved(c *parseContext, v *Value) ParseError {
+	logger, metrics := currentLogger(), currentMetrics()
+	if logger == nil && metrics == nil {
+		return parseRoot(c, v)
+	}
+	start := time.Now()
+	err := parseRoot(c, v)
+	if metrics != nil {
+		metrics.ObserveParse(len(c.json), time.Since(start), err)
+	}
+	if logger != nil {
+		logParse(logger, len(c.json), start, v, err)
+	}
+	return err
+}
+
+// parseRoot 解析 c 中完整的JSON文本
+func parseRoot(c *parseContext, v *Value) ParseError {
 	v.Type = NULL // 初始化为NULL类型
 
 	// 检查输入总大小（安全检查）
@@ -148,6 +178,10 @@ func ParseWithOptions(v *Value, json string, options ParseOptions) ParseError {
 
 	// 跳过尾部空白字符和注释
 	c.parseWhitespace()
+	if c.options.Comments != nil && len(c.pendingComments) > 0 {
+		c.options.Comments.Footer = append(c.options.Comments.Footer, c.pendingComments...)
+		c.pendingComments = nil
+	}
 
 	// 检查是否有多余内容
 	if c.index < len(c.json) {
@@ -260,6 +294,10 @@ func parseNumber(c *parseContext, v *Value) ParseError {
 
 	// 解析完成，将JSON文本中的数字子串转换为浮点数
 	numStr := c.json[startIndex:c.index]
+	// 自定义的数字处理函数自行决定如何保存数字，不再进行范围检查
+	if c.options.NumberHandler != nil {
+		return c.options.NumberHandler(v, numStr)
+	}
 	num, err := strconv.ParseFloat(numStr, 64)
 	if err != nil {
 		// 可能是数字太大等原因导致的转换失败
@@ -283,6 +321,18 @@ func parseNumber(c *parseContext, v *Value) ParseError {
 //
 // 解析双引号包围的字符串并处理转义序列
 func parseString(c *parseContext, v *Value) ParseError {
+	if threshold := c.options.StringChunkThreshold; threshold > 0 && c.options.Normalization == NORMALIZE_NONE {
+		if end := c.stringEnd(); end-c.index > threshold {
+			return parseChunkedString(c, v)
+		}
+	}
+	if c.trusted {
+		if str, ok := c.scanPlainString(); ok {
+			v.Type = STRING
+			v.S = NormalizeString(str, c.options.Normalization)
+			return PARSE_OK
+		}
+	}
 	var sb strings.Builder
 	err := parseStringRaw(c, nil, &sb)
 	if err != PARSE_OK {
@@ -290,7 +340,7 @@ func parseString(c *parseContext, v *Value) ParseError {
 	}
 
 	// 获取解析后的字符串值
-	result := sb.String()
+	result := NormalizeString(sb.String(), c.options.Normalization)
 
 	// 添加字符串长度安全检查
 	if ok, errInfo := c.checkStringLength(len(result)); !ok {
@@ -311,6 +361,12 @@ func parseStringRaw(c *parseContext, s *string, sb *strings.Builder) ParseError
 	if c.peekChar() != '"' {
 		return PARSE_MISS_QUOTATION_MARK
 	}
+	if c.trusted && s != nil {
+		if str, ok := c.scanPlainString(); ok {
+			*s = str
+			return PARSE_OK
+		}
+	}
 	c.nextChar() // 跳过开始的双引号
 
 	for c.index < len(c.json) {
@@ -322,109 +378,14 @@ func parseStringRaw(c *parseContext, s *string, sb *strings.Builder) ParseError
 			}
 			return PARSE_OK
 		case '\\': // 转义序列
-			if c.index >= len(c.json) {
-				return PARSE_INVALID_STRING_ESCAPE
-			}
-			switch c.peekChar() {
-			case '"', '\\':
-				sb.WriteByte(c.nextChar())
-			case 'b':
-				c.nextChar()
-				sb.WriteByte('\b')
-			case 'f':
-				c.nextChar()
-				sb.WriteByte('\f')
-			case 'n':
-				c.nextChar()
-				sb.WriteByte('\n')
-			case 'r':
-				c.nextChar()
-				sb.WriteByte('\r')
-			case 't':
-				c.nextChar()
-				sb.WriteByte('\t')
-			case 'u': // Unicode
-				c.nextChar() // 跳过'u'
-				// 解析4位十六进制数字
-				if c.index+3 >= len(c.json) {
-					return PARSE_INVALID_UNICODE_HEX
-				}
-
-				// 解析高代理项（surrogate high）
-				var codepoint uint32
-				for i := 0; i < 4; i++ {
-					ch := c.nextChar()
-					codepoint <<= 4
-					if ch >= '0' && ch <= '9' {
-						codepoint |= uint32(ch - '0')
-					} else if ch >= 'A' && ch <= 'F' {
-						codepoint |= uint32(ch - 'A' + 10)
-					} else if ch >= 'a' && ch <= 'f' {
-						codepoint |= uint32(ch - 'a' + 10)
-					} else {
-						return PARSE_INVALID_UNICODE_HEX
-					}
-				}
-
-				// 检查是否是代理对的高位部分
-				if codepoint >= 0xD800 && codepoint <= 0xDBFF {
-					// 确保后面跟着低代理项
-					if c.index+5 >= len(c.json) {
-						return PARSE_INVALID_UNICODE_SURROGATE
-					}
-					if c.nextChar() != '\\' || c.nextChar() != 'u' {
-						return PARSE_INVALID_UNICODE_SURROGATE
-					}
-
-					// 解析低代理项（surrogate low）
-					var lowSurrogate uint32
-					for i := 0; i < 4; i++ {
-						ch := c.nextChar()
-						lowSurrogate <<= 4
-						if ch >= '0' && ch <= '9' {
-							lowSurrogate |= uint32(ch - '0')
-						} else if ch >= 'A' && ch <= 'F' {
-							lowSurrogate |= uint32(ch - 'A' + 10)
-						} else if ch >= 'a' && ch <= 'f' {
-							lowSurrogate |= uint32(ch - 'a' + 10)
-						} else {
-							return PARSE_INVALID_UNICODE_HEX
-						}
-					}
-
-					// 验证是否是有效的低代理项
-					if lowSurrogate < 0xDC00 || lowSurrogate > 0xDFFF {
-						return PARSE_INVALID_UNICODE_SURROGATE
-					}
-
-					// 组合高低代理项计算实际的Unicode码点
-					codepoint = 0x10000 + ((codepoint - 0xD800) << 10) + (lowSurrogate - 0xDC00)
-				}
-
-				// 将Unicode码点编码为UTF-8
-				if codepoint <= 0x7F {
-					sb.WriteByte(byte(codepoint & 0xFF))
-				} else if codepoint <= 0x7FF {
-					sb.WriteByte(byte(0xC0 | ((codepoint >> 6) & 0xFF)))
-					sb.WriteByte(byte(0x80 | (codepoint & 0x3F)))
-				} else if codepoint <= 0xFFFF {
-					sb.WriteByte(byte(0xE0 | ((codepoint >> 12) & 0xFF)))
-					sb.WriteByte(byte(0x80 | ((codepoint >> 6) & 0x3F)))
-					sb.WriteByte(byte(0x80 | (codepoint & 0x3F)))
-				} else {
-					sb.WriteByte(byte(0xF0 | ((codepoint >> 18) & 0xFF)))
-					sb.WriteByte(byte(0x80 | ((codepoint >> 12) & 0x3F)))
-					sb.WriteByte(byte(0x80 | ((codepoint >> 6) & 0x3F)))
-					sb.WriteByte(byte(0x80 | (codepoint & 0x3F)))
-				}
-			default:
-				return PARSE_INVALID_STRING_ESCAPE
+			if err := parseEscape(c, sb); err != PARSE_OK {
+				return err
 			}
 		case 0: // 这里用数字0代替'\0'，避免非法字符错误
 			return PARSE_MISS_QUOTATION_MARK
 		default:
 			// 控制字符（ASCII码小于0x20的字符）必须使用转义表示
-			if ch < 0x20 {
+			if ch < 0x20 && !c.trusted {
 				return PARSE_INVALID_STRING_CHAR
 			}
 			sb.WriteByte(ch)
@@ -435,6 +396,109 @@ func parseStringRaw(c *parseContext, s *string, sb *strings.Builder) ParseError
 	return PARSE_MISS_QUOTATION_MARK
 }
 
+// parseEscape 解析反斜杠之后的转义序列，将解码后的字符写入 sb
+func parseEscape(c *parseContext, sb io.ByteWriter) ParseError {
+	if c.index >= len(c.json) {
+		return PARSE_INVALID_STRING_ESCAPE
+	}
+	switch c.peekChar() {
+	case '"', '\\':
+		sb.WriteByte(c.nextChar())
+	case 'b':
+		c.nextChar()
+		sb.WriteByte('\b')
+	case 'f':
+		c.nextChar()
+		sb.WriteByte('\f')
+	case 'n':
+		c.nextChar()
+		sb.WriteByte('\n')
+	case 'r':
+		c.nextChar()
+		sb.WriteByte('\r')
+	case 't':
+		c.nextChar()
+		sb.WriteByte('\t')
+	case 'u': // Unicode
+		c.nextChar() // 跳过'u'
+		// 解析4位十六进制数字
+		if c.index+3 >= len(c.json) {
+			return PARSE_INVALID_UNICODE_HEX
+		}
+
+		// 解析高代理项（surrogate high）
+		var codepoint uint32
+		for i := 0; i < 4; i++ {
+			ch := c.nextChar()
+			codepoint <<= 4
+			if ch >= '0' && ch <= '9' {
+				codepoint |= uint32(ch - '0')
+			} else if ch >= 'A' && ch <= 'F' {
+				codepoint |= uint32(ch - 'A' + 10)
+			} else if ch >= 'a' && ch <= 'f' {
+				codepoint |= uint32(ch - 'a' + 10)
+			} else {
+				return PARSE_INVALID_UNICODE_HEX
+			}
+		}
+
+		// 检查是否是代理对的高位部分
+		if codepoint >= 0xD800 && codepoint <= 0xDBFF {
+			// 确保后面跟着低代理项
+			if c.index+5 >= len(c.json) {
+				return PARSE_INVALID_UNICODE_SURROGATE
+			}
+			if c.nextChar() != '\\' || c.nextChar() != 'u' {
+				return PARSE_INVALID_UNICODE_SURROGATE
+			}
+
+			// 解析低代理项（surrogate low）
+			var lowSurrogate uint32
+			for i := 0; i < 4; i++ {
+				ch := c.nextChar()
+				lowSurrogate <<= 4
+				if ch >= '0' && ch <= '9' {
+					lowSurrogate |= uint32(ch - '0')
+				} else if ch >= 'A' && ch <= 'F' {
+					lowSurrogate |= uint32(ch - 'A' + 10)
+				} else if ch >= 'a' && ch <= 'f' {
+					lowSurrogate |= uint32(ch - 'a' + 10)
+				} else {
+					return PARSE_INVALID_UNICODE_HEX
+				}
+			}
+
+			// 验证是否是有效的低代理项
+			if !c.trusted && (lowSurrogate < 0xDC00 || lowSurrogate > 0xDFFF) {
+				return PARSE_INVALID_UNICODE_SURROGATE
+			}
+
+			// 组合高低代理项计算实际的Unicode码点
+			codepoint = 0x10000 + ((codepoint - 0xD800) << 10) + (lowSurrogate - 0xDC00)
+		}
+
+		// 将Unicode码点编码为UTF-8
+		if codepoint <= 0x7F {
+			sb.WriteByte(byte(codepoint & 0xFF))
+		} else if codepoint <= 0x7FF {
+			sb.WriteByte(byte(0xC0 | ((codepoint >> 6) & 0xFF)))
+			sb.WriteByte(byte(0x80 | (codepoint & 0x3F)))
+		} else if codepoint <= 0xFFFF {
+			sb.WriteByte(byte(0xE0 | ((codepoint >> 12) & 0xFF)))
+			sb.WriteByte(byte(0x80 | ((codepoint >> 6) & 0x3F)))
+			sb.WriteByte(byte(0x80 | (codepoint & 0x3F)))
+		} else {
+			sb.WriteByte(byte(0xF0 | ((codepoint >> 18) & 0xFF)))
+			sb.WriteByte(byte(0x80 | ((codepoint >> 12) & 0x3F)))
+			sb.WriteByte(byte(0x80 | ((codepoint >> 6) & 0x3F)))
+			sb.WriteByte(byte(0x80 | (codepoint & 0x3F)))
+		}
+	default:
+		return PARSE_INVALID_STRING_ESCAPE
+	}
+	return PARSE_OK
+}
+
 // parseArray 解析数组值
 //
 // 解析形如 [value, value, ...] 的数组
@@ -483,7 +547,7 @@ func parseArray(c *parseContext, v *Value) ParseError {
 		}
 
 		// 添加到数组中
-		v.A = append(v.A, e)
+		v.A = c.appendValue(v.A, e)
 
 		// 安全检查：添加数组元素
 		if ok, errInfo := c.addArrayElement(); !ok {
@@ -569,6 +633,19 @@ func parseObject(c *parseContext, v *Value) ParseError {
 			v.O = nil
 			return err
 		}
+		m.K = NormalizeString(m.K, c.options.Normalization)
+		// 被过滤的成员仍然要解析以检查语法，但不加入对象
+		filtered := keyFiltered(&c.options, m.K, c.depth == 1)
+		if filtered && c.options.RejectFilteredKeys {
+			v.Type = NULL
+			v.O = nil
+			return PARSE_KEY_NOT_ALLOWED
+		}
+		if c.options.InternKey != nil {
+			m.K = c.options.InternKey(m.K)
+		} else if !c.options.DisableKeyInterning {
+			m.K = c.internKey(m.K)
+		}
 
 		// 跳过空白字符
 		c.parseWhitespace()
@@ -592,15 +669,17 @@ func parseObject(c *parseContext, v *Value) ParseError {
 			return err
 		}
 
-		// 添加到对象中
-		v.O = append(v.O, m)
+		if !filtered {
+			// 添加到对象中
+			v.O = c.appendMember(v.O, m)
 
-		// 安全检查：添加对象成员
-		if ok, errInfo := c.addObjectMember(); !ok {
-			// 安全检查失败，释放已分配的内存
-			v.Type = NULL
-			v.O = nil
-			return errInfo.Code
+			// 安全检查：添加对象成员
+			if ok, errInfo := c.addObjectMember(); !ok {
+				// 安全检查失败，释放已分配的内存
+				v.Type = NULL
+				v.O = nil
+				return errInfo.Code
+			}
 		}
 
 		// 跳过空白字符
@@ -639,10 +718,28 @@ func parseObject(c *parseContext, v *Value) ParseError {
 // - object: 以'{'开头
 // - number: 以'-'或数字开头
 func parseValue(c *parseContext, v *Value) ParseError {
+	if c.done != nil {
+		if c.values++; c.values%cancelCheckInterval == 0 && isDone(c.done) {
+			return PARSE_CANCELLED
+		}
+	}
 	if c.index >= len(c.json) {
 		return PARSE_EXPECT_VALUE
 	}
+	if c.options.Comments == nil {
+		return parseValueKind(c, v)
+	}
+
+	c.beginCommentedValue(v)
+	err := parseValueKind(c, v)
+	if err == PARSE_OK {
+		c.endCommentedValue(v)
+	}
+	return err
+}
 
+// parseValueKind 按第一个字符调用相应的解析函数
+func parseValueKind(c *parseContext, v *Value) ParseError {
 	switch c.json[c.index] {
 	case 'n':
 		return parseNull(c, v)
@@ -694,7 +791,12 @@ func SetNumber(v *Value, n float64) {
 }
 
 // GetString 获取JSON字符串值
+//
+// 分块保存的字符串在第一次调用时才拼接为一个字符串。
 func GetString(v *Value) string {
+	if v.chunks != nil {
+		return v.chunks.String()
+	}
 	return v.S
 }
 
@@ -702,6 +804,7 @@ func GetString(v *Value) string {
 func SetString(v *Value, s string) {
 	v.Type = STRING
 	v.S = s
+	v.chunks = nil
 }
 
 // GetArraySize 获取JSON数组的大小
@@ -781,8 +884,18 @@ func Stringify(v *Value) (string, StringifyError) {
 		return "", STRINGIFY_OK
 	}
 
-	var buffer bytes.Buffer
-	stringifyValue(v, &buffer)
+	logger := currentLogger()
+	var start time.Time
+	if logger != nil {
+		start = time.Now()
+	}
+
+	buffer := acquireBuffer()
+	defer releaseBuffer(buffer)
+	stringifyValue(v, buffer)
+	if logger != nil {
+		logStringify(logger, buffer.Len(), start, v)
+	}
 	return buffer.String(), STRINGIFY_OK
 }
 
@@ -796,10 +909,13 @@ func stringifyValue(v *Value, buffer *bytes.Buffer) {
 	case FALSE:
 		buffer.WriteString("false")
 	case NUMBER:
-		// 使用 -1 精度以获得最短的表示形式
-		buffer.WriteString(strconv.FormatFloat(v.N, 'g', -1, 64))
+		stringifyNumber(v.N, buffer)
 	case STRING:
-		stringifyString(v.S, buffer)
+		if v.chunks != nil {
+			v.chunks.stringify(buffer)
+		} else {
+			stringifyString(v.S, buffer)
+		}
 	case ARRAY:
 		stringifyArray(v, buffer)
 	case OBJECT:
@@ -807,36 +923,77 @@ func stringifyValue(v *Value, buffer *bytes.Buffer) {
 	}
 }
 
+// smallIntStrings 是 0 到 len-1 的整数的文本，文档中大量重复的 0、1 等小整数不需要每次格式化
+var smallIntStrings = func() (table [1024]string) {
+	for i := range table {
+		table[i] = strconv.Itoa(i)
+	}
+	return table
+}()
+
+// maxIntegralFormat 绝对值小于它的整数按整数格式输出，与 'g' 格式的结果相同；
+// 更大的数 'g' 格式使用指数形式（1e+06）
+const maxIntegralFormat = 1e6
+
+// stringifyNumber 将数字写入Buffer，使用 -1 精度以获得最短的表示形式
+//
+// 结果与 strconv.FormatFloat(n, 'g', -1, 64) 相同。整数（价格、计数、ID 等最常见的
+// 数字）跳过浮点数格式化，小整数直接查表；其他数字格式化到栈上的数组，不分配内存。
+func stringifyNumber(n float64, buffer *bytes.Buffer) {
+	if n > -maxIntegralFormat && n < maxIntegralFormat && n == math.Trunc(n) && !(n == 0 && math.Signbit(n)) {
+		i := int(n)
+		if i >= 0 && i < len(smallIntStrings) {
+			buffer.WriteString(smallIntStrings[i])
+			return
+		}
+		var scratch [24]byte
+		buffer.Write(strconv.AppendInt(scratch[:0], int64(i), 10))
+		return
+	}
+	var scratch [32]byte
+	buffer.Write(strconv.AppendFloat(scratch[:0], n, 'g', -1, 64))
+}
+
 // stringifyString 将字符串写入Buffer，处理转义字符
 func stringifyString(s string, buffer *bytes.Buffer) {
 	buffer.WriteByte('"')
+	escapeString(s, buffer)
+	buffer.WriteByte('"')
+}
+
+// escapeTable 是输出字符串时每个字节的转义序列，不需要转义的字节为空字符串
+var escapeTable = func() (table [256]string) {
+	const hex = "0123456789abcdef"
+	for ch := 0; ch < 0x20; ch++ {
+		// 控制字符使用 \u00xx 形式，常见的几个在下面改为简写
+		table[ch] = `\u00` + string(hex[ch>>4]) + string(hex[ch&0xF])
+	}
+	table['"'] = `\"`
+	table['\\'] = `\\`
+	table['\b'] = `\b`
+	table['\f'] = `\f`
+	table['\n'] = `\n`
+	table['\r'] = `\r`
+	table['\t'] = `\t`
+	return table
+}()
+
+// escapeString 将字符串转义后写入Buffer，不包括两侧的引号
+//
+// 大多数字符串不需要转义，只需扫描一遍后整段写入；需要转义时，
+// 两个转义字符之间的片段也是整段写入。
+func escapeString(s string, buffer *bytes.Buffer) {
+	start := 0
 	for i := 0; i < len(s); i++ {
-		ch := s[i]
-		switch ch {
-		case '"':
-			buffer.WriteString("\\\"")
-		case '\\':
-			buffer.WriteString("\\\\")
-		case '\b':
-			buffer.WriteString("\\b")
-		case '\f':
-			buffer.WriteString("\\f")
-		case '\n':
-			buffer.WriteString("\\n")
-		case '\r':
-			buffer.WriteString("\\r")
-		case '\t':
-			buffer.WriteString("\\t")
-		default:
-			if ch < 0x20 {
-				// 对于其他控制字符，使用 \u00xx 形式
-				buffer.WriteString(fmt.Sprintf("\\u%04x", ch))
-			} else {
-				buffer.WriteByte(ch)
-			}
+		escaped := escapeTable[s[i]]
+		if escaped == "" {
+			continue
 		}
+		buffer.WriteString(s[start:i])
+		buffer.WriteString(escaped)
+		start = i + 1
 	}
-	buffer.WriteByte('"')
+	buffer.WriteString(s[start:])
 }
 
 // stringifyArray 将数组写入Buffer
@@ -867,6 +1024,11 @@ func stringifyObject(v *Value, buffer *bytes.Buffer) {
 
 // Equal 判断两个JSON值是否相等
 func Equal(lhs, rhs *Value) bool {
+	return equalValues(lhs, rhs, &EqualOptions{})
+}
+
+// equalValues 判断两个JSON值在选项下是否相等，见 EqualWithOptions
+func equalValues(lhs, rhs *Value, opts *EqualOptions) bool {
 	// 首先检查指针是否相同
 	if lhs == rhs {
 		return true
@@ -879,7 +1041,7 @@ func Equal(lhs, rhs *Value) bool {
 
 	// 检查类型是否相同
 	if lhs.Type != rhs.Type {
-		return false
+		return opts.CoerceNumericStrings && numericStringEqual(lhs, rhs, opts)
 	}
 
 	// 根据类型进行比较
@@ -887,17 +1049,20 @@ func Equal(lhs, rhs *Value) bool {
 	case NULL, FALSE, TRUE:
 		return true // 这些类型只要类型相同就相等
 	case NUMBER:
-		return lhs.N == rhs.N
+		return opts.numbersEqual(lhs.N, rhs.N)
 	case STRING:
-		return lhs.S == rhs.S
+		return canonicalEqual(GetString(lhs), GetString(rhs), opts.Normalization)
 	case ARRAY:
 		// 数组长度必须相同
 		if len(lhs.A) != len(rhs.A) {
 			return false
 		}
+		if opts.IgnoreArrayOrder {
+			return unorderedEqual(lhs.A, rhs.A, opts)
+		}
 		// 递归比较每个元素
 		for i := 0; i < len(lhs.A); i++ {
-			if !Equal(lhs.A[i], rhs.A[i]) {
+			if !equalValues(lhs.A[i], rhs.A[i], opts) {
 				return false
 			}
 		}
@@ -913,10 +1078,10 @@ func Equal(lhs, rhs *Value) bool {
 			// 在rhs中查找对应的键
 			found := false
 			for _, m2 := range rhs.O {
-				if m1.K == m2.K {
+				if canonicalEqual(m1.K, m2.K, opts.Normalization) {
 					found = true
 					// 递归比较值
-					if !Equal(m1.V, m2.V) {
+					if !equalValues(m1.V, m2.V, opts) {
 						return false
 					}
 					break
@@ -953,7 +1118,9 @@ func Copy(dst, src *Value) {
 		dst.Type = NUMBER
 		dst.N = src.N
 	case STRING:
+		// 分块字符串不会被修改，可以共享
 		SetString(dst, src.S)
+		dst.chunks = src.chunks
 	case ARRAY:
 		// 设置为数组类型并预分配空间
 		SetArray(dst, len(src.A))
@@ -1047,6 +1214,7 @@ func Free(v *Value) {
 	case STRING:
 		// Go中字符串是不可变的，不需要手动释放内存
 		v.S = ""
+		v.chunks = nil
 	case ARRAY:
 		// 递归释放数组中的每个元素
 		for i := 0; i < len(v.A); i++ {
@@ -1106,9 +1274,8 @@ func PushBackArrayElement(v *Value) *Value {
 
 	// 当容量不足时扩容
 	if len(v.A) == cap(v.A) {
-		// 使用自定义的max函数
-		newCapacity := maxInt(1, cap(v.A)*2)
-		ReserveArray(v, newCapacity)
+		policy := currentGrowthPolicy()
+		ReserveArray(v, policy.next(cap(v.A), policy.ArrayCapacity))
 	}
 
 	// 创建新元素
@@ -1144,9 +1311,8 @@ func InsertArrayElement(v *Value, index int) *Value {
 
 	// 当容量不足时扩容
 	if len(v.A) == cap(v.A) {
-		// 使用自定义的max函数
-		newCapacity := maxInt(1, cap(v.A)*2)
-		ReserveArray(v, newCapacity)
+		policy := currentGrowthPolicy()
+		ReserveArray(v, policy.next(cap(v.A), policy.ArrayCapacity))
 	}
 
 	// 创建新元素
@@ -1248,8 +1414,8 @@ func SetObjectValue(v *Value, key string) *Value {
 
 	// 当容量不足时扩容
 	if len(v.O) == cap(v.O) {
-		newCapacity := maxInt(1, cap(v.O)*2)
-		ReserveObject(v, newCapacity)
+		policy := currentGrowthPolicy()
+		ReserveObject(v, policy.next(cap(v.O), policy.ObjectCapacity))
 	}
 
 	// 创建新值
@@ -1327,6 +1493,22 @@ func (e ParseError) Error() string {
 		return "超过最大嵌套深度"
 	case PARSE_COMMENT_NOT_CLOSED:
 		return "注释未闭合"
+	case PARSE_MAX_STRING_LENGTH_EXCEEDED:
+		return "超过最大字符串长度"
+	case PARSE_MAX_ARRAY_SIZE_EXCEEDED:
+		return "超过最大数组元素数量"
+	case PARSE_MAX_OBJECT_SIZE_EXCEEDED:
+		return "超过最大对象成员数量"
+	case PARSE_MAX_TOTAL_SIZE_EXCEEDED:
+		return "超过最大输入大小"
+	case PARSE_NUMBER_RANGE_EXCEEDED:
+		return "数值超出允许范围"
+	case PARSE_SECURITY_VIOLATION:
+		return "安全策略违规"
+	case PARSE_KEY_NOT_ALLOWED:
+		return "对象键不被允许"
+	case PARSE_CANCELLED:
+		return "解析被取消"
 	default:
 		return "未知错误"
 	}
@@ -1337,6 +1519,8 @@ func (e StringifyError) Error() string {
 	switch e {
 	case STRINGIFY_OK:
 		return "字符串化成功"
+	case STRINGIFY_CANCELLED:
+		return "字符串化被取消"
 	default:
 		return "未知错误"
 	}
@@ -1438,6 +1622,11 @@ func Marshal(v interface{}) (string, error) {
 	return s, nil // 成功时返回 nil error
 }
 
+// MarshalValue 将 Go 值转换为JSON值，规则与 Marshal 相同
+func MarshalValue(v interface{}) (*Value, error) {
+	return marshalToValue(reflect.ValueOf(v))
+}
+
 // marshalToValue 是 Marshal 的核心递归函数
 // 它将 reflect.Value 转换为 *leptjson.Value
 func marshalToValue(rv reflect.Value) (*Value, error) {
@@ -1451,12 +1640,19 @@ func marshalToValue(rv reflect.Value) (*Value, error) {
 		if rv.IsNil() {
 			return &Value{Type: NULL}, nil
 		}
+		// 实现了 LeptMarshaler 的类型自行完成序列化
+		if m, ok := asLeptMarshaler(rv); ok {
+			return callLeptMarshaler(m, rv.Type())
+		}
 		rv = rv.Elem() // 解引用或获取接口的动态值
 		// 再次检查解引用后的值是否有效
 		if !rv.IsValid() {
 			return &Value{Type: NULL}, nil
 		}
 	}
+	if m, ok := asLeptMarshaler(rv); ok {
+		return callLeptMarshaler(m, rv.Type())
+	}
 
 	// 根据类型处理
 	switch rv.Kind() {
@@ -1542,58 +1738,69 @@ func marshalMapToValue(rv reflect.Value) (*Value, error) {
 }
 
 // marshalStructToValue 将 Go struct 转换为 *leptjson.Value (OBJECT)
+// 字段规则（嵌入、string、inline 等选项）见 typeFields
 func marshalStructToValue(rv reflect.Value) (*Value, error) {
 	objVal := &Value{}
 	SetObject(objVal)
-	t := rv.Type()
-
-	for i := 0; i < rv.NumField(); i++ {
-		field := t.Field(i)       // 获取字段类型信息 (StructField)
-		fieldValue := rv.Field(i) // 获取字段值 (Value)
 
-		// 跳过非导出字段
-		if field.PkgPath != "" {
+	fields := cachedTypeFields(rv.Type())
+	for _, field := range fields {
+		// 经过nil的嵌入指针时跳过该字段
+		fieldValue, ok := fieldByIndex(rv, field.index)
+		if !ok {
 			continue
 		}
-
-		// 解析 json tag
-		tag := field.Tag.Get("json")
-		if tag == "-" {
-			continue // 忽略带 "-" tag 的字段
-		}
-
-		// 解析 tag 选项 (omitempty)
-		tagParts := strings.Split(tag, ",")
-		jsonKey := tagParts[0]
-		omitempty := false
-		if len(tagParts) > 1 && tagParts[1] == "omitempty" {
-			omitempty = true
-		}
-
-		// 如果 tag 没有指定 key name，使用字段名
-		if jsonKey == "" {
-			jsonKey = field.Name
+		if field.inline {
+			if err := marshalInlineMap(objVal, fieldValue); err != nil {
+				return nil, err
+			}
+			continue
 		}
 
 		// 处理 omitempty
-		if omitempty && isEmptyValue(fieldValue) {
+		if field.omitEmpty && isEmptyValue(fieldValue) {
 			continue
 		}
 
 		// 递归转换字段值
 		structFieldValue, err := marshalToValue(fieldValue)
 		if err != nil {
-			return nil, fmt.Errorf("序列化 struct 字段 '%s' 失败: %w", field.Name, err)
+			return nil, fmt.Errorf("序列化 struct 字段 '%s' 失败: %w", field.goName, err)
+		}
+		// 处理 string 选项，数字和布尔值编码为字符串
+		if field.quoted && structFieldValue.Type != NULL {
+			s, _ := Stringify(structFieldValue)
+			SetString(structFieldValue, s)
 		}
 
 		// 添加到对象
-		valuePtr := SetObjectValue(objVal, jsonKey)
+		valuePtr := SetObjectValue(objVal, field.name)
 		Copy(valuePtr, structFieldValue)
 	}
 
 	return objVal, nil
 }
 
+// marshalInlineMap 将带有 inline 选项的 map 中的键按字典序写入对象，已存在的键被跳过
+func marshalInlineMap(objVal *Value, mv reflect.Value) error {
+	keys := mv.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	for _, key := range keys {
+		name := key.String()
+		if _, exists := FindObjectKey(objVal, name); exists {
+			continue
+		}
+		mapValue, err := marshalToValue(mv.MapIndex(key))
+		if err != nil {
+			return fmt.Errorf("序列化 inline 字段失败 (key: %s): %w", name, err)
+		}
+		Copy(SetObjectValue(objVal, name), mapValue)
+	}
+	return nil
+}
+
 // isEmptyValue 检查 reflect.Value 是否为其类型的零值
 // 这是 omitempty 的简化实现
 func isEmptyValue(v reflect.Value) bool {
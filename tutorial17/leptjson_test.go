@@ -303,6 +303,9 @@ func TestSecurity(t *testing.T) {
 				t.Errorf("Parse(%q) error = %v (code=%d), wantErr %v (code=%d)",
 					tt.json, GetErrorMessage(err), err, GetErrorMessage(tt.expectErr), tt.expectErr)
 			}
+			if err.Error() == "未知错误" {
+				t.Errorf("错误码 %d 没有描述", err)
+			}
 		})
 	}
 }
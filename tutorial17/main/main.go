@@ -1,7 +1,7 @@
 package main
 
 import (
-	leptjson "github.com/Cactusinhand/go-json-tutorial/tutorial16"
+	leptjson "github.com/Cactusinhand/go-json-tutorial/tutorial17"
 )
 
 func main() {
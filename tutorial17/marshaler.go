@@ -0,0 +1,72 @@
+// marshaler.go - 自定义序列化接口
+package leptjson
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// LeptMarshaler 由能够自行转换为JSON值的类型实现
+//
+// Marshal 遇到实现了该接口的值时直接调用 ToLeptJSON，而不再反射其字段，
+// 适用于金额、UUID、时间等有固定文本表示的领域类型。返回nil表示 null。
+type LeptMarshaler interface {
+	ToLeptJSON() (*Value, error)
+}
+
+// LeptUnmarshaler 由能够从JSON值还原自身的类型实现
+//
+// Unmarshal 遇到实现了该接口的目标时直接调用 FromLeptJSON。
+// 由于需要修改接收者，该方法通常定义在指针类型上。
+type LeptUnmarshaler interface {
+	FromLeptJSON(v *Value) error
+}
+
+var (
+	leptMarshalerType   = reflect.TypeOf((*LeptMarshaler)(nil)).Elem()
+	leptUnmarshalerType = reflect.TypeOf((*LeptUnmarshaler)(nil)).Elem()
+)
+
+// asLeptMarshaler 判断值是否实现了 LeptMarshaler
+//
+// 值本身未实现但可以取地址且其指针实现了该接口时，使用其指针。
+// nil指针不会被视为 LeptMarshaler，而是序列化为 null。
+func asLeptMarshaler(rv reflect.Value) (LeptMarshaler, bool) {
+	if !rv.IsValid() || !rv.CanInterface() {
+		return nil, false
+	}
+	if (rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface) && rv.IsNil() {
+		return nil, false
+	}
+	if rv.Type().Implements(leptMarshalerType) {
+		m, ok := rv.Interface().(LeptMarshaler)
+		return m, ok
+	}
+	if rv.Kind() != reflect.Ptr && rv.CanAddr() && reflect.PtrTo(rv.Type()).Implements(leptMarshalerType) {
+		return rv.Addr().Interface().(LeptMarshaler), true
+	}
+	return nil, false
+}
+
+// asLeptUnmarshaler 判断可设置的值的指针是否实现了 LeptUnmarshaler
+func asLeptUnmarshaler(rv reflect.Value) (LeptUnmarshaler, bool) {
+	if rv.Kind() == reflect.Ptr || !rv.CanAddr() || !rv.Addr().CanInterface() {
+		return nil, false
+	}
+	if !reflect.PtrTo(rv.Type()).Implements(leptUnmarshalerType) {
+		return nil, false
+	}
+	return rv.Addr().Interface().(LeptUnmarshaler), true
+}
+
+// callLeptMarshaler 调用 ToLeptJSON 并处理返回的nil值
+func callLeptMarshaler(m LeptMarshaler, t reflect.Type) (*Value, error) {
+	v, err := m.ToLeptJSON()
+	if err != nil {
+		return nil, fmt.Errorf("调用 %s 的 ToLeptJSON 失败: %w", t, err)
+	}
+	if v == nil {
+		return &Value{Type: NULL}, nil
+	}
+	return v, nil
+}
@@ -0,0 +1,121 @@
+package leptjson
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// testMoney 以分为单位保存金额，序列化为 "12.34" 形式的字符串
+type testMoney struct {
+	cents int64
+}
+
+func (m testMoney) ToLeptJSON() (*Value, error) {
+	v := &Value{}
+	SetString(v, fmt.Sprintf("%d.%02d", m.cents/100, m.cents%100))
+	return v, nil
+}
+
+func (m *testMoney) FromLeptJSON(v *Value) error {
+	s, err := GetStringChecked(v)
+	if err != nil {
+		return err
+	}
+	var yuan, fen int64
+	if _, err := fmt.Sscanf(s, "%d.%d", &yuan, &fen); err != nil {
+		return fmt.Errorf("无效的金额: %q", s)
+	}
+	m.cents = yuan*100 + fen
+	return nil
+}
+
+// testStatus 只在指针上实现接口，null 被还原为 "unknown"
+type testStatus string
+
+func (s *testStatus) ToLeptJSON() (*Value, error) {
+	if *s == "" {
+		return nil, nil
+	}
+	v := &Value{}
+	SetString(v, strings.ToUpper(string(*s)))
+	return v, nil
+}
+
+func (s *testStatus) FromLeptJSON(v *Value) error {
+	if v.Type == NULL {
+		*s = "unknown"
+		return nil
+	}
+	str, err := GetStringChecked(v)
+	*s = testStatus(strings.ToLower(str))
+	return err
+}
+
+type testOrder struct {
+	ID     int                  `json:"id"`
+	Price  testMoney            `json:"price"`
+	Refund *testMoney           `json:"refund"`
+	Status *testStatus          `json:"status"`
+	Items  []testMoney          `json:"items"`
+	Fees   map[string]testMoney `json:"fees"`
+}
+
+func TestLeptMarshaler(t *testing.T) {
+	status := testStatus("paid")
+	order := testOrder{
+		ID:     1,
+		Price:  testMoney{1234},
+		Status: &status,
+		Items:  []testMoney{{5}, {100}},
+		Fees:   map[string]testMoney{"tax": {99}},
+	}
+	got, err := Marshal(order)
+	if err != nil {
+		t.Fatalf("Marshal 失败: %v", err)
+	}
+	expected := `{"id":1,"price":"12.34","refund":null,"status":"PAID","items":["0.05","1.00"],"fees":{"tax":"0.99"}}`
+	if got != expected {
+		t.Errorf("Marshal = %s, 期望 %s", got, expected)
+	}
+
+	var decoded testOrder
+	if err := Unmarshal(expected, &decoded); err != nil {
+		t.Fatalf("Unmarshal 失败: %v", err)
+	}
+	if decoded.Price.cents != 1234 || decoded.Refund != nil || *decoded.Status != "paid" ||
+		len(decoded.Items) != 2 || decoded.Items[1].cents != 100 || decoded.Fees["tax"].cents != 99 {
+		t.Errorf("Unmarshal 结果错误: %+v", decoded)
+	}
+}
+
+func TestLeptUnmarshalerErrors(t *testing.T) {
+	var order testOrder
+	err := Unmarshal(`{"price": 12, "items": ["abc"]}`, &order)
+	var unmarshalErr *UnmarshalError
+	if !errors.As(err, &unmarshalErr) || len(unmarshalErr.Violations) != 2 {
+		t.Fatalf("应当报告两处错误, 实际为 %v", err)
+	}
+	if unmarshalErr.Violations[0].Path != "/price" || !strings.Contains(unmarshalErr.Violations[0].Message, "FromLeptJSON") {
+		t.Errorf("错误信息不正确: %v", unmarshalErr.Violations[0])
+	}
+
+	var s testStatus
+	if err := Unmarshal(`null`, &s); err != nil || s != "unknown" {
+		t.Errorf("FromLeptJSON 应当接收 null: %q, %v", s, err)
+	}
+}
+
+type failingMarshaler struct{}
+
+func (failingMarshaler) ToLeptJSON() (*Value, error) {
+	return nil, errors.New("故意失败")
+}
+
+func TestLeptMarshalerError(t *testing.T) {
+	_, err := Marshal([]interface{}{failingMarshaler{}})
+	if err == nil || !strings.Contains(err.Error(), "故意失败") {
+		t.Errorf("应当返回 ToLeptJSON 的错误, 实际为 %v", err)
+	}
+}
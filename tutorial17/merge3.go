@@ -0,0 +1,208 @@
+// merge3.go - JSON文档的结构化三方合并
+package leptjson
+
+import (
+	"strconv"
+)
+
+// 冲突标记对象的键。无法自动合并的值被替换为包含这些键的对象，
+// 一方删除了该值时对应的键不出现
+const (
+	MergeMarkerOurs   = "<<<<<<< ours"
+	MergeMarkerBase   = "||||||| base"
+	MergeMarkerTheirs = ">>>>>>> theirs"
+)
+
+// MergeConflict 是三方合并中无法自动解决的一处冲突，值为 nil 表示该方没有这个值
+type MergeConflict struct {
+	Path   string // 冲突位置的 JSON Pointer
+	Base   *Value
+	Ours   *Value
+	Theirs *Value
+}
+
+// Merge3 以 base 为共同祖先，合并 ours 和 theirs 两个版本
+//
+// 对象按键合并：只有一方修改、添加或删除的成员采用该方的结果，双方做了相同修改时
+// 直接采用。数组在三个版本长度相同时按下标合并，否则整体视为一个值。双方对同一个值
+// 做了不同修改时产生冲突，结果中该值被替换为冲突标记对象
+// {"<<<<<<< ours": ..., "||||||| base": ..., ">>>>>>> theirs": ...}。
+// base 为 nil 时视为双方各自添加了整个文档。输入的值不会被修改。
+func Merge3(base, ours, theirs *Value) (*Value, []MergeConflict) {
+	return Merge3WithOptions(base, ours, theirs, Merge3Options{})
+}
+
+// Merge3Options 是 Merge3WithOptions 的选项
+type Merge3Options struct {
+	// Units 不为 nil 时，{"value": 5, "unit": "GiB"} 形式的带单位数值（见 ParseQuantity）
+	// 用这个换算表按大小比较，并且作为一个整体合并：一方把 1024 MiB 改写为 1 GiB 不算修改，
+	// 双方都修改了大小时是冲突，不会把一方的 value 和另一方的 unit 拼在一起
+	Units *UnitTable
+}
+
+// Merge3WithOptions 与 Merge3 相同，按 options 比较和合并带单位的数值
+func Merge3WithOptions(base, ours, theirs *Value, options Merge3Options) (*Value, []MergeConflict) {
+	m := &merger{units: options.Units}
+	result := m.merge(base, ours, theirs, "")
+	if result == nil {
+		// ours 和 theirs 都为 nil
+		result = &Value{}
+	}
+	return result, m.conflicts
+}
+
+// merger 记录合并过程中的冲突
+type merger struct {
+	conflicts []MergeConflict
+	units     *UnitTable
+}
+
+// merge 合并一个位置的三个版本，nil 表示该版本没有这个值；返回 nil 表示合并结果是删除
+func (m *merger) merge(base, ours, theirs *Value, path string) *Value {
+	switch {
+	case m.same(ours, theirs):
+		return copyOrNil(ours)
+	case m.same(base, ours):
+		return copyOrNil(theirs)
+	case m.same(base, theirs):
+		return copyOrNil(ours)
+	}
+
+	// 双方都修改了这个值，且结果不同
+	if m.units != nil && (isQuantity(ours) || isQuantity(theirs)) {
+		// 带单位的数值不逐个成员合并
+		return m.conflict(base, ours, theirs, path)
+	}
+	if ours != nil && theirs != nil && ours.Type == theirs.Type {
+		switch ours.Type {
+		case OBJECT:
+			if base == nil || base.Type == OBJECT {
+				return m.mergeObjects(base, ours, theirs, path)
+			}
+		case ARRAY:
+			if base != nil && base.Type == ARRAY && len(base.A) == len(ours.A) && len(base.A) == len(theirs.A) {
+				return m.mergeArrays(base, ours, theirs, path)
+			}
+		}
+	}
+	return m.conflict(base, ours, theirs, path)
+}
+
+// mergeObjects 按键合并三个对象，键的顺序为 ours 中的顺序，之后是 theirs 新增的键
+func (m *merger) mergeObjects(base, ours, theirs *Value, path string) *Value {
+	lookup := func(v *Value, key string) *Value {
+		if v == nil {
+			return nil
+		}
+		return findObjectKey(v, key)
+	}
+
+	result := &Value{}
+	SetObject(result)
+	add := func(key string) {
+		merged := m.merge(lookup(base, key), lookup(ours, key), lookup(theirs, key), path+"/"+escapeJSONPointerToken(key))
+		if merged != nil {
+			result.O = append(result.O, Member{K: key, V: merged})
+		}
+	}
+	for _, member := range ours.O {
+		add(member.K)
+	}
+	for _, member := range theirs.O {
+		if findObjectKey(ours, member.K) == nil {
+			add(member.K)
+		}
+	}
+	return result
+}
+
+// mergeArrays 按下标合并长度相同的三个数组
+func (m *merger) mergeArrays(base, ours, theirs *Value, path string) *Value {
+	result := &Value{}
+	SetArray(result, len(ours.A))
+	for i := range ours.A {
+		merged := m.merge(base.A[i], ours.A[i], theirs.A[i], path+"/"+strconv.Itoa(i))
+		result.A = append(result.A, merged)
+	}
+	return result
+}
+
+// conflict 记录冲突并返回冲突标记对象
+func (m *merger) conflict(base, ours, theirs *Value, path string) *Value {
+	m.conflicts = append(m.conflicts, MergeConflict{Path: path, Base: base, Ours: ours, Theirs: theirs})
+	marker := &Value{}
+	SetObject(marker)
+	for _, side := range []struct {
+		key   string
+		value *Value
+	}{{MergeMarkerOurs, ours}, {MergeMarkerBase, base}, {MergeMarkerTheirs, theirs}} {
+		if side.value != nil {
+			marker.O = append(marker.O, Member{K: side.key, V: copyOrNil(side.value)})
+		}
+	}
+	return marker
+}
+
+// sameValue 判断两个可能为 nil 的值是否相同
+func sameValue(a, b *Value) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return Equal(a, b)
+}
+
+// same 判断两个可能为 nil 的值是否相同，设置了 units 时大小相等的带单位数值视为相同
+func (m *merger) same(a, b *Value) bool {
+	if sameValue(a, b) {
+		return true
+	}
+	if m.units == nil {
+		return false
+	}
+	qa, ok := ParseQuantity(a)
+	qb, ok2 := ParseQuantity(b)
+	if !ok || !ok2 {
+		return false
+	}
+	cmp, err := m.units.Compare(qa, qb)
+	return err == nil && cmp == 0
+}
+
+// isQuantity 判断 v 是否是带单位的数值
+func isQuantity(v *Value) bool {
+	_, ok := ParseQuantity(v)
+	return ok
+}
+
+// copyOrNil 返回 v 的深拷贝，v 为 nil 时返回 nil
+func copyOrNil(v *Value) *Value {
+	if v == nil {
+		return nil
+	}
+	c := &Value{}
+	Copy(c, v)
+	return c
+}
+
+// HasMergeMarkers 判断 v 中是否还有冲突标记对象，用于检查冲突是否都已解决
+func HasMergeMarkers(v *Value) bool {
+	switch v.Type {
+	case ARRAY:
+		for _, elem := range v.A {
+			if HasMergeMarkers(elem) {
+				return true
+			}
+		}
+	case OBJECT:
+		for _, member := range v.O {
+			switch member.K {
+			case MergeMarkerOurs, MergeMarkerBase, MergeMarkerTheirs:
+				return true
+			}
+			if HasMergeMarkers(member.V) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,196 @@
+package leptjson
+
+import (
+	"testing"
+)
+
+func mustParse(t *testing.T, json string) *Value {
+	t.Helper()
+	if json == "" {
+		return nil
+	}
+	v := &Value{}
+	if err := Parse(v, json); err != PARSE_OK {
+		t.Fatalf("解析 %s 失败: %v", json, err)
+	}
+	return v
+}
+
+func TestMerge3(t *testing.T) {
+	tests := []struct {
+		name               string
+		base, ours, theirs string
+		want               string
+		conflicts          []string
+	}{
+		{
+			name:   "双方添加不同的依赖",
+			base:   `{"name":"app","dependencies":{"a":"1.0"}}`,
+			ours:   `{"name":"app","dependencies":{"a":"1.0","b":"2.0"}}`,
+			theirs: `{"name":"app","dependencies":{"a":"1.0","c":"3.0"}}`,
+			want:   `{"name":"app","dependencies":{"a":"1.0","b":"2.0","c":"3.0"}}`,
+		},
+		{
+			name:   "一方修改一方删除其他键",
+			base:   `{"version":"1.0","scripts":{"test":"x","lint":"y"}}`,
+			ours:   `{"version":"1.1","scripts":{"test":"x","lint":"y"}}`,
+			theirs: `{"version":"1.0","scripts":{"test":"x"}}`,
+			want:   `{"version":"1.1","scripts":{"test":"x"}}`,
+		},
+		{
+			name:   "双方做了相同修改",
+			base:   `{"v":1}`,
+			ours:   `{"v":2}`,
+			theirs: `{"v":2}`,
+			want:   `{"v":2}`,
+		},
+		{
+			name:      "同一个值的不同修改",
+			base:      `{"version":"1.0","name":"app"}`,
+			ours:      `{"version":"1.1","name":"app"}`,
+			theirs:    `{"version":"2.0","name":"app"}`,
+			want:      `{"version":{"<<<<<<< ours":"1.1","||||||| base":"1.0",">>>>>>> theirs":"2.0"},"name":"app"}`,
+			conflicts: []string{"/version"},
+		},
+		{
+			name:      "删除与修改冲突",
+			base:      `{"a/b":{"x":1}}`,
+			ours:      `{}`,
+			theirs:    `{"a/b":{"x":2}}`,
+			want:      `{"a/b":{"||||||| base":{"x":1},">>>>>>> theirs":{"x":2}}}`,
+			conflicts: []string{"/a~1b"},
+		},
+		{
+			name:   "长度相同的数组按下标合并",
+			base:   `{"files":["a","b","c"]}`,
+			ours:   `{"files":["A","b","c"]}`,
+			theirs: `{"files":["a","b","C"]}`,
+			want:   `{"files":["A","b","C"]}`,
+		},
+		{
+			name:      "长度变化的数组整体冲突",
+			base:      `[1]`,
+			ours:      `[1,2]`,
+			theirs:    `[1,3]`,
+			want:      `{"<<<<<<< ours":[1,2],"||||||| base":[1],">>>>>>> theirs":[1,3]}`,
+			conflicts: []string{""},
+		},
+		{
+			name:   "双方各自新增文件",
+			ours:   `{"a":1,"same":true}`,
+			theirs: `{"b":2,"same":true}`,
+			want:   `{"a":1,"same":true,"b":2}`,
+		},
+	}
+	for _, tt := range tests {
+		base, ours, theirs := mustParse(t, tt.base), mustParse(t, tt.ours), mustParse(t, tt.theirs)
+		oursBefore, _ := Stringify(ours)
+		merged, conflicts := Merge3(base, ours, theirs)
+		if got, _ := Stringify(merged); got != tt.want {
+			t.Errorf("%s: 合并结果 = %s, 期望 %s", tt.name, got, tt.want)
+		}
+		var paths []string
+		for _, c := range conflicts {
+			paths = append(paths, c.Path)
+		}
+		if len(paths) != len(tt.conflicts) || (len(paths) > 0 && paths[0] != tt.conflicts[0]) {
+			t.Errorf("%s: 冲突 = %q, 期望 %q", tt.name, paths, tt.conflicts)
+		}
+		if HasMergeMarkers(merged) != (len(tt.conflicts) > 0) {
+			t.Errorf("%s: HasMergeMarkers = %v", tt.name, HasMergeMarkers(merged))
+		}
+		if after, _ := Stringify(ours); after != oursBefore {
+			t.Errorf("%s: Merge3 修改了输入", tt.name)
+		}
+	}
+}
+
+func TestDetectIndent(t *testing.T) {
+	for text, want := range map[string]string{
+		"{\n    \"a\": 1\n}\n": "    ",
+		"{\n\t\"a\": 1\n}":     "\t",
+		`{"a":1}`:              "  ",
+	} {
+		if got := detectIndent(text); got != want {
+			t.Errorf("detectIndent(%q) = %q, 期望 %q", text, got, want)
+		}
+	}
+}
+
+func TestMerge3Units(t *testing.T) {
+	tests := []struct {
+		name, base, ours, theirs, expected string
+		conflicts                          int
+	}{
+		{
+			"一方只改写单位",
+			`{"mem": {"value": 1024, "unit": "MiB"}}`,
+			`{"mem": {"value": 1, "unit": "GiB"}}`,
+			`{"mem": {"value": 1024, "unit": "MiB"}}`,
+			`{"mem": {"value": 1, "unit": "GiB"}}`, 0,
+		},
+		{
+			"一方修改数值，另一方只改写单位",
+			`{"mem": {"value": 1024, "unit": "MiB"}}`,
+			`{"mem": {"value": 2048, "unit": "MiB"}}`,
+			`{"mem": {"value": 1, "unit": "GiB"}}`,
+			`{"mem": {"value": 2048, "unit": "MiB"}}`, 0,
+		},
+		{
+			"另一方修改数值时保留它的写法",
+			`{"mem": {"value": 1024, "unit": "MiB"}, "cpu": 1}`,
+			`{"mem": {"value": 1, "unit": "GiB"}, "cpu": 2}`,
+			`{"mem": {"value": 1, "unit": "GB"}, "cpu": 1}`,
+			`{"mem": {"value": 1, "unit": "GB"}, "cpu": 2}`, 0,
+		},
+		{
+			"双方改为相同的大小",
+			`{"disks": [{"value": 1, "unit": "TB"}]}`,
+			`{"disks": [{"value": 2, "unit": "TB"}]}`,
+			`{"disks": [{"value": 2000, "unit": "GB"}]}`,
+			`{"disks": [{"value": 2, "unit": "TB"}]}`, 0,
+		},
+		{
+			"双方改为不同的大小",
+			`{"mem": {"value": 1, "unit": "GiB"}}`,
+			`{"mem": {"value": 2, "unit": "GiB"}}`,
+			`{"mem": {"value": 3072, "unit": "MiB"}}`,
+			"", 1,
+		},
+		{
+			"一方修改数值，另一方修改单位",
+			`{"mem": {"value": 1, "unit": "GiB"}}`,
+			`{"mem": {"value": 2, "unit": "GiB"}}`,
+			`{"mem": {"value": 1, "unit": "GB"}}`,
+			"", 1,
+		},
+		{
+			"一方删除，另一方只改写单位",
+			`{"mem": {"value": 1024, "unit": "MiB"}, "a": 1}`,
+			`{"a": 1}`,
+			`{"mem": {"value": 1, "unit": "GiB"}, "a": 1}`,
+			`{"a": 1}`, 0,
+		},
+	}
+	for _, tt := range tests {
+		base, ours, theirs := mustParse(t, tt.base), mustParse(t, tt.ours), mustParse(t, tt.theirs)
+		merged, conflicts := Merge3WithOptions(base, ours, theirs, Merge3Options{Units: DefaultUnitTable()})
+		if len(conflicts) != tt.conflicts {
+			t.Errorf("%s: %d 处冲突, 期望 %d", tt.name, len(conflicts), tt.conflicts)
+			continue
+		}
+		if tt.expected != "" && !Equal(merged, mustParse(t, tt.expected)) {
+			text, _ := Stringify(merged)
+			t.Errorf("%s: 合并结果 = %s, 期望 %s", tt.name, text, tt.expected)
+		}
+		if !Equal(theirs, mustParse(t, tt.theirs)) {
+			t.Errorf("%s: 输入被修改了", tt.name)
+		}
+	}
+
+	// 不按单位合并时，逐个成员合并会得到错误的数值
+	merged, conflicts := Merge3(mustParse(t, tests[5].base), mustParse(t, tests[5].ours), mustParse(t, tests[5].theirs))
+	if text, _ := Stringify(merged); len(conflicts) != 0 || text != `{"mem":{"value":2,"unit":"GB"}}` {
+		t.Errorf("不按单位合并的结果 = %s", text)
+	}
+}
@@ -0,0 +1,201 @@
+// metrics.go - 以 Prometheus 文本格式导出解析指标
+package leptjson
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 直方图的默认桶上界
+var (
+	parseDurationBuckets = []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5}
+	documentBytesBuckets = []float64{256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304, 16777216}
+)
+
+// Metrics 收集解析操作的指标，并以 Prometheus 文本格式导出
+//
+// 导出的指标：
+//
+//	leptjson_parse_total                 解析次数
+//	leptjson_parse_errors_total{code}    按错误码统计的解析失败次数
+//	leptjson_parse_duration_seconds      解析耗时的直方图
+//	leptjson_document_bytes              被解析文档大小的直方图
+//
+// Metrics 可以被多个 goroutine 同时使用。
+type Metrics struct {
+	mu       sync.Mutex
+	total    uint64
+	errors   map[ParseError]uint64
+	duration histogram
+	bytes    histogram
+}
+
+// NewMetrics 创建空的指标集合
+func NewMetrics() *Metrics {
+	return &Metrics{
+		errors:   make(map[ParseError]uint64),
+		duration: newHistogram(parseDurationBuckets),
+		bytes:    newHistogram(documentBytesBuckets),
+	}
+}
+
+// ObserveParse 记录一次解析
+func (m *Metrics) ObserveParse(bytes int, duration time.Duration, err ParseError) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.total++
+	if err != PARSE_OK {
+		m.errors[err]++
+	}
+	m.duration.observe(duration.Seconds())
+	m.bytes.observe(float64(bytes))
+}
+
+// WriteTo 以 Prometheus 文本格式写出所有指标
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	cw := &countingWriter{w: bw}
+	fmt.Fprintln(cw, "# HELP leptjson_parse_total Total number of JSON parse operations.")
+	fmt.Fprintln(cw, "# TYPE leptjson_parse_total counter")
+	fmt.Fprintf(cw, "leptjson_parse_total %d\n", m.total)
+
+	fmt.Fprintln(cw, "# HELP leptjson_parse_errors_total Total number of failed JSON parse operations by error code.")
+	fmt.Fprintln(cw, "# TYPE leptjson_parse_errors_total counter")
+	codes := make([]ParseError, 0, len(m.errors))
+	for code := range m.errors {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	for _, code := range codes {
+		fmt.Fprintf(cw, "leptjson_parse_errors_total{code=%q} %d\n", parseErrorName(code), m.errors[code])
+	}
+
+	m.duration.write(cw, "leptjson_parse_duration_seconds", "Duration of JSON parse operations in seconds.")
+	m.bytes.write(cw, "leptjson_document_bytes", "Size of parsed JSON documents in bytes.")
+
+	if err := bw.Flush(); err != nil {
+		return cw.n, err
+	}
+	return cw.n, cw.err
+}
+
+// ServeHTTP 以 Prometheus 文本格式响应指标
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// histogram 是累计桶的直方图，不做并发保护
+type histogram struct {
+	bounds []float64
+	counts []uint64 // counts[i] 是落在 (bounds[i-1], bounds[i]] 中的观测数，最后一个元素对应 +Inf
+	sum    float64
+	count  uint64
+}
+
+func newHistogram(bounds []float64) histogram {
+	return histogram{bounds: bounds, counts: make([]uint64, len(bounds)+1)}
+}
+
+func (h *histogram) observe(x float64) {
+	i := sort.SearchFloat64s(h.bounds, x)
+	h.counts[i]++
+	h.sum += x
+	h.count++
+}
+
+func (h *histogram) write(w io.Writer, name, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", name)
+	var cumulative uint64
+	for i, bound := range h.bounds {
+		cumulative += h.counts[i]
+		fmt.Fprintf(w, "%s_bucket{le=%q} %d\n", name, strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", name, h.count)
+	fmt.Fprintf(w, "%s_sum %s\n", name, strconv.FormatFloat(h.sum, 'g', -1, 64))
+	fmt.Fprintf(w, "%s_count %d\n", name, h.count)
+}
+
+// countingWriter 统计写入的字节数并记住第一个错误
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
+
+// parseErrorNames 是错误码在指标标签中使用的名称
+var parseErrorNames = map[ParseError]string{
+	PARSE_EXPECT_VALUE:                 "expect_value",
+	PARSE_INVALID_VALUE:                "invalid_value",
+	PARSE_ROOT_NOT_SINGULAR:            "root_not_singular",
+	PARSE_NUMBER_TOO_BIG:               "number_too_big",
+	PARSE_MISS_QUOTATION_MARK:          "miss_quotation_mark",
+	PARSE_INVALID_STRING_ESCAPE:        "invalid_string_escape",
+	PARSE_INVALID_STRING_CHAR:          "invalid_string_char",
+	PARSE_INVALID_UNICODE_HEX:          "invalid_unicode_hex",
+	PARSE_INVALID_UNICODE_SURROGATE:    "invalid_unicode_surrogate",
+	PARSE_MISS_COMMA_OR_SQUARE_BRACKET: "miss_comma_or_square_bracket",
+	PARSE_MISS_KEY:                     "miss_key",
+	PARSE_MISS_COLON:                   "miss_colon",
+	PARSE_MISS_COMMA_OR_CURLY_BRACKET:  "miss_comma_or_curly_bracket",
+	PARSE_MAX_DEPTH_EXCEEDED:           "max_depth_exceeded",
+	PARSE_COMMENT_NOT_CLOSED:           "comment_not_closed",
+	PARSE_MAX_STRING_LENGTH_EXCEEDED:   "max_string_length_exceeded",
+	PARSE_MAX_ARRAY_SIZE_EXCEEDED:      "max_array_size_exceeded",
+	PARSE_MAX_OBJECT_SIZE_EXCEEDED:     "max_object_size_exceeded",
+	PARSE_MAX_TOTAL_SIZE_EXCEEDED:      "max_total_size_exceeded",
+	PARSE_NUMBER_RANGE_EXCEEDED:        "number_range_exceeded",
+	PARSE_SECURITY_VIOLATION:           "security_violation",
+	PARSE_KEY_NOT_ALLOWED:              "key_not_allowed",
+	PARSE_CANCELLED:                    "cancelled",
+}
+
+// parseErrorName 返回错误码的名称，未知的错误码使用数字
+func parseErrorName(code ParseError) string {
+	if name, ok := parseErrorNames[code]; ok {
+		return name
+	}
+	return strconv.Itoa(int(code))
+}
+
+// metricsHolder 包装 *Metrics，使 atomic.Value 中始终保存同一种类型
+type metricsHolder struct {
+	metrics *Metrics
+}
+
+var operationMetrics atomic.Value // metricsHolder
+
+// SetMetrics 让进程中所有的 ParseWithOptions 调用都记录到 m，为 nil 时停止记录
+//
+// Server 会自动记录请求体的解析，不需要调用 SetMetrics；如果同时把 Server
+// 使用的 Metrics 传给 SetMetrics，请求体会被记录两次。
+func SetMetrics(m *Metrics) {
+	operationMetrics.Store(metricsHolder{metrics: m})
+}
+
+// currentMetrics 返回当前的全局指标，没有设置时返回 nil
+func currentMetrics() *Metrics {
+	holder, _ := operationMetrics.Load().(metricsHolder)
+	return holder.metrics
+}
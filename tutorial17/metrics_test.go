@@ -0,0 +1,80 @@
+package leptjson
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsExposition(t *testing.T) {
+	m := NewMetrics()
+	m.ObserveParse(100, 200*time.Microsecond, PARSE_OK)
+	m.ObserveParse(5000, 2*time.Millisecond, PARSE_OK)
+	m.ObserveParse(10, 50*time.Microsecond, PARSE_INVALID_VALUE)
+	m.ObserveParse(10, 50*time.Microsecond, PARSE_KEY_NOT_ALLOWED)
+	m.ObserveParse(10, 50*time.Microsecond, PARSE_INVALID_VALUE)
+
+	var sb strings.Builder
+	if _, err := m.WriteTo(&sb); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+	for _, line := range []string{
+		"# TYPE leptjson_parse_total counter",
+		"leptjson_parse_total 5",
+		`leptjson_parse_errors_total{code="invalid_value"} 2`,
+		`leptjson_parse_errors_total{code="key_not_allowed"} 1`,
+		"# TYPE leptjson_parse_duration_seconds histogram",
+		`leptjson_parse_duration_seconds_bucket{le="0.0001"} 3`,
+		`leptjson_parse_duration_seconds_bucket{le="0.0005"} 4`,
+		`leptjson_parse_duration_seconds_bucket{le="+Inf"} 5`,
+		"leptjson_parse_duration_seconds_count 5",
+		`leptjson_document_bytes_bucket{le="256"} 4`,
+		`leptjson_document_bytes_bucket{le="16384"} 5`,
+		"leptjson_document_bytes_sum 5130",
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("缺少 %q\n%s", line, out)
+		}
+	}
+}
+
+func TestSetMetrics(t *testing.T) {
+	m := NewMetrics()
+	SetMetrics(m)
+	defer SetMetrics(nil)
+
+	v := &Value{}
+	Parse(v, `[1,2]`)
+	Parse(v, `[1,`)
+	SetMetrics(nil)
+	Parse(v, `1`)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.total != 2 || len(m.errors) != 1 || m.bytes.sum != 8 {
+		t.Errorf("total = %d, errors = %v, bytes = %v", m.total, m.errors, m.bytes.sum)
+	}
+}
+
+func TestServerMetrics(t *testing.T) {
+	s := NewServer(DefaultServerOptions())
+	serverRequest(t, s, http.MethodPost, "/format", `{"a":1}`)
+	serverRequest(t, s, http.MethodPost, "/format", `{"a":`)
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("GET /metrics = %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "leptjson_parse_total 2\n") || !strings.Contains(body, "leptjson_parse_errors_total{code=") {
+		t.Errorf("指标 = %s", body)
+	}
+
+	if status, _ := serverRequest(t, s, http.MethodPost, "/metrics", ""); status != http.StatusMethodNotAllowed {
+		t.Errorf("POST /metrics 状态码 = %d", status)
+	}
+}
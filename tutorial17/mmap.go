@@ -0,0 +1,105 @@
+// mmap.go - 内存映射文件输入和零拷贝的 []byte 解析
+package leptjson
+
+import (
+	"fmt"
+	"os"
+	"unsafe"
+)
+
+// MappedFile 是以只读方式映射到内存的文件
+//
+// 文件内容由操作系统按需换入，并可以随时换出，解析超大文件时常驻内存
+// 只包含解析出的 Value，而不是文件内容加上 Value 两份数据。
+type MappedFile struct {
+	data  []byte
+	unmap func() error
+}
+
+// MapFile 将文件映射到内存，使用完毕后必须调用 Close
+//
+// 不支持 mmap 的平台上退化为一次性读取整个文件。
+func MapFile(filename string) (*MappedFile, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if size == 0 {
+		// 长度为0的映射是非法的
+		return &MappedFile{unmap: func() error { return nil }}, nil
+	}
+	if int64(int(size)) != size {
+		return nil, fmt.Errorf("文件过大，无法映射: %d字节", size)
+	}
+
+	data, unmap, err := mmapFile(f, int(size))
+	if err != nil {
+		return nil, fmt.Errorf("映射文件失败: %w", err)
+	}
+	return &MappedFile{data: data, unmap: unmap}, nil
+}
+
+// Bytes 返回映射的文件内容，Close 之后不能再访问
+func (m *MappedFile) Bytes() []byte {
+	return m.data
+}
+
+// Len 返回文件大小
+func (m *MappedFile) Len() int {
+	return len(m.data)
+}
+
+// Close 解除映射，多次调用是安全的
+func (m *MappedFile) Close() error {
+	if m.unmap == nil {
+		return nil
+	}
+	err := m.unmap()
+	m.data = nil
+	m.unmap = nil
+	return err
+}
+
+// ParseBytes 解析 []byte 形式的JSON文本，不复制输入
+//
+// 解析结果中的字符串都是新分配的，不引用 data，解析完成后可以立即修改或释放 data
+// （例如解除文件映射）。NumberHandler 收到的数字文本同样是独立的副本。
+func ParseBytes(v *Value, data []byte, options ParseOptions) ParseError {
+	if handler := options.NumberHandler; handler != nil {
+		options.NumberHandler = func(v *Value, literal string) ParseError {
+			return handler(v, string([]byte(literal)))
+		}
+	}
+	c := newContext(bytesToString(data), options)
+	c.borrowed = true
+	return parseObserved(c, v)
+}
+
+// ParseFile 通过内存映射读取并解析文件
+func ParseFile(v *Value, filename string, options ParseOptions) error {
+	m, err := MapFile(filename)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if parseErr := ParseBytes(v, m.Bytes(), options); parseErr != PARSE_OK {
+		return parseErr
+	}
+	return nil
+}
+
+// bytesToString 将 []byte 转换为共享底层内存的 string
+func bytesToString(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	return *(*string)(unsafe.Pointer(&b))
+}
@@ -0,0 +1,22 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+// mmap_other.go - 不支持 mmap 的平台上退化为读取整个文件
+package leptjson
+
+import (
+	"io"
+	"os"
+)
+
+// mmapSupported 表示当前平台是否支持内存映射
+const mmapSupported = false
+
+// mmapFile 读取整个文件，行为与内存映射一致但需要占用同样大小的内存
+func mmapFile(f *os.File, size int) ([]byte, func() error, error) {
+	data := make([]byte, size)
+	if _, err := io.ReadFull(f, data); err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
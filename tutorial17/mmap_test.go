@@ -0,0 +1,108 @@
+package leptjson
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTempJSON(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "data.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestMapFile(t *testing.T) {
+	content := `{"name": "leptjson", "tags": ["a", "b"]}`
+	m, err := MapFile(writeTempJSON(t, content))
+	if err != nil {
+		t.Fatalf("MapFile 失败: %v", err)
+	}
+	if string(m.Bytes()) != content || m.Len() != len(content) {
+		t.Errorf("映射内容错误: %q", m.Bytes())
+	}
+	if err := m.Close(); err != nil {
+		t.Errorf("Close 失败: %v", err)
+	}
+	if err := m.Close(); err != nil {
+		t.Errorf("重复 Close 应当是安全的: %v", err)
+	}
+
+	empty, err := MapFile(writeTempJSON(t, ""))
+	if err != nil || empty.Len() != 0 {
+		t.Errorf("空文件应当可以映射: %v", err)
+	}
+	empty.Close()
+
+	if _, err := MapFile(filepath.Join(t.TempDir(), "missing.json")); !os.IsNotExist(err) {
+		t.Errorf("文件不存在时应当返回 IsNotExist 错误: %v", err)
+	}
+	t.Logf("mmap 支持: %v", mmapSupported)
+}
+
+func TestParseBytesDoesNotRetainInput(t *testing.T) {
+	data := []byte(`{"key": "value", "list": [1.5, "x"], "n": 0.1000000000000000055}`)
+	literals := NewNumberLiterals()
+	opts := DefaultParseOptions()
+	opts.NumberHandler = literals.Handle
+
+	var v Value
+	if err := ParseBytes(&v, data, opts); err != PARSE_OK {
+		t.Fatalf("ParseBytes 失败: %v", err)
+	}
+
+	// 覆盖输入后解析结果不受影响
+	for i := range data {
+		data[i] = 'X'
+	}
+	if got, _ := Stringify(&v); got != `{"key":"value","list":[1.5,"x"],"n":0.1}` {
+		t.Errorf("解析结果引用了输入: %s", got)
+	}
+	if literal, ok := literals.Literal(GetObjectValueByKey(&v, "n")); !ok || literal != "0.1000000000000000055" {
+		t.Errorf("数字文本引用了输入: %q", literal)
+	}
+
+	if err := ParseBytes(&v, nil, opts); err != PARSE_EXPECT_VALUE {
+		t.Errorf("空输入应当返回 PARSE_EXPECT_VALUE, 实际为 %v", err)
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	var v Value
+	if err := ParseFile(&v, writeTempJSON(t, `[1, {"a": true}]`), DefaultParseOptions()); err != nil {
+		t.Fatalf("ParseFile 失败: %v", err)
+	}
+	if got, _ := Stringify(&v); got != `[1,{"a":true}]` {
+		t.Errorf("ParseFile 结果错误: %s", got)
+	}
+
+	err := ParseFile(&v, writeTempJSON(t, `[1,`), DefaultParseOptions())
+	if parseErr, ok := err.(ParseError); !ok || parseErr == PARSE_OK {
+		t.Errorf("无效JSON应当返回 ParseError, 实际为 %v", err)
+	}
+}
+
+func TestParseFileChunkedString(t *testing.T) {
+	long := strings.Repeat("a", 5000)
+	opts := DefaultParseOptions()
+	opts.StringChunkThreshold = 1024
+	var v Value
+	if err := ParseFile(&v, writeTempJSON(t, `{"s": "`+long+`"}`), opts); err != nil {
+		t.Fatalf("ParseFile 失败: %v", err)
+	}
+	// 文件已经解除映射，分块字符串不能引用映射的内存
+	s := GetObjectValueByKey(&v, "s")
+	if s.chunks == nil {
+		t.Fatal("字符串应当分块保存")
+	}
+	if got, _ := Stringify(&v); got != `{"s":"`+long+`"}` {
+		t.Errorf("ParseFile 结果错误: %.40s...", got)
+	}
+	if GetString(s) != long {
+		t.Error("GetString 结果错误")
+	}
+}
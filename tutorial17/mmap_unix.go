@@ -0,0 +1,22 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+// mmap_unix.go - 在类Unix系统上使用 mmap 映射文件
+package leptjson
+
+import (
+	"os"
+	"syscall"
+)
+
+// mmapSupported 表示当前平台是否支持内存映射
+const mmapSupported = true
+
+// mmapFile 将文件以只读方式映射到内存，返回的函数解除映射
+func mmapFile(f *os.File, size int) ([]byte, func() error, error) {
+	data, err := syscall.Mmap(int(f.Fd()), 0, size, syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
@@ -0,0 +1,97 @@
+// operation_log.go - 记录解析和序列化操作的结构化日志
+package leptjson
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Logger 接收结构化日志，args 是交替出现的键和值
+//
+// *slog.Logger 直接满足此接口；zap 等其他日志库可以通过 LoggerFunc 适配。
+type Logger interface {
+	Info(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// LoggerFunc 将函数适配为 Logger，level 为 "info" 或 "error"
+//
+// 例如 zap 的 SugaredLogger：
+//
+//	leptjson.SetLogger(leptjson.LoggerFunc(func(level, msg string, kv ...interface{}) {
+//		if level == "error" {
+//			sugar.Errorw(msg, kv...)
+//		} else {
+//			sugar.Infow(msg, kv...)
+//		}
+//	}))
+type LoggerFunc func(level, msg string, args ...interface{})
+
+// Info 记录一条普通日志
+func (f LoggerFunc) Info(msg string, args ...interface{}) {
+	f("info", msg, args...)
+}
+
+// Error 记录一条错误日志
+func (f LoggerFunc) Error(msg string, args ...interface{}) {
+	f("error", msg, args...)
+}
+
+// loggerHolder 包装 Logger，使 atomic.Value 中始终保存同一种类型
+type loggerHolder struct {
+	logger Logger
+}
+
+var operationLogger atomic.Value // loggerHolder
+
+// SetLogger 设置记录解析和序列化操作的日志，为 nil 时关闭日志
+//
+// 每次 Parse、ParseWithOptions、Stringify 和 StringifyWithOptions 调用结束后记录
+// 一条消息 "json parse" 或 "json stringify"，包含以下键：
+//
+//	op        操作名称，parse 或 stringify
+//	bytes     输入（解析）或输出（序列化）的字节数
+//	duration  耗时，time.Duration
+//	nodes     值的总数，解析失败时为0
+//	code      解析错误码，只在失败时出现，同时以 Error 级别记录 error 描述
+//
+// 没有设置日志时不会计时或统计节点，开销只有一次原子读取。
+func SetLogger(logger Logger) {
+	operationLogger.Store(loggerHolder{logger: logger})
+}
+
+// currentLogger 返回当前的日志，没有设置时返回 nil
+func currentLogger() Logger {
+	holder, _ := operationLogger.Load().(loggerHolder)
+	return holder.logger
+}
+
+// logParse 记录一次解析操作
+func logParse(logger Logger, bytes int, start time.Time, v *Value, err ParseError) {
+	if err != PARSE_OK {
+		logger.Error("json parse", "op", "parse", "bytes", bytes, "duration", time.Since(start),
+			"nodes", 0, "code", int(err), "error", GetErrorMessage(err))
+		return
+	}
+	logger.Info("json parse", "op", "parse", "bytes", bytes, "duration", time.Since(start), "nodes", countNodes(v))
+}
+
+// logStringify 记录一次序列化操作
+func logStringify(logger Logger, bytes int, start time.Time, v *Value) {
+	logger.Info("json stringify", "op", "stringify", "bytes", bytes, "duration", time.Since(start), "nodes", countNodes(v))
+}
+
+// countNodes 返回 v 及其所有子值的数量
+func countNodes(v *Value) int {
+	if v == nil {
+		return 0
+	}
+	n := 1
+	for _, e := range v.A {
+		n += countNodes(e)
+	}
+	for _, m := range v.O {
+		n += countNodes(m.V)
+	}
+	return n
+}
@@ -0,0 +1,78 @@
+package leptjson
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// recordingLogger 保存收到的日志，每条日志转换为 level、msg 和键值映射
+type recordingLogger struct {
+	entries []map[string]interface{}
+}
+
+func (l *recordingLogger) record(level, msg string, args []interface{}) {
+	entry := map[string]interface{}{"level": level, "msg": msg}
+	for i := 0; i+1 < len(args); i += 2 {
+		entry[fmt.Sprint(args[i])] = args[i+1]
+	}
+	l.entries = append(l.entries, entry)
+}
+
+func (l *recordingLogger) Info(msg string, args ...interface{})  { l.record("info", msg, args) }
+func (l *recordingLogger) Error(msg string, args ...interface{}) { l.record("error", msg, args) }
+
+func TestOperationLogging(t *testing.T) {
+	logger := &recordingLogger{}
+	SetLogger(logger)
+	defer SetLogger(nil)
+
+	v := &Value{}
+	Parse(v, `{"a":[1,2],"b":null}`)
+	Stringify(v)
+	StringifyWithOptions(v, StringifyOptions{Indent: "  "})
+	Parse(v, `[1,`)
+
+	if len(logger.entries) != 4 {
+		t.Fatalf("日志数量 = %d, 期望 4: %v", len(logger.entries), logger.entries)
+	}
+	parse := logger.entries[0]
+	if parse["msg"] != "json parse" || parse["op"] != "parse" || parse["bytes"] != 20 || parse["nodes"] != 5 {
+		t.Errorf("解析日志 = %v", parse)
+	}
+	if _, ok := parse["duration"].(time.Duration); !ok {
+		t.Errorf("duration 应当是 time.Duration: %v", parse["duration"])
+	}
+	if s := logger.entries[1]; s["op"] != "stringify" || s["bytes"] != 20 || s["nodes"] != 5 {
+		t.Errorf("序列化日志 = %v", s)
+	}
+	if s := logger.entries[2]; s["op"] != "stringify" || s["bytes"].(int) <= 20 {
+		t.Errorf("带选项的序列化日志 = %v", s)
+	}
+	failed := logger.entries[3]
+	if code, ok := failed["code"].(int); failed["level"] != "error" || !ok || code == int(PARSE_OK) || failed["error"] == nil {
+		t.Errorf("失败的解析日志 = %v", failed)
+	}
+
+	SetLogger(nil)
+	Parse(v, `1`)
+	if len(logger.entries) != 4 {
+		t.Errorf("关闭日志后不应当再记录")
+	}
+}
+
+func TestLoggerFunc(t *testing.T) {
+	var levels []string
+	logger := LoggerFunc(func(level, msg string, args ...interface{}) {
+		levels = append(levels, level+":"+msg)
+	})
+	SetLogger(logger)
+	defer SetLogger(nil)
+
+	v := &Value{}
+	Parse(v, `true`)
+	Parse(v, `tru`)
+	if fmt.Sprint(levels) != "[info:json parse error:json parse]" {
+		t.Errorf("日志 = %v", levels)
+	}
+}
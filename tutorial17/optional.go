@@ -0,0 +1,109 @@
+// optional.go - 区分对象成员缺失、为null和有值的辅助函数
+package leptjson
+
+// Presence 表示对象成员的存在状态
+//
+// 处理 HTTP PATCH 请求体时，"键不存在"表示不修改该字段，
+// "键为 null" 表示清除该字段，两者必须加以区分。
+type Presence int
+
+// 存在状态常量
+const (
+	PRESENCE_ABSENT Presence = iota // 键不存在
+	PRESENCE_NULL                   // 键存在且值为 null
+	PRESENCE_VALUE                  // 键存在且值不为 null
+)
+
+// String 返回存在状态的名称
+func (p Presence) String() string {
+	switch p {
+	case PRESENCE_ABSENT:
+		return "absent"
+	case PRESENCE_NULL:
+		return "null"
+	case PRESENCE_VALUE:
+		return "value"
+	default:
+		return "unknown"
+	}
+}
+
+// OptionalValue 表示从对象中读取的可能缺失的成员
+type OptionalValue struct {
+	Presence Presence
+	Value    *Value // 键不存在时为nil
+}
+
+// GetOptional 读取对象成员并记录其存在状态，v 不是对象时视为键不存在
+func GetOptional(v *Value, key string) OptionalValue {
+	member, ok := FindObjectKey(v, key)
+	switch {
+	case !ok:
+		return OptionalValue{Presence: PRESENCE_ABSENT}
+	case member.Type == NULL:
+		return OptionalValue{Presence: PRESENCE_NULL, Value: member}
+	default:
+		return OptionalValue{Presence: PRESENCE_VALUE, Value: member}
+	}
+}
+
+// IsAbsent 判断键是否不存在
+func (o OptionalValue) IsAbsent() bool {
+	return o.Presence == PRESENCE_ABSENT
+}
+
+// IsNull 判断键是否存在且值为 null
+func (o OptionalValue) IsNull() bool {
+	return o.Presence == PRESENCE_NULL
+}
+
+// IsPresent 判断键是否存在（包括值为 null 的情况）
+func (o OptionalValue) IsPresent() bool {
+	return o.Presence != PRESENCE_ABSENT
+}
+
+// HasValue 判断键是否存在且值不为 null
+func (o OptionalValue) HasValue() bool {
+	return o.Presence == PRESENCE_VALUE
+}
+
+// Get 返回成员的值，仅当键存在且值不为 null 时第二个返回值为 true
+func (o OptionalValue) Get() (*Value, bool) {
+	return o.Value, o.HasValue()
+}
+
+// GetOptionalBoolean 读取布尔类型的对象成员
+//
+// 仅当键存在且值既不是 null 也不是布尔值时返回 *ErrWrongType。
+func GetOptionalBoolean(v *Value, key string) (bool, Presence, error) {
+	o := GetOptional(v, key)
+	if !o.HasValue() {
+		return false, o.Presence, nil
+	}
+	b, err := GetBooleanChecked(o.Value)
+	return b, o.Presence, err
+}
+
+// GetOptionalNumber 读取数字类型的对象成员
+//
+// 仅当键存在且值既不是 null 也不是数字时返回 *ErrWrongType。
+func GetOptionalNumber(v *Value, key string) (float64, Presence, error) {
+	o := GetOptional(v, key)
+	if !o.HasValue() {
+		return 0, o.Presence, nil
+	}
+	n, err := GetNumberChecked(o.Value)
+	return n, o.Presence, err
+}
+
+// GetOptionalString 读取字符串类型的对象成员
+//
+// 仅当键存在且值既不是 null 也不是字符串时返回 *ErrWrongType。
+func GetOptionalString(v *Value, key string) (string, Presence, error) {
+	o := GetOptional(v, key)
+	if !o.HasValue() {
+		return "", o.Presence, nil
+	}
+	s, err := GetStringChecked(o.Value)
+	return s, o.Presence, err
+}
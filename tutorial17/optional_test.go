@@ -0,0 +1,58 @@
+package leptjson
+
+import (
+	"testing"
+)
+
+func TestGetOptional(t *testing.T) {
+	body := &Value{}
+	if err := Parse(body, `{"name":"Bob","nickname":null,"age":30,"admin":"yes"}`); err != PARSE_OK {
+		t.Fatalf("解析失败: %s", err.Error())
+	}
+
+	tests := []struct {
+		key      string
+		presence Presence
+	}{
+		{"name", PRESENCE_VALUE},
+		{"nickname", PRESENCE_NULL},
+		{"email", PRESENCE_ABSENT},
+	}
+	for _, tt := range tests {
+		o := GetOptional(body, tt.key)
+		if o.Presence != tt.presence {
+			t.Errorf("GetOptional(%q) = %s, 期望 %s", tt.key, o.Presence, tt.presence)
+		}
+		if o.IsPresent() != (tt.presence != PRESENCE_ABSENT) {
+			t.Errorf("GetOptional(%q).IsPresent() 结果错误", tt.key)
+		}
+	}
+
+	if v, ok := GetOptional(body, "nickname").Get(); ok || v == nil || v.Type != NULL {
+		t.Error("null 成员的 Get 应当返回 null 值和 false")
+	}
+	if o := GetOptional(&Value{Type: ARRAY}, "name"); !o.IsAbsent() {
+		t.Error("非对象值应当视为键不存在")
+	}
+}
+
+func TestGetOptionalTyped(t *testing.T) {
+	body := &Value{}
+	Parse(body, `{"name":"Bob","nickname":null,"age":30,"admin":"yes"}`)
+
+	if s, p, err := GetOptionalString(body, "name"); err != nil || p != PRESENCE_VALUE || s != "Bob" {
+		t.Errorf("GetOptionalString(name) = %q, %s, %v", s, p, err)
+	}
+	if _, p, err := GetOptionalString(body, "nickname"); err != nil || p != PRESENCE_NULL {
+		t.Errorf("GetOptionalString(nickname) = %s, %v", p, err)
+	}
+	if n, p, err := GetOptionalNumber(body, "age"); err != nil || p != PRESENCE_VALUE || n != 30 {
+		t.Errorf("GetOptionalNumber(age) = %v, %s, %v", n, p, err)
+	}
+	if _, p, err := GetOptionalNumber(body, "height"); err != nil || p != PRESENCE_ABSENT {
+		t.Errorf("GetOptionalNumber(height) = %s, %v", p, err)
+	}
+	if _, p, err := GetOptionalBoolean(body, "admin"); err == nil || p != PRESENCE_VALUE {
+		t.Errorf("类型不匹配时应当返回错误, 实际为 %s, %v", p, err)
+	}
+}
@@ -0,0 +1,297 @@
+// parquet.go - 把列式数据写为 Apache Parquet 文件
+package leptjson
+
+import (
+	"bufio"
+	"io"
+	"math"
+)
+
+// Parquet 元数据中使用的枚举值，见 parquet-format 仓库的 parquet.thrift
+const (
+	parquetBoolean       = 0 // Type.BOOLEAN
+	parquetInt64         = 2 // Type.INT64
+	parquetDouble        = 5 // Type.DOUBLE
+	parquetByteArray     = 6 // Type.BYTE_ARRAY
+	parquetOptional      = 1 // FieldRepetitionType.OPTIONAL
+	parquetConvertedUTF8 = 0 // ConvertedType.UTF8
+	parquetPlain         = 0 // Encoding.PLAIN
+	parquetRLE           = 3 // Encoding.RLE
+	parquetUncompressed  = 0 // CompressionCodec.UNCOMPRESSED
+	parquetDataPage      = 0 // PageType.DATA_PAGE
+)
+
+// parquetMagic 是文件开头和结尾的标记
+const parquetMagic = "PAR1"
+
+// parquetCreatedBy 写入 FileMetaData.created_by
+const parquetCreatedBy = "leptjson"
+
+// Thrift Compact 协议的字段类型
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter 以 Thrift Compact 协议编码结构体
+type thriftWriter struct {
+	buf    []byte
+	lastID int16
+	stack  []int16 // 外层结构体的 lastID
+}
+
+func (w *thriftWriter) varint(v uint64) {
+	for v >= 0x80 {
+		w.buf = append(w.buf, byte(v)|0x80)
+		v >>= 7
+	}
+	w.buf = append(w.buf, byte(v))
+}
+
+func (w *thriftWriter) zigzag(v int64) {
+	w.varint(uint64(v<<1) ^ uint64(v>>63))
+}
+
+// field 写入字段头：与上一个字段编号的差在 1 到 15 之间时和类型合并为一个字节
+func (w *thriftWriter) field(id int16, typ byte) {
+	if delta := id - w.lastID; delta > 0 && delta <= 15 {
+		w.buf = append(w.buf, byte(delta)<<4|typ)
+	} else {
+		w.buf = append(w.buf, typ)
+		w.zigzag(int64(id))
+	}
+	w.lastID = id
+}
+
+func (w *thriftWriter) i32(id int16, v int32) {
+	w.field(id, thriftI32)
+	w.zigzag(int64(v))
+}
+
+func (w *thriftWriter) i64(id int16, v int64) {
+	w.field(id, thriftI64)
+	w.zigzag(v)
+}
+
+func (w *thriftWriter) binary(id int16, s string) {
+	w.field(id, thriftBinary)
+	w.varint(uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+// list 写入列表头，之后由调用方依次写入 n 个元素
+func (w *thriftWriter) list(id int16, elemType byte, n int) {
+	w.field(id, thriftList)
+	if n < 15 {
+		w.buf = append(w.buf, byte(n)<<4|elemType)
+	} else {
+		w.buf = append(w.buf, 0xF0|elemType)
+		w.varint(uint64(n))
+	}
+}
+
+// beginStruct 开始一个结构体类型的字段，id 为 0 时开始列表中的结构体元素
+func (w *thriftWriter) beginStruct(id int16) {
+	if id != 0 {
+		w.field(id, thriftStruct)
+	}
+	w.stack = append(w.stack, w.lastID)
+	w.lastID = 0
+}
+
+// endStruct 写入结束标记
+func (w *thriftWriter) endStruct() {
+	w.buf = append(w.buf, 0)
+	w.lastID = w.stack[len(w.stack)-1]
+	w.stack = w.stack[:len(w.stack)-1]
+}
+
+// parquetColumnChunk 记录写入的列块在文件中的位置
+type parquetColumnChunk struct {
+	offset    int64
+	size      int64
+	numValues int
+	nullCount int
+}
+
+// parquetPhysicalType 返回列类型对应的物理类型
+func parquetPhysicalType(t ColumnType) int32 {
+	switch t {
+	case COLUMN_INT64:
+		return parquetInt64
+	case COLUMN_FLOAT64:
+		return parquetDouble
+	case COLUMN_BOOL:
+		return parquetBoolean
+	default:
+		return parquetByteArray
+	}
+}
+
+// parquetPageData 返回数据页的内容：定义级别和 PLAIN 编码的非 null 值
+//
+// 列都是 OPTIONAL 的，最大定义级别为 1，定义级别用位宽为 1 的 RLE/位打包混合编码，
+// 前面是4字节的长度。
+func parquetPageData(c *ColumnData, t ColumnType, length int) []byte {
+	levels := &thriftWriter{}
+	levels.varint(uint64((length+7)/8)<<1 | 1) // 位打包，每组8个值
+	levels.buf = append(levels.buf, packBits(c.Valid)...)
+
+	data := appendUint32LE(nil, uint32(len(levels.buf)))
+	data = append(data, levels.buf...)
+	switch t {
+	case COLUMN_INT64:
+		for i, v := range c.Int64s {
+			if c.Valid[i] {
+				data = appendUint64LE(data, uint64(v))
+			}
+		}
+	case COLUMN_FLOAT64:
+		for i, v := range c.Float64s {
+			if c.Valid[i] {
+				data = appendUint64LE(data, math.Float64bits(v))
+			}
+		}
+	case COLUMN_BOOL:
+		var bits []bool
+		for i, v := range c.Bools {
+			if c.Valid[i] {
+				bits = append(bits, v)
+			}
+		}
+		data = append(data, packBits(bits)...)
+	default:
+		for i, v := range c.Strings {
+			if c.Valid[i] {
+				data = appendUint32LE(data, uint32(len(v)))
+				data = append(data, v...)
+			}
+		}
+	}
+	return data
+}
+
+// parquetPageHeader 返回未压缩的 DATA_PAGE 的 PageHeader
+func parquetPageHeader(size int, numValues int) []byte {
+	w := &thriftWriter{}
+	w.i32(1, parquetDataPage)
+	w.i32(2, int32(size)) // uncompressed_page_size
+	w.i32(3, int32(size)) // compressed_page_size
+	w.beginStruct(5)      // data_page_header
+	w.i32(1, int32(numValues))
+	w.i32(2, parquetPlain) // encoding
+	w.i32(3, parquetRLE)   // definition_level_encoding
+	w.i32(4, parquetRLE)   // repetition_level_encoding
+	w.endStruct()
+	w.buf = append(w.buf, 0)
+	return w.buf
+}
+
+// parquetFileMetaData 返回文件尾部的 FileMetaData
+func (t *ColumnarTable) parquetFileMetaData(chunks [][]parquetColumnChunk) []byte {
+	w := &thriftWriter{}
+	w.i32(1, 1) // version
+
+	w.list(2, thriftStruct, len(t.Fields)+1) // schema
+	w.beginStruct(0)
+	w.binary(4, "schema")
+	w.i32(5, int32(len(t.Fields))) // num_children
+	w.endStruct()
+	for _, f := range t.Fields {
+		w.beginStruct(0)
+		w.i32(1, parquetPhysicalType(f.Type))
+		w.i32(3, parquetOptional)
+		w.binary(4, f.Name)
+		if f.Type == COLUMN_UTF8 {
+			w.i32(6, parquetConvertedUTF8)
+			w.beginStruct(10) // logicalType
+			w.beginStruct(1)  // STRING
+			w.endStruct()
+			w.endStruct()
+		}
+		w.endStruct()
+	}
+
+	w.i64(3, int64(t.Rows))
+	w.list(4, thriftStruct, len(t.Batches)) // row_groups
+	for i, batch := range t.Batches {
+		w.beginStruct(0)
+		var total int64
+		w.list(1, thriftStruct, len(t.Fields)) // columns
+		for j, f := range t.Fields {
+			chunk := chunks[i][j]
+			total += chunk.size
+			w.beginStruct(0)
+			w.i64(2, chunk.offset) // file_offset
+			w.beginStruct(3)       // meta_data
+			w.i32(1, parquetPhysicalType(f.Type))
+			w.list(2, thriftI32, 2) // encodings
+			w.zigzag(parquetPlain)
+			w.zigzag(parquetRLE)
+			w.list(3, thriftBinary, 1) // path_in_schema
+			w.varint(uint64(len(f.Name)))
+			w.buf = append(w.buf, f.Name...)
+			w.i32(4, parquetUncompressed)
+			w.i64(5, int64(chunk.numValues))
+			w.i64(6, chunk.size) // total_uncompressed_size
+			w.i64(7, chunk.size) // total_compressed_size
+			w.i64(9, chunk.offset)
+			w.beginStruct(12) // statistics
+			w.i64(3, int64(chunk.nullCount))
+			w.endStruct()
+			w.endStruct()
+			w.endStruct()
+		}
+		w.i64(2, total) // total_byte_size
+		w.i64(3, int64(batch.Length))
+		w.endStruct()
+	}
+	w.binary(6, parquetCreatedBy)
+	w.buf = append(w.buf, 0)
+	return w.buf
+}
+
+// WriteParquet 以 Parquet 格式输出表
+//
+// 每个批次是一个行组，每列一个未压缩的数据页，所有列都是 OPTIONAL 的。
+// utf8 列的物理类型是带 STRING 逻辑类型的 BYTE_ARRAY。
+func (t *ColumnarTable) WriteParquet(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	cw := &countingWriter{w: bw}
+	if _, err := cw.Write([]byte(parquetMagic)); err != nil {
+		return err
+	}
+
+	chunks := make([][]parquetColumnChunk, len(t.Batches))
+	for i, batch := range t.Batches {
+		chunks[i] = make([]parquetColumnChunk, len(t.Fields))
+		for j, f := range t.Fields {
+			c := batch.Columns[j]
+			data := parquetPageData(c, f.Type, batch.Length)
+			header := parquetPageHeader(len(data), batch.Length)
+			offset := cw.n
+			if _, err := cw.Write(header); err != nil {
+				return err
+			}
+			if _, err := cw.Write(data); err != nil {
+				return err
+			}
+			chunks[i][j] = parquetColumnChunk{offset, cw.n - offset, batch.Length, c.NullCount}
+		}
+	}
+
+	meta := t.parquetFileMetaData(chunks)
+	if _, err := cw.Write(meta); err != nil {
+		return err
+	}
+	if _, err := cw.Write(appendUint32LE(nil, uint32(len(meta)))); err != nil {
+		return err
+	}
+	if _, err := cw.Write([]byte(parquetMagic)); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
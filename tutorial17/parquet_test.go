@@ -0,0 +1,213 @@
+package leptjson
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// thriftReader 解码 Thrift Compact 协议，结构体解码为字段编号到值的映射
+type thriftReader struct {
+	buf []byte
+	pos int
+}
+
+func (r *thriftReader) varint() uint64 {
+	var v uint64
+	for shift := uint(0); ; shift += 7 {
+		b := r.buf[r.pos]
+		r.pos++
+		v |= uint64(b&0x7F) << shift
+		if b < 0x80 {
+			return v
+		}
+	}
+}
+
+func (r *thriftReader) zigzag() int64 {
+	v := r.varint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case 1:
+		return true
+	case 2:
+		return false
+	case thriftI32, thriftI64:
+		return r.zigzag()
+	case thriftBinary:
+		n := int(r.varint())
+		r.pos += n
+		return string(r.buf[r.pos-n : r.pos])
+	case thriftList:
+		header := r.buf[r.pos]
+		r.pos++
+		n := int(header >> 4)
+		if n == 15 {
+			n = int(r.varint())
+		}
+		list := make([]interface{}, n)
+		for i := range list {
+			list[i] = r.value(header & 0x0F)
+		}
+		return list
+	case thriftStruct:
+		return r.structure()
+	default:
+		panic("不支持的 Thrift 类型")
+	}
+}
+
+func (r *thriftReader) structure() map[int16]interface{} {
+	fields := make(map[int16]interface{})
+	var last int16
+	for {
+		header := r.buf[r.pos]
+		r.pos++
+		if header == 0 {
+			return fields
+		}
+		id := last + int16(header>>4)
+		if header>>4 == 0 {
+			id = int16(r.zigzag())
+		}
+		last = id
+		fields[id] = r.value(header & 0x0F)
+	}
+}
+
+func TestThriftWriter(t *testing.T) {
+	w := &thriftWriter{}
+	w.i32(1, -3)
+	w.i64(20, 300) // 编号的差超过15，使用长格式
+	w.beginStruct(21)
+	w.binary(1, "ab")
+	w.endStruct()
+	w.list(22, thriftI32, 16)
+	for i := 0; i < 16; i++ {
+		w.zigzag(int64(i))
+	}
+	w.buf = append(w.buf, 0)
+
+	expected := []byte{0x15, 0x05, 0x06, 0x28, 0xD8, 0x04, 0x1C, 0x18, 0x02, 'a', 'b', 0x00, 0x19, 0xF5, 0x10}
+	if !bytes.HasPrefix(w.buf, expected) {
+		t.Errorf("编码结果 = % x, 期望以 % x 开头", w.buf, expected)
+	}
+	fields := (&thriftReader{buf: w.buf}).structure()
+	if fields[1] != int64(-3) || fields[20] != int64(300) || fields[21].(map[int16]interface{})[1] != "ab" || len(fields[22].([]interface{})) != 16 {
+		t.Errorf("解码结果 = %v", fields)
+	}
+}
+
+// parquetColumn 读回一个列块的唯一数据页，返回定义级别和非 null 值的字节
+func parquetColumn(t *testing.T, data []byte, meta map[int16]interface{}) ([]bool, []byte) {
+	t.Helper()
+	offset := int(meta[9].(int64))
+	r := &thriftReader{buf: data, pos: offset}
+	header := r.structure()
+	if header[1] != int64(parquetDataPage) || header[2] != header[3] {
+		t.Fatalf("PageHeader = %v", header)
+	}
+	size := int(header[2].(int64))
+	if int64(r.pos-offset+size) != meta[6].(int64) {
+		t.Errorf("列块大小 %d 与页的大小不一致", meta[6])
+	}
+	numValues := int(header[5].(map[int16]interface{})[1].(int64))
+	page := data[r.pos : r.pos+size]
+
+	// 定义级别：4字节长度，然后是一个位打包的组
+	n := int(binary.LittleEndian.Uint32(page))
+	levels := &thriftReader{buf: page[4 : 4+n]}
+	runHeader := levels.varint()
+	if runHeader&1 != 1 || int(runHeader>>1) != (numValues+7)/8 {
+		t.Fatalf("定义级别的游程头 = %d", runHeader)
+	}
+	valid := make([]bool, numValues)
+	for i := range valid {
+		valid[i] = levels.buf[levels.pos+i/8]&(1<<uint(i%8)) != 0
+	}
+	return valid, page[4+n:]
+}
+
+func TestWriteParquet(t *testing.T) {
+	table := arrowTestTable(t)
+	var buf bytes.Buffer
+	if err := table.WriteParquet(&buf); err != nil {
+		t.Fatalf("WriteParquet 失败: %v", err)
+	}
+	data := buf.Bytes()
+	if !bytes.HasPrefix(data, []byte("PAR1")) || !bytes.HasSuffix(data, []byte("PAR1")) {
+		t.Fatalf("文件的开头或结尾没有 PAR1 标记")
+	}
+	size := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	r := &thriftReader{buf: data[len(data)-8-size : len(data)-8]}
+	meta := r.structure()
+	if r.pos != size {
+		t.Errorf("FileMetaData 解码了 %d 字节，期望 %d", r.pos, size)
+	}
+	if meta[1] != int64(1) || meta[3] != int64(3) || meta[6] != parquetCreatedBy {
+		t.Errorf("FileMetaData = %v", meta)
+	}
+
+	schema := meta[2].([]interface{})
+	if len(schema) != 5 || schema[0].(map[int16]interface{})[5] != int64(4) {
+		t.Fatalf("schema = %v", schema)
+	}
+	types := []int64{parquetInt64, parquetDouble, parquetBoolean, parquetByteArray}
+	for i, typ := range types {
+		e := schema[i+1].(map[int16]interface{})
+		if e[1] != typ || e[3] != int64(parquetOptional) || e[4] != table.Fields[i].Name {
+			t.Errorf("第%d列的 SchemaElement = %v", i+1, e)
+		}
+	}
+	if name := schema[4].(map[int16]interface{}); name[6] != int64(parquetConvertedUTF8) || name[10] == nil {
+		t.Errorf("utf8 列应该标记为字符串: %v", name)
+	}
+
+	groups := meta[4].([]interface{})
+	if len(groups) != 2 {
+		t.Fatalf("有 %d 个行组，期望 2", len(groups))
+	}
+	columns := func(group int) []map[int16]interface{} {
+		var metas []map[int16]interface{}
+		for _, c := range groups[group].(map[int16]interface{})[1].([]interface{}) {
+			metas = append(metas, c.(map[int16]interface{})[3].(map[int16]interface{}))
+		}
+		return metas
+	}
+	first := columns(0)
+	if groups[0].(map[int16]interface{})[3] != int64(2) || len(first) != 4 {
+		t.Fatalf("第一个行组 = %v", groups[0])
+	}
+
+	valid, values := parquetColumn(t, data, first[0])
+	if !valid[0] || !valid[1] || binary.LittleEndian.Uint64(values[8:]) != 2 || len(values) != 16 {
+		t.Errorf("id 列解码错误")
+	}
+	valid, values = parquetColumn(t, data, first[1])
+	if !valid[0] || valid[1] || len(values) != 8 || math.Float64frombits(binary.LittleEndian.Uint64(values)) != 0.5 {
+		t.Errorf("score 列解码错误")
+	}
+	if first[1][12].(map[int16]interface{})[3] != int64(1) {
+		t.Errorf("score 列的 null_count = %v", first[1][12])
+	}
+	if _, values = parquetColumn(t, data, first[2]); !bytes.Equal(values, []byte{0x01}) {
+		t.Errorf("ok 列的值 = % x", values)
+	}
+	valid, values = parquetColumn(t, data, first[3])
+	if !valid[0] || valid[1] || binary.LittleEndian.Uint32(values) != 6 || string(values[4:]) != "张三" {
+		t.Errorf("name 列解码错误")
+	}
+	if path := first[3][3].([]interface{}); len(path) != 1 || path[0] != "name" {
+		t.Errorf("path_in_schema = %v", path)
+	}
+
+	// 第二个行组只有一行，空字符串不是 null
+	valid, values = parquetColumn(t, data, columns(1)[3])
+	if len(valid) != 1 || !valid[0] || !bytes.Equal(values, []byte{0, 0, 0, 0}) {
+		t.Errorf("第二个行组的 name 列解码错误")
+	}
+}
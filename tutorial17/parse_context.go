@@ -23,6 +23,45 @@ type parseContext struct {
 	// 当前处理的数组/对象统计
 	currentArraySize  int // 当前数组的元素数量
 	currentObjectSize int // 当前对象的成员数量
+
+	// 本次解析的键驻留表，相同的键共享同一个字符串
+	keys map[string]string
+
+	// 取消信号，为 nil 时不检查；每解析 cancelCheckInterval 个值检查一次
+	done   <-chan struct{}
+	values int
+
+	// 由 ParseTrusted 设置，跳过控制字符和代理对检查，见 parse_trusted.go
+	trusted bool
+
+	// 由 ParseBytes 设置，JSON 文本引用调用者的内存，解析结果不能引用它的子串
+	borrowed bool
+
+	// 设置了 ParseOptions.Comments 时关联注释的状态，见 comments.go
+	pendingComments []string // 还没有关联到值的注释
+	lastValue       *Value   // 最近解析完成的值，行尾注释关联到它
+	trailFrom       int      // lastValue 之后可以出现行尾注释的开始位置
+}
+
+// maxInternedKeyLength 参与驻留的键的最大长度，更长的键很少重复
+const maxInternedKeyLength = 64
+
+// internKey 返回与 key 相等的驻留字符串
+//
+// 数组中成千上万个对象通常有相同的键，驻留后它们共享同一份内存，
+// 解析时临时产生的重复字符串随后即可被回收。
+func (c *parseContext) internKey(key string) string {
+	if len(key) > maxInternedKeyLength {
+		return key
+	}
+	if c.keys == nil {
+		c.keys = make(map[string]string)
+	}
+	if interned, ok := c.keys[key]; ok {
+		return interned
+	}
+	c.keys[key] = key
+	return key
 }
 
 // 初始化解析上下文
@@ -129,6 +168,9 @@ func (c *parseContext) parseWhitespace() {
 				c.column = savedColumn
 				break
 			}
+			if c.options.Comments != nil {
+				c.recordComment(savedIndex)
+			}
 		} else {
 			break
 		}
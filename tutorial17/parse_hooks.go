@@ -0,0 +1,88 @@
+// parse_hooks.go - 解析钩子的辅助实现
+package leptjson
+
+import (
+	"strconv"
+	"sync"
+)
+
+// StringInterner 是并发安全的字符串驻留表
+//
+// 大量对象共享相同的键时（如数百万条记录的数组），把它的 Intern 方法设置为
+// ParseOptions.InternKey，所有相同的键都会指向同一个字符串，从而节省内存。
+// 同一个驻留表可以在多次解析之间共享。
+type StringInterner struct {
+	mu      sync.Mutex
+	strings map[string]string
+	hits    int
+}
+
+// NewStringInterner 创建空的字符串驻留表
+func NewStringInterner() *StringInterner {
+	return &StringInterner{strings: make(map[string]string)}
+}
+
+// Intern 返回与 s 相等的驻留字符串，s 第一次出现时被加入驻留表
+func (in *StringInterner) Intern(s string) string {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if interned, ok := in.strings[s]; ok {
+		in.hits++
+		return interned
+	}
+	in.strings[s] = s
+	return s
+}
+
+// Len 返回驻留表中不同字符串的数量
+func (in *StringInterner) Len() int {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return len(in.strings)
+}
+
+// Hits 返回 Intern 命中已有字符串的次数，即被复用的字符串数量
+func (in *StringInterner) Hits() int {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return in.hits
+}
+
+// NumberLiterals 记录数字的原始文本，用于需要精确十进制表示的场景
+//
+// 把它的 Handle 方法设置为 ParseOptions.NumberHandler 后，数字仍然按浮点数解析，
+// 同时保留原始文本，调用者可以据此构造 decimal 等高精度类型：
+//
+//	literals := NewNumberLiterals()
+//	opts := DefaultParseOptions()
+//	opts.NumberHandler = literals.Handle
+//	ParseWithOptions(v, `{"price": 0.1000000000000000055}`, opts)
+//	text, _ := literals.Literal(GetObjectValueByKey(v, "price"))
+//
+// 记录以值的地址为键，值被 Copy 或 Move 到其他位置后需要通过原来的值查找。
+type NumberLiterals struct {
+	literals map[*Value]string
+}
+
+// NewNumberLiterals 创建空的数字文本记录
+func NewNumberLiterals() *NumberLiterals {
+	return &NumberLiterals{literals: make(map[*Value]string)}
+}
+
+// Handle 按默认方式解析数字并记录其原始文本
+func (nl *NumberLiterals) Handle(v *Value, literal string) ParseError {
+	n, err := strconv.ParseFloat(literal, 64)
+	if err != nil {
+		return PARSE_NUMBER_TOO_BIG
+	}
+	v.Type = NUMBER
+	v.N = n
+	nl.literals[v] = literal
+	return PARSE_OK
+}
+
+// Literal 返回解析时记录的数字原始文本
+func (nl *NumberLiterals) Literal(v *Value) (string, bool) {
+	literal, ok := nl.literals[v]
+	return literal, ok
+}
@@ -0,0 +1,109 @@
+package leptjson
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func TestInternKeyHook(t *testing.T) {
+	interner := NewStringInterner()
+	opts := DefaultParseOptions()
+	opts.InternKey = interner.Intern
+
+	json := `[{"id":1,"name":"a"},{"id":2,"name":"b"},{"id":3,"name":"c","extra":{"id":4}}]`
+	v := &Value{}
+	if err := ParseWithOptions(v, json, opts); err != PARSE_OK {
+		t.Fatalf("解析失败: %s", err.Error())
+	}
+	if interner.Len() != 3 {
+		t.Errorf("驻留表中应有 3 个不同的键, 实际为 %d", interner.Len())
+	}
+	if interner.Hits() != 5 {
+		t.Errorf("应当复用 5 次, 实际为 %d", interner.Hits())
+	}
+
+	// 驻留函数可以改写键
+	opts.InternKey = strings.ToUpper
+	ParseWithOptions(v, `{"name":"x"}`, opts)
+	if _, ok := FindObjectKey(v, "NAME"); !ok {
+		t.Error("键应当被驻留函数改写")
+	}
+}
+
+func TestNumberHandlerHook(t *testing.T) {
+	opts := DefaultParseOptions()
+	// 以字符串形式保存数字，避免精度损失
+	opts.NumberHandler = func(v *Value, literal string) ParseError {
+		SetString(v, literal)
+		return PARSE_OK
+	}
+
+	v := &Value{}
+	if err := ParseWithOptions(v, `{"amount": 12345678901234567890.123, "list": [-0.10, 1e2]}`, opts); err != PARSE_OK {
+		t.Fatalf("解析失败: %s", err.Error())
+	}
+	if got := GetObjectValueByKey(v, "amount"); got.Type != STRING || got.S != "12345678901234567890.123" {
+		t.Errorf("amount = %+v", got)
+	}
+	if list := GetObjectValueByKey(v, "list"); list.A[0].S != "-0.10" || list.A[1].S != "1e2" {
+		t.Errorf("list = %+v", list.A)
+	}
+
+	// 处理函数返回的错误终止解析
+	opts.NumberHandler = func(v *Value, literal string) ParseError {
+		return PARSE_NUMBER_TOO_BIG
+	}
+	if err := ParseWithOptions(v, `[1]`, opts); err != PARSE_NUMBER_TOO_BIG {
+		t.Errorf("应当返回处理函数的错误, 实际为 %v", err)
+	}
+}
+
+func TestNumberLiterals(t *testing.T) {
+	literals := NewNumberLiterals()
+	opts := DefaultParseOptions()
+	opts.NumberHandler = literals.Handle
+
+	v := &Value{}
+	if err := ParseWithOptions(v, `{"price": 0.1000000000000000055, "qty": 3}`, opts); err != PARSE_OK {
+		t.Fatalf("解析失败: %s", err.Error())
+	}
+	price := GetObjectValueByKey(v, "price")
+	if price.Type != NUMBER || price.N != 0.1 {
+		t.Errorf("数字仍应按浮点数解析: %+v", price)
+	}
+	if literal, ok := literals.Literal(price); !ok || literal != "0.1000000000000000055" {
+		t.Errorf("Literal = %q, %v", literal, ok)
+	}
+	if _, ok := literals.Literal(v); ok {
+		t.Error("非数字值不应有记录")
+	}
+}
+
+// stringData 返回字符串底层数据的地址
+func stringData(s string) uintptr {
+	return (*reflect.StringHeader)(unsafe.Pointer(&s)).Data
+}
+
+func TestDefaultKeyInterning(t *testing.T) {
+	json := `[{"name":"a"},{"name":"b"},{"name":"c"}]`
+
+	v := &Value{}
+	if err := Parse(v, json); err != PARSE_OK {
+		t.Fatalf("解析失败: %s", err.Error())
+	}
+	first := stringData(v.A[0].O[0].K)
+	for i, elem := range v.A {
+		if stringData(elem.O[0].K) != first {
+			t.Errorf("第 %d 个对象的键应当与第一个共享内存", i)
+		}
+	}
+
+	opts := DefaultParseOptions()
+	opts.DisableKeyInterning = true
+	ParseWithOptions(v, json, opts)
+	if stringData(v.A[0].O[0].K) == stringData(v.A[1].O[0].K) {
+		t.Error("关闭键驻留后每个键应当使用独立的字符串")
+	}
+}
@@ -0,0 +1,49 @@
+// parse_trusted.go - 解析可信输入的快速路径
+package leptjson
+
+// ParseTrusted 解析可信来源的JSON文本，跳过只对不可信输入有意义的检查
+//
+// 适用于本程序或其他可靠程序生成的JSON，例如自己写入的缓存和日志。与 Parse 相比：
+//   - 不检查字符串中未转义的控制字符，也不验证代理对的低位是否有效；
+//   - 不进行 ParseOptions 中的安全检查（输入大小、嵌套深度、字符串长度、元素数量、数值范围）；
+//   - 不含转义序列的字符串直接引用 json 的子串而不复制，结果会让整个 json 保持可达；
+//   - 不预先计算行号，出错时只返回错误码。
+//
+// 语法错误仍然会被发现并返回错误，但对上面这些本应报错的输入，结果是未定义的。
+// 输入来自网络、用户或其他不受控制的来源时必须使用 Parse 或 ParseWithOptions。
+func ParseTrusted(v *Value, json string) ParseError {
+	c := &parseContext{
+		json:    json,
+		options: trustedParseOptions(),
+		line:    1,
+		column:  1,
+		trusted: true,
+	}
+	return parseObserved(c, v)
+}
+
+// trustedParseOptions 返回 ParseTrusted 使用的解析选项：严格的 JSON 语法，关闭安全检查
+func trustedParseOptions() ParseOptions {
+	options := DefaultParseOptions()
+	options.EnabledSecurity = false
+	return options
+}
+
+// scanPlainString 在当前位置的字符串不含转义序列时返回它的内容并跳过整个字符串
+//
+// 当前字符必须是开始的双引号。字符串含有转义序列或没有结束时返回 false，
+// 位置保持不变，由 parseStringRaw 逐字符解析。
+func (c *parseContext) scanPlainString() (string, bool) {
+	start := c.index + 1
+	for i := start; i < len(c.json); i++ {
+		switch c.json[i] {
+		case '"':
+			c.column += i + 1 - c.index
+			c.index = i + 1
+			return c.json[start:i], true
+		case '\\':
+			return "", false
+		}
+	}
+	return "", false
+}
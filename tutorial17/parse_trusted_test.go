@@ -0,0 +1,87 @@
+package leptjson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseTrusted(t *testing.T) {
+	for _, json := range []string{
+		`null`,
+		`[1,-2.5e3,true,false,null]`,
+		`{"a":"plain","b":"esc\"aped\né𝄞","c":{"d":[]}}`,
+		` { "k" : [ "x" , { } ] } `,
+	} {
+		want, got := &Value{}, &Value{}
+		if err := Parse(want, json); err != PARSE_OK {
+			t.Fatalf("Parse(%s) = %v", json, err)
+		}
+		if err := ParseTrusted(got, json); err != PARSE_OK {
+			t.Fatalf("ParseTrusted(%s) = %v", json, err)
+		}
+		if !Equal(got, want) {
+			t.Errorf("ParseTrusted(%s) 的结果与 Parse 不同", json)
+		}
+	}
+}
+
+func TestParseTrustedSkipsChecks(t *testing.T) {
+	// 未转义的控制字符
+	v := &Value{}
+	if err := ParseTrusted(v, "\"a\tb\""); err != PARSE_OK || v.S != "a\tb" {
+		t.Errorf("ParseTrusted(控制字符) = %v, %q", err, v.S)
+	}
+	// 安全检查的上限
+	long := `"` + strings.Repeat("x", DefaultParseOptions().MaxStringLength+1) + `"`
+	if err := Parse(v, long); err != PARSE_MAX_STRING_LENGTH_EXCEEDED {
+		t.Fatalf("Parse(长字符串) = %v", err)
+	}
+	if err := ParseTrusted(v, long); err != PARSE_OK {
+		t.Errorf("ParseTrusted(长字符串) = %v", err)
+	}
+}
+
+func TestParseTrustedSyntaxErrors(t *testing.T) {
+	for json, want := range map[string]ParseError{
+		``:            PARSE_EXPECT_VALUE,
+		`[1,]`:        PARSE_INVALID_VALUE,
+		`{"a" 1}`:     PARSE_MISS_COLON,
+		`"abc`:        PARSE_MISS_QUOTATION_MARK,
+		`"a\qb"`:      PARSE_INVALID_STRING_ESCAPE,
+		`[1] 2`:       PARSE_ROOT_NOT_SINGULAR,
+		`{"a":1 "b"}`: PARSE_MISS_COMMA_OR_CURLY_BRACKET,
+	} {
+		if err := ParseTrusted(&Value{}, json); err != want {
+			t.Errorf("ParseTrusted(%s) = %v, 期望 %v", json, err, want)
+		}
+	}
+}
+
+func trustedBenchmarkInput() string {
+	return `[` + strings.Repeat(`{"id":12345,"name":"machine generated record","tags":["alpha","beta","gamma"],"ok":true},`, 999) +
+		`{"id":0,"name":"","tags":[],"ok":false}]`
+}
+
+func BenchmarkParseDefault(b *testing.B) {
+	json := trustedBenchmarkInput()
+	options := DefaultParseOptions()
+	options.EnabledSecurity = false
+	b.SetBytes(int64(len(json)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := ParseWithOptions(&Value{}, json, options); err != PARSE_OK {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseTrusted(b *testing.B) {
+	json := trustedBenchmarkInput()
+	b.SetBytes(int64(len(json)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := ParseTrusted(&Value{}, json); err != PARSE_OK {
+			b.Fatal(err)
+		}
+	}
+}
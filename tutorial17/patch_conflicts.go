@@ -0,0 +1,188 @@
+// patch_conflicts.go - 检测两个并发的 JSON Patch 之间的冲突
+package leptjson
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ConflictKind 是两个补丁操作之间冲突的种类
+type ConflictKind int
+
+const (
+	CONFLICT_SAME_PATH   ConflictKind = iota // 两个操作修改（或一个修改、一个读取）同一个位置
+	CONFLICT_NESTED                          // 一个操作涉及的位置在另一个操作涉及的子树之内，且至少一个是修改
+	CONFLICT_INDEX_SHIFT                     // 一个操作插入或删除数组元素，使另一个操作的下标指向不同的元素
+)
+
+// String 返回冲突种类的名称
+func (k ConflictKind) String() string {
+	switch k {
+	case CONFLICT_SAME_PATH:
+		return "same-path"
+	case CONFLICT_NESTED:
+		return "nested"
+	case CONFLICT_INDEX_SHIFT:
+		return "index-shift"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalJSON 将冲突种类输出为名称
+func (k ConflictKind) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + k.String() + `"`), nil
+}
+
+// PatchConflict 是两个补丁中一对互相影响的操作
+type PatchConflict struct {
+	Kind    ConflictKind `json:"kind"`
+	IndexA  int          `json:"indexA"` // 操作在第一个补丁中的下标
+	IndexB  int          `json:"indexB"` // 操作在第二个补丁中的下标
+	PathA   string       `json:"pathA"`  // 第一个操作中重叠的路径（path 或 from）
+	PathB   string       `json:"pathB"`  // 第二个操作中重叠的路径
+	Message string       `json:"message"`
+}
+
+// patchAccess 是一个操作读取或修改的一个位置
+type patchAccess struct {
+	pointer string
+	tokens  []string
+	write   bool
+}
+
+// Conflicts 返回两个基于同一文档、并发产生的补丁之间互相影响的操作对
+//
+// 两个操作修改同一位置、一个修改另一个所在的子树、一个修改另一个 test 或 copy 读取的位置，
+// 或者一个在数组中插入或删除元素而另一个使用该数组中相同或更大的下标时，它们冲突：
+// 先后应用两个补丁的结果取决于顺序，或者后应用的补丁会作用在错误的元素上。
+// 没有冲突时两个补丁可以按任意顺序应用。两边完全相同、重复执行也不改变结果的操作不算冲突。
+//
+// 检测只依据路径，不读取文档，所以形如数组下标的对象键（如 "/counts/0"）也按数组处理，
+// 可能报告实际不存在的下标冲突。结果按 patchA 中操作的顺序排列，每对操作最多报告一次。
+func Conflicts(patchA, patchB *JSONPatch) []PatchConflict {
+	conflicts := []PatchConflict{}
+	if patchA == nil || patchB == nil {
+		return conflicts
+	}
+	for i, a := range patchA.Operations {
+		accessesA := patchAccesses(a)
+		for j, b := range patchB.Operations {
+			if sameIdempotentOperation(a, b) {
+				continue
+			}
+			if c, ok := operationConflict(a, b, accessesA, patchAccesses(b)); ok {
+				c.IndexA, c.IndexB = i, j
+				conflicts = append(conflicts, c)
+			}
+		}
+	}
+	return conflicts
+}
+
+// patchAccesses 返回操作读取和修改的位置，无法解析的路径被忽略
+func patchAccesses(op PatchOperation) []patchAccess {
+	var accesses []patchAccess
+	add := func(pointer string, write bool) {
+		if p, err := ParseJSONPointer(pointer); err == POINTER_OK {
+			accesses = append(accesses, patchAccess{pointer, p.tokens, write})
+		}
+	}
+	switch op.Op {
+	case "test":
+		add(op.Path, false)
+	case "copy":
+		add(op.From, false)
+		add(op.Path, true)
+	case "move":
+		add(op.From, true)
+		add(op.Path, true)
+	default:
+		add(op.Path, true)
+	}
+	return accesses
+}
+
+// sameIdempotentOperation 判断两个操作是否相同，且执行两次与执行一次的结果相同
+//
+// 在数组中插入或删除元素的操作执行两次会影响两个元素，不算相同。
+func sameIdempotentOperation(a, b PatchOperation) bool {
+	if a.Op != b.Op || normalizePointer(a.Path) != normalizePointer(b.Path) ||
+		normalizePointer(a.From) != normalizePointer(b.From) {
+		return false
+	}
+	if (a.Value == nil) != (b.Value == nil) || (a.Value != nil && !Equal(a.Value, b.Value)) {
+		return false
+	}
+	switch a.Op {
+	case "replace", "test":
+		return true
+	case "add", "remove":
+		return !isArrayIndexPointer(a.Path)
+	default:
+		return false
+	}
+}
+
+// operationConflict 判断两个操作是否冲突，同时有多种冲突时报告最直接的一种
+func operationConflict(a, b PatchOperation, accessesA, accessesB []patchAccess) (PatchConflict, bool) {
+	var found PatchConflict
+	ok := false
+	record := func(kind ConflictKind, x, y patchAccess) {
+		if !ok || kind < found.Kind {
+			found = PatchConflict{Kind: kind, PathA: x.pointer, PathB: y.pointer}
+			ok = true
+		}
+	}
+	for _, x := range accessesA {
+		for _, y := range accessesB {
+			if !x.write && !y.write {
+				continue
+			}
+			switch {
+			case tokensEqual(x.tokens, y.tokens):
+				record(CONFLICT_SAME_PATH, x, y)
+			case isTokenPrefix(x.tokens, y.tokens) || isTokenPrefix(y.tokens, x.tokens):
+				record(CONFLICT_NESTED, x, y)
+			case shiftsIndex(a, x, y) || shiftsIndex(b, y, x):
+				record(CONFLICT_INDEX_SHIFT, x, y)
+			}
+		}
+	}
+	if ok {
+		found.Message = conflictMessage(found.Kind, a, b, found.PathA, found.PathB)
+	}
+	return found, ok
+}
+
+// shiftsIndex 判断操作 op 在位置 x 插入或删除数组元素时，是否改变位置 y 所用的下标指向的元素
+func shiftsIndex(op PatchOperation, x, y patchAccess) bool {
+	if !x.write || op.Op == "replace" || len(x.tokens) == 0 {
+		return false
+	}
+	n := len(x.tokens) - 1
+	index, err := strconv.Atoi(x.tokens[n])
+	if err != nil || index < 0 || len(y.tokens) <= n || !isTokenPrefix(x.tokens[:n], y.tokens) {
+		return false
+	}
+	other, err := strconv.Atoi(y.tokens[n])
+	return err == nil && other >= index
+}
+
+// conflictMessage 生成冲突的描述
+func conflictMessage(kind ConflictKind, a, b PatchOperation, pathA, pathB string) string {
+	describe := func(op PatchOperation) string {
+		if op.Op == "move" || op.Op == "copy" {
+			return fmt.Sprintf("%s %s -> %s", op.Op, op.From, op.Path)
+		}
+		return op.Op + " " + op.Path
+	}
+	switch kind {
+	case CONFLICT_SAME_PATH:
+		return fmt.Sprintf("%s 和 %s 都涉及 %s", describe(a), describe(b), pathA)
+	case CONFLICT_NESTED:
+		return fmt.Sprintf("%s 和 %s 涉及嵌套的位置 %s 和 %s", describe(a), describe(b), pathA, pathB)
+	default:
+		return fmt.Sprintf("%s 和 %s 改变了同一数组中的下标 %s 和 %s", describe(a), describe(b), pathA, pathB)
+	}
+}
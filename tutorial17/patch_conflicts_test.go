@@ -0,0 +1,102 @@
+package leptjson
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func mustPatch(t *testing.T, text string) *JSONPatch {
+	t.Helper()
+	patch, err := NewJSONPatch(mustParse(t, text))
+	if err != nil {
+		t.Fatalf("解析补丁 %s 失败: %v", text, err)
+	}
+	return patch
+}
+
+func TestConflicts(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		expected []ConflictKind
+	}{
+		{"不相关的路径",
+			`[{"op": "replace", "path": "/a", "value": 1}]`,
+			`[{"op": "replace", "path": "/b", "value": 2}]`, nil},
+		{"同一路径",
+			`[{"op": "replace", "path": "/a", "value": 1}]`,
+			`[{"op": "remove", "path": "/a"}]`, []ConflictKind{CONFLICT_SAME_PATH}},
+		{"相同的替换",
+			`[{"op": "replace", "path": "/a", "value": {"x": 1}}]`,
+			`[{"op": "replace", "path": "/a", "value": {"x": 1}}]`, nil},
+		{"相同的数组插入执行两次",
+			`[{"op": "add", "path": "/items/0", "value": 1}]`,
+			`[{"op": "add", "path": "/items/0", "value": 1}]`, []ConflictKind{CONFLICT_SAME_PATH}},
+		{"修改父节点",
+			`[{"op": "remove", "path": "/user"}]`,
+			`[{"op": "replace", "path": "/user/name", "value": "x"}]`, []ConflictKind{CONFLICT_NESTED}},
+		{"test 读取被修改的子树",
+			`[{"op": "test", "path": "/user", "value": {}}]`,
+			`[{"op": "add", "path": "/user/age", "value": 3}]`, []ConflictKind{CONFLICT_NESTED}},
+		{"两个 test 不冲突",
+			`[{"op": "test", "path": "/a", "value": 1}]`,
+			`[{"op": "test", "path": "/a", "value": 2}]`, nil},
+		{"插入使后面的下标移动",
+			`[{"op": "add", "path": "/items/1", "value": "x"}]`,
+			`[{"op": "replace", "path": "/items/3/name", "value": "y"}, {"op": "replace", "path": "/items/0", "value": "z"}]`,
+			[]ConflictKind{CONFLICT_INDEX_SHIFT}},
+		{"追加不影响下标",
+			`[{"op": "add", "path": "/items/-", "value": "x"}]`,
+			`[{"op": "remove", "path": "/items/2"}, {"op": "replace", "path": "/items/0", "value": 1}]`, nil},
+		{"两次追加的顺序不同结果不同",
+			`[{"op": "add", "path": "/items/-", "value": "x"}]`,
+			`[{"op": "add", "path": "/items/-", "value": "y"}]`, []ConflictKind{CONFLICT_SAME_PATH}},
+		{"move 的源和目标",
+			`[{"op": "move", "from": "/a", "path": "/b"}]`,
+			`[{"op": "copy", "from": "/b/c", "path": "/d"}, {"op": "replace", "path": "/a", "value": 0}]`,
+			[]ConflictKind{CONFLICT_NESTED, CONFLICT_SAME_PATH}},
+		{"删除数组元素",
+			`[{"op": "remove", "path": "/list/2"}]`,
+			`[{"op": "test", "path": "/list/5", "value": 1}]`, []ConflictKind{CONFLICT_INDEX_SHIFT}},
+	}
+	for _, tt := range tests {
+		conflicts := Conflicts(mustPatch(t, tt.a), mustPatch(t, tt.b))
+		if len(conflicts) != len(tt.expected) {
+			t.Errorf("%s: Conflicts = %+v, 期望 %v", tt.name, conflicts, tt.expected)
+			continue
+		}
+		for i, c := range conflicts {
+			if c.Kind != tt.expected[i] || c.Message == "" {
+				t.Errorf("%s: 第 %d 个冲突 = %+v, 期望 %v", tt.name, i, c, tt.expected[i])
+			}
+		}
+		// 冲突关系是对称的
+		if reverse := Conflicts(mustPatch(t, tt.b), mustPatch(t, tt.a)); len(reverse) != len(conflicts) {
+			t.Errorf("%s: 交换补丁后得到 %d 个冲突, 期望 %d", tt.name, len(reverse), len(conflicts))
+		}
+	}
+}
+
+func TestConflictDetails(t *testing.T) {
+	conflicts := Conflicts(
+		mustPatch(t, `[{"op": "add", "path": "/x", "value": 1}, {"op": "move", "from": "/a/b", "path": "/c"}]`),
+		mustPatch(t, `[{"op": "remove", "path": "/a"}]`))
+	if len(conflicts) != 1 {
+		t.Fatalf("Conflicts = %+v", conflicts)
+	}
+	c := conflicts[0]
+	if c.IndexA != 1 || c.IndexB != 0 || c.PathA != "/a/b" || c.PathB != "/a" || c.Kind != CONFLICT_NESTED {
+		t.Errorf("冲突 = %+v", c)
+	}
+	if expected := "move /a/b -> /c 和 remove /a 涉及嵌套的位置 /a/b 和 /a"; c.Message != expected {
+		t.Errorf("Message = %q, 期望 %q", c.Message, expected)
+	}
+	data, _ := json.Marshal(c)
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded["kind"] != "nested" || decoded["pathB"] != "/a" {
+		t.Errorf("JSON = %s", data)
+	}
+	if len(Conflicts(nil, mustPatch(t, `[]`))) != 0 {
+		t.Errorf("nil 补丁不应该有冲突")
+	}
+}
@@ -0,0 +1,99 @@
+// patch_preview.go - 以彩色的前后对比显示补丁将产生的变更
+package leptjson
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// 终端颜色
+const (
+	ansiReset  = "\x1b[0m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+)
+
+// previewValueLength 是预览中单个值显示的最大字符数
+const previewValueLength = 80
+
+// WritePatchPreview 将结构化差异写为逐路径的前后对比
+//
+// 修改的路径以 "~" 开头，随后是 "-" 旧值和 "+" 新值；删除和新增的路径分别以 "-" 和 "+" 开头。
+// 数组长度变化时逐个列出多出或缺少的元素。color 为 true 时使用ANSI颜色。
+func WritePatchPreview(w io.Writer, differences []Difference, color bool) error {
+	p := &previewWriter{w: w, color: color}
+	for _, diff := range differences {
+		switch diff.Kind {
+		case DIFF_REMOVED:
+			p.line(ansiRed, "- %s: %s", diff.Path, previewValue(diff.Left))
+		case DIFF_ADDED:
+			p.line(ansiGreen, "+ %s: %s", diff.Path, previewValue(diff.Right))
+		case DIFF_MOVED:
+			p.line(ansiYellow, "~ %s → %s: %s", diff.Path, diff.RightPath, previewValue(diff.Left))
+		case DIFF_LENGTH_CHANGED:
+			p.line(ansiYellow, "~ %s: 数组长度 %d → %d", diff.Path, len(diff.Left.A), len(diff.Right.A))
+			for i := len(diff.Right.A); i < len(diff.Left.A); i++ {
+				p.line(ansiRed, "- %s[%d]: %s", diff.Path, i, previewValue(diff.Left.A[i]))
+			}
+			for i := len(diff.Left.A); i < len(diff.Right.A); i++ {
+				p.line(ansiGreen, "+ %s[%d]: %s", diff.Path, i, previewValue(diff.Right.A[i]))
+			}
+		default:
+			p.line(ansiYellow, "~ %s", diff.Path)
+			p.line(ansiRed, "    - %s", previewValue(diff.Left))
+			p.line(ansiGreen, "    + %s", previewValue(diff.Right))
+		}
+	}
+	return p.err
+}
+
+// previewWriter 逐行写出预览，记录第一个写入错误
+type previewWriter struct {
+	w     io.Writer
+	color bool
+	err   error
+}
+
+func (p *previewWriter) line(color string, format string, args ...interface{}) {
+	if p.err != nil {
+		return
+	}
+	text := fmt.Sprintf(format, args...)
+	if p.color {
+		text = color + text + ansiReset
+	}
+	_, p.err = fmt.Fprintln(p.w, text)
+}
+
+// previewValue 返回值的紧凑JSON文本，过长时截断
+func previewValue(v *Value) string {
+	if v == nil {
+		return "(无)"
+	}
+	text, err := Stringify(v)
+	if err != STRINGIFY_OK {
+		return "(" + err.Error() + ")"
+	}
+	if runes := []rune(text); len(runes) > previewValueLength {
+		text = string(runes[:previewValueLength]) + "…"
+	}
+	return text
+}
+
+// useColor 根据 --color 选项的值判断是否在 f 上使用颜色
+//
+// auto 在 f 是终端且未设置 NO_COLOR 环境变量时使用颜色。
+func useColor(mode string, f *os.File) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto", "":
+		return os.Getenv("NO_COLOR") == "" && isTerminal(f), nil
+	default:
+		return false, fmt.Errorf("无效的颜色模式: %s，可选值: auto, always, never", mode)
+	}
+}
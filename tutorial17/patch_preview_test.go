@@ -0,0 +1,66 @@
+package leptjson
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWritePatchPreview(t *testing.T) {
+	before, after := &Value{}, &Value{}
+	Parse(before, `{"name":"John","tags":["a","b","c"],"age":30,"old":{"x":1}}`)
+	Parse(after, `{"name":"Jane","tags":["a"],"age":"30","email":"j@example.com"}`)
+
+	var sb strings.Builder
+	if err := WritePatchPreview(&sb, CompareValues(before, after), false); err != nil {
+		t.Fatal(err)
+	}
+	expected := `~ $.name
+    - "John"
+    + "Jane"
+~ $.tags: 数组长度 3 → 1
+- $.tags[1]: "b"
+- $.tags[2]: "c"
+~ $.age
+    - 30
+    + "30"
+- $.old: {"x":1}
++ $.email: "j@example.com"
+`
+	if sb.String() != expected {
+		t.Errorf("预览 =\n%s\n期望\n%s", sb.String(), expected)
+	}
+}
+
+func TestWritePatchPreviewColor(t *testing.T) {
+	before, after := &Value{}, &Value{}
+	Parse(before, `[1]`)
+	Parse(after, `[1,2]`)
+
+	var sb strings.Builder
+	WritePatchPreview(&sb, CompareValues(before, after), true)
+	if !strings.Contains(sb.String(), ansiGreen+"+ $[1]: 2"+ansiReset) {
+		t.Errorf("预览 = %q", sb.String())
+	}
+}
+
+func TestPreviewValueTruncated(t *testing.T) {
+	v := &Value{}
+	SetString(v, strings.Repeat("长", 200))
+	text := previewValue(v)
+	if len([]rune(text)) != previewValueLength+1 || !strings.HasSuffix(text, "…") {
+		t.Errorf("previewValue 长度 = %d", len([]rune(text)))
+	}
+}
+
+func TestUseColor(t *testing.T) {
+	if c, _ := useColor("always", os.Stdout); !c {
+		t.Errorf("always 应该使用颜色")
+	}
+	if c, _ := useColor("never", os.Stdout); c {
+		t.Errorf("never 不应该使用颜色")
+	}
+	if _, err := useColor("rainbow", os.Stdout); err == nil {
+		t.Errorf("无效的模式应该返回错误")
+	}
+}
@@ -0,0 +1,268 @@
+// patch_rebase.go - 把待应用的补丁变基到另一个已经应用的并发补丁之后（操作变换）
+package leptjson
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Rebase 改写基于同一文档、与 appliedPatch 并发产生的 patch，使它可以在 appliedPatch 之后应用
+//
+// 改写的规则与操作变换（OT）相同，冲突时后应用的 patch 获胜：
+//   - appliedPatch 在数组中插入或删除元素时，patch 中经过该数组的下标随之调整；
+//     双方在同一下标插入时，patch 插入的元素排在后面
+//   - appliedPatch 移动的值，patch 中指向它的路径改为新的位置
+//   - 双方修改同一位置时保留 patch 的修改，appliedPatch 已经删除的位置上的 replace 改为 add
+//   - 位于 appliedPatch 删除或整体替换的子树之内的操作被丢弃，因为它们指向的值已经不存在
+//
+// test 操作只调整下标，不会被丢弃：它检查的值被 appliedPatch 修改后，应用时会失败，
+// 调用者可以据此放弃这次修改。与 Conflicts 一样，改写只依据路径而不读取文档，
+// 形如数组下标的对象键也按数组处理。
+//
+// 协作编辑时，服务器把客户端基于旧版本产生的补丁依次变基到之后已经应用到 Document 的补丁之后，
+// 再用 Document.Patch 应用，就得到最后写入者获胜的结果。
+func Rebase(patch, appliedPatch *JSONPatch) (*JSONPatch, error) {
+	result := &JSONPatch{Operations: []PatchOperation{}}
+	if patch == nil {
+		return result, nil
+	}
+	var applied []rebaseOp
+	if appliedPatch != nil {
+		for _, op := range appliedPatch.Operations {
+			r, err := newRebaseOp(op)
+			if err != nil {
+				return nil, err
+			}
+			applied = append(applied, r)
+		}
+	}
+
+	for _, op := range patch.Operations {
+		cur, err := newRebaseOp(op)
+		if err != nil {
+			return nil, err
+		}
+		keep := true
+		// 同时把 applied 中的操作变换到 cur 之后，供 patch 中后面的操作使用
+		remaining := applied[:0]
+		for _, a := range applied {
+			if !keep {
+				remaining = append(remaining, a)
+				continue
+			}
+			newA, keepA := a.transform(cur, false)
+			cur, keep = cur.transform(a, true)
+			if keepA {
+				remaining = append(remaining, newA)
+			}
+		}
+		applied = remaining
+		if keep {
+			result.Operations = append(result.Operations, cur.operation())
+		}
+	}
+	return result, nil
+}
+
+// rebaseOp 是解析过路径的补丁操作
+type rebaseOp struct {
+	op   PatchOperation
+	path []string
+	from []string // 只有 move 和 copy 有
+}
+
+func newRebaseOp(op PatchOperation) (rebaseOp, error) {
+	r := rebaseOp{op: op}
+	path, err := ParseJSONPointer(op.Path)
+	if err != POINTER_OK {
+		return r, fmt.Errorf("无效的路径 %q: %v", op.Path, err)
+	}
+	r.path = path.tokens
+	if op.Op == "move" || op.Op == "copy" {
+		from, err := ParseJSONPointer(op.From)
+		if err != POINTER_OK {
+			return r, fmt.Errorf("无效的路径 %q: %v", op.From, err)
+		}
+		r.from = from.tokens
+	}
+	return r, nil
+}
+
+// operation 返回使用改写后路径的补丁操作
+func (r rebaseOp) operation() PatchOperation {
+	op := r.op
+	op.Path = pointerFromTokens(r.path)
+	if r.from != nil {
+		op.From = pointerFromTokens(r.from)
+	}
+	return op
+}
+
+// inserts 判断操作是否在 tokens 处插入数组元素（而不是替换对象成员）
+func inserts(op string, tokens []string) bool {
+	return (op == "add" || op == "move" || op == "copy") && len(tokens) > 0 && isArrayIndex(tokens[len(tokens)-1])
+}
+
+// isArrayIndex 判断令牌是否是数字形式的数组下标（不含 "-"）
+func isArrayIndex(token string) bool {
+	index, err := strconv.Atoi(token)
+	return err == nil && index >= 0 && token == strconv.Itoa(index)
+}
+
+// rebaseStatus 是一个路径相对于另一个操作修改的位置的关系
+type rebaseStatus int
+
+const (
+	rebaseOutside           rebaseStatus = iota // 不在被删除或替换的子树中，可能需要调整下标
+	rebaseRemoved                               // 就是被删除的位置
+	rebaseInsideRemoved                         // 在被删除的子树之内
+	rebaseOverwritten                           // 就是被整体替换的位置
+	rebaseInsideOverwritten                     // 在被整体替换的子树之内
+)
+
+// transform 返回把 r 变换到 w 之后的操作，r 应该被丢弃时返回 false
+//
+// r 和 w 基于同一个文档状态。wins 为 true 时 r 是后应用、冲突时获胜的一方。
+func (r rebaseOp) transform(w rebaseOp, wins bool) (rebaseOp, bool) {
+	if w.op.Op == "test" {
+		return r, true
+	}
+	path, pathStatus := transformTokens(r.path, inserts(r.op.Op, r.path), w, wins)
+	var from []string
+	fromStatus := rebaseOutside
+	if r.from != nil {
+		from, fromStatus = transformTokens(r.from, false, w, wins)
+	}
+
+	if r.op.Op == "test" {
+		if pathStatus == rebaseOutside {
+			r.path = path
+		}
+		return r, true
+	}
+
+	switch fromStatus {
+	case rebaseRemoved, rebaseInsideRemoved, rebaseInsideOverwritten:
+		return r, false
+	}
+	switch pathStatus {
+	case rebaseInsideRemoved, rebaseInsideOverwritten:
+		return r, false
+	case rebaseRemoved:
+		if !wins || r.op.Op == "remove" {
+			return r, false
+		}
+		if r.op.Op == "replace" {
+			r.op.Op = "add"
+		}
+	case rebaseOverwritten:
+		if !wins {
+			return r, false
+		}
+	}
+	r.path, r.from = path, from
+	return r, true
+}
+
+// transformTokens 把 r 中的一个路径变换到操作 w 之后
+//
+// inserting 表示路径是 r 插入数组元素的位置，这时在同一下标上只有 wins 的一方向后移动。
+func transformTokens(tokens []string, inserting bool, w rebaseOp, wins bool) ([]string, rebaseStatus) {
+	switch w.op.Op {
+	case "remove":
+		return removeTokens(tokens, inserting, w.path)
+	case "replace":
+		return tokens, overwriteStatus(tokens, w.path)
+	case "add", "copy":
+		return addTokens(tokens, inserting, w.op.Op, w.path, wins)
+	case "move":
+		if tokensEqual(w.from, w.path) {
+			return tokens, rebaseOutside
+		}
+		// 被移动的值跟着移动到新的位置
+		if isTokenPrefix(w.from, tokens) && !(inserting && tokensEqual(w.from, tokens)) {
+			moved := copyTokens(w.path)
+			return append(moved, tokens[len(w.from):]...), rebaseOutside
+		}
+		tokens, status := removeTokens(tokens, inserting, w.from)
+		if status != rebaseOutside {
+			return tokens, status
+		}
+		return addTokens(tokens, inserting, w.op.Op, w.path, wins)
+	}
+	return tokens, rebaseOutside
+}
+
+// removeTokens 把路径变换到删除 removed 之后
+func removeTokens(tokens []string, inserting bool, removed []string) ([]string, rebaseStatus) {
+	n := len(removed) - 1
+	if n < 0 {
+		return tokens, overwriteStatus(tokens, removed)
+	}
+	if !isArrayIndex(removed[n]) {
+		status := overwriteStatus(tokens, removed)
+		if status == rebaseOverwritten {
+			return tokens, rebaseRemoved
+		} else if status == rebaseInsideOverwritten {
+			return tokens, rebaseInsideRemoved
+		}
+		return tokens, status
+	}
+	if len(tokens) <= n || !isTokenPrefix(removed[:n], tokens) || !isArrayIndex(tokens[n]) {
+		return tokens, rebaseOutside
+	}
+	removedIndex, _ := strconv.Atoi(removed[n])
+	index, _ := strconv.Atoi(tokens[n])
+	switch {
+	case index < removedIndex:
+		return tokens, rebaseOutside
+	case index == removedIndex && len(tokens) == n+1 && inserting:
+		// 在被删除的元素的位置插入，位置仍然有效
+		return tokens, rebaseOutside
+	case index == removedIndex && len(tokens) == n+1:
+		return tokens, rebaseRemoved
+	case index == removedIndex:
+		return tokens, rebaseInsideRemoved
+	}
+	return shiftIndex(tokens, n, -1), rebaseOutside
+}
+
+// addTokens 把路径变换到在 added 处添加值之后
+func addTokens(tokens []string, inserting bool, op string, added []string, wins bool) ([]string, rebaseStatus) {
+	if !inserts(op, added) {
+		// 添加对象成员或 "-" 追加：前者替换已有的成员，后者不影响已有元素的下标
+		if n := len(added) - 1; n >= 0 && added[n] == "-" {
+			return tokens, rebaseOutside
+		}
+		return tokens, overwriteStatus(tokens, added)
+	}
+	n := len(added) - 1
+	if len(tokens) <= n || !isTokenPrefix(added[:n], tokens) || !isArrayIndex(tokens[n]) {
+		return tokens, rebaseOutside
+	}
+	addedIndex, _ := strconv.Atoi(added[n])
+	index, _ := strconv.Atoi(tokens[n])
+	if index < addedIndex || (index == addedIndex && len(tokens) == n+1 && inserting && !wins) {
+		return tokens, rebaseOutside
+	}
+	return shiftIndex(tokens, n, 1), rebaseOutside
+}
+
+// overwriteStatus 返回路径相对于被整体替换的位置 written 的关系
+func overwriteStatus(tokens, written []string) rebaseStatus {
+	switch {
+	case tokensEqual(tokens, written):
+		return rebaseOverwritten
+	case isTokenPrefix(written, tokens):
+		return rebaseInsideOverwritten
+	}
+	return rebaseOutside
+}
+
+// shiftIndex 返回把第 n 个令牌（数组下标）加上 delta 后的路径
+func shiftIndex(tokens []string, n, delta int) []string {
+	index, _ := strconv.Atoi(tokens[n])
+	shifted := copyTokens(tokens)
+	shifted[n] = strconv.Itoa(index + delta)
+	return shifted
+}
@@ -0,0 +1,131 @@
+package leptjson
+
+import "testing"
+
+func TestRebase(t *testing.T) {
+	tests := []struct {
+		name     string
+		doc      string
+		pending  string
+		applied  string
+		rebased  string
+		expected string
+	}{
+		{"插入使下标后移",
+			`{"items": ["a", "b", "c"]}`,
+			`[{"op": "replace", "path": "/items/1", "value": "B"}]`,
+			`[{"op": "add", "path": "/items/0", "value": "z"}]`,
+			`[{"op":"replace","path":"/items/2","value":"B"}]`,
+			`{"items": ["z", "a", "B", "c"]}`},
+		{"删除使下标前移",
+			`{"items": ["a", "b", "c"]}`,
+			`[{"op": "remove", "path": "/items/2"}, {"op": "add", "path": "/items/0", "value": "x"}]`,
+			`[{"op": "remove", "path": "/items/0"}]`,
+			`[{"op":"remove","path":"/items/1"},{"op":"add","path":"/items/0","value":"x"}]`,
+			`{"items": ["x", "b"]}`},
+		{"在同一下标插入时后应用的排在后面",
+			`[1, 2]`,
+			`[{"op": "add", "path": "/1", "value": "p"}]`,
+			`[{"op": "add", "path": "/1", "value": "a"}]`,
+			`[{"op":"add","path":"/2","value":"p"}]`,
+			`[1, "a", "p", 2]`},
+		{"同一位置后写入的获胜",
+			`{"title": "old"}`,
+			`[{"op": "replace", "path": "/title", "value": "mine"}]`,
+			`[{"op": "replace", "path": "/title", "value": "theirs"}]`,
+			`[{"op":"replace","path":"/title","value":"mine"}]`,
+			`{"title": "mine"}`},
+		{"已删除的位置上的替换改为添加",
+			`{"title": "old", "n": 1}`,
+			`[{"op": "replace", "path": "/title", "value": "mine"}]`,
+			`[{"op": "remove", "path": "/title"}]`,
+			`[{"op":"add","path":"/title","value":"mine"}]`,
+			`{"n": 1, "title": "mine"}`},
+		{"已删除的子树中的操作被丢弃",
+			`{"user": {"name": "a"}, "items": [{"v": 1}, {"v": 2}]}`,
+			`[{"op": "replace", "path": "/user/name", "value": "b"}, {"op": "remove", "path": "/items/0/v"}, {"op": "remove", "path": "/items/1"}, {"op": "add", "path": "/x", "value": 1}]`,
+			`[{"op": "remove", "path": "/user"}, {"op": "remove", "path": "/items/0"}]`,
+			`[{"op":"remove","path":"/items/0"},{"op":"add","path":"/x","value":1}]`,
+			`{"items": [], "x": 1}`},
+		{"两边都删除同一个元素",
+			`["a", "b", "c"]`,
+			`[{"op": "remove", "path": "/1"}, {"op": "replace", "path": "/1", "value": "C"}]`,
+			`[{"op": "remove", "path": "/1"}]`,
+			`[{"op":"replace","path":"/1","value":"C"}]`,
+			`["a", "C"]`},
+		{"跟随被移动的值",
+			`{"a": {"b": 1}, "list": [0]}`,
+			`[{"op": "replace", "path": "/a/b", "value": 2}]`,
+			`[{"op": "move", "from": "/a", "path": "/list/0"}]`,
+			`[{"op":"replace","path":"/list/0/b","value":2}]`,
+			`{"list": [{"b": 2}, 0]}`},
+		{"patch 中前面的操作影响后面的操作",
+			`{"items": ["a", "b", "c"]}`,
+			`[{"op": "add", "path": "/items/0", "value": "x"}, {"op": "replace", "path": "/items/3", "value": "C"}]`,
+			`[{"op": "remove", "path": "/items/2"}]`,
+			`[{"op":"add","path":"/items/0","value":"x"},{"op":"add","path":"/items/3","value":"C"}]`,
+			`{"items": ["x", "a", "b", "C"]}`},
+		{"整体替换的子树中的操作被丢弃",
+			`{"cfg": {"a": 1}}`,
+			`[{"op": "replace", "path": "/cfg/a", "value": 2}, {"op": "copy", "from": "/cfg", "path": "/backup"}]`,
+			`[{"op": "replace", "path": "/cfg", "value": {"b": 1}}]`,
+			`[{"op":"copy","path":"/backup","from":"/cfg"}]`,
+			`{"cfg": {"b": 1}, "backup": {"b": 1}}`},
+		{"test 只调整下标",
+			`[1, 2, 3]`,
+			`[{"op": "test", "path": "/1", "value": 2}, {"op": "replace", "path": "/1", "value": 20}]`,
+			`[{"op": "add", "path": "/-", "value": 4}, {"op": "add", "path": "/0", "value": 0}]`,
+			`[{"op":"test","path":"/2","value":2},{"op":"replace","path":"/2","value":20}]`,
+			`[0, 1, 20, 3, 4]`},
+	}
+	for _, tt := range tests {
+		rebased, err := Rebase(mustPatch(t, tt.pending), mustPatch(t, tt.applied))
+		if err != nil {
+			t.Errorf("%s: Rebase 失败: %v", tt.name, err)
+			continue
+		}
+		if s, _ := rebased.String(); s != tt.rebased {
+			t.Errorf("%s: Rebase = %s, 期望 %s", tt.name, s, tt.rebased)
+			continue
+		}
+		doc := mustParse(t, tt.doc)
+		if err := mustPatch(t, tt.applied).Apply(doc); err != nil {
+			t.Fatalf("%s: 应用 applied 失败: %v", tt.name, err)
+		}
+		if err := rebased.Apply(doc); err != nil {
+			t.Errorf("%s: 应用变基后的补丁失败: %v", tt.name, err)
+			continue
+		}
+		if expected := mustParse(t, tt.expected); !Equal(doc, expected) {
+			s, _ := Stringify(doc)
+			t.Errorf("%s: 结果 = %s, 期望 %s", tt.name, s, tt.expected)
+		}
+	}
+}
+
+func TestRebaseTestFails(t *testing.T) {
+	doc := mustParse(t, `{"n": 1}`)
+	applied := mustPatch(t, `[{"op": "replace", "path": "/n", "value": 2}]`)
+	rebased, err := Rebase(mustPatch(t, `[{"op": "test", "path": "/n", "value": 1}, {"op": "replace", "path": "/n", "value": 3}]`), applied)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied.Apply(doc)
+	if err := rebased.Apply(doc); err == nil {
+		t.Errorf("被修改的值上的 test 应该失败")
+	}
+}
+
+func TestRebaseInvalid(t *testing.T) {
+	bad := &JSONPatch{Operations: []PatchOperation{{Op: "remove", Path: "a"}}}
+	if _, err := Rebase(bad, nil); err == nil {
+		t.Errorf("无效的路径应该返回错误")
+	}
+	if _, err := Rebase(mustPatch(t, `[]`), bad); err == nil {
+		t.Errorf("无效的路径应该返回错误")
+	}
+	rebased, err := Rebase(nil, nil)
+	if err != nil || len(rebased.Operations) != 0 {
+		t.Errorf("Rebase(nil, nil) = %v, %v", rebased, err)
+	}
+}
@@ -0,0 +1,437 @@
+// pipeline.go - 流水线模式：逐条过滤、转换 NDJSON 记录，失败的记录写入死信输出
+package leptjson
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// RecordFilter 是编译后的记录过滤表达式，语法与 JSON Path 的过滤器 [?(...)] 相同，
+// @ 和 $ 都表示记录本身
+type RecordFilter struct {
+	Expr string // 原始表达式
+	expr filterExpr
+}
+
+// CompileRecordFilter 编译记录过滤表达式
+//
+// 表达式可以写成 @.status == "active"，也可以带上过滤器的括号：?(...) 或 [?(...)]。
+func CompileRecordFilter(expr string) (*RecordFilter, error) {
+	body := strings.TrimSpace(expr)
+	if strings.HasPrefix(body, "[?") && strings.HasSuffix(body, "]") {
+		body = strings.TrimSpace(body[2 : len(body)-1])
+	} else if strings.HasPrefix(body, "?") {
+		body = strings.TrimSpace(body[1:])
+	}
+	if body == "" {
+		return nil, fmt.Errorf("过滤表达式不能为空")
+	}
+	compiled, err := compileFilter(body, 0, len(body))
+	if err != nil {
+		return nil, fmt.Errorf("无效的过滤表达式 '%s': %v", expr, err)
+	}
+	return &RecordFilter{Expr: expr, expr: compiled}, nil
+}
+
+// Match 判断记录是否满足过滤表达式
+func (f *RecordFilter) Match(record *Value) (bool, error) {
+	return f.expr.test(record, record)
+}
+
+// TransformSpec 描述对每条记录的转换，按字段顺序依次执行：
+//
+//	{
+//	  "patch":   [...],                      // JSON Patch，如添加或替换成员
+//	  "delete":  ["$..password"],            // 删除所有匹配 JSON Path 的值
+//	  "units":   {"bytes": "MiB"},           // 把带单位的数值换算为各量纲的目标单位
+//	  "keyCase": "snake",                    // 转换对象键的命名风格
+//	  "select":  {"id": "$.id", "name": "$.user.name"}
+//	}
+//
+// select 把记录投影为新的对象：每个 JSON Path 恰好匹配一个值时取这个值，
+// 匹配多个值时取所有值组成的数组，没有匹配时省略这个成员。units 使用内置的换算表，
+// 可以用 "unitTable": {"量纲": {"单位": 系数}} 添加单位，见 UnitTable.Load。各部分都可以省略。
+type TransformSpec struct {
+	Patch   *JSONPatch
+	Delete  []*JSONPath
+	Units   *UnitOptions
+	KeyCase *KeyCase
+	Select  []SelectField
+}
+
+// SelectField 是 select 中的一个输出成员
+type SelectField struct {
+	Key  string
+	Path *JSONPath
+}
+
+// ParseTransformSpec 从 JSON 对象解析转换描述
+func ParseTransformSpec(spec *Value) (*TransformSpec, error) {
+	if spec == nil || spec.Type != OBJECT {
+		return nil, fmt.Errorf("转换描述必须是JSON对象")
+	}
+	t := &TransformSpec{}
+	var unitTable *Value
+	for _, member := range spec.O {
+		switch member.K {
+		case "patch":
+			patch, err := NewJSONPatch(member.V)
+			if err != nil {
+				return nil, fmt.Errorf("转换描述的 patch 无效: %v", err)
+			}
+			t.Patch = patch
+		case "delete":
+			if member.V.Type != ARRAY {
+				return nil, fmt.Errorf("转换描述的 delete 必须是JSON Path数组")
+			}
+			for _, e := range member.V.A {
+				if e.Type != STRING {
+					return nil, fmt.Errorf("转换描述的 delete 必须是JSON Path数组")
+				}
+				jp, err := NewJSONPath(GetString(e))
+				if err != nil {
+					return nil, fmt.Errorf("转换描述的 delete 中 '%s' 无效: %v", GetString(e), err)
+				}
+				t.Delete = append(t.Delete, jp)
+			}
+		case "units":
+			if member.V.Type != OBJECT {
+				return nil, fmt.Errorf("转换描述的 units 必须是对象")
+			}
+			t.Units = &UnitOptions{Targets: make(map[string]string)}
+			for _, target := range member.V.O {
+				if target.V.Type != STRING {
+					return nil, fmt.Errorf("转换描述的 units.%s 必须是单位名称", target.K)
+				}
+				t.Units.Targets[target.K] = GetString(target.V)
+			}
+		case "unitTable":
+			unitTable = member.V
+		case "keyCase":
+			if member.V.Type != STRING {
+				return nil, fmt.Errorf("转换描述的 keyCase 必须是字符串")
+			}
+			style, err := ParseKeyCase(GetString(member.V))
+			if err != nil {
+				return nil, err
+			}
+			t.KeyCase = &style
+		case "select":
+			if member.V.Type != OBJECT {
+				return nil, fmt.Errorf("转换描述的 select 必须是对象")
+			}
+			for _, field := range member.V.O {
+				if field.V.Type != STRING {
+					return nil, fmt.Errorf("转换描述的 select.%s 必须是JSON Path字符串", field.K)
+				}
+				jp, err := NewJSONPath(GetString(field.V))
+				if err != nil {
+					return nil, fmt.Errorf("转换描述的 select.%s 无效: %v", field.K, err)
+				}
+				t.Select = append(t.Select, SelectField{Key: field.K, Path: jp})
+			}
+		default:
+			return nil, fmt.Errorf("转换描述中未知的字段 '%s'，可用字段: patch, delete, units, unitTable, keyCase, select", member.K)
+		}
+	}
+	if unitTable != nil {
+		if t.Units == nil {
+			return nil, fmt.Errorf("转换描述的 unitTable 需要与 units 一起使用")
+		}
+		t.Units.Table = DefaultUnitTable()
+		if err := t.Units.Table.Load(unitTable); err != nil {
+			return nil, fmt.Errorf("转换描述的 unitTable 无效: %v", err)
+		}
+	}
+	if t.Units != nil {
+		// 提前检查目标单位，避免每条记录报告同样的错误
+		if _, err := NormalizeUnits(&Value{}, *t.Units); err != nil {
+			return nil, fmt.Errorf("转换描述的 units 无效: %v", err)
+		}
+	}
+	return t, nil
+}
+
+// Apply 转换一条记录，record 可能被修改，返回转换后的记录
+func (t *TransformSpec) Apply(record *Value) (*Value, error) {
+	if t.Patch != nil {
+		if err := t.Patch.Apply(record); err != nil {
+			return nil, fmt.Errorf("应用补丁失败: %v", err)
+		}
+	}
+	for _, jp := range t.Delete {
+		if _, err := jp.Delete(record); err != nil {
+			return nil, fmt.Errorf("删除 '%s' 失败: %v", jp.Path, err)
+		}
+	}
+	if t.Units != nil {
+		if _, err := NormalizeUnits(record, *t.Units); err != nil {
+			return nil, err
+		}
+	}
+	if t.KeyCase != nil {
+		converted, err := ConvertKeys(record, *t.KeyCase)
+		if err != nil {
+			return nil, err
+		}
+		record = converted
+	}
+	if len(t.Select) > 0 {
+		selected := &Value{}
+		SetObject(selected)
+		for _, field := range t.Select {
+			matches, err := field.Path.Query(record)
+			if err != nil {
+				return nil, fmt.Errorf("查询 '%s' 失败: %v", field.Path.Path, err)
+			}
+			switch len(matches) {
+			case 0:
+				continue
+			case 1:
+				Copy(SetObjectValue(selected, field.Key), matches[0])
+			default:
+				list := SetObjectValue(selected, field.Key)
+				SetArray(list, len(matches))
+				for _, m := range matches {
+					Copy(PushBackArrayElement(list), m)
+				}
+			}
+		}
+		record = selected
+	}
+	return record, nil
+}
+
+// Record 是流水线中的一条记录
+type Record struct {
+	Data     []byte // 记录的JSON文本，不包含换行
+	Position string // 记录在输入中的位置，如"第3行"，写入死信输出
+}
+
+// RecordSource 逐条读取记录，读完时返回 io.EOF
+type RecordSource interface {
+	Next() (Record, error)
+	Close() error
+}
+
+// RecordSink 逐条写入记录，Close 时写出缓冲的内容
+type RecordSink interface {
+	Write(data []byte) error
+	Close() error
+}
+
+// PipelineOptions 控制 RunPipeline 如何处理记录
+type PipelineOptions struct {
+	Filter       *RecordFilter  // 为 nil 时保留所有记录
+	Transform    *TransformSpec // 为 nil 时不转换
+	Workers      int            // 并发处理记录的goroutine数量，为0时使用 CPU 数量
+	ParseOptions ParseOptions   // 解析每条记录的选项，安全限制作用于单条记录
+
+	// DeadLetter 接收解析、过滤或转换失败的记录，格式为
+	// {"position": "第3行", "error": "...", "record": "原始文本"}。
+	// 为 nil 时第一条失败的记录使 RunPipeline 返回 *RecordError。
+	DeadLetter RecordSink
+}
+
+// PipelineStats 是流水线处理的记录数量
+type PipelineStats struct {
+	Read     int `json:"read"`     // 读取的记录
+	Written  int `json:"written"`  // 写入输出的记录
+	Filtered int `json:"filtered"` // 不满足过滤表达式的记录
+	Failed   int `json:"failed"`   // 写入死信输出的记录
+}
+
+// RecordError 是一条记录处理失败的错误
+type RecordError struct {
+	Record Record
+	Err    error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Record.Position, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// pipelineResult 是一条记录的处理结果
+type pipelineResult struct {
+	seq     int
+	record  Record
+	output  []byte
+	dropped bool // 不满足过滤表达式
+	err     error
+}
+
+// RunPipeline 从 src 读取记录，并发地解析、过滤和转换后按输入顺序写入 dst
+//
+// 读取、写入 dst 或 DeadLetter 失败时停止处理并返回错误；ctx 取消时返回 ctx.Err()。
+// 返回前不关闭 src、dst 和 DeadLetter。
+func RunPipeline(ctx context.Context, src RecordSource, dst RecordSink, options PipelineOptions) (PipelineStats, error) {
+	var stats PipelineStats
+	workers := options.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// window 限制已经读取但尚未写出的记录数量，避免一条慢记录使后面的结果无限堆积
+	window := make(chan struct{}, workers*4)
+	jobs := make(chan pipelineResult)
+	results := make(chan pipelineResult, workers)
+	var readErr error
+	go func() {
+		defer close(jobs)
+		for seq := 0; ; seq++ {
+			select {
+			case window <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			record, err := src.Next()
+			if err != nil {
+				if err != io.EOF {
+					readErr = err
+				}
+				return
+			}
+			select {
+			case jobs <- pipelineResult{seq: seq, record: record}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				processRecord(&job, options)
+				select {
+				case results <- job:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// 出错或 ctx 取消时立即返回：其余goroutine在 ctx 取消后自行结束，
+	// 阻塞在 src.Next 中的读取在调用者关闭 src 后结束
+	pending := make(map[int]pipelineResult)
+	next := 0
+	for {
+		var result pipelineResult
+		var ok bool
+		select {
+		case result, ok = <-results:
+		case <-ctx.Done():
+			return stats, ctx.Err()
+		}
+		if !ok {
+			break
+		}
+		pending[result.seq] = result
+		for {
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			<-window
+			stats.Read++
+			if err := emitRecord(r, dst, options.DeadLetter, &stats); err != nil {
+				return stats, err
+			}
+		}
+	}
+	// results 在读取goroutine结束之后才关闭，这里可以安全地读取 readErr
+	if readErr != nil {
+		return stats, fmt.Errorf("读取记录失败: %w", readErr)
+	}
+	// 调用者的 ctx 被取消时，各goroutine可能在 results 关闭之前就已经停止
+	if err := ctx.Err(); err != nil {
+		return stats, err
+	}
+	return stats, nil
+}
+
+// processRecord 解析、过滤并转换一条记录，结果写回 r
+func processRecord(r *pipelineResult, options PipelineOptions) {
+	v := &Value{}
+	if err := ParseWithOptions(v, string(r.record.Data), options.ParseOptions); err != PARSE_OK {
+		r.err = fmt.Errorf("解析失败: %s", err)
+		return
+	}
+	if options.Filter != nil {
+		ok, err := options.Filter.Match(v)
+		if err != nil {
+			r.err = fmt.Errorf("过滤失败: %v", err)
+			return
+		}
+		if !ok {
+			r.dropped = true
+			return
+		}
+	}
+	if options.Transform != nil {
+		transformed, err := options.Transform.Apply(v)
+		if err != nil {
+			r.err = fmt.Errorf("转换失败: %v", err)
+			return
+		}
+		v = transformed
+	}
+	text, err := Stringify(v)
+	if err != STRINGIFY_OK {
+		r.err = fmt.Errorf("序列化失败: %v", err)
+		return
+	}
+	r.output = []byte(text)
+}
+
+// emitRecord 把一条处理结果写入输出或死信输出
+func emitRecord(r pipelineResult, dst, deadLetter RecordSink, stats *PipelineStats) error {
+	switch {
+	case r.err != nil:
+		if deadLetter == nil {
+			return &RecordError{Record: r.record, Err: r.err}
+		}
+		if err := deadLetter.Write(deadLetterEntry(r.record, r.err)); err != nil {
+			return fmt.Errorf("写入死信输出失败: %w", err)
+		}
+		stats.Failed++
+	case r.dropped:
+		stats.Filtered++
+	default:
+		if err := dst.Write(r.output); err != nil {
+			return fmt.Errorf("写入输出失败: %w", err)
+		}
+		stats.Written++
+	}
+	return nil
+}
+
+// deadLetterEntry 生成死信输出中的一行
+func deadLetterEntry(record Record, err error) []byte {
+	entry := &Value{}
+	SetObject(entry)
+	SetString(SetObjectValue(entry, "position"), record.Position)
+	SetString(SetObjectValue(entry, "error"), err.Error())
+	SetString(SetObjectValue(entry, "record"), string(record.Data))
+	text, _ := Stringify(entry)
+	return []byte(text)
+}
@@ -0,0 +1,110 @@
+package leptjson
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNDJSONSource(t *testing.T) {
+	src := NewNDJSONSource(strings.NewReader("{\"a\":1}\r\n\n  \n[2]\n\"last\""), 0)
+	expected := []Record{
+		{Data: []byte(`{"a":1}`), Position: "第1行"},
+		{Data: []byte(`[2]`), Position: "第4行"},
+		{Data: []byte(`"last"`), Position: "第5行"},
+	}
+	for _, want := range expected {
+		got, err := src.Next()
+		if err != nil {
+			t.Fatalf("Next 失败: %v", err)
+		}
+		if !bytes.Equal(got.Data, want.Data) || got.Position != want.Position {
+			t.Errorf("Next = %s (%s), 期望 %s (%s)", got.Data, got.Position, want.Data, want.Position)
+		}
+	}
+	if _, err := src.Next(); err != io.EOF {
+		t.Errorf("读完后的错误 = %v, 期望 io.EOF", err)
+	}
+}
+
+func TestNDJSONSourceLongLine(t *testing.T) {
+	// 超过 bufio 缓冲的行也能完整读取
+	long := `"` + strings.Repeat("x", 100000) + `"`
+	src := NewNDJSONSource(strings.NewReader(long+"\n1\n"), 0)
+	if got, err := src.Next(); err != nil || string(got.Data) != long {
+		t.Fatalf("长行读取错误: %v", err)
+	}
+
+	src = NewNDJSONSource(strings.NewReader("1\n"+long+"\n"), 1000)
+	src.Next()
+	_, err := src.Next()
+	var tooLarge *MaxBytesError
+	if !errors.As(err, &tooLarge) || tooLarge.Limit != 1000 || !strings.Contains(err.Error(), "第2行") {
+		t.Errorf("超过限制的行的错误 = %v", err)
+	}
+}
+
+func TestNDJSONSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewNDJSONSink(&buf)
+	sink.Write([]byte(`{"a":1}`))
+	sink.Write([]byte(`2`))
+	if buf.Len() != 0 {
+		t.Errorf("Close 之前不应该写出")
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close 失败: %v", err)
+	}
+	if buf.String() != "{\"a\":1}\n2\n" {
+		t.Errorf("输出 = %q", buf.String())
+	}
+}
+
+func TestOpenRecordSourceAndSink(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.ndjson")
+	output := filepath.Join(dir, "out.ndjson")
+	if err := ioutil.WriteFile(input, []byte("{\"n\":1}\n{\"n\":2}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	src, err := OpenRecordSource(ctx, input, 0)
+	if err != nil {
+		t.Fatalf("OpenRecordSource 失败: %v", err)
+	}
+	dst, err := OpenRecordSink(ctx, output)
+	if err != nil {
+		t.Fatalf("OpenRecordSink 失败: %v", err)
+	}
+	spec, _ := ParseTransformSpec(mustParse(t, `{"patch": [{"op": "add", "path": "/ok", "value": true}]}`))
+	if _, err := RunPipeline(ctx, src, dst, PipelineOptions{Transform: spec}); err != nil {
+		t.Fatalf("RunPipeline 失败: %v", err)
+	}
+	src.Close()
+	if err := dst.Close(); err != nil {
+		t.Fatalf("Close 失败: %v", err)
+	}
+	data, _ := os.ReadFile(output)
+	if string(data) != "{\"n\":1,\"ok\":true}\n{\"n\":2,\"ok\":true}\n" {
+		t.Errorf("输出文件 = %q", data)
+	}
+
+	if _, err := OpenRecordSink(ctx, "ftp://host/out"); err == nil {
+		t.Errorf("未注册的输出协议应该返回错误")
+	}
+	recordStreamMu.RLock()
+	_, registered := recordSources["kafka"]
+	recordStreamMu.RUnlock()
+	if !registered {
+		if _, err := OpenRecordSource(ctx, "kafka://localhost:8082/topic", 0); err == nil || !strings.Contains(err.Error(), "-tags kafka") {
+			t.Errorf("错误 = %v, 期望提示 -tags kafka", err)
+		}
+	}
+}
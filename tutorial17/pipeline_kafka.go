@@ -0,0 +1,281 @@
+//go:build kafka
+// +build kafka
+
+// pipeline_kafka.go - kafka:// 记录源和输出，通过 Kafka REST Proxy（v2 API）读写主题
+package leptjson
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+func init() {
+	RegisterRecordSource("kafka", openKafkaSource)
+	RegisterRecordSink("kafka", openKafkaSink)
+}
+
+// Kafka REST Proxy v2 的内容类型
+const (
+	kafkaV2ContentType   = "application/vnd.kafka.v2+json"
+	kafkaJSONContentType = "application/vnd.kafka.json.v2+json"
+)
+
+// kafkaSinkBatch 是输出一次提交的最大记录数量
+const kafkaSinkBatch = 100
+
+// kafkaTarget 是从 kafka://HOST:PORT/TOPIC?... 解析出的 REST Proxy 地址和主题
+//
+// 查询参数：
+//
+//	tls=1          用 https 访问 REST Proxy
+//	group=NAME     消费者组（默认 leptjson）
+//	follow=1       读完现有记录后继续等待新记录，不结束
+//	idle=DURATION  没有 follow 时，连续这么长时间没有新记录就结束（默认5s）
+type kafkaTarget struct {
+	base   string
+	topic  string
+	query  url.Values
+	client *http.Client
+}
+
+func parseKafkaTarget(uri *url.URL) (*kafkaTarget, error) {
+	topic := strings.Trim(uri.Path, "/")
+	if uri.Host == "" || topic == "" || strings.Contains(topic, "/") {
+		return nil, fmt.Errorf("无效的Kafka地址 %s，应为 kafka://REST代理主机:端口/主题", uri)
+	}
+	scheme := "http"
+	if uri.Query().Get("tls") == "1" {
+		scheme = "https"
+	}
+	return &kafkaTarget{
+		base:   scheme + "://" + uri.Host,
+		topic:  topic,
+		query:  uri.Query(),
+		client: http.DefaultClient,
+	}, nil
+}
+
+// do 发送请求并解析 JSON 响应，out 为 nil 时忽略响应体
+func (t *kafkaTarget) do(ctx context.Context, method, target, contentType, body string, out *Value) error {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return err
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", contentType)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求Kafka REST Proxy失败: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("Kafka REST Proxy 返回 %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := Parse(out, string(data)); err != PARSE_OK {
+		return fmt.Errorf("解析Kafka REST Proxy响应失败: %s", err)
+	}
+	return nil
+}
+
+// kafkaSource 通过消费者实例读取主题中的记录
+type kafkaSource struct {
+	target   *kafkaTarget
+	ctx      context.Context
+	instance string // 消费者实例的地址
+	follow   bool
+	idle     time.Duration
+	poll     time.Duration // 没有新记录时两次拉取的间隔
+	buffered []Record
+	lastSeen time.Time
+}
+
+func openKafkaSource(ctx context.Context, uri *url.URL) (RecordSource, error) {
+	target, err := parseKafkaTarget(uri)
+	if err != nil {
+		return nil, err
+	}
+	s := &kafkaSource{
+		target:   target,
+		ctx:      ctx,
+		follow:   target.query.Get("follow") == "1",
+		idle:     5 * time.Second,
+		poll:     500 * time.Millisecond,
+		lastSeen: time.Now(),
+	}
+	if idle := target.query.Get("idle"); idle != "" {
+		if s.idle, err = time.ParseDuration(idle); err != nil {
+			return nil, fmt.Errorf("无效的 idle 参数 '%s': %v", idle, err)
+		}
+	}
+	group := target.query.Get("group")
+	if group == "" {
+		group = "leptjson"
+	}
+
+	var created Value
+	err = target.do(ctx, http.MethodPost, target.base+"/consumers/"+url.PathEscape(group), kafkaV2ContentType,
+		`{"format":"json","auto.offset.reset":"earliest"}`, &created)
+	if err != nil {
+		return nil, fmt.Errorf("创建Kafka消费者失败: %w", err)
+	}
+	if created.Type != OBJECT || GetObjectValueByKey(&created, "base_uri") == nil {
+		return nil, fmt.Errorf("创建Kafka消费者失败: 响应中没有 base_uri")
+	}
+	s.instance = GetString(GetObjectValueByKey(&created, "base_uri"))
+
+	subscription := `{"topics":[` + formatJSONString(target.topic) + `]}`
+	if err := target.do(ctx, http.MethodPost, s.instance+"/subscription", kafkaV2ContentType, subscription, nil); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("订阅主题 %s 失败: %w", target.topic, err)
+	}
+	return s, nil
+}
+
+// Next 返回下一条记录，没有 follow 时连续 idle 时间没有新记录后返回 io.EOF
+func (s *kafkaSource) Next() (Record, error) {
+	for len(s.buffered) == 0 {
+		if err := s.fetch(); err != nil {
+			return Record{}, err
+		}
+		if len(s.buffered) > 0 {
+			s.lastSeen = time.Now()
+			break
+		}
+		if !s.follow && time.Since(s.lastSeen) >= s.idle {
+			return Record{}, io.EOF
+		}
+		select {
+		case <-time.After(s.poll):
+		case <-s.ctx.Done():
+			return Record{}, s.ctx.Err()
+		}
+	}
+	record := s.buffered[0]
+	s.buffered = s.buffered[1:]
+	return record, nil
+}
+
+// fetch 拉取一批记录放入缓冲
+func (s *kafkaSource) fetch() error {
+	var records Value
+	if err := s.target.do(s.ctx, http.MethodGet, s.instance+"/records", kafkaJSONContentType, "", &records); err != nil {
+		return err
+	}
+	if records.Type != ARRAY {
+		return fmt.Errorf("Kafka REST Proxy 返回的记录不是数组")
+	}
+	for _, r := range records.A {
+		value := GetObjectValueByKey(r, "value")
+		if value == nil {
+			continue
+		}
+		text, err := Stringify(value)
+		if err != STRINGIFY_OK {
+			return fmt.Errorf("序列化Kafka记录失败: %v", err)
+		}
+		position := fmt.Sprintf("%s[%v]@%v", s.target.topic, kafkaNumber(r, "partition"), kafkaNumber(r, "offset"))
+		s.buffered = append(s.buffered, Record{Data: []byte(text), Position: position})
+	}
+	return nil
+}
+
+// kafkaNumber 返回对象中的整数成员，不存在时返回 "?"
+func kafkaNumber(v *Value, key string) interface{} {
+	n := GetObjectValueByKey(v, key)
+	if n == nil || n.Type != NUMBER {
+		return "?"
+	}
+	return int64(n.N)
+}
+
+// Close 删除消费者实例，已经读取的偏移量由 REST Proxy 自动提交
+func (s *kafkaSource) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return s.target.do(ctx, http.MethodDelete, s.instance, kafkaV2ContentType, "", nil)
+}
+
+// kafkaSink 把记录成批地写入主题
+type kafkaSink struct {
+	target *kafkaTarget
+	ctx    context.Context
+
+	mu      sync.Mutex
+	pending []string
+}
+
+func openKafkaSink(ctx context.Context, uri *url.URL) (RecordSink, error) {
+	target, err := parseKafkaTarget(uri)
+	if err != nil {
+		return nil, err
+	}
+	return &kafkaSink{target: target, ctx: ctx}, nil
+}
+
+// Write 缓冲一条记录，缓冲满 kafkaSinkBatch 条时提交
+func (s *kafkaSink) Write(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending = append(s.pending, string(data))
+	if len(s.pending) >= kafkaSinkBatch {
+		return s.flushLocked()
+	}
+	return nil
+}
+
+// Close 提交缓冲中的记录
+func (s *kafkaSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.flushLocked()
+}
+
+// flushLocked 提交缓冲中的记录，任何一条写入失败时返回错误，调用者必须持有 s.mu
+func (s *kafkaSink) flushLocked() error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+	var sb strings.Builder
+	sb.WriteString(`{"records":[`)
+	for i, data := range s.pending {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(`{"value":` + data + `}`)
+	}
+	sb.WriteString(`]}`)
+
+	var result Value
+	target := s.target.base + "/topics/" + url.PathEscape(s.target.topic)
+	if err := s.target.do(s.ctx, http.MethodPost, target, kafkaJSONContentType, sb.String(), &result); err != nil {
+		return fmt.Errorf("写入主题 %s 失败: %w", s.target.topic, err)
+	}
+	s.pending = s.pending[:0]
+	if offsets := GetObjectValueByKey(&result, "offsets"); offsets != nil && offsets.Type == ARRAY {
+		for i, offset := range offsets.A {
+			if e := GetObjectValueByKey(offset, "error"); e != nil && e.Type == STRING {
+				return fmt.Errorf("写入主题 %s 的第%d条记录失败: %s", s.target.topic, i+1, GetString(e))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+//go:build kafka
+// +build kafka
+
+package leptjson
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeKafkaProxy 模拟 Kafka REST Proxy 的消费者和生产者端点
+type fakeKafkaProxy struct {
+	mu       sync.Mutex
+	batches  []string // 依次由 GET records 返回的响应
+	produced []string // POST topics 收到的请求体
+	deleted  bool
+}
+
+func (p *fakeKafkaProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	body, _ := io.ReadAll(r.Body)
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/consumers/g1":
+		io.WriteString(w, `{"instance_id":"c1","base_uri":"http://`+r.Host+`/consumers/g1/instances/c1"}`)
+	case r.Method == http.MethodPost && r.URL.Path == "/consumers/g1/instances/c1/subscription":
+		if string(body) != `{"topics":["events"]}` {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet && r.URL.Path == "/consumers/g1/instances/c1/records":
+		if len(p.batches) == 0 {
+			io.WriteString(w, `[]`)
+			return
+		}
+		io.WriteString(w, p.batches[0])
+		p.batches = p.batches[1:]
+	case r.Method == http.MethodDelete && r.URL.Path == "/consumers/g1/instances/c1":
+		p.deleted = true
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPost && r.URL.Path == "/topics/out":
+		if r.Header.Get("Content-Type") != kafkaJSONContentType {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		p.produced = append(p.produced, string(body))
+		io.WriteString(w, `{"offsets":[{"partition":0,"offset":0}]}`)
+	case r.Method == http.MethodPost && r.URL.Path == "/topics/broken":
+		io.WriteString(w, `{"offsets":[{"partition":0,"offset":null,"error_code":40403,"error":"Schema not found"}]}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestKafkaPipeline(t *testing.T) {
+	proxy := &fakeKafkaProxy{batches: []string{
+		`[{"topic":"events","partition":0,"offset":10,"value":{"level":"error","id":1}},
+		  {"topic":"events","partition":0,"offset":11,"value":{"level":"info","id":2}}]`,
+		`[]`,
+		`[{"topic":"events","partition":1,"offset":3,"value":"bad"}]`,
+	}}
+	ts := httptest.NewServer(proxy)
+	defer ts.Close()
+	host := strings.TrimPrefix(ts.URL, "http://")
+
+	ctx := context.Background()
+	src, err := OpenRecordSource(ctx, "kafka://"+host+"/events?group=g1&idle=200ms", 0)
+	if err != nil {
+		t.Fatalf("打开Kafka记录源失败: %v", err)
+	}
+	src.(*kafkaSource).poll = 10 * time.Millisecond
+	dst, err := OpenRecordSink(ctx, "kafka://"+host+"/out")
+	if err != nil {
+		t.Fatalf("打开Kafka输出失败: %v", err)
+	}
+	// 过滤掉 info 记录；字符串记录无法应用补丁，写入死信输出
+	filter, _ := CompileRecordFilter(`@.level != "info"`)
+	spec, _ := ParseTransformSpec(mustParse(t, `{"patch": [{"op": "add", "path": "/seen", "value": true}]}`))
+	deadLetter := &sliceSink{}
+	stats, err := RunPipeline(ctx, src, dst, PipelineOptions{Filter: filter, Transform: spec, DeadLetter: deadLetter})
+	if err != nil {
+		t.Fatalf("RunPipeline 失败: %v", err)
+	}
+	if err := dst.Close(); err != nil {
+		t.Fatalf("提交记录失败: %v", err)
+	}
+	if err := src.Close(); err != nil {
+		t.Fatalf("删除消费者失败: %v", err)
+	}
+
+	if stats.Read != 3 || stats.Written != 1 || stats.Filtered != 1 || stats.Failed != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+	proxy.mu.Lock()
+	defer proxy.mu.Unlock()
+	if len(proxy.produced) != 1 || proxy.produced[0] != `{"records":[{"value":{"level":"error","id":1,"seen":true}}]}` {
+		t.Errorf("写入主题的内容 = %v", proxy.produced)
+	}
+	if !proxy.deleted {
+		t.Errorf("Close 应该删除消费者实例")
+	}
+	if len(deadLetter.records) != 1 || !strings.Contains(deadLetter.records[0], `"position":"events[1]@3"`) {
+		t.Errorf("死信输出 = %v", deadLetter.records)
+	}
+}
+
+func TestKafkaSinkErrors(t *testing.T) {
+	ts := httptest.NewServer(&fakeKafkaProxy{})
+	defer ts.Close()
+	host := strings.TrimPrefix(ts.URL, "http://")
+
+	dst, err := OpenRecordSink(context.Background(), "kafka://"+host+"/broken")
+	if err != nil {
+		t.Fatalf("打开Kafka输出失败: %v", err)
+	}
+	dst.Write([]byte(`1`))
+	if err := dst.Close(); err == nil || !strings.Contains(err.Error(), "Schema not found") {
+		t.Errorf("写入失败时的错误 = %v", err)
+	}
+
+	for _, uri := range []string{"kafka:///events", "kafka://" + host, "kafka://" + host + "/a/b"} {
+		if _, err := OpenRecordSink(context.Background(), uri); err == nil {
+			t.Errorf("OpenRecordSink(%s) 应该返回错误", uri)
+		}
+	}
+	if _, err := OpenRecordSource(context.Background(), "kafka://"+host+"/events?group=missing", 0); err == nil {
+		t.Errorf("创建消费者失败时应该返回错误")
+	}
+}
@@ -0,0 +1,265 @@
+package leptjson
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// sliceSource 依次返回给定的记录
+type sliceSource struct {
+	records []string
+	next    int
+}
+
+func (s *sliceSource) Next() (Record, error) {
+	if s.next >= len(s.records) {
+		return Record{}, io.EOF
+	}
+	i := s.next
+	s.next++
+	return Record{Data: []byte(s.records[i]), Position: fmt.Sprintf("第%d条", i+1)}, nil
+}
+
+func (s *sliceSource) Close() error { return nil }
+
+// sliceSink 收集写入的记录
+type sliceSink struct {
+	mu      sync.Mutex
+	records []string
+	err     error
+}
+
+func (s *sliceSink) Write(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.records = append(s.records, string(data))
+	return nil
+}
+
+func (s *sliceSink) Close() error { return nil }
+
+func TestCompileRecordFilter(t *testing.T) {
+	record := mustParse(t, `{"level":"error","code":500,"tags":["db"]}`)
+	tests := []struct {
+		expr  string
+		match bool
+	}{
+		{`@.level == "error"`, true},
+		{`?(@.code >= 500)`, true},
+		{`[?(@.code < 500)]`, false},
+		{`$.level == "error" && "db" in @.tags`, true},
+		{`@.missing`, false},
+		{`!@.missing`, true},
+	}
+	for _, tt := range tests {
+		filter, err := CompileRecordFilter(tt.expr)
+		if err != nil {
+			t.Errorf("CompileRecordFilter(%s) 失败: %v", tt.expr, err)
+			continue
+		}
+		match, err := filter.Match(record)
+		if err != nil || match != tt.match {
+			t.Errorf("Match(%s) = %v, %v, 期望 %v", tt.expr, match, err, tt.match)
+		}
+	}
+
+	for _, expr := range []string{"", "?()", "@.a ==", "@.a == 1)"} {
+		if _, err := CompileRecordFilter(expr); err == nil {
+			t.Errorf("CompileRecordFilter(%q) 应该返回错误", expr)
+		}
+	}
+}
+
+func TestTransformSpec(t *testing.T) {
+	spec, err := ParseTransformSpec(mustParse(t, `{
+		"patch": [{"op": "add", "path": "/source", "value": "app"}],
+		"delete": ["$..password"],
+		"keyCase": "snake",
+		"select": {"id": "$.user_id", "src": "$.source", "names": "$.users[*].user_name", "none": "$.missing"}
+	}`))
+	if err != nil {
+		t.Fatalf("ParseTransformSpec 失败: %v", err)
+	}
+	record := mustParse(t, `{"userId": 7, "password": "x", "users": [{"userName": "a", "password": "y"}, {"userName": "b"}]}`)
+	result, err := spec.Apply(record)
+	if err != nil {
+		t.Fatalf("Apply 失败: %v", err)
+	}
+	expected := mustParse(t, `{"id": 7, "src": "app", "names": ["a", "b"]}`)
+	if !Equal(result, expected) {
+		text, _ := Stringify(result)
+		t.Errorf("Apply = %s, 期望 {\"id\":7,\"src\":\"app\",\"names\":[\"a\",\"b\"]}", text)
+	}
+
+	// 没有 select 时返回修改后的记录本身
+	spec, _ = ParseTransformSpec(mustParse(t, `{"delete": ["$.b"]}`))
+	result, _ = spec.Apply(mustParse(t, `{"a": 1, "b": 2}`))
+	if !Equal(result, mustParse(t, `{"a": 1}`)) {
+		t.Errorf("只有 delete 时的结果错误")
+	}
+
+	// units 在 keyCase 之前执行，unitTable 添加的单位可以使用
+	spec, err = ParseTransformSpec(mustParse(t, `{"units": {"bytes": "GiB"}, "unitTable": {"bytes": {"block": 4096}}}`))
+	if err != nil {
+		t.Fatalf("ParseTransformSpec 失败: %v", err)
+	}
+	result, _ = spec.Apply(mustParse(t, `{"a": {"value": 512, "unit": "MiB"}, "b": {"value": 262144, "unit": "block"}}`))
+	if !Equal(result, mustParse(t, `{"a": {"value": 0.5, "unit": "GiB"}, "b": {"value": 1, "unit": "GiB"}}`)) {
+		t.Errorf("units 的结果错误")
+	}
+	for _, bad := range []string{`{"units": {"bytes": "s"}}`, `{"units": []}`, `{"unitTable": {}}`, `{"units": {}, "unitTable": {"x": 1}}`} {
+		if _, err := ParseTransformSpec(mustParse(t, bad)); err == nil {
+			t.Errorf("%s 应该返回错误", bad)
+		}
+	}
+
+	// 补丁无法应用时返回错误
+	spec, _ = ParseTransformSpec(mustParse(t, `{"patch": [{"op": "remove", "path": "/missing"}]}`))
+	if _, err := spec.Apply(mustParse(t, `{}`)); err == nil {
+		t.Errorf("补丁失败时应该返回错误")
+	}
+
+	invalid := []string{
+		`[]`,
+		`{"unknown": 1}`,
+		`{"delete": "$.a"}`,
+		`{"delete": ["$["]}`,
+		`{"keyCase": "upper"}`,
+		`{"select": {"a": 1}}`,
+		`{"patch": [{"op": "bad"}]}`,
+	}
+	for _, text := range invalid {
+		if _, err := ParseTransformSpec(mustParse(t, text)); err == nil {
+			t.Errorf("ParseTransformSpec(%s) 应该返回错误", text)
+		}
+	}
+}
+
+func TestRunPipelineKeepsOrder(t *testing.T) {
+	var records []string
+	for i := 0; i < 500; i++ {
+		records = append(records, fmt.Sprintf(`{"n":%d,"pad":"%s"}`, i, strings.Repeat("x", (i*37)%200)))
+	}
+	filter, _ := CompileRecordFilter(`@.n >= 0`)
+	spec, _ := ParseTransformSpec(mustParse(t, `{"select": {"n": "$.n"}}`))
+	sink := &sliceSink{}
+	stats, err := RunPipeline(context.Background(), &sliceSource{records: records}, sink, PipelineOptions{
+		Filter:    filter,
+		Transform: spec,
+		Workers:   8,
+	})
+	if err != nil {
+		t.Fatalf("RunPipeline 失败: %v", err)
+	}
+	if stats.Read != 500 || stats.Written != 500 || stats.Filtered != 0 || stats.Failed != 0 {
+		t.Errorf("stats = %+v, 期望读取和输出各500条", stats)
+	}
+	for i, record := range sink.records {
+		if expected := fmt.Sprintf(`{"n":%d}`, i); record != expected {
+			t.Fatalf("第%d条输出 = %s, 期望 %s", i+1, record, expected)
+		}
+	}
+}
+
+func TestRunPipelineDeadLetter(t *testing.T) {
+	records := []string{
+		`{"level":"error","id":1}`,
+		`{"level":"info","id":2}`,
+		`not json`,
+		`{"level":"error","id":4}`,
+	}
+	filter, _ := CompileRecordFilter(`@.level == "error"`)
+	sink, deadLetter := &sliceSink{}, &sliceSink{}
+	stats, err := RunPipeline(context.Background(), &sliceSource{records: records}, sink, PipelineOptions{
+		Filter:     filter,
+		Workers:    2,
+		DeadLetter: deadLetter,
+	})
+	if err != nil {
+		t.Fatalf("RunPipeline 失败: %v", err)
+	}
+	expected := PipelineStats{Read: 4, Written: 2, Filtered: 1, Failed: 1}
+	if stats != expected {
+		t.Errorf("stats = %+v, 期望 %+v", stats, expected)
+	}
+	if len(sink.records) != 2 || sink.records[1] != `{"level":"error","id":4}` {
+		t.Errorf("输出 = %v", sink.records)
+	}
+	if len(deadLetter.records) != 1 {
+		t.Fatalf("死信输出 = %v, 期望1条", deadLetter.records)
+	}
+	entry := mustParse(t, deadLetter.records[0])
+	if GetString(GetObjectValueByKey(entry, "position")) != "第3条" ||
+		GetString(GetObjectValueByKey(entry, "record")) != "not json" ||
+		!strings.HasPrefix(GetString(GetObjectValueByKey(entry, "error")), "解析失败") {
+		t.Errorf("死信记录 = %s", deadLetter.records[0])
+	}
+}
+
+func TestRunPipelineErrors(t *testing.T) {
+	// 没有死信输出时第一条失败的记录使流水线失败，之前的记录已经写出
+	sink := &sliceSink{}
+	stats, err := RunPipeline(context.Background(), &sliceSource{records: []string{`1`, `[`, `3`}}, sink, PipelineOptions{Workers: 1})
+	var recordErr *RecordError
+	if !errors.As(err, &recordErr) || recordErr.Record.Position != "第2条" {
+		t.Fatalf("错误 = %v, 期望第2条的 *RecordError", err)
+	}
+	if stats.Written != 1 || len(sink.records) != 1 {
+		t.Errorf("失败前应该写出1条记录, stats = %+v", stats)
+	}
+
+	// 写入失败
+	sink = &sliceSink{err: errors.New("磁盘已满")}
+	if _, err := RunPipeline(context.Background(), &sliceSource{records: []string{`1`}}, sink, PipelineOptions{}); err == nil || !strings.Contains(err.Error(), "磁盘已满") {
+		t.Errorf("写入失败时的错误 = %v", err)
+	}
+
+	// 超过解析限制的记录
+	options := PipelineOptions{ParseOptions: DefaultParseOptions(), DeadLetter: &sliceSink{}}
+	options.ParseOptions.MaxTotalSize = 8
+	stats, err = RunPipeline(context.Background(), &sliceSource{records: []string{`"short"`, `"much longer string"`}}, &sliceSink{}, options)
+	if err != nil || stats.Failed != 1 || stats.Written != 1 {
+		t.Errorf("超过限制的记录应该写入死信输出, stats = %+v, err = %v", stats, err)
+	}
+
+	// ctx 取消
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := RunPipeline(ctx, &sliceSource{records: []string{`1`, `2`}}, &sliceSink{}, PipelineOptions{}); err != context.Canceled {
+		t.Errorf("ctx 取消时的错误 = %v, 期望 context.Canceled", err)
+	}
+}
+
+// errorSource 读取若干条记录后返回错误
+type errorSource struct {
+	sliceSource
+	err error
+}
+
+func (s *errorSource) Next() (Record, error) {
+	record, err := s.sliceSource.Next()
+	if err == io.EOF {
+		return Record{}, s.err
+	}
+	return record, err
+}
+
+func TestRunPipelineReadError(t *testing.T) {
+	src := &errorSource{sliceSource: sliceSource{records: []string{`1`, `2`}}, err: errors.New("连接断开")}
+	sink := &sliceSink{}
+	stats, err := RunPipeline(context.Background(), src, sink, PipelineOptions{Workers: 4})
+	if err == nil || !strings.Contains(err.Error(), "连接断开") {
+		t.Errorf("读取失败时的错误 = %v", err)
+	}
+	if stats.Written != 2 {
+		t.Errorf("读取失败前的记录应该全部写出, stats = %+v", stats)
+	}
+}
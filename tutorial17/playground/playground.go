@@ -0,0 +1,112 @@
+// Package playground 是浏览器 playground 使用的门面，输入和输出都是字符串
+//
+// 这些函数不依赖 syscall/js，可以在任何平台上测试；wasm 子目录把它们注册为
+// JavaScript 全局对象 leptjson 的方法。
+package playground
+
+import (
+	"strings"
+
+	leptjson "github.com/Cactusinhand/go-json-tutorial/tutorial17"
+)
+
+// Response 是每个操作的结果
+type Response struct {
+	OK      bool                       // 操作是否成功；Validate 中表示文档是否符合Schema
+	Error   string                     // 失败原因
+	Result  string                     // Parse、Format 输出的JSON文本
+	Issues  []leptjson.ValidationIssue // Validate 的验证错误
+	Results []string                   // Query 匹配的值，每个都是紧凑的JSON文本
+	Paths   []string                   // Query 匹配的值的规范化路径，与 Results 一一对应
+}
+
+// parse 按默认选项解析输入，name 用于错误信息
+func parse(text, name string) (*leptjson.Value, string) {
+	v := &leptjson.Value{}
+	if err := leptjson.Parse(v, text); err != leptjson.PARSE_OK {
+		return nil, name + ": " + err.Error()
+	}
+	return v, ""
+}
+
+// Parse 检查文本是否是有效的JSON，成功时返回紧凑格式
+func Parse(text string) Response {
+	v, errMsg := parse(text, "JSON")
+	if v == nil {
+		return Response{Error: errMsg}
+	}
+	return stringify(v, leptjson.StringifyOptions{})
+}
+
+// Format 以 indent 个空格缩进格式化JSON，indent 不大于 0 时输出紧凑格式；sortKeys 为 true 时按字典序输出对象键
+func Format(text string, indent int, sortKeys bool) Response {
+	v, errMsg := parse(text, "JSON")
+	if v == nil {
+		return Response{Error: errMsg}
+	}
+	var options leptjson.StringifyOptions
+	if indent > 0 {
+		options.Indent = strings.Repeat(" ", indent)
+	}
+	if sortKeys {
+		options.KeyComparator = leptjson.AlphabeticalKeyComparator
+	}
+	return stringify(v, options)
+}
+
+// stringify 按选项输出 v
+func stringify(v *leptjson.Value, options leptjson.StringifyOptions) Response {
+	text, err := leptjson.StringifyWithOptions(v, options)
+	if err != leptjson.STRINGIFY_OK {
+		return Response{Error: err.Error()}
+	}
+	return Response{OK: true, Result: text}
+}
+
+// Validate 用Schema验证文档，lang 是错误描述的语言，为空时使用默认语言
+//
+// 浏览器中没有文件系统，Schema中只能使用文档内部的 $ref。
+func Validate(schemaText, dataText, lang string) Response {
+	schema, errMsg := parse(schemaText, "Schema")
+	if schema == nil {
+		return Response{Error: errMsg}
+	}
+	data, errMsg := parse(dataText, "JSON")
+	if data == nil {
+		return Response{Error: errMsg}
+	}
+	if lang == "" {
+		lang = leptjson.DefaultValidationLanguage
+	}
+	translator, ok := leptjson.LookupValidationTranslator(lang)
+	if !ok {
+		return Response{Error: "未知的语言: " + lang}
+	}
+	result, err := leptjson.ValidateWithOptions(schema, data, leptjson.ValidationOptions{Translator: translator})
+	if err != nil {
+		return Response{Error: err.Error()}
+	}
+	return Response{OK: result.Valid, Issues: result.Issues}
+}
+
+// Query 用 JSONPath 查询文档
+func Query(text, path string) Response {
+	v, errMsg := parse(text, "JSON")
+	if v == nil {
+		return Response{Error: errMsg}
+	}
+	matches, err := leptjson.QueryWithPathsString(v, path)
+	if err != nil {
+		return Response{Error: "JSONPath: " + err.Error()}
+	}
+	response := Response{OK: true, Results: make([]string, 0, len(matches)), Paths: make([]string, 0, len(matches))}
+	for _, match := range matches {
+		text, serr := leptjson.Stringify(match.Value)
+		if serr != leptjson.STRINGIFY_OK {
+			return Response{Error: serr.Error()}
+		}
+		response.Results = append(response.Results, text)
+		response.Paths = append(response.Paths, match.Path)
+	}
+	return response
+}
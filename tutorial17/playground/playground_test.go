@@ -0,0 +1,68 @@
+package playground
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	if r := Parse(` [1, {"a" : null}] `); !r.OK || r.Result != `[1,{"a":null}]` {
+		t.Errorf("Parse = %+v", r)
+	}
+	if r := Parse(`[1,`); r.OK || r.Error == "" {
+		t.Errorf("Parse 无效输入 = %+v", r)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		indent   int
+		sortKeys bool
+		want     string
+	}{
+		{2, false, "{\n  \"b\": 1,\n  \"a\": 2\n}"},
+		{4, true, "{\n    \"a\": 2,\n    \"b\": 1\n}"},
+		{0, true, `{"a":2,"b":1}`},
+	}
+	for _, tt := range tests {
+		if r := Format(`{"b":1,"a":2}`, tt.indent, tt.sortKeys); !r.OK || r.Result != tt.want {
+			t.Errorf("Format(%d, %v) = %+v, 期望 %q", tt.indent, tt.sortKeys, r, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	schema := `{"type":"object","properties":{"age":{"type":"number","minimum":0}}}`
+	if r := Validate(schema, `{"age":3}`, ""); !r.OK || r.Error != "" {
+		t.Errorf("Validate 有效文档 = %+v", r)
+	}
+	r := Validate(schema, `{"age":-1}`, "en")
+	if r.OK || r.Error != "" || len(r.Issues) != 1 || r.Issues[0].Keyword != "minimum" {
+		t.Errorf("Validate 无效文档 = %+v", r)
+	}
+	if r := Validate(schema, `{}`, "xx"); r.Error == "" {
+		t.Error("未知的语言应返回错误")
+	}
+	if r := Validate(`{`, `{}`, ""); r.Error == "" {
+		t.Error("无效的Schema应返回错误")
+	}
+}
+
+func TestQuery(t *testing.T) {
+	r := Query(`{"a":[{"x":1},{"x":"s"}]}`, `$.a[*].x`)
+	if !r.OK {
+		t.Fatalf("Query = %+v", r)
+	}
+	if want := []string{`1`, `"s"`}; !reflect.DeepEqual(r.Results, want) {
+		t.Errorf("Results = %q, 期望 %q", r.Results, want)
+	}
+	if want := []string{`$['a'][0]['x']`, `$['a'][1]['x']`}; !reflect.DeepEqual(r.Paths, want) {
+		t.Errorf("Paths = %q, 期望 %q", r.Paths, want)
+	}
+	if r := Query(`{}`, `$[`); r.OK || r.Error == "" {
+		t.Errorf("无效的JSONPath = %+v", r)
+	}
+	if r := Query(`{}`, `$.missing`); !r.OK || len(r.Results) != 0 {
+		t.Errorf("没有匹配 = %+v", r)
+	}
+}
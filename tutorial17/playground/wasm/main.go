@@ -0,0 +1,87 @@
+//go:build js && wasm
+// +build js,wasm
+
+// Command wasm 把 playground 的函数注册为 JavaScript 全局对象 leptjson
+//
+// 构建（-s -w 去掉符号表和调试信息，-trimpath 去掉本地路径，显著减小体积）：
+//
+//	GOOS=js GOARCH=wasm go build -trimpath -ldflags="-s -w" -o leptjson.wasm ./tutorial17/playground/wasm
+//
+// 页面加载 Go 发行版中的 wasm_exec.js 并运行 leptjson.wasm 之后即可调用：
+//
+//	leptjson.parse(text)                    // {ok, error, result}
+//	leptjson.format(text, indent, sortKeys) // {ok, error, result}
+//	leptjson.validate(schema, data, lang)   // {ok, error, issues: [{path, keyword, message}]}
+//	leptjson.query(text, path)              // {ok, error, results: [...], paths: [...]}
+//
+// 所有参数都是字符串形式的JSON文本，结果是普通的 JavaScript 对象。
+package main
+
+import (
+	"syscall/js"
+
+	leptjson "github.com/Cactusinhand/go-json-tutorial/tutorial17"
+	"github.com/Cactusinhand/go-json-tutorial/tutorial17/playground"
+)
+
+func main() {
+	api := js.Global().Get("Object").New()
+	api.Set("version", leptjson.Version)
+	api.Set("parse", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		return toJS(playground.Parse(stringArg(args, 0)))
+	}))
+	api.Set("format", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		indent := 2
+		if len(args) > 1 && args[1].Type() == js.TypeNumber {
+			indent = args[1].Int()
+		}
+		sortKeys := len(args) > 2 && args[2].Truthy()
+		return toJS(playground.Format(stringArg(args, 0), indent, sortKeys))
+	}))
+	api.Set("validate", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		return toJS(playground.Validate(stringArg(args, 0), stringArg(args, 1), stringArg(args, 2)))
+	}))
+	api.Set("query", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		return toJS(playground.Query(stringArg(args, 0), stringArg(args, 1)))
+	}))
+	js.Global().Set("leptjson", api)
+
+	// 函数在 Go 程序运行期间才能调用，阻塞 main 使程序不退出
+	select {}
+}
+
+// stringArg 返回第 i 个参数，缺少或不是字符串时返回空字符串
+func stringArg(args []js.Value, i int) string {
+	if i >= len(args) || args[i].Type() != js.TypeString {
+		return ""
+	}
+	return args[i].String()
+}
+
+// toJS 把结果转换为 JavaScript 对象，只包含有内容的字段
+func toJS(r playground.Response) map[string]interface{} {
+	obj := map[string]interface{}{"ok": r.OK}
+	if r.Error != "" {
+		obj["error"] = r.Error
+	}
+	if r.Result != "" {
+		obj["result"] = r.Result
+	}
+	if r.Issues != nil {
+		issues := make([]interface{}, len(r.Issues))
+		for i, issue := range r.Issues {
+			issues[i] = map[string]interface{}{"path": issue.Path, "keyword": issue.Keyword, "message": issue.Message}
+		}
+		obj["issues"] = issues
+	}
+	if r.Results != nil {
+		results := make([]interface{}, len(r.Results))
+		paths := make([]interface{}, len(r.Paths))
+		for i := range r.Results {
+			results[i], paths[i] = r.Results[i], r.Paths[i]
+		}
+		obj["results"] = results
+		obj["paths"] = paths
+	}
+	return obj
+}
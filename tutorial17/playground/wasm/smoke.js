@@ -0,0 +1,49 @@
+// smoke.js - 在 Node.js 中加载 leptjson.wasm 并检查每个导出的函数
+//
+// 用法: node smoke.js WASM_EXEC_JS LEPTJSON_WASM
+// 通常通过仓库根目录的 make wasm-smoke 运行。
+"use strict";
+
+const assert = require("assert");
+const fs = require("fs");
+const path = require("path");
+
+const [wasmExec, wasmFile] = process.argv.slice(2);
+if (!wasmExec || !wasmFile) {
+  console.error("用法: node smoke.js WASM_EXEC_JS LEPTJSON_WASM");
+  process.exit(2);
+}
+require(path.resolve(wasmExec));
+
+async function main() {
+  const go = new Go();
+  const { instance } = await WebAssembly.instantiate(fs.readFileSync(wasmFile), go.importObject);
+  go.run(instance);
+  const lj = globalThis.leptjson;
+  assert.ok(lj, "leptjson 全局对象未注册");
+
+  assert.deepStrictEqual(lj.parse('{ "a" : [1, 2] }'), { ok: true, result: '{"a":[1,2]}' });
+  const bad = lj.parse("{");
+  assert.strictEqual(bad.ok, false);
+  assert.ok(bad.error.length > 0);
+
+  assert.strictEqual(lj.format('{"b":1,"a":2}', 2, true).result, '{\n  "a": 2,\n  "b": 1\n}');
+
+  const schema = '{"type":"object","required":["name"]}';
+  assert.strictEqual(lj.validate(schema, '{"name":"x"}').ok, true);
+  const invalid = lj.validate(schema, "{}", "en");
+  assert.strictEqual(invalid.ok, false);
+  assert.strictEqual(invalid.issues[0].keyword, "required");
+
+  const q = lj.query('{"items":[{"id":1},{"id":2}]}', "$.items[*].id");
+  assert.deepStrictEqual(q.results, ["1", "2"]);
+  assert.deepStrictEqual(q.paths, ["$['items'][0]['id']", "$['items'][1]['id']"]);
+
+  console.log(`leptjson ${lj.version} wasm smoke test: ok (${fs.statSync(wasmFile).size} bytes)`);
+  process.exit(0);
+}
+
+main().catch((err) => {
+  console.error(err);
+  process.exit(1);
+});
@@ -0,0 +1,176 @@
+// plugin.go - 以外部可执行文件实现的子命令插件，以及编写插件用的辅助函数
+package leptjson
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// PluginPrefix 是插件可执行文件名的前缀，PATH 中的 leptjson-foo 作为子命令 foo 运行
+const PluginPrefix = "leptjson-"
+
+// 命令行运行插件时传给它的环境变量
+const (
+	PluginEnvVerbose    = "LEPTJSON_VERBOSE"    // 使用了 -v 或 --verbose 时为 1
+	PluginEnvConfig     = "LEPTJSON_CONFIG"     // 生效的项目配置文件路径，没有配置文件时为空
+	PluginEnvExecutable = "LEPTJSON_EXECUTABLE" // leptjson 自身的路径，插件可以用它调用内置命令
+)
+
+// Plugin 是找到的一个插件
+type Plugin struct {
+	Name string // 子命令名
+	Path string // 可执行文件路径
+}
+
+// pluginName 从可执行文件名得到子命令名，不是插件时返回空字符串
+func pluginName(filename string) string {
+	if runtime.GOOS == "windows" {
+		filename = strings.TrimSuffix(filename, filepath.Ext(filename))
+	}
+	if !strings.HasPrefix(filename, PluginPrefix) {
+		return ""
+	}
+	return strings.TrimPrefix(filename, PluginPrefix)
+}
+
+// LookupPlugin 在 PATH 中查找子命令 name 的插件
+func LookupPlugin(name string) (Plugin, bool) {
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return Plugin{}, false
+	}
+	path, err := exec.LookPath(PluginPrefix + name)
+	if err != nil {
+		return Plugin{}, false
+	}
+	return Plugin{Name: name, Path: path}, true
+}
+
+// FindPlugins 返回 PATH 中的所有插件，按名字排序；同名插件取 PATH 中靠前的一个
+func FindPlugins() []Plugin {
+	return findPluginsIn(filepath.SplitList(os.Getenv("PATH")))
+}
+
+// findPluginsIn 在目录列表中查找插件
+func findPluginsIn(dirs []string) []Plugin {
+	seen := make(map[string]bool)
+	var plugins []Plugin
+	for _, dir := range dirs {
+		if dir == "" {
+			dir = "."
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := pluginName(entry.Name())
+			if name == "" || seen[name] || entry.IsDir() {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil || (runtime.GOOS != "windows" && info.Mode()&0111 == 0) {
+				continue
+			}
+			seen[name] = true
+			plugins = append(plugins, Plugin{Name: name, Path: filepath.Join(dir, entry.Name())})
+		}
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins
+}
+
+// PluginContext 为用Go编写的插件提供与内置命令一致的选项解析和输入输出
+//
+// 典型的插件：
+//
+//	func main() {
+//		ctx, err := leptjson.NewPluginContext(os.Args[1:])
+//		...
+//		v, err := ctx.ReadJSON(ctx.Args[0])
+//		...
+//		ctx.WriteJSON(v)
+//	}
+type PluginContext struct {
+	Args    []string          // 位置参数
+	Flags   map[string]string // 选项，--name=value 记为 value，--name 记为空字符串
+	Verbose bool
+	Config  ProjectConfig // 生效的项目配置
+	Stdin   io.Reader
+	Stdout  io.Writer
+}
+
+// NewPluginContext 解析插件的参数并加载项目配置
+//
+// 以 -- 开头的参数是选项，单独的 -- 之后都是位置参数。项目配置使用 leptjson 传入的
+// 配置文件，直接运行插件时从当前目录向上查找。
+func NewPluginContext(args []string) (*PluginContext, error) {
+	ctx := &PluginContext{
+		Flags:   make(map[string]string),
+		Verbose: os.Getenv(PluginEnvVerbose) == "1",
+		Stdin:   os.Stdin,
+		Stdout:  os.Stdout,
+	}
+	for i, arg := range args {
+		if arg == "--" {
+			ctx.Args = append(ctx.Args, args[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(arg, "--") {
+			ctx.Args = append(ctx.Args, arg)
+			continue
+		}
+		name, value := arg[2:], ""
+		if eq := strings.Index(name, "="); eq >= 0 {
+			name, value = name[:eq], name[eq+1:]
+		}
+		ctx.Flags[name] = value
+	}
+
+	dir := "."
+	if path := os.Getenv(PluginEnvConfig); path != "" {
+		dir = filepath.Dir(path)
+	}
+	config, err := LoadProjectConfig(dir)
+	if err != nil {
+		return nil, err
+	}
+	ctx.Config = config
+	return ctx, nil
+}
+
+// Flag 返回选项的值以及是否给出了该选项
+func (ctx *PluginContext) Flag(name string) (string, bool) {
+	value, ok := ctx.Flags[name]
+	return value, ok
+}
+
+// ReadJSON 按项目配置的安全限制读取并解析JSON文件，文件名为 - 时读取标准输入
+func (ctx *PluginContext) ReadJSON(filename string) (*Value, error) {
+	var data []byte
+	var err error
+	if filename == "-" {
+		data, err = io.ReadAll(ctx.Stdin)
+	} else {
+		data, err = os.ReadFile(filename)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("无法读取 %s: %w", filename, err)
+	}
+	v := &Value{}
+	if err := ParseWithOptions(v, string(data), ctx.Config.ParseOptions()); err != PARSE_OK {
+		return nil, fmt.Errorf("%s: 解析JSON失败: %s", filename, err)
+	}
+	return v, nil
+}
+
+// WriteJSON 按项目配置的格式把 v 写到标准输出
+func (ctx *PluginContext) WriteJSON(v *Value) error {
+	_, err := io.WriteString(ctx.Stdout, ctx.Config.Format(v))
+	return err
+}
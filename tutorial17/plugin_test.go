@@ -0,0 +1,84 @@
+package leptjson
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestFindPluginsIn(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("测试依赖可执行权限位")
+	}
+	first, second := t.TempDir(), t.TempDir()
+	write := func(dir, name string, mode os.FileMode) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(first, "leptjson-sort", 0755)
+	write(first, "leptjson-notes", 0644) // 不可执行
+	write(first, "other-tool", 0755)
+	write(second, "leptjson-sort", 0755) // 被 first 中的同名插件遮蔽
+	write(second, "leptjson-anonymize", 0755)
+	if err := os.Mkdir(filepath.Join(second, "leptjson-dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	got := findPluginsIn([]string{first, filepath.Join(first, "missing"), second})
+	want := []Plugin{
+		{Name: "anonymize", Path: filepath.Join(second, "leptjson-anonymize")},
+		{Name: "sort", Path: filepath.Join(first, "leptjson-sort")},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findPluginsIn = %+v, 期望 %+v", got, want)
+	}
+
+	if _, ok := LookupPlugin("../escape"); ok {
+		t.Error("LookupPlugin 不应接受包含路径分隔符的名字")
+	}
+}
+
+func TestPluginContext(t *testing.T) {
+	ctx, err := NewPluginContext([]string{"--mode=fast", "in.json", "--dry-run", "--", "--literal"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"in.json", "--literal"}; !reflect.DeepEqual(ctx.Args, want) {
+		t.Errorf("Args = %q, 期望 %q", ctx.Args, want)
+	}
+	if mode, ok := ctx.Flag("mode"); !ok || mode != "fast" {
+		t.Errorf("Flag(mode) = %q, %v", mode, ok)
+	}
+	if value, ok := ctx.Flag("dry-run"); !ok || value != "" {
+		t.Errorf("Flag(dry-run) = %q, %v", value, ok)
+	}
+	if _, ok := ctx.Flag("missing"); ok {
+		t.Error("Flag(missing) 应返回 false")
+	}
+
+	var out bytes.Buffer
+	ctx.Config = DefaultProjectConfig()
+	ctx.Stdin = strings.NewReader(`{"a":[1,2]}`)
+	ctx.Stdout = &out
+	v, err := ctx.ReadJSON("-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.WriteJSON(v); err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  \"a\": [\n    1,\n    2\n  ]\n}\n"; out.String() != want {
+		t.Errorf("WriteJSON = %q, 期望 %q", out.String(), want)
+	}
+
+	ctx.Config.Security.MaxDepth = 1
+	ctx.Stdin = strings.NewReader(`[[1]]`)
+	if _, err := ctx.ReadJSON("-"); err == nil {
+		t.Error("超过项目配置的深度限制时 ReadJSON 应返回错误")
+	}
+}
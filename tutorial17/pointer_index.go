@@ -0,0 +1,90 @@
+// pointer_index.go - JSON指针索引
+package leptjson
+
+import (
+	"sort"
+	"strconv"
+)
+
+// PointerIndex 是一次性建立的 JSON Pointer 到节点的映射
+//
+// 对同一个大文档进行大量指针读取时，先建立索引可以把每次查找从
+// O(路径长度 × 对象成员数) 降低为一次哈希查找。
+// 索引记录的是建立时的文档结构，文档被修改后需要调用 Rebuild 重新建立。
+type PointerIndex struct {
+	root  *Value
+	nodes map[string]*Value // 规范化的指针 => 节点
+}
+
+// NewPointerIndex 遍历文档并为每个节点建立索引
+func NewPointerIndex(root *Value) *PointerIndex {
+	idx := &PointerIndex{root: root}
+	idx.Rebuild()
+	return idx
+}
+
+// Rebuild 重新遍历文档建立索引
+func (idx *PointerIndex) Rebuild() {
+	idx.nodes = make(map[string]*Value)
+	if idx.root != nil {
+		idx.indexNode(idx.root, "")
+	}
+}
+
+// indexNode 递归地为节点及其子节点建立索引
+func (idx *PointerIndex) indexNode(v *Value, pointer string) {
+	idx.nodes[pointer] = v
+	switch v.Type {
+	case ARRAY:
+		for i, elem := range v.A {
+			idx.indexNode(elem, pointer+"/"+strconv.Itoa(i))
+		}
+	case OBJECT:
+		for _, member := range v.O {
+			childPointer := pointer + "/" + escapeJSONPointerToken(member.K)
+			// 对象中有重复键时，与 FindObjectKey 一样以第一个为准
+			if _, exists := idx.nodes[childPointer]; exists {
+				continue
+			}
+			idx.indexNode(member.V, childPointer)
+		}
+	}
+}
+
+// Len 返回索引中的节点数量（包括根节点）
+func (idx *PointerIndex) Len() int {
+	return len(idx.nodes)
+}
+
+// Get 根据JSON指针查找节点
+func (idx *PointerIndex) Get(pointer string) (*Value, bool) {
+	if v, ok := idx.nodes[pointer]; ok {
+		return v, true
+	}
+	// 指针的转义写法可能与索引中的不同，规范化后再查找一次
+	normalized := normalizePointer(pointer)
+	if normalized == pointer {
+		return nil, false
+	}
+	v, ok := idx.nodes[normalized]
+	return v, ok
+}
+
+// GetAll 批量查找节点，结果与 pointers 一一对应，不存在的指针对应nil
+func (idx *PointerIndex) GetAll(pointers []string) []*Value {
+	results := make([]*Value, len(pointers))
+	for i, pointer := range pointers {
+		results[i], _ = idx.Get(pointer)
+	}
+	return results
+}
+
+// Pointers 返回索引中的所有指针，按字典序排列
+func (idx *PointerIndex) Pointers() []string {
+	pointers := make([]string, 0, len(idx.nodes))
+	for pointer := range idx.nodes {
+		pointers = append(pointers, pointer)
+	}
+	sort.Strings(pointers)
+	return pointers
+}
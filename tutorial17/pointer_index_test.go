@@ -0,0 +1,105 @@
+package leptjson
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestPointerIndex(t *testing.T) {
+	doc := &Value{}
+	if err := Parse(doc, `{"a":{"b/c":[1,{"~d":true}]},"e":null,"a2":[]}`); err != PARSE_OK {
+		t.Fatalf("解析失败: %s", err.Error())
+	}
+	idx := NewPointerIndex(doc)
+
+	if idx.Len() != 8 {
+		t.Errorf("节点数量 = %d, 期望 8: %v", idx.Len(), idx.Pointers())
+	}
+
+	tests := []struct {
+		pointer string
+		found   bool
+	}{
+		{"", true},
+		{"/a", true},
+		{"/a/b~1c", true},
+		{"/a/b~1c/1/~0d", true},
+		{"/e", true},
+		{"/a2", true},
+		{"/a/b~1c/2", false},
+		{"/missing", false},
+		{"a", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pointer, func(t *testing.T) {
+			got, ok := idx.Get(tt.pointer)
+			if ok != tt.found {
+				t.Fatalf("Get(%q) found = %v, 期望 %v", tt.pointer, ok, tt.found)
+			}
+			if !ok {
+				return
+			}
+			// 与 JSONPointer.Get 返回同一个节点
+			p, _ := ParseJSONPointer(tt.pointer)
+			want, _ := p.Get(doc)
+			if got != want {
+				t.Errorf("Get(%q) 返回的节点与 JSONPointer.Get 不同", tt.pointer)
+			}
+		})
+	}
+
+	results := idx.GetAll([]string{"/e", "/missing", "/a/b~1c/0"})
+	if results[0] == nil || results[1] != nil || results[2] == nil || results[2].N != 1 {
+		t.Errorf("GetAll 结果错误: %v", results)
+	}
+
+	// 修改文档后重建索引
+	SetString(SetObjectValue(doc, "f"), "new")
+	if _, ok := idx.Get("/f"); ok {
+		t.Error("重建之前不应找到新节点")
+	}
+	idx.Rebuild()
+	if v, ok := idx.Get("/f"); !ok || v.S != "new" {
+		t.Error("重建之后应找到新节点")
+	}
+}
+
+// 构造一个较大的文档用于基准测试
+func buildPointerBenchDoc() (*Value, []string) {
+	var sb strings.Builder
+	var pointers []string
+	sb.WriteString(`{"items":[`)
+	for i := 0; i < 1000; i++ {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		fmt.Fprintf(&sb, `{"id":%d,"name":"item%d","tags":["a","b"]}`, i, i)
+		pointers = append(pointers, fmt.Sprintf("/items/%d/tags/1", i))
+	}
+	sb.WriteString(`]}`)
+
+	doc := &Value{}
+	Parse(doc, sb.String())
+	return doc, pointers
+}
+
+func BenchmarkPointerLookup(b *testing.B) {
+	doc, pointers := buildPointerBenchDoc()
+
+	b.Run("JSONPointer", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			p, _ := ParseJSONPointer(pointers[i%len(pointers)])
+			p.Get(doc)
+		}
+	})
+
+	b.Run("PointerIndex", func(b *testing.B) {
+		idx := NewPointerIndex(doc)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			idx.Get(pointers[i%len(pointers)])
+		}
+	})
+}
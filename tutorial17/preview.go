@@ -0,0 +1,78 @@
+// preview.go - 截断大文档，用于日志和预览
+package leptjson
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// PreviewMoreKey 是对象被截断时，表示剩余成员数量的成员的键
+const PreviewMoreKey = "..."
+
+// Preview 返回 v 的截断副本，适合把很大的文档安全地写入日志
+//
+// 每项限制不大于 0 时不限制：
+//   - maxDepth: 最多展开的层数，更深的数组和对象替换为 "...(array of 12 items)" 这样的字符串
+//   - maxElems: 数组和对象最多保留的元素数，数组末尾追加 "...(1523 more items)"，
+//     对象追加键为 PreviewMoreKey、值为 "...(8 more members)" 的成员
+//   - maxStringLen: 字符串最多保留的字符数，超出部分替换为 "...(4096 more chars)"
+//
+// 截断标记都是字符串，结果仍是有效的JSON，但类型不再与原文档一致。v 不会被修改。
+func Preview(v *Value, maxDepth, maxElems, maxStringLen int) *Value {
+	if v == nil {
+		return nil
+	}
+	return previewValueAt(v, 1, maxDepth, maxElems, maxStringLen)
+}
+
+// previewValueAt 返回位于第 depth 层的 v 的截断副本
+func previewValueAt(v *Value, depth, maxDepth, maxElems, maxStringLen int) *Value {
+	result := &Value{}
+	switch v.Type {
+	case STRING:
+		s := GetString(v)
+		if maxStringLen > 0 && utf8.RuneCountInString(s) > maxStringLen {
+			runes := []rune(s)
+			s = fmt.Sprintf("%s...(%d more chars)", string(runes[:maxStringLen]), len(runes)-maxStringLen)
+		}
+		SetString(result, s)
+	case ARRAY:
+		if maxDepth > 0 && depth > maxDepth {
+			SetString(result, fmt.Sprintf("...(array of %d items)", len(v.A)))
+			break
+		}
+		kept := len(v.A)
+		if maxElems > 0 && kept > maxElems {
+			kept = maxElems
+		}
+		SetArray(result, kept+1)
+		for _, e := range v.A[:kept] {
+			result.A = append(result.A, previewValueAt(e, depth+1, maxDepth, maxElems, maxStringLen))
+		}
+		if kept < len(v.A) {
+			result.A = append(result.A, &Value{Type: STRING, S: fmt.Sprintf("...(%d more items)", len(v.A)-kept)})
+		}
+	case OBJECT:
+		if maxDepth > 0 && depth > maxDepth {
+			SetString(result, fmt.Sprintf("...(object of %d members)", len(v.O)))
+			break
+		}
+		kept := len(v.O)
+		if maxElems > 0 && kept > maxElems {
+			kept = maxElems
+		}
+		SetObject(result)
+		for _, m := range v.O[:kept] {
+			result.O = append(result.O, Member{K: m.K, V: previewValueAt(m.V, depth+1, maxDepth, maxElems, maxStringLen)})
+		}
+		if kept < len(v.O) {
+			result.O = append(result.O, Member{
+				K: PreviewMoreKey,
+				V: &Value{Type: STRING, S: fmt.Sprintf("...(%d more members)", len(v.O)-kept)},
+			})
+		}
+	default:
+		Copy(result, v)
+	}
+	return result
+}
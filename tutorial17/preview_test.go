@@ -0,0 +1,56 @@
+package leptjson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPreview(t *testing.T) {
+	tests := []struct {
+		input                        string
+		maxDepth, maxElems, maxChars int
+		expected                     string
+	}{
+		{`[1,2,3,4,5]`, 0, 2, 0, `[1,2,"...(3 more items)"]`},
+		{`[1,2]`, 0, 2, 0, `[1,2]`},
+		{`{"a":1,"b":2,"c":3}`, 0, 1, 0, `{"a":1,"...":"...(2 more members)"}`},
+		{`{"a":{"b":[1,2],"c":{}}}`, 2, 0, 0, `{"a":{"b":"...(array of 2 items)","c":"...(object of 0 members)"}}`},
+		{`{"a":{"b":[1,2]}}`, 3, 0, 0, `{"a":{"b":[1,2]}}`},
+		{`["abcdef","abc"]`, 0, 0, 3, `["abc...(3 more chars)","abc"]`},
+		{`"你好世界"`, 0, 0, 2, `"你好...(2 more chars)"`},
+		{`[true,null,1.5]`, 1, 1, 1, `[true,"...(2 more items)"]`},
+		{`[[1,2,3],[4]]`, 0, 0, 0, `[[1,2,3],[4]]`},
+	}
+	for _, tt := range tests {
+		v := mustParse(t, tt.input)
+		result := Preview(v, tt.maxDepth, tt.maxElems, tt.maxChars)
+		if s, _ := Stringify(result); s != tt.expected {
+			t.Errorf("Preview(%s, %d, %d, %d) = %s, 期望 %s", tt.input, tt.maxDepth, tt.maxElems, tt.maxChars, s, tt.expected)
+		}
+		if s, _ := Stringify(v); s != tt.input {
+			t.Errorf("Preview 不应该修改原值: %s", s)
+		}
+	}
+	if Preview(nil, 1, 1, 1) != nil {
+		t.Errorf("Preview(nil) 应该返回 nil")
+	}
+}
+
+func TestPreviewLargeArray(t *testing.T) {
+	v := mustParse(t, "["+strings.Repeat("0,", 1524)+"0]")
+	result := Preview(v, 0, 2, 0)
+	if len(result.A) != 3 || GetString(result.A[2]) != "...(1523 more items)" {
+		t.Errorf("Preview 结果 = %v", result.A)
+	}
+}
+
+func TestParsePreviewLimits(t *testing.T) {
+	if limits, err := parsePreviewLimits("3, 0,80"); err != nil || limits[0] != 3 || limits[1] != 0 || limits[2] != 80 {
+		t.Errorf("parsePreviewLimits = %v, %v", limits, err)
+	}
+	for _, spec := range []string{"", "1,2", "1,2,x", "1,-2,3"} {
+		if _, err := parsePreviewLimits(spec); err == nil {
+			t.Errorf("parsePreviewLimits(%q) 应该返回错误", spec)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// progress.go - 命令行工具读取大文件时的进度显示
+package leptjson
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	progressMinSize  = 32 << 20               // 小于该大小的文件不显示进度
+	progressInterval = 100 * time.Millisecond // 进度条的最短刷新间隔
+	progressBarWidth = 30
+)
+
+// progressReader 统计已读取的字节数，并定期在 w 上重绘进度条和预计剩余时间
+type progressReader struct {
+	r     io.Reader
+	w     io.Writer
+	total int64
+	read  int64
+	start time.Time
+	last  time.Time
+	now   func() time.Time
+}
+
+// newProgressReader 创建进度读取器，total 为预期读取的总字节数
+func newProgressReader(r io.Reader, w io.Writer, total int64) *progressReader {
+	return &progressReader{r: r, w: w, total: total, start: time.Now(), now: time.Now}
+}
+
+// Read 实现 io.Reader
+func (p *progressReader) Read(buf []byte) (int, error) {
+	n, err := p.r.Read(buf)
+	p.read += int64(n)
+	if now := p.now(); now.Sub(p.last) >= progressInterval {
+		p.last = now
+		p.render(now)
+	}
+	return n, err
+}
+
+// Finish 绘制最终状态并换行
+func (p *progressReader) Finish() {
+	p.render(p.now())
+	fmt.Fprintln(p.w)
+}
+
+// render 绘制一行进度，使用回车覆盖上一次的输出
+func (p *progressReader) render(now time.Time) {
+	ratio := 0.0
+	if p.total > 0 {
+		ratio = float64(p.read) / float64(p.total)
+		if ratio > 1 {
+			ratio = 1
+		}
+	}
+	filled := int(ratio * progressBarWidth)
+	bar := strings.Repeat("=", filled) + strings.Repeat(" ", progressBarWidth-filled)
+
+	eta := "--:--"
+	if elapsed := now.Sub(p.start); p.read > 0 && elapsed > 0 {
+		remaining := time.Duration(float64(elapsed) * float64(p.total-p.read) / float64(p.read))
+		eta = formatETA(remaining)
+	}
+
+	fmt.Fprintf(p.w, "\r读取中 [%s] %5.1f%% %s/%s 剩余 %s",
+		bar, ratio*100, formatBytes(p.read), formatBytes(p.total), eta)
+}
+
+// formatETA 将剩余时间格式化为 mm:ss 或 hh:mm:ss
+func formatETA(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	secs := int64(d.Round(time.Second) / time.Second)
+	if secs >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", secs/3600, secs/60%60, secs%60)
+	}
+	return fmt.Sprintf("%02d:%02d", secs/60, secs%60)
+}
+
+// formatBytes 将字节数格式化为易读的形式
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// shouldShowProgress 判断是否显示进度：文件足够大且标准错误输出是终端
+func shouldShowProgress(size int64) bool {
+	if size < progressMinSize {
+		return false
+	}
+	return isTerminal(os.Stderr)
+}
+
+// isTerminal 判断文件是否为终端
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
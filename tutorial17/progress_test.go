@@ -0,0 +1,76 @@
+package leptjson
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgressReader(t *testing.T) {
+	data := strings.Repeat("x", 1000)
+	var out bytes.Buffer
+	p := newProgressReader(strings.NewReader(data), &out, int64(len(data)))
+
+	// 使用可控的时钟：每次读取经过1秒
+	clock := p.start
+	p.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+
+	buf := make([]byte, 250)
+	if _, err := p.Read(buf); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); !strings.Contains(got, " 25.0%") || !strings.Contains(got, "250B/1000B") || !strings.Contains(got, "剩余 00:03") {
+		t.Errorf("进度输出错误: %q", got)
+	}
+
+	rest, err := io.ReadAll(p)
+	if err != nil || len(rest) != 750 {
+		t.Fatalf("读取剩余内容失败: %d, %v", len(rest), err)
+	}
+	p.Finish()
+	lines := strings.Split(out.String(), "\r")
+	last := lines[len(lines)-1]
+	if !strings.Contains(last, "100.0%") || !strings.Contains(last, "剩余 00:00") || !strings.HasSuffix(last, "\n") {
+		t.Errorf("最终进度输出错误: %q", last)
+	}
+}
+
+func TestFormatETA(t *testing.T) {
+	tests := map[time.Duration]string{
+		-time.Second:                    "00:00",
+		1500 * time.Millisecond:         "00:02",
+		75 * time.Second:                "01:15",
+		2*time.Hour + 3*time.Minute + 4: "2:03:00",
+	}
+	for d, expected := range tests {
+		if got := formatETA(d); got != expected {
+			t.Errorf("formatETA(%v) = %s, 期望 %s", d, got, expected)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{
+		0:         "0B",
+		1023:      "1023B",
+		1536:      "1.5KB",
+		200 << 20: "200.0MB",
+		3 << 30:   "3.0GB",
+	}
+	for n, expected := range tests {
+		if got := formatBytes(n); got != expected {
+			t.Errorf("formatBytes(%d) = %s, 期望 %s", n, got, expected)
+		}
+	}
+}
+
+func TestShouldShowProgress(t *testing.T) {
+	if shouldShowProgress(progressMinSize - 1) {
+		t.Error("小文件不应当显示进度")
+	}
+}
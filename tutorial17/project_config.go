@@ -0,0 +1,307 @@
+// project_config.go - 项目配置文件 .leptjsonrc，团队共享命令行的默认设置
+package leptjson
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 项目配置文件的文件名，同一目录中都存在时使用 ProjectConfigName
+const (
+	ProjectConfigName    = ".leptjsonrc"
+	ProjectConfigAltName = "leptjson.config.json"
+)
+
+// ProjectConfig 是项目配置，文件内容是一个JSON对象，例如
+//
+//	{
+//	  "indent": 4,
+//	  "printWidth": 80,
+//	  "sortKeys": "alpha",
+//	  "finalNewline": true,
+//	  "color": "never",
+//	  "security": {"maxDepth": 100, "maxTotalSize": 10485760},
+//	  "schemas": {"config/**/*.json": "schemas/config.schema.json"}
+//	}
+//
+// 没有出现的设置使用 DefaultProjectConfig 中的默认值。
+type ProjectConfig struct {
+	Path         string          `json:"path,omitempty"`     // 配置文件的路径，没有配置文件时为空
+	Indent       int             `json:"indent"`             // 缩进的空格数
+	PrintWidth   int             `json:"printWidth"`         // 最大行宽，能放下的数组和对象写在一行；为0时总是展开为多行
+	SortKeys     string          `json:"sortKeys,omitempty"` // 对象键的排序规则名称（见 RegisterKeyComparator），为空时保持原有顺序
+	KeyOrder     []string        `json:"keyOrder,omitempty"` // 排在最前面的键，其余的键按字典序排列；设置后忽略 SortKeys
+	FinalNewline bool            `json:"finalNewline"`       // 文件是否以换行符结尾
+	Color        string          `json:"color"`              // 是否使用颜色: auto, always, never
+	Security     SecurityLimits  `json:"security"`           // 解析输入文件时的安全限制
+	Schemas      []SchemaMapping `json:"schemas,omitempty"`  // 文件到Schema的映射，按配置中的顺序匹配
+}
+
+// SecurityLimits 是解析时的安全限制，0 表示使用 DefaultParseOptions 中的值
+type SecurityLimits struct {
+	MaxDepth        int `json:"maxDepth"`
+	MaxStringLength int `json:"maxStringLength"`
+	MaxArraySize    int `json:"maxArraySize"`
+	MaxObjectSize   int `json:"maxObjectSize"`
+	MaxTotalSize    int `json:"maxTotalSize"`
+}
+
+// SchemaMapping 指定与模式匹配的文件使用的Schema
+type SchemaMapping struct {
+	Pattern string `json:"pattern"` // 相对于配置文件所在目录、以 / 分隔的模式，** 匹配任意层目录
+	Schema  string `json:"schema"`  // Schema文件的路径，读取配置时相对路径已转换为以配置文件所在目录为基准
+}
+
+// DefaultProjectConfig 返回没有配置文件时使用的设置
+func DefaultProjectConfig() ProjectConfig {
+	defaults := DefaultParseOptions()
+	return ProjectConfig{
+		Indent:       2,
+		FinalNewline: true,
+		Color:        "auto",
+		Security: SecurityLimits{
+			MaxDepth:        defaults.MaxDepth,
+			MaxStringLength: defaults.MaxStringLength,
+			MaxArraySize:    defaults.MaxArraySize,
+			MaxObjectSize:   defaults.MaxObjectSize,
+			MaxTotalSize:    defaults.MaxTotalSize,
+		},
+	}
+}
+
+// FindProjectConfig 从 dir 开始逐级向上查找配置文件，返回它的路径，找不到时返回空字符串
+func FindProjectConfig(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		for _, name := range []string{ProjectConfigName, ProjectConfigAltName} {
+			candidate := filepath.Join(dir, name)
+			if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+				return candidate, nil
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// LoadProjectConfig 查找并读取 dir 适用的配置文件，没有配置文件时返回默认设置
+func LoadProjectConfig(dir string) (ProjectConfig, error) {
+	path, err := FindProjectConfig(dir)
+	if err != nil || path == "" {
+		return DefaultProjectConfig(), err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ProjectConfig{}, err
+	}
+	v := &Value{}
+	if err := Parse(v, string(data)); err != PARSE_OK {
+		return ProjectConfig{}, fmt.Errorf("%s: 解析JSON失败: %s", path, err)
+	}
+	config, err := ParseProjectConfig(v)
+	if err != nil {
+		return ProjectConfig{}, fmt.Errorf("%s: %w", path, err)
+	}
+	config.Path = path
+	for i, mapping := range config.Schemas {
+		if !filepath.IsAbs(mapping.Schema) {
+			config.Schemas[i].Schema = filepath.Join(filepath.Dir(path), filepath.FromSlash(mapping.Schema))
+		}
+	}
+	return config, nil
+}
+
+// ParseProjectConfig 从JSON对象读取配置，未知的设置返回错误以便发现拼写错误
+func ParseProjectConfig(v *Value) (ProjectConfig, error) {
+	config := DefaultProjectConfig()
+	if v.Type != OBJECT {
+		return config, fmt.Errorf("配置必须是JSON对象")
+	}
+	for _, m := range v.O {
+		switch m.K {
+		case "indent":
+			n, err := nonNegativeInt(m.V)
+			if err != nil {
+				return config, fmt.Errorf("indent %w", err)
+			}
+			config.Indent = n
+		case "printWidth":
+			n, err := nonNegativeInt(m.V)
+			if err != nil {
+				return config, fmt.Errorf("printWidth %w", err)
+			}
+			config.PrintWidth = n
+		case "sortKeys":
+			if m.V.Type != STRING {
+				return config, fmt.Errorf("sortKeys 必须是字符串")
+			}
+			if _, ok := LookupKeyComparator(GetString(m.V)); !ok {
+				return config, fmt.Errorf("未注册的键排序规则: %s", GetString(m.V))
+			}
+			config.SortKeys = GetString(m.V)
+		case "keyOrder":
+			keys, err := stringArray(m.V)
+			if err != nil {
+				return config, fmt.Errorf("keyOrder %w", err)
+			}
+			config.KeyOrder = keys
+		case "finalNewline":
+			if m.V.Type != TRUE && m.V.Type != FALSE {
+				return config, fmt.Errorf("finalNewline 必须是布尔值")
+			}
+			config.FinalNewline = m.V.Type == TRUE
+		case "color":
+			color := GetString(m.V)
+			if m.V.Type != STRING || (color != "auto" && color != "always" && color != "never") {
+				return config, fmt.Errorf("color 必须是 auto、always 或 never")
+			}
+			config.Color = color
+		case "security":
+			if err := parseSecurityLimits(m.V, &config.Security); err != nil {
+				return config, err
+			}
+		case "schemas":
+			if m.V.Type != OBJECT {
+				return config, fmt.Errorf("schemas 必须是从文件模式到Schema路径的对象")
+			}
+			for _, s := range m.V.O {
+				if s.V.Type != STRING {
+					return config, fmt.Errorf("schemas 中 %s 的值必须是字符串", s.K)
+				}
+				if err := checkTreePattern(s.K); err != nil {
+					return config, err
+				}
+				config.Schemas = append(config.Schemas, SchemaMapping{Pattern: s.K, Schema: GetString(s.V)})
+			}
+		default:
+			return config, fmt.Errorf("未知的设置: %s", m.K)
+		}
+	}
+	return config, nil
+}
+
+// parseSecurityLimits 读取 security 设置，只覆盖出现的限制
+func parseSecurityLimits(v *Value, limits *SecurityLimits) error {
+	if v.Type != OBJECT {
+		return fmt.Errorf("security 必须是JSON对象")
+	}
+	for _, m := range v.O {
+		var field *int
+		switch m.K {
+		case "maxDepth":
+			field = &limits.MaxDepth
+		case "maxStringLength":
+			field = &limits.MaxStringLength
+		case "maxArraySize":
+			field = &limits.MaxArraySize
+		case "maxObjectSize":
+			field = &limits.MaxObjectSize
+		case "maxTotalSize":
+			field = &limits.MaxTotalSize
+		default:
+			return fmt.Errorf("未知的安全限制: %s", m.K)
+		}
+		n, err := nonNegativeInt(m.V)
+		if err != nil {
+			return fmt.Errorf("security.%s %w", m.K, err)
+		}
+		*field = n
+	}
+	return nil
+}
+
+// nonNegativeInt 读取非负整数
+func nonNegativeInt(v *Value) (int, error) {
+	if v.Type != NUMBER || v.N < 0 || v.N != float64(int(v.N)) {
+		return 0, fmt.Errorf("必须是非负整数")
+	}
+	return int(v.N), nil
+}
+
+// stringArray 读取字符串数组
+func stringArray(v *Value) ([]string, error) {
+	if v.Type != ARRAY {
+		return nil, fmt.Errorf("必须是字符串数组")
+	}
+	result := make([]string, 0, len(v.A))
+	for _, elem := range v.A {
+		if elem.Type != STRING {
+			return nil, fmt.Errorf("必须是字符串数组")
+		}
+		result = append(result, GetString(elem))
+	}
+	return result, nil
+}
+
+// KeyComparator 返回配置的键排序规则，不排序时返回 nil
+func (c ProjectConfig) KeyComparator() KeyComparator {
+	if len(c.KeyOrder) > 0 {
+		return NewKeyOrderComparator(c.KeyOrder...)
+	}
+	if c.SortKeys != "" {
+		cmp, _ := LookupKeyComparator(c.SortKeys)
+		return cmp
+	}
+	return nil
+}
+
+// Format 按配置格式化 v，返回规范格式的文件内容
+func (c ProjectConfig) Format(v *Value) string {
+	text, _ := formatJSONWithWidth(v, strings.Repeat(" ", c.Indent), c.KeyComparator(), c.PrintWidth)
+	if c.FinalNewline {
+		text += "\n"
+	}
+	return text
+}
+
+// ParseOptions 返回按安全限制调整后的默认解析选项，为 0 的限制保持默认值
+func (c ProjectConfig) ParseOptions() ParseOptions {
+	options := DefaultParseOptions()
+	for _, limit := range []struct {
+		value int
+		field *int
+	}{
+		{c.Security.MaxDepth, &options.MaxDepth},
+		{c.Security.MaxStringLength, &options.MaxStringLength},
+		{c.Security.MaxArraySize, &options.MaxArraySize},
+		{c.Security.MaxObjectSize, &options.MaxObjectSize},
+		{c.Security.MaxTotalSize, &options.MaxTotalSize},
+	} {
+		if limit.value > 0 {
+			*limit.field = limit.value
+		}
+	}
+	return options
+}
+
+// SchemaFor 返回 file 适用的Schema路径，没有匹配的映射时返回 false
+//
+// 模式匹配的是 file 相对于配置文件所在目录的路径，不在该目录下的文件不匹配任何映射。
+func (c ProjectConfig) SchemaFor(file string) (string, bool) {
+	if c.Path == "" || len(c.Schemas) == 0 {
+		return "", false
+	}
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(filepath.Dir(c.Path), abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, mapping := range c.Schemas {
+		if matchTreePattern(mapping.Pattern, rel) {
+			return mapping.Schema, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,137 @@
+package leptjson
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadProjectConfig(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// 写入之前找不到 root 下的配置文件（临时目录的上层可能有其他配置文件）
+	if path, err := FindProjectConfig(nested); err != nil || filepath.Dir(path) == root {
+		t.Fatalf("FindProjectConfig = %q, %v", path, err)
+	}
+
+	rc := filepath.Join(root, ProjectConfigName)
+	if err := os.WriteFile(rc, []byte(`{"indent":4,"keyOrder":["name","version"],"finalNewline":false}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := LoadProjectConfig(nested)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := DefaultProjectConfig()
+	want.Path, want.Indent, want.KeyOrder, want.FinalNewline = rc, 4, []string{"name", "version"}, false
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("LoadProjectConfig = %+v, 期望 %+v", config, want)
+	}
+
+	v := &Value{}
+	Parse(v, `{"z":1,"version":"1","name":"x"}`)
+	if got := config.Format(v); got != "{\n    \"name\": \"x\",\n    \"version\": \"1\",\n    \"z\": 1\n}" {
+		t.Errorf("Format = %q", got)
+	}
+}
+
+func TestParseProjectConfigErrors(t *testing.T) {
+	for _, json := range []string{
+		`[]`,
+		`{"indent":-1}`,
+		`{"indent":1.5}`,
+		`{"printWidth":-80}`,
+		`{"sortKeys":"no-such-order"}`,
+		`{"keyOrder":[1]}`,
+		`{"finalNewline":"yes"}`,
+		`{"indnet":2}`,
+		`{"color":"sometimes"}`,
+		`{"security":{"maxDepth":-1}}`,
+		`{"security":{"maxDeph":10}}`,
+		`{"schemas":{"*.json":1}}`,
+		`{"schemas":{"[":"a.json"}}`,
+	} {
+		v := &Value{}
+		Parse(v, json)
+		if _, err := ParseProjectConfig(v); err == nil {
+			t.Errorf("ParseProjectConfig(%s) 应返回错误", json)
+		}
+	}
+}
+
+func TestProjectConfigAltName(t *testing.T) {
+	root := t.TempDir()
+	alt := filepath.Join(root, ProjectConfigAltName)
+	if err := os.WriteFile(alt, []byte(`{"indent":3}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if path, err := FindProjectConfig(root); err != nil || path != alt {
+		t.Fatalf("FindProjectConfig = %q, %v, 期望 %q", path, err, alt)
+	}
+
+	// 同一目录中两个文件都存在时使用 .leptjsonrc
+	rc := filepath.Join(root, ProjectConfigName)
+	if err := os.WriteFile(rc, []byte(`{}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if path, err := FindProjectConfig(root); err != nil || path != rc {
+		t.Fatalf("FindProjectConfig = %q, %v, 期望 %q", path, err, rc)
+	}
+}
+
+func TestProjectConfigParseOptions(t *testing.T) {
+	if got := DefaultProjectConfig().ParseOptions(); !reflect.DeepEqual(got, DefaultParseOptions()) {
+		t.Errorf("默认配置的 ParseOptions = %+v, 期望 DefaultParseOptions()", got)
+	}
+
+	v := &Value{}
+	Parse(v, `{"security":{"maxDepth":3,"maxTotalSize":0}}`)
+	config, err := ParseProjectConfig(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := config.ParseOptions()
+	if options.MaxDepth != 3 || options.MaxTotalSize != DefaultParseOptions().MaxTotalSize {
+		t.Errorf("ParseOptions = %+v", options)
+	}
+	if err := ParseWithOptions(&Value{}, `[[[[1]]]]`, options); err == PARSE_OK {
+		t.Error("超过 maxDepth 的输入应解析失败")
+	}
+}
+
+func TestProjectConfigSchemaFor(t *testing.T) {
+	root := t.TempDir()
+	rc := filepath.Join(root, ProjectConfigName)
+	config := `{"schemas":{"config/**/*.json":"schemas/config.json","*.json":"/abs/root.json"}}`
+	if err := os.WriteFile(rc, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadProjectConfig(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		file, schema string
+	}{
+		{filepath.Join(root, "config", "app.json"), filepath.Join(root, "schemas", "config.json")},
+		{filepath.Join(root, "config", "a", "b.json"), filepath.Join(root, "schemas", "config.json")},
+		{filepath.Join(root, "top.json"), "/abs/root.json"},
+		{filepath.Join(root, "data", "x.json"), ""},
+		{filepath.Join(filepath.Dir(root), "outside.json"), ""},
+	} {
+		schema, ok := loaded.SchemaFor(tc.file)
+		if schema != tc.schema || ok != (tc.schema != "") {
+			t.Errorf("SchemaFor(%s) = %q, %v, 期望 %q", tc.file, schema, ok, tc.schema)
+		}
+	}
+
+	if _, ok := DefaultProjectConfig().SchemaFor("a.json"); ok {
+		t.Error("没有配置文件时不应匹配任何Schema")
+	}
+}
@@ -0,0 +1,528 @@
+// proto_descriptor.go - 解码 protoc --descriptor_set_out 生成的 FileDescriptorSet
+package leptjson
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ProtoFieldType 是字段的类型，取值与 FieldDescriptorProto.Type 相同
+type ProtoFieldType int
+
+// 字段类型常量
+const (
+	PROTO_DOUBLE   ProtoFieldType = 1
+	PROTO_FLOAT    ProtoFieldType = 2
+	PROTO_INT64    ProtoFieldType = 3
+	PROTO_UINT64   ProtoFieldType = 4
+	PROTO_INT32    ProtoFieldType = 5
+	PROTO_FIXED64  ProtoFieldType = 6
+	PROTO_FIXED32  ProtoFieldType = 7
+	PROTO_BOOL     ProtoFieldType = 8
+	PROTO_STRING   ProtoFieldType = 9
+	PROTO_GROUP    ProtoFieldType = 10
+	PROTO_MESSAGE  ProtoFieldType = 11
+	PROTO_BYTES    ProtoFieldType = 12
+	PROTO_UINT32   ProtoFieldType = 13
+	PROTO_ENUM     ProtoFieldType = 14
+	PROTO_SFIXED32 ProtoFieldType = 15
+	PROTO_SFIXED64 ProtoFieldType = 16
+	PROTO_SINT32   ProtoFieldType = 17
+	PROTO_SINT64   ProtoFieldType = 18
+)
+
+var protoFieldTypeNames = map[ProtoFieldType]string{
+	PROTO_DOUBLE: "double", PROTO_FLOAT: "float", PROTO_INT64: "int64", PROTO_UINT64: "uint64",
+	PROTO_INT32: "int32", PROTO_FIXED64: "fixed64", PROTO_FIXED32: "fixed32", PROTO_BOOL: "bool",
+	PROTO_STRING: "string", PROTO_GROUP: "group", PROTO_MESSAGE: "message", PROTO_BYTES: "bytes",
+	PROTO_UINT32: "uint32", PROTO_ENUM: "enum", PROTO_SFIXED32: "sfixed32", PROTO_SFIXED64: "sfixed64",
+	PROTO_SINT32: "sint32", PROTO_SINT64: "sint64",
+}
+
+// String 返回类型在 .proto 文件中的名称
+func (t ProtoFieldType) String() string {
+	if name, ok := protoFieldTypeNames[t]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// MarshalJSON 将字段类型编码为名称字符串
+func (t ProtoFieldType) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(t.String())), nil
+}
+
+// ProtoField 是消息的一个字段
+type ProtoField struct {
+	Name           string         // .proto 文件中的字段名，如 user_id
+	JSONName       string         // JSON 中的字段名，默认为小驼峰形式，如 userId
+	Number         int32          // 字段编号
+	Type           ProtoFieldType // 字段类型
+	TypeName       string         // 消息和枚举类型的完整名称，不含开头的点，如 pkg.User
+	Repeated       bool           // 是否是 repeated 字段（包括 map）
+	OneofIndex     int            // 所属 oneof 在 ProtoMessage.Oneofs 中的下标，不属于 oneof 时为 -1
+	Proto3Optional bool           // 是否是 proto3 的 optional 字段，它所属的 oneof 是编译器合成的
+}
+
+// ProtoMessage 是一个消息类型
+type ProtoMessage struct {
+	FullName string
+	Fields   []*ProtoField
+	Oneofs   []string
+	MapEntry bool // 编译器为 map 字段生成的键值对类型，字段 1 是键，字段 2 是值
+}
+
+// ProtoEnum 是一个枚举类型
+type ProtoEnum struct {
+	FullName string
+	Values   map[string]int32 // 枚举值名称 -> 数字
+}
+
+// ProtoRegistry 保存描述符集中所有的消息和枚举类型，按完整名称索引
+type ProtoRegistry struct {
+	messages map[string]*ProtoMessage
+	enums    map[string]*ProtoEnum
+}
+
+// Message 返回完整名称对应的消息类型，名称可以以点开头
+func (r *ProtoRegistry) Message(name string) (*ProtoMessage, bool) {
+	m, ok := r.messages[strings.TrimPrefix(name, ".")]
+	return m, ok
+}
+
+// Enum 返回完整名称对应的枚举类型，名称可以以点开头
+func (r *ProtoRegistry) Enum(name string) (*ProtoEnum, bool) {
+	e, ok := r.enums[strings.TrimPrefix(name, ".")]
+	return e, ok
+}
+
+// MessageNames 按字母顺序返回所有消息类型的完整名称，不包括 map 的键值对类型
+func (r *ProtoRegistry) MessageNames() []string {
+	var names []string
+	for name, m := range r.messages {
+		if !m.MapEntry {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// protoReader 解码 protobuf 二进制格式
+type protoReader struct {
+	buf []byte
+	pos int
+}
+
+func (r *protoReader) done() bool {
+	return r.pos >= len(r.buf)
+}
+
+func (r *protoReader) varint() (uint64, error) {
+	var v uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		if r.pos >= len(r.buf) {
+			return 0, fmt.Errorf("varint 在位置 %d 被截断", r.pos)
+		}
+		b := r.buf[r.pos]
+		r.pos++
+		v |= uint64(b&0x7F) << shift
+		if b < 0x80 {
+			return v, nil
+		}
+	}
+	return 0, fmt.Errorf("位置 %d 的 varint 过长", r.pos)
+}
+
+// tag 读取下一个字段的编号和线路类型
+func (r *protoReader) tag() (int, int, error) {
+	v, err := r.varint()
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(v >> 3), int(v & 7), nil
+}
+
+// bytes 读取长度前缀的内容
+func (r *protoReader) bytes() ([]byte, error) {
+	n, err := r.varint()
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(r.buf)-r.pos) {
+		return nil, fmt.Errorf("位置 %d 的长度 %d 超出了数据范围", r.pos, n)
+	}
+	data := r.buf[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return data, nil
+}
+
+// skip 跳过一个不需要的字段
+func (r *protoReader) skip(wireType int) error {
+	switch wireType {
+	case 0:
+		_, err := r.varint()
+		return err
+	case 1, 5:
+		n := 8
+		if wireType == 5 {
+			n = 4
+		}
+		if r.pos+n > len(r.buf) {
+			return fmt.Errorf("位置 %d 的定长字段被截断", r.pos)
+		}
+		r.pos += n
+		return nil
+	case 2:
+		_, err := r.bytes()
+		return err
+	default:
+		return fmt.Errorf("不支持的线路类型 %d", wireType)
+	}
+}
+
+// fields 依次对消息中的每个字段调用 fn，fn 读取需要的字段并返回 true，不需要的字段由 fields 跳过
+func (r *protoReader) fields(fn func(field, wireType int) (bool, error)) error {
+	for !r.done() {
+		field, wireType, err := r.tag()
+		if err != nil {
+			return err
+		}
+		handled, err := fn(field, wireType)
+		if err != nil {
+			return err
+		}
+		if !handled {
+			if err := r.skip(wireType); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// stringField 读取字符串字段，线路类型不对时返回错误
+func (r *protoReader) stringField(wireType int) (string, error) {
+	if wireType != 2 {
+		return "", fmt.Errorf("字符串字段的线路类型是 %d", wireType)
+	}
+	data, err := r.bytes()
+	return string(data), err
+}
+
+// intField 读取 varint 编码的整数字段
+func (r *protoReader) intField(wireType int) (int64, error) {
+	if wireType != 0 {
+		return 0, fmt.Errorf("整数字段的线路类型是 %d", wireType)
+	}
+	v, err := r.varint()
+	return int64(v), err
+}
+
+// ParseProtoDescriptorSet 解码 FileDescriptorSet，即 protoc --descriptor_set_out 的输出
+//
+// 字段引用的类型必须都在描述符集中（生成时使用 --include_imports），
+// google.protobuf 的知名类型（Timestamp、Duration、Struct 等）除外。
+func ParseProtoDescriptorSet(data []byte) (*ProtoRegistry, error) {
+	registry := &ProtoRegistry{messages: make(map[string]*ProtoMessage), enums: make(map[string]*ProtoEnum)}
+	r := &protoReader{buf: data}
+	err := r.fields(func(field, wireType int) (bool, error) {
+		if field != 1 || wireType != 2 { // FileDescriptorSet.file
+			return false, nil
+		}
+		file, err := r.bytes()
+		if err != nil {
+			return true, err
+		}
+		return true, registry.parseFile(file)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("解码描述符集失败: %v", err)
+	}
+	if len(registry.messages) == 0 && len(registry.enums) == 0 {
+		return nil, fmt.Errorf("描述符集中没有任何类型")
+	}
+	if err := registry.checkReferences(); err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
+
+// parseFile 解码 FileDescriptorProto
+func (reg *ProtoRegistry) parseFile(data []byte) error {
+	var pkg string
+	var messages, enums [][]byte
+	r := &protoReader{buf: data}
+	err := r.fields(func(field, wireType int) (bool, error) {
+		var err error
+		switch field {
+		case 2: // package
+			pkg, err = r.stringField(wireType)
+		case 4: // message_type
+			var m []byte
+			m, err = r.bytes()
+			messages = append(messages, m)
+		case 5: // enum_type
+			var e []byte
+			e, err = r.bytes()
+			enums = append(enums, e)
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+	if err != nil {
+		return err
+	}
+	for _, m := range messages {
+		if err := reg.parseMessage(m, pkg); err != nil {
+			return err
+		}
+	}
+	for _, e := range enums {
+		if err := reg.parseEnum(e, pkg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// qualify 把作用域和名称连接为完整名称
+func qualify(scope, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
+
+// parseMessage 解码 DescriptorProto，包括嵌套的消息和枚举
+func (reg *ProtoRegistry) parseMessage(data []byte, scope string) error {
+	m := &ProtoMessage{}
+	var name string
+	var nested, enums [][]byte
+	r := &protoReader{buf: data}
+	err := r.fields(func(field, wireType int) (bool, error) {
+		var err error
+		switch field {
+		case 1: // name
+			name, err = r.stringField(wireType)
+		case 2: // field
+			var f []byte
+			if f, err = r.bytes(); err == nil {
+				var pf *ProtoField
+				pf, err = parseProtoField(f)
+				m.Fields = append(m.Fields, pf)
+			}
+		case 3: // nested_type
+			var n []byte
+			n, err = r.bytes()
+			nested = append(nested, n)
+		case 4: // enum_type
+			var e []byte
+			e, err = r.bytes()
+			enums = append(enums, e)
+		case 7: // options
+			var opts []byte
+			if opts, err = r.bytes(); err == nil {
+				m.MapEntry, err = parseMapEntryOption(opts)
+			}
+		case 8: // oneof_decl
+			var o []byte
+			if o, err = r.bytes(); err == nil {
+				var oneof string
+				oneof, err = parseNameOnly(o)
+				m.Oneofs = append(m.Oneofs, oneof)
+			}
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		return fmt.Errorf("%s 中有没有名称的消息", scope)
+	}
+	m.FullName = qualify(scope, name)
+	for _, f := range m.Fields {
+		if f.OneofIndex >= len(m.Oneofs) {
+			return fmt.Errorf("字段 %s.%s 的 oneof 下标 %d 无效", m.FullName, f.Name, f.OneofIndex)
+		}
+	}
+	reg.messages[m.FullName] = m
+	for _, n := range nested {
+		if err := reg.parseMessage(n, m.FullName); err != nil {
+			return err
+		}
+	}
+	for _, e := range enums {
+		if err := reg.parseEnum(e, m.FullName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseProtoField 解码 FieldDescriptorProto
+func parseProtoField(data []byte) (*ProtoField, error) {
+	f := &ProtoField{OneofIndex: -1}
+	r := &protoReader{buf: data}
+	err := r.fields(func(field, wireType int) (bool, error) {
+		var err error
+		var v int64
+		switch field {
+		case 1: // name
+			f.Name, err = r.stringField(wireType)
+		case 3: // number
+			v, err = r.intField(wireType)
+			f.Number = int32(v)
+		case 4: // label
+			v, err = r.intField(wireType)
+			f.Repeated = v == 3 // LABEL_REPEATED
+		case 5: // type
+			v, err = r.intField(wireType)
+			f.Type = ProtoFieldType(v)
+		case 6: // type_name
+			f.TypeName, err = r.stringField(wireType)
+			f.TypeName = strings.TrimPrefix(f.TypeName, ".")
+		case 9: // oneof_index
+			v, err = r.intField(wireType)
+			f.OneofIndex = int(v)
+		case 10: // json_name
+			f.JSONName, err = r.stringField(wireType)
+		case 17: // proto3_optional
+			v, err = r.intField(wireType)
+			f.Proto3Optional = v != 0
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := protoFieldTypeNames[f.Type]; !ok {
+		return nil, fmt.Errorf("字段 %s 的类型 %d 无效", f.Name, f.Type)
+	}
+	if f.JSONName == "" {
+		f.JSONName = protoJSONName(f.Name)
+	}
+	return f, nil
+}
+
+// protoJSONName 按 protoc 的规则把字段名转换为小驼峰形式：删除下划线，下划线后的字母大写
+func protoJSONName(name string) string {
+	var sb strings.Builder
+	upper := false
+	for _, c := range name {
+		if c == '_' {
+			upper = true
+			continue
+		}
+		if upper && c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		upper = false
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
+
+// parseMapEntryOption 从 MessageOptions 中读取 map_entry
+func parseMapEntryOption(data []byte) (bool, error) {
+	mapEntry := false
+	r := &protoReader{buf: data}
+	err := r.fields(func(field, wireType int) (bool, error) {
+		if field != 7 {
+			return false, nil
+		}
+		v, err := r.intField(wireType)
+		mapEntry = v != 0
+		return true, err
+	})
+	return mapEntry, err
+}
+
+// parseNameOnly 读取只关心名称（字段 1）的描述符，如 OneofDescriptorProto
+func parseNameOnly(data []byte) (string, error) {
+	var name string
+	r := &protoReader{buf: data}
+	err := r.fields(func(field, wireType int) (bool, error) {
+		if field != 1 {
+			return false, nil
+		}
+		var err error
+		name, err = r.stringField(wireType)
+		return true, err
+	})
+	return name, err
+}
+
+// parseEnum 解码 EnumDescriptorProto
+func (reg *ProtoRegistry) parseEnum(data []byte, scope string) error {
+	e := &ProtoEnum{Values: make(map[string]int32)}
+	var name string
+	r := &protoReader{buf: data}
+	err := r.fields(func(field, wireType int) (bool, error) {
+		var err error
+		switch field {
+		case 1: // name
+			name, err = r.stringField(wireType)
+		case 2: // value
+			var v []byte
+			if v, err = r.bytes(); err != nil {
+				return true, err
+			}
+			var valueName string
+			var number int64
+			vr := &protoReader{buf: v}
+			err = vr.fields(func(field, wireType int) (bool, error) {
+				var err error
+				switch field {
+				case 1:
+					valueName, err = vr.stringField(wireType)
+				case 2:
+					number, err = vr.intField(wireType)
+				default:
+					return false, nil
+				}
+				return true, err
+			})
+			e.Values[valueName] = int32(number)
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		return fmt.Errorf("%s 中有没有名称的枚举", scope)
+	}
+	e.FullName = qualify(scope, name)
+	reg.enums[e.FullName] = e
+	return nil
+}
+
+// checkReferences 检查字段引用的消息和枚举类型都在描述符集中
+func (reg *ProtoRegistry) checkReferences() error {
+	for _, m := range reg.messages {
+		for _, f := range m.Fields {
+			switch f.Type {
+			case PROTO_MESSAGE, PROTO_GROUP:
+				if _, ok := reg.messages[f.TypeName]; !ok && !isWellKnownProto(f.TypeName) {
+					return fmt.Errorf("字段 %s.%s 引用的消息类型 %s 不在描述符集中，生成时请使用 --include_imports", m.FullName, f.Name, f.TypeName)
+				}
+			case PROTO_ENUM:
+				if _, ok := reg.enums[f.TypeName]; !ok && f.TypeName != protoNullValue {
+					return fmt.Errorf("字段 %s.%s 引用的枚举类型 %s 不在描述符集中，生成时请使用 --include_imports", m.FullName, f.Name, f.TypeName)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package leptjson
+
+import (
+	"strings"
+	"testing"
+)
+
+// protoBuilder 在测试中以 protobuf 二进制格式编码描述符
+type protoBuilder []byte
+
+func (b protoBuilder) varint(v uint64) protoBuilder {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+func (b protoBuilder) int(field int, v int64) protoBuilder {
+	return b.varint(uint64(field << 3)).varint(uint64(v))
+}
+
+func (b protoBuilder) bytes(field int, data []byte) protoBuilder {
+	return append(b.varint(uint64(field<<3|2)).varint(uint64(len(data))), data...)
+}
+
+func (b protoBuilder) str(field int, s string) protoBuilder {
+	return b.bytes(field, []byte(s))
+}
+
+// protoTestField 编码 FieldDescriptorProto，oneof 为 -1 表示不属于 oneof
+func protoTestField(name string, number int, typ ProtoFieldType, typeName string, repeated bool, oneof int) []byte {
+	b := protoBuilder(nil).str(1, name).int(3, int64(number))
+	label := int64(1)
+	if repeated {
+		label = 3
+	}
+	b = b.int(4, label).int(5, int64(typ))
+	if typeName != "" {
+		b = b.str(6, "."+typeName)
+	}
+	if oneof >= 0 {
+		b = b.int(9, int64(oneof))
+	}
+	return b
+}
+
+// protoTestDescriptorSet 编码测试使用的 test.v1 包：
+//
+//	enum Status { STATUS_UNKNOWN = 0; ACTIVE = 1; }
+//	message Address { string city = 1; }
+//	message User {
+//	  int64 user_id = 1; string name = 2; repeated string tags = 3; Status status = 4;
+//	  map<string, int32> scores = 5;
+//	  oneof contact { string email = 6; string phone = 7; }
+//	  optional double weight = 8;
+//	  google.protobuf.Timestamp created_at = 9; Address address = 10; bytes avatar = 11;
+//	  google.protobuf.Any extra = 12; map<bool, Address> by_flag = 13; float ratio = 14;
+//	  uint32 count = 15; google.protobuf.Int64Value big = 16;
+//	  google.protobuf.Duration ttl = 17; google.protobuf.Value meta = 18;
+//	}
+func protoTestDescriptorSet() []byte {
+	mapEntry := func(name string, key ProtoFieldType, value ProtoFieldType, valueType string) []byte {
+		return protoBuilder(nil).str(1, name).
+			bytes(2, protoTestField("key", 1, key, "", false, -1)).
+			bytes(2, protoTestField("value", 2, value, valueType, false, -1)).
+			bytes(7, protoBuilder(nil).int(7, 1))
+	}
+	weight := protoBuilder(protoTestField("weight", 8, PROTO_DOUBLE, "", false, 1)).int(17, 1)
+	user := protoBuilder(nil).str(1, "User").
+		bytes(2, protoTestField("user_id", 1, PROTO_INT64, "", false, -1)).
+		bytes(2, protoTestField("name", 2, PROTO_STRING, "", false, -1)).
+		bytes(2, protoTestField("tags", 3, PROTO_STRING, "", true, -1)).
+		bytes(2, protoTestField("status", 4, PROTO_ENUM, "test.v1.Status", false, -1)).
+		bytes(2, protoTestField("scores", 5, PROTO_MESSAGE, "test.v1.User.ScoresEntry", true, -1)).
+		bytes(2, protoTestField("email", 6, PROTO_STRING, "", false, 0)).
+		bytes(2, protoTestField("phone", 7, PROTO_STRING, "", false, 0)).
+		bytes(2, weight).
+		bytes(2, protoTestField("created_at", 9, PROTO_MESSAGE, "google.protobuf.Timestamp", false, -1)).
+		bytes(2, protoTestField("address", 10, PROTO_MESSAGE, "test.v1.Address", false, -1)).
+		bytes(2, protoTestField("avatar", 11, PROTO_BYTES, "", false, -1)).
+		bytes(2, protoTestField("extra", 12, PROTO_MESSAGE, "google.protobuf.Any", false, -1)).
+		bytes(2, protoTestField("by_flag", 13, PROTO_MESSAGE, "test.v1.User.ByFlagEntry", true, -1)).
+		bytes(2, protoTestField("ratio", 14, PROTO_FLOAT, "", false, -1)).
+		bytes(2, protoTestField("count", 15, PROTO_UINT32, "", false, -1)).
+		bytes(2, protoTestField("big", 16, PROTO_MESSAGE, "google.protobuf.Int64Value", false, -1)).
+		bytes(2, protoTestField("ttl", 17, PROTO_MESSAGE, "google.protobuf.Duration", false, -1)).
+		bytes(2, protoTestField("meta", 18, PROTO_MESSAGE, "google.protobuf.Value", false, -1)).
+		bytes(3, mapEntry("ScoresEntry", PROTO_STRING, PROTO_INT32, "")).
+		bytes(3, mapEntry("ByFlagEntry", PROTO_BOOL, PROTO_MESSAGE, "test.v1.Address")).
+		bytes(8, protoBuilder(nil).str(1, "contact")).
+		bytes(8, protoBuilder(nil).str(1, "_weight"))
+	address := protoBuilder(nil).str(1, "Address").
+		bytes(2, protoTestField("city", 1, PROTO_STRING, "", false, -1))
+	status := protoBuilder(nil).str(1, "Status").
+		bytes(2, protoBuilder(nil).str(1, "STATUS_UNKNOWN").int(2, 0)).
+		bytes(2, protoBuilder(nil).str(1, "ACTIVE").int(2, 1))
+	file := protoBuilder(nil).str(1, "test/v1/user.proto").str(2, "test.v1").
+		str(3, "google/protobuf/timestamp.proto").
+		bytes(4, user).bytes(4, address).bytes(5, status).str(12, "proto3")
+	return protoBuilder(nil).bytes(1, file)
+}
+
+func protoTestRegistry(t *testing.T) *ProtoRegistry {
+	t.Helper()
+	registry, err := ParseProtoDescriptorSet(protoTestDescriptorSet())
+	if err != nil {
+		t.Fatalf("ParseProtoDescriptorSet 失败: %v", err)
+	}
+	return registry
+}
+
+func TestParseProtoDescriptorSet(t *testing.T) {
+	registry := protoTestRegistry(t)
+	names := strings.Join(registry.MessageNames(), ",")
+	if names != "test.v1.Address,test.v1.User" {
+		t.Errorf("MessageNames() = %s", names)
+	}
+	user, ok := registry.Message(".test.v1.User")
+	if !ok || len(user.Fields) != 18 || len(user.Oneofs) != 2 || user.Oneofs[1] != "_weight" {
+		t.Fatalf("User = %+v", user)
+	}
+	id := user.Fields[0]
+	if id.Name != "user_id" || id.JSONName != "userId" || id.Type != PROTO_INT64 || id.OneofIndex != -1 {
+		t.Errorf("user_id = %+v", id)
+	}
+	if f := user.Fields[4]; !f.Repeated || f.TypeName != "test.v1.User.ScoresEntry" {
+		t.Errorf("scores = %+v", f)
+	}
+	if f := user.Fields[7]; f.OneofIndex != 1 || !f.Proto3Optional {
+		t.Errorf("weight = %+v", f)
+	}
+	if entry, ok := registry.Message("test.v1.User.ScoresEntry"); !ok || !entry.MapEntry {
+		t.Errorf("ScoresEntry 应该是 map 的键值对类型")
+	}
+	status, ok := registry.Enum("test.v1.Status")
+	if !ok || len(status.Values) != 2 || status.Values["ACTIVE"] != 1 {
+		t.Errorf("Status = %+v", status)
+	}
+}
+
+func TestParseProtoDescriptorSetErrors(t *testing.T) {
+	missing := protoBuilder(nil).bytes(1, protoBuilder(nil).str(2, "p").
+		bytes(4, protoBuilder(nil).str(1, "M").bytes(2, protoTestField("other", 1, PROTO_MESSAGE, "p.Other", false, -1))))
+	tests := map[string][]byte{
+		"没有任何类型":            protoBuilder(nil).bytes(1, protoBuilder(nil).str(2, "p")),
+		"--include_imports": missing,
+		"超出了数据范围":           protoTestDescriptorSet()[:40],
+	}
+	for expected, data := range tests {
+		if _, err := ParseProtoDescriptorSet(data); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("错误 = %v, 期望包含 %q", err, expected)
+		}
+	}
+}
+
+func TestProtoJSONName(t *testing.T) {
+	tests := map[string]string{"user_id": "userId", "name": "name", "a_b_c": "aBC", "x_1": "x1", "already_Upper": "alreadyUpper"}
+	for name, expected := range tests {
+		if got := protoJSONName(name); got != expected {
+			t.Errorf("protoJSONName(%s) = %s, 期望 %s", name, got, expected)
+		}
+	}
+}
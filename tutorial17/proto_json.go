@@ -0,0 +1,500 @@
+// proto_json.go - 按 proto3 JSON 映射验证JSON文档是否能解析为指定的 protobuf 消息
+package leptjson
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// google.protobuf 中在JSON映射里有特殊形式的类型
+const (
+	protoAny       = "google.protobuf.Any"
+	protoTimestamp = "google.protobuf.Timestamp"
+	protoDuration  = "google.protobuf.Duration"
+	protoFieldMask = "google.protobuf.FieldMask"
+	protoStruct    = "google.protobuf.Struct"
+	protoValue     = "google.protobuf.Value"
+	protoListValue = "google.protobuf.ListValue"
+	protoEmpty     = "google.protobuf.Empty"
+	protoNullValue = "google.protobuf.NullValue"
+)
+
+// protoWrappers 是包装类型和它们包装的标量类型
+var protoWrappers = map[string]ProtoFieldType{
+	"google.protobuf.DoubleValue": PROTO_DOUBLE,
+	"google.protobuf.FloatValue":  PROTO_FLOAT,
+	"google.protobuf.Int64Value":  PROTO_INT64,
+	"google.protobuf.UInt64Value": PROTO_UINT64,
+	"google.protobuf.Int32Value":  PROTO_INT32,
+	"google.protobuf.UInt32Value": PROTO_UINT32,
+	"google.protobuf.BoolValue":   PROTO_BOOL,
+	"google.protobuf.StringValue": PROTO_STRING,
+	"google.protobuf.BytesValue":  PROTO_BYTES,
+}
+
+// isWellKnownProto 判断消息类型是否是JSON映射有特殊形式的知名类型
+func isWellKnownProto(name string) bool {
+	switch name {
+	case protoAny, protoTimestamp, protoDuration, protoFieldMask, protoStruct, protoValue, protoListValue, protoEmpty:
+		return true
+	}
+	_, ok := protoWrappers[name]
+	return ok
+}
+
+// ProtoJSONOptions 控制 ValidateProtoJSON 的行为
+type ProtoJSONOptions struct {
+	IgnoreUnknownFields bool // 允许消息中出现描述符没有定义的字段，与 protojson 的 DiscardUnknown 对应
+}
+
+// protoJSONValidator 保存一次验证的状态
+type protoJSONValidator struct {
+	registry *ProtoRegistry
+	options  ProtoJSONOptions
+	issues   []ValidationIssue
+}
+
+// ValidateProtoJSON 验证 data 是否符合消息 messageName 的 proto3 JSON 映射
+//
+// 检查字段名（JSON 名称或原始字段名）、字段类型、枚举值、oneof 中最多设置一个字段，
+// 以及 Timestamp、Duration、包装类型等知名类型的特殊形式。null 表示字段的默认值。
+// 64位整数可以是数字或字符串，超过 2^53 的数字会丢失精度，应该使用字符串。
+// 消息类型不存在时返回错误。
+func (r *ProtoRegistry) ValidateProtoJSON(data *Value, messageName string, options ProtoJSONOptions) (ValidationResult, error) {
+	name := strings.TrimPrefix(messageName, ".")
+	if _, ok := r.messages[name]; !ok && !isWellKnownProto(name) {
+		return ValidationResult{}, fmt.Errorf("描述符集中没有消息类型 %s", messageName)
+	}
+	v := &protoJSONValidator{registry: r, options: options}
+	v.message(name, data, "$")
+	translator, _ := LookupValidationTranslator(DefaultValidationLanguage)
+	return newValidationResult(v.issues, translator), nil
+}
+
+// fail 记录一条错误
+func (v *protoJSONValidator) fail(path, keyword string, format string, args ...interface{}) {
+	v.issues = append(v.issues, ValidationIssue{
+		Path:    path,
+		Keyword: keyword,
+		Message: "路径 " + path + ": " + fmt.Sprintf(format, args...),
+	})
+}
+
+// message 验证消息，包括知名类型
+func (v *protoJSONValidator) message(name string, data *Value, path string) {
+	if t, ok := protoWrappers[name]; ok {
+		v.scalar(t, "", data, path)
+		return
+	}
+	switch name {
+	case protoValue:
+		return
+	case protoStruct:
+		if data.Type != OBJECT {
+			v.fail(path, "type", "%s 应该是对象", name)
+		}
+		return
+	case protoListValue:
+		if data.Type != ARRAY {
+			v.fail(path, "type", "%s 应该是数组", name)
+		}
+		return
+	case protoTimestamp:
+		v.timestamp(data, path)
+		return
+	case protoDuration:
+		v.duration(data, path)
+		return
+	case protoFieldMask:
+		if data.Type != STRING {
+			v.fail(path, "type", "FieldMask 应该是以逗号分隔路径的字符串")
+		}
+		return
+	case protoAny:
+		v.any(data, path)
+		return
+	}
+
+	if data.Type != OBJECT {
+		v.fail(path, "type", "消息 %s 应该是对象，实际是 %s", name, valueTypeName(data.Type))
+		return
+	}
+	m, ok := v.registry.messages[name]
+	if !ok {
+		// 描述符集中没有的 Empty
+		for _, member := range data.O {
+			v.unknownField(name, path, member.K)
+		}
+		return
+	}
+	v.fields(m, data.O, path)
+}
+
+// unknownField 报告描述符中没有定义的字段
+func (v *protoJSONValidator) unknownField(message, path, key string) {
+	if !v.options.IgnoreUnknownFields {
+		v.fail(path+"."+key, "unknownField", "消息 %s 没有字段 %s", message, key)
+	}
+}
+
+// fields 验证消息的成员
+func (v *protoJSONValidator) fields(m *ProtoMessage, members []Member, path string) {
+	byName := make(map[string]*ProtoField, 2*len(m.Fields))
+	for _, f := range m.Fields {
+		byName[f.JSONName] = f
+		byName[f.Name] = f
+	}
+	seen := make(map[*ProtoField]string)
+	oneofs := make(map[int]string) // oneof 下标 -> 已经设置的字段
+	for _, member := range members {
+		memberPath := path + "." + member.K
+		f, ok := byName[member.K]
+		if !ok {
+			v.unknownField(m.FullName, path, member.K)
+			continue
+		}
+		if first, dup := seen[f]; dup {
+			v.fail(memberPath, "duplicateField", "字段 %s 已经以 %s 出现过", f.Name, first)
+			continue
+		}
+		seen[f] = member.K
+
+		// null 表示默认值，只有 Value 和 NullValue 把 null 当作一个值
+		if member.V.Type == NULL && !(f.TypeName == protoValue || f.TypeName == protoNullValue) {
+			continue
+		}
+		if f.OneofIndex >= 0 && !f.Proto3Optional {
+			if other, set := oneofs[f.OneofIndex]; set {
+				v.fail(memberPath, "oneof", "oneof %s 中已经设置了 %s，不能再设置 %s", m.Oneofs[f.OneofIndex], other, member.K)
+				continue
+			}
+			oneofs[f.OneofIndex] = member.K
+		}
+		v.field(f, member.V, memberPath)
+	}
+}
+
+// field 验证一个字段的值，处理 repeated 和 map 字段
+func (v *protoJSONValidator) field(f *ProtoField, data *Value, path string) {
+	if !f.Repeated {
+		v.single(f.Type, f.TypeName, data, path)
+		return
+	}
+	if entry, ok := v.registry.messages[f.TypeName]; ok && entry.MapEntry && f.Type == PROTO_MESSAGE {
+		v.mapField(entry, data, path)
+		return
+	}
+	if data.Type != ARRAY {
+		v.fail(path, "type", "repeated 字段 %s 应该是数组，实际是 %s", f.Name, valueTypeName(data.Type))
+		return
+	}
+	for i, elem := range data.A {
+		elemPath := fmt.Sprintf("%s[%d]", path, i)
+		if elem.Type == NULL && f.TypeName != protoValue {
+			v.fail(elemPath, "type", "repeated 字段的元素不能是 null")
+			continue
+		}
+		v.single(f.Type, f.TypeName, elem, elemPath)
+	}
+}
+
+// mapField 验证 map 字段：JSON 对象的键是 map 的键的字符串形式
+func (v *protoJSONValidator) mapField(entry *ProtoMessage, data *Value, path string) {
+	if data.Type != OBJECT {
+		v.fail(path, "type", "map 字段应该是对象，实际是 %s", valueTypeName(data.Type))
+		return
+	}
+	var key, value *ProtoField
+	for _, f := range entry.Fields {
+		switch f.Number {
+		case 1:
+			key = f
+		case 2:
+			value = f
+		}
+	}
+	if key == nil || value == nil {
+		v.fail(path, "type", "map 类型 %s 缺少键或值字段", entry.FullName)
+		return
+	}
+	for _, member := range data.O {
+		memberPath := path + "." + member.K
+		if err := checkProtoMapKey(key.Type, member.K); err != nil {
+			v.fail(memberPath, "mapKey", "%v", err)
+		}
+		if member.V.Type == NULL && value.TypeName != protoValue {
+			v.fail(memberPath, "type", "map 的值不能是 null")
+			continue
+		}
+		v.single(value.Type, value.TypeName, member.V, memberPath)
+	}
+}
+
+// checkProtoMapKey 检查 map 的键能否解析为键的类型
+func checkProtoMapKey(t ProtoFieldType, key string) error {
+	switch t {
+	case PROTO_STRING:
+		return nil
+	case PROTO_BOOL:
+		if key != "true" && key != "false" {
+			return fmt.Errorf("bool 类型的 map 键只能是 true 或 false，实际是 %q", key)
+		}
+		return nil
+	default:
+		if _, _, err := parseProtoInteger(t, key); err != nil {
+			return fmt.Errorf("%s 类型的 map 键 %q 无效: %v", t, key, err)
+		}
+		return nil
+	}
+}
+
+// single 验证非 repeated 的值
+func (v *protoJSONValidator) single(t ProtoFieldType, typeName string, data *Value, path string) {
+	switch t {
+	case PROTO_MESSAGE, PROTO_GROUP:
+		v.message(typeName, data, path)
+	case PROTO_ENUM:
+		v.enum(typeName, data, path)
+	default:
+		v.scalar(t, typeName, data, path)
+	}
+}
+
+// enum 验证枚举值：值的名称或 int32 范围内的数字
+func (v *protoJSONValidator) enum(typeName string, data *Value, path string) {
+	if typeName == protoNullValue {
+		if data.Type != NULL && !(data.Type == STRING && GetString(data) == "NULL_VALUE") {
+			v.fail(path, "enum", "NullValue 只能是 null")
+		}
+		return
+	}
+	e := v.registry.enums[typeName]
+	switch data.Type {
+	case STRING:
+		if _, ok := e.Values[GetString(data)]; !ok {
+			v.fail(path, "enum", "%q 不是枚举 %s 的值", GetString(data), typeName)
+		}
+	case NUMBER:
+		if data.N != math.Trunc(data.N) || data.N < math.MinInt32 || data.N > math.MaxInt32 {
+			v.fail(path, "enum", "枚举的数字 %v 不是 int32", data.N)
+		}
+	default:
+		v.fail(path, "type", "枚举 %s 应该是值的名称或数字，实际是 %s", typeName, valueTypeName(data.Type))
+	}
+}
+
+// parseProtoInteger 按JSON数字的语法解析整数类型的字符串形式，返回值和是否超出范围
+func parseProtoInteger(t ProtoFieldType, s string) (float64, bool, error) {
+	n, err := parseJSONNumberString(s)
+	if err != nil {
+		return 0, false, err
+	}
+	// 不带指数和小数的整数直接解析，避免64位整数在转换为浮点数时丢失精度
+	if !strings.ContainsAny(s, ".eE") {
+		unsigned := t == PROTO_UINT32 || t == PROTO_FIXED32 || t == PROTO_UINT64 || t == PROTO_FIXED64
+		bits := 32
+		if t == PROTO_INT64 || t == PROTO_UINT64 || t == PROTO_FIXED64 || t == PROTO_SFIXED64 || t == PROTO_SINT64 {
+			bits = 64
+		}
+		if unsigned {
+			_, err = strconv.ParseUint(s, 10, bits)
+		} else {
+			_, err = strconv.ParseInt(s, 10, bits)
+		}
+		if err != nil {
+			return n, true, fmt.Errorf("%s 超出了 %s 的范围", s, t)
+		}
+		return n, false, nil
+	}
+	if err := checkProtoIntegerRange(t, n); err != nil {
+		return n, true, err
+	}
+	return n, false, nil
+}
+
+// checkProtoIntegerRange 检查数字是否是整数类型范围内的整数
+func checkProtoIntegerRange(t ProtoFieldType, n float64) error {
+	if n != math.Trunc(n) {
+		return fmt.Errorf("%v 不是整数", n)
+	}
+	var min, max float64
+	switch t {
+	case PROTO_INT32, PROTO_SINT32, PROTO_SFIXED32:
+		min, max = math.MinInt32, math.MaxInt32
+	case PROTO_UINT32, PROTO_FIXED32:
+		min, max = 0, math.MaxUint32
+	case PROTO_UINT64, PROTO_FIXED64:
+		// 2^64 本身超出范围
+		if n < 0 || n >= 1<<64 {
+			return fmt.Errorf("%v 超出了 %s 的范围", n, t)
+		}
+		return nil
+	default:
+		if n < -(1<<63) || n >= 1<<63 {
+			return fmt.Errorf("%v 超出了 %s 的范围", n, t)
+		}
+		return nil
+	}
+	if n < min || n > max {
+		return fmt.Errorf("%v 超出了 %s 的范围", n, t)
+	}
+	return nil
+}
+
+// parseJSONNumberString 按JSON数字的语法解析字符串
+func parseJSONNumberString(s string) (float64, error) {
+	n := &Value{}
+	if s == "" || strings.TrimSpace(s) != s || Parse(n, s) != PARSE_OK || n.Type != NUMBER {
+		return 0, fmt.Errorf("%q 不是数字", s)
+	}
+	return n.N, nil
+}
+
+// scalar 验证标量类型的值
+func (v *protoJSONValidator) scalar(t ProtoFieldType, typeName string, data *Value, path string) {
+	switch t {
+	case PROTO_BOOL:
+		if data.Type != TRUE && data.Type != FALSE {
+			v.fail(path, "type", "bool 字段应该是 true 或 false，实际是 %s", valueTypeName(data.Type))
+		}
+	case PROTO_STRING:
+		if data.Type != STRING {
+			v.fail(path, "type", "string 字段应该是字符串，实际是 %s", valueTypeName(data.Type))
+		}
+	case PROTO_BYTES:
+		if data.Type != STRING {
+			v.fail(path, "type", "bytes 字段应该是 base64 字符串，实际是 %s", valueTypeName(data.Type))
+		} else if !isProtoBase64(GetString(data)) {
+			v.fail(path, "format", "bytes 字段不是有效的 base64 编码")
+		}
+	case PROTO_DOUBLE, PROTO_FLOAT:
+		n := data.N
+		switch data.Type {
+		case NUMBER:
+		case STRING:
+			str := GetString(data)
+			if str == "NaN" || str == "Infinity" || str == "-Infinity" {
+				return
+			}
+			var err error
+			if n, err = parseJSONNumberString(str); err != nil {
+				v.fail(path, "type", "%s 字段的字符串只能是数字、NaN、Infinity 或 -Infinity", t)
+				return
+			}
+		default:
+			v.fail(path, "type", "%s 字段应该是数字，实际是 %s", t, valueTypeName(data.Type))
+			return
+		}
+		if t == PROTO_FLOAT && math.Abs(n) > math.MaxFloat32 {
+			v.fail(path, "range", "%v 超出了 float 的范围", n)
+		}
+	default:
+		// 各种整数类型
+		switch data.Type {
+		case NUMBER:
+			if err := checkProtoIntegerRange(t, data.N); err != nil {
+				hint := ""
+				if math.Abs(data.N) >= 1<<53 && data.N == math.Trunc(data.N) {
+					hint = "，64位整数应该使用字符串以免丢失精度"
+				}
+				v.fail(path, "range", "%v%s", err, hint)
+			}
+		case STRING:
+			if _, outOfRange, err := parseProtoInteger(t, GetString(data)); err != nil {
+				keyword := "type"
+				if outOfRange {
+					keyword = "range"
+				}
+				v.fail(path, keyword, "%s 字段的值无效: %v", t, err)
+			}
+		default:
+			v.fail(path, "type", "%s 字段应该是整数或整数字符串，实际是 %s", t, valueTypeName(data.Type))
+		}
+	}
+}
+
+// isProtoBase64 判断字符串是否是标准或 URL 安全的 base64，填充可以省略
+func isProtoBase64(s string) bool {
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+		if _, err := enc.DecodeString(s); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// timestamp 验证 RFC 3339 格式的 Timestamp，年份在 0001 到 9999 之间
+func (v *protoJSONValidator) timestamp(data *Value, path string) {
+	if data.Type != STRING {
+		v.fail(path, "type", "Timestamp 应该是 RFC 3339 格式的字符串，实际是 %s", valueTypeName(data.Type))
+		return
+	}
+	if _, err := time.Parse(time.RFC3339Nano, GetString(data)); err != nil || !strings.Contains(GetString(data), "T") {
+		v.fail(path, "format", "%q 不是 RFC 3339 格式的时间，如 1972-01-01T10:00:20.021Z", GetString(data))
+	}
+}
+
+// protoDurationPattern 是 Duration 的JSON形式：秒数，最多9位小数，以 s 结尾
+var protoDurationPattern = regexp.MustCompile(`^-?([0-9]+)(\.[0-9]{1,9})?s$`)
+
+// protoMaxDurationSeconds 是 Duration 的最大秒数，约一万年
+const protoMaxDurationSeconds = 315576000000
+
+// duration 验证 Duration，如 1.5s
+func (v *protoJSONValidator) duration(data *Value, path string) {
+	if data.Type != STRING {
+		v.fail(path, "type", "Duration 应该是以 s 结尾的字符串，实际是 %s", valueTypeName(data.Type))
+		return
+	}
+	m := protoDurationPattern.FindStringSubmatch(GetString(data))
+	if m == nil {
+		v.fail(path, "format", "%q 不是有效的 Duration，如 1.5s", GetString(data))
+		return
+	}
+	if seconds, err := strconv.ParseInt(m[1], 10, 64); err != nil || seconds > protoMaxDurationSeconds {
+		v.fail(path, "range", "Duration %s 超出了 ±%d 秒的范围", GetString(data), protoMaxDurationSeconds)
+	}
+}
+
+// any 验证 Any：@type 指定的类型必须在描述符集中；知名类型的值放在 value 中，
+// 其他消息的字段与 @type 并列
+func (v *protoJSONValidator) any(data *Value, path string) {
+	if data.Type != OBJECT {
+		v.fail(path, "type", "Any 应该是对象，实际是 %s", valueTypeName(data.Type))
+		return
+	}
+	typeURL := GetObjectValueByKey(data, "@type")
+	if typeURL == nil || typeURL.Type != STRING {
+		v.fail(path, "anyType", "Any 缺少字符串类型的 @type")
+		return
+	}
+	url := GetString(typeURL)
+	name := url[strings.LastIndex(url, "/")+1:]
+	m, ok := v.registry.messages[name]
+	if !ok && !isWellKnownProto(name) {
+		v.fail(path+".@type", "anyType", "描述符集中没有 @type 指定的消息类型 %s", name)
+		return
+	}
+
+	var rest []Member
+	for _, member := range data.O {
+		if member.K != "@type" {
+			rest = append(rest, member)
+		}
+	}
+	if isWellKnownProto(name) {
+		value := GetObjectValueByKey(data, "value")
+		if value == nil || len(rest) != 1 {
+			v.fail(path, "anyType", "知名类型 %s 的 Any 应该只有 @type 和 value 两个成员", name)
+			return
+		}
+		v.message(name, value, path+".value")
+		return
+	}
+	v.fields(m, rest, path)
+}
@@ -0,0 +1,124 @@
+package leptjson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateProtoJSON(t *testing.T) {
+	registry := protoTestRegistry(t)
+	valid := []string{
+		`{}`,
+		`{"userId": "9007199254740993", "name": "a", "tags": ["x", "y"], "status": "ACTIVE"}`,
+		`{"user_id": 12, "status": 7, "scores": {"a": 1, "b": "-2"}, "email": "a@b.c", "weight": 1.5}`,
+		`{"createdAt": "2024-01-02T03:04:05.123Z", "address": {"city": "上海"}, "avatar": "aGk-_w"}`,
+		`{"byFlag": {"true": {"city": "x"}}, "ratio": "NaN", "count": 4294967295, "big": "-5"}`,
+		`{"ttl": "-1.000000001s", "meta": {"any": [null]}, "name": null, "phone": null, "email": "x"}`,
+		`{"extra": {"@type": "type.googleapis.com/test.v1.Address", "city": "x"}}`,
+		`{"extra": {"@type": "type.googleapis.com/google.protobuf.Duration", "value": "3s"}}`,
+		`{"count": 1e2, "ratio": "-Infinity", "avatar": "aGk=", "userId": "-9223372036854775808"}`,
+	}
+	for _, json := range valid {
+		result, err := registry.ValidateProtoJSON(mustParse(t, json), "test.v1.User", ProtoJSONOptions{})
+		if err != nil || !result.Valid {
+			t.Errorf("%s 应该有效: %v %v", json, err, result.Errors)
+		}
+	}
+
+	invalid := []struct {
+		json, path, keyword string
+	}{
+		{`[]`, "$", "type"},
+		{`{"nickname": "x"}`, "$.nickname", "unknownField"},
+		{`{"userId": 1, "user_id": 2}`, "$.user_id", "duplicateField"},
+		{`{"userId": 1.5}`, "$.userId", "range"},
+		{`{"userId": 1e19}`, "$.userId", "range"},
+		{`{"userId": "9223372036854775808"}`, "$.userId", "range"},
+		{`{"userId": "12abc"}`, "$.userId", "type"},
+		{`{"userId": true}`, "$.userId", "type"},
+		{`{"count": -1}`, "$.count", "range"},
+		{`{"name": 1}`, "$.name", "type"},
+		{`{"tags": "x"}`, "$.tags", "type"},
+		{`{"tags": ["x", null]}`, "$.tags[1]", "type"},
+		{`{"status": "DELETED"}`, "$.status", "enum"},
+		{`{"status": 1.5}`, "$.status", "enum"},
+		{`{"scores": {"a": "x"}}`, "$.scores.a", "type"},
+		{`{"byFlag": {"yes": {}}}`, "$.byFlag.yes", "mapKey"},
+		{`{"email": "a", "phone": "b"}`, "$.phone", "oneof"},
+		{`{"createdAt": "2024-01-02"}`, "$.createdAt", "format"},
+		{`{"address": {"town": "x"}}`, "$.address.town", "unknownField"},
+		{`{"avatar": "!!"}`, "$.avatar", "format"},
+		{`{"ratio": 1e39}`, "$.ratio", "range"},
+		{`{"ratio": "fast"}`, "$.ratio", "type"},
+		{`{"big": {"value": 1}}`, "$.big", "type"},
+		{`{"ttl": "1.5"}`, "$.ttl", "format"},
+		{`{"ttl": "315576000001s"}`, "$.ttl", "range"},
+		{`{"extra": {"city": "x"}}`, "$.extra", "anyType"},
+		{`{"extra": {"@type": "x/test.v1.Missing"}}`, "$.extra.@type", "anyType"},
+		{`{"extra": {"@type": "x/test.v1.Address", "town": "x"}}`, "$.extra.town", "unknownField"},
+		{`{"extra": {"@type": "x/google.protobuf.Timestamp", "value": "yesterday"}}`, "$.extra.value", "format"},
+	}
+	for _, tt := range invalid {
+		result, err := registry.ValidateProtoJSON(mustParse(t, tt.json), "test.v1.User", ProtoJSONOptions{})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.json, err)
+		}
+		if result.Valid || len(result.Issues) != 1 {
+			t.Errorf("%s 应该有一个错误，实际是 %v", tt.json, result.Errors)
+			continue
+		}
+		if issue := result.Issues[0]; issue.Path != tt.path || issue.Keyword != tt.keyword {
+			t.Errorf("%s 的错误 = %s %s, 期望 %s %s", tt.json, issue.Path, issue.Keyword, tt.path, tt.keyword)
+		}
+	}
+}
+
+func TestValidateProtoJSONOptions(t *testing.T) {
+	registry := protoTestRegistry(t)
+	doc := mustParse(t, `{"nickname": "x", "address": {"town": "y"}}`)
+	result, _ := registry.ValidateProtoJSON(doc, "test.v1.User", ProtoJSONOptions{IgnoreUnknownFields: true})
+	if !result.Valid {
+		t.Errorf("忽略未知字段时应该有效: %v", result.Errors)
+	}
+	result, _ = registry.ValidateProtoJSON(doc, "test.v1.User", ProtoJSONOptions{})
+	if result.Valid || len(result.Errors) != 2 || !strings.Contains(result.Errors[0], "nickname") {
+		t.Errorf("未知字段的错误 = %v", result.Errors)
+	}
+
+	result, _ = registry.ValidateProtoJSON(mustParse(t, `{"tags": {}}`), "test.v1.User", ProtoJSONOptions{})
+	if len(result.Errors) != 1 || result.Errors[0] != "路径 $.tags: repeated 字段 tags 应该是数组，实际是 object" {
+		t.Errorf("类型错误的信息 = %v", result.Errors)
+	}
+
+	if _, err := registry.ValidateProtoJSON(doc, "test.v1.Missing", ProtoJSONOptions{}); err == nil {
+		t.Errorf("不存在的消息类型应该返回错误")
+	}
+	// 知名类型可以直接作为顶层消息
+	result, err := registry.ValidateProtoJSON(mustParse(t, `"2024-01-02T03:04:05+08:00"`), "google.protobuf.Timestamp", ProtoJSONOptions{})
+	if err != nil || !result.Valid {
+		t.Errorf("Timestamp 应该有效: %v %v", err, result.Errors)
+	}
+}
+
+func TestParseProtoInteger(t *testing.T) {
+	tests := []struct {
+		t          ProtoFieldType
+		s          string
+		ok, excess bool
+	}{
+		{PROTO_UINT64, "18446744073709551615", true, false},
+		{PROTO_UINT64, "18446744073709551616", false, true},
+		{PROTO_UINT64, "-1", false, true},
+		{PROTO_INT32, "2147483648", false, true},
+		{PROTO_SINT32, "-2147483648", true, false},
+		{PROTO_INT32, "1.0e3", true, false},
+		{PROTO_INT32, " 1", false, false},
+		{PROTO_INT64, "01", false, false},
+	}
+	for _, tt := range tests {
+		_, excess, err := parseProtoInteger(tt.t, tt.s)
+		if (err == nil) != tt.ok || excess != tt.excess {
+			t.Errorf("parseProtoInteger(%s, %q) = %v, %v", tt.t, tt.s, excess, err)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+// query_cache.go - 缓存重复的 JSONPath、JSON Pointer 和 Schema 查询结果
+package leptjson
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+)
+
+// 缓存条目的查询种类
+const (
+	cacheKindPath = iota
+	cacheKindPointer
+	cacheKindSchema
+)
+
+// queryCacheKey 标识一次查询：文档（Document 和版本号，或内容哈希）加上查询本身
+type queryCacheKey struct {
+	doc     *Document
+	version uint64
+	hash    [sha256.Size]byte // 没有 Document 时使用文档内容的哈希
+	kind    int
+	query   string // JSONPath 表达式、JSON Pointer 或 schema 内容的哈希
+}
+
+type queryCacheEntry struct {
+	key        queryCacheKey
+	values     []*Value
+	validation ValidationResult
+}
+
+// QueryCache 是查询结果的 LRU 缓存，可以被多个 goroutine 同时使用
+//
+// 缓存的键由文档和查询组成。普通的 *Value 按内容的哈希识别，每次查询都要
+// 序列化整个文档；Document 按自身和版本号识别，不需要哈希，文档被修改后
+// 旧的条目立即失效。缓存保存结果的深拷贝，每次命中也返回新的深拷贝。
+type QueryCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[queryCacheKey]*list.Element
+	order    *list.List // 最近使用的在前面
+	hits     uint64
+	misses   uint64
+}
+
+// NewQueryCache 创建最多保存 capacity 个结果的缓存
+func NewQueryCache(capacity int) *QueryCache {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &QueryCache{
+		capacity: capacity,
+		entries:  make(map[queryCacheKey]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Len 返回缓存中的条目数量
+func (c *QueryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Stats 返回命中和未命中的次数
+func (c *QueryCache) Stats() (hits, misses uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+// Clear 删除所有条目
+func (c *QueryCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[queryCacheKey]*list.Element)
+	c.order.Init()
+}
+
+// Query 使用 JSONPath 查询 doc，结果按 doc 的内容和表达式缓存
+func (c *QueryCache) Query(doc *Value, path string) ([]*Value, error) {
+	return c.query(queryCacheKey{hash: contentHash(doc), kind: cacheKindPath, query: path}, doc)
+}
+
+// Get 返回 JSON 指针所指值的深拷贝，结果按 doc 的内容和指针缓存
+func (c *QueryCache) Get(doc *Value, pointer string) (*Value, error) {
+	return c.get(queryCacheKey{hash: contentHash(doc), kind: cacheKindPointer, query: pointer}, doc)
+}
+
+// Validate 使用 schema 验证 doc，结果按 doc 和 schema 的内容缓存
+func (c *QueryCache) Validate(schema, doc *Value) ValidationResult {
+	return c.validate(queryCacheKey{hash: contentHash(doc), kind: cacheKindSchema, query: schemaCacheKey(schema)}, schema, doc)
+}
+
+// query 返回缓存的 JSONPath 结果，没有时查询 doc 并缓存
+func (c *QueryCache) query(key queryCacheKey, doc *Value) ([]*Value, error) {
+	if entry, ok := c.lookup(key); ok {
+		return copyValues(entry.values), nil
+	}
+	jp, err := NewJSONPath(key.query)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := jp.Query(doc)
+	if err != nil {
+		return nil, err
+	}
+	values := copyValues(matches)
+	c.store(&queryCacheEntry{key: key, values: values})
+	return copyValues(values), nil
+}
+
+// get 返回缓存的 JSON Pointer 结果，没有时查询 doc 并缓存
+func (c *QueryCache) get(key queryCacheKey, doc *Value) (*Value, error) {
+	if entry, ok := c.lookup(key); ok {
+		return copyValues(entry.values)[0], nil
+	}
+	p, err := ParseJSONPointer(key.query)
+	if err != POINTER_OK {
+		return nil, err
+	}
+	value, err := p.Get(doc)
+	if err != POINTER_OK {
+		return nil, err
+	}
+	values := copyValues([]*Value{value})
+	c.store(&queryCacheEntry{key: key, values: values})
+	return copyValues(values)[0], nil
+}
+
+// validate 返回缓存的验证结果，没有时验证 doc 并缓存
+func (c *QueryCache) validate(key queryCacheKey, schema, doc *Value) ValidationResult {
+	if entry, ok := c.lookup(key); ok {
+		return copyValidationResult(entry.validation)
+	}
+	result := validateWithSchema(schema, doc)
+	c.store(&queryCacheEntry{key: key, validation: copyValidationResult(result)})
+	return result
+}
+
+// lookup 查找条目并将其标记为最近使用
+func (c *QueryCache) lookup(key queryCacheKey) (*queryCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.order.MoveToFront(elem)
+	return elem.Value.(*queryCacheEntry), true
+}
+
+// store 添加条目，超出容量时淘汰最久未使用的条目
+func (c *QueryCache) store(entry *queryCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[entry.key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[entry.key] = c.order.PushFront(entry)
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*queryCacheEntry).key)
+	}
+}
+
+// dropDocument 删除文档 d 的所有条目
+func (c *QueryCache) dropDocument(d *Document) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for elem := c.order.Front(); elem != nil; {
+		next := elem.Next()
+		if entry := elem.Value.(*queryCacheEntry); entry.key.doc == d {
+			c.order.Remove(elem)
+			delete(c.entries, entry.key)
+		}
+		elem = next
+	}
+}
+
+// contentHash 返回文档内容的哈希
+func contentHash(v *Value) [sha256.Size]byte {
+	s, _ := Stringify(v)
+	return sha256.Sum256([]byte(s))
+}
+
+// schemaCacheKey 返回 schema 内容的哈希，用作查询字符串
+func schemaCacheKey(schema *Value) string {
+	hash := contentHash(schema)
+	return string(hash[:])
+}
+
+// copyValues 返回每个值的深拷贝
+func copyValues(values []*Value) []*Value {
+	result := make([]*Value, len(values))
+	for i, v := range values {
+		result[i] = &Value{}
+		Copy(result[i], v)
+	}
+	return result
+}
+
+// copyValidationResult 复制验证结果中的切片，使调用者的修改不影响缓存
+func copyValidationResult(result ValidationResult) ValidationResult {
+	result.Errors = append([]string(nil), result.Errors...)
+	result.Issues = append([]ValidationIssue(nil), result.Issues...)
+	return result
+}
@@ -0,0 +1,122 @@
+package leptjson
+
+import (
+	"testing"
+)
+
+func TestQueryCacheValues(t *testing.T) {
+	cache := NewQueryCache(10)
+	doc := &Value{}
+	Parse(doc, `{"items":[{"price":1},{"price":2}]}`)
+
+	first, err := cache.Query(doc, "$..price")
+	if err != nil || len(first) != 2 {
+		t.Fatalf("查询结果 = %v, %v", first, err)
+	}
+	// 修改返回的结果不影响缓存
+	first[0].N = 100
+	second, _ := cache.Query(doc, "$..price")
+	if second[0].N != 1 {
+		t.Errorf("缓存的结果被调用者修改了: %v", second[0].N)
+	}
+	if hits, misses := cache.Stats(); hits != 1 || misses != 1 {
+		t.Errorf("命中 %d 次, 未命中 %d 次", hits, misses)
+	}
+
+	// 内容相同的另一个文档也会命中
+	same := &Value{}
+	Parse(same, `{"items":[{"price":1},{"price":2}]}`)
+	cache.Query(same, "$..price")
+	// 内容不同时不会命中
+	doc.O[0].V.A[0].O[0].V.N = 5
+	changed, _ := cache.Query(doc, "$..price")
+	if changed[0].N != 5 {
+		t.Errorf("文档内容变化后返回了旧结果: %v", changed[0].N)
+	}
+	if hits, misses := cache.Stats(); hits != 2 || misses != 2 {
+		t.Errorf("命中 %d 次, 未命中 %d 次", hits, misses)
+	}
+
+	if v, err := cache.Get(doc, "/items/1/price"); err != nil || v.N != 2 {
+		t.Errorf("Get = %v, %v", v, err)
+	}
+	if _, err := cache.Get(doc, "/missing"); err == nil {
+		t.Error("不存在的路径应当返回错误")
+	}
+	if _, err := cache.Query(doc, "$[invalid"); err == nil {
+		t.Error("无效的表达式应当返回错误")
+	}
+
+	schema := &Value{}
+	Parse(schema, `{"required":["name"]}`)
+	result := cache.Validate(schema, doc)
+	result.Errors[0] = "changed"
+	if again := cache.Validate(schema, doc); again.Valid || again.Errors[0] == "changed" {
+		t.Errorf("验证结果 = %+v", again)
+	}
+}
+
+func TestQueryCacheEviction(t *testing.T) {
+	cache := NewQueryCache(2)
+	doc := &Value{}
+	Parse(doc, `{"a":1,"b":2,"c":3}`)
+
+	cache.Query(doc, "$.a")
+	cache.Query(doc, "$.b")
+	cache.Query(doc, "$.a") // a 成为最近使用的
+	cache.Query(doc, "$.c") // 淘汰 b
+	if cache.Len() != 2 {
+		t.Errorf("Len = %d", cache.Len())
+	}
+	cache.Query(doc, "$.a")
+	cache.Query(doc, "$.b")
+	if hits, misses := cache.Stats(); hits != 2 || misses != 4 {
+		t.Errorf("命中 %d 次, 未命中 %d 次", hits, misses)
+	}
+
+	cache.Clear()
+	if cache.Len() != 0 {
+		t.Errorf("Clear 后 Len = %d", cache.Len())
+	}
+}
+
+func TestDocumentQueryCache(t *testing.T) {
+	doc, _ := ParseDocument(`{"users":[{"name":"a"}]}`)
+	cache := NewQueryCache(10)
+	doc.SetQueryCache(cache)
+
+	doc.Query("$.users[*].name")
+	doc.Get("/users/0")
+	doc.Query("$.users[*].name")
+	if hits, _ := cache.Stats(); hits != 1 || cache.Len() != 2 {
+		t.Errorf("命中 %d 次, %d 个条目", hits, cache.Len())
+	}
+
+	// 修改后文档的条目被删除，查询返回新结果
+	name := &Value{}
+	SetString(name, "b")
+	if err := doc.Set("/users/-", &Value{Type: OBJECT, O: []Member{{K: "name", V: name}}}); err != nil {
+		t.Fatal(err)
+	}
+	if cache.Len() != 0 {
+		t.Errorf("修改后还有 %d 个条目", cache.Len())
+	}
+	names, _ := doc.Query("$.users[*].name")
+	if len(names) != 2 {
+		t.Errorf("修改后的查询结果 = %v", names)
+	}
+
+	schema := &Value{}
+	Parse(schema, `{"properties":{"users":{"maxItems":1}}}`)
+	if doc.Validate(schema).Valid || doc.Validate(schema).Valid {
+		t.Error("验证应当失败")
+	}
+	if hits, _ := cache.Stats(); hits != 2 {
+		t.Errorf("命中 %d 次", hits)
+	}
+
+	doc.SetQueryCache(nil)
+	if cache.Len() != 0 {
+		t.Errorf("取消缓存后还有 %d 个条目", cache.Len())
+	}
+}
@@ -0,0 +1,122 @@
+// reader_limits.go - 限制读取大小和速度的 io.Reader 包装
+package leptjson
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// MaxBytesError 表示输入超过了 MaxBytesReader 的大小限制
+type MaxBytesError struct {
+	Limit int64 // 允许读取的最大字节数
+}
+
+func (e *MaxBytesError) Error() string {
+	return fmt.Sprintf("输入超过大小限制: 最多%d字节", e.Limit)
+}
+
+// MaxBytesReader 返回最多从 r 读取 n 字节的 Reader
+//
+// 与 io.LimitReader 不同，输入超过 n 字节时返回 *MaxBytesError 而不是 io.EOF，
+// 因此调用者不需要先读完再比较长度，超出部分也不会被缓冲。
+func MaxBytesReader(r io.Reader, n int64) io.Reader {
+	return &maxBytesReader{r: r, remaining: n, limit: n}
+}
+
+type maxBytesReader struct {
+	r         io.Reader
+	remaining int64
+	limit     int64
+	err       error
+}
+
+func (m *maxBytesReader) Read(p []byte) (int, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	// 多读一个字节以判断是否超过限制
+	if int64(len(p)) > m.remaining+1 {
+		p = p[:m.remaining+1]
+	}
+	n, err := m.r.Read(p)
+	if int64(n) <= m.remaining {
+		m.remaining -= int64(n)
+		m.err = err
+		return n, err
+	}
+	n = int(m.remaining)
+	m.remaining = 0
+	m.err = &MaxBytesError{Limit: m.limit}
+	return n, m.err
+}
+
+// ThrottledReader 返回每秒最多从 r 读取 bytesPerSecond 字节的 Reader
+//
+// 读取速度按开始读取以来的平均值计算，超过时 Read 会等待。单次 Read 最多
+// 返回一秒的配额，避免较大的缓冲区造成突发。bytesPerSecond 不大于 0 时不限速。
+func ThrottledReader(r io.Reader, bytesPerSecond int64) io.Reader {
+	if bytesPerSecond <= 0 {
+		return r
+	}
+	return &throttledReader{r: r, rate: bytesPerSecond, now: time.Now, sleep: time.Sleep}
+}
+
+type throttledReader struct {
+	r     io.Reader
+	rate  int64
+	start time.Time
+	read  int64
+
+	// 便于测试替换
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	if t.start.IsZero() {
+		t.start = t.now()
+	}
+	if int64(len(p)) > t.rate {
+		p = p[:t.rate]
+	}
+	n, err := t.r.Read(p)
+	t.read += int64(n)
+
+	// 按已读字节数计算至少应当经过的时间
+	expected := time.Duration(float64(t.read) / float64(t.rate) * float64(time.Second))
+	if wait := expected - t.now().Sub(t.start); wait > 0 {
+		t.sleep(wait)
+	}
+	return n, err
+}
+
+// LimitRequestBody 返回限制请求体大小和读取速度的 HTTP 中间件
+//
+// 请求体超过 maxBytes 时读取返回 *MaxBytesError，bytesPerSecond 限制读取速度。
+// 两者不大于 0 时分别不做限制。
+func LimitRequestBody(next http.Handler, maxBytes, bytesPerSecond int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body io.Reader = r.Body
+		if maxBytes > 0 {
+			body = MaxBytesReader(body, maxBytes)
+		}
+		body = ThrottledReader(body, bytesPerSecond)
+		if body != io.Reader(r.Body) {
+			r2 := *r
+			r2.Body = readCloser{Reader: body, Closer: r.Body}
+			r = &r2
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// readCloser 组合包装后的 Reader 和原来的 Closer
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
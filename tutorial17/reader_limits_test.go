@@ -0,0 +1,91 @@
+package leptjson
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// endlessReader 无限产生同一个字节，并记录被读取的字节数
+type endlessReader struct {
+	read int64
+}
+
+func (r *endlessReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = ' '
+	}
+	r.read += int64(len(p))
+	return len(p), nil
+}
+
+func TestMaxBytesReader(t *testing.T) {
+	data, err := io.ReadAll(MaxBytesReader(strings.NewReader("12345"), 5))
+	if err != nil || string(data) != "12345" {
+		t.Errorf("恰好达到限制: %q, %v", data, err)
+	}
+
+	// 逐字节读取时也要在第一个超出的字节处报错
+	data, err = io.ReadAll(MaxBytesReader(iotest.OneByteReader(strings.NewReader("123456")), 5))
+	var tooLarge *MaxBytesError
+	if !errors.As(err, &tooLarge) || tooLarge.Limit != 5 || string(data) != "12345" {
+		t.Errorf("超过限制: %q, %v", data, err)
+	}
+
+	// 超过限制后只多读一个字节
+	source := &endlessReader{}
+	if _, err := io.ReadAll(MaxBytesReader(source, 100)); !errors.As(err, &tooLarge) {
+		t.Errorf("无限输入 error = %v", err)
+	}
+	if source.read != 101 {
+		t.Errorf("读取了 %d 字节, 期望 101", source.read)
+	}
+}
+
+func TestThrottledReader(t *testing.T) {
+	now := time.Unix(0, 0)
+	var slept time.Duration
+	r := ThrottledReader(strings.NewReader(strings.Repeat("x", 250)), 100).(*throttledReader)
+	r.now = func() time.Time { return now.Add(slept) }
+	r.sleep = func(d time.Duration) { slept += d }
+
+	buf := make([]byte, 1000)
+	n, _ := r.Read(buf)
+	if n != 100 {
+		t.Errorf("单次读取 %d 字节, 期望不超过每秒配额 100", n)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil || len(data) != 150 {
+		t.Fatalf("读取剩余数据: %d, %v", len(data), err)
+	}
+	if slept != 2500*time.Millisecond {
+		t.Errorf("等待时间 = %v, 期望 2.5s", slept)
+	}
+
+	if plain := strings.NewReader("x"); ThrottledReader(plain, 0) != io.Reader(plain) {
+		t.Errorf("速度为0时应当返回原来的 Reader")
+	}
+}
+
+func TestLimitRequestBody(t *testing.T) {
+	var readErr error
+	handler := LimitRequestBody(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = io.ReadAll(r.Body)
+		r.Body.Close()
+	}), 16, 0)
+
+	source := &endlessReader{}
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", source))
+	var tooLarge *MaxBytesError
+	if !errors.As(readErr, &tooLarge) || tooLarge.Limit != 16 {
+		t.Errorf("error = %v", readErr)
+	}
+	if source.read > 17 {
+		t.Errorf("限制应在缓冲之前生效，实际读取了 %d 字节", source.read)
+	}
+}
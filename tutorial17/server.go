@@ -0,0 +1,361 @@
+// server.go - leptjson serve 使用的HTTP服务
+package leptjson
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServerOptions 是HTTP服务的配置
+type ServerOptions struct {
+	// ParseOptions 用于解析所有请求体，默认启用安全限制
+	ParseOptions ParseOptions
+	// MaxBodyBytes 是请求体的最大字节数，为0时使用 ParseOptions.MaxTotalSize
+	MaxBodyBytes int64
+	// ReadBytesPerSecond 限制每个请求体的读取速度，为0时不限速
+	ReadBytesPerSecond int64
+	// Metrics 记录请求体的解析，通过 GET /metrics 导出，为 nil 时自动创建
+	Metrics *Metrics
+	// EvalBudget 限制每个 /validate 和 /query 请求的求值时间和步数
+	EvalBudget EvalBudget
+	// Document 是 /ws 端点实时同步的文档，为 nil 时不提供 /ws
+	Document *Document
+	// DocumentSchema 验证客户端通过 /ws 修改后的文档，为 nil 时不验证
+	DocumentSchema *Value
+	// AllowedOrigins 是除同源页面外允许连接 /ws 的来源，如 "https://app.example.com"，
+	// "*" 允许任何来源；浏览器发起的跨源握手默认被拒绝，防止其他网站的页面修改文档
+	AllowedOrigins []string
+}
+
+// DefaultServerOptions 返回默认的服务配置
+func DefaultServerOptions() ServerOptions {
+	return ServerOptions{
+		ParseOptions: DefaultParseOptions(),
+		EvalBudget:   EvalBudget{Timeout: 5 * time.Second, MaxSteps: 1000000},
+	}
+}
+
+// Server 通过HTTP提供验证、格式化、补丁和查询功能
+//
+// 除 /metrics 外，所有端点只接受 POST 请求，请求体和响应体都是JSON：
+//
+//	POST /validate  {"schema": {...}, "data": ...}         -> {"valid": ..., "errors": [...]}
+//	POST /format    任意JSON文档，?indent=N&sort-keys=NAME -> 格式化后的文档
+//	POST /patch     {"patch": [...], "document": ...}      -> 应用补丁后的文档
+//	POST /query     {"path": "$..x", "document": ...}      -> {"results": [...]}
+//
+// /validate 的查询参数 lang 选择错误描述的语言。出错时返回 {"error": "..."}，
+// 请求体超过大小限制时状态码为 413，验证或查询超出 EvalBudget 时状态码为 422。GET /metrics 以 Prometheus 文本格式
+// 返回请求体的解析指标。
+//
+// 设置了 ServerOptions.Document 时，GET /ws 升级为 WebSocket 连接，消息都是JSON文本：
+// 服务先发送 {"type": "snapshot", "version": N, "document": ...}，之后文档每次被修改（无论来自哪个客户端
+// 还是服务端代码）都发送 {"type": "patch", "version": N, "patch": [...]}；客户端发送
+// {"type": "patch", "patch": [...]} 修改文档，修改后的文档不符合 DocumentSchema 时被拒绝。
+type Server struct {
+	options ServerOptions
+	mux     *http.ServeMux
+	handler http.Handler
+	metrics *Metrics
+	sync    *documentSync // 为 nil 时没有 /ws
+}
+
+// NewServer 创建HTTP服务
+func NewServer(options ServerOptions) *Server {
+	s := &Server{options: options, mux: http.NewServeMux(), metrics: options.Metrics}
+	if s.metrics == nil {
+		s.metrics = NewMetrics()
+	}
+	s.mux.HandleFunc("/validate", s.handleValidate)
+	s.mux.HandleFunc("/format", s.handleFormat)
+	s.mux.HandleFunc("/patch", s.handlePatch)
+	s.mux.HandleFunc("/query", s.handleQuery)
+	s.mux.HandleFunc("/metrics", s.handleMetrics)
+	if options.Document != nil {
+		s.sync = newDocumentSync(s, options.Document)
+		s.mux.HandleFunc("/ws", s.handleWebSocket)
+	}
+	s.handler = LimitRequestBody(s.mux, s.maxBodyBytes(), options.ReadBytesPerSecond)
+	return s
+}
+
+// ServeHTTP 实现 http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// Close 断开所有 /ws 连接并停止监听文档的修改，不影响其他端点
+func (s *Server) Close() {
+	if s.sync != nil {
+		s.sync.close()
+	}
+}
+
+// handleWebSocket 把连接升级为 WebSocket 并同步文档，消息的大小限制与请求体相同
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	if !websocketOriginAllowed(r, s.options.AllowedOrigins) {
+		writeServerError(w, http.StatusForbidden, fmt.Sprintf("不允许来自 %s 的WebSocket连接", r.Header.Get("Origin")))
+		return
+	}
+	conn, err := upgradeWebSocket(w, r, s.maxBodyBytes())
+	if err != nil {
+		return
+	}
+	s.sync.serve(conn)
+}
+
+// maxBodyBytes 返回请求体的大小限制，为0表示不限制
+func (s *Server) maxBodyBytes() int64 {
+	if s.options.MaxBodyBytes > 0 {
+		return s.options.MaxBodyBytes
+	}
+	if s.options.ParseOptions.EnabledSecurity {
+		return int64(s.options.ParseOptions.MaxTotalSize)
+	}
+	return 0
+}
+
+// readBody 检查请求方法并按服务的解析选项解析请求体
+func (s *Server) readBody(w http.ResponseWriter, r *http.Request) (*Value, bool) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeServerError(w, http.StatusMethodNotAllowed, "只支持POST请求")
+		return nil, false
+	}
+
+	// 大小限制由 LimitRequestBody 在读取时检查
+	data, err := io.ReadAll(r.Body)
+	var tooLarge *MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeServerError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("请求体超过大小限制: 最多%d字节", tooLarge.Limit))
+		return nil, false
+	}
+	if err != nil {
+		writeServerError(w, http.StatusBadRequest, fmt.Sprintf("读取请求体失败: %v", err))
+		return nil, false
+	}
+
+	v := &Value{}
+	start := time.Now()
+	parseErr := ParseWithOptionsContext(r.Context(), v, string(data), s.options.ParseOptions)
+	s.metrics.ObserveParse(len(data), time.Since(start), parseErr)
+	if parseErr != PARSE_OK {
+		writeServerError(w, http.StatusBadRequest, fmt.Sprintf("解析请求体失败: %s", parseErr))
+		return nil, false
+	}
+	return v, true
+}
+
+// handleMetrics 导出解析指标
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeServerError(w, http.StatusMethodNotAllowed, "只支持GET请求")
+		return
+	}
+	s.metrics.ServeHTTP(w, r)
+}
+
+// requireMember 返回请求对象中的必需成员
+func requireMember(w http.ResponseWriter, body *Value, key string) (*Value, bool) {
+	if body.Type != OBJECT {
+		writeServerError(w, http.StatusBadRequest, "请求体必须是对象")
+		return nil, false
+	}
+	member, ok := FindObjectKey(body, key)
+	if !ok {
+		writeServerError(w, http.StatusBadRequest, fmt.Sprintf("请求体缺少 '%s' 字段", key))
+		return nil, false
+	}
+	return member, true
+}
+
+// handleValidate 使用请求中的 schema 验证 data
+func (s *Server) handleValidate(w http.ResponseWriter, r *http.Request) {
+	body, ok := s.readBody(w, r)
+	if !ok {
+		return
+	}
+	schema, ok := requireMember(w, body, "schema")
+	if !ok {
+		return
+	}
+	data, ok := requireMember(w, body, "data")
+	if !ok {
+		return
+	}
+
+	lang := r.URL.Query().Get("lang")
+	if lang == "" {
+		lang = DefaultValidationLanguage
+	}
+	translator, ok := LookupValidationTranslator(lang)
+	if !ok {
+		writeServerError(w, http.StatusBadRequest, fmt.Sprintf("未知的语言: %s", lang))
+		return
+	}
+
+	_, span := startSpan(r.Context(), "leptjson.validate")
+	budget, cancel := newEvalBudget(r.Context(), "Schema 验证", s.options.EvalBudget)
+	defer cancel()
+	sctx := newSchemaContext(translator, newRefResolver(schema, "", nil))
+	sctx.budget = budget
+	result := validateWithContext(schema, data, sctx)
+	if span != nil {
+		endValidateSpan(span, result, budget.failed())
+	}
+	if err := budget.failed(); err != nil {
+		writeServerError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	out, err := Marshal(result)
+	if err != nil {
+		writeServerError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeServerJSON(w, http.StatusOK, out)
+}
+
+// handleFormat 格式化请求体中的文档
+func (s *Server) handleFormat(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	indent := 2
+	if text := query.Get("indent"); text != "" {
+		n, err := strconv.Atoi(text)
+		if err != nil || n < 0 || n > 16 {
+			writeServerError(w, http.StatusBadRequest, fmt.Sprintf("无效的缩进值: %s", text))
+			return
+		}
+		indent = n
+	}
+
+	var cmp KeyComparator
+	if name := query.Get("sort-keys"); name != "" {
+		var ok bool
+		if cmp, ok = LookupKeyComparator(name); !ok {
+			writeServerError(w, http.StatusBadRequest, fmt.Sprintf("未注册的键排序规则: %s", name))
+			return
+		}
+	}
+
+	doc, ok := s.readBody(w, r)
+	if !ok {
+		return
+	}
+	formatted, err := formatJSONWithComparator(doc, strings.Repeat(" ", indent), cmp)
+	if err != nil {
+		writeServerError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeServerJSON(w, http.StatusOK, formatted)
+}
+
+// handlePatch 将请求中的补丁应用到文档
+func (s *Server) handlePatch(w http.ResponseWriter, r *http.Request) {
+	body, ok := s.readBody(w, r)
+	if !ok {
+		return
+	}
+	patchDoc, ok := requireMember(w, body, "patch")
+	if !ok {
+		return
+	}
+	doc, ok := requireMember(w, body, "document")
+	if !ok {
+		return
+	}
+
+	operations, err := parsePatch(patchDoc)
+	if err != nil {
+		writeServerError(w, http.StatusBadRequest, fmt.Sprintf("解析补丁失败: %v", err))
+		return
+	}
+	_, span := startSpan(r.Context(), "leptjson.patch")
+	err = applyPatch(doc, operations, false)
+	if span != nil {
+		endPatchSpan(span, len(operations), err)
+	}
+	if err != nil {
+		// 补丁无法应用于文档属于语义错误
+		writeServerError(w, http.StatusUnprocessableEntity, fmt.Sprintf("应用补丁失败: %v", err))
+		return
+	}
+	writeServerValue(w, doc)
+}
+
+// handleQuery 使用 JSONPath 查询文档
+func (s *Server) handleQuery(w http.ResponseWriter, r *http.Request) {
+	body, ok := s.readBody(w, r)
+	if !ok {
+		return
+	}
+	pathValue, ok := requireMember(w, body, "path")
+	if !ok {
+		return
+	}
+	if pathValue.Type != STRING {
+		writeServerError(w, http.StatusBadRequest, "'path' 字段必须是字符串")
+		return
+	}
+	doc, ok := requireMember(w, body, "document")
+	if !ok {
+		return
+	}
+
+	path, err := NewJSONPath(GetString(pathValue))
+	if err != nil {
+		writeServerError(w, http.StatusBadRequest, fmt.Sprintf("无效的JSONPath表达式: %v", err))
+		return
+	}
+	results, err := path.QueryWithBudget(r.Context(), doc, s.options.EvalBudget)
+	var budgetErr *BudgetError
+	if errors.As(err, &budgetErr) {
+		writeServerError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if err != nil {
+		writeServerError(w, http.StatusBadRequest, fmt.Sprintf("查询失败: %v", err))
+		return
+	}
+
+	resultArray := &Value{}
+	SetArray(resultArray, len(results))
+	for _, result := range results {
+		Copy(PushBackArrayElement(resultArray), result)
+	}
+	response := &Value{}
+	SetObject(response)
+	Move(SetObjectValue(response, "results"), resultArray)
+	writeServerValue(w, response)
+}
+
+// writeServerValue 序列化并写入响应
+func writeServerValue(w http.ResponseWriter, v *Value) {
+	out, err := Stringify(v)
+	if err != STRINGIFY_OK {
+		writeServerError(w, http.StatusInternalServerError, fmt.Sprintf("序列化失败: %v", err))
+		return
+	}
+	writeServerJSON(w, http.StatusOK, out)
+}
+
+// writeServerError 写入 {"error": "..."} 格式的错误响应
+func writeServerError(w http.ResponseWriter, status int, message string) {
+	writeServerJSON(w, status, `{"error":`+formatJSONString(message)+`}`)
+}
+
+// writeServerJSON 写入JSON响应
+func writeServerJSON(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	io.WriteString(w, body)
+	if !strings.HasSuffix(body, "\n") {
+		io.WriteString(w, "\n")
+	}
+}
@@ -0,0 +1,85 @@
+package leptjson
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func serverRequest(t *testing.T, s *Server, method, target, body string) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("%s %s: Content-Type = %q", method, target, ct)
+	}
+	return rec.Code, strings.TrimSpace(rec.Body.String())
+}
+
+func TestServerEndpoints(t *testing.T) {
+	s := NewServer(DefaultServerOptions())
+
+	tests := []struct {
+		name     string
+		target   string
+		body     string
+		status   int
+		expected string
+	}{
+		{"validate ok", "/validate", `{"schema": {"type": "object", "required": ["id"]}, "data": {"id": 1}}`,
+			http.StatusOK, `{"valid":true}`},
+		{"validate fail", "/validate", `{"schema": {"type": "string"}, "data": 1}`,
+			http.StatusOK, `"valid":false`},
+		{"validate en", "/validate?lang=en", `{"schema": {"type": "string"}, "data": 1}`,
+			http.StatusOK, `expected 'string'`},
+		{"validate bad lang", "/validate?lang=xx", `{"schema": {}, "data": 1}`,
+			http.StatusBadRequest, `未知的语言`},
+		{"format", "/format?indent=1", `{"b":[1,2],"a":null}`,
+			http.StatusOK, "{\n \"b\": [\n  1,\n  2\n ],\n \"a\": null\n}"},
+		{"format sorted", "/format?indent=0&sort-keys=alpha", `{"b":1,"a":2}`,
+			http.StatusOK, "{\n\"a\": 2,\n\"b\": 1\n}"},
+		{"patch", "/patch", `{"patch": [{"op": "replace", "path": "/a", "value": 2}, {"op": "add", "path": "/b", "value": [true]}], "document": {"a": 1}}`,
+			http.StatusOK, `{"a":2,"b":[true]}`},
+		{"query", "/query", `{"path": "$..price", "document": {"items": [{"price": 1}, {"price": 2.5}]}}`,
+			http.StatusOK, `{"results":[1,2.5]}`},
+		{"invalid json", "/format", `{"a":`, http.StatusBadRequest, `"error":"解析请求体失败`},
+		{"missing member", "/query", `{"path": "$.a"}`, http.StatusBadRequest, `缺少 'document' 字段`},
+		{"not object", "/patch", `[1]`, http.StatusBadRequest, `请求体必须是对象`},
+		{"bad indent", "/format?indent=x", `{}`, http.StatusBadRequest, `无效的缩进值`},
+		{"bad path", "/query", `{"path": 1, "document": {}}`, http.StatusBadRequest, `必须是字符串`},
+		{"patch fails", "/patch", `{"patch": [{"op": "remove", "path": "/missing"}], "document": {}}`,
+			http.StatusUnprocessableEntity, `应用补丁失败`},
+	}
+	for _, tt := range tests {
+		status, body := serverRequest(t, s, http.MethodPost, tt.target, tt.body)
+		if status != tt.status || !strings.Contains(body, tt.expected) {
+			t.Errorf("%s: %d %s\n期望 %d 包含 %s", tt.name, status, body, tt.status, tt.expected)
+		}
+	}
+
+	status, _ := serverRequest(t, s, http.MethodGet, "/format", "")
+	if status != http.StatusMethodNotAllowed {
+		t.Errorf("GET 请求的状态码 = %d, 期望 405", status)
+	}
+}
+
+func TestServerSecurityLimits(t *testing.T) {
+	options := DefaultServerOptions()
+	options.MaxBodyBytes = 32
+	s := NewServer(options)
+
+	status, body := serverRequest(t, s, http.MethodPost, "/format", `{"data": "`+strings.Repeat("x", 40)+`"}`)
+	if status != http.StatusRequestEntityTooLarge {
+		t.Errorf("超过大小限制的状态码 = %d, 期望 413: %s", status, body)
+	}
+
+	options = DefaultServerOptions()
+	options.ParseOptions.MaxDepth = 2
+	s = NewServer(options)
+	status, body = serverRequest(t, s, http.MethodPost, "/format", `[[[1]]]`)
+	if status != http.StatusBadRequest || !strings.Contains(body, "error") {
+		t.Errorf("超过深度限制的请求应当被拒绝: %d %s", status, body)
+	}
+}
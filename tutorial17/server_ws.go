@@ -0,0 +1,231 @@
+// server_ws.go - /ws 端点：通过 WebSocket 实时同步文档
+package leptjson
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// wsSendBuffer 是每个客户端待发送消息的缓冲数量，缓冲满时断开这个客户端
+const wsSendBuffer = 64
+
+// documentSync 把文档的修改广播给所有 /ws 客户端，并应用客户端提交的补丁
+type documentSync struct {
+	server *Server
+	doc    *Document
+	cancel func() // 取消文档修改的监听
+
+	mu      sync.Mutex
+	clients map[*wsClient]bool
+	last    *Value // 最后一次广播时的文档内容，新客户端的快照与之后的补丁从这里衔接
+	version uint64 // last 的版本号
+	closed  bool
+}
+
+// wsClient 是一个已连接的客户端
+type wsClient struct {
+	conn *wsConn
+	send chan string
+}
+
+// newDocumentSync 开始监听文档的修改
+func newDocumentSync(s *Server, doc *Document) *documentSync {
+	ds := &documentSync{server: s, doc: doc, clients: make(map[*wsClient]bool)}
+	ds.last, ds.version = doc.snapshotWithVersion()
+	ds.cancel = doc.OnChange(func(ChangeEvent) { ds.broadcastChange() })
+	return ds
+}
+
+// broadcastChange 计算文档自上次广播以来的补丁并发送给所有客户端
+//
+// 监听器按版本顺序调用，但调用时文档可能已经被之后的写操作修改，
+// 所以补丁按当前内容计算，之后的监听器发现没有新版本时不再发送。
+func (ds *documentSync) broadcastChange() {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	current, version := ds.doc.snapshotWithVersion()
+	if ds.closed || version <= ds.version {
+		return
+	}
+	patch, err := CreatePatch(ds.last, current)
+	ds.last, ds.version = current, version
+	if err != nil || len(patch.Operations) == 0 {
+		return
+	}
+	text, err := patch.String()
+	if err != nil {
+		return
+	}
+	message := fmt.Sprintf(`{"type":"patch","version":%d,"patch":%s}`, version, text)
+	for client := range ds.clients {
+		ds.enqueueLocked(client, message)
+	}
+}
+
+// enqueueLocked 把消息放入客户端的发送缓冲，缓冲满时断开客户端，调用者必须持有 ds.mu
+func (ds *documentSync) enqueueLocked(client *wsClient, message string) {
+	if !ds.clients[client] {
+		return
+	}
+	select {
+	case client.send <- message:
+	default:
+		ds.removeLocked(client)
+	}
+}
+
+// removeLocked 移除客户端，它的发送goroutine随之关闭连接，调用者必须持有 ds.mu
+func (ds *documentSync) removeLocked(client *wsClient) {
+	if ds.clients[client] {
+		delete(ds.clients, client)
+		close(client.send)
+	}
+}
+
+// serve 处理一个客户端连接，直到连接断开
+func (ds *documentSync) serve(conn *wsConn) {
+	client := &wsClient{conn: conn, send: make(chan string, wsSendBuffer)}
+	ds.mu.Lock()
+	if ds.closed {
+		ds.mu.Unlock()
+		conn.writeClose(wsCloseNormal, "")
+		conn.Close()
+		return
+	}
+	snapshot, _ := Stringify(ds.last)
+	client.send <- fmt.Sprintf(`{"type":"snapshot","version":%d,"document":%s}`, ds.version, snapshot)
+	ds.clients[client] = true
+	ds.mu.Unlock()
+
+	go client.writeLoop()
+	for {
+		_, message, err := conn.readMessage()
+		if err != nil {
+			break
+		}
+		reply := ds.handleMessage(message)
+		ds.mu.Lock()
+		ds.enqueueLocked(client, reply)
+		ds.mu.Unlock()
+	}
+	ds.mu.Lock()
+	ds.removeLocked(client)
+	ds.mu.Unlock()
+}
+
+// writeLoop 发送缓冲中的消息，缓冲关闭后发送关闭帧并关闭连接
+func (c *wsClient) writeLoop() {
+	for message := range c.send {
+		if err := c.conn.writeMessage(message); err != nil {
+			// 关闭连接使读取结束，客户端随之被移除、缓冲被关闭
+			c.conn.Close()
+			for range c.send {
+			}
+			return
+		}
+	}
+	c.conn.writeClose(wsCloseNormal, "")
+	c.conn.Close()
+}
+
+// handleMessage 应用客户端提交的补丁，返回发给这个客户端的应答
+//
+// 客户端消息的格式为 {"type": "patch", "patch": [...], "version": N, "id": ...}，
+// version 可选，给出时只有文档仍是这个版本才应用；id 可选，原样放在应答中。
+// 成功时应答 {"type": "ack", "version": 新版本号}，失败时应答 {"type": "error", "message": "..."}。
+func (ds *documentSync) handleMessage(message []byte) string {
+	request := &Value{}
+	start := time.Now()
+	parseErr := ParseWithOptions(request, string(message), ds.server.options.ParseOptions)
+	ds.server.metrics.ObserveParse(len(message), time.Since(start), parseErr)
+	if parseErr != PARSE_OK {
+		return wsReply("error", nil, "message", fmt.Sprintf("解析消息失败: %s", parseErr))
+	}
+	var id *Value
+	if request.Type == OBJECT {
+		id = GetObjectValueByKey(request, "id")
+	}
+	if request.Type != OBJECT || GetObjectValueByKey(request, "type") == nil ||
+		GetString(GetObjectValueByKey(request, "type")) != "patch" {
+		return wsReply("error", id, "message", `消息必须是 {"type": "patch", "patch": [...]}`)
+	}
+	patchDoc := GetObjectValueByKey(request, "patch")
+	if patchDoc == nil {
+		return wsReply("error", id, "message", "缺少 'patch' 字段")
+	}
+	patch, err := NewJSONPatch(patchDoc)
+	if err != nil {
+		return wsReply("error", id, "message", fmt.Sprintf("解析补丁失败: %v", err))
+	}
+	var base *uint64
+	if v := GetObjectValueByKey(request, "version"); v != nil {
+		if v.Type != NUMBER || v.N < 0 || v.N != float64(uint64(v.N)) {
+			return wsReply("error", id, "message", "'version' 字段必须是非负整数")
+		}
+		n := uint64(v.N)
+		base = &n
+	}
+
+	version, err := ds.apply(patch, base)
+	if err != nil {
+		return wsReply("error", id, "message", err.Error())
+	}
+	return wsReply("ack", id, "version", version)
+}
+
+// apply 在文档上应用补丁并用 schema 验证结果，任何一步失败时文档保持不变，返回修改后的版本号
+func (ds *documentSync) apply(patch *JSONPatch, base *uint64) (uint64, error) {
+	schema := ds.server.options.DocumentSchema
+	var version uint64
+	err := ds.doc.Update(func(root *Value) error {
+		// Update 在写锁保护下调用，可以直接读取版本号
+		if base != nil && *base != ds.doc.version {
+			return fmt.Errorf("版本冲突: 文档已经是版本 %d，补丁基于版本 %d", ds.doc.version, *base)
+		}
+		if err := patch.Apply(root); err != nil {
+			return fmt.Errorf("应用补丁失败: %v", err)
+		}
+		if schema != nil {
+			result, err := ValidateWithOptions(schema, root, ValidationOptions{Budget: ds.server.options.EvalBudget})
+			if err != nil {
+				return err
+			}
+			if !result.Valid {
+				return fmt.Errorf("修改后的文档不符合 Schema: %s", strings.Join(result.Errors, "; "))
+			}
+		}
+		version = ds.doc.version + 1
+		return nil
+	})
+	return version, err
+}
+
+// wsReply 生成 {"type": kind, "id": id, key: value} 形式的应答，id 为 nil 时省略
+func wsReply(kind string, id *Value, key string, value interface{}) string {
+	var sb strings.Builder
+	sb.WriteString(`{"type":` + formatJSONString(kind))
+	if id != nil {
+		text, _ := Stringify(id)
+		sb.WriteString(`,"id":` + text)
+	}
+	switch value := value.(type) {
+	case string:
+		fmt.Fprintf(&sb, `,%s:%s}`, formatJSONString(key), formatJSONString(value))
+	default:
+		fmt.Fprintf(&sb, `,%s:%v}`, formatJSONString(key), value)
+	}
+	return sb.String()
+}
+
+// close 停止监听文档并断开所有客户端
+func (ds *documentSync) close() {
+	ds.cancel()
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	ds.closed = true
+	for client := range ds.clients {
+		ds.removeLocked(client)
+	}
+}
@@ -0,0 +1,288 @@
+package leptjson
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testWSClient 是测试用的最小 WebSocket 客户端
+type testWSClient struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func dialTestWS(t *testing.T, server *httptest.Server) *testWSClient {
+	t.Helper()
+	resp, c := handshakeTestWS(t, server, "")
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("握手失败: %d %v", resp.StatusCode, resp.Header)
+	}
+	return c
+}
+
+// handshakeTestWS 发送 WebSocket 握手请求，origin 不为空时带上 Origin 头
+func handshakeTestWS(t *testing.T, server *httptest.Server, origin string) (*http.Response, *testWSClient) {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	key := make([]byte, 16)
+	rand.Read(key)
+	encodedKey := base64.StdEncoding.EncodeToString(key)
+	request := "GET /ws HTTP/1.1\r\nHost: test\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: " + encodedKey + "\r\nSec-WebSocket-Version: 13\r\n"
+	if origin != "" {
+		request += "Origin: " + origin + "\r\n"
+	}
+	io.WriteString(conn, request+"\r\n")
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode == http.StatusSwitchingProtocols && resp.Header.Get("Sec-WebSocket-Accept") != websocketAccept(encodedKey) {
+		t.Fatalf("握手失败: %v", resp.Header)
+	}
+	return resp, &testWSClient{t: t, conn: conn, reader: reader}
+}
+
+// send 发送带掩码的文本帧，split 大于 0 时把消息分成两帧
+func (c *testWSClient) send(message string, split int) {
+	c.t.Helper()
+	frames := []string{message}
+	if split > 0 {
+		frames = []string{message[:split], message[split:]}
+	}
+	for i, frame := range frames {
+		opcode := byte(wsText)
+		if i > 0 {
+			opcode = wsContinuation
+		}
+		c.writeFrame(i == len(frames)-1, opcode, []byte(frame))
+	}
+}
+
+func (c *testWSClient) writeFrame(fin bool, opcode byte, payload []byte) {
+	header := []byte{opcode, 0x80}
+	if fin {
+		header[0] |= 0x80
+	}
+	if len(payload) <= 125 {
+		header[1] |= byte(len(payload))
+	} else {
+		header[1] |= 126
+		header = append(header, byte(len(payload)>>8), byte(len(payload)))
+	}
+	mask := []byte{1, 2, 3, 4}
+	masked := make([]byte, len(payload))
+	for i := range payload {
+		masked[i] = payload[i] ^ mask[i%4]
+	}
+	c.conn.Write(append(append(header, mask...), masked...))
+}
+
+// receive 读取服务端发送的下一帧，返回操作码和内容
+func (c *testWSClient) receive() (byte, string) {
+	c.t.Helper()
+	c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var header [2]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		c.t.Fatalf("读取帧失败: %v", err)
+	}
+	length := int(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		io.ReadFull(c.reader, ext[:])
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		io.ReadFull(c.reader, ext[:])
+		length = int(binary.BigEndian.Uint64(ext[:]))
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		c.t.Fatalf("读取帧失败: %v", err)
+	}
+	return header[0] & 0x0F, string(payload)
+}
+
+// expect 读取下一条消息并检查它包含 expected
+func (c *testWSClient) expect(expected string) {
+	c.t.Helper()
+	opcode, message := c.receive()
+	if opcode != wsText || !strings.Contains(message, expected) {
+		c.t.Fatalf("消息 = %d %s, 期望包含 %s", opcode, message, expected)
+	}
+}
+
+func newSyncServer(t *testing.T, doc *Document, schema string) *httptest.Server {
+	t.Helper()
+	options := DefaultServerOptions()
+	if schema != "" {
+		options.DocumentSchema = mustParse(t, schema)
+	}
+	return newSyncServerWithOptions(t, doc, options)
+}
+
+func newSyncServerWithOptions(t *testing.T, doc *Document, options ServerOptions) *httptest.Server {
+	t.Helper()
+	options.Document = doc
+	s := NewServer(options)
+	server := httptest.NewServer(s)
+	t.Cleanup(func() {
+		s.Close()
+		server.Close()
+	})
+	return server
+}
+
+func TestWebSocketAccept(t *testing.T) {
+	// RFC 6455 第 1.3 节的例子
+	if got := websocketAccept("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("websocketAccept = %s", got)
+	}
+}
+
+func TestServerDocumentSync(t *testing.T) {
+	doc, _ := ParseDocument(`{"theme": "light", "refresh": 30}`)
+	server := newSyncServer(t, doc, `{"properties": {"refresh": {"type": "integer", "minimum": 5}}}`)
+
+	a := dialTestWS(t, server)
+	a.expect(`{"type":"snapshot","version":0,"document":{"theme":"light","refresh":30}}`)
+	b := dialTestWS(t, server)
+	b.expect(`"type":"snapshot"`)
+
+	// a 的修改广播给所有客户端，a 还会收到应答
+	a.send(`{"type": "patch", "id": "r1", "patch": [{"op": "replace", "path": "/theme", "value": "dark"}]}`, 20)
+	patch := `{"type":"patch","version":1,"patch":[{"op":"replace","path":"/theme","value":"dark"}]}`
+	b.expect(patch)
+	a.expect(patch)
+	a.expect(`{"type":"ack","id":"r1","version":1}`)
+
+	// 服务端代码的修改同样被广播
+	doc.Set("/refresh", mustParse(t, `60`))
+	b.expect(`{"type":"patch","version":2,"patch":[{"op":"replace","path":"/refresh","value":60}]}`)
+	a.expect(`"version":2`)
+
+	// 不符合 Schema 或版本过期的修改被拒绝
+	b.send(`{"type": "patch", "patch": [{"op": "replace", "path": "/refresh", "value": 1}]}`, 0)
+	b.expect(`"type":"error","message":"修改后的文档不符合 Schema`)
+	b.send(`{"type": "patch", "version": 1, "patch": [{"op": "remove", "path": "/theme"}]}`, 0)
+	b.expect(`版本冲突`)
+	b.send(`{"type": "patch", "patch": [{"op": "remove", "path": "/missing"}]}`, 0)
+	b.expect(`应用补丁失败`)
+	b.send(`[1]`, 0)
+	b.expect(`消息必须是`)
+	if s := doc.String(); s != `{"theme":"dark","refresh":60}` {
+		t.Errorf("文档 = %s", s)
+	}
+
+	// ping 得到 pong，关闭帧得到关闭帧
+	b.writeFrame(true, wsPing, []byte("hi"))
+	if opcode, payload := b.receive(); opcode != wsPong || payload != "hi" {
+		t.Errorf("ping 的应答 = %d %q", opcode, payload)
+	}
+	b.writeFrame(true, wsClose, []byte{0x03, 0xE8})
+	if opcode, _ := b.receive(); opcode != wsClose {
+		t.Errorf("关闭帧的应答 = %d", opcode)
+	}
+
+	// 断开的客户端不影响其他客户端
+	doc.Remove("/refresh")
+	a.expect(`{"type":"patch","version":3,"patch":[{"op":"remove","path":"/refresh"}]}`)
+}
+
+func TestServerWebSocketErrors(t *testing.T) {
+	doc, _ := ParseDocument(`{}`)
+	server := newSyncServer(t, doc, "")
+	resp, err := http.Get(server.URL + "/ws")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("普通GET请求的状态码 = %d, 期望 400", resp.StatusCode)
+	}
+
+	// 没有掩码的帧使连接被关闭
+	c := dialTestWS(t, server)
+	c.expect(`"type":"snapshot"`)
+	c.conn.Write([]byte{0x81, 0x01, 'x'})
+	if opcode, payload := c.receive(); opcode != wsClose || binary.BigEndian.Uint16([]byte(payload)) != wsCloseProtocolError {
+		t.Errorf("无掩码帧的应答 = %d %q", opcode, payload)
+	}
+
+	// 没有配置文档时不提供 /ws
+	plain := httptest.NewServer(NewServer(DefaultServerOptions()))
+	defer plain.Close()
+	resp, err = http.Get(plain.URL + "/ws")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("没有文档时 /ws 的状态码 = %d, 期望 404", resp.StatusCode)
+	}
+}
+
+func TestServerWebSocketOrigin(t *testing.T) {
+	doc, _ := ParseDocument(`{}`)
+	options := DefaultServerOptions()
+	options.AllowedOrigins = []string{"https://app.example.com"}
+	server := newSyncServerWithOptions(t, doc, options)
+
+	tests := []struct {
+		origin string
+		status int
+	}{
+		{"", http.StatusSwitchingProtocols},
+		{"http://test", http.StatusSwitchingProtocols},
+		{"https://APP.example.com", http.StatusSwitchingProtocols},
+		{"https://evil.example", http.StatusForbidden},
+		{"https://app.example.com.evil.example", http.StatusForbidden},
+		{"null", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		resp, _ := handshakeTestWS(t, server, tt.origin)
+		if resp.StatusCode != tt.status {
+			t.Errorf("Origin %q 的状态码 = %d, 期望 %d", tt.origin, resp.StatusCode, tt.status)
+		}
+	}
+
+	// "*" 允许任何来源
+	options.AllowedOrigins = []string{"*"}
+	open := newSyncServerWithOptions(t, doc, options)
+	if resp, _ := handshakeTestWS(t, open, "https://evil.example"); resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Errorf("AllowedOrigins=* 时的状态码 = %d, 期望 101", resp.StatusCode)
+	}
+}
+
+func TestServerWebSocketFrameLimit(t *testing.T) {
+	// 关闭安全限制且没有指定 MaxBodyBytes 时，帧头中的长度也不能超过 wsMaxMessage
+	doc, _ := ParseDocument(`{}`)
+	options := DefaultServerOptions()
+	options.ParseOptions.EnabledSecurity = false
+	server := newSyncServerWithOptions(t, doc, options)
+
+	c := dialTestWS(t, server)
+	c.expect(`"type":"snapshot"`)
+	header := []byte{0x81, 0x80 | 127, 0, 0, 0, 0, 0, 0, 0, 0, 1, 2, 3, 4}
+	binary.BigEndian.PutUint64(header[2:10], 1<<62)
+	c.conn.Write(header)
+	if opcode, payload := c.receive(); opcode != wsClose || binary.BigEndian.Uint16([]byte(payload)) != wsCloseTooBig {
+		t.Errorf("超大帧的应答 = %d %q", opcode, payload)
+	}
+}
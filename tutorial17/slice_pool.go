@@ -0,0 +1,151 @@
+// slice_pool.go - 按容量分级复用数组和对象的切片
+package leptjson
+
+import (
+	"sync"
+)
+
+// 切片池的容量等级为 2^minSliceClass 到 2^maxSliceClass
+const (
+	minSliceClass = 2  // 4 个元素
+	maxSliceClass = 12 // 4096 个元素，更大的切片不复用
+)
+
+// 每个容量等级一个池，池中保存指向切片的指针以避免装箱时复制切片头；
+// 取出切片后，指针本身放回 holder 池，放回切片时再取出使用，避免每次分配
+var (
+	valueSlicePools  [maxSliceClass + 1]sync.Pool
+	memberSlicePools [maxSliceClass + 1]sync.Pool
+	valueHolders     = sync.Pool{New: func() interface{} { return new([]*Value) }}
+	memberHolders    = sync.Pool{New: func() interface{} { return new([]Member) }}
+)
+
+// acquireClass 返回容纳 n 个元素的最小容量等级，超过最大等级时返回 -1
+func acquireClass(n int) int {
+	class := minSliceClass
+	for 1<<class < n {
+		class++
+	}
+	if class > maxSliceClass {
+		return -1
+	}
+	return class
+}
+
+// releaseClass 返回容量为 c 的切片可以放入的最大等级，不足最小等级时返回 -1
+func releaseClass(c int) int {
+	if c < 1<<minSliceClass {
+		return -1
+	}
+	class := minSliceClass
+	for class < maxSliceClass && 1<<(class+1) <= c {
+		class++
+	}
+	return class
+}
+
+// acquireValues 返回长度为 0、容量至少为 n 的 []*Value
+func acquireValues(n int) []*Value {
+	class := acquireClass(n)
+	if class < 0 {
+		return make([]*Value, 0, n)
+	}
+	if p, ok := valueSlicePools[class].Get().(*[]*Value); ok {
+		s := (*p)[:0]
+		*p = nil
+		valueHolders.Put(p)
+		return s
+	}
+	return make([]*Value, 0, 1<<class)
+}
+
+// releaseValues 清空 s 并放回池中
+func releaseValues(s []*Value) {
+	class := releaseClass(cap(s))
+	if class < 0 {
+		return
+	}
+	s = s[:cap(s)]
+	for i := range s {
+		s[i] = nil
+	}
+	p := valueHolders.Get().(*[]*Value)
+	*p = s[: 0 : 1<<class]
+	valueSlicePools[class].Put(p)
+}
+
+// acquireMembers 返回长度为 0、容量至少为 n 的 []Member
+func acquireMembers(n int) []Member {
+	class := acquireClass(n)
+	if class < 0 {
+		return make([]Member, 0, n)
+	}
+	if p, ok := memberSlicePools[class].Get().(*[]Member); ok {
+		s := (*p)[:0]
+		*p = nil
+		memberHolders.Put(p)
+		return s
+	}
+	return make([]Member, 0, 1<<class)
+}
+
+// releaseMembers 清空 s 并放回池中
+func releaseMembers(s []Member) {
+	class := releaseClass(cap(s))
+	if class < 0 {
+		return
+	}
+	s = s[:cap(s)]
+	for i := range s {
+		s[i] = Member{}
+	}
+	p := memberHolders.Get().(*[]Member)
+	*p = s[: 0 : 1<<class]
+	memberSlicePools[class].Put(p)
+}
+
+// appendValue 与 append 相同，但从池中取得扩容后的切片，并把旧切片放回池中
+func appendValue(s []*Value, v *Value) []*Value {
+	if len(s) == cap(s) {
+		grown := acquireValues(2 * len(s))
+		grown = append(grown, s...)
+		releaseValues(s)
+		s = grown
+	}
+	return append(s, v)
+}
+
+// appendMember 与 append 相同，但从池中取得扩容后的切片，并把旧切片放回池中
+func appendMember(s []Member, m Member) []Member {
+	if len(s) == cap(s) {
+		grown := acquireMembers(2 * len(s))
+		grown = append(grown, s...)
+		releaseMembers(s)
+		s = grown
+	}
+	return append(s, m)
+}
+
+// Release 将 v 及其所有子值中数组和对象的切片放回池中，并将它们置为 null
+//
+// 解析大量小消息时，处理完一条消息后调用 Release，之后的解析可以复用这些切片，
+// 减少内存分配和 GC 的压力。调用之后 v 和它的所有子值都不能再使用，
+// 其他地方也不能保留指向它们的指针（例如 Get 返回的子值）；不确定时不要调用。
+func Release(v *Value) {
+	if v == nil {
+		return
+	}
+	switch v.Type {
+	case ARRAY:
+		for _, elem := range v.A {
+			Release(elem)
+		}
+		releaseValues(v.A)
+	case OBJECT:
+		for _, m := range v.O {
+			Release(m.V)
+		}
+		releaseMembers(v.O)
+	}
+	*v = Value{}
+}
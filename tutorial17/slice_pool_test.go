@@ -0,0 +1,70 @@
+package leptjson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSliceClasses(t *testing.T) {
+	for n, want := range map[int]int{0: 2, 1: 2, 4: 2, 5: 3, 4096: 12, 4097: -1} {
+		if got := acquireClass(n); got != want {
+			t.Errorf("acquireClass(%d) = %d, 期望 %d", n, got, want)
+		}
+	}
+	for c, want := range map[int]int{3: -1, 4: 2, 7: 2, 8: 3, 10000: 12} {
+		if got := releaseClass(c); got != want {
+			t.Errorf("releaseClass(%d) = %d, 期望 %d", c, got, want)
+		}
+	}
+
+	// 放回的切片被清空，容量按等级截断
+	s := make([]*Value, 3, 6)
+	s[0] = &Value{}
+	releaseValues(s)
+	if got := acquireValues(4); len(got) != 0 || cap(got) < 4 || got[:1][0] != nil {
+		t.Errorf("acquireValues(4) = len %d cap %d", len(got), cap(got))
+	}
+}
+
+func TestReleaseAndReparse(t *testing.T) {
+	json := `{"items":[` + strings.Repeat(`{"a":1,"b":[true,null]},`, 20) + `{}],"k":"v"}`
+	for i := 0; i < 100; i++ {
+		v := &Value{}
+		if err := Parse(v, json); err != PARSE_OK {
+			t.Fatal(err)
+		}
+		if s, _ := Stringify(v); s != json {
+			t.Fatalf("第 %d 次解析得到 %s", i, s)
+		}
+		Release(v)
+		if v.Type != NULL || v.O != nil {
+			t.Fatalf("Release 之后 = %+v", v)
+		}
+	}
+	Release(nil)
+}
+
+// 高并发解析大量小消息，比较处理完后是否调用 Release
+func benchmarkSmallMessages(b *testing.B, release bool) {
+	json := `{"id":1,"tags":["a","b","c"],"user":{"name":"x","roles":["admin","dev"]},"items":[1,2,3,4,5,6]}`
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			v := &Value{}
+			if err := Parse(v, json); err != PARSE_OK {
+				b.Fatal(err)
+			}
+			if release {
+				Release(v)
+			}
+		}
+	})
+}
+
+func BenchmarkParseSmallMessages(b *testing.B) {
+	benchmarkSmallMessages(b, false)
+}
+
+func BenchmarkParseSmallMessagesRelease(b *testing.B) {
+	benchmarkSmallMessages(b, true)
+}
@@ -0,0 +1,342 @@
+// sqlite_file.go - 不依赖 cgo 直接生成只包含一张表的 SQLite 数据库文件
+package leptjson
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// SQLite 文件格式的参数，见 https://www.sqlite.org/fileformat.html
+const (
+	sqlitePageSize     = 4096
+	sqliteUsable       = sqlitePageSize                                     // 每页可用的字节数（不保留尾部空间）
+	sqliteMaxLocal     = sqliteUsable - 35                                  // 表叶子页中单元格最多在页内保存的负载
+	sqliteMinLocal     = (sqliteUsable-12)*32/255 - 23                      // 有溢出时页内至少保存的负载
+	sqliteOverflowData = sqliteUsable - 4                                   // 每个溢出页保存的负载
+	sqliteHeaderSize   = 100                                                // 第1页开头的数据库文件头
+	sqliteVersion      = 3040001                                            // 写入文件头的 SQLite 版本号
+	sqliteLeafHeader   = 8                                                  // 叶子页的页头大小
+	sqliteInnerHeader  = 12                                                 // 内部页的页头大小
+	sqlitePage1Space   = sqliteUsable - sqliteHeaderSize - sqliteLeafHeader // 第1页能放下的单元格总大小
+)
+
+// B 树页的类型
+const (
+	sqliteTableInterior = 0x05
+	sqliteTableLeaf     = 0x0D
+)
+
+// sqliteWriter 在内存中构建数据库的所有页
+type sqliteWriter struct {
+	pages [][]byte // pages[i] 是第 i+1 页
+}
+
+// allocPage 分配一个新页，返回页号
+func (w *sqliteWriter) allocPage() uint32 {
+	w.pages = append(w.pages, make([]byte, sqlitePageSize))
+	return uint32(len(w.pages))
+}
+
+// sqliteCell 是表叶子页中的一个单元格
+type sqliteCell struct {
+	rowid int64
+	data  []byte
+}
+
+// WriteSQLite 把表写入新的 SQLite 数据库文件 path，文件已经存在时返回错误
+//
+// 生成的数据库只包含这一张表，行号从1开始，可以直接用 sqlite3 或任何 SQLite 驱动打开。
+func (t *SQLTable) WriteSQLite(path string) error {
+	if len(t.Columns) == 0 {
+		return fmt.Errorf("表 %s 没有任何列", t.Name)
+	}
+	if strings.HasPrefix(strings.ToLower(t.Name), "sqlite_") {
+		return fmt.Errorf("表名不能以 sqlite_ 开头: %s", t.Name)
+	}
+	data := t.sqliteFile()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("数据库文件 %s 已经存在，只能写入新的数据库", path)
+		}
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+// sqliteFile 生成整个数据库文件的内容
+func (t *SQLTable) sqliteFile() []byte {
+	w := &sqliteWriter{}
+	w.allocPage() // 第1页：文件头和 sqlite_master 表
+
+	cells := make([]sqliteCell, len(t.Rows))
+	for i, row := range t.Rows {
+		cells[i] = sqliteCell{rowid: int64(i + 1), data: w.leafCell(int64(i+1), sqliteRecord(row))}
+	}
+	root := w.buildTable(cells)
+
+	master := sqliteRecord([]interface{}{"table", t.Name, t.Name, int64(root), t.CreateStatement()})
+	w.writeMaster(w.leafCell(1, master))
+	w.writeHeader()
+
+	out := make([]byte, 0, len(w.pages)*sqlitePageSize)
+	for _, page := range w.pages {
+		out = append(out, page...)
+	}
+	return out
+}
+
+// writeHeader 写入第1页开头的数据库文件头
+func (w *sqliteWriter) writeHeader() {
+	h := w.pages[0][:sqliteHeaderSize]
+	copy(h, "SQLite format 3\x00")
+	binary.BigEndian.PutUint16(h[16:], sqlitePageSize)
+	h[18], h[19] = 1, 1 // 读写版本：回滚日志模式
+	h[20] = 0           // 每页尾部保留的字节数
+	h[21], h[22], h[23] = 64, 32, 32
+	binary.BigEndian.PutUint32(h[24:], 1) // 文件修改计数
+	binary.BigEndian.PutUint32(h[28:], uint32(len(w.pages)))
+	binary.BigEndian.PutUint32(h[40:], 1) // schema cookie
+	binary.BigEndian.PutUint32(h[44:], 4) // schema 格式
+	binary.BigEndian.PutUint32(h[56:], 1) // 文本编码 UTF-8
+	binary.BigEndian.PutUint32(h[92:], 1) // 与修改计数相同，表示 sqliteVersion 有效
+	binary.BigEndian.PutUint32(h[96:], sqliteVersion)
+}
+
+// writeMaster 把 sqlite_master 唯一的一行写入第1页；
+// 第1页放不下时第1页作为没有单元格的内部页，这一行放在它唯一的子页中
+func (w *sqliteWriter) writeMaster(cell []byte) {
+	if len(cell)+2 <= sqlitePage1Space {
+		writeSQLitePage(w.pages[0], sqliteHeaderSize, sqliteTableLeaf, [][]byte{cell}, 0)
+		return
+	}
+	leaf := w.allocPage()
+	writeSQLitePage(w.pages[leaf-1], 0, sqliteTableLeaf, [][]byte{cell}, 0)
+	writeSQLitePage(w.pages[0], sqliteHeaderSize, sqliteTableInterior, nil, leaf)
+}
+
+// buildTable 把单元格按行号顺序放入叶子页并逐层建立内部页，返回根页的页号
+func (w *sqliteWriter) buildTable(cells []sqliteCell) uint32 {
+	type child struct {
+		page     uint32
+		maxRowid int64
+	}
+	var level []child
+	flushLeaf := func(group []sqliteCell) {
+		page := w.allocPage()
+		data := make([][]byte, len(group))
+		for i, c := range group {
+			data[i] = c.data
+		}
+		writeSQLitePage(w.pages[page-1], 0, sqliteTableLeaf, data, 0)
+		maxRowid := int64(0)
+		if len(group) > 0 {
+			maxRowid = group[len(group)-1].rowid
+		}
+		level = append(level, child{page, maxRowid})
+	}
+	var group []sqliteCell
+	used := sqliteLeafHeader
+	for _, c := range cells {
+		if used+len(c.data)+2 > sqliteUsable {
+			flushLeaf(group)
+			group, used = nil, sqliteLeafHeader
+		}
+		group = append(group, c)
+		used += len(c.data) + 2
+	}
+	flushLeaf(group)
+
+	// 每个内部页的单元格指向除最后一个以外的子页，键是子页中最大的行号；最后一个子页由右指针指向
+	for len(level) > 1 {
+		var next []child
+		for start := 0; start < len(level); {
+			var data [][]byte
+			used := sqliteInnerHeader
+			end := start
+			for end < len(level)-1 {
+				cell := appendUint32BE(nil, level[end].page)
+				cell = appendSQLiteVarint(cell, uint64(level[end].maxRowid))
+				if used+len(cell)+2 > sqliteUsable {
+					break
+				}
+				data = append(data, cell)
+				used += len(cell) + 2
+				end++
+			}
+			// level[end] 作为这个内部页的右子页
+			page := w.allocPage()
+			writeSQLitePage(w.pages[page-1], 0, sqliteTableInterior, data, level[end].page)
+			next = append(next, child{page, level[end].maxRowid})
+			start = end + 1
+		}
+		level = next
+	}
+	return level[0].page
+}
+
+// leafCell 生成表叶子页的单元格，负载超出页内大小的部分写入溢出页
+func (w *sqliteWriter) leafCell(rowid int64, payload []byte) []byte {
+	cell := appendSQLiteVarint(nil, uint64(len(payload)))
+	cell = appendSQLiteVarint(cell, uint64(rowid))
+	local := len(payload)
+	if local > sqliteMaxLocal {
+		local = sqliteMinLocal + (len(payload)-sqliteMinLocal)%sqliteOverflowData
+		if local > sqliteMaxLocal {
+			local = sqliteMinLocal
+		}
+	}
+	cell = append(cell, payload[:local]...)
+	if local == len(payload) {
+		return cell
+	}
+
+	rest := payload[local:]
+	first := w.allocPage()
+	cell = appendUint32BE(cell, first)
+	for page := first; ; {
+		n := len(rest)
+		if n > sqliteOverflowData {
+			n = sqliteOverflowData
+		}
+		data := w.pages[page-1]
+		copy(data[4:], rest[:n])
+		rest = rest[n:]
+		if len(rest) == 0 {
+			return cell
+		}
+		next := w.allocPage()
+		binary.BigEndian.PutUint32(data, next)
+		page = next
+	}
+}
+
+// writeSQLitePage 写入 B 树页：页头位于 offset，单元格从页尾向前存放
+func writeSQLitePage(page []byte, offset int, kind byte, cells [][]byte, rightChild uint32) {
+	header := sqliteLeafHeader
+	if kind == sqliteTableInterior {
+		header = sqliteInnerHeader
+		binary.BigEndian.PutUint32(page[offset+8:], rightChild)
+	}
+	page[offset] = kind
+	binary.BigEndian.PutUint16(page[offset+3:], uint16(len(cells)))
+	content := sqliteUsable
+	for i, cell := range cells {
+		content -= len(cell)
+		copy(page[content:], cell)
+		binary.BigEndian.PutUint16(page[offset+header+2*i:], uint16(content))
+	}
+	binary.BigEndian.PutUint16(page[offset+5:], uint16(content))
+}
+
+// appendUint32BE 以大端序追加32位整数
+func appendUint32BE(buf []byte, v uint32) []byte {
+	return append(buf, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+// sqliteRecord 按 SQLite 的记录格式编码一行
+func sqliteRecord(values []interface{}) []byte {
+	var types, body []byte
+	for _, v := range values {
+		switch v := v.(type) {
+		case nil:
+			types = appendSQLiteVarint(types, 0)
+		case int64:
+			switch {
+			case v == 0:
+				types = appendSQLiteVarint(types, 8)
+			case v == 1:
+				types = appendSQLiteVarint(types, 9)
+			default:
+				serial, size := sqliteIntSerial(v)
+				types = appendSQLiteVarint(types, serial)
+				for i := size - 1; i >= 0; i-- {
+					body = append(body, byte(v>>(8*uint(i))))
+				}
+			}
+		case float64:
+			types = appendSQLiteVarint(types, 7)
+			var b [8]byte
+			binary.BigEndian.PutUint64(b[:], math.Float64bits(v))
+			body = append(body, b[:]...)
+		case string:
+			types = appendSQLiteVarint(types, uint64(13+2*len(v)))
+			body = append(body, v...)
+		}
+	}
+	// 记录头的长度包括表示长度本身的 varint
+	headerLen := len(types) + 1
+	for sqliteVarintLen(uint64(headerLen))+len(types) != headerLen {
+		headerLen = sqliteVarintLen(uint64(headerLen)) + len(types)
+	}
+	record := appendSQLiteVarint(nil, uint64(headerLen))
+	record = append(record, types...)
+	return append(record, body...)
+}
+
+// sqliteIntSerial 返回保存整数的最小序列类型及其字节数
+func sqliteIntSerial(v int64) (uint64, int) {
+	switch {
+	case v >= math.MinInt8 && v <= math.MaxInt8:
+		return 1, 1
+	case v >= math.MinInt16 && v <= math.MaxInt16:
+		return 2, 2
+	case v >= -1<<23 && v < 1<<23:
+		return 3, 3
+	case v >= math.MinInt32 && v <= math.MaxInt32:
+		return 4, 4
+	case v >= -1<<47 && v < 1<<47:
+		return 5, 6
+	default:
+		return 6, 8
+	}
+}
+
+// appendSQLiteVarint 按 SQLite 的格式编码 varint：大端序，每字节7位，第9个字节使用全部8位
+func appendSQLiteVarint(buf []byte, v uint64) []byte {
+	if v > 1<<56-1 {
+		var b [9]byte
+		b[8] = byte(v)
+		v >>= 8
+		for i := 7; i >= 0; i-- {
+			b[i] = byte(v&0x7F) | 0x80
+			v >>= 7
+		}
+		return append(buf, b[:]...)
+	}
+	var b [8]byte
+	n := 0
+	for {
+		b[n] = byte(v&0x7F) | 0x80
+		n++
+		v >>= 7
+		if v == 0 {
+			break
+		}
+	}
+	b[0] &= 0x7F
+	for i := n - 1; i >= 0; i-- {
+		buf = append(buf, b[i])
+	}
+	return buf
+}
+
+// sqliteVarintLen 返回 varint 编码后的字节数
+func sqliteVarintLen(v uint64) int {
+	if v > 1<<56-1 {
+		return 9
+	}
+	n := 1
+	for v >>= 7; v != 0; v >>= 7 {
+		n++
+	}
+	return n
+}
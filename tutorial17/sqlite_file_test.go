@@ -0,0 +1,158 @@
+package leptjson
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSQLiteVarint(t *testing.T) {
+	tests := []struct {
+		v        uint64
+		expected []byte
+	}{
+		{0, []byte{0x00}},
+		{127, []byte{0x7F}},
+		{128, []byte{0x81, 0x00}},
+		{300, []byte{0x82, 0x2C}},
+		{1<<56 - 1, []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x7F}},
+		{1 << 56, []byte{0x80, 0xC0, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x00}},
+	}
+	for _, tt := range tests {
+		got := appendSQLiteVarint(nil, tt.v)
+		if !bytes.Equal(got, tt.expected) {
+			t.Errorf("appendSQLiteVarint(%d) = % x, 期望 % x", tt.v, got, tt.expected)
+		}
+		if n := sqliteVarintLen(tt.v); n != len(tt.expected) {
+			t.Errorf("sqliteVarintLen(%d) = %d, 期望 %d", tt.v, n, len(tt.expected))
+		}
+	}
+}
+
+func TestSQLiteRecord(t *testing.T) {
+	got := sqliteRecord([]interface{}{nil, int64(0), int64(1), int64(-2), int64(300), "ab", 1.5})
+	expected := []byte{
+		0x08,                                     // 记录头长度
+		0x00, 0x08, 0x09, 0x01, 0x02, 0x11, 0x07, // 序列类型
+		0xFE,       // -2
+		0x01, 0x2C, // 300
+		'a', 'b',
+		0x3F, 0xF8, 0, 0, 0, 0, 0, 0, // 1.5
+	}
+	if !bytes.Equal(got, expected) {
+		t.Errorf("sqliteRecord = % x, 期望 % x", got, expected)
+	}
+}
+
+// sqliteTestTable 生成 n 行数据，每行包含一个长度不同的字符串，使部分行需要溢出页
+func sqliteTestTable(n int) *SQLTable {
+	table := &SQLTable{Name: "rows", Columns: []SQLColumn{
+		{Name: "id", Type: SQL_INTEGER},
+		{Name: "score", Type: SQL_REAL},
+		{Name: "text", Type: SQL_TEXT},
+		{Name: "flag", Type: SQL_INTEGER},
+	}}
+	for i := 0; i < n; i++ {
+		table.Rows = append(table.Rows, []interface{}{int64(i), float64(i) + 0.5, strings.Repeat("x", (i*997)%9000), int64(1 - i%2)})
+	}
+	return table
+}
+
+func TestWriteSQLiteHeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	if err := sqliteTestTable(50).WriteSQLite(path); err != nil {
+		t.Fatalf("WriteSQLite 失败: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if !bytes.HasPrefix(data, []byte("SQLite format 3\x00")) {
+		t.Fatalf("文件头错误")
+	}
+	if pages := binary.BigEndian.Uint32(data[28:]); int(pages)*sqlitePageSize != len(data) {
+		t.Errorf("文件头中的页数 %d 与文件大小 %d 不一致", pages, len(data))
+	}
+
+	// 不覆盖已经存在的文件
+	if err := sqliteTestTable(1).WriteSQLite(path); err == nil || !strings.Contains(err.Error(), "已经存在") {
+		t.Errorf("文件已经存在时的错误 = %v", err)
+	}
+	table := sqliteTestTable(1)
+	table.Name = "sqlite_stat1"
+	if err := table.WriteSQLite(filepath.Join(t.TempDir(), "x.db")); err == nil {
+		t.Errorf("sqlite_ 开头的表名应该返回错误")
+	}
+}
+
+// sqliteQuery 用 sqlite3 命令行工具执行查询，没有安装时跳过测试
+func sqliteQuery(t *testing.T, path string, query string) string {
+	t.Helper()
+	sqlite3, err := exec.LookPath("sqlite3")
+	if err != nil {
+		t.Skip("没有安装 sqlite3")
+	}
+	out, err := exec.Command(sqlite3, path, query).CombinedOutput()
+	if err != nil {
+		t.Fatalf("sqlite3 %s 失败: %v\n%s", query, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestWriteSQLiteReadBack(t *testing.T) {
+	// 3000 行需要多层内部页，较长的字符串需要溢出页
+	path := filepath.Join(t.TempDir(), "rows.db")
+	if err := sqliteTestTable(3000).WriteSQLite(path); err != nil {
+		t.Fatalf("WriteSQLite 失败: %v", err)
+	}
+	if got := sqliteQuery(t, path, "PRAGMA integrity_check"); got != "ok" {
+		t.Fatalf("integrity_check = %s", got)
+	}
+	expected := "3000|4498500|4500000.0|1500"
+	if got := sqliteQuery(t, path, "SELECT count(*), sum(id), sum(score), sum(flag) FROM rows"); got != expected {
+		t.Errorf("查询结果 = %s, 期望 %s", got, expected)
+	}
+	if got := sqliteQuery(t, path, "SELECT length(text) FROM rows WHERE rowid = 2998"); got != fmt.Sprint((2997*997)%9000) {
+		t.Errorf("第2998行的字符串长度 = %s", got)
+	}
+}
+
+func TestWriteSQLiteLargeSchema(t *testing.T) {
+	// CREATE TABLE 语句超过第1页的容量，sqlite_master 的一行需要放入子页和溢出页
+	var sb strings.Builder
+	sb.WriteString("[{")
+	for i := 0; i < 400; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, `"column_with_a_rather_long_name_%d":%d`, i, i)
+	}
+	sb.WriteString("}]")
+	table, err := FlattenTable("wide", mustParse(t, sb.String()), FlattenOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "wide.db")
+	if err := table.WriteSQLite(path); err != nil {
+		t.Fatalf("WriteSQLite 失败: %v", err)
+	}
+	if got := sqliteQuery(t, path, "PRAGMA integrity_check"); got != "ok" {
+		t.Fatalf("integrity_check = %s", got)
+	}
+	if got := sqliteQuery(t, path, "SELECT column_with_a_rather_long_name_399 FROM wide"); got != "399" {
+		t.Errorf("查询结果 = %s, 期望 399", got)
+	}
+}
+
+func TestWriteSQLiteEmptyTable(t *testing.T) {
+	table := &SQLTable{Name: "empty", Columns: []SQLColumn{{Name: "a", Type: SQL_TEXT}}}
+	path := filepath.Join(t.TempDir(), "empty.db")
+	if err := table.WriteSQLite(path); err != nil {
+		t.Fatalf("WriteSQLite 失败: %v", err)
+	}
+	if got := sqliteQuery(t, path, "SELECT count(*) FROM empty"); got != "0" {
+		t.Errorf("空表的行数 = %s", got)
+	}
+}
@@ -0,0 +1,128 @@
+// storage.go - 按 URI 的协议打开本地文件、HTTP 和对象存储中的文档
+package leptjson
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// StorageOpener 打开 uri 指向的对象，调用者负责关闭返回的 ReadCloser
+type StorageOpener func(ctx context.Context, uri *url.URL) (io.ReadCloser, error)
+
+var (
+	storageMu      sync.RWMutex
+	storageOpeners = map[string]StorageOpener{
+		"file":  openFileStorage,
+		"http":  openHTTPStorage,
+		"https": openHTTPStorage,
+	}
+)
+
+// storageBuildTags 是需要用构建标签启用的协议，未启用时在错误信息中提示
+var storageBuildTags = map[string]string{
+	"s3": "s3",  // storage_s3.go
+	"gs": "gcs", // storage_gcs.go
+}
+
+// RegisterStorage 注册协议 scheme（如 "s3"）的打开函数，已经注册的协议被替换
+//
+// s3:// 和 gs:// 的实现分别在构建标签 s3 和 gcs 下注册：
+//
+//	go build -tags "s3 gcs" ./tutorial17/main
+func RegisterStorage(scheme string, opener StorageOpener) {
+	storageMu.Lock()
+	defer storageMu.Unlock()
+	storageOpeners[strings.ToLower(scheme)] = opener
+}
+
+// StorageSchemes 返回已经注册的协议，按字典序排列
+func StorageSchemes() []string {
+	storageMu.RLock()
+	defer storageMu.RUnlock()
+	schemes := make([]string, 0, len(storageOpeners))
+	for scheme := range storageOpeners {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// storageScheme 返回形如 "scheme://..." 的 URI 的协议（小写），不是这种形式时返回 false
+//
+// Windows 路径 C:\data.json 没有 "//"，不会被当作 URI。
+func storageScheme(name string) (string, bool) {
+	i := strings.Index(name, "://")
+	if i <= 0 {
+		return "", false
+	}
+	for j, c := range name[:i] {
+		isLetter := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		if !isLetter && (j == 0 || !(isDigit(byte(c)) || c == '+' || c == '-' || c == '.')) {
+			return "", false
+		}
+	}
+	return strings.ToLower(name[:i]), true
+}
+
+// OpenStorage 打开 uri 指向的文档
+//
+// uri 可以是本地路径、file:// URI、http(s) URL，或者任何用 RegisterStorage 注册过的协议，
+// 如 s3://bucket/key 和 gs://bucket/object。协议没有注册时返回错误，
+// 如果它需要构建标签才能启用，错误信息中会给出标签。
+func OpenStorage(ctx context.Context, uri string) (io.ReadCloser, error) {
+	scheme, ok := storageScheme(uri)
+	if !ok {
+		return os.Open(uri)
+	}
+	storageMu.RLock()
+	opener := storageOpeners[scheme]
+	storageMu.RUnlock()
+	if opener == nil {
+		if tag, ok := storageBuildTags[scheme]; ok {
+			return nil, fmt.Errorf("不支持的存储协议 %s://，需要使用 -tags %s 编译", scheme, tag)
+		}
+		return nil, fmt.Errorf("不支持的存储协议 %s://", scheme)
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("无效的URI: %w", err)
+	}
+	return opener(ctx, u)
+}
+
+// openFileStorage 打开 file:// URI 指向的本地文件
+func openFileStorage(ctx context.Context, uri *url.URL) (io.ReadCloser, error) {
+	if uri.Host != "" && uri.Host != "localhost" {
+		return nil, fmt.Errorf("不支持其他主机上的文件: %s", uri)
+	}
+	return os.Open(uri.Path)
+}
+
+// openHTTPStorage 用 GET 请求打开 http(s) URL，状态码不是 2xx 时返回错误
+func openHTTPStorage(ctx context.Context, uri *url.URL) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return doStorageRequest(req, uri)
+}
+
+// doStorageRequest 发送请求，状态码不是 2xx 时关闭响应并返回错误
+func doStorageRequest(req *http.Request, uri *url.URL) (io.ReadCloser, error) {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求 %s 失败: %w", uri, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("请求 %s 失败: %s", uri, resp.Status)
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,51 @@
+//go:build gcs
+// +build gcs
+
+// storage_gcs.go - gs:// 输入，通过 Cloud Storage JSON API 下载对象
+package leptjson
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+func init() {
+	RegisterStorage("gs", openGCS)
+}
+
+// gcsEndpoint 返回 JSON API 的地址，设置了 STORAGE_EMULATOR_HOST 时使用模拟器
+func gcsEndpoint() string {
+	host := os.Getenv("STORAGE_EMULATOR_HOST")
+	if host == "" {
+		return "https://storage.googleapis.com"
+	}
+	if !strings.Contains(host, "://") {
+		host = "http://" + host
+	}
+	return strings.TrimSuffix(host, "/")
+}
+
+// openGCS 下载 gs://bucket/object 指向的对象
+//
+// 设置了 GOOGLE_OAUTH_ACCESS_TOKEN 时作为 Bearer 令牌发送（可用 gcloud auth print-access-token 获取），
+// 否则匿名访问，只能读取公开的对象。
+func openGCS(ctx context.Context, uri *url.URL) (io.ReadCloser, error) {
+	bucket, object := uri.Host, strings.TrimPrefix(uri.Path, "/")
+	if bucket == "" || object == "" {
+		return nil, fmt.Errorf("无效的GCS地址 %s，应为 gs://bucket/object", uri)
+	}
+	target := gcsEndpoint() + "/storage/v1/b/" + url.PathEscape(bucket) + "/o/" + url.PathEscape(object) + "?alt=media"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return doStorageRequest(req, uri)
+}
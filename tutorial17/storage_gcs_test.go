@@ -0,0 +1,46 @@
+//go:build gcs
+// +build gcs
+
+package leptjson
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpenStorageGCS(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 对象名中的 "/" 必须编码为 %2F
+		if r.URL.EscapedPath() != "/storage/v1/b/bucket/o/dir%2Fdata.json" || r.URL.Query().Get("alt") != "media" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		io.WriteString(w, `[true]`)
+	}))
+	defer ts.Close()
+	setEnv(t, "STORAGE_EMULATOR_HOST", strings.TrimPrefix(ts.URL, "http://"))
+	setEnv(t, "GOOGLE_OAUTH_ACCESS_TOKEN", "token")
+
+	if got := readStorage(t, "gs://bucket/dir/data.json"); got != `[true]` {
+		t.Errorf("内容 = %s, 期望 [true]", got)
+	}
+	if _, err := OpenStorage(context.Background(), "gs://bucket/missing.json"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("对象不存在时的错误 = %v, 期望包含 404", err)
+	}
+	if _, err := OpenStorage(context.Background(), "gs://bucket/"); err == nil {
+		t.Errorf("缺少对象名时应该返回错误")
+	}
+
+	setEnv(t, "GOOGLE_OAUTH_ACCESS_TOKEN", "")
+	if _, err := OpenStorage(context.Background(), "gs://bucket/dir/data.json"); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("没有令牌时的错误 = %v, 期望包含 401", err)
+	}
+}
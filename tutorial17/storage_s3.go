@@ -0,0 +1,178 @@
+//go:build s3
+// +build s3
+
+// storage_s3.go - s3:// 输入，用 AWS 签名版本 4 直接调用 S3 REST API
+package leptjson
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterStorage("s3", openS3)
+}
+
+// s3Config 是访问 S3 所需的凭证和地址，从标准的 AWS 环境变量读取
+type s3Config struct {
+	accessKey    string // AWS_ACCESS_KEY_ID
+	secretKey    string // AWS_SECRET_ACCESS_KEY
+	sessionToken string // AWS_SESSION_TOKEN，可选
+	region       string // AWS_REGION 或 AWS_DEFAULT_REGION，默认 us-east-1
+	endpoint     string // AWS_ENDPOINT_URL，设置时使用路径风格的地址，用于 MinIO 等兼容服务
+}
+
+// s3ConfigFromEnv 从环境变量读取 S3 配置
+func s3ConfigFromEnv() s3Config {
+	cfg := s3Config{
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		region:       os.Getenv("AWS_REGION"),
+		endpoint:     strings.TrimSuffix(os.Getenv("AWS_ENDPOINT_URL"), "/"),
+	}
+	if cfg.region == "" {
+		cfg.region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if cfg.region == "" {
+		cfg.region = "us-east-1"
+	}
+	return cfg
+}
+
+// openS3 下载 s3://bucket/key 指向的对象
+func openS3(ctx context.Context, uri *url.URL) (io.ReadCloser, error) {
+	bucket, key := uri.Host, strings.TrimPrefix(uri.Path, "/")
+	if bucket == "" || key == "" {
+		return nil, fmt.Errorf("无效的S3地址 %s，应为 s3://bucket/key", uri)
+	}
+	cfg := s3ConfigFromEnv()
+	if cfg.accessKey == "" || cfg.secretKey == "" {
+		return nil, fmt.Errorf("读取 %s 需要设置 AWS_ACCESS_KEY_ID 和 AWS_SECRET_ACCESS_KEY", uri)
+	}
+
+	var target string
+	if cfg.endpoint != "" {
+		target = cfg.endpoint + "/" + bucket + "/" + s3EscapePath(key)
+	} else {
+		target = "https://" + bucket + ".s3." + cfg.region + ".amazonaws.com/" + s3EscapePath(key)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Amz-Content-Sha256", "UNSIGNED-PAYLOAD")
+	if cfg.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", cfg.sessionToken)
+	}
+	signS3Request(req, cfg, time.Now().UTC())
+	return doStorageRequest(req, uri)
+}
+
+// s3EscapePath 按 S3 的规则编码对象键，保留 "/"
+func s3EscapePath(key string) string {
+	parts := strings.Split(key, "/")
+	for i, part := range parts {
+		parts[i] = s3Escape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+// s3Escape 编码除 A-Z a-z 0-9 - _ . ~ 以外的所有字节
+func s3Escape(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || isDigit(c) || c == '-' || c == '_' || c == '.' || c == '~' {
+			sb.WriteByte(c)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
+
+// signS3Request 用 AWS 签名版本 4 为请求添加 X-Amz-Date 和 Authorization 请求头
+//
+// 所有已经设置的请求头和 Host 都参与签名；请求体的哈希取自 X-Amz-Content-Sha256。
+func signS3Request(req *http.Request, cfg s3Config, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	query := req.URL.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var params []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			params = append(params, s3Escape(k)+"="+s3Escape(v))
+		}
+	}
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		strings.Join(params, "&"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		req.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+
+	scope := date + "/" + cfg.region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+cfg.secretKey), date)
+	key = hmacSHA256(key, cfg.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		cfg.accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,135 @@
+package leptjson
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setEnv 设置环境变量，测试结束后恢复原值
+func setEnv(t *testing.T, name, value string) {
+	old, had := os.LookupEnv(name)
+	os.Setenv(name, value)
+	t.Cleanup(func() {
+		if had {
+			os.Setenv(name, old)
+		} else {
+			os.Unsetenv(name)
+		}
+	})
+}
+
+func readStorage(t *testing.T, uri string) string {
+	t.Helper()
+	r, err := OpenStorage(context.Background(), uri)
+	if err != nil {
+		t.Fatalf("OpenStorage(%s) 失败: %v", uri, err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("读取 %s 失败: %v", uri, err)
+	}
+	return string(data)
+}
+
+func TestStorageScheme(t *testing.T) {
+	tests := []struct {
+		name   string
+		scheme string
+		ok     bool
+	}{
+		{"data.json", "", false},
+		{"/tmp/data.json", "", false},
+		{`C:\data.json`, "", false},
+		{"s3://bucket/key", "s3", true},
+		{"GS://bucket/key", "gs", true},
+		{"git+ssh://host/repo", "git+ssh", true},
+		{"://missing", "", false},
+		{"1x://bad", "", false},
+		{"dir/a://b", "", false},
+	}
+	for _, tt := range tests {
+		scheme, ok := storageScheme(tt.name)
+		if scheme != tt.scheme || ok != tt.ok {
+			t.Errorf("storageScheme(%q) = %q, %v, 期望 %q, %v", tt.name, scheme, ok, tt.scheme, tt.ok)
+		}
+	}
+}
+
+func TestOpenStorageFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	if err := ioutil.WriteFile(path, []byte(`[1,2]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := readStorage(t, path); got != `[1,2]` {
+		t.Errorf("本地路径的内容 = %s, 期望 [1,2]", got)
+	}
+	if got := readStorage(t, (&url.URL{Scheme: "file", Path: path}).String()); got != `[1,2]` {
+		t.Errorf("file:// 的内容 = %s, 期望 [1,2]", got)
+	}
+	if _, err := OpenStorage(context.Background(), "file://other-host/data.json"); err == nil {
+		t.Errorf("其他主机上的文件应该返回错误")
+	}
+}
+
+func TestOpenStorageHTTP(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/data.json" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, `{"a":1}`)
+	}))
+	defer ts.Close()
+
+	if got := readStorage(t, ts.URL+"/data.json"); got != `{"a":1}` {
+		t.Errorf("内容 = %s, 期望 {\"a\":1}", got)
+	}
+	if _, err := OpenStorage(context.Background(), ts.URL+"/missing"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("错误 = %v, 期望包含 404", err)
+	}
+}
+
+func TestRegisterStorage(t *testing.T) {
+	RegisterStorage("Mem", func(ctx context.Context, uri *url.URL) (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader(`"` + uri.Host + uri.Path + `"`)), nil
+	})
+	defer func() {
+		storageMu.Lock()
+		delete(storageOpeners, "mem")
+		storageMu.Unlock()
+	}()
+
+	if got := readStorage(t, "mem://bucket/key"); got != `"bucket/key"` {
+		t.Errorf("内容 = %s, 期望 \"bucket/key\"", got)
+	}
+	found := false
+	for _, scheme := range StorageSchemes() {
+		found = found || scheme == "mem"
+	}
+	if !found {
+		t.Errorf("StorageSchemes() = %v, 期望包含 mem", StorageSchemes())
+	}
+}
+
+func TestOpenStorageUnknownScheme(t *testing.T) {
+	if _, err := OpenStorage(context.Background(), "ftp://host/data.json"); err == nil || !strings.Contains(err.Error(), "ftp://") {
+		t.Errorf("错误 = %v, 期望提示不支持 ftp://", err)
+	}
+	storageMu.RLock()
+	_, registered := storageOpeners["s3"]
+	storageMu.RUnlock()
+	if !registered {
+		if _, err := OpenStorage(context.Background(), "s3://bucket/key"); err == nil || !strings.Contains(err.Error(), "-tags s3") {
+			t.Errorf("错误 = %v, 期望提示 -tags s3", err)
+		}
+	}
+}
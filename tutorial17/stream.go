@@ -0,0 +1,285 @@
+// stream.go - 流式解析超大的顶层数组，支持检查点和断点续读
+package leptjson
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Checkpoint 记录流式解析的进度，可以持久化后用于 ResumeArrayDecoder
+//
+// Index 为 0 时 Offset 指向开始的 '[' 之后，否则指向第 Index 个元素结束之后。
+type Checkpoint struct {
+	Offset int64 `json:"offset"` // 距输入开头的字节偏移
+	Index  int   `json:"index"`  // 已经解析的元素数量
+}
+
+// 流式解析的状态
+const (
+	streamStart        = iota // 尚未读取 '['
+	streamFirstElement        // 已读取 '['，等待第一个元素或 ']'
+	streamAfterElement        // 已读取一个元素，等待 ',' 或 ']'
+	streamEnd                 // 已读取 ']'
+)
+
+// ErrStreamNotArray 表示流式解析的输入不是数组
+var ErrStreamNotArray = errors.New("流式解析的输入必须是JSON数组")
+
+// ArrayDecoder 逐个解析顶层JSON数组的元素，内存占用只与单个元素的大小有关
+//
+// 每个元素按 ParseOptions 独立解析，安全限制作用于单个元素；顶层数组本身不受
+// MaxArraySize 和 MaxTotalSize 的限制。启用安全限制时，超过 MaxTotalSize 的元素
+// 在缓冲过程中就返回 *MaxBytesError。需要限制整个输入的大小或读取速度时，
+// 用 MaxBytesReader 和 ThrottledReader 包装 r。流式解析不支持注释。
+type ArrayDecoder struct {
+	r       *bufio.Reader
+	options ParseOptions
+	state   int
+	offset  int64 // 已经消耗的字节数
+	index   int   // 已经解析的元素数量
+	buf     []byte
+
+	checkpointEvery int
+	onCheckpoint    func(Checkpoint) error
+}
+
+// NewArrayDecoder 创建从 r 开头读取数组的流式解析器
+func NewArrayDecoder(r io.Reader, options ParseOptions) *ArrayDecoder {
+	return &ArrayDecoder{r: bufio.NewReader(r), options: options, state: streamStart}
+}
+
+// ResumeArrayDecoder 从检查点恢复流式解析，r 必须是生成检查点时的同一份输入
+func ResumeArrayDecoder(r io.ReadSeeker, cp Checkpoint, options ParseOptions) (*ArrayDecoder, error) {
+	if cp.Offset < 0 || cp.Index < 0 {
+		return nil, fmt.Errorf("无效的检查点: %+v", cp)
+	}
+	if _, err := r.Seek(cp.Offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("定位到检查点失败: %w", err)
+	}
+	d := NewArrayDecoder(r, options)
+	d.offset = cp.Offset
+	d.index = cp.Index
+	d.state = streamAfterElement
+	if cp.Index == 0 {
+		d.state = streamFirstElement
+	}
+	return d, nil
+}
+
+// SetCheckpointFunc 设置每解析 every 个元素后调用的检查点函数
+//
+// fn 返回错误时 Next 返回该错误，但元素已经解析完成。
+func (d *ArrayDecoder) SetCheckpointFunc(every int, fn func(Checkpoint) error) {
+	d.checkpointEvery = every
+	d.onCheckpoint = fn
+}
+
+// Checkpoint 返回当前的进度，从它恢复后 Next 返回的是下一个尚未返回的元素
+func (d *ArrayDecoder) Checkpoint() Checkpoint {
+	return Checkpoint{Offset: d.offset, Index: d.index}
+}
+
+// Index 返回已经解析的元素数量
+func (d *ArrayDecoder) Index() int {
+	return d.index
+}
+
+// Next 解析下一个元素，数组结束时返回 io.EOF
+func (d *ArrayDecoder) Next(v *Value) error {
+	if err := d.advance(); err != nil {
+		return err
+	}
+
+	if err := d.readElement(); err != nil {
+		return err
+	}
+	if err := ParseWithOptions(v, string(d.buf), d.options); err != PARSE_OK {
+		return fmt.Errorf("解析第%d个元素失败: %w", d.index, err)
+	}
+	d.index++
+	d.state = streamAfterElement
+
+	if d.onCheckpoint != nil && d.checkpointEvery > 0 && d.index%d.checkpointEvery == 0 {
+		return d.onCheckpoint(d.Checkpoint())
+	}
+	return nil
+}
+
+// advance 读取元素前的 '['、',' 和 ']'，数组结束时返回 io.EOF
+func (d *ArrayDecoder) advance() error {
+	if d.state == streamEnd {
+		return io.EOF
+	}
+
+	if d.state == streamStart {
+		ch, err := d.nextNonSpace()
+		if err != nil {
+			return d.unexpected(err)
+		}
+		if ch != '[' {
+			return ErrStreamNotArray
+		}
+		d.state = streamFirstElement
+	}
+
+	ch, err := d.nextNonSpace()
+	if err != nil {
+		return d.unexpected(err)
+	}
+	switch {
+	case ch == ']':
+		d.state = streamEnd
+		return io.EOF
+	case d.state == streamAfterElement:
+		if ch != ',' {
+			return fmt.Errorf("偏移%d处期望 ',' 或 ']'，实际为 %q", d.offset-1, ch)
+		}
+	default:
+		d.unreadByte()
+	}
+	return nil
+}
+
+// readElement 将下一个完整的JSON值读入 d.buf
+func (d *ArrayDecoder) readElement() error {
+	d.buf = d.buf[:0]
+	ch, err := d.nextNonSpace()
+	if err != nil {
+		return d.unexpected(err)
+	}
+
+	switch ch {
+	case '{', '[':
+		return d.readComposite(ch)
+	case '"':
+		if err := d.push(ch); err != nil {
+			return err
+		}
+		return d.readString()
+	case ']', ',':
+		return fmt.Errorf("偏移%d处期望数组元素，实际为 %q", d.offset-1, ch)
+	}
+
+	// 字面量和数字读到分隔符为止
+	if err := d.push(ch); err != nil {
+		return err
+	}
+	for {
+		ch, err := d.readByte()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if ch == ',' || ch == ']' || ch == '}' || isStreamSpace(ch) {
+			d.unreadByte()
+			return nil
+		}
+		if err := d.push(ch); err != nil {
+			return err
+		}
+	}
+}
+
+// readComposite 读取对象或数组，直到对应的括号闭合
+func (d *ArrayDecoder) readComposite(open byte) error {
+	if err := d.push(open); err != nil {
+		return err
+	}
+	depth := 1
+	for depth > 0 {
+		ch, err := d.readByte()
+		if err != nil {
+			return d.unexpected(err)
+		}
+		if err := d.push(ch); err != nil {
+			return err
+		}
+		switch ch {
+		case '"':
+			if err := d.readString(); err != nil {
+				return err
+			}
+		case '{', '[':
+			depth++
+			if d.options.MaxDepth > 0 && depth > d.options.MaxDepth {
+				return fmt.Errorf("解析第%d个元素失败: %w", d.index, PARSE_MAX_DEPTH_EXCEEDED)
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+	return nil
+}
+
+// readString 读取字符串的剩余部分，开始的引号已经在 d.buf 中
+func (d *ArrayDecoder) readString() error {
+	for {
+		ch, err := d.readByte()
+		if err != nil {
+			return d.unexpected(err)
+		}
+		if err := d.push(ch); err != nil {
+			return err
+		}
+		switch ch {
+		case '"':
+			return nil
+		case '\\':
+			next, err := d.readByte()
+			if err != nil {
+				return d.unexpected(err)
+			}
+			if err := d.push(next); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// push 将 ch 追加到当前元素，元素超过大小限制时在继续缓冲之前返回 *MaxBytesError
+func (d *ArrayDecoder) push(ch byte) error {
+	if d.options.EnabledSecurity && d.options.MaxTotalSize > 0 && len(d.buf) >= d.options.MaxTotalSize {
+		return fmt.Errorf("解析第%d个元素失败: %w", d.index, &MaxBytesError{Limit: int64(d.options.MaxTotalSize)})
+	}
+	d.buf = append(d.buf, ch)
+	return nil
+}
+
+// nextNonSpace 跳过空白字符并返回下一个字节
+func (d *ArrayDecoder) nextNonSpace() (byte, error) {
+	for {
+		ch, err := d.readByte()
+		if err != nil || !isStreamSpace(ch) {
+			return ch, err
+		}
+	}
+}
+
+func (d *ArrayDecoder) readByte() (byte, error) {
+	ch, err := d.r.ReadByte()
+	if err == nil {
+		d.offset++
+	}
+	return ch, err
+}
+
+func (d *ArrayDecoder) unreadByte() {
+	d.r.UnreadByte()
+	d.offset--
+}
+
+// unexpected 将输入提前结束转换为 io.ErrUnexpectedEOF
+func (d *ArrayDecoder) unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func isStreamSpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
+}
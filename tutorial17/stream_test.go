@@ -0,0 +1,179 @@
+package leptjson
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// decodeAll 读取流中剩余的所有元素并序列化
+func decodeAll(t *testing.T, d *ArrayDecoder) []string {
+	t.Helper()
+	var out []string
+	for {
+		var v Value
+		err := d.Next(&v)
+		if err == io.EOF {
+			return out
+		}
+		if err != nil {
+			t.Fatalf("Next 失败: %v", err)
+		}
+		s, _ := Stringify(&v)
+		out = append(out, s)
+	}
+}
+
+func TestArrayDecoder(t *testing.T) {
+	input := ` [ 1 , "a,]\"b" , {"k": [1, {"x": "}"}]}, [], true,null , -2.5e3 ] `
+	d := NewArrayDecoder(strings.NewReader(input), DefaultParseOptions())
+	got := decodeAll(t, d)
+	expected := []string{`1`, `"a,]\"b"`, `{"k":[1,{"x":"}"}]}`, `[]`, `true`, `null`, `-2500`}
+	if strings.Join(got, "|") != strings.Join(expected, "|") {
+		t.Errorf("元素 = %v\n期望 %v", got, expected)
+	}
+	if d.Index() != len(expected) {
+		t.Errorf("Index = %d, 期望 %d", d.Index(), len(expected))
+	}
+	var v Value
+	if err := d.Next(&v); err != io.EOF {
+		t.Errorf("数组结束后应当一直返回 io.EOF: %v", err)
+	}
+
+	if got := decodeAll(t, NewArrayDecoder(strings.NewReader(`[]`), DefaultParseOptions())); len(got) != 0 {
+		t.Errorf("空数组不应当有元素: %v", got)
+	}
+}
+
+func TestArrayDecoderErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected error
+	}{
+		{`{"a": 1}`, ErrStreamNotArray},
+		{`[1, 2`, io.ErrUnexpectedEOF},
+		{`[1, "abc`, io.ErrUnexpectedEOF},
+		{`[1, {"a": [}]`, PARSE_INVALID_VALUE},
+		{`[1 2]`, nil},
+		{`[1,,2]`, nil},
+		{`[tru]`, PARSE_INVALID_VALUE},
+	}
+	for _, tt := range tests {
+		d := NewArrayDecoder(strings.NewReader(tt.input), DefaultParseOptions())
+		var err error
+		for err == nil {
+			var v Value
+			err = d.Next(&v)
+		}
+		if err == io.EOF {
+			t.Errorf("%s: 期望错误, 实际正常结束", tt.input)
+		} else if tt.expected != nil && !errors.Is(err, tt.expected) {
+			t.Errorf("%s: 错误 = %v, 期望 %v", tt.input, err, tt.expected)
+		}
+	}
+}
+
+func TestArrayDecoderSecurityLimitsPerElement(t *testing.T) {
+	opts := DefaultParseOptions()
+	opts.MaxArraySize = 3
+	// 顶层数组不受 MaxArraySize 限制
+	if got := decodeAll(t, NewArrayDecoder(strings.NewReader(`[1,2,3,4,5]`), opts)); len(got) != 5 {
+		t.Errorf("元素数量 = %d, 期望 5", len(got))
+	}
+	d := NewArrayDecoder(strings.NewReader(`[[1,2,3,4]]`), opts)
+	var v Value
+	if err := d.Next(&v); !errors.Is(err, PARSE_MAX_ARRAY_SIZE_EXCEEDED) {
+		t.Errorf("元素内部仍然受安全限制: %v", err)
+	}
+
+	// 过大的元素在缓冲时就被拒绝，不会读完整个元素
+	opts = DefaultParseOptions()
+	opts.MaxTotalSize = 8
+	d = NewArrayDecoder(strings.NewReader(`["123456", "`+strings.Repeat("x", 100)), opts)
+	decoded := &Value{}
+	if err := d.Next(decoded); err != nil {
+		t.Fatalf("未超过限制的元素: %v", err)
+	}
+	var tooLarge *MaxBytesError
+	if err := d.Next(decoded); !errors.As(err, &tooLarge) || tooLarge.Limit != 8 {
+		t.Errorf("超过 MaxTotalSize 的元素 error = %v", err)
+	}
+}
+
+func TestArrayDecoderCheckpointResume(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("[\n")
+	for i := 0; i < 10; i++ {
+		if i > 0 {
+			sb.WriteString(",\n")
+		}
+		fmt.Fprintf(&sb, `  {"id": %d, "name": "item %d"}`, i, i)
+	}
+	sb.WriteString("\n]\n")
+	input := sb.String()
+
+	// 每3个元素保存一次检查点，在第8个元素处“崩溃”
+	var saved Checkpoint
+	d := NewArrayDecoder(strings.NewReader(input), DefaultParseOptions())
+	d.SetCheckpointFunc(3, func(cp Checkpoint) error {
+		saved = cp
+		return nil
+	})
+	var processed []string
+	for {
+		var v Value
+		if err := d.Next(&v); err != nil {
+			t.Fatalf("Next 失败: %v", err)
+		}
+		if d.Index() == 8 {
+			break
+		}
+		s, _ := Stringify(&v)
+		processed = append(processed, s)
+	}
+	if saved.Index != 6 {
+		t.Fatalf("检查点 = %+v, 期望 Index 为 6", saved)
+	}
+
+	// 从检查点恢复，丢弃检查点之后处理过的结果
+	processed = processed[:saved.Index]
+	resumed, err := ResumeArrayDecoder(strings.NewReader(input), saved, DefaultParseOptions())
+	if err != nil {
+		t.Fatalf("ResumeArrayDecoder 失败: %v", err)
+	}
+	processed = append(processed, decodeAll(t, resumed)...)
+
+	expected := decodeAll(t, NewArrayDecoder(strings.NewReader(input), DefaultParseOptions()))
+	if strings.Join(processed, "|") != strings.Join(expected, "|") {
+		t.Errorf("恢复后的结果 = %v\n期望 %v", processed, expected)
+	}
+	if resumed.Index() != 10 {
+		t.Errorf("恢复后的 Index = %d, 期望 10", resumed.Index())
+	}
+
+	// 从 '[' 之后的检查点恢复
+	resumed, _ = ResumeArrayDecoder(strings.NewReader(input), Checkpoint{Offset: 1}, DefaultParseOptions())
+	if got := decodeAll(t, resumed); len(got) != 10 {
+		t.Errorf("从数组开头恢复应当得到全部元素: %d", len(got))
+	}
+
+	if _, err := ResumeArrayDecoder(strings.NewReader(input), Checkpoint{Offset: -1}, DefaultParseOptions()); err == nil {
+		t.Error("无效的检查点应当返回错误")
+	}
+}
+
+func TestArrayDecoderCheckpointFuncError(t *testing.T) {
+	stop := errors.New("stop")
+	d := NewArrayDecoder(strings.NewReader(`[1,2,3]`), DefaultParseOptions())
+	d.SetCheckpointFunc(2, func(cp Checkpoint) error { return stop })
+	var v Value
+	d.Next(&v)
+	if err := d.Next(&v); err != stop || v.N != 2 {
+		t.Errorf("检查点函数的错误应当被返回: %v", err)
+	}
+	if err := d.Next(&v); err != nil || v.N != 3 {
+		t.Errorf("返回错误后仍然可以继续解析: %v", err)
+	}
+}
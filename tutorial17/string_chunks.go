@@ -0,0 +1,151 @@
+// string_chunks.go - 分块保存的大字符串，避免分配大块连续内存
+package leptjson
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+)
+
+const (
+	stringChunkSize = 64 << 10 // 转义解码后的片段攒到这么长时成为一块
+	minDirectChunk  = 256      // 不含转义的片段至少这么长时作为单独的一块，输入是 Go 字符串时直接引用而不复制
+)
+
+// stringChunks 是分块保存的字符串
+//
+// 不含转义的长片段直接引用 JSON 文本的子串（ParseBytes 的输入除外），其余部分解码后按 stringChunkSize
+// 分块，因此解析几 MB 的字符串时既不需要复制整个字符串，也不需要在 strings.Builder
+// 扩容时反复分配和复制越来越大的缓冲区。Stringify 逐块输出，只有 GetString
+// 才会拼接出完整的字符串，拼接的结果被缓存。分块创建之后不再修改，可以在多个值之间共享。
+type stringChunks struct {
+	parts  []string
+	length int
+
+	once   sync.Once
+	joined string
+}
+
+// add 追加一块
+func (r *stringChunks) add(part string) {
+	if part != "" {
+		r.parts = append(r.parts, part)
+		r.length += len(part)
+	}
+}
+
+// String 返回拼接后的完整字符串
+func (r *stringChunks) String() string {
+	r.once.Do(func() {
+		var sb strings.Builder
+		sb.Grow(r.length)
+		for _, part := range r.parts {
+			sb.WriteString(part)
+		}
+		r.joined = sb.String()
+	})
+	return r.joined
+}
+
+// stringify 逐块转义后写入 buffer，不拼接完整的字符串
+func (r *stringChunks) stringify(buffer *bytes.Buffer) {
+	buffer.WriteByte('"')
+	for _, part := range r.parts {
+		escapeString(part, buffer)
+	}
+	buffer.WriteByte('"')
+}
+
+// MaterializeStrings 将 v 及其子值中分块保存的字符串拼接为普通字符串
+//
+// 分块保存的字符串只能通过 GetString 读取，它们的 S 字段为空；交给直接读取
+// S 字段的代码之前先调用 MaterializeStrings。
+func MaterializeStrings(v *Value) {
+	switch v.Type {
+	case STRING:
+		if v.chunks != nil {
+			SetString(v, v.chunks.String())
+		}
+	case ARRAY:
+		for _, elem := range v.A {
+			MaterializeStrings(elem)
+		}
+	case OBJECT:
+		for _, m := range v.O {
+			MaterializeStrings(m.V)
+		}
+	}
+}
+
+// stringEnd 返回从当前的双引号开始的字符串的结束引号位置，没有结束时返回 JSON 文本的长度
+func (c *parseContext) stringEnd() int {
+	for i := c.index + 1; i < len(c.json); i++ {
+		switch c.json[i] {
+		case '"':
+			return i
+		case '\\':
+			i++
+		}
+	}
+	return len(c.json)
+}
+
+// parseChunkedString 将当前位置的字符串解析为分块字符串
+func parseChunkedString(c *parseContext, v *Value) ParseError {
+	c.nextChar() // 跳过开始的双引号
+	chunks := &stringChunks{}
+	// 解码的片段先写入 buf，攒够一块后按实际长度复制出来，buf 重复使用
+	var buf bytes.Buffer
+	flush := func() {
+		if buf.Len() > 0 {
+			chunks.add(string(buf.Bytes()))
+			buf.Reset()
+		}
+	}
+
+	for {
+		// 不含转义和控制字符的片段
+		start := c.index
+		for c.index < len(c.json) {
+			ch := c.json[c.index]
+			if ch == '"' || ch == '\\' || (ch < 0x20 && !c.trusted) {
+				break
+			}
+			c.index++
+		}
+		c.column += c.index - start
+		if run := c.json[start:c.index]; len(run) >= minDirectChunk {
+			flush()
+			if c.borrowed {
+				// 输入可能在解析后被修改或解除映射，片段需要复制
+				run = string([]byte(run))
+			}
+			chunks.add(run)
+		} else {
+			buf.WriteString(run)
+		}
+
+		if c.index >= len(c.json) {
+			return PARSE_MISS_QUOTATION_MARK
+		}
+		switch c.nextChar() {
+		case '"':
+			flush()
+			if ok, errInfo := c.checkStringLength(chunks.length); !ok {
+				return errInfo.Code
+			}
+			SetString(v, "")
+			v.chunks = chunks
+			return PARSE_OK
+		case '\\':
+			if err := parseEscape(c, &buf); err != PARSE_OK {
+				return err
+			}
+		default:
+			return PARSE_INVALID_STRING_CHAR
+		}
+		if buf.Len() >= stringChunkSize {
+			flush()
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package leptjson
+
+import (
+	"strings"
+	"testing"
+)
+
+func chunkedOptions(threshold int) ParseOptions {
+	options := DefaultParseOptions()
+	options.EnabledSecurity = false
+	options.StringChunkThreshold = threshold
+	return options
+}
+
+func TestChunkedString(t *testing.T) {
+	long := strings.Repeat("plain text without escapes ", 100)
+	escaped := strings.Repeat(`a\"b\\c\né𝄞`, 20000)
+	json := `{"long":"` + long + `","escaped":"` + escaped + `","short":"x\ty"}`
+
+	want, got := &Value{}, &Value{}
+	if err := ParseWithOptions(want, json, chunkedOptions(0)); err != PARSE_OK {
+		t.Fatal(err)
+	}
+	if err := ParseWithOptions(got, json, chunkedOptions(1024)); err != PARSE_OK {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"long", "escaped", "short"} {
+		w, g := findObjectKey(want, key), findObjectKey(got, key)
+		if GetString(g) != GetString(w) {
+			t.Errorf("%s: GetString 与普通解析的结果不同", key)
+		}
+	}
+	if !Equal(got, want) {
+		t.Error("Equal(分块, 普通) = false")
+	}
+	if s1, _ := Stringify(got); s1 != json {
+		t.Error("Stringify(分块) 与原文不同")
+	}
+
+	// 只有超过阈值的字符串分块，长片段直接引用输入，解码的部分按块大小拆分
+	if findObjectKey(got, "short").chunks != nil {
+		t.Error("短字符串不应分块")
+	}
+	if c := findObjectKey(got, "long").chunks; c == nil || len(c.parts) != 1 || c.parts[0] != long {
+		t.Errorf("不含转义的字符串应直接引用输入: %+v", c)
+	}
+	c := findObjectKey(got, "escaped").chunks
+	if c == nil || len(c.parts) < 2 {
+		t.Fatalf("含转义的长字符串应分为多块")
+	}
+	for _, part := range c.parts {
+		if len(part) > stringChunkSize+8 {
+			t.Errorf("块长度 %d 超过 %d", len(part), stringChunkSize)
+		}
+	}
+}
+
+func TestChunkedStringCopyAndMaterialize(t *testing.T) {
+	v := &Value{}
+	if err := ParseWithOptions(v, `["`+strings.Repeat("ab", 600)+`"]`, chunkedOptions(100)); err != PARSE_OK {
+		t.Fatal(err)
+	}
+	elem := v.A[0]
+	if elem.S != "" || elem.chunks == nil {
+		t.Fatal("字符串应分块保存")
+	}
+
+	dup := &Value{}
+	Copy(dup, v)
+	if dup.A[0].chunks != elem.chunks || !Equal(dup, v) {
+		t.Error("Copy 应共享分块")
+	}
+
+	MaterializeStrings(v)
+	if elem.chunks != nil || elem.S != strings.Repeat("ab", 600) {
+		t.Error("MaterializeStrings 之后 S 应为完整字符串")
+	}
+	SetString(dup.A[0], "x")
+	if dup.A[0].chunks != nil || GetString(dup.A[0]) != "x" {
+		t.Error("SetString 应清除分块")
+	}
+}
+
+func TestChunkedStringErrors(t *testing.T) {
+	body := strings.Repeat("x", 300)
+	options := chunkedOptions(10)
+	for json, want := range map[string]ParseError{
+		`"` + body:              PARSE_MISS_QUOTATION_MARK,
+		`"` + body + "\x01\"":   PARSE_INVALID_STRING_CHAR,
+		`"` + body + `\q"`:      PARSE_INVALID_STRING_ESCAPE,
+		`"` + body + `\ud834x"`: PARSE_INVALID_UNICODE_SURROGATE,
+	} {
+		if err := ParseWithOptions(&Value{}, json, options); err != want {
+			t.Errorf("%q: %v, 期望 %v", json[len(json)-8:], err, want)
+		}
+	}
+
+	options.EnabledSecurity = true
+	options.MaxStringLength = 100
+	if err := ParseWithOptions(&Value{}, `"`+body+`"`, options); err != PARSE_MAX_STRING_LENGTH_EXCEEDED {
+		t.Errorf("超长字符串 = %v", err)
+	}
+}
+
+func benchmarkLargeString(b *testing.B, threshold int) {
+	json := `"` + strings.Repeat(`some text \"quoted\" and\n`, 1<<17) + `"`
+	options := chunkedOptions(threshold)
+	b.SetBytes(int64(len(json)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := ParseWithOptions(&Value{}, json, options); err != PARSE_OK {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLargeStringBuilder(b *testing.B) { benchmarkLargeString(b, 0) }
+func BenchmarkLargeStringChunked(b *testing.B) { benchmarkLargeString(b, 1<<16) }
+
+// chunkedValue 解析 json，其中所有非空字符串都分块保存，S 字段为空
+func chunkedValue(t *testing.T, json string) *Value {
+	t.Helper()
+	v := &Value{}
+	if err := ParseWithOptions(v, json, chunkedOptions(1)); err != PARSE_OK {
+		t.Fatalf("解析失败: %s", err.Error())
+	}
+	return v
+}
+
+func TestChunkedStringChecked(t *testing.T) {
+	v := chunkedValue(t, `"hello"`)
+	if v.chunks == nil {
+		t.Fatal("字符串应当分块保存")
+	}
+	if s, err := GetStringChecked(v); err != nil || s != "hello" {
+		t.Errorf("GetStringChecked = %q, %v, 期望 hello", s, err)
+	}
+}
+
+func TestChunkedStringFrozen(t *testing.T) {
+	frozen := Freeze(chunkedValue(t, `"hello"`))
+	if got := frozen.GetString(); got != "hello" {
+		t.Errorf("FrozenValue.GetString = %q, 期望 hello", got)
+	}
+
+	// 写操作失败前已经复制了根节点，复制时要保留分块
+	m := frozen.CloneMutable()
+	if err := m.Remove("/x"); err == nil {
+		t.Fatal("从字符串中删除成员应当失败")
+	}
+	if got, _ := m.Get(""); got.GetString() != "hello" {
+		t.Errorf("复制后的 GetString = %q, 期望 hello", got.GetString())
+	}
+}
+
+func TestChunkedStringFilterComparison(t *testing.T) {
+	doc := chunkedValue(t, `["ab", "0"]`)
+	if got := queryStrings(t, doc, `$[?@ > 'a']`); len(got) != 1 || got[0] != `"ab"` {
+		t.Errorf("比较的结果 = %q, 期望 [ab]", got)
+	}
+}
+
+func TestChunkedStringFilterRegex(t *testing.T) {
+	doc := chunkedValue(t, `[{"name": "alice", "pattern": "^a"}, {"name": "bob", "pattern": "^a"}]`)
+	if got := queryStrings(t, doc, `$[?@.name =~ /^a/].name`); len(got) != 1 || got[0] != `"alice"` {
+		t.Errorf("=~ 字面量的结果 = %q, 期望 [alice]", got)
+	}
+	if got := queryStrings(t, doc, `$[?@.name =~ @.pattern].name`); len(got) != 1 || got[0] != `"alice"` {
+		t.Errorf("=~ 表达式的结果 = %q, 期望 [alice]", got)
+	}
+}
+
+func TestChunkedStringFilterLength(t *testing.T) {
+	doc := chunkedValue(t, `["abc", "de"]`)
+	if got := queryStrings(t, doc, `$[?length(@) == 3]`); len(got) != 1 || got[0] != `"abc"` {
+		t.Errorf("length() 的结果 = %q, 期望 [abc]", got)
+	}
+}
+
+func TestChunkedStringFilterMatch(t *testing.T) {
+	doc := chunkedValue(t, `[{"s": "abc", "p": "a.c"}, {"s": "xbc", "p": "a.c"}]`)
+	if got := queryStrings(t, doc, `$[?match(@.s, @.p)].s`); len(got) != 1 || got[0] != `"abc"` {
+		t.Errorf("match() 的结果 = %q, 期望 [abc]", got)
+	}
+	if got := queryStrings(t, doc, `$[?search(@.s, 'x')].s`); len(got) != 1 || got[0] != `"xbc"` {
+		t.Errorf("search() 的结果 = %q, 期望 [xbc]", got)
+	}
+}
+
+func TestChunkedStringSortResults(t *testing.T) {
+	doc := chunkedValue(t, `["b", "a", "10", "9"]`)
+	tests := []struct {
+		mode SortMode
+		want string
+	}{
+		{SORT_AUTO, "10 9 a b"},
+		{SORT_NUMERIC, "9 10 a b"},
+		{SORT_STRING, "10 9 a b"},
+	}
+	for _, tt := range tests {
+		sorted, err := SortResults(doc.A, "", false, tt.mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, v := range sorted {
+			got = append(got, GetString(v))
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("SortResults(%v) = %q, 期望 %s", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestChunkedStringGroupValues(t *testing.T) {
+	doc := chunkedValue(t, `[{"k": "x"}, {"k": "y"}, {"k": "x"}]`)
+	groups, err := GroupValues(doc.A, "@.k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 2 || groups[0].Key != "x" || len(groups[0].Values) != 2 || groups[1].Key != "y" {
+		t.Errorf("GroupValues = %+v", groups)
+	}
+}
+
+func TestChunkedStringAudit(t *testing.T) {
+	root := chunkedValue(t, `{"defs": {"a": [1, 2]}, "long": "`+strings.Repeat("x", 100)+`", "ref": {"$ref": "#/defs/a"}}`)
+	a := &auditor{options: AuditOptions{MaxStringLength: 50, MaxFindings: 10}, report: &AuditReport{}}
+	a.walk(root, "$", 0)
+	if len(a.report.Findings) != 1 || a.report.Findings[0].Kind != AUDIT_LONG_STRING {
+		t.Errorf("审计结果 = %+v, 期望一个过长字符串", a.report.Findings)
+	}
+	if !a.hasRefs {
+		t.Error("应当发现 $ref")
+	}
+	if target := localRefTarget(root, findObjectKey(root, "ref")); target == nil || target.Type != ARRAY {
+		t.Errorf("localRefTarget = %v, 期望 /defs/a", target)
+	}
+}
+
+func TestChunkedStringContentKeywords(t *testing.T) {
+	schema := chunkedValue(t, `{"contentEncoding": "base64", "contentMediaType": "application/json"}`)
+	doc, keyword, err := decodeContent(schema, "eyJhIjoxfQ==")
+	if err != nil || keyword != "" || doc == nil || doc.Type != OBJECT {
+		t.Errorf("decodeContent = %v, %q, %v, 期望解码后的对象", doc, keyword, err)
+	}
+}
+
+func TestChunkedStringServerQuery(t *testing.T) {
+	options := DefaultServerOptions()
+	options.ParseOptions.StringChunkThreshold = 1
+	s := NewServer(options)
+	status, body := serverRequest(t, s, "POST", "/query", `{"path": "$.a", "document": {"a": 1}}`)
+	if status != 200 || !strings.Contains(body, "[1]") {
+		t.Errorf("POST /query = %d %s", status, body)
+	}
+}
+
+func TestChunkedStringUnmarshal(t *testing.T) {
+	long := strings.Repeat("long text ", 100)
+	v := &Value{}
+	if err := ParseWithOptions(v, `{"name": "`+long+`", "count": "42", "tags": {"k": "`+long+`"}}`, chunkedOptions(64)); err != PARSE_OK {
+		t.Fatalf("解析失败: %s", err.Error())
+	}
+	var dst struct {
+		Name  string            `json:"name"`
+		Count int               `json:"count,string"`
+		Tags  map[string]string `json:"tags"`
+	}
+	if err := UnmarshalValue(v, &dst, UnmarshalOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if dst.Name != long || dst.Count != 42 || dst.Tags["k"] != long {
+		t.Errorf("UnmarshalValue = %q %d %q", dst.Name, dst.Count, dst.Tags["k"])
+	}
+	var generic interface{}
+	if err := UnmarshalValue(GetObjectValueByKey(v, "name"), &generic, UnmarshalOptions{}); err != nil || generic != long {
+		t.Errorf("UnmarshalValue(interface{}) = %q, %v", generic, err)
+	}
+}
+
+func TestChunkedStringRFC9535Match(t *testing.T) {
+	long := strings.Repeat("abc", 100)
+	doc := &Value{}
+	if err := ParseWithOptions(doc, `[{"s": "`+long+`", "p": "(abc)+"}, {"s": "x`+long+`", "p": "(abc)+"}]`, chunkedOptions(64)); err != PARSE_OK {
+		t.Fatalf("解析失败: %s", err.Error())
+	}
+	for path, want := range map[string]int{
+		`$[?match(@.s, @.p)]`:    1,
+		`$[?match(@.s, 'x.*')]`:  1,
+		`$[?search(@.s, 'cab')]`: 2,
+	} {
+		jp, err := NewJSONPathWithOptions(path, JSONPathOptions{RFC9535: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		results, err := jp.Query(doc)
+		if err != nil || len(results) != want {
+			t.Errorf("%s 的结果数 = %d, %v, 期望 %d", path, len(results), err, want)
+		}
+	}
+}
+
+func TestChunkedStringProperties(t *testing.T) {
+	long := strings.Repeat("long text ", 100)
+	v := &Value{}
+	if err := ParseWithOptions(v, `{"app": {"title": "`+long+`"}}`, chunkedOptions(64)); err != PARSE_OK {
+		t.Fatalf("解析失败: %s", err.Error())
+	}
+	got, err := StringifyProperties(v)
+	if err != nil || !strings.Contains(got, "app.title="+strings.TrimSpace(long)) {
+		t.Errorf("StringifyProperties = %q, %v", got, err)
+	}
+}
@@ -0,0 +1,188 @@
+// string_table.go - 把重复的字符串值提取到字典中，用下标引用（应用层压缩）
+package leptjson
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 字符串表编码结果的结构：
+//
+//	{"strings": ["active", "Acme Corporation"], "data": [{"status": "#0", "vendor": "#1"}, ...]}
+//
+// data 中以 StringTableMarker 开头、后面是十进制下标的字符串引用字典中的字符串；
+// 原本以 StringTableMarker 开头的字符串在前面再加一个 StringTableMarker。
+const (
+	StringTableKey     = "strings"
+	StringTableDataKey = "data"
+	StringTableMarker  = "#"
+)
+
+// StringTableOptions 控制 EncodeStringTable 把哪些字符串放入字典
+type StringTableOptions struct {
+	MinCount  int // 至少出现这么多次的字符串才放入字典，不大于 1 时为 2
+	MinLength int // 至少有这么多字节的字符串才放入字典
+}
+
+// EncodeStringTable 返回把 v 中重复的字符串值替换为字典下标后的文档，v 不会被修改
+//
+// 出现次数越多的字符串下标越短；只有替换后总长度确实变短的字符串才放入字典。
+// 对象的键不变。结果仍是普通的JSON，可以再用 gzip 等通用算法压缩，
+// 适合向带宽有限的客户端发送包含大量重复枚举值、名称的数据。用 DecodeStringTable 还原。
+func EncodeStringTable(v *Value, options StringTableOptions) *Value {
+	minCount := options.MinCount
+	if minCount < 2 {
+		minCount = 2
+	}
+	counts := make(map[string]int)
+	countStrings(v, counts)
+
+	type candidate struct {
+		s      string
+		count  int
+		quoted int // 序列化后的字节数
+	}
+	var candidates []candidate
+	for s, count := range counts {
+		if count >= minCount && len(s) >= options.MinLength {
+			candidates = append(candidates, candidate{s, count, quotedLength(s)})
+		}
+	}
+	// 可能节省的字节数多的在前，得到较短的下标
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.count*a.quoted != b.count*b.quoted {
+			return a.count*a.quoted > b.count*b.quoted
+		}
+		return a.s < b.s
+	})
+
+	table := make(map[string]string)
+	var dictionary []string
+	for _, c := range candidates {
+		ref := StringTableMarker + strconv.Itoa(len(dictionary))
+		// 每次出现节省的字节数乘以次数，要超过字典中多出的一项（字符串和逗号）
+		if c.count*(c.quoted-quotedLength(ref)) <= c.quoted+1 {
+			continue
+		}
+		table[c.s] = ref
+		dictionary = append(dictionary, c.s)
+	}
+
+	result := &Value{}
+	SetObject(result)
+	strs := SetObjectValue(result, StringTableKey)
+	SetArray(strs, len(dictionary))
+	for _, s := range dictionary {
+		strs.A = append(strs.A, &Value{Type: STRING, S: s})
+	}
+	data := SetObjectValue(result, StringTableDataKey)
+	Copy(data, v)
+	encodeStrings(data, table)
+	return result
+}
+
+// countStrings 统计 v 中每个字符串值出现的次数
+func countStrings(v *Value, counts map[string]int) {
+	switch v.Type {
+	case STRING:
+		counts[GetString(v)]++
+	case ARRAY:
+		for _, e := range v.A {
+			countStrings(e, counts)
+		}
+	case OBJECT:
+		for _, m := range v.O {
+			countStrings(m.V, counts)
+		}
+	}
+}
+
+// quotedLength 返回字符串序列化后的字节数
+func quotedLength(s string) int {
+	text, _ := Stringify(&Value{Type: STRING, S: s})
+	return len(text)
+}
+
+// encodeStrings 原地把 v 中的字符串替换为引用，并转义以 StringTableMarker 开头的字符串
+func encodeStrings(v *Value, table map[string]string) {
+	switch v.Type {
+	case STRING:
+		s := GetString(v)
+		if ref, ok := table[s]; ok {
+			SetString(v, ref)
+		} else if strings.HasPrefix(s, StringTableMarker) {
+			SetString(v, StringTableMarker+s)
+		}
+	case ARRAY:
+		for _, e := range v.A {
+			encodeStrings(e, table)
+		}
+	case OBJECT:
+		for _, m := range v.O {
+			encodeStrings(m.V, table)
+		}
+	}
+}
+
+// DecodeStringTable 还原 EncodeStringTable 的结果，结构无效或下标超出字典时返回错误
+func DecodeStringTable(encoded *Value) (*Value, error) {
+	if encoded == nil || encoded.Type != OBJECT {
+		return nil, fmt.Errorf("字符串表必须是对象")
+	}
+	strs := GetObjectValueByKey(encoded, StringTableKey)
+	data := GetObjectValueByKey(encoded, StringTableDataKey)
+	if strs == nil || strs.Type != ARRAY || data == nil {
+		return nil, fmt.Errorf("字符串表必须包含 %s 数组和 %s 成员", StringTableKey, StringTableDataKey)
+	}
+	dictionary := make([]string, len(strs.A))
+	for i, s := range strs.A {
+		if s.Type != STRING {
+			return nil, fmt.Errorf("%s[%d] 不是字符串", StringTableKey, i)
+		}
+		dictionary[i] = GetString(s)
+	}
+
+	result := &Value{}
+	Copy(result, data)
+	if err := decodeStrings(result, dictionary, "$"); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// decodeStrings 原地把 v 中的引用替换为字典中的字符串
+func decodeStrings(v *Value, dictionary []string, path string) error {
+	switch v.Type {
+	case STRING:
+		s := GetString(v)
+		if !strings.HasPrefix(s, StringTableMarker) {
+			return nil
+		}
+		rest := s[len(StringTableMarker):]
+		if strings.HasPrefix(rest, StringTableMarker) {
+			SetString(v, rest)
+			return nil
+		}
+		index, err := strconv.Atoi(rest)
+		if err != nil || index < 0 || index >= len(dictionary) || rest != strconv.Itoa(index) {
+			return fmt.Errorf("路径 %s: 无效的字符串引用 %s", path, s)
+		}
+		SetString(v, dictionary[index])
+	case ARRAY:
+		for i, e := range v.A {
+			if err := decodeStrings(e, dictionary, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case OBJECT:
+		for _, m := range v.O {
+			if err := decodeStrings(m.V, dictionary, path+"."+m.K); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
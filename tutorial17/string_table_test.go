@@ -0,0 +1,89 @@
+package leptjson
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestEncodeStringTable(t *testing.T) {
+	doc := mustParse(t, `[
+		{"status": "active", "vendor": "Acme Corporation", "note": "#1", "id": 1},
+		{"status": "active", "vendor": "Acme Corporation", "note": "x", "id": 2},
+		{"status": "inactive", "vendor": "Acme Corporation", "note": "x", "id": 3}
+	]`)
+	original := &Value{}
+	Copy(original, doc)
+
+	encoded := EncodeStringTable(doc, StringTableOptions{})
+	text, _ := Stringify(encoded)
+	// "active" 只出现两次，替换节省的字节数不足以抵消字典中的一项
+	expected := `{"strings":["Acme Corporation"],"data":[` +
+		`{"status":"active","vendor":"#0","note":"##1","id":1},` +
+		`{"status":"active","vendor":"#0","note":"x","id":2},` +
+		`{"status":"inactive","vendor":"#0","note":"x","id":3}]}`
+	if text != expected {
+		t.Errorf("EncodeStringTable = %s, 期望 %s", text, expected)
+	}
+	if !Equal(doc, original) {
+		t.Errorf("EncodeStringTable 不应该修改原文档")
+	}
+
+	decoded, err := DecodeStringTable(encoded)
+	if err != nil {
+		t.Fatalf("DecodeStringTable 失败: %v", err)
+	}
+	if !Equal(decoded, original) {
+		s, _ := Stringify(decoded)
+		t.Errorf("DecodeStringTable = %s", s)
+	}
+
+	// 提高次数要求后没有字符串放入字典
+	encoded = EncodeStringTable(doc, StringTableOptions{MinCount: 4})
+	if strs := GetObjectValueByKey(encoded, StringTableKey); len(strs.A) != 0 {
+		t.Errorf("MinCount=4 时字典 = %v", strs.A)
+	}
+}
+
+func TestEncodeStringTableRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	words := []string{"#", "##", "#0", "#12", "a", "status", "很长的中文字符串", "Acme Corporation", "x\"y", ""}
+	for i := 0; i < 200; i++ {
+		parts := make([]string, r.Intn(40))
+		for j := range parts {
+			parts[j] = fmt.Sprintf("%q", words[r.Intn(len(words))])
+		}
+		doc := mustParse(t, "["+strings.Join(parts, ",")+"]")
+		encoded := EncodeStringTable(doc, StringTableOptions{})
+		decoded, err := DecodeStringTable(encoded)
+		if err != nil {
+			t.Fatalf("DecodeStringTable 失败: %v", err)
+		}
+		if !Equal(decoded, doc) {
+			t.Fatalf("往返后不同: %v", parts)
+		}
+		before, _ := Stringify(doc)
+		after, _ := Stringify(GetObjectValueByKey(encoded, StringTableDataKey))
+		dict, _ := Stringify(GetObjectValueByKey(encoded, StringTableKey))
+		// 除了转义的字符串，字典只在能缩短文档时使用
+		if escaped := strings.Count(before, `"#`); len(after)+len(dict)-2 > len(before)+escaped {
+			t.Fatalf("编码后变长: %s → %s %s", before, dict, after)
+		}
+	}
+}
+
+func TestDecodeStringTableErrors(t *testing.T) {
+	for _, input := range []string{
+		`[]`,
+		`{"strings": ["a"]}`,
+		`{"strings": [1], "data": "#0"}`,
+		`{"strings": ["a"], "data": ["#1"]}`,
+		`{"strings": ["a"], "data": {"k": "#x"}}`,
+		`{"strings": ["a"], "data": "#00"}`,
+	} {
+		if _, err := DecodeStringTable(mustParse(t, input)); err == nil {
+			t.Errorf("DecodeStringTable(%s) 应该返回错误", input)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+// stringify_cache.go - 记录文档中被修改的子树，重新序列化时复用没有变化的部分
+package leptjson
+
+import (
+	"bytes"
+)
+
+// stringifyChunkSize 是缓存序列化结果的子树的最大字节数
+//
+// 不超过这个大小的子树整体缓存为一段字节；更大的对象和数组只缓存各个成员，
+// 这样每个字节最多被缓存一次，缓存占用的内存与文档的大小相当。
+const stringifyChunkSize = 64 << 10
+
+// stringifyNode 与文档中的一个对象或数组对应，被修改的子树 data 为 nil
+type stringifyNode struct {
+	data    []byte                    // 整个子树序列化后的字节
+	elems   []*stringifyNode          // 较大的数组的元素，与 Value.A 对齐
+	members map[string]*stringifyNode // 较大的对象的成员，按键查找
+}
+
+// stringifyCache 是文档序列化结果的缓存，根节点与文档的根值对应
+type stringifyCache struct {
+	root *stringifyNode
+}
+
+func newStringifyCache() *stringifyCache {
+	return &stringifyCache{root: &stringifyNode{}}
+}
+
+// reset 丢弃所有缓存，用于整个文档被替换的情况
+func (c *stringifyCache) reset() {
+	c.root = &stringifyNode{}
+}
+
+// invalidate 根据修改步骤将被修改的子树和它们的所有祖先标记为脏
+//
+// effects 必须按步骤的顺序排列，每个步骤的路径都是执行这个步骤时文档中的路径。
+func (c *stringifyCache) invalidate(effects []deltaEffect) {
+	for _, e := range effects {
+		if e.kind != deltaReplace {
+			// 数组自身被修改，在元素之间插入或删除对应的缓存
+			if node := c.dirtyPath(e.path); node != nil && node.elems != nil {
+				node.elems = spliceStringifyNodes(node.elems, e.index, e.kind == deltaInsert)
+			}
+			continue
+		}
+		parent := c.dirtyPath(e.path[:len(e.path)-1])
+		if parent == nil {
+			continue
+		}
+		token := e.path[len(e.path)-1]
+		if parent.members != nil {
+			delete(parent.members, token)
+		} else if index, ok := parseArrayIndexToken(token, false); ok && index < len(parent.elems) {
+			parent.elems[index] = nil
+		}
+	}
+}
+
+// dirtyPath 将路径上的每个节点标记为脏并返回路径末端的节点，没有缓存时返回 nil
+func (c *stringifyCache) dirtyPath(path []string) *stringifyNode {
+	node := c.root
+	node.data = nil
+	for _, token := range path {
+		var child *stringifyNode
+		if node.members != nil {
+			child = node.members[token]
+		} else if index, ok := parseArrayIndexToken(token, false); ok && index < len(node.elems) {
+			child = node.elems[index]
+		}
+		if child == nil {
+			return nil
+		}
+		node = child
+		node.data = nil
+	}
+	return node
+}
+
+// spliceStringifyNodes 在 index 处插入一个空位或删除一个元素
+func spliceStringifyNodes(elems []*stringifyNode, index int, insert bool) []*stringifyNode {
+	if insert {
+		if index > len(elems) {
+			return nil
+		}
+		elems = append(elems, nil)
+		copy(elems[index+1:], elems[index:])
+		elems[index] = nil
+		return elems
+	}
+	if index >= len(elems) {
+		return nil
+	}
+	return append(elems[:index], elems[index+1:]...)
+}
+
+// stringify 序列化 v，复用没有被修改的子树的缓存
+func (c *stringifyCache) stringify(v *Value) string {
+	var buffer bytes.Buffer
+	c.root.write(v, &buffer)
+	return buffer.String()
+}
+
+// write 将 v 写入 buffer，并缓存写入的字节
+func (n *stringifyNode) write(v *Value, buffer *bytes.Buffer) {
+	if n.data != nil {
+		buffer.Write(n.data)
+		return
+	}
+	start := buffer.Len()
+	switch v.Type {
+	case ARRAY:
+		if len(n.elems) != len(v.A) {
+			// 缓存与文档不一致时重新建立
+			n.elems = make([]*stringifyNode, len(v.A))
+		}
+		buffer.WriteByte('[')
+		for i, elem := range v.A {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			n.elems[i] = writeStringifyChild(n.elems[i], elem, buffer)
+		}
+		buffer.WriteByte(']')
+	case OBJECT:
+		members := make(map[string]*stringifyNode, len(v.O))
+		buffer.WriteByte('{')
+		for i, m := range v.O {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			stringifyString(m.K, buffer)
+			buffer.WriteByte(':')
+			if _, dup := members[m.K]; dup {
+				// 重复的键无法按键对应，不缓存
+				stringifyValue(m.V, buffer)
+				continue
+			}
+			members[m.K] = writeStringifyChild(n.members[m.K], m.V, buffer)
+		}
+		buffer.WriteByte('}')
+		n.members = members
+	default:
+		stringifyValue(v, buffer)
+		return
+	}
+
+	if buffer.Len()-start <= stringifyChunkSize {
+		// 整体缓存，不再需要成员的缓存
+		n.data = append([]byte(nil), buffer.Bytes()[start:]...)
+		n.elems, n.members = nil, nil
+	}
+}
+
+// writeStringifyChild 写入对象成员或数组元素，返回它的缓存节点
+//
+// 标量直接序列化，不建立缓存节点。
+func writeStringifyChild(n *stringifyNode, v *Value, buffer *bytes.Buffer) *stringifyNode {
+	if v.Type != ARRAY && v.Type != OBJECT {
+		stringifyValue(v, buffer)
+		return nil
+	}
+	if n == nil {
+		n = &stringifyNode{}
+	}
+	n.write(v, buffer)
+	return n
+}
+
+// SetStringifyCache 开启或关闭 String 的增量序列化
+//
+// 开启后文档记录每次修改涉及的子树，String 只重新序列化被修改过的部分，
+// 其余部分复用上一次的结果。适合对大文档反复做小的修改并序列化的场景，
+// 代价是缓存占用与序列化结果相当的内存。
+func (d *Document) SetStringifyCache(enabled bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !enabled {
+		d.stringCache = nil
+	} else if d.stringCache == nil {
+		d.stringCache = newStringifyCache()
+	}
+}
+
+// markDirtyLocked 将修改步骤涉及的子树标记为脏，调用者必须持有写锁并且已经完成修改
+func (d *Document) markDirtyLocked(steps []deltaStep) {
+	if d.stringCache == nil {
+		return
+	}
+	effects, _ := analyzeDelta(d.root, steps)
+	d.stringCache.invalidate(effects)
+}
+
+// resetDirtyLocked 在整个文档被替换后丢弃序列化缓存，调用者必须持有写锁
+func (d *Document) resetDirtyLocked() {
+	if d.stringCache != nil {
+		d.stringCache.reset()
+	}
+}
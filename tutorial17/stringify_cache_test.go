@@ -0,0 +1,90 @@
+package leptjson
+
+import (
+	"testing"
+)
+
+// checkDocumentString 比较增量序列化与完整序列化的结果
+func checkDocumentString(t *testing.T, doc *Document, step string) {
+	t.Helper()
+	want, _ := Stringify(doc.Snapshot())
+	if got := doc.String(); got != want {
+		t.Fatalf("%s 之后增量序列化的结果不同:\n%.200s\n%.200s", step, got, want)
+	}
+}
+
+func TestDocumentStringifyCache(t *testing.T) {
+	// 超过 stringifyChunkSize，根数组按元素缓存
+	doc, _ := ParseDocument(`{"items":` + largeArray(8000) + `,"meta":{"n":1}}`)
+	doc.SetStringifyCache(true)
+	checkDocumentString(t, doc, "第一次序列化")
+
+	value := &Value{}
+	Parse(value, `{"a":[2,"y"]}`)
+	steps := []struct {
+		name string
+		run  func() error
+	}{
+		{"Set 对象成员", func() error { return doc.Set("/meta/n", value) }},
+		{"Set 插入数组元素", func() error { return doc.Set("/items/3", value) }},
+		{"Set 追加数组元素", func() error { return doc.Set("/items/-", value) }},
+		{"Remove 数组元素", func() error { return doc.Remove("/items/0") }},
+		{"Patch", func() error {
+			patch, _ := NewJSONPatchFromString(`[
+				{"op":"replace","path":"/items/5/a/0","value":7},
+				{"op":"add","path":"/items/-","value":{"a":[]}},
+				{"op":"move","from":"/items/2","path":"/items/7000"},
+				{"op":"remove","path":"/items/1"},
+				{"op":"copy","from":"/meta","path":"/items/0/meta"},
+				{"op":"test","path":"/items/0/a/0","value":1}
+			]`)
+			return doc.Patch(patch)
+		}},
+		{"Patch 替换整个文档", func() error {
+			patch, _ := NewJSONPatchFromString(`[{"op":"replace","path":"","value":[1,{"b":2}]}]`)
+			return doc.Patch(patch)
+		}},
+		{"Update", func() error {
+			return doc.Update(func(root *Value) error {
+				root.A[1].O[0].V.N = 3
+				return nil
+			})
+		}},
+	}
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		checkDocumentString(t, doc, step.name)
+	}
+}
+
+func TestStringifyCacheReusesUnchangedSubtrees(t *testing.T) {
+	doc, _ := ParseDocument(largeArray(8000))
+	doc.SetStringifyCache(true)
+	checkDocumentString(t, doc, "第一次序列化")
+
+	root := doc.stringCache.root
+	if root.data != nil || len(root.elems) != 8000 || root.elems[0].data == nil {
+		t.Fatal("大数组应当按元素缓存")
+	}
+	first, last := root.elems[0], root.elems[7999]
+
+	value := &Value{}
+	SetString(value, "z")
+	doc.Set("/100/a/1", value)
+	if root.elems[100].data != nil || root.elems[0] != first || first.data == nil {
+		t.Error("只有被修改的元素应当标记为脏")
+	}
+	doc.Set("/0", value)
+	if root.elems[1] != first || root.elems[8000] != last || root.elems[0] != nil {
+		t.Error("插入元素后缓存没有随之移动")
+	}
+	checkDocumentString(t, doc, "插入元素")
+
+	doc.SetStringifyCache(false)
+	if doc.stringCache != nil {
+		t.Error("关闭后应当丢弃缓存")
+	}
+	checkDocumentString(t, doc, "关闭缓存")
+}
@@ -0,0 +1,191 @@
+// stringify_options.go - 可配置的JSON字符串化（缩进、键排序、输出风格）
+package leptjson
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// KeyComparator 对象键比较函数，当 a 应排在 b 之前时返回 true
+type KeyComparator func(a, b string) bool
+
+// StringifyOptions 字符串化选项
+type StringifyOptions struct {
+	Indent        string        // 每一级的缩进字符串，为空时输出紧凑格式
+	KeyComparator KeyComparator // 对象键排序规则，为nil时保持原有顺序
+	Style         OutputStyle   // 输出风格，STYLE_JSON5 在缩进格式下输出尾随逗号，标识符形式的键不加引号
+}
+
+// DefaultStringifyOptions 返回默认的字符串化选项（紧凑格式，保持键顺序）
+func DefaultStringifyOptions() StringifyOptions {
+	return StringifyOptions{}
+}
+
+// 已注册的键比较函数
+var (
+	keyComparatorsMu sync.RWMutex
+	keyComparators   = map[string]KeyComparator{
+		"alpha":   AlphabeticalKeyComparator,
+		"natural": NaturalKeyComparator,
+		"collate": CollationKeyComparator,
+	}
+)
+
+// AlphabeticalKeyComparator 按字典序比较键
+func AlphabeticalKeyComparator(a, b string) bool {
+	return a < b
+}
+
+// NewKeyOrderComparator 创建一个按优先级排序的比较函数
+//
+// priority 中列出的键按给定顺序排在最前面，其余的键按字典序排在后面。
+// 例如 NewKeyOrderComparator("id", "name") 会让 "id"、"name" 总是排在前两位。
+func NewKeyOrderComparator(priority ...string) KeyComparator {
+	rank := make(map[string]int, len(priority))
+	for i, key := range priority {
+		if _, exists := rank[key]; !exists {
+			rank[key] = i
+		}
+	}
+
+	return func(a, b string) bool {
+		ra, okA := rank[a]
+		rb, okB := rank[b]
+		switch {
+		case okA && okB:
+			return ra < rb
+		case okA:
+			return true
+		case okB:
+			return false
+		default:
+			return a < b
+		}
+	}
+}
+
+// RegisterKeyComparator 以指定名称注册键比较函数，已存在的同名比较函数会被替换
+func RegisterKeyComparator(name string, cmp KeyComparator) error {
+	if name == "" {
+		return fmt.Errorf("比较函数名称不能为空")
+	}
+	if cmp == nil {
+		return fmt.Errorf("比较函数不能为空")
+	}
+
+	keyComparatorsMu.Lock()
+	defer keyComparatorsMu.Unlock()
+	keyComparators[name] = cmp
+	return nil
+}
+
+// LookupKeyComparator 查找已注册的键比较函数
+func LookupKeyComparator(name string) (KeyComparator, bool) {
+	keyComparatorsMu.RLock()
+	defer keyComparatorsMu.RUnlock()
+	cmp, ok := keyComparators[name]
+	return cmp, ok
+}
+
+// StringifyWithOptions 按照选项将Value转换为JSON字符串
+func StringifyWithOptions(v *Value, opts StringifyOptions) (string, StringifyError) {
+	if v == nil {
+		return "", STRINGIFY_OK
+	}
+
+	logger := currentLogger()
+	var start time.Time
+	if logger != nil {
+		start = time.Now()
+	}
+
+	var buffer bytes.Buffer
+	stringifyValueWithOptions(v, &buffer, &opts, 0)
+	if logger != nil {
+		logStringify(logger, buffer.Len(), start, v)
+	}
+	return buffer.String(), STRINGIFY_OK
+}
+
+// stringifyValueWithOptions 按照选项将Value写入Buffer
+func stringifyValueWithOptions(v *Value, buffer *bytes.Buffer, opts *StringifyOptions, level int) {
+	switch v.Type {
+	case ARRAY:
+		if len(v.A) == 0 {
+			buffer.WriteString("[]")
+			return
+		}
+		buffer.WriteByte('[')
+		for i, elem := range v.A {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			writeNewlineIndent(buffer, opts.Indent, level+1)
+			stringifyValueWithOptions(elem, buffer, opts, level+1)
+		}
+		writeTrailingComma(buffer, opts)
+		writeNewlineIndent(buffer, opts.Indent, level)
+		buffer.WriteByte(']')
+	case OBJECT:
+		if len(v.O) == 0 {
+			buffer.WriteString("{}")
+			return
+		}
+		buffer.WriteByte('{')
+		for i, member := range sortedMembers(v.O, opts.KeyComparator) {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			writeNewlineIndent(buffer, opts.Indent, level+1)
+			if opts.Style == STYLE_JSON5 && isJSON5Identifier(member.K) {
+				buffer.WriteString(member.K)
+			} else {
+				stringifyString(member.K, buffer)
+			}
+			buffer.WriteByte(':')
+			if opts.Indent != "" {
+				buffer.WriteByte(' ')
+			}
+			stringifyValueWithOptions(member.V, buffer, opts, level+1)
+		}
+		writeTrailingComma(buffer, opts)
+		writeNewlineIndent(buffer, opts.Indent, level)
+		buffer.WriteByte('}')
+	default:
+		stringifyValue(v, buffer)
+	}
+}
+
+// writeNewlineIndent 在缩进模式下写入换行和缩进
+func writeNewlineIndent(buffer *bytes.Buffer, indent string, level int) {
+	if indent == "" {
+		return
+	}
+	buffer.WriteByte('\n')
+	buffer.WriteString(strings.Repeat(indent, level))
+}
+
+// writeTrailingComma 在 JSON5 风格的缩进模式下，在最后一个元素后写入逗号
+func writeTrailingComma(buffer *bytes.Buffer, opts *StringifyOptions) {
+	if opts.Style == STYLE_JSON5 && opts.Indent != "" {
+		buffer.WriteByte(',')
+	}
+}
+
+// sortedMembers 返回按比较函数稳定排序后的成员列表，不修改原对象
+func sortedMembers(members []Member, cmp KeyComparator) []Member {
+	if cmp == nil || len(members) < 2 {
+		return members
+	}
+
+	sorted := make([]Member, len(members))
+	copy(sorted, members)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return cmp(sorted[i].K, sorted[j].K)
+	})
+	return sorted
+}
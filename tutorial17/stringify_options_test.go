@@ -0,0 +1,92 @@
+package leptjson
+
+import (
+	"testing"
+)
+
+func TestStringifyWithOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     StringifyOptions
+		expected string
+	}{
+		{
+			name:     "默认选项与Stringify一致",
+			input:    `{"b":1,"a":[true,null,"x"]}`,
+			opts:     DefaultStringifyOptions(),
+			expected: `{"b":1,"a":[true,null,"x"]}`,
+		},
+		{
+			name:     "按字典序排序",
+			input:    `{"b":1,"a":{"d":2,"c":3}}`,
+			opts:     StringifyOptions{KeyComparator: AlphabeticalKeyComparator},
+			expected: `{"a":{"c":3,"d":2},"b":1}`,
+		},
+		{
+			name:     "优先键排在前面",
+			input:    `{"zeta":1,"name":"n","alpha":2,"id":7}`,
+			opts:     StringifyOptions{KeyComparator: NewKeyOrderComparator("id", "name")},
+			expected: `{"id":7,"name":"n","alpha":2,"zeta":1}`,
+		},
+		{
+			name:     "缩进输出",
+			input:    `{"a":[1,2],"b":{},"c":[]}`,
+			opts:     StringifyOptions{Indent: "  "},
+			expected: "{\n  \"a\": [\n    1,\n    2\n  ],\n  \"b\": {},\n  \"c\": []\n}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &Value{}
+			if err := Parse(v, tt.input); err != PARSE_OK {
+				t.Fatalf("解析输入失败: %s", err.Error())
+			}
+			original, _ := Stringify(v)
+
+			got, err := StringifyWithOptions(v, tt.opts)
+			if err != STRINGIFY_OK {
+				t.Fatalf("StringifyWithOptions() 失败: %s", err.Error())
+			}
+			if got != tt.expected {
+				t.Errorf("StringifyWithOptions() = %q, 期望 %q", got, tt.expected)
+			}
+
+			// 排序不应修改原对象的成员顺序
+			if after, _ := Stringify(v); after != original {
+				t.Errorf("原始值被修改: %s, 期望 %s", after, original)
+			}
+		})
+	}
+}
+
+func TestKeyComparatorRegistry(t *testing.T) {
+	if _, ok := LookupKeyComparator("alpha"); !ok {
+		t.Fatal("内置的alpha比较函数未注册")
+	}
+
+	if err := RegisterKeyComparator("", AlphabeticalKeyComparator); err == nil {
+		t.Error("空名称应当返回错误")
+	}
+	if err := RegisterKeyComparator("test-nil", nil); err == nil {
+		t.Error("空比较函数应当返回错误")
+	}
+
+	reverse := func(a, b string) bool { return a > b }
+	if err := RegisterKeyComparator("test-reverse", reverse); err != nil {
+		t.Fatalf("注册比较函数失败: %v", err)
+	}
+	cmp, ok := LookupKeyComparator("test-reverse")
+	if !ok {
+		t.Fatal("未找到已注册的比较函数")
+	}
+
+	v := &Value{}
+	Parse(v, `{"a":1,"c":2,"b":3}`)
+	got, _ := formatJSONWithComparator(v, "", cmp)
+	expected := "{\n\"c\": 2,\n\"b\": 3,\n\"a\": 1\n}"
+	if got != expected {
+		t.Errorf("formatJSONWithComparator() = %q, 期望 %q", got, expected)
+	}
+}
@@ -0,0 +1,77 @@
+package leptjson
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestEscapeString(t *testing.T) {
+	for s, want := range map[string]string{
+		"":                  ``,
+		"plain ascii":       `plain ascii`,
+		"中文 é 𝄞":            `中文 é 𝄞`,
+		"\"":                `\"`,
+		"a\\b":              `a\\b`,
+		"\b\f\n\r\t":        `\b\f\n\r\t`,
+		"x\x00y\x1fz":       `x\u0000y\u001fz`,
+		"head \"mid\" tail": `head \"mid\" tail`,
+		"\x7f/<>&":          "\x7f/<>&",
+	} {
+		var buffer bytes.Buffer
+		escapeString(s, &buffer)
+		if buffer.String() != want {
+			t.Errorf("escapeString(%q) = %s, 期望 %s", s, buffer.String(), want)
+		}
+	}
+}
+
+func BenchmarkStringifyStrings(b *testing.B) {
+	v := &Value{}
+	json := `[` + strings.Repeat(`"a typical string value without escapes","name","line\nbreak",`, 1000) + `""]`
+	options := DefaultParseOptions()
+	options.EnabledSecurity = false
+	if err := ParseWithOptions(v, json, options); err != PARSE_OK {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(json)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Stringify(v)
+	}
+}
+
+func TestStringifyNumberMatchesFormatFloat(t *testing.T) {
+	numbers := []float64{
+		0, math.Copysign(0, -1), 1, -1, 7, 1023, 1024, -1024, 9.99, 0.1, 1e-7,
+		999999, -999999, 1e6, -1e6, 1234567, 1e21, 1e300, math.MaxFloat64,
+		math.SmallestNonzeroFloat64, math.Inf(1), math.Inf(-1), math.NaN(),
+	}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		numbers = append(numbers, float64(r.Intn(4000000)-2000000), r.NormFloat64()*1e4)
+	}
+	for _, n := range numbers {
+		var buffer bytes.Buffer
+		stringifyNumber(n, &buffer)
+		if want := strconv.FormatFloat(n, 'g', -1, 64); buffer.String() != want {
+			t.Errorf("stringifyNumber(%v) = %s, 期望 %s", n, buffer.String(), want)
+		}
+	}
+}
+
+func BenchmarkStringifyNumbers(b *testing.B) {
+	v := &Value{}
+	json := `[` + strings.Repeat(`0,1,42,1999,-5,9.99,0.5,`, 1000) + `100000]`
+	if err := ParseWithOptions(v, json, ParseOptions{}); err != PARSE_OK {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(json)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Stringify(v)
+	}
+}
@@ -0,0 +1,53 @@
+// stringify_to.go - 复用缓冲区的序列化，直接写入 io.Writer
+package leptjson
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+)
+
+// maxPooledBuffer 放回池中的缓冲区的最大容量，偶尔序列化的大文档不应长期占用内存
+const maxPooledBuffer = 1 << 20
+
+var bufferPool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+// acquireBuffer 从池中取出一个空的缓冲区
+func acquireBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// releaseBuffer 清空缓冲区并放回池中
+func releaseBuffer(buffer *bytes.Buffer) {
+	if buffer.Cap() > maxPooledBuffer {
+		return
+	}
+	buffer.Reset()
+	bufferPool.Put(buffer)
+}
+
+// StringifyTo 将 v 序列化后写入 w，输出与 Stringify 相同
+//
+// 序列化使用池中的缓冲区，写入之后放回，不需要为结果分配字符串；
+// 高并发地把响应写入 http.ResponseWriter 等场景应使用 StringifyTo 而不是 Stringify。
+func StringifyTo(w io.Writer, v *Value) error {
+	if v == nil {
+		return nil
+	}
+
+	logger := currentLogger()
+	var start time.Time
+	if logger != nil {
+		start = time.Now()
+	}
+
+	buffer := acquireBuffer()
+	defer releaseBuffer(buffer)
+	stringifyValue(v, buffer)
+	if logger != nil {
+		logStringify(logger, buffer.Len(), start, v)
+	}
+	_, err := w.Write(buffer.Bytes())
+	return err
+}
@@ -0,0 +1,84 @@
+package leptjson
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestEscapeTable(t *testing.T) {
+	for ch := 0; ch < 256; ch++ {
+		var want string
+		switch {
+		case ch == '"' || ch == '\\':
+			want = `\` + string(rune(ch))
+		case ch == '\b' || ch == '\f' || ch == '\n' || ch == '\r' || ch == '\t':
+			want = strings.Trim(fmt.Sprintf("%q", string(rune(ch))), `"`)
+		case ch < 0x20:
+			want = fmt.Sprintf(`\u%04x`, ch)
+		}
+		if escapeTable[ch] != want {
+			t.Errorf("escapeTable[%#x] = %q, 期望 %q", ch, escapeTable[ch], want)
+		}
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) { return 0, errors.New("写入失败") }
+
+func TestStringifyTo(t *testing.T) {
+	v := &Value{}
+	json := `{"a":[1,2.5,"x\ny",null,true,false],"b":{"c":"\u0001"}}`
+	if err := Parse(v, json); err != PARSE_OK {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		var out bytes.Buffer
+		if err := StringifyTo(&out, v); err != nil {
+			t.Fatal(err)
+		}
+		if want, _ := Stringify(v); out.String() != want {
+			t.Errorf("StringifyTo = %s, 期望 %s", out.String(), want)
+		}
+	}
+	if err := StringifyTo(failingWriter{}, v); err == nil || err.Error() != "写入失败" {
+		t.Errorf("StringifyTo(failingWriter) = %v", err)
+	}
+	var out bytes.Buffer
+	if err := StringifyTo(&out, nil); err != nil || out.Len() != 0 {
+		t.Errorf("StringifyTo(nil) = %v, %q", err, out.String())
+	}
+}
+
+func TestReleaseBufferDropsLargeBuffers(t *testing.T) {
+	buffer := acquireBuffer()
+	buffer.Grow(maxPooledBuffer + 1)
+	buffer.WriteString("x")
+	releaseBuffer(buffer)
+	if buffer.Len() != 1 {
+		t.Error("过大的缓冲区不应被清空后放回池中")
+	}
+	small := acquireBuffer()
+	small.WriteString("x")
+	releaseBuffer(small)
+	if small.Len() != 0 {
+		t.Error("放回池中的缓冲区应被清空")
+	}
+}
+
+func BenchmarkStringifyTo(b *testing.B) {
+	v := &Value{}
+	if err := Parse(v, `{"id":123,"name":"item","tags":["a","b\tc"],"price":9.99,"ok":true}`); err != PARSE_OK {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			StringifyTo(io.Discard, v)
+		}
+	})
+}
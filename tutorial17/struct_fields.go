@@ -0,0 +1,236 @@
+// struct_fields.go - 结构体字段的序列化规则
+package leptjson
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// fieldInfo 描述结构体中参与序列化的一个字段
+type fieldInfo struct {
+	name      string // JSON中的键名
+	goName    string // Go中的字段名
+	index     []int  // 字段的下标序列，经过嵌入的结构体时长度大于1
+	tagged    bool   // 键名是否来自 tag
+	omitEmpty bool   // 是否带有 omitempty 选项
+	quoted    bool   // 是否带有 string 选项，数字和布尔值以字符串形式编码
+	inline    bool   // 是否为带有 inline 选项的 map，用于收集未知的键
+}
+
+// fieldCache 缓存每个结构体类型的字段列表: reflect.Type => []fieldInfo
+var fieldCache sync.Map
+
+// cachedTypeFields 与 typeFields 相同，但每个类型只解析一次
+//
+// 结构体的布局在运行期间不会改变，缓存后重复序列化同一类型时
+// 不必再次遍历字段和解析 tag。返回的切片是共享的，调用者不能修改。
+func cachedTypeFields(t reflect.Type) []fieldInfo {
+	if fields, ok := fieldCache.Load(t); ok {
+		return fields.([]fieldInfo)
+	}
+	fields, _ := fieldCache.LoadOrStore(t, typeFields(t))
+	return fields.([]fieldInfo)
+}
+
+// typeFields 返回结构体中参与序列化的字段，按声明顺序排列
+//
+// 规则与 encoding/json 一致：
+//   - 没有 tag 名称的匿名结构体（或指向结构体的指针）字段会被展开，其字段提升到外层；
+//     带有 inline 选项的结构体字段同样会被展开
+//   - 同名字段中层级较浅的优先，同一层级时带 tag 名称的优先，仍无法区分时全部忽略
+//   - 带有 inline 选项的 map[string]T 字段收集没有对应字段的键，只有第一个生效
+func typeFields(t reflect.Type) []fieldInfo {
+	type embedded struct {
+		typ   reflect.Type
+		index []int
+	}
+
+	var fields []fieldInfo
+	var inline *fieldInfo
+	visited := make(map[reflect.Type]bool)
+	next := []embedded{{typ: t}}
+
+	for len(next) > 0 {
+		current := next
+		next = nil
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			visited[e.typ] = true
+
+			for i := 0; i < e.typ.NumField(); i++ {
+				sf := e.typ.Field(i)
+				ft := sf.Type
+				if sf.Anonymous && ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				// 跳过非导出字段，但非导出的嵌入结构体中的导出字段仍然可见
+				if sf.PkgPath != "" && !(sf.Anonymous && ft.Kind() == reflect.Struct) {
+					continue
+				}
+
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				tagParts := strings.Split(tag, ",")
+				info := fieldInfo{name: tagParts[0], goName: sf.Name, tagged: tagParts[0] != ""}
+				info.index = make([]int, len(e.index)+1)
+				copy(info.index, e.index)
+				info.index[len(e.index)] = i
+
+				isInline := false
+				for _, option := range tagParts[1:] {
+					switch option {
+					case "omitempty":
+						info.omitEmpty = true
+					case "string":
+						info.quoted = isQuotableKind(sf.Type)
+					case "inline":
+						isInline = true
+					}
+				}
+
+				// 展开嵌入的结构体
+				if !info.tagged && ft.Kind() == reflect.Struct && (sf.Anonymous || isInline) {
+					next = append(next, embedded{typ: ft, index: info.index})
+					continue
+				}
+				if isInline && ft.Kind() == reflect.Map && ft.Key().Kind() == reflect.String {
+					if inline == nil {
+						info.inline = true
+						inline = &info
+					}
+					continue
+				}
+				if sf.PkgPath != "" {
+					continue
+				}
+
+				if info.name == "" {
+					info.name = sf.Name
+				}
+				fields = append(fields, info)
+			}
+		}
+	}
+
+	fields = dominantFields(fields)
+	if inline != nil {
+		fields = append(fields, *inline)
+	}
+	return fields
+}
+
+// dominantFields 在同名字段中选出生效的字段，并按声明顺序排列
+func dominantFields(fields []fieldInfo) []fieldInfo {
+	sort.SliceStable(fields, func(i, j int) bool {
+		a, b := fields[i], fields[j]
+		if a.name != b.name {
+			return a.name < b.name
+		}
+		if len(a.index) != len(b.index) {
+			return len(a.index) < len(b.index)
+		}
+		return a.tagged && !b.tagged
+	})
+
+	var result []fieldInfo
+	for i := 0; i < len(fields); {
+		j := i + 1
+		for j < len(fields) && fields[j].name == fields[i].name {
+			j++
+		}
+		// 最浅层级中只有一个字段，或只有一个带 tag 名称的字段时才生效
+		dominant := fields[i]
+		if j-i == 1 || len(fields[i+1].index) > len(dominant.index) ||
+			(dominant.tagged && !fields[i+1].tagged) {
+			result = append(result, dominant)
+		}
+		i = j
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i].index, result[j].index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	return result
+}
+
+// isQuotableKind 判断类型能否使用 string 选项，只有数字和布尔值（及其指针）可以
+func isQuotableKind(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// lookupField 按键名查找字段，精确匹配优先，其次忽略大小写匹配
+func lookupField(fields []fieldInfo, key string) (fieldInfo, bool) {
+	for _, field := range fields {
+		if !field.inline && field.name == key {
+			return field, true
+		}
+	}
+	for _, field := range fields {
+		if !field.inline && strings.EqualFold(field.name, key) {
+			return field, true
+		}
+	}
+	return fieldInfo{}, false
+}
+
+// inlineField 返回收集未知键的 inline 字段
+func inlineField(fields []fieldInfo) (fieldInfo, bool) {
+	if n := len(fields); n > 0 && fields[n-1].inline {
+		return fields[n-1], true
+	}
+	return fieldInfo{}, false
+}
+
+// fieldByIndex 读取字段的值，路径上有nil的嵌入指针时返回 false
+func fieldByIndex(rv reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				return reflect.Value{}, false
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	return rv, true
+}
+
+// fieldByIndexAlloc 获取可设置的字段，路径上nil的嵌入指针会被分配
+func fieldByIndexAlloc(rv reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				if !rv.CanSet() {
+					return reflect.Value{}, false
+				}
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	return rv, true
+}
@@ -0,0 +1,182 @@
+package leptjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+type tagBase struct {
+	ID      int    `json:"id"`
+	Created string `json:"created"`
+}
+
+// TagAudit 是导出类型，以便通过嵌入指针反序列化时可以分配内存
+type TagAudit struct {
+	Created string `json:"created"` // 与 tagBase.Created 同级冲突，两者都被忽略
+	By      string `json:"by"`
+}
+
+type tagMeta struct {
+	Version int `json:"version"`
+}
+
+type tagDocument struct {
+	tagBase
+	*TagAudit
+	Meta   tagMeta                `json:",inline"`
+	ID     string                 `json:"id"` // 外层字段覆盖嵌入字段
+	Count  int64                  `json:"count,string"`
+	Ratio  *float64               `json:"ratio,string,omitempty"`
+	OK     bool                   `json:"ok,string"`
+	Name   string                 `json:"name,string"` // 字符串不受 string 选项影响
+	Extras map[string]interface{} `json:",inline"`
+}
+
+func TestTypeFields(t *testing.T) {
+	fields := typeFields(reflect.TypeOf(tagDocument{}))
+	var names []string
+	for _, field := range fields {
+		if !field.inline {
+			names = append(names, field.name)
+		}
+	}
+	expected := []string{"by", "version", "id", "count", "ratio", "ok", "name"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("typeFields = %v, 期望 %v", names, expected)
+	}
+	if inline, ok := inlineField(fields); !ok || inline.goName != "Extras" {
+		t.Errorf("inline 字段错误: %+v", inline)
+	}
+}
+
+func TestMarshalStructTags(t *testing.T) {
+	ratio := 0.5
+	doc := tagDocument{
+		tagBase:  tagBase{ID: 7, Created: "today"},
+		TagAudit: &TagAudit{By: "alice"},
+		Meta:     tagMeta{Version: 2},
+		ID:       "doc-1",
+		Count:    42,
+		Ratio:    &ratio,
+		OK:       true,
+		Name:     "n",
+		Extras:   map[string]interface{}{"z": 1, "a": "x", "id": "ignored"},
+	}
+	got, err := Marshal(doc)
+	if err != nil {
+		t.Fatalf("Marshal 失败: %v", err)
+	}
+	expected := `{"by":"alice","version":2,"id":"doc-1","count":"42","ratio":"0.5","ok":"true","name":"n","a":"x","z":1}`
+	if got != expected {
+		t.Errorf("Marshal = %s\n期望 %s", got, expected)
+	}
+
+	// nil 的嵌入指针中的字段被跳过
+	doc.TagAudit = nil
+	doc.Ratio = nil
+	doc.Extras = nil
+	got, _ = Marshal(doc)
+	expected = `{"version":2,"id":"doc-1","count":"42","ok":"true","name":"n"}`
+	if got != expected {
+		t.Errorf("Marshal = %s\n期望 %s", got, expected)
+	}
+}
+
+func TestUnmarshalStructTags(t *testing.T) {
+	var doc tagDocument
+	err := UnmarshalStrict(`{"by":"bob","version":3,"id":"d","count":"-12","ratio":"1.5","ok":"false","name":"n","color":"red"}`, &doc)
+	if err != nil {
+		t.Fatalf("UnmarshalStrict 失败: %v", err)
+	}
+	if doc.TagAudit == nil || doc.By != "bob" {
+		t.Error("嵌入指针应当被分配")
+	}
+	if doc.Meta.Version != 3 || doc.ID != "d" || doc.Count != -12 || doc.Ratio == nil || *doc.Ratio != 1.5 || doc.OK {
+		t.Errorf("Unmarshal 结果错误: %+v", doc)
+	}
+	if len(doc.Extras) != 1 || doc.Extras["color"] != "red" {
+		t.Errorf("未知的键应当存入 inline map: %v", doc.Extras)
+	}
+
+	tests := []string{
+		`{"count": 12}`,
+		`{"count": "abc"}`,
+		`{"ok": "1"}`,
+	}
+	for _, input := range tests {
+		var d tagDocument
+		if err := Unmarshal(input, &d); err == nil {
+			t.Errorf("Unmarshal(%s) 应当返回错误", input)
+		}
+	}
+}
+
+func TestUnmarshalUnexportedEmbeddedPointer(t *testing.T) {
+	var target struct {
+		*tagMeta
+	}
+	err := Unmarshal(`{"version": 1}`, &target)
+	if err == nil || target.tagMeta != nil {
+		t.Errorf("非导出的嵌入指针无法分配，应当返回错误: %v", err)
+	}
+}
+
+func TestCachedTypeFields(t *testing.T) {
+	typ := reflect.TypeOf(tagDocument{})
+	fieldCache.Delete(typ)
+
+	first := cachedTypeFields(typ)
+	if !reflect.DeepEqual(first, typeFields(typ)) {
+		t.Error("缓存的字段与 typeFields 的结果不一致")
+	}
+	if second := cachedTypeFields(typ); &second[0] != &first[0] {
+		t.Error("同一类型应当复用缓存的字段列表")
+	}
+}
+
+type benchOrderItem struct {
+	SKU      string  `json:"sku"`
+	Quantity int     `json:"quantity"`
+	Price    float64 `json:"price"`
+	Note     string  `json:"note,omitempty"`
+}
+
+type benchOrder struct {
+	tagBase
+	Customer string           `json:"customer"`
+	Paid     bool             `json:"paid"`
+	Total    float64          `json:"total,string"`
+	Items    []benchOrderItem `json:"items"`
+}
+
+func BenchmarkMarshalStruct(b *testing.B) {
+	order := benchOrder{
+		tagBase:  tagBase{ID: 1, Created: "2024-01-01"},
+		Customer: "alice",
+		Paid:     true,
+		Total:    99.5,
+		Items: []benchOrderItem{
+			{SKU: "a-1", Quantity: 2, Price: 10},
+			{SKU: "b-2", Quantity: 1, Price: 79.5, Note: "gift"},
+		},
+	}
+	orderType := reflect.TypeOf(order)
+	itemType := reflect.TypeOf(benchOrderItem{})
+
+	b.Run("Uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			// 清除缓存，模拟每次都重新解析结构体布局
+			fieldCache.Delete(orderType)
+			fieldCache.Delete(itemType)
+			Marshal(order)
+		}
+	})
+
+	b.Run("Cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			Marshal(order)
+		}
+	})
+}
@@ -0,0 +1,306 @@
+// to_sql.go - 把对象数组展平为关系表，输出 CREATE TABLE 和 INSERT 语句
+package leptjson
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// SQLColumnType 是推断出的列类型
+type SQLColumnType int
+
+// 列类型常量，与 SQLite 的类型亲和性对应
+const (
+	SQL_INTEGER SQLColumnType = iota // 整数和布尔值（0/1）
+	SQL_REAL                         // 含有小数的数字
+	SQL_TEXT                         // 字符串、嵌套的数组，以及类型混杂的列
+)
+
+// String 返回类型在 CREATE TABLE 中的名称
+func (t SQLColumnType) String() string {
+	switch t {
+	case SQL_INTEGER:
+		return "INTEGER"
+	case SQL_REAL:
+		return "REAL"
+	case SQL_TEXT:
+		return "TEXT"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalJSON 将列类型编码为名称字符串
+func (t SQLColumnType) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(t.String())), nil
+}
+
+// SQLColumn 是表的一列
+type SQLColumn struct {
+	Name string        `json:"name"`
+	Path string        `json:"path"` // 列对应的成员在记录中的 JSON Pointer，如 /user/name
+	Type SQLColumnType `json:"type"`
+}
+
+// SQLTable 是展平后的关系表
+//
+// Rows 中每个值是 nil（NULL）、int64、float64 或 string，与对应列的类型一致。
+type SQLTable struct {
+	Name    string
+	Columns []SQLColumn
+	Rows    [][]interface{}
+}
+
+// FlattenOptions 控制 FlattenTable 如何展平记录
+type FlattenOptions struct {
+	Separator string // 嵌套对象的键连接成列名时使用的分隔符，为空时使用 "_"
+}
+
+// sqlMaxColumns 是 SQLite 默认允许的最大列数
+const sqlMaxColumns = 2000
+
+// columnKinds 记录一列中出现过的值的种类，用于推断列类型
+type columnKinds struct {
+	integer, real, boolean, text, composite bool
+}
+
+// observe 记录一个值的种类
+func (k *columnKinds) observe(v *Value) {
+	switch v.Type {
+	case NUMBER:
+		if isSQLInteger(v.N) {
+			k.integer = true
+		} else {
+			k.real = true
+		}
+	case TRUE, FALSE:
+		k.boolean = true
+	case STRING:
+		k.text = true
+	case ARRAY, OBJECT:
+		k.composite = true
+	}
+}
+
+func (k columnKinds) columnType() SQLColumnType {
+	switch {
+	case k.text || k.composite:
+		return SQL_TEXT
+	case k.real:
+		return SQL_REAL
+	default:
+		// 只有整数和布尔值，或者全是 null
+		if !k.integer && !k.boolean {
+			return SQL_TEXT
+		}
+		return SQL_INTEGER
+	}
+}
+
+// FlattenTable 把对象数组展平为关系表
+//
+// 嵌套的对象展开为多列，列名是各层的键用 Separator 连接，如 user_name；
+// 数组和空对象作为紧凑的JSON文本保存。列按第一次出现的顺序排列，
+// 某条记录没有的列为 NULL。列类型根据所有非 null 值推断：全是整数或布尔值时为
+// INTEGER，全是数字时为 REAL，其余情况为 TEXT，此时数字和布尔值按JSON文本保存。
+// SQLite 的列名不区分大小写，只有大小写不同的列名会加上 _2、_3 等后缀。
+func FlattenTable(name string, records *Value, options FlattenOptions) (*SQLTable, error) {
+	if records == nil || records.Type != ARRAY {
+		return nil, fmt.Errorf("只能把对象数组转换为表")
+	}
+	separator := options.Separator
+	if separator == "" {
+		separator = "_"
+	}
+
+	table := &SQLTable{Name: name}
+	index := make(map[string]int) // 列的 JSON Pointer -> 列下标
+	taken := make(map[string]bool)
+	var kinds []columnKinds
+	var rows [][]*Value
+
+	for i, record := range records.A {
+		if record.Type != OBJECT {
+			return nil, fmt.Errorf("第%d个元素不是对象", i+1)
+		}
+		row := make([]*Value, len(table.Columns))
+		err := walkFlattened(record, func(path []string, v *Value) error {
+			pointer := pointerFromTokens(path)
+			col, ok := index[pointer]
+			if !ok {
+				if len(table.Columns) == sqlMaxColumns {
+					return fmt.Errorf("列数超过 %d", sqlMaxColumns)
+				}
+				col = len(table.Columns)
+				index[pointer] = col
+				table.Columns = append(table.Columns, SQLColumn{Name: uniqueColumnName(strings.Join(path, separator), taken), Path: pointer})
+				kinds = append(kinds, columnKinds{})
+				row = append(row, nil)
+			}
+			row[col] = v
+			kinds[col].observe(v)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+
+	for i := range table.Columns {
+		table.Columns[i].Type = kinds[i].columnType()
+	}
+	table.Rows = make([][]interface{}, len(rows))
+	for i, row := range rows {
+		values := make([]interface{}, len(table.Columns))
+		for col, v := range row {
+			values[col] = sqlValue(v, table.Columns[col].Type)
+		}
+		table.Rows[i] = values
+	}
+	return table, nil
+}
+
+// walkFlattened 按顺序对记录中的每个叶子成员调用 fn，path 是从记录到成员的键
+//
+// 非空的嵌套对象继续展开，数组、空对象和标量是叶子。fn 不能保留 path。
+func walkFlattened(record *Value, fn func(path []string, v *Value) error) error {
+	var walk func(v *Value, path []string) error
+	walk = func(v *Value, path []string) error {
+		for i := range v.O {
+			m := &v.O[i]
+			p := append(path, m.K)
+			if m.V.Type == OBJECT && len(m.V.O) > 0 {
+				if err := walk(m.V, p); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := fn(p, m.V); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(record, nil)
+}
+
+// uniqueColumnName 返回不与已有列名重复（不区分大小写）的列名，并记录下来
+func uniqueColumnName(name string, taken map[string]bool) string {
+	if name == "" {
+		name = "column"
+	}
+	candidate := name
+	for n := 2; taken[strings.ToLower(candidate)]; n++ {
+		candidate = fmt.Sprintf("%s_%d", name, n)
+	}
+	taken[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+// isSQLInteger 判断数字能否无损地保存为64位整数
+func isSQLInteger(n float64) bool {
+	return n == math.Trunc(n) && n >= -(1<<63) && n < 1<<63
+}
+
+// sqlValue 把 JSON 值转换为列类型对应的 Go 值
+func sqlValue(v *Value, t SQLColumnType) interface{} {
+	if v == nil || v.Type == NULL {
+		return nil
+	}
+	switch t {
+	case SQL_INTEGER:
+		switch v.Type {
+		case TRUE:
+			return int64(1)
+		case FALSE:
+			return int64(0)
+		default:
+			return int64(v.N)
+		}
+	case SQL_REAL:
+		switch v.Type {
+		case TRUE:
+			return float64(1)
+		case FALSE:
+			return float64(0)
+		default:
+			return v.N
+		}
+	default:
+		if v.Type == STRING {
+			return GetString(v)
+		}
+		text, _ := Stringify(v)
+		return text
+	}
+}
+
+// quoteSQLIdent 用双引号引用标识符
+func quoteSQLIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// sqlLiteral 返回值的 SQL 字面量
+func sqlLiteral(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		text := strconv.FormatFloat(v, 'g', -1, 64)
+		if !strings.ContainsAny(text, ".eE") {
+			text += ".0"
+		}
+		return text
+	default:
+		return "'" + strings.ReplaceAll(fmt.Sprint(v), "'", "''") + "'"
+	}
+}
+
+// CreateStatement 返回创建这张表的 CREATE TABLE 语句
+func (t *SQLTable) CreateStatement() string {
+	var sb strings.Builder
+	sb.WriteString("CREATE TABLE " + quoteSQLIdent(t.Name) + " (")
+	for i, c := range t.Columns {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(quoteSQLIdent(c.Name) + " " + c.Type.String())
+	}
+	sb.WriteString(")")
+	return sb.String()
+}
+
+// WriteSQL 输出 CREATE TABLE 语句和每行一条的 INSERT 语句，INSERT 放在一个事务中
+func (t *SQLTable) WriteSQL(w io.Writer) error {
+	if len(t.Columns) == 0 {
+		return fmt.Errorf("表 %s 没有任何列", t.Name)
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%s;\n", t.CreateStatement())
+	bw.WriteString("BEGIN TRANSACTION;\n")
+
+	columns := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		columns[i] = quoteSQLIdent(c.Name)
+	}
+	prefix := "INSERT INTO " + quoteSQLIdent(t.Name) + " (" + strings.Join(columns, ", ") + ") VALUES ("
+	for _, row := range t.Rows {
+		bw.WriteString(prefix)
+		for i, v := range row {
+			if i > 0 {
+				bw.WriteString(", ")
+			}
+			bw.WriteString(sqlLiteral(v))
+		}
+		bw.WriteString(");\n")
+	}
+	bw.WriteString("COMMIT;\n")
+	return bw.Flush()
+}
@@ -0,0 +1,99 @@
+package leptjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestFlattenTable(t *testing.T) {
+	records := mustParse(t, `[
+		{"id": 1, "name": "a", "price": 9.5, "active": true, "user": {"name": "x", "age": 30}, "tags": ["t"]},
+		{"id": 2, "price": 10, "active": false, "user": {"name": "y"}, "ID": 7, "meta": {}, "none": null},
+		{"id": 3, "name": 5}
+	]`)
+	table, err := FlattenTable("items", records, FlattenOptions{})
+	if err != nil {
+		t.Fatalf("FlattenTable 失败: %v", err)
+	}
+
+	expected := []SQLColumn{
+		{"id", "/id", SQL_INTEGER},
+		{"name", "/name", SQL_TEXT},
+		{"price", "/price", SQL_REAL},
+		{"active", "/active", SQL_INTEGER},
+		{"user_name", "/user/name", SQL_TEXT},
+		{"user_age", "/user/age", SQL_INTEGER},
+		{"tags", "/tags", SQL_TEXT},
+		{"ID_2", "/ID", SQL_INTEGER},
+		{"meta", "/meta", SQL_TEXT},
+		{"none", "/none", SQL_TEXT},
+	}
+	if len(table.Columns) != len(expected) {
+		t.Fatalf("列 = %+v, 期望 %+v", table.Columns, expected)
+	}
+	for i, c := range expected {
+		if table.Columns[i] != c {
+			t.Errorf("第%d列 = %+v, 期望 %+v", i+1, table.Columns[i], c)
+		}
+	}
+
+	rows := [][]interface{}{
+		{int64(1), "a", 9.5, int64(1), "x", int64(30), `["t"]`, nil, nil, nil},
+		{int64(2), nil, 10.0, int64(0), "y", nil, nil, int64(7), "{}", nil},
+		{int64(3), "5", nil, nil, nil, nil, nil, nil, nil, nil},
+	}
+	for i, row := range rows {
+		for j, v := range row {
+			if table.Rows[i][j] != v {
+				t.Errorf("第%d行第%d列 = %#v, 期望 %#v", i+1, j+1, table.Rows[i][j], v)
+			}
+		}
+	}
+
+	data, _ := json.Marshal(table.Columns[0])
+	if string(data) != `{"name":"id","path":"/id","type":"INTEGER"}` {
+		t.Errorf("列的JSON = %s", data)
+	}
+}
+
+func TestFlattenTableOptions(t *testing.T) {
+	table, err := FlattenTable("t", mustParse(t, `[{"a": {"b": {"c": 1}}, "a.b": {"c": 2}}]`), FlattenOptions{Separator: "."})
+	if err != nil {
+		t.Fatalf("FlattenTable 失败: %v", err)
+	}
+	// 连接后同名的列仍然是不同的列
+	if len(table.Columns) != 2 || table.Columns[0].Name != "a.b.c" || table.Columns[1].Name != "a.b.c_2" {
+		t.Errorf("列 = %+v", table.Columns)
+	}
+
+	for _, text := range []string{`{"a": 1}`, `[{"a": 1}, 2]`} {
+		if _, err := FlattenTable("t", mustParse(t, text), FlattenOptions{}); err == nil {
+			t.Errorf("FlattenTable(%s) 应该返回错误", text)
+		}
+	}
+}
+
+func TestWriteSQL(t *testing.T) {
+	// 2.0e20 超出64位整数的范围，这一列为 REAL
+	table, _ := FlattenTable(`my "table"`, mustParse(t, `[{"n": 1, "s": "it's", "f": 2.0e20, "r": 0.5}, {"n": null, "r": 3}]`), FlattenOptions{})
+	var buf bytes.Buffer
+	if err := table.WriteSQL(&buf); err != nil {
+		t.Fatalf("WriteSQL 失败: %v", err)
+	}
+	expected := `CREATE TABLE "my ""table""" ("n" INTEGER, "s" TEXT, "f" REAL, "r" REAL);
+BEGIN TRANSACTION;
+INSERT INTO "my ""table""" ("n", "s", "f", "r") VALUES (1, 'it''s', 2e+20, 0.5);
+INSERT INTO "my ""table""" ("n", "s", "f", "r") VALUES (NULL, NULL, NULL, 3.0);
+COMMIT;
+`
+	if buf.String() != expected {
+		t.Errorf("WriteSQL =\n%s\n期望\n%s", buf.String(), expected)
+	}
+
+	empty, _ := FlattenTable("t", mustParse(t, `[{}]`), FlattenOptions{})
+	if err := empty.WriteSQL(&buf); err == nil || !strings.Contains(err.Error(), "没有任何列") {
+		t.Errorf("没有列时的错误 = %v", err)
+	}
+}
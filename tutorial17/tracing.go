@@ -0,0 +1,155 @@
+// tracing.go - 为解析、验证和补丁创建分布式追踪的 span
+package leptjson
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Tracer 创建追踪 span，通常是对 OpenTelemetry trace.Tracer 的简单适配：
+//
+//	type otelTracer struct{ t trace.Tracer }
+//
+//	func (o otelTracer) Start(ctx context.Context, name string) (context.Context, leptjson.Span) {
+//		ctx, span := o.t.Start(ctx, name)
+//		return ctx, otelSpan{span}
+//	}
+//
+// 其中 otelSpan 把 SpanAttribute 转换为 attribute.KeyValue，把 RecordError
+// 转发给 span.RecordError 并设置错误状态。
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span 是一次操作的追踪区间
+type Span interface {
+	SetAttributes(attrs ...SpanAttribute)
+	RecordError(err error)
+	End()
+}
+
+// SpanAttribute 是 span 的属性，Value 为 string、int 或 bool
+type SpanAttribute struct {
+	Key   string
+	Value interface{}
+}
+
+// tracerHolder 包装 Tracer，使 atomic.Value 中始终保存同一种类型
+type tracerHolder struct {
+	tracer Tracer
+}
+
+var operationTracer atomic.Value // tracerHolder
+
+// SetTracer 设置 ParseContext、ValidateContext 和 ApplyContext 使用的 Tracer，为 nil 时不追踪
+//
+// 创建的 span 及其属性：
+//
+//	leptjson.parse     json.bytes、json.depth，失败时 json.error_code
+//	leptjson.validate  json.valid、json.error_count
+//	leptjson.patch     json.operations，失败时记录错误
+func SetTracer(tracer Tracer) {
+	operationTracer.Store(tracerHolder{tracer: tracer})
+}
+
+// startSpan 在设置了 Tracer 时创建 span，否则返回 nil
+func startSpan(ctx context.Context, name string) (context.Context, Span) {
+	holder, _ := operationTracer.Load().(tracerHolder)
+	if holder.tracer == nil {
+		return ctx, nil
+	}
+	return holder.tracer.Start(ctx, name)
+}
+
+// ParseContext 使用默认选项解析JSON文本，并在 ctx 中创建 leptjson.parse span
+//
+// 解析过程中定期检查 ctx，ctx 被取消或超时后返回 PARSE_CANCELLED，
+// 请求处理函数可以借此在客户端断开后停止工作。
+func ParseContext(ctx context.Context, v *Value, json string) ParseError {
+	return ParseWithOptionsContext(ctx, v, json, DefaultParseOptions())
+}
+
+// ParseWithOptionsContext 使用自定义选项解析JSON文本，并在 ctx 中创建 leptjson.parse span
+//
+// 解析过程中定期检查 ctx，ctx 被取消或超时后返回 PARSE_CANCELLED。
+func ParseWithOptionsContext(ctx context.Context, v *Value, json string, options ParseOptions) ParseError {
+	_, span := startSpan(ctx, "leptjson.parse")
+	var err ParseError
+	if ctx.Err() != nil {
+		v.Type = NULL
+		err = PARSE_CANCELLED
+	} else {
+		c := newContext(json, options)
+		c.done = ctx.Done()
+		err = parseObserved(c, v)
+	}
+	if span == nil {
+		return err
+	}
+	span.SetAttributes(SpanAttribute{"json.bytes", len(json)})
+	if err != PARSE_OK {
+		span.SetAttributes(SpanAttribute{"json.error_code", parseErrorName(err)})
+		span.RecordError(err)
+	} else {
+		span.SetAttributes(SpanAttribute{"json.depth", valueDepth(v)})
+	}
+	span.End()
+	return err
+}
+
+// ValidateContext 与 ValidateWithOptions 相同，并在 ctx 中创建 leptjson.validate span
+//
+// 验证过程中定期检查 ctx，被取消或超时后返回 *BudgetError。
+func ValidateContext(ctx context.Context, schema, data *Value, options ValidationOptions) (ValidationResult, error) {
+	_, span := startSpan(ctx, "leptjson.validate")
+	result, err := validateWithOptionsContext(ctx, schema, data, options)
+	if span != nil {
+		endValidateSpan(span, result, err)
+	}
+	return result, err
+}
+
+// endValidateSpan 记录验证结果并结束 span
+func endValidateSpan(span Span, result ValidationResult, err error) {
+	if err != nil {
+		span.RecordError(err)
+	} else {
+		span.SetAttributes(SpanAttribute{"json.valid", result.Valid}, SpanAttribute{"json.error_count", len(result.Issues)})
+	}
+	span.End()
+}
+
+// ApplyContext 与 Apply 相同，并在 ctx 中创建 leptjson.patch span
+func (p *JSONPatch) ApplyContext(ctx context.Context, doc *Value) error {
+	_, span := startSpan(ctx, "leptjson.patch")
+	err := p.Apply(doc)
+	if span != nil {
+		endPatchSpan(span, len(p.Operations), err)
+	}
+	return err
+}
+
+// endPatchSpan 记录补丁的操作数量和错误并结束 span
+func endPatchSpan(span Span, operations int, err error) {
+	span.SetAttributes(SpanAttribute{"json.operations", operations})
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+// valueDepth 返回 v 的嵌套深度，标量为0
+func valueDepth(v *Value) int {
+	depth := 0
+	for _, e := range v.A {
+		if d := valueDepth(e) + 1; d > depth {
+			depth = d
+		}
+	}
+	for _, m := range v.O {
+		if d := valueDepth(m.V) + 1; d > depth {
+			depth = d
+		}
+	}
+	return depth
+}
@@ -0,0 +1,120 @@
+package leptjson
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+)
+
+type spanKey struct{}
+
+// recordingTracer 记录结束的 span，span 的父子关系通过 context 传递
+type recordingTracer struct {
+	spans []*recordingSpan
+}
+
+type recordingSpan struct {
+	tracer *recordingTracer
+	name   string
+	parent string
+	attrs  map[string]interface{}
+	errors []error
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	parent, _ := ctx.Value(spanKey{}).(string)
+	span := &recordingSpan{tracer: t, name: name, parent: parent, attrs: make(map[string]interface{})}
+	return context.WithValue(ctx, spanKey{}, name), span
+}
+
+func (s *recordingSpan) SetAttributes(attrs ...SpanAttribute) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+}
+
+func (s *recordingSpan) RecordError(err error) { s.errors = append(s.errors, err) }
+func (s *recordingSpan) End()                  { s.tracer.spans = append(s.tracer.spans, s) }
+
+// String 返回 "name key=value ..."，属性按键排序
+func (s *recordingSpan) String() string {
+	var parts []string
+	for k, v := range s.attrs {
+		parts = append(parts, fmt.Sprintf("%s=%v", k, v))
+	}
+	sort.Strings(parts)
+	if len(s.errors) > 0 {
+		parts = append(parts, "error")
+	}
+	return strings.Join(append([]string{s.name}, parts...), " ")
+}
+
+func TestTracingSpans(t *testing.T) {
+	tracer := &recordingTracer{}
+	SetTracer(tracer)
+	defer SetTracer(nil)
+
+	ctx := context.WithValue(context.Background(), spanKey{}, "request")
+	v := &Value{}
+	ParseContext(ctx, v, `{"a":[1,{"b":2}]}`)
+	ParseContext(ctx, v, `{"a":`)
+
+	schema, data := &Value{}, &Value{}
+	Parse(schema, `{"type":"object","required":["id"]}`)
+	Parse(data, `{}`)
+	ValidateContext(ctx, schema, data, ValidationOptions{})
+
+	patchDoc := &Value{}
+	Parse(patchDoc, `[{"op":"remove","path":"/missing"}]`)
+	patch, err := NewJSONPatch(patchDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	patch.ApplyContext(ctx, data)
+
+	expected := []string{
+		"leptjson.parse json.bytes=17 json.depth=3",
+		"leptjson.parse json.bytes=5 json.error_code=expect_value error",
+		"leptjson.validate json.error_count=1 json.valid=false",
+		"leptjson.patch json.operations=1 error",
+	}
+	var got []string
+	for _, span := range tracer.spans {
+		got = append(got, span.String())
+		if span.parent != "request" {
+			t.Errorf("%s 的父 span = %q", span.name, span.parent)
+		}
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("spans:\n%s\n期望:\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+
+	SetTracer(nil)
+	ParseContext(ctx, v, `1`)
+	if len(tracer.spans) != 4 {
+		t.Errorf("关闭追踪后不应当再创建 span")
+	}
+}
+
+func TestServerTracing(t *testing.T) {
+	tracer := &recordingTracer{}
+	SetTracer(tracer)
+	defer SetTracer(nil)
+
+	s := NewServer(DefaultServerOptions())
+	serverRequest(t, s, http.MethodPost, "/validate", `{"schema":{"type":"string"},"data":1}`)
+	serverRequest(t, s, http.MethodPost, "/patch", `{"patch":[{"op":"add","path":"/a","value":1}],"document":{}}`)
+
+	var names []string
+	for _, span := range tracer.spans {
+		names = append(names, span.String())
+	}
+	expected := "leptjson.parse json.bytes=37 json.depth=2|leptjson.validate json.error_count=1 json.valid=false|" +
+		"leptjson.parse json.bytes=60 json.depth=3|leptjson.patch json.operations=1"
+	if strings.Join(names, "|") != expected {
+		t.Errorf("spans = %s", strings.Join(names, "|"))
+	}
+}
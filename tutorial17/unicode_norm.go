@@ -0,0 +1,267 @@
+// unicode_norm.go - 字符串的 Unicode 规范化（NFC/NFD）和规范等价比较
+package leptjson
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+//go:generate go run ./internal/gennorm -ucd=$UCD_DIR -version=14.0.0 -o unicode_norm_tables.go
+
+// NormalizationForm 是字符串的 Unicode 规范化形式
+//
+// 同一个字符可以有组合形式（"é" 即 U+00E9）和分解形式（"e" + U+0301），
+// 二者规范等价但字节不同。混用两种形式的文档在比较时会报告不存在的差异。
+type NormalizationForm int
+
+const (
+	NORMALIZE_NONE NormalizationForm = iota // 不规范化（默认）
+	NORMALIZE_NFC                           // 规范分解后再规范组合，最常用的存储形式
+	NORMALIZE_NFD                           // 规范分解，组合字符按组合类排序
+)
+
+// String 返回规范化形式的名称
+func (f NormalizationForm) String() string {
+	switch f {
+	case NORMALIZE_NONE:
+		return "none"
+	case NORMALIZE_NFC:
+		return "nfc"
+	case NORMALIZE_NFD:
+		return "nfd"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseNormalizationForm 按名称（none、nfc、nfd，不区分大小写）返回规范化形式
+func ParseNormalizationForm(name string) (NormalizationForm, error) {
+	switch strings.ToLower(name) {
+	case "", "none":
+		return NORMALIZE_NONE, nil
+	case "nfc":
+		return NORMALIZE_NFC, nil
+	case "nfd":
+		return NORMALIZE_NFD, nil
+	default:
+		return NORMALIZE_NONE, fmt.Errorf("未知的规范化形式: %s（可选值: none, nfc, nfd）", name)
+	}
+}
+
+// normClassRange 是组合类相同的连续码点区间
+type normClassRange struct {
+	lo, hi rune
+	class  uint8
+}
+
+// normDecomposition 是一个字符的规范分解，见 unicode_norm_tables.go
+type normDecomposition struct {
+	r       rune
+	a, b    rune // b 为 0 表示单字符分解
+	compose bool // 是否参与规范组合
+}
+
+// 谚文音节按算法分解和组合，见 Unicode 标准 3.12 节
+const (
+	hangulSBase  = 0xAC00
+	hangulLBase  = 0x1100
+	hangulVBase  = 0x1161
+	hangulTBase  = 0x11A7
+	hangulLCount = 19
+	hangulVCount = 21
+	hangulTCount = 28
+	hangulNCount = hangulVCount * hangulTCount
+	hangulSCount = hangulLCount * hangulNCount
+)
+
+var (
+	normOnce      sync.Once
+	normDecompose map[rune][2]rune
+	normCompose   map[[2]rune]rune
+)
+
+// initNormTables 第一次规范化时由生成的表建立查找表
+func initNormTables() {
+	normDecompose = make(map[rune][2]rune, len(canonicalDecompositions))
+	normCompose = make(map[[2]rune]rune, len(canonicalDecompositions))
+	for _, d := range canonicalDecompositions {
+		normDecompose[d.r] = [2]rune{d.a, d.b}
+		if d.compose {
+			normCompose[[2]rune{d.a, d.b}] = d.r
+		}
+	}
+}
+
+// combiningClass 返回字符的规范组合类，起始字符为 0
+func combiningClass(r rune) uint8 {
+	i := sort.Search(len(canonicalClasses), func(i int) bool { return canonicalClasses[i].hi >= r })
+	if i < len(canonicalClasses) && canonicalClasses[i].lo <= r {
+		return canonicalClasses[i].class
+	}
+	return 0
+}
+
+// NormalizeString 把 s 转换为指定的规范化形式，无效的 UTF-8 字节被替换为 U+FFFD
+func NormalizeString(s string, form NormalizationForm) string {
+	// U+00C0 之前没有可分解的字符，U+0300 之前没有组合字符
+	limit := rune(utf8.RuneSelf)
+	switch form {
+	case NORMALIZE_NFC:
+		limit = 0x300
+	case NORMALIZE_NFD:
+		limit = 0xC0
+	default:
+		return s
+	}
+	if !needsNormalization(s, limit) {
+		return s
+	}
+	normOnce.Do(initNormTables)
+
+	runes := make([]rune, 0, len(s))
+	for _, r := range s {
+		runes = decomposeRune(runes, r)
+	}
+	reorderMarks(runes)
+	if form == NORMALIZE_NFC {
+		runes = composeRunes(runes)
+	}
+	return string(runes)
+}
+
+// needsNormalization 判断 s 中是否有不小于 limit 的字符或无效的 UTF-8
+func needsNormalization(s string, limit rune) bool {
+	for i := 0; i < len(s); {
+		if s[i] < utf8.RuneSelf {
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r >= limit || r == utf8.RuneError {
+			return true
+		}
+		i += size
+	}
+	return false
+}
+
+// decomposeRune 把 r 的完全规范分解追加到 runes
+func decomposeRune(runes []rune, r rune) []rune {
+	if r >= hangulSBase && r < hangulSBase+hangulSCount {
+		index := r - hangulSBase
+		runes = append(runes, hangulLBase+index/hangulNCount, hangulVBase+index%hangulNCount/hangulTCount)
+		if t := index % hangulTCount; t != 0 {
+			runes = append(runes, hangulTBase+t)
+		}
+		return runes
+	}
+	d, ok := normDecompose[r]
+	if !ok {
+		return append(runes, r)
+	}
+	runes = decomposeRune(runes, d[0])
+	if d[1] != 0 {
+		runes = decomposeRune(runes, d[1])
+	}
+	return runes
+}
+
+// reorderMarks 把每段连续的组合字符按组合类稳定排序（规范排序）
+func reorderMarks(runes []rune) {
+	for i := 1; i < len(runes); i++ {
+		class := combiningClass(runes[i])
+		if class == 0 {
+			continue
+		}
+		for j := i; j > 0; j-- {
+			prev := combiningClass(runes[j-1])
+			if prev <= class {
+				break
+			}
+			runes[j-1], runes[j] = runes[j], runes[j-1]
+		}
+	}
+}
+
+// composeRunes 对规范排序后的字符做规范组合，结果写回 runes 的前部
+func composeRunes(runes []rune) []rune {
+	if len(runes) == 0 {
+		return runes
+	}
+	starter := 0
+	lastClass := int(combiningClass(runes[0]))
+	if lastClass != 0 {
+		lastClass = 256 // 开头不是起始字符，后面的字符不能与它组合
+	}
+	out := 1
+	for _, r := range runes[1:] {
+		class := int(combiningClass(r))
+		// 与起始字符之间没有组合类相同或更高的字符阻隔时才能组合
+		if composite, ok := composePair(runes[starter], r); ok && (lastClass < class || lastClass == 0) {
+			runes[starter] = composite
+			continue
+		}
+		if class == 0 {
+			starter = out
+		}
+		lastClass = class
+		runes[out] = r
+		out++
+	}
+	return runes[:out]
+}
+
+// composePair 返回 a 和 b 的主组合字符
+func composePair(a, b rune) (rune, bool) {
+	if a >= hangulLBase && a < hangulLBase+hangulLCount && b >= hangulVBase && b < hangulVBase+hangulVCount {
+		return hangulSBase + ((a-hangulLBase)*hangulVCount+b-hangulVBase)*hangulTCount, true
+	}
+	if a >= hangulSBase && a < hangulSBase+hangulSCount && (a-hangulSBase)%hangulTCount == 0 &&
+		b > hangulTBase && b < hangulTBase+hangulTCount {
+		return a + b - hangulTBase, true
+	}
+	r, ok := normCompose[[2]rune{a, b}]
+	return r, ok
+}
+
+// NormalizeValue 原地把 v 中所有字符串值和对象键转换为指定的规范化形式
+func NormalizeValue(v *Value, form NormalizationForm) {
+	if v == nil || form == NORMALIZE_NONE {
+		return
+	}
+	switch v.Type {
+	case STRING:
+		// 分块字符串只有在内容改变时才合并
+		s := GetString(v)
+		if n := NormalizeString(s, form); n != s {
+			SetString(v, n)
+		}
+	case ARRAY:
+		for _, e := range v.A {
+			NormalizeValue(e, form)
+		}
+	case OBJECT:
+		for i := range v.O {
+			v.O[i].K = NormalizeString(v.O[i].K, form)
+			NormalizeValue(v.O[i].V, form)
+		}
+	}
+}
+
+// EqualNormalized 与 Equal 相同，但字符串值和对象键按规范等价比较
+//
+// 例如 "\u00e9" 和 "e\u0301" 相等。form 为 NORMALIZE_NONE 时等同于 Equal。
+func EqualNormalized(lhs, rhs *Value, form NormalizationForm) bool {
+	return EqualWithOptions(lhs, rhs, EqualOptions{Normalization: form})
+}
+
+// canonicalEqual 判断两个字符串在 form 下是否规范等价
+func canonicalEqual(a, b string, form NormalizationForm) bool {
+	if a == b || form == NORMALIZE_NONE {
+		return a == b
+	}
+	return NormalizeString(a, form) == NormalizeString(b, form)
+}
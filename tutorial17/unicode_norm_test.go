@@ -0,0 +1,103 @@
+package leptjson
+
+import (
+	"testing"
+)
+
+func TestNormalizeString(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		nfc, nfd string
+	}{
+		{"ASCII", "plain", "plain", "plain"},
+		{"组合形式", "caf\u00e9", "caf\u00e9", "cafe\u0301"},
+		{"分解形式", "cafe\u0301", "caf\u00e9", "cafe\u0301"},
+		{"多层分解", "\u1e69", "\u1e69", "s\u0323\u0307"},
+		{"组合字符重排序", "s\u0307\u0323", "\u1e69", "s\u0323\u0307"},
+		{"被阻隔的组合字符", "a\u0301\u0301", "\u00e1\u0301", "a\u0301\u0301"},
+		{"单字符分解", "\u212b", "\u00c5", "A\u030a"},
+		{"组合排除", "\u0958", "\u0915\u093c", "\u0915\u093c"},
+		{"谚文LV", "\uac00", "\uac00", "\u1100\u1161"},
+		{"谚文LVT", "\ud55c", "\ud55c", "\u1112\u1161\u11ab"},
+		{"谚文LV+T", "\uac00\u11a8", "\uac01", "\u1100\u1161\u11a8"},
+		{"开头是组合字符", "\u0301e", "\u0301e", "\u0301e"},
+		{"中文", "你好", "你好", "你好"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeString(tt.input, NORMALIZE_NFC); got != tt.nfc {
+			t.Errorf("%s: NFC(%+q) = %+q, 期望 %+q", tt.name, tt.input, got, tt.nfc)
+		}
+		if got := NormalizeString(tt.input, NORMALIZE_NFD); got != tt.nfd {
+			t.Errorf("%s: NFD(%+q) = %+q, 期望 %+q", tt.name, tt.input, got, tt.nfd)
+		}
+		if got := NormalizeString(tt.input, NORMALIZE_NONE); got != tt.input {
+			t.Errorf("%s: NONE 改变了字符串: %+q", tt.name, got)
+		}
+	}
+}
+
+func TestParseNormalizationForm(t *testing.T) {
+	for name, want := range map[string]NormalizationForm{"": NORMALIZE_NONE, "none": NORMALIZE_NONE, "NFC": NORMALIZE_NFC, "nfd": NORMALIZE_NFD} {
+		if got, err := ParseNormalizationForm(name); err != nil || got != want {
+			t.Errorf("ParseNormalizationForm(%q) = %v, %v", name, got, err)
+		}
+	}
+	if _, err := ParseNormalizationForm("nfkc"); err == nil {
+		t.Error("不支持的形式应返回错误")
+	}
+}
+
+func TestParseNormalization(t *testing.T) {
+	options := DefaultParseOptions()
+	options.Normalization = NORMALIZE_NFC
+	v := &Value{}
+	if err := ParseWithOptions(v, `{"cafe\u0301":["e\u0301", "x"]}`, options); err != PARSE_OK {
+		t.Fatal(err)
+	}
+	if s, _ := Stringify(v); s != "{\"caf\u00e9\":[\"\u00e9\",\"x\"]}" {
+		t.Errorf("NFC 解析结果 = %+q", s)
+	}
+
+	// 设置规范化后超过分块阈值的字符串也不再分块
+	options.StringChunkThreshold = 4
+	if err := ParseWithOptions(v, "\"e\u0301e\u0301e\u0301\"", options); err != PARSE_OK {
+		t.Fatal(err)
+	}
+	if v.chunks != nil || GetString(v) != "\u00e9\u00e9\u00e9" {
+		t.Errorf("分块阈值下的 NFC 结果 = %+q", GetString(v))
+	}
+	if err := ParseTrusted(v, "\"e\u0301\""); err != PARSE_OK || GetString(v) != "e\u0301" {
+		t.Errorf("默认不规范化: %+q", GetString(v))
+	}
+}
+
+func TestEqualNormalized(t *testing.T) {
+	a, b := &Value{}, &Value{}
+	if err := Parse(a, "{\"caf\u00e9\":\"\u00c5\"}"); err != PARSE_OK {
+		t.Fatal(err)
+	}
+	if err := Parse(b, "{\"cafe\u0301\":\"A\u030a\"}"); err != PARSE_OK {
+		t.Fatal(err)
+	}
+	if Equal(a, b) {
+		t.Error("Equal 不应把不同的字节序列视为相等")
+	}
+	for _, form := range []NormalizationForm{NORMALIZE_NFC, NORMALIZE_NFD} {
+		if !EqualNormalized(a, b, form) {
+			t.Errorf("EqualNormalized(%s) = false", form)
+		}
+		if diffs, err := CompareValuesWithOptions(a, b, CompareOptions{Normalization: form}); err != nil || len(diffs) != 0 {
+			t.Errorf("CompareValuesWithOptions(%s) = %v", form, diffs)
+		}
+	}
+	if diffs := CompareValues(a, b); len(diffs) != 2 {
+		t.Errorf("CompareValues 应报告删除和新增的键: %v", diffs)
+	}
+
+	NormalizeValue(b, NORMALIZE_NFC)
+	if !Equal(a, b) {
+		s, _ := Stringify(b)
+		t.Errorf("NormalizeValue(NFC) = %+q", s)
+	}
+}
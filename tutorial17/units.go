@@ -0,0 +1,233 @@
+// units.go - 带单位的数值：{"value": 5, "unit": "MiB"} 的识别、换算和统一
+package leptjson
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// Quantity 是带单位的数值，在JSON中写作只有 value 和 unit 两个成员的对象，
+// 如 {"value": 5, "unit": "MiB"}
+type Quantity struct {
+	Value float64
+	Unit  string
+}
+
+// ParseQuantity 判断 v 是否是带单位的数值：恰好有数字类型的 value 和字符串类型的 unit 两个成员
+func ParseQuantity(v *Value) (Quantity, bool) {
+	if v == nil || v.Type != OBJECT || len(v.O) != 2 {
+		return Quantity{}, false
+	}
+	value, unit := findObjectKey(v, "value"), findObjectKey(v, "unit")
+	if value == nil || value.Type != NUMBER || unit == nil || unit.Type != STRING {
+		return Quantity{}, false
+	}
+	return Quantity{Value: value.N, Unit: GetString(unit)}, true
+}
+
+// setQuantity 把 v 设置为 {"value": ..., "unit": ...}
+func setQuantity(v *Value, q Quantity) {
+	SetObject(v)
+	SetNumber(SetObjectValue(v, "value"), q.Value)
+	SetString(SetObjectValue(v, "unit"), q.Unit)
+}
+
+// unitDef 是一个单位所属的量纲和换算为该量纲基本单位的系数
+type unitDef struct {
+	dimension string
+	factor    float64
+}
+
+// UnitTable 是单位换算表：每个单位属于一个量纲（如 bytes、duration），
+// 同一量纲的单位之间按系数换算
+type UnitTable struct {
+	units map[string]unitDef
+}
+
+// NewUnitTable 创建空的换算表
+func NewUnitTable() *UnitTable {
+	return &UnitTable{units: make(map[string]unitDef)}
+}
+
+// DefaultUnitTable 返回内置的换算表，每次调用返回新的表，可以继续用 Define 或 Load 扩充：
+//
+//	bytes:     B、kB/KB、MB、GB、TB、PB（1000进制），KiB/Ki、MiB/Mi、GiB/Gi、TiB/Ti、PiB/Pi（1024进制）
+//	duration:  ns、us/µs、ms、s、min、h、d
+//	frequency: Hz、kHz、MHz、GHz
+func DefaultUnitTable() *UnitTable {
+	t := NewUnitTable()
+	for i, prefix := range []string{"k", "M", "G", "T", "P"} {
+		t.units[prefix+"B"] = unitDef{"bytes", math.Pow(1000, float64(i+1))}
+	}
+	t.units["B"] = unitDef{"bytes", 1}
+	t.units["KB"] = t.units["kB"]
+	for i, prefix := range []string{"Ki", "Mi", "Gi", "Ti", "Pi"} {
+		t.units[prefix+"B"] = unitDef{"bytes", math.Pow(1024, float64(i+1))}
+		t.units[prefix] = unitDef{"bytes", math.Pow(1024, float64(i+1))}
+	}
+	for unit, factor := range map[string]float64{"ns": 1e-9, "us": 1e-6, "µs": 1e-6, "ms": 1e-3, "s": 1, "min": 60, "h": 3600, "d": 86400} {
+		t.units[unit] = unitDef{"duration", factor}
+	}
+	for unit, factor := range map[string]float64{"Hz": 1, "kHz": 1e3, "MHz": 1e6, "GHz": 1e9} {
+		t.units[unit] = unitDef{"frequency", factor}
+	}
+	return t
+}
+
+// Define 添加或修改一个单位，factor 是1个该单位等于多少个量纲的基本单位
+//
+// 单位已经属于另一个量纲时返回错误。
+func (t *UnitTable) Define(unit, dimension string, factor float64) error {
+	if unit == "" || dimension == "" {
+		return fmt.Errorf("单位和量纲的名称不能为空")
+	}
+	if !(factor > 0) || math.IsInf(factor, 0) {
+		return fmt.Errorf("单位 %s 的换算系数必须是正数", unit)
+	}
+	if def, ok := t.units[unit]; ok && def.dimension != dimension {
+		return fmt.Errorf("单位 %s 已经属于量纲 %s", unit, def.dimension)
+	}
+	t.units[unit] = unitDef{dimension, factor}
+	return nil
+}
+
+// Load 从JSON对象添加单位，格式为 {"量纲": {"单位": 系数, ...}, ...}，
+// 如 {"bytes": {"block": 4096}, "currency": {"USD": 1, "cent": 0.01}}
+func (t *UnitTable) Load(spec *Value) error {
+	if spec == nil || spec.Type != OBJECT {
+		return fmt.Errorf("单位表必须是JSON对象")
+	}
+	for _, dim := range spec.O {
+		if dim.V.Type != OBJECT {
+			return fmt.Errorf("单位表中量纲 %s 的值必须是对象", dim.K)
+		}
+		for _, unit := range dim.V.O {
+			if unit.V.Type != NUMBER {
+				return fmt.Errorf("单位表中 %s.%s 的换算系数必须是数字", dim.K, unit.K)
+			}
+			if err := t.Define(unit.K, dim.K, unit.V.N); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Dimension 返回单位所属的量纲
+func (t *UnitTable) Dimension(unit string) (string, bool) {
+	def, ok := t.units[unit]
+	return def.dimension, ok
+}
+
+// Units 按字母顺序返回量纲的所有单位
+func (t *UnitTable) Units(dimension string) []string {
+	var units []string
+	for unit, def := range t.units {
+		if def.dimension == dimension {
+			units = append(units, unit)
+		}
+	}
+	sort.Strings(units)
+	return units
+}
+
+// Convert 把数值换算为 unit，两个单位属于不同量纲或未定义时返回错误
+//
+// 结果保留15位有效数字，避免 1 GiB 换算为 MiB 之类的结果带上浮点误差的尾数。
+func (t *UnitTable) Convert(q Quantity, unit string) (Quantity, error) {
+	from, ok := t.units[q.Unit]
+	if !ok {
+		return Quantity{}, fmt.Errorf("未知的单位 %q", q.Unit)
+	}
+	to, ok := t.units[unit]
+	if !ok {
+		return Quantity{}, fmt.Errorf("未知的单位 %q", unit)
+	}
+	if from.dimension != to.dimension {
+		return Quantity{}, fmt.Errorf("不能把 %s（%s）换算为 %s（%s）", q.Unit, from.dimension, unit, to.dimension)
+	}
+	if q.Unit == unit {
+		return q, nil
+	}
+	value, _ := strconv.ParseFloat(strconv.FormatFloat(q.Value*from.factor/to.factor, 'g', 15, 64), 64)
+	return Quantity{Value: value, Unit: unit}, nil
+}
+
+// Compare 比较两个同一量纲的数值，返回 -1、0 或 1
+func (t *UnitTable) Compare(a, b Quantity) (int, error) {
+	converted, err := t.Convert(b, a.Unit)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case a.Value < converted.Value:
+		return -1, nil
+	case a.Value > converted.Value:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// UnitOptions 是 NormalizeUnits 的选项
+type UnitOptions struct {
+	Table   *UnitTable        // 换算表，为 nil 时使用 DefaultUnitTable
+	Targets map[string]string // 量纲 -> 目标单位，如 {"bytes": "MiB"}；没有目标的量纲保持原单位
+}
+
+// NormalizeUnits 把 v 中所有带单位的数值换算为所属量纲的目标单位，返回换算的数量
+//
+// 单位未定义时返回错误，错误中包含数值的路径；出错时 v 可能已经被部分修改。
+func NormalizeUnits(v *Value, options UnitOptions) (int, error) {
+	table := options.Table
+	if table == nil {
+		table = DefaultUnitTable()
+	}
+	for dim, unit := range options.Targets {
+		if d, ok := table.Dimension(unit); !ok || d != dim {
+			return 0, fmt.Errorf("目标单位 %s 不属于量纲 %s", unit, dim)
+		}
+	}
+	count := 0
+	err := walkQuantities(v, "$", func(q Quantity, target *Value, path string) error {
+		dim, ok := table.Dimension(q.Unit)
+		if !ok {
+			return fmt.Errorf("路径 %s: 未知的单位 %q", path, q.Unit)
+		}
+		unit, ok := options.Targets[dim]
+		if !ok || unit == q.Unit {
+			return nil
+		}
+		converted, err := table.Convert(q, unit)
+		if err != nil {
+			return fmt.Errorf("路径 %s: %v", path, err)
+		}
+		setQuantity(target, converted)
+		count++
+		return nil
+	})
+	return count, err
+}
+
+// walkQuantities 对 v 中的每个带单位的数值调用 fn，不进入数值对象的内部
+func walkQuantities(v *Value, path string, fn func(q Quantity, target *Value, path string) error) error {
+	if q, ok := ParseQuantity(v); ok {
+		return fn(q, v, path)
+	}
+	switch v.Type {
+	case OBJECT:
+		for _, m := range v.O {
+			if err := walkQuantities(m.V, path+"."+m.K, fn); err != nil {
+				return err
+			}
+		}
+	case ARRAY:
+		for i, elem := range v.A {
+			if err := walkQuantities(elem, fmt.Sprintf("%s[%d]", path, i), fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package leptjson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseQuantity(t *testing.T) {
+	if q, ok := ParseQuantity(mustParse(t, `{"unit": "MiB", "value": 5}`)); !ok || q != (Quantity{5, "MiB"}) {
+		t.Errorf("ParseQuantity = %v, %v", q, ok)
+	}
+	for _, json := range []string{`5`, `{"value": 5}`, `{"value": "5", "unit": "MiB"}`, `{"value": 5, "unit": "MiB", "note": ""}`} {
+		if _, ok := ParseQuantity(mustParse(t, json)); ok {
+			t.Errorf("%s 不是带单位的数值", json)
+		}
+	}
+}
+
+func TestUnitTableConvert(t *testing.T) {
+	table := DefaultUnitTable()
+	tests := []struct {
+		from     Quantity
+		to       string
+		expected float64
+	}{
+		{Quantity{1, "GiB"}, "MiB", 1024},
+		{Quantity{1, "Gi"}, "MiB", 1024},
+		{Quantity{1, "GB"}, "MB", 1000},
+		{Quantity{1, "KB"}, "B", 1000},
+		{Quantity{512, "MiB"}, "MB", 536.870912},
+		{Quantity{0.1, "GB"}, "MB", 100},
+		{Quantity{90, "min"}, "h", 1.5},
+		{Quantity{250, "µs"}, "ms", 0.25},
+		{Quantity{2.4, "GHz"}, "MHz", 2400},
+	}
+	for _, tt := range tests {
+		got, err := table.Convert(tt.from, tt.to)
+		if err != nil || got.Value != tt.expected || got.Unit != tt.to {
+			t.Errorf("Convert(%v, %s) = %v, %v, 期望 %v", tt.from, tt.to, got, err, tt.expected)
+		}
+	}
+	if _, err := table.Convert(Quantity{1, "GiB"}, "s"); err == nil || !strings.Contains(err.Error(), "bytes") {
+		t.Errorf("不同量纲的换算错误 = %v", err)
+	}
+	if _, err := table.Convert(Quantity{1, "parsec"}, "B"); err == nil {
+		t.Errorf("未知单位应该返回错误")
+	}
+	if cmp, _ := table.Compare(Quantity{1, "GB"}, Quantity{1, "GiB"}); cmp != -1 {
+		t.Errorf("1 GB 应该小于 1 GiB")
+	}
+	if cmp, _ := table.Compare(Quantity{1024, "MiB"}, Quantity{1, "GiB"}); cmp != 0 {
+		t.Errorf("1024 MiB 应该等于 1 GiB")
+	}
+}
+
+func TestUnitTableLoad(t *testing.T) {
+	table := DefaultUnitTable()
+	if err := table.Load(mustParse(t, `{"bytes": {"block": 4096}, "currency": {"USD": 1, "cent": 0.01}}`)); err != nil {
+		t.Fatalf("Load 失败: %v", err)
+	}
+	if q, _ := table.Convert(Quantity{2, "block"}, "KiB"); q.Value != 8 {
+		t.Errorf("2 block = %v KiB", q.Value)
+	}
+	if units := strings.Join(table.Units("currency"), ","); units != "USD,cent" {
+		t.Errorf("Units(currency) = %s", units)
+	}
+	// 内置的表不受影响
+	if _, ok := DefaultUnitTable().Dimension("block"); ok {
+		t.Errorf("Load 不应该修改其他表")
+	}
+
+	for _, spec := range []string{`[]`, `{"bytes": 1}`, `{"bytes": {"x": "1"}}`, `{"bytes": {"x": 0}}`, `{"duration": {"MB": 1}}`, `{"": {"x": 1}}`} {
+		if err := NewUnitTable().Load(mustParse(t, spec)); err == nil && spec != `{"duration": {"MB": 1}}` {
+			t.Errorf("%s 应该返回错误", spec)
+		}
+		if err := DefaultUnitTable().Load(mustParse(t, spec)); err == nil {
+			t.Errorf("%s 应该返回错误", spec)
+		}
+	}
+}
+
+func TestNormalizeUnits(t *testing.T) {
+	doc := mustParse(t, `{"memory": {"value": 2, "unit": "GiB"}, "disks": [{"size": {"value": 500, "unit": "GB"}}],
+		"timeout": {"value": 1500, "unit": "ms"}, "cache": {"value": 64, "unit": "MiB"}, "plain": {"value": 1}}`)
+	n, err := NormalizeUnits(doc, UnitOptions{Targets: map[string]string{"bytes": "MiB", "duration": "s"}})
+	if err != nil || n != 3 {
+		t.Fatalf("NormalizeUnits = %d, %v", n, err)
+	}
+	expected := mustParse(t, `{"memory": {"value": 2048, "unit": "MiB"}, "disks": [{"size": {"value": 476837.158203125, "unit": "MiB"}}],
+		"timeout": {"value": 1.5, "unit": "s"}, "cache": {"value": 64, "unit": "MiB"}, "plain": {"value": 1}}`)
+	if !Equal(doc, expected) {
+		text, _ := Stringify(doc)
+		t.Errorf("NormalizeUnits 的结果 = %s", text)
+	}
+
+	_, err = NormalizeUnits(mustParse(t, `{"a": [{"value": 1, "unit": "parsec"}]}`), UnitOptions{})
+	if err == nil || !strings.Contains(err.Error(), "$.a[0]") {
+		t.Errorf("未知单位的错误 = %v", err)
+	}
+	if _, err := NormalizeUnits(&Value{}, UnitOptions{Targets: map[string]string{"bytes": "ms"}}); err == nil {
+		t.Errorf("目标单位不属于量纲时应该返回错误")
+	}
+}
@@ -0,0 +1,390 @@
+// unmarshal.go - 将JSON反序列化为Go值
+package leptjson
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// UnmarshalOptions 反序列化选项
+type UnmarshalOptions struct {
+	DisallowUnknownFields bool // 结构体中没有对应字段的键视为错误
+	DisallowDuplicateKeys bool // 对象中重复出现的键视为错误
+	DisallowOverflow      bool // 数字超出目标类型的范围，或整数类型收到小数时视为错误
+}
+
+// StrictUnmarshalOptions 返回启用全部检查的严格模式选项
+func StrictUnmarshalOptions() UnmarshalOptions {
+	return UnmarshalOptions{
+		DisallowUnknownFields: true,
+		DisallowDuplicateKeys: true,
+		DisallowOverflow:      true,
+	}
+}
+
+// UnmarshalViolation 表示反序列化过程中发现的一处错误
+type UnmarshalViolation struct {
+	Path    string // 出错位置的JSON指针
+	Message string // 错误描述
+}
+
+// UnmarshalError 汇总了反序列化过程中发现的所有错误
+//
+// 反序列化不会在第一处错误时停止，而是继续处理其余的值，
+// 以便一次性报告所有问题。
+type UnmarshalError struct {
+	Violations []UnmarshalViolation
+}
+
+func (e *UnmarshalError) Error() string {
+	if len(e.Violations) == 1 {
+		return fmt.Sprintf("反序列化失败: %s", e.Violations[0].String())
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "反序列化失败 (共 %d 处错误):", len(e.Violations))
+	for _, violation := range e.Violations {
+		sb.WriteString("\n  ")
+		sb.WriteString(violation.String())
+	}
+	return sb.String()
+}
+
+// String 返回带位置信息的错误描述
+func (v UnmarshalViolation) String() string {
+	path := v.Path
+	if path == "" {
+		path = "/"
+	}
+	return path + ": " + v.Message
+}
+
+// Unmarshal 解析JSON文本并将结果存入 v 指向的Go值，支持 struct tag
+func Unmarshal(data string, v interface{}) error {
+	return UnmarshalWithOptions(data, v, UnmarshalOptions{})
+}
+
+// UnmarshalStrict 以严格模式反序列化，未知的键、重复的键和数字溢出都会报错
+func UnmarshalStrict(data string, v interface{}) error {
+	return UnmarshalWithOptions(data, v, StrictUnmarshalOptions())
+}
+
+// UnmarshalWithOptions 使用自定义选项反序列化JSON文本
+func UnmarshalWithOptions(data string, v interface{}, opts UnmarshalOptions) error {
+	src := &Value{}
+	if err := Parse(src, data); err != PARSE_OK {
+		return err
+	}
+	return UnmarshalValue(src, v, opts)
+}
+
+// UnmarshalValue 将已解析的JSON值存入 v 指向的Go值
+func UnmarshalValue(src *Value, v interface{}, opts UnmarshalOptions) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("Unmarshal 的目标必须是非nil指针，实际为 %T", v)
+	}
+
+	d := &decodeState{opts: opts}
+	d.decode(src, rv.Elem(), "")
+	if len(d.violations) > 0 {
+		return &UnmarshalError{Violations: d.violations}
+	}
+	return nil
+}
+
+// decodeState 保存一次反序列化的选项和收集到的错误
+type decodeState struct {
+	opts       UnmarshalOptions
+	violations []UnmarshalViolation
+}
+
+// addViolation 记录一处错误
+func (d *decodeState) addViolation(path, format string, args ...interface{}) {
+	d.violations = append(d.violations, UnmarshalViolation{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// typeMismatch 记录JSON类型与Go类型不匹配的错误
+func (d *decodeState) typeMismatch(src *Value, t reflect.Type, path string) {
+	d.addViolation(path, "无法将 JSON %s 解码为 Go 类型 %s", valueTypeName(src.Type), t)
+}
+
+// decode 将 src 存入 rv，rv 必须是可设置的
+func (d *decodeState) decode(src *Value, rv reflect.Value, path string) {
+	if src.Type == OBJECT && d.opts.DisallowDuplicateKeys {
+		d.checkDuplicateKeys(src, path)
+	}
+
+	// null 将指针、接口、切片和映射置为nil
+	if src.Type == NULL {
+		switch rv.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+			rv.Set(reflect.Zero(rv.Type()))
+			return
+		}
+	}
+
+	// 实现了 LeptUnmarshaler 的类型自行完成反序列化，包括处理 null
+	if u, ok := asLeptUnmarshaler(rv); ok {
+		if err := u.FromLeptJSON(src); err != nil {
+			d.addViolation(path, "调用 %s 的 FromLeptJSON 失败: %v", rv.Addr().Type(), err)
+		}
+		return
+	}
+
+	// 其他类型遇到 null 时保持不变
+	if src.Type == NULL {
+		return
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		d.decode(src, rv.Elem(), path)
+	case reflect.Interface:
+		if rv.NumMethod() != 0 {
+			d.typeMismatch(src, rv.Type(), path)
+			return
+		}
+		rv.Set(reflect.ValueOf(d.toInterface(src, path)))
+	case reflect.Bool:
+		if src.Type != TRUE && src.Type != FALSE {
+			d.typeMismatch(src, rv.Type(), path)
+			return
+		}
+		rv.SetBool(src.Type == TRUE)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if src.Type != NUMBER {
+			d.typeMismatch(src, rv.Type(), path)
+			return
+		}
+		if d.opts.DisallowOverflow && !fitsInt(src.N, rv) {
+			d.addViolation(path, "数字 %s 超出 Go 类型 %s 的范围", formatViolationNumber(src.N), rv.Type())
+			return
+		}
+		rv.SetInt(int64(src.N))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if src.Type != NUMBER {
+			d.typeMismatch(src, rv.Type(), path)
+			return
+		}
+		if d.opts.DisallowOverflow && !fitsUint(src.N, rv) {
+			d.addViolation(path, "数字 %s 超出 Go 类型 %s 的范围", formatViolationNumber(src.N), rv.Type())
+			return
+		}
+		rv.SetUint(uint64(src.N))
+	case reflect.Float32, reflect.Float64:
+		if src.Type != NUMBER {
+			d.typeMismatch(src, rv.Type(), path)
+			return
+		}
+		if d.opts.DisallowOverflow && rv.OverflowFloat(src.N) {
+			d.addViolation(path, "数字 %s 超出 Go 类型 %s 的范围", formatViolationNumber(src.N), rv.Type())
+			return
+		}
+		rv.SetFloat(src.N)
+	case reflect.String:
+		if src.Type != STRING {
+			d.typeMismatch(src, rv.Type(), path)
+			return
+		}
+		rv.SetString(GetString(src))
+	case reflect.Slice:
+		if src.Type != ARRAY {
+			d.typeMismatch(src, rv.Type(), path)
+			return
+		}
+		slice := reflect.MakeSlice(rv.Type(), len(src.A), len(src.A))
+		for i, elem := range src.A {
+			d.decode(elem, slice.Index(i), path+"/"+strconv.Itoa(i))
+		}
+		rv.Set(slice)
+	case reflect.Array:
+		if src.Type != ARRAY {
+			d.typeMismatch(src, rv.Type(), path)
+			return
+		}
+		for i := 0; i < rv.Len(); i++ {
+			if i < len(src.A) {
+				d.decode(src.A[i], rv.Index(i), path+"/"+strconv.Itoa(i))
+			} else {
+				rv.Index(i).Set(reflect.Zero(rv.Type().Elem()))
+			}
+		}
+	case reflect.Map:
+		d.decodeMap(src, rv, path)
+	case reflect.Struct:
+		d.decodeStruct(src, rv, path)
+	default:
+		d.addViolation(path, "不支持的 Go 类型进行 Unmarshal: %s", rv.Type())
+	}
+}
+
+// decodeMap 将JSON对象存入键为字符串的映射，重复的键以最后一个为准
+func (d *decodeState) decodeMap(src *Value, rv reflect.Value, path string) {
+	if src.Type != OBJECT {
+		d.typeMismatch(src, rv.Type(), path)
+		return
+	}
+	t := rv.Type()
+	if t.Key().Kind() != reflect.String {
+		d.addViolation(path, "只支持 string 类型的 map key 进行 Unmarshal")
+		return
+	}
+	if rv.IsNil() {
+		rv.Set(reflect.MakeMapWithSize(t, len(src.O)))
+	}
+	for _, member := range src.O {
+		elem := reflect.New(t.Elem()).Elem()
+		d.decode(member.V, elem, path+"/"+escapeJSONPointerToken(member.K))
+		rv.SetMapIndex(reflect.ValueOf(member.K).Convert(t.Key()), elem)
+	}
+}
+
+// decodeStruct 将JSON对象存入结构体
+//
+// 键首先按 tag 名称（或字段名）精确匹配，找不到时再忽略大小写匹配；
+// 仍然找不到时存入带有 inline 选项的 map 字段。
+func (d *decodeState) decodeStruct(src *Value, rv reflect.Value, path string) {
+	if src.Type != OBJECT {
+		d.typeMismatch(src, rv.Type(), path)
+		return
+	}
+	fields := cachedTypeFields(rv.Type())
+	inline, hasInline := inlineField(fields)
+	for _, member := range src.O {
+		memberPath := path + "/" + escapeJSONPointerToken(member.K)
+		field, ok := lookupField(fields, member.K)
+		if !ok && hasInline {
+			d.decodeInline(member, rv, inline, memberPath)
+			continue
+		}
+		if !ok {
+			if d.opts.DisallowUnknownFields {
+				d.addViolation(memberPath, "未知的字段 %q（目标类型 %s）", member.K, rv.Type())
+			}
+			continue
+		}
+
+		fv, ok := fieldByIndexAlloc(rv, field.index)
+		if !ok {
+			d.addViolation(memberPath, "无法为字段 %s 所在的非导出嵌入指针分配内存", field.goName)
+			continue
+		}
+		if field.quoted && member.V.Type != NULL {
+			inner, ok := d.unquote(member.V, memberPath)
+			if !ok {
+				continue
+			}
+			d.decode(inner, fv, memberPath)
+			continue
+		}
+		d.decode(member.V, fv, memberPath)
+	}
+}
+
+// decodeInline 将没有对应字段的键存入带有 inline 选项的 map 字段
+func (d *decodeState) decodeInline(member Member, rv reflect.Value, inline fieldInfo, path string) {
+	mv, ok := fieldByIndexAlloc(rv, inline.index)
+	if !ok {
+		return
+	}
+	if mv.IsNil() {
+		mv.Set(reflect.MakeMap(mv.Type()))
+	}
+	elem := reflect.New(mv.Type().Elem()).Elem()
+	d.decode(member.V, elem, path)
+	mv.SetMapIndex(reflect.ValueOf(member.K).Convert(mv.Type().Key()), elem)
+}
+
+// unquote 解析带有 string 选项的字段，其值必须是包含数字或布尔值的JSON字符串
+func (d *decodeState) unquote(src *Value, path string) (*Value, bool) {
+	if src.Type != STRING {
+		d.addViolation(path, "带有 string 选项的字段需要 JSON string，实际为 %s", valueTypeName(src.Type))
+		return nil, false
+	}
+	inner := &Value{}
+	if err := Parse(inner, GetString(src)); err != PARSE_OK ||
+		(inner.Type != NUMBER && inner.Type != TRUE && inner.Type != FALSE) {
+		d.addViolation(path, "无效的 string 选项字段值 %q", GetString(src))
+		return nil, false
+	}
+	return inner, true
+}
+
+// checkDuplicateKeys 检查对象中是否有重复的键
+func (d *decodeState) checkDuplicateKeys(src *Value, path string) {
+	seen := make(map[string]bool, len(src.O))
+	reported := make(map[string]bool)
+	for _, member := range src.O {
+		if seen[member.K] && !reported[member.K] {
+			d.addViolation(path+"/"+escapeJSONPointerToken(member.K), "重复的键 %q", member.K)
+			reported[member.K] = true
+		}
+		seen[member.K] = true
+	}
+}
+
+// toInterface 将JSON值转换为通用的Go值
+//
+// 转换规则与 encoding/json 相同：null => nil，布尔 => bool，数字 => float64，
+// 字符串 => string，数组 => []interface{}，对象 => map[string]interface{}。
+func (d *decodeState) toInterface(src *Value, path string) interface{} {
+	switch src.Type {
+	case TRUE:
+		return true
+	case FALSE:
+		return false
+	case NUMBER:
+		return src.N
+	case STRING:
+		return GetString(src)
+	case ARRAY:
+		arr := make([]interface{}, len(src.A))
+		for i, elem := range src.A {
+			elemPath := path + "/" + strconv.Itoa(i)
+			if elem.Type == OBJECT && d.opts.DisallowDuplicateKeys {
+				d.checkDuplicateKeys(elem, elemPath)
+			}
+			arr[i] = d.toInterface(elem, elemPath)
+		}
+		return arr
+	case OBJECT:
+		obj := make(map[string]interface{}, len(src.O))
+		for _, member := range src.O {
+			memberPath := path + "/" + escapeJSONPointerToken(member.K)
+			if member.V.Type == OBJECT && d.opts.DisallowDuplicateKeys {
+				d.checkDuplicateKeys(member.V, memberPath)
+			}
+			obj[member.K] = d.toInterface(member.V, memberPath)
+		}
+		return obj
+	default:
+		return nil
+	}
+}
+
+// fitsInt 判断数字能否精确地存入有符号整数类型
+func fitsInt(n float64, rv reflect.Value) bool {
+	if n != math.Trunc(n) || n < math.MinInt64 || n >= math.MaxInt64 {
+		return false
+	}
+	return !rv.OverflowInt(int64(n))
+}
+
+// fitsUint 判断数字能否精确地存入无符号整数类型
+func fitsUint(n float64, rv reflect.Value) bool {
+	if n != math.Trunc(n) || n < 0 || n >= math.MaxUint64 {
+		return false
+	}
+	return !rv.OverflowUint(uint64(n))
+}
+
+// formatViolationNumber 格式化错误信息中的数字
+func formatViolationNumber(n float64) string {
+	return strconv.FormatFloat(n, 'g', -1, 64)
+}
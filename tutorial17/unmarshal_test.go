@@ -0,0 +1,157 @@
+package leptjson
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type unmarshalAddress struct {
+	City string `json:"city"`
+	Zip  string `json:"zip,omitempty"`
+}
+
+type unmarshalPerson struct {
+	Name    string                 `json:"name"`
+	Age     int8                   `json:"age"`
+	Score   float64                `json:"score"`
+	Active  bool                   `json:"active"`
+	Tags    []string               `json:"tags"`
+	Address *unmarshalAddress      `json:"address"`
+	Extra   map[string]interface{} `json:"extra"`
+	Ignored string                 `json:"-"`
+	secret  string
+}
+
+func TestUnmarshal(t *testing.T) {
+	var p unmarshalPerson
+	err := Unmarshal(`{
+		"name": "Alice", "AGE": 30, "score": 9.5, "active": true,
+		"tags": ["a", "b"], "address": {"city": "Paris"},
+		"extra": {"n": 1, "list": [null, false]}, "Ignored": "x", "unknown": 1
+	}`, &p)
+	if err != nil {
+		t.Fatalf("Unmarshal 失败: %v", err)
+	}
+
+	expected := unmarshalPerson{
+		Name: "Alice", Age: 30, Score: 9.5, Active: true,
+		Tags:    []string{"a", "b"},
+		Address: &unmarshalAddress{City: "Paris"},
+		Extra:   map[string]interface{}{"n": 1.0, "list": []interface{}{nil, false}},
+	}
+	if !reflect.DeepEqual(p, expected) {
+		t.Errorf("Unmarshal 结果 = %+v, 期望 %+v", p, expected)
+	}
+
+	// null 将指针置为nil
+	if err := Unmarshal(`{"address": null}`, &p); err != nil || p.Address != nil {
+		t.Errorf("null 应当将指针置为nil: %v", err)
+	}
+
+	// 往返序列化
+	s, _ := Marshal(expected)
+	var roundTrip unmarshalPerson
+	if err := Unmarshal(s, &roundTrip); err != nil || !reflect.DeepEqual(roundTrip, expected) {
+		t.Errorf("往返序列化结果不一致: %+v, %v", roundTrip, err)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	var p unmarshalPerson
+	if err := Unmarshal(`{"name":`, &p); err == nil {
+		t.Error("无效的JSON应当返回解析错误")
+	}
+	if err := Unmarshal(`{}`, p); err == nil {
+		t.Error("非指针目标应当返回错误")
+	}
+
+	// 类型错误会全部报告，而不是在第一处停止
+	err := Unmarshal(`{"name": 1, "tags": ["a", 2], "active": "yes"}`, &p)
+	var unmarshalErr *UnmarshalError
+	if !errors.As(err, &unmarshalErr) {
+		t.Fatalf("应当返回 *UnmarshalError, 实际为 %v", err)
+	}
+	paths := make([]string, len(unmarshalErr.Violations))
+	for i, violation := range unmarshalErr.Violations {
+		paths[i] = violation.Path
+	}
+	if got := strings.Join(paths, ","); got != "/name,/tags/1,/active" {
+		t.Errorf("错误位置 = %s", got)
+	}
+	if !strings.Contains(err.Error(), "共 3 处错误") {
+		t.Errorf("错误消息应当包含错误总数: %s", err.Error())
+	}
+}
+
+func TestUnmarshalStrict(t *testing.T) {
+	input := `{"name": "Bob", "age": 300, "nick": "b", "name": "Bobby",
+		"address": {"city": "Rome", "country": "IT"}, "score": 1.5, "extra": {"k": 1, "k": 2, "list": [{"t": 1, "t": 2}]}}`
+
+	// 非严格模式下忽略这些问题
+	var lenient unmarshalPerson
+	if err := Unmarshal(input, &lenient); err != nil {
+		t.Fatalf("非严格模式不应报错: %v", err)
+	}
+	if lenient.Name != "Bobby" {
+		t.Errorf("重复的键应当以最后一个为准, 实际为 %q", lenient.Name)
+	}
+
+	var strict unmarshalPerson
+	err := UnmarshalStrict(input, &strict)
+	var unmarshalErr *UnmarshalError
+	if !errors.As(err, &unmarshalErr) {
+		t.Fatalf("严格模式应当返回 *UnmarshalError, 实际为 %v", err)
+	}
+
+	expected := []string{
+		"/name: 重复的键",
+		"/age: 数字 300 超出 Go 类型 int8 的范围",
+		"/nick: 未知的字段",
+		"/address/country: 未知的字段",
+		"/extra/k: 重复的键",
+		"/extra/list/0/t: 重复的键",
+	}
+	if len(unmarshalErr.Violations) != len(expected) {
+		t.Fatalf("错误数量 = %d, 期望 %d:\n%s", len(unmarshalErr.Violations), len(expected), err.Error())
+	}
+	for i, violation := range unmarshalErr.Violations {
+		if !strings.HasPrefix(violation.String(), expected[i]) {
+			t.Errorf("第 %d 处错误 = %q, 期望以 %q 开头", i, violation.String(), expected[i])
+		}
+	}
+
+	// 数组中的对象同样检查重复的键
+	var iface interface{}
+	err = UnmarshalStrict(`[{"k":1,"k":2}]`, &iface)
+	if !errors.As(err, &unmarshalErr) || len(unmarshalErr.Violations) != 1 ||
+		!strings.HasPrefix(unmarshalErr.Violations[0].String(), "/0/k: 重复的键") {
+		t.Errorf("UnmarshalStrict(数组中的重复键) = %v", err)
+	}
+}
+
+func TestUnmarshalStrictNumbers(t *testing.T) {
+	var target struct {
+		U uint16  `json:"u"`
+		I int     `json:"i"`
+		F float32 `json:"f"`
+	}
+	tests := []struct {
+		input   string
+		wantErr bool
+	}{
+		{`{"u": 65535, "i": -5, "f": 1.5}`, false},
+		{`{"u": -1}`, true},
+		{`{"u": 65536}`, true},
+		{`{"i": 1.5}`, true},
+		{`{"i": 1e300}`, true},
+		{`{"f": 1e40}`, true},
+	}
+	for _, tt := range tests {
+		err := UnmarshalStrict(tt.input, &target)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("UnmarshalStrict(%s) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+		}
+	}
+}
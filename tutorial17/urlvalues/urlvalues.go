@@ -0,0 +1,251 @@
+// Package urlvalues 在URL查询参数/表单与 leptjson.Value 之间转换
+//
+// 键使用方括号表示嵌套，与 PHP、Rails 等框架的约定一致：
+//
+//	user[name]=alice&user[tags][]=a&user[tags][]=b&ids[0]=1&ids[1]=2
+//
+// 转换为
+//
+//	{"ids": ["1", "2"], "user": {"name": "alice", "tags": ["a", "b"]}}
+//
+// 查询参数没有类型信息，所有叶子值都是字符串，可以在之后用 JSON Schema 验证或转换类型。
+package urlvalues
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	leptjson "github.com/Cactusinhand/go-json-tutorial/tutorial17"
+)
+
+// MaxArrayIndex 是方括号中允许的最大数组下标，防止 a[99999999]=1 分配大量内存
+const MaxArrayIndex = 10000
+
+// ToValue 将查询参数转换为对象
+//
+// 同一个键出现多次时（如 tag=a&tag=b）得到字符串数组；以 [] 结尾的键总是追加到数组；
+// 方括号中的非负整数表示数组下标，缺少的元素为 null。键按字典序处理，结果是确定的。
+func ToValue(values url.Values) (*leptjson.Value, error) {
+	root := &leptjson.Value{}
+	leptjson.SetObject(root)
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		segments, err := splitKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if err := insert(root, segments, values[key], key); err != nil {
+			return nil, err
+		}
+	}
+	return root, nil
+}
+
+// ParseQuery 解析查询字符串并转换为对象
+func ParseQuery(query string) (*leptjson.Value, error) {
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return ToValue(values)
+}
+
+// splitKey 将 a[b][0][] 拆分为 ["a", "b", "0", ""]
+func splitKey(key string) ([]string, error) {
+	open := strings.IndexByte(key, '[')
+	if open < 0 {
+		if strings.IndexByte(key, ']') >= 0 {
+			return nil, fmt.Errorf("无效的键 '%s': 方括号不匹配", key)
+		}
+		return []string{key}, nil
+	}
+	if open == 0 {
+		return nil, fmt.Errorf("无效的键 '%s': 缺少名称", key)
+	}
+
+	segments := []string{key[:open]}
+	rest := key[open:]
+	for rest != "" {
+		if rest[0] != '[' {
+			return nil, fmt.Errorf("无效的键 '%s': 方括号之后只能是方括号", key)
+		}
+		end := strings.IndexByte(rest, ']')
+		if end < 0 {
+			return nil, fmt.Errorf("无效的键 '%s': 方括号不匹配", key)
+		}
+		segment := rest[1:end]
+		if strings.IndexByte(segment, '[') >= 0 {
+			return nil, fmt.Errorf("无效的键 '%s': 方括号不匹配", key)
+		}
+		segments = append(segments, segment)
+		rest = rest[end+1:]
+	}
+	return segments, nil
+}
+
+// arrayIndex 判断路径段是否为数组下标，"" 表示追加
+func arrayIndex(segment string) (int, bool) {
+	if segment == "" {
+		return -1, true
+	}
+	for i := 0; i < len(segment); i++ {
+		if segment[i] < '0' || segment[i] > '9' {
+			return 0, false
+		}
+	}
+	n, err := strconv.Atoi(segment)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// insert 将 vals 写入 container 中 segments 指向的位置
+func insert(container *leptjson.Value, segments []string, vals []string, key string) error {
+	segment := segments[0]
+	last := len(segments) == 1
+
+	// 叶子：以 [] 结尾时逐个追加，否则多个值组成数组
+	if last && segment == "" {
+		if container.Type != leptjson.ARRAY {
+			return fmt.Errorf("键 '%s' 与其他参数的结构冲突", key)
+		}
+		for _, val := range vals {
+			leptjson.SetString(leptjson.PushBackArrayElement(container), val)
+		}
+		return nil
+	}
+
+	child, err := childSlot(container, segment, key)
+	if err != nil {
+		return err
+	}
+
+	if last {
+		if child.Type != leptjson.NULL {
+			return fmt.Errorf("键 '%s' 重复或与其他参数的结构冲突", key)
+		}
+		if len(vals) == 1 {
+			leptjson.SetString(child, vals[0])
+			return nil
+		}
+		leptjson.SetArray(child, len(vals))
+		for _, val := range vals {
+			leptjson.SetString(leptjson.PushBackArrayElement(child), val)
+		}
+		return nil
+	}
+
+	// 中间节点：按下一段决定是数组还是对象
+	_, nextIsIndex := arrayIndex(segments[1])
+	switch {
+	case child.Type == leptjson.NULL && nextIsIndex:
+		leptjson.SetArray(child, 0)
+	case child.Type == leptjson.NULL:
+		leptjson.SetObject(child)
+	case nextIsIndex && child.Type != leptjson.ARRAY,
+		!nextIsIndex && child.Type != leptjson.OBJECT:
+		return fmt.Errorf("键 '%s' 与其他参数的结构冲突", key)
+	}
+	return insert(child, segments[1:], vals, key)
+}
+
+// childSlot 返回 container 中 segment 对应的子值，不存在时创建为 null
+func childSlot(container *leptjson.Value, segment string, key string) (*leptjson.Value, error) {
+	if container.Type == leptjson.OBJECT {
+		if child, ok := leptjson.FindObjectKey(container, segment); ok {
+			return child, nil
+		}
+		return leptjson.SetObjectValue(container, segment), nil
+	}
+
+	index, ok := arrayIndex(segment)
+	if !ok {
+		return nil, fmt.Errorf("键 '%s' 与其他参数的结构冲突", key)
+	}
+	if index < 0 {
+		// a[][b]=1：每个值追加一个新元素
+		return leptjson.PushBackArrayElement(container), nil
+	}
+	if index > MaxArrayIndex {
+		return nil, fmt.Errorf("键 '%s' 的数组下标超过最大值%d", key, MaxArrayIndex)
+	}
+	for len(container.A) <= index {
+		leptjson.PushBackArrayElement(container)
+	}
+	return container.A[index], nil
+}
+
+// FromValue 将对象转换为查询参数，是 ToValue 的逆操作
+//
+// 嵌套的对象和数组使用方括号表示，数组总是带下标；数字和布尔值转换为文本，null 转换为空字符串。
+// 空数组和空对象无法用查询参数表示，会被忽略。
+func FromValue(v *leptjson.Value) (url.Values, error) {
+	if v == nil || v.Type != leptjson.OBJECT {
+		return nil, fmt.Errorf("只能转换对象")
+	}
+	values := url.Values{}
+	for _, member := range v.O {
+		if err := flatten(values, "", member.K, member.V); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// Encode 将对象转换为按键排序的查询字符串
+func Encode(v *leptjson.Value) (string, error) {
+	values, err := FromValue(v)
+	if err != nil {
+		return "", err
+	}
+	return values.Encode(), nil
+}
+
+// flatten 将 v 写入 values，prefix 为父级的完整键
+func flatten(values url.Values, prefix string, segment string, v *leptjson.Value) error {
+	if strings.ContainsAny(segment, "[]") {
+		return fmt.Errorf("键 '%s' 包含方括号，无法表示为查询参数", segment)
+	}
+	key := segment
+	if prefix != "" {
+		key = prefix + "[" + segment + "]"
+	} else if segment == "" {
+		return fmt.Errorf("顶层的键不能为空")
+	}
+
+	switch v.Type {
+	case leptjson.OBJECT:
+		for _, member := range v.O {
+			if err := flatten(values, key, member.K, member.V); err != nil {
+				return err
+			}
+		}
+	case leptjson.ARRAY:
+		for i, element := range v.A {
+			if err := flatten(values, key, strconv.Itoa(i), element); err != nil {
+				return err
+			}
+		}
+	case leptjson.STRING:
+		values.Add(key, leptjson.GetString(v))
+	case leptjson.NUMBER:
+		values.Add(key, strconv.FormatFloat(v.N, 'g', -1, 64))
+	case leptjson.TRUE:
+		values.Add(key, "true")
+	case leptjson.FALSE:
+		values.Add(key, "false")
+	default:
+		values.Add(key, "")
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package urlvalues
+
+import (
+	"net/url"
+	"testing"
+
+	leptjson "github.com/Cactusinhand/go-json-tutorial/tutorial17"
+)
+
+func stringify(t *testing.T, v *leptjson.Value) string {
+	t.Helper()
+	s, err := leptjson.Stringify(v)
+	if err != leptjson.STRINGIFY_OK {
+		t.Fatalf("Stringify 失败: %v", err)
+	}
+	return s
+}
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"a=1&b=x", `{"a":"1","b":"x"}`},
+		{"tag=a&tag=b", `{"tag":["a","b"]}`},
+		{"a[b][c]=1&a[b][d]=2", `{"a":{"b":{"c":"1","d":"2"}}}`},
+		{"a[b][0]=1", `{"a":{"b":["1"]}}`},
+		{"ids[]=1&ids[]=2", `{"ids":["1","2"]}`},
+		{"ids[2]=c&ids[0]=a", `{"ids":["a",null,"c"]}`},
+		{"users[0][name]=alice&users[1][name]=bob&users[0][age]=30", `{"users":[{"age":"30","name":"alice"},{"name":"bob"}]}`},
+		{"q=%E4%BD%A0%E5%A5%BD&empty=", `{"empty":"","q":"你好"}`},
+		{"m[a][]=1&m[a][]=2", `{"m":{"a":["1","2"]}}`},
+	}
+	for _, tt := range tests {
+		v, err := ParseQuery(tt.query)
+		if err != nil {
+			t.Errorf("ParseQuery(%q) 失败: %v", tt.query, err)
+			continue
+		}
+		if got := stringify(t, v); got != tt.expected {
+			t.Errorf("ParseQuery(%q) = %s, 期望 %s", tt.query, got, tt.expected)
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []string{
+		"a=1&a[b]=2",
+		"a[b]=1&a[0]=2",
+		"a[b=1",
+		"a]=1",
+		"[a]=1",
+		"a[b]c=1",
+		"a[99999999]=1",
+		"a[x[y]]=1",
+		"a[]=1&a[b]=2",
+	}
+	for _, query := range tests {
+		if v, err := ParseQuery(query); err == nil {
+			t.Errorf("ParseQuery(%q) 应当返回错误, 实际为 %s", query, stringify(t, v))
+		}
+	}
+	if _, err := ParseQuery("a=%zz"); err == nil {
+		t.Error("无效的转义应当返回错误")
+	}
+}
+
+func TestFromValue(t *testing.T) {
+	v := &leptjson.Value{}
+	leptjson.Parse(v, `{"user":{"name":"alice","tags":["a","b"],"age":30,"admin":false,"note":null},"empty":[],"q":"x y"}`)
+
+	values, err := FromValue(v)
+	if err != nil {
+		t.Fatalf("FromValue 失败: %v", err)
+	}
+	expected := url.Values{
+		"user[name]":    {"alice"},
+		"user[tags][0]": {"a"},
+		"user[tags][1]": {"b"},
+		"user[age]":     {"30"},
+		"user[admin]":   {"false"},
+		"user[note]":    {""},
+		"q":             {"x y"},
+	}
+	if values.Encode() != expected.Encode() {
+		t.Errorf("FromValue = %s\n期望 %s", values.Encode(), expected.Encode())
+	}
+
+	encoded, _ := Encode(v)
+	if encoded != expected.Encode() {
+		t.Errorf("Encode = %s", encoded)
+	}
+
+	// 往返：字符串叶子保持不变
+	back, err := ToValue(values)
+	if err != nil {
+		t.Fatalf("ToValue 失败: %v", err)
+	}
+	if got := stringify(t, back); got != `{"q":"x y","user":{"admin":"false","age":"30","name":"alice","note":"","tags":["a","b"]}}` {
+		t.Errorf("往返结果 = %s", got)
+	}
+}
+
+func TestFromValueErrors(t *testing.T) {
+	tests := []string{`[1]`, `"a"`, `{"a[b]":1}`, `{"":1}`, `{"a":{"x]":1}}`}
+	for _, input := range tests {
+		v := &leptjson.Value{}
+		leptjson.Parse(v, input)
+		if _, err := FromValue(v); err == nil {
+			t.Errorf("FromValue(%s) 应当返回错误", input)
+		}
+	}
+	if _, err := FromValue(nil); err == nil {
+		t.Error("FromValue(nil) 应当返回错误")
+	}
+}
@@ -0,0 +1,202 @@
+// validation_messages.go - 验证错误描述的翻译和自定义
+package leptjson
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ValidationTranslator 生成验证错误的描述
+//
+// Translate 根据错误的路径、关键字和参数返回描述，Summary 返回验证失败时的总结。
+// 对于不认识的关键字，Translate 可以返回空字符串，此时使用中文的默认描述。
+type ValidationTranslator interface {
+	Translate(issue ValidationIssue) string
+	Summary(count int) string
+}
+
+// DefaultValidationLanguage 是没有指定语言时使用的翻译器名称
+const DefaultValidationLanguage = "zh"
+
+var (
+	validationTranslatorsMu sync.RWMutex
+	validationTranslators   = map[string]ValidationTranslator{
+		"zh": chineseValidationTranslator{},
+		"en": englishValidationTranslator{},
+	}
+)
+
+// RegisterValidationTranslator 注册名为 lang 的翻译器，已存在时替换原来的翻译器
+//
+// 注册后可以用 LookupValidationTranslator(lang) 取得它，语言名称或翻译器为空时返回错误，如
+//
+//	if err := RegisterValidationTranslator("ja", japaneseTranslator{}); err != nil {
+//		log.Fatal(err)
+//	}
+func RegisterValidationTranslator(lang string, translator ValidationTranslator) error {
+	if lang == "" {
+		return fmt.Errorf("翻译器语言名称不能为空")
+	}
+	if translator == nil {
+		return fmt.Errorf("翻译器不能为空")
+	}
+	validationTranslatorsMu.Lock()
+	defer validationTranslatorsMu.Unlock()
+	validationTranslators[lang] = translator
+	return nil
+}
+
+// LookupValidationTranslator 返回名为 lang 的翻译器
+func LookupValidationTranslator(lang string) (ValidationTranslator, bool) {
+	validationTranslatorsMu.RLock()
+	defer validationTranslatorsMu.RUnlock()
+	translator, ok := validationTranslators[lang]
+	return translator, ok
+}
+
+// ValidationLanguages 返回已注册的翻译器名称，按字典序排列
+func ValidationLanguages() []string {
+	validationTranslatorsMu.RLock()
+	defer validationTranslatorsMu.RUnlock()
+	langs := make([]string, 0, len(validationTranslators))
+	for lang := range validationTranslators {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// issueMessage 返回一条验证错误的描述
+//
+// schema 中的 x-errorMessage 优先：字符串用于该层的所有错误，对象按关键字
+// 指定描述。描述中的 {path} 和 {参数名} 会被替换为对应的值。
+func issueMessage(schema *Value, issue ValidationIssue, translator ValidationTranslator) string {
+	if custom := findObjectKey(schema, "x-errorMessage"); custom != nil {
+		if custom.Type == OBJECT {
+			custom = findObjectKey(custom, issue.Keyword)
+		}
+		if custom != nil && custom.Type == STRING {
+			return expandIssueMessage(GetString(custom), issue)
+		}
+	}
+	if message := translator.Translate(issue); message != "" {
+		return message
+	}
+	return chineseValidationTranslator{}.Translate(issue)
+}
+
+// expandIssueMessage 替换自定义描述中的占位符
+func expandIssueMessage(template string, issue ValidationIssue) string {
+	pairs := []string{"{path}", issue.Path}
+	for name, value := range issue.Params {
+		pairs = append(pairs, "{"+name+"}", formatIssueParam(value))
+	}
+	return strings.NewReplacer(pairs...).Replace(template)
+}
+
+// formatIssueParam 将错误参数格式化为字符串
+func formatIssueParam(value interface{}) string {
+	switch v := value.(type) {
+	case float64:
+		return fmt.Sprintf("%g", v)
+	case []string:
+		return strings.Join(v, ", ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// chineseValidationTranslator 生成中文描述，也是其他翻译器的后备
+type chineseValidationTranslator struct{}
+
+func (chineseValidationTranslator) Translate(issue ValidationIssue) string {
+	p, params := issue.Path, issue.Params
+	switch issue.Keyword {
+	case "type":
+		if expected, ok := params["expected"].(string); ok {
+			return fmt.Sprintf("位于'%s'的值类型为'%s'，而不是预期的'%s'", p, params["actual"], expected)
+		}
+		return fmt.Sprintf("位于'%s'的值类型'%s'不在允许的类型列表中", p, params["actual"])
+	case "minimum":
+		return fmt.Sprintf("位于'%s'的数值%g小于最小值%g", p, params["actual"], params["limit"])
+	case "maximum":
+		return fmt.Sprintf("位于'%s'的数值%g大于最大值%g", p, params["actual"], params["limit"])
+	case "multipleOf":
+		return fmt.Sprintf("位于'%s'的数值%g不是%g的倍数", p, params["actual"], params["limit"])
+	case "minLength":
+		return fmt.Sprintf("位于'%s'的字符串长度%d小于最小长度%d", p, params["actual"], params["limit"])
+	case "maxLength":
+		return fmt.Sprintf("位于'%s'的字符串长度%d大于最大长度%d", p, params["actual"], params["limit"])
+	case "pattern":
+		return fmt.Sprintf("位于'%s'的字符串不匹配正则表达式'%s'", p, params["pattern"])
+	case "format":
+		return fmt.Sprintf("位于'%s'的字符串不是有效的%s", p, formatLabel(fmt.Sprint(params["format"])))
+	case "contentEncoding":
+		return fmt.Sprintf("位于'%s'的字符串不是有效的%s编码", p, params["encoding"])
+	case "contentMediaType":
+		return fmt.Sprintf("位于'%s'的内容不是有效的%s: %s", p, params["mediaType"], params["error"])
+	case "minItems":
+		return fmt.Sprintf("位于'%s'的数组元素数量%d小于最小数量%d", p, params["actual"], params["limit"])
+	case "maxItems":
+		return fmt.Sprintf("位于'%s'的数组元素数量%d大于最大数量%d", p, params["actual"], params["limit"])
+	case "required":
+		return fmt.Sprintf("位于'%s'的对象缺少必需的属性'%s'", p, params["property"])
+	case "$ref":
+		return fmt.Sprintf("位于'%s'的Schema引用无法解析: %s", p, params["error"])
+	}
+	return fmt.Sprintf("位于'%s'的值不满足'%s'", p, issue.Keyword)
+}
+
+func (chineseValidationTranslator) Summary(count int) string {
+	return fmt.Sprintf("发现%d个验证错误", count)
+}
+
+// englishValidationTranslator 生成英文描述
+type englishValidationTranslator struct{}
+
+func (englishValidationTranslator) Translate(issue ValidationIssue) string {
+	p, params := issue.Path, issue.Params
+	switch issue.Keyword {
+	case "type":
+		if expected, ok := params["expected"].(string); ok {
+			return fmt.Sprintf("value at '%s' is of type '%s', expected '%s'", p, params["actual"], expected)
+		}
+		return fmt.Sprintf("value at '%s' is of type '%s', which is not one of the allowed types", p, params["actual"])
+	case "minimum":
+		return fmt.Sprintf("number %g at '%s' is less than the minimum %g", params["actual"], p, params["limit"])
+	case "maximum":
+		return fmt.Sprintf("number %g at '%s' is greater than the maximum %g", params["actual"], p, params["limit"])
+	case "multipleOf":
+		return fmt.Sprintf("number %g at '%s' is not a multiple of %g", params["actual"], p, params["limit"])
+	case "minLength":
+		return fmt.Sprintf("string at '%s' has length %d, less than the minimum length %d", p, params["actual"], params["limit"])
+	case "maxLength":
+		return fmt.Sprintf("string at '%s' has length %d, greater than the maximum length %d", p, params["actual"], params["limit"])
+	case "pattern":
+		return fmt.Sprintf("string at '%s' does not match pattern '%s'", p, params["pattern"])
+	case "format":
+		return fmt.Sprintf("string at '%s' does not match format '%s'", p, params["format"])
+	case "contentEncoding":
+		return fmt.Sprintf("string at '%s' is not valid %s", p, params["encoding"])
+	case "contentMediaType":
+		return fmt.Sprintf("content at '%s' is not valid %s: %s", p, params["mediaType"], params["error"])
+	case "minItems":
+		return fmt.Sprintf("array at '%s' has %d items, fewer than the minimum %d", p, params["actual"], params["limit"])
+	case "maxItems":
+		return fmt.Sprintf("array at '%s' has %d items, more than the maximum %d", p, params["actual"], params["limit"])
+	case "required":
+		return fmt.Sprintf("object at '%s' is missing required property '%s'", p, params["property"])
+	case "$ref":
+		return fmt.Sprintf("schema reference '%s' at '%s' cannot be resolved", params["ref"], p)
+	}
+	return ""
+}
+
+func (englishValidationTranslator) Summary(count int) string {
+	if count == 1 {
+		return "found 1 validation error"
+	}
+	return fmt.Sprintf("found %d validation errors", count)
+}
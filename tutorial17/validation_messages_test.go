@@ -0,0 +1,112 @@
+package leptjson
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestValidationLanguages(t *testing.T) {
+	schema, data := &Value{}, &Value{}
+	Parse(schema, `{"type":"object","required":["id"],"properties":{"age":{"minimum":0},"tags":{"maxItems":1}}}`)
+	Parse(data, `{"age":-1,"tags":[1,2]}`)
+
+	zh := validateWithSchema(schema, data)
+	expected := []string{
+		"位于'$'的对象缺少必需的属性'id'",
+		"位于'$.age'的数值-1小于最小值0",
+		"位于'$.tags'的数组元素数量2大于最大数量1",
+	}
+	if strings.Join(zh.Errors, "\n") != strings.Join(expected, "\n") || zh.Message != "发现3个验证错误" {
+		t.Errorf("中文描述 = %q, %q", zh.Errors, zh.Message)
+	}
+
+	translator, ok := LookupValidationTranslator("en")
+	if !ok {
+		t.Fatal("应该内置 en 翻译器")
+	}
+	en := ValidateWithTranslator(schema, data, translator)
+	expected = []string{
+		"object at '$' is missing required property 'id'",
+		"number -1 at '$.age' is less than the minimum 0",
+		"array at '$.tags' has 2 items, more than the maximum 1",
+	}
+	if strings.Join(en.Errors, "\n") != strings.Join(expected, "\n") || en.Message != "found 3 validation errors" {
+		t.Errorf("英文描述 = %q, %q", en.Errors, en.Message)
+	}
+	if en.Issues[1].Params["limit"] != 0.0 || en.Issues[0].Params["property"] != "id" {
+		t.Errorf("参数 = %v, %v", en.Issues[1].Params, en.Issues[0].Params)
+	}
+}
+
+func TestCustomErrorMessage(t *testing.T) {
+	schema, data := &Value{}, &Value{}
+	Parse(schema, `{
+		"required": ["id", "name"],
+		"x-errorMessage": {"required": "缺少字段 {property}"},
+		"properties": {
+			"age": {"type": "number", "minimum": 0, "x-errorMessage": "{path} 必须是不小于 {limit} 的数字"},
+			"code": {"pattern": "^[A-Z]+$", "x-errorMessage": {"minLength": "太短"}}
+		}
+	}`)
+	Parse(data, `{"name":"a","age":-1,"code":"ab"}`)
+
+	result := validateWithSchema(schema, data)
+	expected := []string{
+		"缺少字段 id",
+		"$.age 必须是不小于 0 的数字",
+		"位于'$.code'的字符串不匹配正则表达式'^[A-Z]+$'",
+	}
+	if strings.Join(result.Errors, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("描述 = %q", result.Errors)
+	}
+}
+
+// shoutTranslator 只翻译 required，其余关键字使用默认描述
+type shoutTranslator struct{}
+
+func (shoutTranslator) Translate(issue ValidationIssue) string {
+	if issue.Keyword == "required" {
+		return fmt.Sprintf("%v IS REQUIRED", issue.Params["property"])
+	}
+	return ""
+}
+
+func (shoutTranslator) Summary(count int) string {
+	return fmt.Sprintf("%d ERRORS", count)
+}
+
+func TestRegisterValidationTranslator(t *testing.T) {
+	if err := RegisterValidationTranslator("", shoutTranslator{}); err == nil {
+		t.Error("RegisterValidationTranslator 应当拒绝空名称")
+	}
+	if err := RegisterValidationTranslator("test-nil", nil); err == nil {
+		t.Error("RegisterValidationTranslator 应当拒绝空的翻译器")
+	}
+	if err := RegisterValidationTranslator("shout", shoutTranslator{}); err != nil {
+		t.Fatal(err)
+	}
+	translator, ok := LookupValidationTranslator("shout")
+	if !ok {
+		t.Fatal("注册的翻译器应该可以查找到")
+	}
+	found := false
+	for _, lang := range ValidationLanguages() {
+		found = found || lang == "shout"
+	}
+	if !found {
+		t.Errorf("ValidationLanguages() = %v", ValidationLanguages())
+	}
+
+	schema, data := &Value{}, &Value{}
+	Parse(schema, `{"required":["id"],"type":"array"}`)
+	Parse(data, `{}`)
+	result := ValidateWithTranslator(schema, data, translator)
+	expected := []string{
+		"位于'$'的值类型为'object'，而不是预期的'array'",
+		"id IS REQUIRED",
+	}
+	if strings.Join(result.Errors, "\n") != strings.Join(expected, "\n") || result.Message != "2 ERRORS" {
+		t.Errorf("描述 = %q, %q", result.Errors, result.Message)
+	}
+}
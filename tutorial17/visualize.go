@@ -0,0 +1,170 @@
+// visualize.go - 将JSON结构渲染为 Graphviz DOT 图
+package leptjson
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// DOTOptions 控制 DOT 图的内容
+type DOTOptions struct {
+	GraphName      string // 图的名称，默认为 json
+	MaxDepth       int    // 最大展开深度，0表示不限制；更深的容器只显示为一个节点
+	MaxChildren    int    // 每个容器最多显示的成员数，0表示不限制；其余成员合并为一个 "…" 节点
+	IncludeScalars bool   // 是否为字符串、数字等标量生成叶子节点
+	MaxLabelLength int    // 标量标签的最大字符数，0表示不截断
+}
+
+// DefaultDOTOptions 返回默认的 DOT 选项
+func DefaultDOTOptions() DOTOptions {
+	return DOTOptions{
+		GraphName:      "json",
+		MaxChildren:    50,
+		IncludeScalars: true,
+		MaxLabelLength: 32,
+	}
+}
+
+// dotWriter 生成 DOT 文本，节点按出现顺序编号
+type dotWriter struct {
+	w       io.Writer
+	options DOTOptions
+	nextID  int
+	err     error
+}
+
+// WriteDOT 将值的结构写为 DOT 图
+//
+// 对象和数组是节点，成员关系是边，边的标签是对象的键或数组下标：
+//
+//	leptjson graph data.json -o data.dot
+//	dot -Tsvg data.dot -o data.svg
+func WriteDOT(w io.Writer, v *Value, options DOTOptions) error {
+	name := options.GraphName
+	if name == "" {
+		name = "json"
+	}
+	d := &dotWriter{w: w, options: options}
+	d.printf("digraph %s {\n", dotQuote(name))
+	d.printf("  rankdir=LR;\n")
+	d.printf("  node [fontname=\"Helvetica\", fontsize=10];\n")
+	d.printf("  edge [fontname=\"Helvetica\", fontsize=9];\n")
+	d.writeNode(v, 0)
+	d.printf("}\n")
+	return d.err
+}
+
+// ToDOT 返回值的 DOT 图文本
+func ToDOT(v *Value, options DOTOptions) string {
+	var sb strings.Builder
+	WriteDOT(&sb, v, options)
+	return sb.String()
+}
+
+func (d *dotWriter) printf(format string, args ...interface{}) {
+	if d.err == nil {
+		_, d.err = fmt.Fprintf(d.w, format, args...)
+	}
+}
+
+// writeNode 写出节点及其子树，返回节点ID
+func (d *dotWriter) writeNode(v *Value, depth int) string {
+	id := "n" + strconv.Itoa(d.nextID)
+	d.nextID++
+
+	switch v.Type {
+	case OBJECT:
+		d.printf("  %s [shape=box, style=rounded, label=%s];\n", id, dotQuote(fmt.Sprintf("{} %d", len(v.O))))
+		if d.options.MaxDepth > 0 && depth >= d.options.MaxDepth {
+			return id
+		}
+		for i, member := range v.O {
+			if d.truncated(id, i, len(v.O)) {
+				break
+			}
+			d.writeEdge(id, member.K, member.V, depth)
+		}
+	case ARRAY:
+		d.printf("  %s [shape=box3d, label=%s];\n", id, dotQuote(fmt.Sprintf("[] %d", len(v.A))))
+		if d.options.MaxDepth > 0 && depth >= d.options.MaxDepth {
+			return id
+		}
+		for i, element := range v.A {
+			if d.truncated(id, i, len(v.A)) {
+				break
+			}
+			d.writeEdge(id, "["+strconv.Itoa(i)+"]", element, depth)
+		}
+	default:
+		d.printf("  %s [shape=plaintext, label=%s];\n", id, dotQuote(d.scalarLabel(v)))
+	}
+	return id
+}
+
+// writeEdge 写出从 parent 到子值的边，不显示标量时跳过标量成员
+func (d *dotWriter) writeEdge(parent string, label string, child *Value, depth int) {
+	if !d.options.IncludeScalars && child.Type != OBJECT && child.Type != ARRAY {
+		return
+	}
+	childID := d.writeNode(child, depth+1)
+	d.printf("  %s -> %s [label=%s];\n", parent, childID, dotQuote(label))
+}
+
+// truncated 在成员数超过 MaxChildren 时写出省略节点并返回 true
+func (d *dotWriter) truncated(parent string, index, total int) bool {
+	if d.options.MaxChildren <= 0 || index < d.options.MaxChildren {
+		return false
+	}
+	id := "n" + strconv.Itoa(d.nextID)
+	d.nextID++
+	d.printf("  %s [shape=plaintext, label=%s];\n", id, dotQuote(fmt.Sprintf("… 另外%d个", total-index)))
+	d.printf("  %s -> %s [style=dashed];\n", parent, id)
+	return true
+}
+
+// scalarLabel 返回标量的标签，过长时截断
+func (d *dotWriter) scalarLabel(v *Value) string {
+	var label string
+	switch v.Type {
+	case STRING:
+		// 只加上引号，转义由 dotQuote 完成
+		label = `"` + GetString(v) + `"`
+	case NUMBER:
+		label = strconv.FormatFloat(v.N, 'g', -1, 64)
+	case TRUE:
+		label = "true"
+	case FALSE:
+		label = "false"
+	default:
+		label = "null"
+	}
+	if max := d.options.MaxLabelLength; max > 0 {
+		if runes := []rune(label); len(runes) > max {
+			label = string(runes[:max]) + "…"
+		}
+	}
+	return label
+}
+
+// dotQuote 将文本转换为 DOT 的双引号字符串
+func dotQuote(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
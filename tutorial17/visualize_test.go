@@ -0,0 +1,78 @@
+package leptjson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToDOT(t *testing.T) {
+	v := &Value{}
+	Parse(v, `{"name":"a \"quoted\" C:\\dir","tags":["x",1],"meta":{"ok":true,"none":null}}`)
+
+	got := ToDOT(v, DefaultDOTOptions())
+	expected := `digraph "json" {
+  rankdir=LR;
+  node [fontname="Helvetica", fontsize=10];
+  edge [fontname="Helvetica", fontsize=9];
+  n0 [shape=box, style=rounded, label="{} 3"];
+  n1 [shape=plaintext, label="\"a \"quoted\" C:\\dir\""];
+  n0 -> n1 [label="name"];
+  n2 [shape=box3d, label="[] 2"];
+  n3 [shape=plaintext, label="\"x\""];
+  n2 -> n3 [label="[0]"];
+  n4 [shape=plaintext, label="1"];
+  n2 -> n4 [label="[1]"];
+  n0 -> n2 [label="tags"];
+  n5 [shape=box, style=rounded, label="{} 2"];
+  n6 [shape=plaintext, label="true"];
+  n5 -> n6 [label="ok"];
+  n7 [shape=plaintext, label="null"];
+  n5 -> n7 [label="none"];
+  n0 -> n5 [label="meta"];
+}
+`
+	if got != expected {
+		t.Errorf("ToDOT =\n%s\n期望\n%s", got, expected)
+	}
+}
+
+func TestToDOTOptions(t *testing.T) {
+	v := &Value{}
+	Parse(v, `{"items":[{"id":1},{"id":2},{"id":3}],"deep":{"a":{"b":{}}},"long":"abcdefghij"}`)
+
+	options := DOTOptions{GraphName: "g", MaxChildren: 2, MaxDepth: 2, MaxLabelLength: 4}
+	got := ToDOT(v, options)
+	for _, want := range []string{
+		`digraph "g" {`,
+		`label="… 另外1个"`,
+		`[style=dashed]`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("输出缺少 %s:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, `"id"`) || strings.Contains(got, `"long"`) {
+		t.Errorf("不显示标量时不应当出现标量成员:\n%s", got)
+	}
+	// deep -> a 之后达到最大深度，b 不再展开
+	if strings.Contains(got, `label="b"`) {
+		t.Errorf("超过最大深度的容器不应当展开:\n%s", got)
+	}
+
+	options.IncludeScalars = true
+	options.MaxChildren = 0
+	got = ToDOT(v, options)
+	if !strings.Contains(got, `label="\"abc…"`) {
+		t.Errorf("过长的标签应当被截断:\n%s", got)
+	}
+	if strings.Contains(got, "另外") {
+		t.Errorf("MaxChildren 为0时不应当省略成员:\n%s", got)
+	}
+}
+
+func TestToDOTChunkedString(t *testing.T) {
+	got := ToDOT(chunkedValue(t, `["hello"]`), DefaultDOTOptions())
+	if !strings.Contains(got, `label="\"hello\""`) {
+		t.Errorf("分块保存的字符串标签不正确:\n%s", got)
+	}
+}
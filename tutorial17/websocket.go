@@ -0,0 +1,287 @@
+// websocket.go - 服务端 WebSocket（RFC 6455）的最小实现，供 /ws 端点使用
+package leptjson
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// websocketGUID 是握手时计算 Sec-WebSocket-Accept 用的固定字符串
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocket 帧的操作码
+const (
+	wsContinuation = 0x0
+	wsText         = 0x1
+	wsBinary       = 0x2
+	wsClose        = 0x8
+	wsPing         = 0x9
+	wsPong         = 0xA
+)
+
+// WebSocket 关闭帧的状态码
+const (
+	wsCloseNormal        = 1000
+	wsCloseProtocolError = 1002
+	wsCloseTooBig        = 1009
+)
+
+// wsWriteTimeout 是写入一帧的最长时间，避免慢客户端阻塞服务
+const wsWriteTimeout = 10 * time.Second
+
+// wsMaxMessage 是没有指定大小限制时消息的最大字节数，帧头中的长度不可信，不能按它直接分配内存
+const wsMaxMessage = 64 << 20
+
+// wsConn 是一个已经完成握手的 WebSocket 连接
+//
+// 同一时间只能有一个goroutine读取，写入可以并发。
+type wsConn struct {
+	conn       net.Conn
+	reader     *bufio.Reader
+	maxMessage int64 // 消息的最大字节数
+
+	writeMu   sync.Mutex
+	writer    *bufio.Writer
+	closeSent bool
+}
+
+// websocketAccept 计算握手响应中的 Sec-WebSocket-Accept
+func websocketAccept(key string) string {
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// headerHasToken 判断逗号分隔的请求头中是否包含 token（不区分大小写）
+func headerHasToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// websocketOriginAllowed 检查握手请求的 Origin，防止跨站 WebSocket 劫持
+//
+// 没有 Origin 的请求不是浏览器发起的，允许连接；否则 Origin 的主机必须与请求的
+// Host 相同，或者出现在 allowed 中（不区分大小写），allowed 中的 "*" 允许任何来源。
+func websocketOriginAllowed(r *http.Request, allowed []string) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, a := range allowed {
+		if a == "*" || strings.EqualFold(strings.TrimSuffix(a, "/"), origin) {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host != "" && strings.EqualFold(u.Host, r.Host)
+}
+
+// upgradeWebSocket 完成 WebSocket 握手并接管连接，失败时已经写入错误响应
+//
+// maxMessage 为0时消息的大小限制为 wsMaxMessage。
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request, maxMessage int64) (*wsConn, error) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeServerError(w, http.StatusMethodNotAllowed, "只支持GET请求")
+		return nil, errors.New("不是GET请求")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !headerHasToken(r.Header, "Connection", "upgrade") || !headerHasToken(r.Header, "Upgrade", "websocket") || key == "" {
+		writeServerError(w, http.StatusBadRequest, "需要WebSocket握手请求")
+		return nil, errors.New("不是WebSocket握手请求")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		writeServerError(w, http.StatusUpgradeRequired, "只支持WebSocket版本13")
+		return nil, errors.New("不支持的WebSocket版本")
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		writeServerError(w, http.StatusInternalServerError, "连接不支持WebSocket")
+		return nil, errors.New("ResponseWriter 不支持 Hijack")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	if maxMessage <= 0 {
+		maxMessage = wsMaxMessage
+	}
+	c := &wsConn{conn: conn, reader: rw.Reader, writer: rw.Writer, maxMessage: maxMessage}
+	fmt.Fprintf(c.writer, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n", websocketAccept(key))
+	conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if err := c.writer.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// readMessage 读取一条完整的文本或二进制消息
+//
+// ping 帧自动回复 pong；收到关闭帧时回复关闭帧并返回 io.EOF。
+// 客户端发送的帧没有掩码或消息超过大小限制时，发送关闭帧并返回错误。
+func (c *wsConn) readMessage() (opcode byte, message []byte, err error) {
+	started := false
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+		switch op {
+		case wsPing:
+			if err := c.writeFrame(wsPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case wsPong:
+			continue
+		case wsClose:
+			c.writeClose(wsCloseNormal, "")
+			return 0, nil, io.EOF
+		case wsText, wsBinary:
+			if started {
+				return 0, nil, c.fail(wsCloseProtocolError, "上一条消息尚未结束")
+			}
+			started, opcode, message = true, op, payload
+		case wsContinuation:
+			if !started {
+				return 0, nil, c.fail(wsCloseProtocolError, "没有需要继续的消息")
+			}
+			message = append(message, payload...)
+		default:
+			return 0, nil, c.fail(wsCloseProtocolError, fmt.Sprintf("未知的操作码 %d", op))
+		}
+		if int64(len(message)) > c.maxMessage {
+			return 0, nil, c.fail(wsCloseTooBig, fmt.Sprintf("消息超过大小限制: 最多%d字节", c.maxMessage))
+		}
+		if fin {
+			return opcode, message, nil
+		}
+	}
+}
+
+// readFrame 读取一帧并去掉掩码
+func (c *wsConn) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin = header[0]&0x80 != 0
+	opcode = header[0] & 0x0F
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, c.fail(wsCloseProtocolError, "不支持扩展")
+	}
+	if header[1]&0x80 == 0 {
+		return false, 0, nil, c.fail(wsCloseProtocolError, "客户端发送的帧必须带掩码")
+	}
+
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.reader, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.reader, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if opcode >= wsClose && (length > 125 || !fin) {
+		return false, 0, nil, c.fail(wsCloseProtocolError, "控制帧过长或被分片")
+	}
+	if length > uint64(c.maxMessage) {
+		return false, 0, nil, c.fail(wsCloseTooBig, fmt.Sprintf("消息超过大小限制: 最多%d字节", c.maxMessage))
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.reader, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, opcode, payload, nil
+}
+
+// writeMessage 发送一条文本消息
+func (c *wsConn) writeMessage(message string) error {
+	return c.writeFrame(wsText, []byte(message))
+}
+
+// writeFrame 发送一个不分片、不带掩码的帧
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closeSent {
+		return errors.New("WebSocket 连接已经关闭")
+	}
+	if opcode == wsClose {
+		c.closeSent = true
+	}
+
+	header := []byte{0x80 | opcode, 0}
+	switch n := len(payload); {
+	case n <= 125:
+		header[1] = byte(n)
+	case n <= 0xFFFF:
+		header[1] = 126
+		header = append(header, byte(n>>8), byte(n))
+	default:
+		header[1] = 127
+		var ext [8]byte
+		binary.BigEndian.PutUint64(ext[:], uint64(n))
+		header = append(header, ext[:]...)
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	c.writer.Write(header)
+	c.writer.Write(payload)
+	return c.writer.Flush()
+}
+
+// writeClose 发送关闭帧，已经发送过时什么也不做
+func (c *wsConn) writeClose(code uint16, reason string) {
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, code)
+	payload = append(payload, reason...)
+	if len(payload) > 125 {
+		payload = payload[:125]
+	}
+	c.writeFrame(wsClose, payload)
+}
+
+// fail 发送带状态码的关闭帧，返回描述原因的错误
+func (c *wsConn) fail(code uint16, reason string) error {
+	c.writeClose(code, reason)
+	return errors.New(reason)
+}
+
+// Close 关闭底层连接
+func (c *wsConn) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,208 @@
+// xlsx.go - 把表格写为 Office Open XML 电子表格（.xlsx），单元格保留数字和布尔类型
+package leptjson
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Excel 工作表的容量限制
+const (
+	xlsxMaxRows      = 1048576
+	xlsxMaxColumns   = 16384
+	xlsxMaxCellChars = 32767
+	xlsxMaxSheetName = 31
+)
+
+// xlsxStaticParts 是除工作表以外的固定内容：内容类型、关系、工作簿和样式，
+// 样式 1 是表头使用的粗体
+var xlsxStaticParts = []struct{ name, content string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`</Relationships>`},
+	{"xl/styles.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+		`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+		`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+		`</styleSheet>`},
+}
+
+// xlsxCreate 在压缩包中添加一个部件，修改时间固定，使相同的输入得到相同的文件
+func xlsxCreate(zw *zip.Writer, name string) (io.Writer, error) {
+	return zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+}
+
+// xlsxColumnName 返回第 col 列（从0开始）的列名，如 A、Z、AA
+func xlsxColumnName(col int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name
+}
+
+// validateSheetName 检查 Excel 对工作表名称的限制
+func validateSheetName(name string) error {
+	if name == "" || utf8.RuneCountInString(name) > xlsxMaxSheetName {
+		return fmt.Errorf("工作表名称的长度必须在1到%d个字符之间", xlsxMaxSheetName)
+	}
+	if strings.ContainsAny(name, `[]:*?/\`) || strings.HasPrefix(name, "'") || strings.HasSuffix(name, "'") {
+		return fmt.Errorf("工作表名称 %q 包含不允许的字符", name)
+	}
+	return nil
+}
+
+// WriteXLSX 把表格写为只有一个工作表的 .xlsx 文件
+//
+// header 为空时没有表头行，否则表头以粗体显示并冻结在顶部。rows 中的 nil 和 null
+// 是空单元格，数字和布尔值写为对应类型的单元格，字符串写为文本，对象和数组写为
+// 紧凑的JSON文本。超过 Excel 的行数、列数或单元格文本长度限制时返回错误。
+func WriteXLSX(w io.Writer, sheetName string, header []string, rows [][]*Value) error {
+	if err := validateSheetName(sheetName); err != nil {
+		return err
+	}
+	total := len(rows)
+	if len(header) > 0 {
+		total++
+	}
+	if total > xlsxMaxRows {
+		return fmt.Errorf("共 %d 行，超过了工作表的 %d 行限制", total, xlsxMaxRows)
+	}
+
+	zw := zip.NewWriter(w)
+	for _, part := range xlsxStaticParts {
+		f, err := xlsxCreate(zw, part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return err
+		}
+	}
+	f, err := xlsxCreate(zw, "xl/workbook.xml")
+	if err != nil {
+		return err
+	}
+	var name strings.Builder
+	xml.EscapeText(&name, []byte(sheetName))
+	fmt.Fprintf(f, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">`+
+		`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`, name.String())
+
+	f, err = xlsxCreate(zw, "xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	if err := writeXLSXSheet(f, header, rows); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// writeXLSXSheet 输出工作表的 XML
+func writeXLSXSheet(w io.Writer, header []string, rows [][]*Value) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	if len(header) > 0 {
+		bw.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	}
+	bw.WriteString(`<sheetData>`)
+
+	row := 0
+	if len(header) > 0 {
+		if len(header) > xlsxMaxColumns {
+			return fmt.Errorf("共 %d 列，超过了工作表的 %d 列限制", len(header), xlsxMaxColumns)
+		}
+		row++
+		fmt.Fprintf(bw, `<row r="%d">`, row)
+		for col, h := range header {
+			if err := writeXLSXText(bw, xlsxColumnName(col)+"1", h, ` s="1"`); err != nil {
+				return err
+			}
+		}
+		bw.WriteString(`</row>`)
+	}
+	for _, values := range rows {
+		if len(values) > xlsxMaxColumns {
+			return fmt.Errorf("共 %d 列，超过了工作表的 %d 列限制", len(values), xlsxMaxColumns)
+		}
+		row++
+		fmt.Fprintf(bw, `<row r="%d">`, row)
+		for col, v := range values {
+			if v == nil || v.Type == NULL {
+				continue
+			}
+			ref := xlsxColumnName(col) + strconv.Itoa(row)
+			switch v.Type {
+			case NUMBER:
+				fmt.Fprintf(bw, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(v.N, 'g', -1, 64))
+			case TRUE:
+				fmt.Fprintf(bw, `<c r="%s" t="b"><v>1</v></c>`, ref)
+			case FALSE:
+				fmt.Fprintf(bw, `<c r="%s" t="b"><v>0</v></c>`, ref)
+			case STRING:
+				if err := writeXLSXText(bw, ref, GetString(v), ""); err != nil {
+					return err
+				}
+			default:
+				text, _ := Stringify(v)
+				if err := writeXLSXText(bw, ref, text, ""); err != nil {
+					return err
+				}
+			}
+		}
+		bw.WriteString(`</row>`)
+	}
+	bw.WriteString(`</sheetData></worksheet>`)
+	return bw.Flush()
+}
+
+// writeXLSXText 输出内联字符串单元格，attrs 是附加的属性
+func writeXLSXText(w *bufio.Writer, ref string, text string, attrs string) error {
+	// Excel 按 UTF-16 编码单元计算长度
+	n := 0
+	for _, r := range text {
+		n++
+		if r > 0xFFFF {
+			n++
+		}
+	}
+	if n > xlsxMaxCellChars {
+		return fmt.Errorf("单元格 %s 的文本有 %d 个字符，超过了 %d 个字符的限制", ref, n, xlsxMaxCellChars)
+	}
+	fmt.Fprintf(w, `<c r="%s" t="inlineStr"%s><is><t xml:space="preserve">`, ref, attrs)
+	xml.EscapeText(w, []byte(text))
+	w.WriteString(`</t></is></c>`)
+	return nil
+}
@@ -0,0 +1,176 @@
+package leptjson
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestXLSXColumnName(t *testing.T) {
+	tests := map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA", xlsxMaxColumns - 1: "XFD"}
+	for col, expected := range tests {
+		if got := xlsxColumnName(col); got != expected {
+			t.Errorf("xlsxColumnName(%d) = %s, 期望 %s", col, got, expected)
+		}
+	}
+}
+
+// xlsxCell 是测试中解码的单元格
+type xlsxCell struct {
+	Ref   string `xml:"r,attr"`
+	Type  string `xml:"t,attr"`
+	Style string `xml:"s,attr"`
+	Value string `xml:"v"`
+	Text  string `xml:"is>t"`
+}
+
+// readXLSX 解压 .xlsx 文件，返回每个部件的内容和工作表中的单元格
+func readXLSX(t *testing.T, data []byte) (map[string]string, [][]xlsxCell) {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("不是有效的zip文件: %v", err)
+	}
+	parts := make(map[string]string)
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		parts[f.Name] = string(content)
+	}
+
+	var sheet struct {
+		Rows []struct {
+			Cells []xlsxCell `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal([]byte(parts["xl/worksheets/sheet1.xml"]), &sheet); err != nil {
+		t.Fatalf("解析工作表失败: %v", err)
+	}
+	var rows [][]xlsxCell
+	for _, r := range sheet.Rows {
+		rows = append(rows, r.Cells)
+	}
+	return parts, rows
+}
+
+func TestWriteXLSX(t *testing.T) {
+	row := mustParse(t, `[1.5, "a<b>\n&c", true, false, null, {"k": [1]}, 2e20]`)
+	rows := [][]*Value{append(row.A, nil, row.A[0])}
+	var buf bytes.Buffer
+	if err := WriteXLSX(&buf, "结果 & 数据", []string{"n", "s", "t", "f", "null", "obj", "big", "missing", "last"}, rows); err != nil {
+		t.Fatalf("WriteXLSX 失败: %v", err)
+	}
+	parts, cells := readXLSX(t, buf.Bytes())
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml"} {
+		content, ok := parts[name]
+		if !ok {
+			t.Errorf("缺少部件 %s", name)
+			continue
+		}
+		var doc struct{}
+		if err := xml.Unmarshal([]byte(content), &doc); err != nil {
+			t.Errorf("%s 不是有效的XML: %v", name, err)
+		}
+	}
+	if !strings.Contains(parts["xl/workbook.xml"], `name="结果 &amp; 数据"`) {
+		t.Errorf("工作簿中的工作表名称错误: %s", parts["xl/workbook.xml"])
+	}
+	if !strings.Contains(parts["xl/worksheets/sheet1.xml"], `state="frozen"`) {
+		t.Errorf("表头应该冻结在顶部")
+	}
+
+	if len(cells) != 2 || len(cells[0]) != 9 {
+		t.Fatalf("单元格 = %+v", cells)
+	}
+	if h := cells[0][8]; h.Ref != "I1" || h.Type != "inlineStr" || h.Style != "1" || h.Text != "last" {
+		t.Errorf("表头单元格 = %+v", h)
+	}
+	expected := []xlsxCell{
+		{Ref: "A2", Value: "1.5"},
+		{Ref: "B2", Type: "inlineStr", Text: "a<b>\n&c"},
+		{Ref: "C2", Type: "b", Value: "1"},
+		{Ref: "D2", Type: "b", Value: "0"},
+		{Ref: "F2", Type: "inlineStr", Text: `{"k":[1]}`},
+		{Ref: "G2", Value: "2e+20"},
+		{Ref: "I2", Value: "1.5"},
+	}
+	if len(cells[1]) != len(expected) {
+		t.Fatalf("数据行 = %+v, 期望 %+v", cells[1], expected)
+	}
+	for i, c := range expected {
+		if cells[1][i] != c {
+			t.Errorf("第%d个单元格 = %+v, 期望 %+v", i+1, cells[1][i], c)
+		}
+	}
+}
+
+func TestWriteXLSXLimits(t *testing.T) {
+	for _, name := range []string{"", "a/b", "'quoted'", strings.Repeat("x", 32)} {
+		if err := WriteXLSX(io.Discard, name, nil, nil); err == nil {
+			t.Errorf("工作表名称 %q 应该返回错误", name)
+		}
+	}
+	long := &Value{}
+	SetString(long, strings.Repeat("😀", xlsxMaxCellChars/2+1))
+	if err := WriteXLSX(io.Discard, "s", nil, [][]*Value{{long}}); err == nil || !strings.Contains(err.Error(), "A1") {
+		t.Errorf("超长文本的错误 = %v", err)
+	}
+	if err := WriteXLSX(io.Discard, "s", make([]string, xlsxMaxColumns+1), nil); err == nil {
+		t.Errorf("列数超过限制时应该返回错误")
+	}
+
+	// 没有表头时不冻结首行
+	var buf bytes.Buffer
+	if err := WriteXLSX(&buf, "s", nil, [][]*Value{{mustParse(t, `1`)}}); err != nil {
+		t.Fatalf("WriteXLSX 失败: %v", err)
+	}
+	parts, cells := readXLSX(t, buf.Bytes())
+	if strings.Contains(parts["xl/worksheets/sheet1.xml"], "frozen") || len(cells) != 1 || cells[0][0].Ref != "A1" {
+		t.Errorf("没有表头时的单元格 = %+v", cells)
+	}
+}
+
+func TestResultsTable(t *testing.T) {
+	results := mustParse(t, `[{"b": 1, "a": "x"}, 3, {"c": null, "a": true}]`).A
+	headers, rows := resultsTable(results)
+	if strings.Join(headers, ",") != "a,b,c" || len(rows) != 2 {
+		t.Fatalf("表头 = %v，%d 行", headers, len(rows))
+	}
+	if rows[0][0].S != "x" || rows[0][2] != nil || rows[1][1] != nil || rows[1][2].Type != NULL {
+		t.Errorf("行 = %v", rows)
+	}
+
+	// CSV 和 xlsx 使用相同的表头
+	dir := t.TempDir()
+	if err := saveResultsAsCSV(results, filepath.Join(dir, "r.csv")); err != nil {
+		t.Fatal(err)
+	}
+	f, _ := os.Open(filepath.Join(dir, "r.csv"))
+	records, _ := csv.NewReader(f).ReadAll()
+	f.Close()
+	if len(records) != 3 || strings.Join(records[0], ",") != "a,b,c" || strings.Join(records[2], ",") != "true,,null" {
+		t.Errorf("CSV = %v", records)
+	}
+	if err := saveResultsAsXLSX(results, filepath.Join(dir, "r.xlsx")); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "r.xlsx"))
+	_, cells := readXLSX(t, data)
+	if len(cells) != 3 || cells[0][2].Text != "c" || cells[2][0].Type != "b" {
+		t.Errorf("xlsx 单元格 = %+v", cells)
+	}
+
+	// 没有对象时所有结果组成一行
+	headers, rows = resultsTable(mustParse(t, `[1, "a"]`).A)
+	if headers != nil || len(rows) != 1 || len(rows[0]) != 2 {
+		t.Errorf("没有对象时 = %v, %v", headers, rows)
+	}
+}